@@ -7,6 +7,7 @@ require (
 	github.com/chromedp/cdproto v0.0.0-20200608134039-8a80cdaf865c
 	github.com/chromedp/chromedp v0.5.3
 	github.com/disintegration/imaging v1.6.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/fvbommel/sortorder v1.0.2
 	github.com/go-chi/chi v4.0.2+incompatible
 	github.com/gobuffalo/packr/v2 v2.0.2
@@ -22,6 +23,7 @@ require (
 	github.com/natefinch/pie v0.0.0-20170715172608-9a0d72014007
 	github.com/remeh/sizedwaitgroup v1.0.0
 	github.com/rs/cors v1.6.0
+	github.com/russross/blackfriday/v2 v2.0.1
 	github.com/shurcooL/graphql v0.0.0-20181231061246-d48a9a75455f
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/pflag v1.0.3
@@ -33,6 +35,7 @@ require (
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/image v0.0.0-20190802002840-cff245a6509b
 	golang.org/x/net v0.0.0-20200822124328-c89045814202
+	golang.org/x/text v0.3.2
 	golang.org/x/tools v0.0.0-20200915031644-64986481280e // indirect
 	gopkg.in/yaml.v2 v2.3.0
 )
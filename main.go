@@ -19,6 +19,11 @@ func main() {
 		manager.GetInstance().PostMigrate()
 	}
 
+	// regenerate the files left invalid by tasks interrupted by a shutdown
+	if !database.NeedsMigration() && !database.NeedsRecovery() {
+		manager.GetInstance().CheckGenerated()
+	}
+
 	api.Start()
 	blockForever()
 }
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// activityQueueSize is the number of events which may be waiting to be
+// recorded before further events are dropped.
+const activityQueueSize = 1000
+
+// activityPruneInterval is the minimum time between removals of expired
+// activity entries.
+const activityPruneInterval = time.Hour
+
+type activityEntry struct {
+	event event.Event
+	time  time.Time
+}
+
+var activityQueue = make(chan activityEntry, activityQueueSize)
+
+func init() {
+	event.Subscribe(queueActivity)
+	go recordActivity()
+}
+
+// queueActivity queues the event to be recorded, so that publishers are not
+// blocked by the database.
+func queueActivity(e event.Event) {
+	select {
+	case activityQueue <- activityEntry{event: e, time: time.Now()}:
+	default:
+		logger.Warnf("activity queue is full, not recording %s %s activity", e.Entity, e.Action)
+	}
+}
+
+// recordActivity stores an activity entry for each object changed by the
+// queued events, and removes expired entries.
+func recordActivity() {
+	var lastPruned time.Time
+	for entry := range activityQueue {
+		if err := createActivity(entry); err != nil {
+			logger.Errorf("error recording activity: %s", err.Error())
+		}
+
+		if time.Since(lastPruned) >= activityPruneInterval {
+			lastPruned = time.Now()
+			if err := pruneActivity(); err != nil {
+				logger.Errorf("error removing expired activity: %s", err.Error())
+			}
+		}
+	}
+}
+
+func createActivity(entry activityEntry) error {
+	if database.DB == nil {
+		return nil
+	}
+
+	tx, err := database.DB.BeginTxx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+
+	qb := models.NewActivityQueryBuilder()
+	e := entry.event
+	for _, id := range e.IDs {
+		newActivity := models.Activity{
+			Entity:    string(e.Entity),
+			Action:    string(e.Action),
+			EntityID:  id,
+			Actor:     sql.NullString{String: e.Actor, Valid: e.Actor != ""},
+			CreatedAt: models.SQLiteTimestamp{Timestamp: entry.time},
+		}
+
+		if _, err := qb.Create(newActivity, tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// pruneActivity removes the activity entries older than the configured
+// retention period.
+func pruneActivity() error {
+	days := config.GetActivityRetentionDays()
+	if days <= 0 || database.DB == nil {
+		return nil
+	}
+
+	tx, err := database.DB.BeginTxx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+
+	qb := models.NewActivityQueryBuilder()
+	if err := qb.DestroyCreatedBefore(time.Now().AddDate(0, 0, -days), tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
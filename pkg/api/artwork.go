@@ -0,0 +1,43 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+// serveArtworkURL serves the remote artwork at url. It is fetched and cached
+// if the cache remote artwork setting is enabled, otherwise the client is
+// redirected to it. Returns false if the artwork could not be fetched.
+func serveArtworkURL(w http.ResponseWriter, r *http.Request, url string) bool {
+	if !config.IsCacheRemoteArtwork() {
+		http.Redirect(w, r, url, http.StatusFound)
+		return true
+	}
+
+	path, err := manager.CachedArtwork(url)
+	if err != nil {
+		logger.Warnf("Error fetching artwork %s: %s", url, err.Error())
+		return false
+	}
+
+	http.ServeFile(w, r, path)
+	return true
+}
+
+// getArtworkURLInput returns the artwork URL of the input after validating
+// it. An empty URL is null.
+func getArtworkURLInput(value *string) (sql.NullString, error) {
+	if value == nil || *value == "" {
+		return sql.NullString{}, nil
+	}
+
+	if err := manager.ValidateArtworkURL(*value); err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: *value, Valid: true}, nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxBatchOperations is the maximum number of operations of a batched
+// GraphQL request.
+const maxBatchOperations = 100
+
+// maxBatchBodySize is the maximum size in bytes of the body of a batched
+// GraphQL request. The bodies of other requests are not limited.
+const maxBatchBodySize = 32 << 20
+
+// batchMiddleware handles batched GraphQL requests, whose bodies are arrays
+// of operations. Each operation is passed to next as its own request, in
+// order, and the response is the array of their responses. An operation
+// which fails only has errors in its own response. Handlers which add
+// request state, such as the data loaders, must be wrapped by next, so that
+// each operation has its own state.
+func batchMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		br := bufio.NewReader(r.Body)
+		if !isBatchBody(br) {
+			r.Body = ioutil.NopCloser(br)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, ioutil.NopCloser(br), maxBatchBodySize))
+		if err != nil {
+			writeBatchError(w, http.StatusBadRequest, "error reading request body: "+err.Error())
+			return
+		}
+
+		var operations []json.RawMessage
+		if err := json.Unmarshal(body, &operations); err != nil {
+			writeBatchError(w, http.StatusBadRequest, "invalid batch: "+err.Error())
+			return
+		}
+
+		if len(operations) > maxBatchOperations {
+			writeBatchError(w, http.StatusBadRequest, fmt.Sprintf("batch of %d operations exceeds the maximum of %d", len(operations), maxBatchOperations))
+			return
+		}
+
+		responses := make([]json.RawMessage, len(operations))
+		for i, operation := range operations {
+			responses[i] = serveBatchOperation(next, r, operation)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(responses)
+	})
+}
+
+// isBatchBody returns true if the JSON read by r is an array. Leading
+// whitespace is discarded from r.
+func isBatchBody(r *bufio.Reader) bool {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return false
+		}
+
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			_ = r.UnreadByte()
+			return b == '['
+		}
+	}
+}
+
+// serveBatchOperation passes the operation of a batched request to next and
+// returns its response.
+func serveBatchOperation(next http.Handler, r *http.Request, operation json.RawMessage) json.RawMessage {
+	req := r.Clone(r.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(operation))
+	req.ContentLength = int64(len(operation))
+
+	rw := &batchResponseWriter{header: make(http.Header)}
+	next.ServeHTTP(rw, req)
+
+	if !json.Valid(rw.body.Bytes()) {
+		return batchErrorResponse(strings.TrimSpace(rw.body.String()))
+	}
+
+	return rw.body.Bytes()
+}
+
+// batchResponseWriter buffers the response of an operation of a batched
+// request.
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func batchErrorResponse(message string) json.RawMessage {
+	ret, _ := json.Marshal(map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	})
+	return ret
+}
+
+func writeBatchError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(batchErrorResponse(message))
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/api/loaders"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchTestOperation struct {
+	Query string `json:"query"`
+}
+
+type batchTestResponse struct {
+	Data   string `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// serveBatchTest serves a POST request of the JSON body with handler, and
+// returns the status and body of the response.
+func serveBatchTest(handler http.Handler, body string) (int, string) {
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code, w.Body.String()
+}
+
+// echoHandler responds to each operation with its query as the data, or with
+// a non-JSON error if the query is "fail". The bodies it receives are
+// appended to bodies.
+func echoHandler(bodies *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+
+		var op batchTestOperation
+		if err := json.Unmarshal(body, &op); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if op.Query == "fail" {
+			http.Error(w, "operation failed", http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"data": op.Query})
+	})
+}
+
+func TestBatchMiddlewareSplitsOperations(t *testing.T) {
+	var bodies []string
+	status, body := serveBatchTest(batchMiddleware(echoHandler(&bodies)), ` [{"query":"a"},{"query":"b"},{"query":"c"}]`)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{`{"query":"a"}`, `{"query":"b"}`, `{"query":"c"}`}, bodies)
+
+	var responses []batchTestResponse
+	if assert.NoError(t, json.Unmarshal([]byte(body), &responses)) && assert.Len(t, responses, 3) {
+		for i, want := range []string{"a", "b", "c"} {
+			assert.Equal(t, want, responses[i].Data)
+		}
+	}
+}
+
+func TestBatchMiddlewareSingleOperation(t *testing.T) {
+	var bodies []string
+	status, body := serveBatchTest(batchMiddleware(echoHandler(&bodies)), `{"query":"a"}`)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{`{"query":"a"}`}, bodies)
+	assert.JSONEq(t, `{"data":"a"}`, body)
+}
+
+func TestBatchMiddlewareOperationErrors(t *testing.T) {
+	var bodies []string
+	status, body := serveBatchTest(batchMiddleware(echoHandler(&bodies)), `[{"query":"a"},{"query":"fail"},{"query":"c"}]`)
+
+	assert.Equal(t, http.StatusOK, status)
+
+	var responses []batchTestResponse
+	if assert.NoError(t, json.Unmarshal([]byte(body), &responses)) && assert.Len(t, responses, 3) {
+		assert.Equal(t, "a", responses[0].Data)
+		assert.Empty(t, responses[0].Errors)
+		if assert.Len(t, responses[1].Errors, 1) {
+			assert.Equal(t, "operation failed", responses[1].Errors[0].Message)
+		}
+		assert.Equal(t, "c", responses[2].Data)
+		assert.Empty(t, responses[2].Errors)
+	}
+}
+
+func TestBatchMiddlewareInvalidBatches(t *testing.T) {
+	var bodies []string
+	handler := batchMiddleware(echoHandler(&bodies))
+
+	status, _ := serveBatchTest(handler, `[{"query":"a"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = serveBatchTest(handler, "["+strings.Repeat(`{"query":"a"},`, maxBatchOperations)+`{"query":"a"}]`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = serveBatchTest(handler, "["+strings.Repeat(" ", maxBatchBodySize)+"]")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	assert.Empty(t, bodies)
+}
+
+func TestBatchMiddlewareMutationThenRead(t *testing.T) {
+	const movieID = 1
+	name := "old"
+
+	// reads cache the movie in the loaders of the request, as if it was
+	// fetched, and the mutation renames it
+	handler := batchMiddleware(loaders.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var op batchTestOperation
+		_ = json.Unmarshal(body, &op)
+
+		ldrs := loaders.From(r.Context())
+		if op.Query == "mutation" {
+			name = "new"
+			_ = json.NewEncoder(w).Encode(map[string]string{"data": name})
+			return
+		}
+
+		ldrs.MovieByID.Prime(movieID, &models.Movie{ID: movieID, Name: sql.NullString{String: name, Valid: true}})
+		movie, _ := ldrs.MovieByID.Load(movieID)
+		_ = json.NewEncoder(w).Encode(map[string]string{"data": movie.Name.String})
+	})))
+
+	status, body := serveBatchTest(handler, `[{"query":"read"},{"query":"mutation"},{"query":"read"}]`)
+	assert.Equal(t, http.StatusOK, status)
+
+	var responses []batchTestResponse
+	if assert.NoError(t, json.Unmarshal([]byte(body), &responses)) && assert.Len(t, responses, 3) {
+		assert.Equal(t, "old", responses[0].Data)
+		assert.Equal(t, "new", responses[2].Data)
+	}
+}
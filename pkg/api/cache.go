@@ -0,0 +1,110 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/event"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// aggregateCacheTTL is the maximum time that the results of aggregate queries
+// are cached for. Changes made by scans are only reflected after this time.
+const aggregateCacheTTL = 30 * time.Second
+
+// aggregateCache caches the results of expensive aggregate queries, such as
+// statistics and object counts. It is cleared whenever an object is changed
+// through the API.
+var aggregateCache = newResultCache(aggregateCacheTTL)
+
+func init() {
+	event.Subscribe(func(e event.Event) {
+		aggregateCache.clear()
+	})
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+type resultCache struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+
+	// generation is incremented when the cache is cleared, so that results
+	// queried before the cache was cleared are not stored
+	generation int
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// get returns the cached value for key. If the value is not cached or has
+// expired, it is populated by calling fn. Errors returned by fn are not cached.
+func (c *resultCache) get(key string, fn func() (interface{}, error)) (interface{}, error) {
+	now := time.Now()
+
+	c.mutex.Lock()
+	entry, found := c.entries[key]
+	generation := c.generation
+	c.mutex.Unlock()
+
+	if found && now.Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	if generation == c.generation {
+		c.entries[key] = cacheEntry{
+			value:   value,
+			expires: now.Add(c.ttl),
+		}
+	}
+	c.mutex.Unlock()
+
+	return value, nil
+}
+
+// getInt is a convenience wrapper around get for integer results.
+func (c *resultCache) getInt(key string, fn func() (int, error)) (int, error) {
+	value, err := c.get(key, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return value.(int), nil
+}
+
+// getSceneDateRange is a convenience wrapper around get for scene date range
+// results.
+func (c *resultCache) getSceneDateRange(key string, fn func() (*models.SceneDateRange, error)) (*models.SceneDateRange, error) {
+	value, err := c.get(key, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*models.SceneDateRange), nil
+}
+
+func (c *resultCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+	c.generation++
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/stashapp/stash/pkg/markdown"
 	"github.com/stashapp/stash/pkg/models"
 )
 
@@ -80,6 +81,32 @@ func (t changesetTranslator) nullString(value *string, field string) *sql.NullSt
 	return ret
 }
 
+// markdown returns the sanitized markdown value for the field, stripping
+// any disallowed HTML.
+func (t changesetTranslator) markdown(value *string, field string) *sql.NullString {
+	if value != nil {
+		sanitized := markdown.Sanitize(*value)
+		value = &sanitized
+	}
+
+	return t.nullString(value, field)
+}
+
+// artworkURL returns the validated artwork URL for the field. An empty URL
+// clears the field.
+func (t changesetTranslator) artworkURL(value *string, field string) (*sql.NullString, error) {
+	if !t.hasField(field) {
+		return nil, nil
+	}
+
+	ret, err := getArtworkURLInput(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
 func (t changesetTranslator) sqliteDate(value *string, field string) *models.SQLiteDate {
 	if !t.hasField(field) {
 		return nil
@@ -110,6 +137,16 @@ func (t changesetTranslator) nullInt64(value *int, field string) *sql.NullInt64
 	return ret
 }
 
+// rating returns the rating on the 1-100 scale from the rating100 field, or
+// from the legacy 1-5 rating field if rating100 is not set.
+func (t changesetTranslator) rating(rating *int, rating100 *int) *sql.NullInt64 {
+	if t.hasField("rating100") {
+		return t.nullInt64(rating100, "rating100")
+	}
+
+	return t.nullInt64(getRating100Input(rating, nil), "rating")
+}
+
 func (t changesetTranslator) nullInt64FromString(value *string, field string) *sql.NullInt64 {
 	if !t.hasField(field) {
 		return nil
@@ -125,6 +162,21 @@ func (t changesetTranslator) nullInt64FromString(value *string, field string) *s
 	return ret
 }
 
+func (t changesetTranslator) nullFloat64(value *float64, field string) *sql.NullFloat64 {
+	if !t.hasField(field) {
+		return nil
+	}
+
+	ret := &sql.NullFloat64{}
+
+	if value != nil {
+		ret.Float64 = *value
+		ret.Valid = true
+	}
+
+	return ret
+}
+
 func (t changesetTranslator) nullBool(value *bool, field string) *sql.NullBool {
 	if !t.hasField(field) {
 		return nil
@@ -14,4 +14,5 @@ const (
 	tagKey       key = 6
 	downloadKey  key = 7
 	imageKey     key = 8
+	ContextRole  key = 9
 )
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// getCustomFieldsInput converts a custom fields map from the GraphQL input to
+// field values. Fields with a null value are omitted. Non-string values are
+// stored in their string form.
+func getCustomFieldsInput(input map[string]interface{}) (map[string]string, error) {
+	ret := make(map[string]string)
+	for field, v := range input {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, errors.New("custom field name must not be empty")
+		}
+
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			ret[field] = v
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("custom field %s must not be an object or array", field)
+		default:
+			ret[field] = fmt.Sprint(v)
+		}
+	}
+
+	return ret, nil
+}
+
+func customFieldsToMap(fields map[string]string) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for field, value := range fields {
+		ret[field] = value
+	}
+
+	return ret
+}
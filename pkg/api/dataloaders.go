@@ -0,0 +1,19 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/api/loaders"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// findMovie returns the movie with the given id. The lookup is batched with
+// those of the other resolvers of the request, if the request has loaders.
+func findMovie(ctx context.Context, id int) (*models.Movie, error) {
+	if ldrs := loaders.From(ctx); ldrs != nil {
+		return ldrs.MovieByID.Load(id)
+	}
+
+	qb := models.NewMovieQueryBuilder()
+	return qb.Find(id, nil)
+}
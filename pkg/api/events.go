@@ -0,0 +1,23 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/event"
+)
+
+// publishEvent notifies subscribers that objects were changed by the
+// current user.
+func publishEvent(ctx context.Context, entity event.Entity, action event.Action, ids ...int) {
+	var actor string
+	if userID := getCurrentUserID(ctx); userID != nil {
+		actor = *userID
+	}
+
+	event.Publish(event.Event{
+		Entity: entity,
+		Action: action,
+		IDs:    ids,
+		Actor:  actor,
+	})
+}
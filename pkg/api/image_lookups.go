@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// skipImageLookupsArg is the argument of findStudios which requests that the
+// image tables are not queried when resolving the returned studios. The image
+// paths of performers, movies and tags never query the image tables.
+const skipImageLookupsArg = "skip_image_lookups"
+
+// isSkippingImageLookups returns true if the field being resolved is part of a
+// query which requested that image paths be returned without checking whether
+// the images exist.
+func isSkippingImageLookups(ctx context.Context) bool {
+	for fc := graphql.GetFieldContext(ctx); fc != nil; fc = fc.Parent {
+		if v, ok := fc.Args[skipImageLookupsArg].(*bool); ok && v != nil {
+			return *v
+		}
+	}
+
+	return false
+}
@@ -1,48 +1,142 @@
 package api
 
 import (
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gobuffalo/packr/v2"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
 var performerBox *packr.Box
 var performerBoxMale *packr.Box
 
+// Names of the assets in the assets directory which override the default
+// images. The performer assets are directories of images, of which one is
+// chosen for each performer.
+const (
+	movieAsset         = "movie"
+	studioAsset        = "studio"
+	tagAsset           = "tag"
+	screenshotAsset    = "screenshot"
+	performerAsset     = "performer"
+	performerMaleAsset = "performer_male"
+)
+
+// assetExtensions are the extensions of the asset files, in order of
+// precedence.
+var assetExtensions = []string{".png", ".jpg", ".jpeg", ".webp", ".gif", ".svg"}
+
 func initialiseImages() {
 	performerBox = packr.New("Performer Box", "../../static/performer")
 	performerBoxMale = packr.New("Male Performer Box", "../../static/performer_male")
 }
 
-func getRandomPerformerImage(gender string) ([]byte, error) {
-	var box *packr.Box
+// getAsset returns the contents of the asset with the name in the assets
+// directory, or nil if the directory is not set or has no such asset.
+func getAsset(name string) []byte {
+	dir := config.GetAssetsPath()
+	if dir == "" {
+		return nil
+	}
+
+	for _, ext := range assetExtensions {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name+ext))
+		if err == nil {
+			return data
+		}
+		if !os.IsNotExist(err) {
+			logger.Warnf("error reading asset %s%s: %s", name, ext, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// getAssetDirImages returns the paths of the images in the asset directory
+// with the name, in name order.
+func getAssetDirImages(name string) []string {
+	dir := config.GetAssetsPath()
+	if dir == "" {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("error reading asset directory %s: %s", name, err.Error())
+		}
+		return nil
+	}
+
+	var ret []string
+	for _, f := range files {
+		if !f.IsDir() && utils.StrInclude(assetExtensions, strings.ToLower(filepath.Ext(f.Name()))) {
+			ret = append(ret, filepath.Join(dir, name, f.Name()))
+		}
+	}
+	return ret
+}
+
+func getDefaultMovieImage() []byte {
+	if ret := getAsset(movieAsset); ret != nil {
+		return ret
+	}
+	_, ret, _ := utils.ProcessBase64Image(models.DefaultMovieImage)
+	return ret
+}
+
+func getDefaultStudioImage() []byte {
+	if ret := getAsset(studioAsset); ret != nil {
+		return ret
+	}
+	_, ret, _ := utils.ProcessBase64Image(models.DefaultStudioImage)
+	return ret
+}
+
+func getDefaultTagImage() []byte {
+	if ret := getAsset(tagAsset); ret != nil {
+		return ret
+	}
+	return models.DefaultTagImage
+}
+
+// getPerformerImageSource returns the box of the default performer images of
+// the gender, and the name of the asset directory overriding them.
+func getPerformerImageSource(gender string) (*packr.Box, string) {
 	switch strings.ToUpper(gender) {
-	case "FEMALE":
-		box = performerBox
 	case "MALE":
-		box = performerBoxMale
+		return performerBoxMale, performerMaleAsset
 	default:
-		box = performerBox
+		return performerBox, performerAsset
+	}
+}
 
+func getRandomPerformerImage(gender string) ([]byte, error) {
+	box, asset := getPerformerImageSource(gender)
+	if assetFiles := getAssetDirImages(asset); len(assetFiles) > 0 {
+		index := rand.Intn(len(assetFiles))
+		return ioutil.ReadFile(assetFiles[index])
 	}
+
 	imageFiles := box.List()
 	index := rand.Intn(len(imageFiles))
 	return box.Find(imageFiles[index])
 }
 
 func getRandomPerformerImageUsingName(name, gender string) ([]byte, error) {
-	var box *packr.Box
-	switch strings.ToUpper(gender) {
-	case "FEMALE":
-		box = performerBox
-	case "MALE":
-		box = performerBoxMale
-	default:
-		box = performerBox
-
+	box, asset := getPerformerImageSource(gender)
+	if assetFiles := getAssetDirImages(asset); len(assetFiles) > 0 {
+		index := utils.IntFromString(name) % uint64(len(assetFiles))
+		return ioutil.ReadFile(assetFiles[index])
 	}
+
 	imageFiles := box.List()
 	index := utils.IntFromString(name) % uint64(len(imageFiles))
 	return box.Find(imageFiles[index])
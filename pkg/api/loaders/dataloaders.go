@@ -0,0 +1,66 @@
+//go:generate go run -mod=vendor github.com/vektah/dataloaden MovieLoader int *github.com/stashapp/stash/pkg/models.Movie
+
+// Package loaders batches the lookups of objects by id made while resolving
+// a GraphQL request, so that resolving a field across many objects does not
+// issue a query per object.
+package loaders
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type contextKey struct{}
+
+const (
+	wait     = 1 * time.Millisecond
+	maxBatch = 100
+)
+
+// Loaders holds the data loaders of a request.
+type Loaders struct {
+	MovieByID *MovieLoader
+}
+
+// Middleware adds new data loaders to the context of each request. Loaders
+// cache the objects they load, so they are not added to websocket requests,
+// which last for the lifetime of the subscription.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") == "websocket" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ldrs := &Loaders{
+			MovieByID: NewMovieLoader(MovieLoaderConfig{
+				Wait:     wait,
+				MaxBatch: maxBatch,
+				Fetch:    fetchMovies,
+			}),
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, ldrs)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// From returns the data loaders of the context, or nil if the context has no
+// loaders.
+func From(ctx context.Context) *Loaders {
+	ldrs, _ := ctx.Value(contextKey{}).(*Loaders)
+	return ldrs
+}
+
+func fetchMovies(keys []int) ([]*models.Movie, []error) {
+	qb := models.NewMovieQueryBuilder()
+	ret, err := qb.FindMany(keys)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return ret, nil
+}
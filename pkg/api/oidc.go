@@ -0,0 +1,204 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/oidc"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const oidcLoginEndPoint = "/login/oidc"
+const oidcCallbackEndPoint = "/login/oidc/callback"
+
+const oidcStateKey = "oidcState"
+const oidcNonceKey = "oidcNonce"
+const oidcReturnURLKey = "oidcReturnURL"
+
+// oidcUsernameClaims are the ID token claims used as the username, in order
+// of preference.
+var oidcUsernameClaims = []string{"preferred_username", "email", "sub"}
+
+func getOIDCProvider() (*oidc.Provider, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	return oidc.Discover(config.GetOIDCIssuer(), client)
+}
+
+func getOIDCConfig(r *http.Request) oidc.Config {
+	baseURL, _ := r.Context().Value(BaseURLCtxKey).(string)
+
+	return oidc.Config{
+		ClientID:     config.GetOIDCClientID(),
+		ClientSecret: config.GetOIDCClientSecret(),
+		RedirectURL:  baseURL + oidcCallbackEndPoint,
+		Scopes:       config.GetOIDCScopes(),
+	}
+}
+
+func randomOIDCValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// handleOIDCLogin redirects the user to the identity provider to log in.
+func handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	returnURL := r.URL.Query().Get(returnURLParam)
+	if !config.IsOIDCEnabled() {
+		redirectToLogin(w, returnURL, "")
+		return
+	}
+
+	provider, err := getOIDCProvider()
+	if err != nil {
+		logger.Errorf("error logging in with OpenID Connect: %s", err.Error())
+		redirectToLogin(w, returnURL, "Identity provider is unavailable")
+		return
+	}
+
+	state, err := randomOIDCValue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomOIDCValue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// ignore error - we want a new session regardless
+	session, _ := sessionStore.Get(r, cookieName)
+	session.Values[oidcStateKey] = state
+	session.Values[oidcNonceKey] = nonce
+	session.Values[oidcReturnURLKey] = returnURL
+	if err := session.Save(r, w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, provider.AuthCodeURL(getOIDCConfig(r), state, nonce), http.StatusFound)
+}
+
+// handleOIDCCallback logs in the user returned by the identity provider, with
+// the role of their groups.
+func handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	session, err := sessionStore.Get(r, cookieName)
+	if err != nil {
+		redirectToLogin(w, "", "Login session has expired")
+		return
+	}
+
+	state, _ := session.Values[oidcStateKey].(string)
+	nonce, _ := session.Values[oidcNonceKey].(string)
+	returnURL, _ := session.Values[oidcReturnURLKey].(string)
+	delete(session.Values, oidcStateKey)
+	delete(session.Values, oidcNonceKey)
+	delete(session.Values, oidcReturnURLKey)
+
+	if returnURL == "" {
+		returnURL = "/"
+	}
+
+	if !config.IsOIDCEnabled() {
+		redirectToLogin(w, returnURL, "")
+		return
+	}
+
+	if state == "" || r.URL.Query().Get("state") != state {
+		redirectToLogin(w, returnURL, "Login session has expired")
+		return
+	}
+
+	if errorCode := r.URL.Query().Get("error"); errorCode != "" {
+		logger.Warnf("identity provider returned error %s: %s", errorCode, r.URL.Query().Get("error_description"))
+		redirectToLogin(w, returnURL, "Login was rejected by the identity provider")
+		return
+	}
+
+	username, role, err := getOIDCUser(r, nonce)
+	if err != nil {
+		logger.Errorf("error logging in with OpenID Connect: %s", err.Error())
+		redirectToLogin(w, returnURL, "Login with the identity provider failed")
+		return
+	}
+
+	if role == "" {
+		logger.Warnf("OpenID Connect user %s is not in a group with a role", username)
+		redirectToLogin(w, returnURL, "User is not allowed to log in")
+		return
+	}
+
+	session.Values[userIDKey] = username
+	session.Values[roleKey] = role
+	if err := session.Save(r, w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, returnURL, http.StatusFound)
+}
+
+// getOIDCUser exchanges the authorization code of the request for an ID token,
+// and returns the username and role of the user of the token.
+func getOIDCUser(r *http.Request, nonce string) (string, string, error) {
+	provider, err := getOIDCProvider()
+	if err != nil {
+		return "", "", err
+	}
+
+	c := getOIDCConfig(r)
+	idToken, err := provider.Exchange(c, r.URL.Query().Get("code"))
+	if err != nil {
+		return "", "", err
+	}
+
+	claims, err := provider.VerifyIDToken(c, idToken, nonce)
+	if err != nil {
+		return "", "", err
+	}
+
+	var username string
+	for _, claim := range oidcUsernameClaims {
+		if username = claims.String(claim); username != "" {
+			break
+		}
+	}
+
+	return username, getOIDCRole(claims.Strings(config.GetOIDCGroupsClaim())), nil
+}
+
+// getOIDCRole returns the role of a user in groups. Admin groups take
+// precedence over viewer groups. All users are admins if no groups are
+// configured, and users not in a configured group have no role.
+func getOIDCRole(groups []string) string {
+	adminGroups := config.GetOIDCAdminGroups()
+	viewerGroups := config.GetOIDCViewerGroups()
+	if len(adminGroups) == 0 && len(viewerGroups) == 0 {
+		return roleAdmin
+	}
+
+	for _, g := range groups {
+		if utils.StrInclude(adminGroups, g) {
+			return roleAdmin
+		}
+	}
+
+	for _, g := range groups {
+		if utils.StrInclude(viewerGroups, g) {
+			return roleViewer
+		}
+	}
+
+	return ""
+}
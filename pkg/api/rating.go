@@ -0,0 +1,40 @@
+package api
+
+import (
+	"database/sql"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// getRating100Input returns the rating on the 1-100 scale from the rating100
+// input, or from the legacy 1-5 rating input if rating100 is not set.
+func getRating100Input(rating *int, rating100 *int) *int {
+	if rating100 != nil {
+		return rating100
+	}
+
+	if rating != nil {
+		ret := models.Rating5To100(*rating)
+		return &ret
+	}
+
+	return nil
+}
+
+func resolveRating5(rating sql.NullInt64) *int {
+	if !rating.Valid {
+		return nil
+	}
+
+	ret := models.Rating100To5(int(rating.Int64))
+	return &ret
+}
+
+func resolveRating100(rating sql.NullInt64) *int {
+	if !rating.Valid {
+		return nil
+	}
+
+	ret := int(rating.Int64)
+	return &ret
+}
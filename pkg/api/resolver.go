@@ -6,12 +6,17 @@ import (
 	"strconv"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 )
 
 type Resolver struct{}
 
+func (r *Resolver) Activity() models.ActivityResolver {
+	return &activityResolver{r}
+}
 func (r *Resolver) Gallery() models.GalleryResolver {
 	return &galleryResolver{r}
 }
@@ -30,6 +35,12 @@ func (r *Resolver) Scene() models.SceneResolver {
 func (r *Resolver) Image() models.ImageResolver {
 	return &imageResolver{r}
 }
+func (r *Resolver) SceneCaption() models.SceneCaptionResolver {
+	return &sceneCaptionResolver{r}
+}
+func (r *Resolver) SceneChapter() models.SceneChapterResolver {
+	return &sceneChapterResolver{r}
+}
 func (r *Resolver) SceneMarker() models.SceneMarkerResolver {
 	return &sceneMarkerResolver{r}
 }
@@ -46,6 +57,10 @@ func (r *Resolver) Tag() models.TagResolver {
 	return &tagResolver{r}
 }
 
+func (r *Resolver) ScrapeHistory() models.ScrapeHistoryResolver {
+	return &scrapeHistoryResolver{r}
+}
+
 func (r *Resolver) ScrapedSceneTag() models.ScrapedSceneTagResolver {
 	return &scrapedSceneTagResolver{r}
 }
@@ -66,14 +81,18 @@ type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
 
+type activityResolver struct{ *Resolver }
 type galleryResolver struct{ *Resolver }
 type performerResolver struct{ *Resolver }
 type sceneResolver struct{ *Resolver }
+type sceneCaptionResolver struct{ *Resolver }
+type sceneChapterResolver struct{ *Resolver }
 type sceneMarkerResolver struct{ *Resolver }
 type imageResolver struct{ *Resolver }
 type studioResolver struct{ *Resolver }
 type movieResolver struct{ *Resolver }
 type tagResolver struct{ *Resolver }
+type scrapeHistoryResolver struct{ *Resolver }
 type scrapedSceneTagResolver struct{ *Resolver }
 type scrapedSceneMovieResolver struct{ *Resolver }
 type scrapedScenePerformerResolver struct{ *Resolver }
@@ -115,33 +134,14 @@ func (r *queryResolver) ValidGalleriesForScene(ctx context.Context, scene_id *st
 }
 
 func (r *queryResolver) Stats(ctx context.Context) (*models.StatsResultType, error) {
-	scenesQB := models.NewSceneQueryBuilder()
-	scenesCount, _ := scenesQB.Count()
-	scenesSize, _ := scenesQB.Size()
-	imageQB := models.NewImageQueryBuilder()
-	imageCount, _ := imageQB.Count()
-	imageSize, _ := imageQB.Size()
-	galleryQB := models.NewGalleryQueryBuilder()
-	galleryCount, _ := galleryQB.Count()
-	performersQB := models.NewPerformerQueryBuilder()
-	performersCount, _ := performersQB.Count()
-	studiosQB := models.NewStudioQueryBuilder()
-	studiosCount, _ := studiosQB.Count()
-	moviesQB := models.NewMovieQueryBuilder()
-	moviesCount, _ := moviesQB.Count()
-	tagsQB := models.NewTagQueryBuilder()
-	tagsCount, _ := tagsQB.Count()
-	return &models.StatsResultType{
-		SceneCount:     scenesCount,
-		ScenesSize:     scenesSize,
-		ImageCount:     imageCount,
-		ImagesSize:     imageSize,
-		GalleryCount:   galleryCount,
-		PerformerCount: performersCount,
-		StudioCount:    studiosCount,
-		MovieCount:     moviesCount,
-		TagCount:       tagsCount,
-	}, nil
+	ret, err := aggregateCache.get("stats", func() (interface{}, error) {
+		return getStats()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret.(*models.StatsResultType), nil
 }
 
 func (r *queryResolver) Version(ctx context.Context) (*models.Version, error) {
@@ -169,6 +169,34 @@ func (r *queryResolver) Latestversion(ctx context.Context) (*models.ShortVersion
 	}, err
 }
 
+func (r *queryResolver) SystemStatus(ctx context.Context) (*models.SystemStatus, error) {
+	ret := &models.SystemStatus{
+		Status:            models.SystemStatusEnumOk,
+		DatabasePath:      config.GetDatabasePath(),
+		AppSchema:         int(database.AppSchemaVersion()),
+		IntegrityProblems: database.IntegrityProblems(),
+	}
+
+	if version := int(database.Version()); version != 0 {
+		ret.DatabaseSchema = &version
+	}
+
+	switch {
+	case database.NeedsRecovery():
+		ret.Status = models.SystemStatusEnumNeedsRecovery
+
+		backups, err := database.GoodBackups()
+		if err != nil {
+			return nil, err
+		}
+		ret.Backups = backups
+	case database.NeedsMigration():
+		ret.Status = models.SystemStatusEnumNeedsMigration
+	}
+
+	return ret, nil
+}
+
 // Get scene marker tags which show up under the video.
 func (r *queryResolver) SceneMarkerTags(ctx context.Context, scene_id string) ([]*models.SceneMarkerTag, error) {
 	sceneID, _ := strconv.Atoi(scene_id)
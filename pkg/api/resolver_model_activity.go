@@ -0,0 +1,19 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *activityResolver) Actor(ctx context.Context, obj *models.Activity) (*string, error) {
+	if obj.Actor.Valid {
+		return &obj.Actor.String, nil
+	}
+	return nil, nil
+}
+
+func (r *activityResolver) CreatedAt(ctx context.Context, obj *models.Activity) (*time.Time, error) {
+	return resolveTimestamp(obj.CreatedAt), nil
+}
@@ -3,11 +3,14 @@ package api
 import (
 	"context"
 
-	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+func (r *galleryResolver) UUID(ctx context.Context, obj *models.Gallery) (string, error) {
+	return obj.UUID.String, nil
+}
+
 func (r *galleryResolver) Path(ctx context.Context, obj *models.Gallery) (*string, error) {
 	if obj.Path.Valid {
 		return &obj.Path.String, nil
@@ -29,26 +32,17 @@ func (r *galleryResolver) Images(ctx context.Context, obj *models.Gallery) ([]*m
 }
 
 func (r *galleryResolver) Cover(ctx context.Context, obj *models.Gallery) (*models.Image, error) {
-	qb := models.NewImageQueryBuilder()
-
-	imgs, err := qb.FindByGalleryID(obj.ID)
-	if err != nil {
-		return nil, err
+	coverID := obj.AutoCoverImageID
+	if obj.CoverImageID.Valid {
+		coverID = obj.CoverImageID
 	}
 
-	var ret *models.Image
-	if len(imgs) > 0 {
-		ret = imgs[0]
-	}
-
-	for _, img := range imgs {
-		if image.IsCover(img) {
-			ret = img
-			break
-		}
+	if !coverID.Valid {
+		return nil, nil
 	}
 
-	return ret, nil
+	qb := models.NewImageQueryBuilder()
+	return qb.Find(int(coverID.Int64))
 }
 
 func (r *galleryResolver) Date(ctx context.Context, obj *models.Gallery) (*string, error) {
@@ -74,11 +68,11 @@ func (r *galleryResolver) Details(ctx context.Context, obj *models.Gallery) (*st
 }
 
 func (r *galleryResolver) Rating(ctx context.Context, obj *models.Gallery) (*int, error) {
-	if obj.Rating.Valid {
-		rating := int(obj.Rating.Int64)
-		return &rating, nil
-	}
-	return nil, nil
+	return resolveRating5(obj.Rating), nil
+}
+
+func (r *galleryResolver) Rating100(ctx context.Context, obj *models.Gallery) (*int, error) {
+	return resolveRating100(obj.Rating), nil
 }
 
 func (r *galleryResolver) Scene(ctx context.Context, obj *models.Gallery) (*models.Scene, error) {
@@ -113,3 +107,8 @@ func (r *galleryResolver) ImageCount(ctx context.Context, obj *models.Gallery) (
 	qb := models.NewImageQueryBuilder()
 	return qb.CountByGalleryID(obj.ID)
 }
+
+func (r *galleryResolver) ScrapeHistory(ctx context.Context, obj *models.Gallery) ([]*models.ScrapeHistory, error) {
+	qb := models.NewScrapeHistoryQueryBuilder()
+	return qb.FindByEntity(models.ScrapeHistoryEntityGallery, obj.ID)
+}
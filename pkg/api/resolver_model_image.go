@@ -6,19 +6,32 @@ import (
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
 	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
+func (r *imageResolver) UUID(ctx context.Context, obj *models.Image) (string, error) {
+	return obj.UUID.String, nil
+}
+
+func (r *imageResolver) Phash(ctx context.Context, obj *models.Image) (*string, error) {
+	if obj.Phash.Valid {
+		hexval := utils.PhashToString(obj.Phash.Int64)
+		return &hexval, nil
+	}
+	return nil, nil
+}
+
 func (r *imageResolver) Title(ctx context.Context, obj *models.Image) (*string, error) {
 	ret := image.GetTitle(obj)
 	return &ret, nil
 }
 
 func (r *imageResolver) Rating(ctx context.Context, obj *models.Image) (*int, error) {
-	if obj.Rating.Valid {
-		rating := int(obj.Rating.Int64)
-		return &rating, nil
-	}
-	return nil, nil
+	return resolveRating5(obj.Rating), nil
+}
+
+func (r *imageResolver) Rating100(ctx context.Context, obj *models.Image) (*int, error) {
+	return resolveRating100(obj.Rating), nil
 }
 
 func (r *imageResolver) File(ctx context.Context, obj *models.Image) (*models.ImageFileType, error) {
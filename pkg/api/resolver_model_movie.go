@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
+	"github.com/stashapp/stash/pkg/markdown"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -15,13 +17,39 @@ func (r *movieResolver) Name(ctx context.Context, obj *models.Movie) (string, er
 	return "", nil
 }
 
-func (r *movieResolver) URL(ctx context.Context, obj *models.Movie) (*string, error) {
-	if obj.URL.Valid {
-		return &obj.URL.String, nil
+func (r *movieResolver) Slug(ctx context.Context, obj *models.Movie) (string, error) {
+	return obj.Slug.String, nil
+}
+
+func (r *movieResolver) UUID(ctx context.Context, obj *models.Movie) (string, error) {
+	return obj.UUID.String, nil
+}
+
+func (r *movieResolver) Pinned(ctx context.Context, obj *models.Movie) (bool, error) {
+	return obj.PinnedPosition.Valid, nil
+}
+
+func (r *movieResolver) PinnedPosition(ctx context.Context, obj *models.Movie) (*int, error) {
+	if obj.PinnedPosition.Valid {
+		position := int(obj.PinnedPosition.Int64)
+		return &position, nil
 	}
 	return nil, nil
 }
 
+func (r *movieResolver) URL(ctx context.Context, obj *models.Movie) (*string, error) {
+	urls, err := r.Urls(ctx, obj)
+	if err != nil || len(urls) == 0 {
+		return nil, err
+	}
+	return &urls[0], nil
+}
+
+func (r *movieResolver) Urls(ctx context.Context, obj *models.Movie) ([]string, error) {
+	qb := models.NewMovieQueryBuilder()
+	return qb.GetURLs(obj.ID, nil)
+}
+
 func (r *movieResolver) Aliases(ctx context.Context, obj *models.Movie) (*string, error) {
 	if obj.Aliases.Valid {
 		return &obj.Aliases.String, nil
@@ -46,11 +74,11 @@ func (r *movieResolver) Date(ctx context.Context, obj *models.Movie) (*string, e
 }
 
 func (r *movieResolver) Rating(ctx context.Context, obj *models.Movie) (*int, error) {
-	if obj.Rating.Valid {
-		rating := int(obj.Rating.Int64)
-		return &rating, nil
-	}
-	return nil, nil
+	return resolveRating5(obj.Rating), nil
+}
+
+func (r *movieResolver) Rating100(ctx context.Context, obj *models.Movie) (*int, error) {
+	return resolveRating100(obj.Rating), nil
 }
 
 func (r *movieResolver) Studio(ctx context.Context, obj *models.Movie) (*models.Studio, error) {
@@ -76,6 +104,14 @@ func (r *movieResolver) Synopsis(ctx context.Context, obj *models.Movie) (*strin
 	return nil, nil
 }
 
+func (r *movieResolver) SynopsisHTML(ctx context.Context, obj *models.Movie) (*string, error) {
+	if obj.Synopsis.Valid {
+		result := markdown.Render(obj.Synopsis.String)
+		return &result, nil
+	}
+	return nil, nil
+}
+
 func (r *movieResolver) FrontImagePath(ctx context.Context, obj *models.Movie) (*string, error) {
 	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
 	frontimagePath := urlbuilders.NewMovieURLBuilder(baseURL, obj.ID).GetMovieFrontImageURL()
@@ -88,8 +124,79 @@ func (r *movieResolver) BackImagePath(ctx context.Context, obj *models.Movie) (*
 	return &backimagePath, nil
 }
 
+func (r *movieResolver) CoverURL(ctx context.Context, obj *models.Movie) (*string, error) {
+	if obj.CoverURL.Valid {
+		return &obj.CoverURL.String, nil
+	}
+	return nil, nil
+}
+
+func (r *movieResolver) BackgroundURL(ctx context.Context, obj *models.Movie) (*string, error) {
+	if obj.BackgroundURL.Valid {
+		return &obj.BackgroundURL.String, nil
+	}
+	return nil, nil
+}
+
+func (r *movieResolver) BackgroundImagePath(ctx context.Context, obj *models.Movie) (*string, error) {
+	if !obj.BackgroundURL.Valid {
+		return nil, nil
+	}
+
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+	backgroundImagePath := urlbuilders.NewMovieURLBuilder(baseURL, obj.ID).GetMovieBackgroundImageURL()
+	return &backgroundImagePath, nil
+}
+
+func (r *movieResolver) Scenes(ctx context.Context, obj *models.Movie) ([]*models.MovieScene, error) {
+	joinQB := models.NewJoinsQueryBuilder()
+	qb := models.NewSceneQueryBuilder()
+
+	movieScenes, err := joinQB.GetMovieScenes(obj.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sceneIDs := make([]int, len(movieScenes))
+	for i, ms := range movieScenes {
+		sceneIDs[i] = ms.SceneID
+	}
+
+	scenes, err := qb.FindMany(sceneIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*models.MovieScene{}
+	for i, ms := range movieScenes {
+		movieScene := &models.MovieScene{
+			Scene: scenes[i],
+		}
+
+		if ms.SceneIndex.Valid {
+			idx := int(ms.SceneIndex.Int64)
+			movieScene.SceneIndex = &idx
+		}
+
+		ret = append(ret, movieScene)
+	}
+	return ret, nil
+}
+
+func (r *movieResolver) CustomFields(ctx context.Context, obj *models.Movie) (map[string]interface{}, error) {
+	qb := models.NewMovieQueryBuilder()
+	fields, err := qb.GetCustomFields(obj.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return customFieldsToMap(fields), nil
+}
+
 func (r *movieResolver) SceneCount(ctx context.Context, obj *models.Movie) (*int, error) {
 	qb := models.NewSceneQueryBuilder()
-	res, err := qb.CountByMovieID(obj.ID)
+	res, err := aggregateCache.getInt(fmt.Sprintf("movie_scene_count_%d", obj.ID), func() (int, error) {
+		return qb.CountByMovieID(obj.ID)
+	})
 	return &res, err
 }
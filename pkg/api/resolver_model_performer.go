@@ -2,9 +2,11 @@ package api
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/performer"
 )
 
 func (r *performerResolver) Name(ctx context.Context, obj *models.Performer) (*string, error) {
@@ -14,13 +16,62 @@ func (r *performerResolver) Name(ctx context.Context, obj *models.Performer) (*s
 	return nil, nil
 }
 
-func (r *performerResolver) URL(ctx context.Context, obj *models.Performer) (*string, error) {
-	if obj.URL.Valid {
-		return &obj.URL.String, nil
+func (r *performerResolver) Slug(ctx context.Context, obj *models.Performer) (string, error) {
+	return obj.Slug.String, nil
+}
+
+func (r *performerResolver) UUID(ctx context.Context, obj *models.Performer) (string, error) {
+	return obj.UUID.String, nil
+}
+
+func (r *performerResolver) Pinned(ctx context.Context, obj *models.Performer) (bool, error) {
+	return obj.PinnedPosition.Valid, nil
+}
+
+func (r *performerResolver) PinnedPosition(ctx context.Context, obj *models.Performer) (*int, error) {
+	if obj.PinnedPosition.Valid {
+		position := int(obj.PinnedPosition.Int64)
+		return &position, nil
 	}
 	return nil, nil
 }
 
+func (r *performerResolver) AutoTagMinConfidence(ctx context.Context, obj *models.Performer) (*float64, error) {
+	if obj.AutoTagMinConfidence.Valid {
+		return &obj.AutoTagMinConfidence.Float64, nil
+	}
+	return nil, nil
+}
+
+func (r *performerResolver) URL(ctx context.Context, obj *models.Performer) (*string, error) {
+	return r.legacyURL(obj, performer.LegacyWebsite)
+}
+
+func (r *performerResolver) Urls(ctx context.Context, obj *models.Performer) ([]*models.PerformerURL, error) {
+	qb := models.NewPerformerQueryBuilder()
+	urls, err := qb.GetURLs(obj.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*models.PerformerURL, len(urls))
+	for i := range urls {
+		ret[i] = &urls[i]
+	}
+	return ret, nil
+}
+
+// legacyURL returns the URL of the first link of the performer of the legacy
+// kind.
+func (r *performerResolver) legacyURL(obj *models.Performer, kind string) (*string, error) {
+	qb := models.NewPerformerQueryBuilder()
+	urls, err := qb.GetURLs(obj.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return performer.FirstURL(urls, kind), nil
+}
+
 func (r *performerResolver) Gender(ctx context.Context, obj *models.Performer) (*models.GenderEnum, error) {
 	var ret models.GenderEnum
 
@@ -35,17 +86,11 @@ func (r *performerResolver) Gender(ctx context.Context, obj *models.Performer) (
 }
 
 func (r *performerResolver) Twitter(ctx context.Context, obj *models.Performer) (*string, error) {
-	if obj.Twitter.Valid {
-		return &obj.Twitter.String, nil
-	}
-	return nil, nil
+	return r.legacyURL(obj, performer.LegacyTwitter)
 }
 
 func (r *performerResolver) Instagram(ctx context.Context, obj *models.Performer) (*string, error) {
-	if obj.Instagram.Valid {
-		return &obj.Instagram.String, nil
-	}
-	return nil, nil
+	return r.legacyURL(obj, performer.LegacyInstagram)
 }
 
 func (r *performerResolver) Birthdate(ctx context.Context, obj *models.Performer) (*string, error) {
@@ -90,6 +135,46 @@ func (r *performerResolver) Measurements(ctx context.Context, obj *models.Perfor
 	return nil, nil
 }
 
+func (r *performerResolver) HeightCm(ctx context.Context, obj *models.Performer) (*int, error) {
+	if obj.HeightCm.Valid {
+		ret := int(obj.HeightCm.Int64)
+		return &ret, nil
+	}
+	return nil, nil
+}
+
+func (r *performerResolver) Bust(ctx context.Context, obj *models.Performer) (*int, error) {
+	if obj.Bust.Valid {
+		ret := int(obj.Bust.Int64)
+		return &ret, nil
+	}
+	return nil, nil
+}
+
+func (r *performerResolver) Waist(ctx context.Context, obj *models.Performer) (*int, error) {
+	if obj.Waist.Valid {
+		ret := int(obj.Waist.Int64)
+		return &ret, nil
+	}
+	return nil, nil
+}
+
+func (r *performerResolver) Hips(ctx context.Context, obj *models.Performer) (*int, error) {
+	if obj.Hips.Valid {
+		ret := int(obj.Hips.Int64)
+		return &ret, nil
+	}
+	return nil, nil
+}
+
+func (r *performerResolver) Weight(ctx context.Context, obj *models.Performer) (*int, error) {
+	if obj.Weight.Valid {
+		ret := int(obj.Weight.Int64)
+		return &ret, nil
+	}
+	return nil, nil
+}
+
 func (r *performerResolver) FakeTits(ctx context.Context, obj *models.Performer) (*string, error) {
 	if obj.FakeTits.Valid {
 		return &obj.FakeTits.String, nil
@@ -140,7 +225,9 @@ func (r *performerResolver) ImagePath(ctx context.Context, obj *models.Performer
 
 func (r *performerResolver) SceneCount(ctx context.Context, obj *models.Performer) (*int, error) {
 	qb := models.NewSceneQueryBuilder()
-	res, err := qb.CountByPerformerID(obj.ID)
+	res, err := aggregateCache.getInt(fmt.Sprintf("performer_scene_count_%d", obj.ID), func() (int, error) {
+		return qb.CountByPerformerID(obj.ID)
+	})
 	return &res, err
 }
 
@@ -153,3 +240,26 @@ func (r *performerResolver) StashIds(ctx context.Context, obj *models.Performer)
 	qb := models.NewJoinsQueryBuilder()
 	return qb.GetPerformerStashIDs(obj.ID)
 }
+
+func (r *performerResolver) sceneDateRange(obj *models.Performer) (*models.SceneDateRange, error) {
+	qb := models.NewPerformerQueryBuilder()
+	return aggregateCache.getSceneDateRange(fmt.Sprintf("performer_scene_date_range_%d", obj.ID), func() (*models.SceneDateRange, error) {
+		return qb.SceneDateRange(obj.ID)
+	})
+}
+
+func (r *performerResolver) FirstSceneDate(ctx context.Context, obj *models.Performer) (*string, error) {
+	dates, err := r.sceneDateRange(obj)
+	if err != nil || !dates.First.Valid {
+		return nil, err
+	}
+	return &dates.First.String, nil
+}
+
+func (r *performerResolver) LastSceneDate(ctx context.Context, obj *models.Performer) (*string, error) {
+	dates, err := r.sceneDateRange(obj)
+	if err != nil || !dates.Last.Valid {
+		return nil, err
+	}
+	return &dates.Last.String, nil
+}
@@ -2,12 +2,18 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
+	"github.com/stashapp/stash/pkg/markdown"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+func (r *sceneResolver) UUID(ctx context.Context, obj *models.Scene) (string, error) {
+	return obj.UUID.String, nil
+}
+
 func (r *sceneResolver) Checksum(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.Checksum.Valid {
 		return &obj.Checksum.String, nil
@@ -22,6 +28,14 @@ func (r *sceneResolver) Oshash(ctx context.Context, obj *models.Scene) (*string,
 	return nil, nil
 }
 
+func (r *sceneResolver) Phash(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.Phash.Valid {
+		hexval := utils.PhashToString(obj.Phash.Int64)
+		return &hexval, nil
+	}
+	return nil, nil
+}
+
 func (r *sceneResolver) Title(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.Title.Valid {
 		return &obj.Title.String, nil
@@ -29,6 +43,14 @@ func (r *sceneResolver) Title(ctx context.Context, obj *models.Scene) (*string,
 	return nil, nil
 }
 
+func (r *sceneResolver) DisplayTitle(ctx context.Context, obj *models.Scene) (string, error) {
+	return utils.DisplayTitle(obj.Title.String, obj.Path), nil
+}
+
+func (r *sceneResolver) QualityScore(ctx context.Context, obj *models.Scene) (float64, error) {
+	return utils.QualityScore(obj.Width.Int64, obj.Height.Int64, obj.Bitrate.Int64, obj.Framerate.Float64, obj.VideoCodec.String), nil
+}
+
 func (r *sceneResolver) Details(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.Details.Valid {
 		return &obj.Details.String, nil
@@ -36,6 +58,14 @@ func (r *sceneResolver) Details(ctx context.Context, obj *models.Scene) (*string
 	return nil, nil
 }
 
+func (r *sceneResolver) DetailsHTML(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.Details.Valid {
+		result := markdown.Render(obj.Details.String)
+		return &result, nil
+	}
+	return nil, nil
+}
+
 func (r *sceneResolver) URL(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.URL.Valid {
 		return &obj.URL.String, nil
@@ -43,6 +73,20 @@ func (r *sceneResolver) URL(ctx context.Context, obj *models.Scene) (*string, er
 	return nil, nil
 }
 
+func (r *sceneResolver) CoverURL(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.CoverURL.Valid {
+		return &obj.CoverURL.String, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) BackgroundURL(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.BackgroundURL.Valid {
+		return &obj.BackgroundURL.String, nil
+	}
+	return nil, nil
+}
+
 func (r *sceneResolver) Date(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.Date.Valid {
 		result := utils.GetYMDFromDatabaseDate(obj.Date.String)
@@ -52,11 +96,19 @@ func (r *sceneResolver) Date(ctx context.Context, obj *models.Scene) (*string, e
 }
 
 func (r *sceneResolver) Rating(ctx context.Context, obj *models.Scene) (*int, error) {
-	if obj.Rating.Valid {
-		rating := int(obj.Rating.Int64)
-		return &rating, nil
-	}
-	return nil, nil
+	return resolveRating5(obj.Rating), nil
+}
+
+func (r *sceneResolver) Rating100(ctx context.Context, obj *models.Scene) (*int, error) {
+	return resolveRating100(obj.Rating), nil
+}
+
+func (r *sceneResolver) FileCreationTime(ctx context.Context, obj *models.Scene) (*time.Time, error) {
+	return resolveNullTimestamp(obj.FileCreationTime), nil
+}
+
+func (r *sceneResolver) UpdatedAt(ctx context.Context, obj *models.Scene) (*time.Time, error) {
+	return resolveTimestamp(obj.UpdatedAt), nil
 }
 
 func (r *sceneResolver) File(ctx context.Context, obj *models.Scene) (*models.SceneFileType, error) {
@@ -84,13 +136,25 @@ func (r *sceneResolver) Paths(ctx context.Context, obj *models.Scene) (*models.S
 	webpPath := builder.GetStreamPreviewImageURL()
 	vttPath := builder.GetSpriteVTTURL()
 	chaptersVttPath := builder.GetChaptersVTTURL()
+	chaptersMatroskaPath := builder.GetChaptersMatroskaURL()
+	chaptersFFMetadataPath := builder.GetChaptersFFMetadataURL()
+
+	var backgroundPath *string
+	if obj.BackgroundURL.Valid {
+		path := builder.GetBackgroundURL()
+		backgroundPath = &path
+	}
+
 	return &models.ScenePathsType{
-		Screenshot:  &screenshotPath,
-		Preview:     &previewPath,
-		Stream:      &streamPath,
-		Webp:        &webpPath,
-		Vtt:         &vttPath,
-		ChaptersVtt: &chaptersVttPath,
+		Screenshot:         &screenshotPath,
+		Preview:            &previewPath,
+		Stream:             &streamPath,
+		Webp:               &webpPath,
+		Vtt:                &vttPath,
+		ChaptersVtt:        &chaptersVttPath,
+		ChaptersMatroska:   &chaptersMatroskaPath,
+		ChaptersFfmetadata: &chaptersFFMetadataPath,
+		Background:         backgroundPath,
 	}, nil
 }
 
@@ -111,7 +175,6 @@ func (r *sceneResolver) Studio(ctx context.Context, obj *models.Scene) (*models.
 
 func (r *sceneResolver) Movies(ctx context.Context, obj *models.Scene) ([]*models.SceneMovie, error) {
 	joinQB := models.NewJoinsQueryBuilder()
-	qb := models.NewMovieQueryBuilder()
 
 	sceneMovies, err := joinQB.GetSceneMovies(obj.ID, nil)
 	if err != nil {
@@ -120,7 +183,7 @@ func (r *sceneResolver) Movies(ctx context.Context, obj *models.Scene) ([]*model
 
 	var ret []*models.SceneMovie
 	for _, sm := range sceneMovies {
-		movie, err := qb.Find(sm.MovieID, nil)
+		movie, err := findMovie(ctx, sm.MovieID)
 		if err != nil {
 			return nil, err
 		}
@@ -151,7 +214,63 @@ func (r *sceneResolver) Performers(ctx context.Context, obj *models.Scene) ([]*m
 	return qb.FindBySceneID(obj.ID, nil)
 }
 
+func (r *sceneResolver) ScenePerformers(ctx context.Context, obj *models.Scene) ([]*models.ScenePerformer, error) {
+	qb := models.NewPerformerQueryBuilder()
+	performers, err := qb.FindBySceneID(obj.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	date, dateErr := utils.ParseDateStringAsTime(obj.Date.String)
+
+	var ret []*models.ScenePerformer
+	for _, p := range performers {
+		scenePerformer := &models.ScenePerformer{
+			Performer: p,
+		}
+
+		if obj.Date.Valid && dateErr == nil && p.Birthdate.Valid {
+			birthdate, err := utils.ParseDateStringAsTime(p.Birthdate.String)
+			if err == nil && !birthdate.After(date) {
+				age := utils.AgeAt(birthdate, date)
+				scenePerformer.Age = &age
+			}
+		}
+
+		ret = append(ret, scenePerformer)
+	}
+	return ret, nil
+}
+
 func (r *sceneResolver) StashIds(ctx context.Context, obj *models.Scene) ([]*models.StashID, error) {
 	qb := models.NewJoinsQueryBuilder()
 	return qb.GetSceneStashIDs(obj.ID)
 }
+
+func (r *sceneResolver) Chapters(ctx context.Context, obj *models.Scene) ([]*models.SceneChapter, error) {
+	qb := models.NewSceneQueryBuilder()
+	return qb.GetChapters(obj.ID, nil)
+}
+
+func (r *sceneResolver) Captions(ctx context.Context, obj *models.Scene) ([]*models.SceneCaption, error) {
+	qb := models.NewSceneQueryBuilder()
+	return qb.GetCaptions(obj.ID, nil)
+}
+
+func (r *sceneCaptionResolver) URL(ctx context.Context, obj *models.SceneCaption) (string, error) {
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+	builder := urlbuilders.NewSceneURLBuilder(baseURL, obj.SceneID)
+	return builder.GetCaptionURL(obj.LanguageCode, obj.CaptionType), nil
+}
+
+func (r *sceneChapterResolver) EndSeconds(ctx context.Context, obj *models.SceneChapter) (*float64, error) {
+	if obj.EndSeconds.Valid {
+		return &obj.EndSeconds.Float64, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) ScrapeHistory(ctx context.Context, obj *models.Scene) ([]*models.ScrapeHistory, error) {
+	qb := models.NewScrapeHistoryQueryBuilder()
+	return qb.FindByEntity(models.ScrapeHistoryEntityScene, obj.ID)
+}
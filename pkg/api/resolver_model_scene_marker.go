@@ -6,6 +6,10 @@ import (
 	"github.com/stashapp/stash/pkg/models"
 )
 
+func (r *sceneMarkerResolver) UUID(ctx context.Context, obj *models.SceneMarker) (string, error) {
+	return obj.UUID.String, nil
+}
+
 func (r *sceneMarkerResolver) Scene(ctx context.Context, obj *models.SceneMarker) (*models.Scene, error) {
 	if !obj.SceneID.Valid {
 		panic("Invalid scene id")
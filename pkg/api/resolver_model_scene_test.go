@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func TestSceneTimestampsUseConfiguredTimezone(t *testing.T) {
+	if err := utils.SetTimezone("America/New_York"); err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	defer utils.SetTimezone("")
+
+	updatedAt := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	scene := &models.Scene{
+		UpdatedAt: models.SQLiteTimestamp{Timestamp: updatedAt},
+	}
+
+	r := &sceneResolver{}
+
+	ret, err := r.UpdatedAt(context.Background(), scene)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "America/New_York", ret.Location().String())
+		assert.True(t, ret.Equal(updatedAt))
+	}
+
+	ret, err = r.FileCreationTime(context.Background(), scene)
+	assert.Nil(t, err)
+	assert.Nil(t, ret)
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *scrapeHistoryResolver) Fields(ctx context.Context, obj *models.ScrapeHistory) ([]string, error) {
+	return obj.GetFields(), nil
+}
+
+func (r *scrapeHistoryResolver) AppliedFields(ctx context.Context, obj *models.ScrapeHistory) ([]string, error) {
+	return obj.GetAppliedFields(), nil
+}
+
+func (r *scrapeHistoryResolver) CreatedAt(ctx context.Context, obj *models.ScrapeHistory) (*time.Time, error) {
+	return resolveTimestamp(obj.CreatedAt), nil
+}
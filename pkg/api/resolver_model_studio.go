@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
 	"github.com/stashapp/stash/pkg/models"
@@ -14,6 +15,33 @@ func (r *studioResolver) Name(ctx context.Context, obj *models.Studio) (string,
 	panic("null name") // TODO make name required
 }
 
+func (r *studioResolver) Slug(ctx context.Context, obj *models.Studio) (string, error) {
+	return obj.Slug.String, nil
+}
+
+func (r *studioResolver) UUID(ctx context.Context, obj *models.Studio) (string, error) {
+	return obj.UUID.String, nil
+}
+
+func (r *studioResolver) Pinned(ctx context.Context, obj *models.Studio) (bool, error) {
+	return obj.PinnedPosition.Valid, nil
+}
+
+func (r *studioResolver) PinnedPosition(ctx context.Context, obj *models.Studio) (*int, error) {
+	if obj.PinnedPosition.Valid {
+		position := int(obj.PinnedPosition.Int64)
+		return &position, nil
+	}
+	return nil, nil
+}
+
+func (r *studioResolver) AutoTagMinConfidence(ctx context.Context, obj *models.Studio) (*float64, error) {
+	if obj.AutoTagMinConfidence.Valid {
+		return &obj.AutoTagMinConfidence.Float64, nil
+	}
+	return nil, nil
+}
+
 func (r *studioResolver) URL(ctx context.Context, obj *models.Studio) (*string, error) {
 	if obj.URL.Valid {
 		return &obj.URL.String, nil
@@ -25,6 +53,11 @@ func (r *studioResolver) ImagePath(ctx context.Context, obj *models.Studio) (*st
 	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
 	imagePath := urlbuilders.NewStudioURLBuilder(baseURL, obj.ID).GetStudioImageURL()
 
+	// the image endpoint returns the default image if the studio has none
+	if isSkippingImageLookups(ctx) {
+		return &imagePath, nil
+	}
+
 	qb := models.NewStudioQueryBuilder()
 	hasImage, err := qb.HasStudioImage(obj.ID)
 
@@ -42,7 +75,9 @@ func (r *studioResolver) ImagePath(ctx context.Context, obj *models.Studio) (*st
 
 func (r *studioResolver) SceneCount(ctx context.Context, obj *models.Studio) (*int, error) {
 	qb := models.NewSceneQueryBuilder()
-	res, err := qb.CountByStudioID(obj.ID)
+	res, err := aggregateCache.getInt(fmt.Sprintf("studio_scene_count_%d", obj.ID), func() (int, error) {
+		return qb.CountByStudioID(obj.ID)
+	})
 	return &res, err
 }
 
@@ -64,3 +99,61 @@ func (r *studioResolver) StashIds(ctx context.Context, obj *models.Studio) ([]*m
 	qb := models.NewJoinsQueryBuilder()
 	return qb.GetStudioStashIDs(obj.ID)
 }
+
+func (r *studioResolver) SceneCountByYear(ctx context.Context, obj *models.Studio) ([]*models.StudioYearSceneCount, error) {
+	qb := models.NewStudioQueryBuilder()
+	return qb.SceneCountByYear(obj.ID)
+}
+
+func (r *studioResolver) RollupSceneCount(ctx context.Context, obj *models.Studio) (int, error) {
+	qb := models.NewStudioQueryBuilder()
+	return aggregateCache.getInt(fmt.Sprintf("studio_rollup_scene_count_%d", obj.ID), func() (int, error) {
+		return qb.CountScenesWithDescendants(obj.ID)
+	})
+}
+
+func (r *studioResolver) ChildStudioRollups(ctx context.Context, obj *models.Studio) ([]*models.StudioChildRollup, error) {
+	qb := models.NewStudioQueryBuilder()
+	children, err := qb.FindChildren(obj.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := qb.ChildSceneCounts(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*models.StudioChildRollup, len(children))
+	for i, child := range children {
+		ret[i] = &models.StudioChildRollup{
+			Studio:     child,
+			SceneCount: counts[child.ID],
+		}
+	}
+
+	return ret, nil
+}
+
+func (r *studioResolver) sceneDateRange(obj *models.Studio) (*models.SceneDateRange, error) {
+	qb := models.NewStudioQueryBuilder()
+	return aggregateCache.getSceneDateRange(fmt.Sprintf("studio_scene_date_range_%d", obj.ID), func() (*models.SceneDateRange, error) {
+		return qb.SceneDateRange(obj.ID)
+	})
+}
+
+func (r *studioResolver) FirstSceneDate(ctx context.Context, obj *models.Studio) (*string, error) {
+	dates, err := r.sceneDateRange(obj)
+	if err != nil || !dates.First.Valid {
+		return nil, err
+	}
+	return &dates.First.String, nil
+}
+
+func (r *studioResolver) LastSceneDate(ctx context.Context, obj *models.Studio) (*string, error) {
+	dates, err := r.sceneDateRange(obj)
+	if err != nil || !dates.Last.Valid {
+		return nil, err
+	}
+	return &dates.Last.String, nil
+}
@@ -2,17 +2,24 @@ package api
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
 	"github.com/stashapp/stash/pkg/models"
 )
 
+func (r *tagResolver) UUID(ctx context.Context, obj *models.Tag) (string, error) {
+	return obj.UUID.String, nil
+}
+
 func (r *tagResolver) SceneCount(ctx context.Context, obj *models.Tag) (*int, error) {
 	qb := models.NewSceneQueryBuilder()
 	if obj == nil {
 		return nil, nil
 	}
-	count, err := qb.CountByTagID(obj.ID)
+	count, err := aggregateCache.getInt(fmt.Sprintf("tag_scene_count_%d", obj.ID), func() (int, error) {
+		return qb.CountByTagID(obj.ID)
+	})
 	return &count, err
 }
 
@@ -21,7 +28,9 @@ func (r *tagResolver) SceneMarkerCount(ctx context.Context, obj *models.Tag) (*i
 	if obj == nil {
 		return nil, nil
 	}
-	count, err := qb.CountByTagID(obj.ID)
+	count, err := aggregateCache.getInt(fmt.Sprintf("tag_scene_marker_count_%d", obj.ID), func() (int, error) {
+		return qb.CountByTagID(obj.ID)
+	})
 	return &count, err
 }
 
@@ -30,3 +39,58 @@ func (r *tagResolver) ImagePath(ctx context.Context, obj *models.Tag) (*string,
 	imagePath := urlbuilders.NewTagURLBuilder(baseURL, obj.ID).GetTagImageURL()
 	return &imagePath, nil
 }
+
+func (r *tagResolver) sceneDateRange(obj *models.Tag) (*models.SceneDateRange, error) {
+	qb := models.NewTagQueryBuilder()
+	return aggregateCache.getSceneDateRange(fmt.Sprintf("tag_scene_date_range_%d", obj.ID), func() (*models.SceneDateRange, error) {
+		return qb.SceneDateRange(obj.ID)
+	})
+}
+
+func (r *tagResolver) FirstSceneDate(ctx context.Context, obj *models.Tag) (*string, error) {
+	dates, err := r.sceneDateRange(obj)
+	if err != nil || !dates.First.Valid {
+		return nil, err
+	}
+	return &dates.First.String, nil
+}
+
+func (r *tagResolver) LastSceneDate(ctx context.Context, obj *models.Tag) (*string, error) {
+	dates, err := r.sceneDateRange(obj)
+	if err != nil || !dates.Last.Valid {
+		return nil, err
+	}
+	return &dates.Last.String, nil
+}
+
+func (r *tagResolver) Parents(ctx context.Context, obj *models.Tag) ([]*models.Tag, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.FindByChildTagID(obj.ID, nil)
+}
+
+func (r *tagResolver) Children(ctx context.Context, obj *models.Tag) ([]*models.Tag, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.FindByParentTagID(obj.ID, nil)
+}
+
+func (r *tagResolver) ParentCount(ctx context.Context, obj *models.Tag) (int, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.CountByChildTagID(obj.ID)
+}
+
+func (r *tagResolver) ChildCount(ctx context.Context, obj *models.Tag) (int, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.CountByParentTagID(obj.ID)
+}
+
+func (r *tagResolver) Aliases(ctx context.Context, obj *models.Tag) ([]string, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.GetAliases(obj.ID, nil)
+}
+
+func (r *tagResolver) AutoTagMinConfidence(ctx context.Context, obj *models.Tag) (*float64, error) {
+	if obj.AutoTagMinConfidence.Valid {
+		return &obj.AutoTagMinConfidence.Float64, nil
+	}
+	return nil, nil
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"strings"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
@@ -14,12 +18,25 @@ import (
 )
 
 func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.ConfigGeneralInput) (*models.ConfigGeneralResult, error) {
+	// validated before anything is set, so that an invalid input cannot
+	// leave the header trusted without credentials
+	if err := validateTrustedProxyHeader(input); err != nil {
+		return makeConfigGeneralResult(), err
+	}
+
 	if len(input.Stashes) > 0 {
+		names := make(map[string]bool)
 		for _, s := range input.Stashes {
 			exists, err := utils.DirExists(s.Path)
 			if !exists {
 				return makeConfigGeneralResult(), err
 			}
+
+			name := models.StashConfig{Path: s.Path, Name: s.Name}.LibraryName()
+			if names[name] {
+				return makeConfigGeneralResult(), fmt.Errorf("duplicate library name %q", name)
+			}
+			names[name] = true
 		}
 		config.Set(config.Stash, input.Stashes)
 	}
@@ -46,6 +63,15 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		config.Set(config.Cache, input.CachePath)
 	}
 
+	if input.AssetsPath != nil {
+		if *input.AssetsPath != "" {
+			if _, err := utils.DirExists(*input.AssetsPath); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+		}
+		config.Set(config.AssetsPath, *input.AssetsPath)
+	}
+
 	if !input.CalculateMd5 && input.VideoFileNamingAlgorithm == models.HashAlgorithmMd5 {
 		return makeConfigGeneralResult(), errors.New("calculateMD5 must be true if using MD5")
 	}
@@ -61,6 +87,43 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 
 	config.Set(config.CalculateMD5, input.CalculateMd5)
 
+	if input.DeferScanHashing != nil {
+		config.Set(config.DeferScanHashing, *input.DeferScanHashing)
+	}
+
+	if input.ScanMaxReadRate != nil {
+		if *input.ScanMaxReadRate < 0 {
+			return makeConfigGeneralResult(), errors.New("scan maximum read rate must not be negative")
+		}
+		config.Set(config.ScanMaxReadRate, *input.ScanMaxReadRate)
+	}
+
+	if input.ScanHashWhenIdle != nil {
+		config.Set(config.ScanHashWhenIdle, *input.ScanHashWhenIdle)
+	}
+
+	if input.CacheRemoteArtwork != nil {
+		config.Set(config.CacheRemoteArtwork, *input.CacheRemoteArtwork)
+	}
+
+	if input.ChapterMarkersOnScan != nil {
+		config.Set(config.ChapterMarkersOnScan, *input.ChapterMarkersOnScan)
+	}
+
+	if input.ChapterMarkerTag != nil {
+		if strings.TrimSpace(*input.ChapterMarkerTag) == "" {
+			return makeConfigGeneralResult(), errors.New("chapter marker tag must not be empty")
+		}
+		config.Set(config.ChapterMarkerTag, strings.TrimSpace(*input.ChapterMarkerTag))
+	}
+
+	if input.AutoTagMinConfidence != nil {
+		if *input.AutoTagMinConfidence < 0 || *input.AutoTagMinConfidence > 1 {
+			return makeConfigGeneralResult(), errors.New("auto tag minimum confidence must be between 0 and 1")
+		}
+		config.Set(config.AutoTagMinConfidence, *input.AutoTagMinConfidence)
+	}
+
 	if input.ParallelTasks != nil {
 		config.Set(config.ParallelTasks, *input.ParallelTasks)
 	}
@@ -118,6 +181,150 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		logger.SetLogLevel(input.LogLevel)
 	}
 
+	if input.LogMaxSize != nil {
+		if *input.LogMaxSize < 0 {
+			return makeConfigGeneralResult(), errors.New("log max size must not be negative")
+		}
+		config.Set(config.LogMaxSize, *input.LogMaxSize)
+	}
+
+	if input.LogRotateInterval != nil {
+		if *input.LogRotateInterval < 0 {
+			return makeConfigGeneralResult(), errors.New("log rotate interval must not be negative")
+		}
+		config.Set(config.LogRotateInterval, *input.LogRotateInterval)
+	}
+
+	if input.LogMaxBackups != nil {
+		if *input.LogMaxBackups < 0 {
+			return makeConfigGeneralResult(), errors.New("log max backups must not be negative")
+		}
+		config.Set(config.LogMaxBackups, *input.LogMaxBackups)
+	}
+
+	if input.LogMaxAge != nil {
+		if *input.LogMaxAge < 0 {
+			return makeConfigGeneralResult(), errors.New("log max age must not be negative")
+		}
+		config.Set(config.LogMaxAge, *input.LogMaxAge)
+	}
+
+	if input.LogCompress != nil {
+		config.Set(config.LogCompress, *input.LogCompress)
+	}
+
+	logger.SetRotateConfig(config.GetLogRotateConfig())
+
+	if input.Timezone != nil {
+		if err := utils.SetTimezone(*input.Timezone); err != nil {
+			return makeConfigGeneralResult(), err
+		}
+		config.Set(config.Timezone, *input.Timezone)
+	}
+
+	if input.ApproximateCounts != nil {
+		config.Set(config.ApproximateCounts, *input.ApproximateCounts)
+		models.SetApproximateCounts(*input.ApproximateCounts)
+	}
+
+	if input.ActivityRetentionDays != nil {
+		config.Set(config.ActivityRetentionDays, *input.ActivityRetentionDays)
+	}
+
+	if input.MinimumFreeSpace != nil {
+		config.Set(config.MinimumFreeSpace, *input.MinimumFreeSpace)
+	}
+
+	if input.MaxIOJobs != nil {
+		config.Set(config.MaxIOJobs, *input.MaxIOJobs)
+	}
+
+	if input.MaxCPUJobs != nil {
+		config.Set(config.MaxCPUJobs, *input.MaxCPUJobs)
+	}
+
+	if input.TranscodeCacheSize != nil {
+		config.Set(config.TranscodeCacheSize, *input.TranscodeCacheSize)
+	}
+
+	if input.BlobsStorage != nil {
+		config.Set(config.BlobsStorage, *input.BlobsStorage)
+	}
+
+	if input.BlobsPath != nil {
+		config.Set(config.BlobsPath, *input.BlobsPath)
+	}
+
+	if input.EnablePlayground != nil {
+		config.Set(config.EnablePlayground, *input.EnablePlayground)
+	}
+
+	if input.EnableIntrospection != nil {
+		config.Set(config.EnableIntrospection, *input.EnableIntrospection)
+	}
+
+	if input.CorsAllowedOrigins != nil {
+		config.Set(config.CORSAllowedOrigins, input.CorsAllowedOrigins)
+	}
+
+	if input.CorsAllowedHeaders != nil {
+		config.Set(config.CORSAllowedHeaders, input.CorsAllowedHeaders)
+	}
+
+	if input.TrustedProxies != nil {
+		config.Set(config.TrustedProxies, input.TrustedProxies)
+	}
+
+	if input.TrustedProxyHeader != nil {
+		config.Set(config.TrustedProxyHeader, *input.TrustedProxyHeader)
+	}
+
+	if input.OidcIssuer != nil {
+		if *input.OidcIssuer != "" {
+			if u, err := url.Parse(*input.OidcIssuer); err != nil || u.Scheme == "" || u.Host == "" {
+				return makeConfigGeneralResult(), fmt.Errorf("invalid oidcIssuer %s", *input.OidcIssuer)
+			}
+		}
+		config.Set(config.OIDCIssuer, *input.OidcIssuer)
+	}
+
+	if input.OidcClientID != nil {
+		config.Set(config.OIDCClientID, *input.OidcClientID)
+	}
+
+	if input.OidcClientSecret != nil {
+		config.Set(config.OIDCClientSecret, *input.OidcClientSecret)
+	}
+
+	if input.OidcScopes != nil {
+		if len(input.OidcScopes) > 0 && !utils.StrInclude(input.OidcScopes, "openid") {
+			return makeConfigGeneralResult(), errors.New("oidcScopes must include openid")
+		}
+		config.Set(config.OIDCScopes, input.OidcScopes)
+	}
+
+	if input.OidcGroupsClaim != nil {
+		config.Set(config.OIDCGroupsClaim, *input.OidcGroupsClaim)
+	}
+
+	if input.OidcAdminGroups != nil {
+		config.Set(config.OIDCAdminGroups, input.OidcAdminGroups)
+	}
+
+	if input.OidcViewerGroups != nil {
+		config.Set(config.OIDCViewerGroups, input.OidcViewerGroups)
+	}
+
+	// the local credentials are the fallback login of the admin
+	if config.GetOIDCIssuer() != "" {
+		if config.GetOIDCClientID() == "" {
+			return makeConfigGeneralResult(), errors.New("oidcClientID must be set to use oidcIssuer")
+		}
+		if !config.HasCredentials() {
+			return makeConfigGeneralResult(), errors.New("username and password must be set to use oidcIssuer")
+		}
+	}
+
 	if input.Excludes != nil {
 		config.Set(config.Exclude, input.Excludes)
 	}
@@ -126,6 +333,47 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		config.Set(config.ImageExclude, input.ImageExcludes)
 	}
 
+	if input.DisplayTitleCleanup != nil {
+		if err := utils.SetDisplayTitleCleanup(input.DisplayTitleCleanup); err != nil {
+			return makeConfigGeneralResult(), err
+		}
+		changed := strings.Join(config.GetDisplayTitleCleanup(), "\n") != strings.Join(input.DisplayTitleCleanup, "\n")
+		config.Set(config.DisplayTitleCleanup, input.DisplayTitleCleanup)
+
+		// the search documents of scenes hold their display titles
+		if changed {
+			qb := models.NewSceneQueryBuilder()
+			if err := database.WithTxn(func(tx *sqlx.Tx) error {
+				return qb.RebuildSearchDocuments(tx)
+			}); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+		}
+	}
+
+	if input.SceneFilenameTemplate != nil {
+		if *input.SceneFilenameTemplate != "" {
+			if err := manager.ValidateSceneFilenameTemplate(*input.SceneFilenameTemplate); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+		}
+		config.Set(config.SceneFilenameTemplate, *input.SceneFilenameTemplate)
+	}
+
+	if input.DateInferencePriority != nil {
+		var priority []string
+		for _, source := range input.DateInferencePriority {
+			priority = append(priority, source.String())
+		}
+		config.Set(config.DateInferencePriority, priority)
+	}
+
+	if input.QualityScore != nil {
+		if err := setQualityScoreConfig(*input.QualityScore); err != nil {
+			return makeConfigGeneralResult(), err
+		}
+	}
+
 	if input.VideoExtensions != nil {
 		config.Set(config.VideoExtensions, input.VideoExtensions)
 	}
@@ -140,6 +388,17 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 
 	config.Set(config.CreateGalleriesFromFolders, input.CreateGalleriesFromFolders)
 
+	if input.GalleryFolderMinImages != nil {
+		if *input.GalleryFolderMinImages < 1 {
+			return makeConfigGeneralResult(), errors.New("gallery folder minimum images must be at least 1")
+		}
+		config.Set(config.GalleryFolderMinImages, *input.GalleryFolderMinImages)
+	}
+
+	if input.WatchLibraries != nil {
+		config.Set(config.WatchLibraries, *input.WatchLibraries)
+	}
+
 	refreshScraperCache := false
 	if input.ScraperUserAgent != nil {
 		config.Set(config.ScraperUserAgent, input.ScraperUserAgent)
@@ -170,6 +429,51 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 	return makeConfigGeneralResult(), nil
 }
 
+// validateTrustedProxyHeader validates the trusted proxy settings that would
+// be in effect after input is applied. The proxy user is mapped to the
+// configured user, so the header cannot be used without credentials, or
+// without proxies to trust.
+func validateTrustedProxyHeader(input models.ConfigGeneralInput) error {
+	proxies := config.GetTrustedProxies()
+	if input.TrustedProxies != nil {
+		for _, proxy := range input.TrustedProxies {
+			if _, err := parseTrustedProxy(proxy); err != nil {
+				return err
+			}
+		}
+		proxies = input.TrustedProxies
+	}
+
+	header := config.GetTrustedProxyHeader()
+	if input.TrustedProxyHeader != nil {
+		header = *input.TrustedProxyHeader
+	}
+
+	if header == "" {
+		return nil
+	}
+
+	if len(proxies) == 0 {
+		return errors.New("trustedProxies must be set to use trustedProxyHeader")
+	}
+
+	username := config.GetUsername()
+	if input.Username != nil {
+		username = *input.Username
+	}
+
+	password := config.GetPasswordHash()
+	if input.Password != nil {
+		password = *input.Password
+	}
+
+	if username == "" || password == "" {
+		return errors.New("username and password must be set to use trustedProxyHeader")
+	}
+
+	return nil
+}
+
 func (r *mutationResolver) ConfigureInterface(ctx context.Context, input models.ConfigInterfaceInput) (*models.ConfigInterfaceResult, error) {
 	if input.MenuItems != nil {
 		config.Set(config.MenuItems, input.MenuItems)
@@ -221,3 +525,40 @@ func (r *mutationResolver) ConfigureInterface(ctx context.Context, input models.
 
 	return makeConfigInterfaceResult(), nil
 }
+
+// setQualityScoreConfig sets the quality score weights which are set in the
+// input, and applies them.
+func setQualityScoreConfig(input models.QualityScoreConfigInput) error {
+	for _, weight := range []*float64{input.ResolutionWeight, input.BitrateWeight, input.CodecWeight} {
+		if weight != nil && *weight < 0 {
+			return fmt.Errorf("quality score weights must not be negative")
+		}
+	}
+
+	var codecScores map[string]float64
+	if input.CodecScores != nil {
+		codecScores = make(map[string]float64)
+		for _, s := range input.CodecScores {
+			if s.Score < 0 || s.Score > 1 {
+				return fmt.Errorf("score of codec %s must be between 0 and 1", s.Codec)
+			}
+			codecScores[strings.ToLower(s.Codec)] = s.Score
+		}
+	}
+
+	if input.ResolutionWeight != nil {
+		config.Set(config.QualityResolutionWeight, *input.ResolutionWeight)
+	}
+	if input.BitrateWeight != nil {
+		config.Set(config.QualityBitrateWeight, *input.BitrateWeight)
+	}
+	if input.CodecWeight != nil {
+		config.Set(config.QualityCodecWeight, *input.CodecWeight)
+	}
+	if codecScores != nil {
+		config.Set(config.QualityCodecScores, codecScores)
+	}
+
+	utils.SetQualityScoreWeights(config.GetQualityScoreWeights())
+	return nil
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestConfigureGeneralTrustedProxyHeader(t *testing.T) {
+	defer config.Set(config.TrustedProxies, config.GetTrustedProxies())
+	defer config.Set(config.TrustedProxyHeader, config.GetTrustedProxyHeader())
+	defer config.Set(config.Username, config.GetUsername())
+
+	config.Set(config.TrustedProxies, []string{})
+	config.Set(config.TrustedProxyHeader, "")
+	config.Set(config.Username, "")
+
+	header := "X-Forwarded-User"
+	username := "admin"
+	r := &mutationResolver{}
+
+	tests := []struct {
+		name  string
+		input models.ConfigGeneralInput
+	}{
+		{
+			"no proxies",
+			models.ConfigGeneralInput{TrustedProxyHeader: &header},
+		},
+		{
+			"invalid proxy",
+			models.ConfigGeneralInput{TrustedProxyHeader: &header, TrustedProxies: []string{"invalid"}},
+		},
+		{
+			"no password",
+			models.ConfigGeneralInput{TrustedProxyHeader: &header, TrustedProxies: []string{"127.0.0.1"}, Username: &username},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.ConfigureGeneral(context.TODO(), tt.input)
+			assert.NotNil(t, err)
+
+			// nothing is set when the input is invalid
+			assert.Empty(t, config.GetTrustedProxyHeader())
+			assert.Empty(t, config.GetTrustedProxies())
+			assert.Empty(t, config.GetUsername())
+		})
+	}
+}
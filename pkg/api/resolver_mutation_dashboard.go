@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func (r *mutationResolver) ReorderPinned(ctx context.Context, input models.ReorderPinnedInput) (bool, error) {
+	ids := utils.StringSliceToIntSlice(input.Ids)
+
+	var reorder func(ids []int, tx *sqlx.Tx) error
+	switch input.Type {
+	case models.PinnedTypeMovie:
+		qb := models.NewMovieQueryBuilder()
+		reorder = qb.ReorderPinned
+	case models.PinnedTypePerformer:
+		qb := models.NewPerformerQueryBuilder()
+		reorder = qb.ReorderPinned
+	case models.PinnedTypeStudio:
+		qb := models.NewStudioQueryBuilder()
+		reorder = qb.ReorderPinned
+	default:
+		return false, fmt.Errorf("invalid pinned type %s", input.Type)
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	if err := reorder(ids, tx); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
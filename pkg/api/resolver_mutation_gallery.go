@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
@@ -46,8 +48,8 @@ func (r *mutationResolver) GalleryCreate(ctx context.Context, input models.Galle
 	if input.Date != nil {
 		newGallery.Date = models.SQLiteDate{String: *input.Date, Valid: true}
 	}
-	if input.Rating != nil {
-		newGallery.Rating = sql.NullInt64{Int64: int64(*input.Rating), Valid: true}
+	if rating := getRating100Input(input.Rating, input.Rating100); rating != nil {
+		newGallery.Rating = sql.NullInt64{Int64: int64(*rating), Valid: true}
 	} else {
 		// rating must be nullable
 		newGallery.Rating = sql.NullInt64{Valid: false}
@@ -112,6 +114,8 @@ func (r *mutationResolver) GalleryCreate(ctx context.Context, input models.Galle
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityGallery, event.ActionCreate, gallery.ID)
+
 	return gallery, nil
 }
 
@@ -134,6 +138,8 @@ func (r *mutationResolver) GalleryUpdate(ctx context.Context, input models.Galle
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityGallery, event.ActionUpdate, ret.ID)
+
 	return ret, nil
 }
 
@@ -163,6 +169,12 @@ func (r *mutationResolver) GalleriesUpdate(ctx context.Context, input []*models.
 		return nil, err
 	}
 
+	var galleryIDs []int
+	for _, gallery := range ret {
+		galleryIDs = append(galleryIDs, gallery.ID)
+	}
+	publishEvent(ctx, event.EntityGallery, event.ActionUpdate, galleryIDs...)
+
 	return ret, nil
 }
 
@@ -203,7 +215,7 @@ func (r *mutationResolver) galleryUpdate(input models.GalleryUpdateInput, transl
 	updatedGallery.Details = translator.nullString(input.Details, "details")
 	updatedGallery.URL = translator.nullString(input.URL, "url")
 	updatedGallery.Date = translator.sqliteDate(input.Date, "date")
-	updatedGallery.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedGallery.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedGallery.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedGallery.Organized = input.Organized
 
@@ -247,9 +259,37 @@ func (r *mutationResolver) galleryUpdate(input models.GalleryUpdateInput, transl
 		}
 	}
 
+	recordAppliedScrapeFields(models.ScrapeHistoryEntityGallery, galleryID, getGalleryScrapedValues(input, translator), tx)
+
 	return gallery, nil
 }
 
+// getGalleryScrapedValues returns the values saved by the update, keyed by
+// the name of the matching scraped gallery field.
+func getGalleryScrapedValues(input models.GalleryUpdateInput, translator changesetTranslator) map[string]interface{} {
+	ret := make(map[string]interface{})
+	setString := func(field string, value *string) {
+		if value != nil {
+			ret[field] = *value
+		}
+	}
+
+	setString("title", input.Title)
+	setString("details", input.Details)
+	setString("url", input.URL)
+	setString("date", input.Date)
+	setString("studio", input.StudioID)
+
+	if translator.hasField("performer_ids") {
+		ret["performers"] = input.PerformerIds
+	}
+	if translator.hasField("tag_ids") {
+		ret["tags"] = input.TagIds
+	}
+
+	return ret
+}
+
 func (r *mutationResolver) BulkGalleryUpdate(ctx context.Context, input models.BulkGalleryUpdateInput) ([]*models.Gallery, error) {
 	// Populate gallery from the input
 	updatedTime := time.Now()
@@ -270,7 +310,7 @@ func (r *mutationResolver) BulkGalleryUpdate(ctx context.Context, input models.B
 	updatedGallery.Details = translator.nullString(input.Details, "details")
 	updatedGallery.URL = translator.nullString(input.URL, "url")
 	updatedGallery.Date = translator.sqliteDate(input.Date, "date")
-	updatedGallery.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedGallery.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedGallery.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedGallery.SceneID = translator.nullInt64FromString(input.SceneID, "scene_id")
 	updatedGallery.Organized = input.Organized
@@ -339,6 +379,8 @@ func (r *mutationResolver) BulkGalleryUpdate(ctx context.Context, input models.B
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityGallery, event.ActionUpdate, utils.StringSliceToIntSlice(input.Ids)...)
+
 	return ret, nil
 }
 
@@ -455,6 +497,15 @@ func (r *mutationResolver) GalleryDestroy(ctx context.Context, input models.Gall
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntityGallery, event.ActionDestroy, utils.StringSliceToIntSlice(input.Ids)...)
+	if len(imgsToPostProcess) > 0 {
+		var imageIDs []int
+		for _, img := range imgsToPostProcess {
+			imageIDs = append(imageIDs, img.ID)
+		}
+		publishEvent(ctx, event.EntityImage, event.ActionDestroy, imageIDs...)
+	}
+
 	// if delete file is true, then delete the file as well
 	// if it fails, just log a message
 	if input.DeleteFile != nil && *input.DeleteFile {
@@ -510,6 +561,8 @@ func (r *mutationResolver) AddGalleryImages(ctx context.Context, input models.Ga
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntityGallery, event.ActionUpdate, galleryID)
+
 	return true, nil
 }
 
@@ -545,5 +598,70 @@ func (r *mutationResolver) RemoveGalleryImages(ctx context.Context, input models
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntityGallery, event.ActionUpdate, galleryID)
+
 	return true, nil
 }
+
+func (r *mutationResolver) GallerySetCover(ctx context.Context, input models.GallerySetCoverInput) (*models.Gallery, error) {
+	galleryID, err := strconv.Atoi(input.GalleryID)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := models.NewGalleryQueryBuilder()
+	gallery, err := qb.Find(galleryID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if gallery == nil {
+		return nil, errors.New("gallery not found")
+	}
+
+	coverID := sql.NullInt64{}
+	if input.ImageID != nil {
+		imageID, err := strconv.Atoi(*input.ImageID)
+		if err != nil {
+			return nil, err
+		}
+
+		jqb := models.NewJoinsQueryBuilder()
+		joins, err := jqb.GetImageGalleries(imageID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, join := range joins {
+			if join.GalleryID == galleryID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("image with id %d is not in the gallery", imageID)
+		}
+
+		coverID = sql.NullInt64{Int64: int64(imageID), Valid: true}
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	updatedGallery, err := qb.UpdatePartial(models.GalleryPartial{
+		ID:           galleryID,
+		CoverImageID: &coverID,
+		UpdatedAt:    &models.SQLiteTimestamp{Timestamp: time.Now()},
+	}, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityGallery, event.ActionUpdate, galleryID)
+
+	return updatedGallery, nil
+}
@@ -2,14 +2,17 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func (r *mutationResolver) ImageUpdate(ctx context.Context, input models.ImageUpdateInput) (*models.Image, error) {
@@ -32,6 +35,8 @@ func (r *mutationResolver) ImageUpdate(ctx context.Context, input models.ImageUp
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, ret.ID)
+
 	return ret, nil
 }
 
@@ -61,6 +66,12 @@ func (r *mutationResolver) ImagesUpdate(ctx context.Context, input []*models.Ima
 		return nil, err
 	}
 
+	var imageIDs []int
+	for _, image := range ret {
+		imageIDs = append(imageIDs, image.ID)
+	}
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, imageIDs...)
+
 	return ret, nil
 }
 
@@ -75,7 +86,7 @@ func (r *mutationResolver) imageUpdate(input models.ImageUpdateInput, translator
 	}
 
 	updatedImage.Title = translator.nullString(input.Title, "title")
-	updatedImage.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedImage.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedImage.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedImage.Organized = input.Organized
 
@@ -141,7 +152,7 @@ func (r *mutationResolver) BulkImageUpdate(ctx context.Context, input models.Bul
 	}
 
 	updatedImage.Title = translator.nullString(input.Title, "title")
-	updatedImage.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedImage.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedImage.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedImage.Organized = input.Organized
 
@@ -230,6 +241,8 @@ func (r *mutationResolver) BulkImageUpdate(ctx context.Context, input models.Bul
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, utils.StringSliceToIntSlice(input.Ids)...)
+
 	return ret, nil
 }
 
@@ -310,6 +323,8 @@ func (r *mutationResolver) ImageDestroy(ctx context.Context, input models.ImageD
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionDestroy, imageID)
+
 	// if delete generated is true, then delete the generated files
 	// for the image
 	if input.DeleteGenerated != nil && *input.DeleteGenerated {
@@ -349,6 +364,8 @@ func (r *mutationResolver) ImagesDestroy(ctx context.Context, input models.Image
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionDestroy, utils.StringSliceToIntSlice(input.Ids)...)
+
 	for _, image := range images {
 		// if delete generated is true, then delete the generated files
 		// for the image
@@ -366,6 +383,62 @@ func (r *mutationResolver) ImagesDestroy(ctx context.Context, input models.Image
 	return true, nil
 }
 
+func (r *mutationResolver) ImagesMerge(ctx context.Context, input models.ImagesMergeInput) (*models.Image, error) {
+	sourceIDs, err := utils.ParseIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+	destinationID, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.IntInclude(sourceIDs, destinationID) {
+		return nil, fmt.Errorf("Image with ID %d cannot be merged into itself", destinationID)
+	}
+
+	qb := models.NewImageQueryBuilder()
+	destination, err := qb.Find(destinationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if destination == nil {
+		return nil, fmt.Errorf("Image with ID %d not found", destinationID)
+	}
+
+	sources, err := qb.FindMany(sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	if err := qb.Merge(sourceIDs, destinationID, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityImage, event.ActionDestroy, sourceIDs...)
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, destinationID)
+
+	for _, source := range sources {
+		if input.DeleteGenerated != nil && *input.DeleteGenerated {
+			manager.DeleteGeneratedImageFiles(source)
+		}
+
+		// the destination file is kept if it is the same file
+		if input.DeleteFile != nil && *input.DeleteFile && source.Path != destination.Path {
+			manager.DeleteImageFile(source)
+		}
+	}
+
+	return qb.Find(destinationID)
+}
+
 func (r *mutationResolver) ImageIncrementO(ctx context.Context, id string) (int, error) {
 	imageID, _ := strconv.Atoi(id)
 
@@ -383,6 +456,8 @@ func (r *mutationResolver) ImageIncrementO(ctx context.Context, id string) (int,
 		return 0, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, imageID)
+
 	return newVal, nil
 }
 
@@ -403,6 +478,8 @@ func (r *mutationResolver) ImageDecrementO(ctx context.Context, id string) (int,
 		return 0, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, imageID)
+
 	return newVal, nil
 }
 
@@ -423,5 +500,7 @@ func (r *mutationResolver) ImageResetO(ctx context.Context, id string) (int, err
 		return 0, err
 	}
 
+	publishEvent(ctx, event.EntityImage, event.ActionUpdate, imageID)
+
 	return newVal, nil
 }
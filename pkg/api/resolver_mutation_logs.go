@@ -0,0 +1,21 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager"
+)
+
+func (r *mutationResolver) DownloadLogs(ctx context.Context) (string, error) {
+	hash, err := manager.GetInstance().CreateLogArchive()
+	if err != nil {
+		return "", err
+	}
+
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+
+	// generate timestamp
+	suffix := time.Now().Format("20060102-150405")
+	return baseURL + "/downloads/" + hash + "/logs" + suffix + ".zip", nil
+}
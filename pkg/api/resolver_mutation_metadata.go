@@ -2,46 +2,61 @@ package api
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func (r *mutationResolver) MetadataScan(ctx context.Context, input models.ScanMetadataInput) (string, error) {
-	manager.GetInstance().Scan(input)
-	return "todo", nil
+	jobID, err := manager.GetInstance().Scan(input)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataScanSingle(ctx context.Context, input models.ScanSingleMetadataInput) (string, error) {
+	jobID := manager.GetInstance().ScanSingle(utils.StringSliceToIntSlice(input.SceneIds))
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataDuplicateImages(ctx context.Context, input models.DuplicateImagesInput) (string, error) {
+	distance := defaultPhashDistance
+	if input.PhashDistance != nil {
+		distance = *input.PhashDistance
+	}
+
+	jobID := manager.GetInstance().DuplicateImages(distance)
+	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) MetadataImport(ctx context.Context) (string, error) {
-	manager.GetInstance().Import()
-	return "todo", nil
+	jobID := manager.GetInstance().Import()
+	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) ImportObjects(ctx context.Context, input models.ImportObjectsInput) (string, error) {
 	t := manager.CreateImportTask(config.GetVideoFileNamingAlgorithm(), input)
-	_, err := manager.GetInstance().RunSingleTask(t)
-	if err != nil {
-		return "", err
-	}
-
-	return "todo", nil
+	j := manager.GetInstance().RunSingleTask(t)
+	return strconv.Itoa(j.ID), nil
 }
 
 func (r *mutationResolver) MetadataExport(ctx context.Context) (string, error) {
-	manager.GetInstance().Export()
-	return "todo", nil
+	jobID := manager.GetInstance().Export()
+	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) ExportObjects(ctx context.Context, input models.ExportObjectsInput) (*string, error) {
 	t := manager.CreateExportTask(config.GetVideoFileNamingAlgorithm(), input)
-	wg, err := manager.GetInstance().RunSingleTask(t)
-	if err != nil {
-		return nil, err
-	}
-
-	wg.Wait()
+	manager.GetInstance().RunSingleTask(t).Wait()
 
 	if t.DownloadHash != "" {
 		baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
@@ -56,36 +71,177 @@ func (r *mutationResolver) ExportObjects(ctx context.Context, input models.Expor
 }
 
 func (r *mutationResolver) MetadataGenerate(ctx context.Context, input models.GenerateMetadataInput) (string, error) {
-	manager.GetInstance().Generate(input)
-	return "todo", nil
+	jobID := manager.GetInstance().Generate(input)
+	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) MetadataAutoTag(ctx context.Context, input models.AutoTagMetadataInput) (string, error) {
-	manager.GetInstance().AutoTag(input.Performers, input.Studios, input.Tags)
-	return "todo", nil
+	if input.DryRun != nil && *input.DryRun {
+		return manager.GetInstance().AutoTagDryRun(input)
+	}
+
+	jobID := manager.GetInstance().AutoTag(input)
+	return strconv.Itoa(jobID), nil
 }
 
-func (r *mutationResolver) MetadataClean(ctx context.Context) (string, error) {
-	manager.GetInstance().Clean()
-	return "todo", nil
+func (r *mutationResolver) MetadataClean(ctx context.Context, input *models.CleanMetadataInput) (string, error) {
+	if input == nil {
+		input = &models.CleanMetadataInput{}
+	}
+
+	if input.DryRun != nil && *input.DryRun {
+		return manager.GetInstance().CleanDryRun(*input)
+	}
+
+	jobID, err := manager.GetInstance().Clean(*input)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataChapterMarkers(ctx context.Context, input models.ChapterMarkersMetadataInput) (string, error) {
+	jobID := manager.GetInstance().ChapterMarkers(input)
+	return strconv.Itoa(jobID), nil
 }
 
-func (r *mutationResolver) MigrateHashNaming(ctx context.Context) (string, error) {
-	manager.GetInstance().MigrateHash()
-	return "todo", nil
+func (r *mutationResolver) MetadataInferSceneDates(ctx context.Context) (string, error) {
+	jobID := manager.GetInstance().InferSceneDates()
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataCheckConsistency(ctx context.Context) (string, error) {
+	jobID := manager.GetInstance().CheckConsistency()
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataHash(ctx context.Context) (string, error) {
+	jobID := manager.GetInstance().Hash()
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataCleanGenerated(ctx context.Context, input models.CleanGeneratedInput) (string, error) {
+	if input.DryRun != nil && *input.DryRun {
+		return manager.GetInstance().CleanGeneratedDryRun()
+	}
+
+	jobID := manager.GetInstance().CleanGenerated(input)
+	return strconv.Itoa(jobID), nil
 }
 
-func (r *mutationResolver) JobStatus(ctx context.Context) (*models.MetadataUpdateStatus, error) {
-	status := manager.GetInstance().Status
-	ret := models.MetadataUpdateStatus{
-		Progress: status.Progress,
-		Status:   status.Status.String(),
-		Message:  "",
+func (r *mutationResolver) MetadataSync(ctx context.Context, input models.SyncInput) (string, error) {
+	if input.DryRun != nil && *input.DryRun {
+		return manager.GetInstance().SyncDryRun(input)
 	}
 
-	return &ret, nil
+	jobID := manager.GetInstance().Sync(input)
+	return strconv.Itoa(jobID), nil
 }
 
-func (r *mutationResolver) StopJob(ctx context.Context) (bool, error) {
-	return manager.GetInstance().Status.Stop(), nil
+func (r *mutationResolver) BackupDatabase(ctx context.Context, input models.BackupDatabaseInput) (string, error) {
+	backupPath := ""
+	if input.Path != nil {
+		backupPath = *input.Path
+	}
+
+	jobID := manager.GetInstance().BackupDatabase(backupPath)
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) RecoverDatabase(ctx context.Context, input models.RecoverDatabaseInput) (bool, error) {
+	if !database.NeedsRecovery() {
+		return false, errors.New("database does not need recovery")
+	}
+
+	switch input.Method {
+	case models.DatabaseRecoveryMethodRestoreBackup:
+		backupPath := ""
+		if input.BackupPath != nil {
+			backupPath = *input.BackupPath
+		}
+
+		if err := database.RecoverFromBackup(backupPath); err != nil {
+			return false, err
+		}
+	case models.DatabaseRecoveryMethodReload:
+		lost, err := database.RecoverByReload()
+		if err != nil {
+			return false, err
+		}
+		if len(lost) > 0 {
+			logger.Warnf("Rows of tables %s may have been lost when reloading the database", strings.Join(lost, ", "))
+		}
+	default:
+		return false, errors.New("invalid recovery method")
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) MigrateHashNaming(ctx context.Context, input *models.MigrateHashNamingInput) (string, error) {
+	if input != nil && input.DryRun != nil && *input.DryRun {
+		return manager.GetInstance().MigrateHashDryRun()
+	}
+
+	jobID := manager.GetInstance().MigrateHash()
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) RollbackHashNamingMigration(ctx context.Context, name string) (string, error) {
+	jobID, err := manager.GetInstance().RollbackHashMigration(name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MigrateBlobs(ctx context.Context) (string, error) {
+	jobID := manager.GetInstance().MigrateBlobs()
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) StopJob(ctx context.Context, jobID *string) (bool, error) {
+	queue := manager.GetInstance().JobQueue
+	if jobID == nil {
+		j := queue.Current()
+		if j == nil {
+			return false, nil
+		}
+		return queue.Stop(j.ID), nil
+	}
+
+	id, err := strconv.Atoi(*jobID)
+	if err != nil {
+		return false, err
+	}
+
+	return queue.Stop(id), nil
+}
+
+func (r *mutationResolver) PauseJob(ctx context.Context, jobID *string) (bool, error) {
+	queue := manager.GetInstance().JobQueue
+	j := queue.Current()
+	if jobID != nil {
+		id, err := strconv.Atoi(*jobID)
+		if err != nil {
+			return false, err
+		}
+		j = queue.Find(id)
+	}
+
+	return j != nil && j.Pause(), nil
+}
+
+func (r *mutationResolver) ResumeJob(ctx context.Context, jobID *string) (bool, error) {
+	queue := manager.GetInstance().JobQueue
+	j := queue.Paused()
+	if jobID != nil {
+		id, err := strconv.Atoi(*jobID)
+		if err != nil {
+			return false, err
+		}
+		j = queue.Find(id)
+	}
+
+	return j != nil && j.Resume(), nil
 }
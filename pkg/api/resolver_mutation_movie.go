@@ -3,11 +3,19 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/api/urlbuilders"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
+	"github.com/stashapp/stash/pkg/markdown"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/movie"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -22,7 +30,7 @@ func (r *mutationResolver) MovieCreate(ctx context.Context, input models.MovieCr
 	// HACK: if back image is being set, set the front image to the default.
 	// This is because we can't have a null front image with a non-null back image.
 	if input.FrontImage == nil && input.BackImage != nil {
-		input.FrontImage = &models.DefaultMovieImage
+		frontimageData = getDefaultMovieImage()
 	}
 
 	// Process the base 64 encoded image string
@@ -50,6 +58,10 @@ func (r *mutationResolver) MovieCreate(ctx context.Context, input models.MovieCr
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 	}
 
+	if input.Slug != nil {
+		newMovie.Slug = sql.NullString{String: *input.Slug, Valid: true}
+	}
+
 	if input.Aliases != nil {
 		newMovie.Aliases = sql.NullString{String: *input.Aliases, Valid: true}
 	}
@@ -62,9 +74,8 @@ func (r *mutationResolver) MovieCreate(ctx context.Context, input models.MovieCr
 		newMovie.Date = models.SQLiteDate{String: *input.Date, Valid: true}
 	}
 
-	if input.Rating != nil {
-		rating := int64(*input.Rating)
-		newMovie.Rating = sql.NullInt64{Int64: rating, Valid: true}
+	if rating := getRating100Input(input.Rating, input.Rating100); rating != nil {
+		newMovie.Rating = sql.NullInt64{Int64: int64(*rating), Valid: true}
 	}
 
 	if input.StudioID != nil {
@@ -77,13 +88,27 @@ func (r *mutationResolver) MovieCreate(ctx context.Context, input models.MovieCr
 	}
 
 	if input.Synopsis != nil {
-		newMovie.Synopsis = sql.NullString{String: *input.Synopsis, Valid: true}
+		newMovie.Synopsis = sql.NullString{String: markdown.Sanitize(*input.Synopsis), Valid: true}
 	}
 
-	if input.URL != nil {
-		newMovie.URL = sql.NullString{String: *input.URL, Valid: true}
+	if newMovie.CoverURL, err = getArtworkURLInput(input.CoverURL); err != nil {
+		return nil, err
+	}
+	if newMovie.BackgroundURL, err = getArtworkURLInput(input.BackgroundURL); err != nil {
+		return nil, err
+	}
+
+	customFields, err := getCustomFieldsInput(input.CustomFields)
+	if err != nil {
+		return nil, err
 	}
 
+	urls := input.Urls
+	if urls == nil && input.URL != nil {
+		urls = []string{*input.URL}
+	}
+	urls = cleanMovieURLs(urls)
+
 	// Start the transaction and save the movie
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewMovieQueryBuilder()
@@ -93,6 +118,20 @@ func (r *mutationResolver) MovieCreate(ctx context.Context, input models.MovieCr
 		return nil, err
 	}
 
+	if len(urls) > 0 {
+		if err := qb.UpdateURLs(movie.ID, urls, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if len(customFields) > 0 {
+		if err := qb.UpdateCustomFields(movie.ID, customFields, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// update image table
 	if len(frontimageData) > 0 {
 		if err := qb.UpdateMovieImages(movie.ID, frontimageData, backimageData, tx); err != nil {
@@ -106,9 +145,97 @@ func (r *mutationResolver) MovieCreate(ctx context.Context, input models.MovieCr
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityMovie, event.ActionCreate, movie.ID)
+
 	return movie, nil
 }
 
+func (r *mutationResolver) MovieCreateFromScenes(ctx context.Context, input models.MovieCreateFromScenesInput) (*models.MovieCreateFromScenesResult, error) {
+	if len(input.SceneIds) == 0 {
+		return nil, errors.New("scene_ids must not be empty")
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scenes, err := sqb.FindMany(utils.StringSliceToIntSlice(input.SceneIds))
+	if err != nil {
+		return nil, err
+	}
+
+	movie.SortScenesForMovie(scenes)
+
+	newMovie := movie.NewFromScenes(scenes)
+	if input.Name != nil && *input.Name != "" {
+		newMovie.Name = sql.NullString{String: *input.Name, Valid: true}
+		newMovie.Checksum = utils.MD5FromString(*input.Name)
+	}
+	if !newMovie.Name.Valid {
+		return nil, errors.New("name must be set for scenes in different folders")
+	}
+
+	currentTime := time.Now()
+	newMovie.CreatedAt = models.SQLiteTimestamp{Timestamp: currentTime}
+	newMovie.UpdatedAt = models.SQLiteTimestamp{Timestamp: currentTime}
+
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewMovieQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	created, err := qb.Create(newMovie, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	ret := &models.MovieCreateFromScenesResult{
+		Movie:           created,
+		CoverCandidates: []string{},
+	}
+
+	var sceneIDs []int
+	var frontImage []byte
+	for i, scene := range scenes {
+		sceneIdx := i + 1
+		if _, err := jqb.AddMoviesScene(scene.ID, created.ID, &sceneIdx, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		sceneIDs = append(sceneIDs, scene.ID)
+
+		cover, err := sqb.GetSceneCover(scene.ID, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if len(cover) == 0 {
+			continue
+		}
+
+		if frontImage == nil {
+			frontImage = cover
+		}
+		builder := urlbuilders.NewSceneURLBuilder(baseURL, scene.ID)
+		ret.CoverCandidates = append(ret.CoverCandidates, builder.GetScreenshotURL(scene.UpdatedAt.Timestamp))
+	}
+
+	if frontImage != nil {
+		if err := qb.UpdateMovieImages(created.ID, frontImage, nil, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityMovie, event.ActionCreate, created.ID)
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneIDs...)
+
+	return ret, nil
+}
+
 func (r *mutationResolver) MovieUpdate(ctx context.Context, input models.MovieUpdateInput) (*models.Movie, error) {
 	// Populate movie from the input
 	movieID, _ := strconv.Atoi(input.ID)
@@ -150,21 +277,58 @@ func (r *mutationResolver) MovieUpdate(ctx context.Context, input models.MovieUp
 	updatedMovie.Aliases = translator.nullString(input.Aliases, "aliases")
 	updatedMovie.Duration = translator.nullInt64(input.Duration, "duration")
 	updatedMovie.Date = translator.sqliteDate(input.Date, "date")
-	updatedMovie.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedMovie.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedMovie.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedMovie.Director = translator.nullString(input.Director, "director")
-	updatedMovie.Synopsis = translator.nullString(input.Synopsis, "synopsis")
-	updatedMovie.URL = translator.nullString(input.URL, "url")
+	updatedMovie.Synopsis = translator.markdown(input.Synopsis, "synopsis")
+
+	if updatedMovie.CoverURL, err = translator.artworkURL(input.CoverURL, "cover_url"); err != nil {
+		return nil, err
+	}
+	if updatedMovie.BackgroundURL, err = translator.artworkURL(input.BackgroundURL, "background_url"); err != nil {
+		return nil, err
+	}
+
+	customFields, err := getCustomFieldsInput(input.CustomFields)
+	if err != nil {
+		return nil, err
+	}
 
 	// Start the transaction and save the movie
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewMovieQueryBuilder()
+	if input.Slug != nil {
+		if err := qb.UpdateSlug(movieID, *input.Slug, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if input.Pinned != nil {
+		if err := qb.SetPinned(movieID, *input.Pinned, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
 	movie, err := qb.Update(updatedMovie, tx)
 	if err != nil {
 		_ = tx.Rollback()
 		return nil, err
 	}
 
+	if translator.hasField("custom_fields") {
+		if err := qb.UpdateCustomFields(movie.ID, customFields, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := updateMovieURLs(movie.ID, input, translator, tx); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
 	// update image table
 	if frontImageIncluded || backImageIncluded {
 		if !frontImageIncluded {
@@ -192,7 +356,7 @@ func (r *mutationResolver) MovieUpdate(ctx context.Context, input models.MovieUp
 			// HACK - if front image is null and back image is not null, then set the front image
 			// to the default image since we can't have a null front image and a non-null back image
 			if frontimageData == nil && backimageData != nil {
-				_, frontimageData, _ = utils.ProcessBase64Image(models.DefaultMovieImage)
+				frontimageData = getDefaultMovieImage()
 			}
 
 			if err := qb.UpdateMovieImages(movie.ID, frontimageData, backimageData, tx); err != nil {
@@ -207,9 +371,218 @@ func (r *mutationResolver) MovieUpdate(ctx context.Context, input models.MovieUp
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityMovie, event.ActionUpdate, movie.ID)
+
 	return movie, nil
 }
 
+// updateMovieURLs replaces the URLs of the movie if urls is included in the
+// input. Otherwise, if the legacy url is included, only the first URL is
+// replaced, or removed if url is null or empty.
+func updateMovieURLs(movieID int, input models.MovieUpdateInput, translator changesetTranslator, tx *sqlx.Tx) error {
+	qb := models.NewMovieQueryBuilder()
+
+	if translator.hasField("urls") {
+		return qb.UpdateURLs(movieID, cleanMovieURLs(input.Urls), tx)
+	}
+
+	if !translator.hasField("url") {
+		return nil
+	}
+
+	urls, err := qb.GetURLs(movieID, tx)
+	if err != nil {
+		return err
+	}
+
+	url := ""
+	if input.URL != nil {
+		url = strings.TrimSpace(*input.URL)
+	}
+
+	switch {
+	case url == "" && len(urls) > 0:
+		urls = urls[1:]
+	case url == "":
+	case len(urls) > 0:
+		urls[0] = url
+	default:
+		urls = []string{url}
+	}
+
+	return qb.UpdateURLs(movieID, urls, tx)
+}
+
+// cleanMovieURLs trims the URLs and removes empty and duplicate values.
+func cleanMovieURLs(urls []string) []string {
+	var ret []string
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		url = strings.TrimSpace(url)
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		ret = append(ret, url)
+	}
+
+	return ret
+}
+
+func (r *mutationResolver) BulkMovieUpdate(ctx context.Context, input models.BulkMovieUpdateInput) ([]*models.Movie, error) {
+	updatedTime := time.Now()
+
+	translator := changesetTranslator{
+		inputMap: getUpdateInputMap(ctx),
+	}
+
+	updatedMovie := models.MoviePartial{
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: updatedTime},
+	}
+
+	updatedMovie.Rating = translator.rating(input.Rating, input.Rating100)
+	updatedMovie.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
+	updatedMovie.Director = translator.nullString(input.Director, "director")
+	updatedMovie.Date = translator.sqliteDate(input.Date, "date")
+
+	movieIDs, err := utils.ParseIntSlice(input.Ids)
+	if err != nil {
+		return nil, fmt.Errorf("invalid movie id: %s", err.Error())
+	}
+
+	// Start the transaction and save the movies
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewMovieQueryBuilder()
+
+	ret := []*models.Movie{}
+
+	for _, movieID := range movieIDs {
+		updatedMovie.ID = movieID
+
+		movie, err := qb.Update(updatedMovie, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if movie == nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("movie with id %d not found", movieID)
+		}
+
+		ret = append(ret, movie)
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityMovie, event.ActionUpdate, movieIDs...)
+
+	return ret, nil
+}
+
+func (r *mutationResolver) MovieReorderScenes(ctx context.Context, input models.MovieReorderScenesInput) (*models.Movie, error) {
+	movieID, _ := strconv.Atoi(input.ID)
+	sceneIDs := utils.StringSliceToIntSlice(input.SceneIds)
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	jqb := models.NewJoinsQueryBuilder()
+
+	for i, sceneID := range sceneIDs {
+		sceneIdx := sql.NullInt64{Int64: int64(i + 1), Valid: true}
+		found, err := jqb.UpdateMovieSceneIndex(movieID, sceneID, sceneIdx, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if !found {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("scene %d is not in movie %d", sceneID, movieID)
+		}
+	}
+
+	qb := models.NewMovieQueryBuilder()
+	movie, err := qb.Find(movieID, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityMovie, event.ActionUpdate, movieID)
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneIDs...)
+
+	return movie, nil
+}
+
+func (r *mutationResolver) MoviesFixSceneIndexes(ctx context.Context, ids []string) ([]*models.Movie, error) {
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewMovieQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	sqb := models.NewSceneQueryBuilder()
+
+	ret := []*models.Movie{}
+	var sceneIDs []int
+	for _, movieID := range utils.StringSliceToIntSlice(ids) {
+		m, err := qb.Find(movieID, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		if m == nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("movie with id %d not found", movieID)
+		}
+
+		joins, err := jqb.GetMovieScenes(movieID, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		var scenes []*models.Scene
+		for _, j := range joins {
+			scene, err := sqb.Find(j.SceneID)
+			if err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+			if scene != nil {
+				scenes = append(scenes, scene)
+			}
+		}
+
+		movie.SortScenesForIndexes(scenes)
+
+		for i, scene := range scenes {
+			sceneIdx := sql.NullInt64{Int64: int64(i + 1), Valid: true}
+			if _, err := jqb.UpdateMovieSceneIndex(movieID, scene.ID, sceneIdx, tx); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+			sceneIDs = append(sceneIDs, scene.ID)
+		}
+
+		ret = append(ret, m)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityMovie, event.ActionUpdate, utils.StringSliceToIntSlice(ids)...)
+	if len(sceneIDs) > 0 {
+		publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneIDs...)
+	}
+
+	return ret, nil
+}
+
 func (r *mutationResolver) MovieDestroy(ctx context.Context, input models.MovieDestroyInput) (bool, error) {
 	qb := models.NewMovieQueryBuilder()
 	tx := database.DB.MustBeginTx(ctx, nil)
@@ -220,6 +593,8 @@ func (r *mutationResolver) MovieDestroy(ctx context.Context, input models.MovieD
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	id, _ := strconv.Atoi(input.ID)
+	publishEvent(ctx, event.EntityMovie, event.ActionDestroy, id)
 	return true, nil
 }
 
@@ -235,5 +610,6 @@ func (r *mutationResolver) MoviesDestroy(ctx context.Context, ids []string) (boo
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	publishEvent(ctx, event.EntityMovie, event.ActionDestroy, utils.StringSliceToIntSlice(ids)...)
 	return true, nil
 }
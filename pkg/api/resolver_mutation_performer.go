@@ -6,8 +6,11 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/performer"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -34,8 +37,8 @@ func (r *mutationResolver) PerformerCreate(ctx context.Context, input models.Per
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 	}
 	newPerformer.Name = sql.NullString{String: input.Name, Valid: true}
-	if input.URL != nil {
-		newPerformer.URL = sql.NullString{String: *input.URL, Valid: true}
+	if input.Slug != nil {
+		newPerformer.Slug = sql.NullString{String: *input.Slug, Valid: true}
 	}
 	if input.Gender != nil {
 		newPerformer.Gender = sql.NullString{String: input.Gender.String(), Valid: true}
@@ -58,6 +61,9 @@ func (r *mutationResolver) PerformerCreate(ctx context.Context, input models.Per
 	if input.Measurements != nil {
 		newPerformer.Measurements = sql.NullString{String: *input.Measurements, Valid: true}
 	}
+	if input.Weight != nil {
+		newPerformer.Weight = sql.NullInt64{Int64: int64(*input.Weight), Valid: true}
+	}
 	if input.FakeTits != nil {
 		newPerformer.FakeTits = sql.NullString{String: *input.FakeTits, Valid: true}
 	}
@@ -73,18 +79,17 @@ func (r *mutationResolver) PerformerCreate(ctx context.Context, input models.Per
 	if input.Aliases != nil {
 		newPerformer.Aliases = sql.NullString{String: *input.Aliases, Valid: true}
 	}
-	if input.Twitter != nil {
-		newPerformer.Twitter = sql.NullString{String: *input.Twitter, Valid: true}
-	}
-	if input.Instagram != nil {
-		newPerformer.Instagram = sql.NullString{String: *input.Instagram, Valid: true}
-	}
 	if input.Favorite != nil {
 		newPerformer.Favorite = sql.NullBool{Bool: *input.Favorite, Valid: true}
 	} else {
 		newPerformer.Favorite = sql.NullBool{Bool: false, Valid: true}
 	}
 
+	urls := performerURLsFromInput(input.Urls)
+	if input.Urls == nil {
+		urls = legacyPerformerURLs(input.URL, input.Twitter, input.Instagram)
+	}
+
 	// Start the transaction and save the performer
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewPerformerQueryBuilder()
@@ -96,6 +101,11 @@ func (r *mutationResolver) PerformerCreate(ctx context.Context, input models.Per
 		return nil, err
 	}
 
+	if err := qb.UpdateURLs(performer.ID, urls, tx); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
 	// update image table
 	if len(imageData) > 0 {
 		if err := qb.UpdatePerformerImage(performer.ID, imageData, tx); err != nil {
@@ -124,6 +134,8 @@ func (r *mutationResolver) PerformerCreate(ctx context.Context, input models.Per
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityPerformer, event.ActionCreate, performer.ID)
+
 	return performer, nil
 }
 
@@ -157,8 +169,6 @@ func (r *mutationResolver) PerformerUpdate(ctx context.Context, input models.Per
 		updatedPerformer.Checksum = &checksum
 	}
 
-	updatedPerformer.URL = translator.nullString(input.URL, "url")
-
 	if translator.hasField("gender") {
 		if input.Gender != nil {
 			updatedPerformer.Gender = &sql.NullString{String: input.Gender.String(), Valid: true}
@@ -171,6 +181,7 @@ func (r *mutationResolver) PerformerUpdate(ctx context.Context, input models.Per
 	updatedPerformer.Country = translator.nullString(input.Country, "country")
 	updatedPerformer.EyeColor = translator.nullString(input.EyeColor, "eye_color")
 	updatedPerformer.Measurements = translator.nullString(input.Measurements, "measurements")
+	updatedPerformer.Weight = translator.nullInt64(input.Weight, "weight")
 	updatedPerformer.Height = translator.nullString(input.Height, "height")
 	updatedPerformer.Ethnicity = translator.nullString(input.Ethnicity, "ethnicity")
 	updatedPerformer.FakeTits = translator.nullString(input.FakeTits, "fake_tits")
@@ -178,8 +189,6 @@ func (r *mutationResolver) PerformerUpdate(ctx context.Context, input models.Per
 	updatedPerformer.Tattoos = translator.nullString(input.Tattoos, "tattoos")
 	updatedPerformer.Piercings = translator.nullString(input.Piercings, "piercings")
 	updatedPerformer.Aliases = translator.nullString(input.Aliases, "aliases")
-	updatedPerformer.Twitter = translator.nullString(input.Twitter, "twitter")
-	updatedPerformer.Instagram = translator.nullString(input.Instagram, "instagram")
 	updatedPerformer.Favorite = translator.nullBool(input.Favorite, "favorite")
 
 	// Start the transaction and save the performer
@@ -187,12 +196,38 @@ func (r *mutationResolver) PerformerUpdate(ctx context.Context, input models.Per
 	qb := models.NewPerformerQueryBuilder()
 	jqb := models.NewJoinsQueryBuilder()
 
+	if input.Slug != nil {
+		if err := qb.UpdateSlug(performerID, *input.Slug, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if input.Pinned != nil {
+		if err := qb.SetPinned(performerID, *input.Pinned, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if minConfidence := translator.nullFloat64(input.AutoTagMinConfidence, "auto_tag_min_confidence"); minConfidence != nil {
+		if err := qb.UpdateAutoTagMinConfidence(performerID, *minConfidence, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	performer, err := qb.Update(updatedPerformer, tx)
 	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
+	if err := updatePerformerURLs(performer.ID, input, translator, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// update image table
 	if len(imageData) > 0 {
 		if err := qb.UpdatePerformerImage(performer.ID, imageData, tx); err != nil {
@@ -227,9 +262,83 @@ func (r *mutationResolver) PerformerUpdate(ctx context.Context, input models.Per
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityPerformer, event.ActionUpdate, performer.ID)
+
 	return performer, nil
 }
 
+// updatePerformerURLs replaces the links of the performer if urls is
+// included in the input. Otherwise, each of the legacy url, twitter and
+// instagram fields included in the input replaces the first link of its kind,
+// or removes it if the field is null or empty.
+func updatePerformerURLs(performerID int, input models.PerformerUpdateInput, translator changesetTranslator, tx *sqlx.Tx) error {
+	qb := models.NewPerformerQueryBuilder()
+
+	if translator.hasField("urls") {
+		return qb.UpdateURLs(performerID, performerURLsFromInput(input.Urls), tx)
+	}
+
+	legacy := []struct {
+		field string
+		kind  string
+		value *string
+	}{
+		{"url", performer.LegacyWebsite, input.URL},
+		{"twitter", performer.LegacyTwitter, input.Twitter},
+		{"instagram", performer.LegacyInstagram, input.Instagram},
+	}
+
+	urls, err := qb.GetURLs(performerID, tx)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, l := range legacy {
+		if translator.hasField(l.field) {
+			value := ""
+			if l.value != nil {
+				value = *l.value
+			}
+			urls = performer.SetLegacyURL(urls, l.kind, value)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	return qb.UpdateURLs(performerID, urls, tx)
+}
+
+// performerURLsFromInput returns the links of the input, with empty and
+// duplicate URLs removed.
+func performerURLsFromInput(input []*models.PerformerURLInput) []models.PerformerURL {
+	var urls []models.PerformerURL
+	for _, url := range input {
+		site := ""
+		if url.Site != nil {
+			site = *url.Site
+		}
+		urls = append(urls, models.PerformerURL{Site: site, URL: url.URL})
+	}
+
+	return performer.CleanURLs(urls)
+}
+
+// legacyPerformerURLs returns the links of the legacy url, twitter and
+// instagram fields of the input.
+func legacyPerformerURLs(url *string, twitter *string, instagram *string) []models.PerformerURL {
+	var values [3]string
+	for i, value := range []*string{url, twitter, instagram} {
+		if value != nil {
+			values[i] = *value
+		}
+	}
+
+	return performer.LegacyURLs(values[0], values[1], values[2])
+}
+
 func (r *mutationResolver) PerformerDestroy(ctx context.Context, input models.PerformerDestroyInput) (bool, error) {
 	qb := models.NewPerformerQueryBuilder()
 	tx := database.DB.MustBeginTx(ctx, nil)
@@ -240,6 +349,8 @@ func (r *mutationResolver) PerformerDestroy(ctx context.Context, input models.Pe
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	id, _ := strconv.Atoi(input.ID)
+	publishEvent(ctx, event.EntityPerformer, event.ActionDestroy, id)
 	return true, nil
 }
 
@@ -255,5 +366,6 @@ func (r *mutationResolver) PerformersDestroy(ctx context.Context, ids []string)
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	publishEvent(ctx, event.EntityPerformer, event.ActionDestroy, utils.StringSliceToIntSlice(ids)...)
 	return true, nil
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
@@ -34,8 +35,8 @@ func (r *mutationResolver) RunPluginTask(ctx context.Context, pluginID string, t
 		serverConnection.Scheme = "https"
 	}
 
-	manager.GetInstance().RunPluginTask(pluginID, taskName, args, serverConnection)
-	return "todo", nil
+	jobID := manager.GetInstance().RunPluginTask(pluginID, taskName, args, serverConnection)
+	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) ReloadPlugins(ctx context.Context) (bool, error) {
@@ -3,12 +3,18 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
@@ -34,6 +40,8 @@ func (r *mutationResolver) SceneUpdate(ctx context.Context, input models.SceneUp
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, ret.ID)
+
 	return ret, nil
 }
 
@@ -64,6 +72,12 @@ func (r *mutationResolver) ScenesUpdate(ctx context.Context, input []*models.Sce
 		return nil, err
 	}
 
+	var sceneIDs []int
+	for _, scene := range ret {
+		sceneIDs = append(sceneIDs, scene.ID)
+	}
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneIDs...)
+
 	return ret, nil
 }
 
@@ -80,15 +94,22 @@ func (r *mutationResolver) sceneUpdate(input models.SceneUpdateInput, translator
 	}
 
 	updatedScene.Title = translator.nullString(input.Title, "title")
-	updatedScene.Details = translator.nullString(input.Details, "details")
+	updatedScene.Details = translator.markdown(input.Details, "details")
 	updatedScene.URL = translator.nullString(input.URL, "url")
 	updatedScene.Date = translator.sqliteDate(input.Date, "date")
-	updatedScene.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedScene.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedScene.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedScene.Organized = input.Organized
 
+	var err error
+	if updatedScene.CoverURL, err = translator.artworkURL(input.CoverURL, "cover_url"); err != nil {
+		return nil, err
+	}
+	if updatedScene.BackgroundURL, err = translator.artworkURL(input.BackgroundURL, "background_url"); err != nil {
+		return nil, err
+	}
+
 	if input.CoverImage != nil && *input.CoverImage != "" {
-		var err error
 		_, coverImageData, err = utils.ProcessBase64Image(*input.CoverImage)
 		if err != nil {
 			return nil, err
@@ -217,9 +238,44 @@ func (r *mutationResolver) sceneUpdate(input models.SceneUpdateInput, translator
 		}
 	}
 
+	recordAppliedScrapeFields(models.ScrapeHistoryEntityScene, sceneID, getSceneScrapedValues(input, translator), tx)
+
 	return scene, nil
 }
 
+// getSceneScrapedValues returns the values saved by the update, keyed by the
+// name of the matching scraped scene field.
+func getSceneScrapedValues(input models.SceneUpdateInput, translator changesetTranslator) map[string]interface{} {
+	ret := make(map[string]interface{})
+	setString := func(field string, value *string) {
+		if value != nil {
+			ret[field] = *value
+		}
+	}
+
+	setString("title", input.Title)
+	setString("details", input.Details)
+	setString("url", input.URL)
+	setString("date", input.Date)
+	setString("studio", input.StudioID)
+
+	if translator.hasField("performer_ids") {
+		ret["performers"] = input.PerformerIds
+	}
+	if translator.hasField("tag_ids") {
+		ret["tags"] = input.TagIds
+	}
+	if translator.hasField("movies") {
+		var movieIDs []string
+		for _, movie := range input.Movies {
+			movieIDs = append(movieIDs, movie.MovieID)
+		}
+		ret["movies"] = movieIDs
+	}
+
+	return ret
+}
+
 func (r *mutationResolver) BulkSceneUpdate(ctx context.Context, input models.BulkSceneUpdateInput) ([]*models.Scene, error) {
 	// Populate scene from the input
 	updatedTime := time.Now()
@@ -238,10 +294,10 @@ func (r *mutationResolver) BulkSceneUpdate(ctx context.Context, input models.Bul
 	}
 
 	updatedScene.Title = translator.nullString(input.Title, "title")
-	updatedScene.Details = translator.nullString(input.Details, "details")
+	updatedScene.Details = translator.markdown(input.Details, "details")
 	updatedScene.URL = translator.nullString(input.URL, "url")
 	updatedScene.Date = translator.sqliteDate(input.Date, "date")
-	updatedScene.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedScene.Rating = translator.rating(input.Rating, input.Rating100)
 	updatedScene.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedScene.Organized = input.Organized
 
@@ -328,6 +384,8 @@ func (r *mutationResolver) BulkSceneUpdate(ctx context.Context, input models.Bul
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, utils.StringSliceToIntSlice(input.Ids)...)
+
 	return ret, nil
 }
 
@@ -414,17 +472,11 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 		return false, err
 	}
 
-	// if delete generated is true, then delete the generated files
-	// for the scene
-	if input.DeleteGenerated != nil && *input.DeleteGenerated {
-		manager.DeleteGeneratedSceneFiles(scene, config.GetVideoFileNamingAlgorithm())
-	}
+	publishEvent(ctx, event.EntityScene, event.ActionDestroy, sceneID)
 
-	// if delete file is true, then delete the file as well
-	// if it fails, just log a message
-	if input.DeleteFile != nil && *input.DeleteFile {
-		manager.DeleteSceneFile(scene)
-	}
+	deleteGenerated := input.DeleteGenerated != nil && *input.DeleteGenerated
+	deleteFile := input.DeleteFile != nil && *input.DeleteFile
+	deleteSceneFiles(ctx, scene, deleteGenerated, deleteFile, config.GetVideoFileNamingAlgorithm())
 
 	return true, nil
 }
@@ -453,22 +505,34 @@ func (r *mutationResolver) ScenesDestroy(ctx context.Context, input models.Scene
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntityScene, event.ActionDestroy, utils.StringSliceToIntSlice(input.Ids)...)
+
+	deleteGenerated := input.DeleteGenerated != nil && *input.DeleteGenerated
+	deleteFile := input.DeleteFile != nil && *input.DeleteFile
 	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
 	for _, scene := range scenes {
-		// if delete generated is true, then delete the generated files
-		// for the scene
-		if input.DeleteGenerated != nil && *input.DeleteGenerated {
-			manager.DeleteGeneratedSceneFiles(scene, fileNamingAlgo)
-		}
+		deleteSceneFiles(ctx, scene, deleteGenerated, deleteFile, fileNamingAlgo)
+	}
 
-		// if delete file is true, then delete the file as well
-		// if it fails, just log a message
-		if input.DeleteFile != nil && *input.DeleteFile {
-			manager.DeleteSceneFile(scene)
+	return true, nil
+}
+
+// deleteSceneFiles deletes the generated files and the file of a scene once
+// the transaction deleting it is committed. Since the scene is deleted,
+// failures are added to the errors of the response instead of failing the
+// mutation.
+func deleteSceneFiles(ctx context.Context, scene *models.Scene, deleteGenerated bool, deleteFile bool, fileNamingAlgo models.HashAlgorithm) {
+	if deleteGenerated {
+		if err := manager.DeleteGeneratedSceneFiles(scene, fileNamingAlgo); err != nil {
+			graphql.AddErrorf(ctx, "scene %d was deleted, but not all of its generated files: %s", scene.ID, err.Error())
 		}
 	}
 
-	return true, nil
+	if deleteFile {
+		if err := manager.DeleteSceneFile(scene); err != nil {
+			graphql.AddErrorf(ctx, "scene %d was deleted, but not its file: %s", scene.ID, err.Error())
+		}
+	}
 }
 
 func (r *mutationResolver) SceneMarkerCreate(ctx context.Context, input models.SceneMarkerCreateInput) (*models.SceneMarker, error) {
@@ -487,6 +551,139 @@ func (r *mutationResolver) SceneMarkerCreate(ctx context.Context, input models.S
 	return changeMarker(ctx, create, newSceneMarker, input.TagIds)
 }
 
+func (r *mutationResolver) BulkSceneMarkerCreate(ctx context.Context, input models.BulkSceneMarkerCreateInput) ([]*models.SceneMarker, error) {
+	sceneID, err := strconv.Atoi(input.SceneID)
+	if err != nil {
+		return nil, err
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scene, err := sqb.Find(sceneID)
+	if err != nil {
+		return nil, err
+	}
+	if scene == nil {
+		return nil, fmt.Errorf("scene with id %d not found", sceneID)
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewSceneMarkerQueryBuilder()
+	tqb := models.NewTagQueryBuilder()
+
+	// look up each tag name only once
+	tagIDs := make(map[string]int)
+	currentTime := time.Now()
+
+	ret := []*models.SceneMarker{}
+	var ids []int
+	for i, m := range input.Markers {
+		if m.Seconds < 0 || (scene.Duration.Valid && m.Seconds > scene.Duration.Float64) {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("marker %d: seconds %v is outside of the scene", i, m.Seconds)
+		}
+
+		tagName := strings.TrimSpace(m.Tag)
+		if tagName == "" {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("marker %d: tag must not be empty", i)
+		}
+
+		tagID, found := tagIDs[strings.ToLower(tagName)]
+		if !found {
+			const nocase = true
+			tag, err := tqb.FindByName(tagName, tx, nocase)
+			if err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+			if tag == nil {
+				_ = tx.Rollback()
+				return nil, fmt.Errorf("marker %d: tag %s not found", i, tagName)
+			}
+			tagID = tag.ID
+			tagIDs[strings.ToLower(tagName)] = tagID
+		}
+
+		marker, err := qb.Create(models.SceneMarker{
+			Title:        strings.TrimSpace(m.Title),
+			Seconds:      m.Seconds,
+			PrimaryTagID: tagID,
+			SceneID:      sql.NullInt64{Int64: int64(sceneID), Valid: true},
+			CreatedAt:    models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:    models.SQLiteTimestamp{Timestamp: currentTime},
+		}, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		ret = append(ret, marker)
+		ids = append(ids, marker.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		publishEvent(ctx, event.EntitySceneMarker, event.ActionCreate, ids...)
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) SceneChaptersToMarkers(ctx context.Context, input models.SceneChaptersToMarkersInput) ([]*models.SceneMarker, error) {
+	sceneID, err := strconv.Atoi(input.SceneID)
+	if err != nil {
+		return nil, err
+	}
+	primaryTagID, err := strconv.Atoi(input.PrimaryTagID)
+	if err != nil {
+		return nil, err
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scene, err := sqb.Find(sceneID)
+	if err != nil {
+		return nil, err
+	}
+	if scene == nil {
+		return nil, fmt.Errorf("scene with id %d not found", sceneID)
+	}
+
+	var chapters []*models.SceneChapter
+	if input.Reread != nil && *input.Reread {
+		chapters, err = manager.ReadSceneChapters(scene)
+	} else {
+		chapters, err = sqb.GetChapters(sceneID, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	ret, err := manager.CreateChapterMarkers(sceneID, chapters, primaryTagID, utils.StringSliceToIntSlice(input.TagIds), tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	var ids []int
+	for _, marker := range ret {
+		ids = append(ids, marker.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		publishEvent(ctx, event.EntitySceneMarker, event.ActionCreate, ids...)
+	}
+
+	return ret, nil
+}
+
 func (r *mutationResolver) SceneMarkerUpdate(ctx context.Context, input models.SceneMarkerUpdateInput) (*models.SceneMarker, error) {
 	// Populate scene marker from the input
 	sceneMarkerID, _ := strconv.Atoi(input.ID)
@@ -523,6 +720,8 @@ func (r *mutationResolver) SceneMarkerDestroy(ctx context.Context, id string) (b
 		return false, err
 	}
 
+	publishEvent(ctx, event.EntitySceneMarker, event.ActionDestroy, markerID)
+
 	// delete the preview for the marker
 	sqb := models.NewSceneQueryBuilder()
 	scene, _ := sqb.Find(int(marker.SceneID.Int64))
@@ -535,6 +734,125 @@ func (r *mutationResolver) SceneMarkerDestroy(ctx context.Context, id string) (b
 	return true, nil
 }
 
+func (r *mutationResolver) SceneMarkersShift(ctx context.Context, input models.SceneMarkersShiftInput) ([]*models.SceneMarker, error) {
+	sceneID, err := strconv.Atoi(input.SceneID)
+	if err != nil {
+		return nil, err
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scene, err := sqb.Find(sceneID)
+	if err != nil {
+		return nil, err
+	}
+	if scene == nil {
+		return nil, fmt.Errorf("scene with id %d not found", sceneID)
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewSceneMarkerQueryBuilder()
+
+	markers, err := qb.FindBySceneID(sceneID, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	updatedTime := models.SQLiteTimestamp{Timestamp: time.Now()}
+
+	ret := []*models.SceneMarker{}
+	var ids []int
+	for _, m := range markers {
+		seconds := m.Seconds + input.Offset
+		if seconds < 0 || (scene.Duration.Valid && seconds > scene.Duration.Float64) {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("marker %d: seconds %v is outside of the scene", m.ID, seconds)
+		}
+
+		updated := *m
+		updated.Seconds = seconds
+		updated.UpdatedAt = updatedTime
+		marker, err := qb.Update(updated, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		ret = append(ret, marker)
+		ids = append(ids, marker.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		publishEvent(ctx, event.EntitySceneMarker, event.ActionUpdate, ids...)
+	}
+
+	// the previews are named after the positions of the markers
+	if input.Offset != 0 {
+		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+		for _, m := range markers {
+			manager.DeleteSceneMarkerFiles(scene, int(m.Seconds), fileNamingAlgo)
+		}
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) BulkSceneMarkerDestroy(ctx context.Context, input models.BulkSceneMarkerDestroyInput) (int, error) {
+	if len(input.TagIds) == 0 {
+		return 0, errors.New("tag_ids must not be empty")
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewSceneMarkerQueryBuilder()
+
+	markers, err := qb.FindByTagIDs(utils.StringSliceToIntSlice(input.TagIds), utils.StringSliceToIntSlice(input.SceneIds), tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	var ids []int
+	for _, m := range markers {
+		if err := qb.Destroy(strconv.Itoa(m.ID), tx); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		ids = append(ids, m.ID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	publishEvent(ctx, event.EntitySceneMarker, event.ActionDestroy, ids...)
+
+	// delete the previews of the markers
+	sqb := models.NewSceneQueryBuilder()
+	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+	scenes := make(map[int64]*models.Scene)
+	for _, m := range markers {
+		scene, found := scenes[m.SceneID.Int64]
+		if !found {
+			scene, _ = sqb.Find(int(m.SceneID.Int64))
+			scenes[m.SceneID.Int64] = scene
+		}
+
+		if scene != nil {
+			manager.DeleteSceneMarkerFiles(scene, int(m.Seconds), fileNamingAlgo)
+		}
+	}
+
+	return len(ids), nil
+}
+
 func changeMarker(ctx context.Context, changeType int, changedMarker models.SceneMarker, tagIds []string) (*models.SceneMarker, error) {
 	// Start the transaction and save the scene marker
 	tx := database.DB.MustBeginTx(ctx, nil)
@@ -590,6 +908,12 @@ func changeMarker(ctx context.Context, changeType int, changedMarker models.Scen
 		return nil, err
 	}
 
+	action := event.ActionUpdate
+	if changeType == create {
+		action = event.ActionCreate
+	}
+	publishEvent(ctx, event.EntitySceneMarker, action, sceneMarker.ID)
+
 	// remove the marker preview if the timestamp was changed
 	if existingMarker != nil && existingMarker.Seconds != changedMarker.Seconds {
 		sqb := models.NewSceneQueryBuilder()
@@ -622,6 +946,8 @@ func (r *mutationResolver) SceneIncrementO(ctx context.Context, id string) (int,
 		return 0, err
 	}
 
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneID)
+
 	return newVal, nil
 }
 
@@ -642,6 +968,8 @@ func (r *mutationResolver) SceneDecrementO(ctx context.Context, id string) (int,
 		return 0, err
 	}
 
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneID)
+
 	return newVal, nil
 }
 
@@ -662,6 +990,8 @@ func (r *mutationResolver) SceneResetO(ctx context.Context, id string) (int, err
 		return 0, err
 	}
 
+	publishEvent(ctx, event.EntityScene, event.ActionUpdate, sceneID)
+
 	return newVal, nil
 }
 
@@ -674,3 +1004,230 @@ func (r *mutationResolver) SceneGenerateScreenshot(ctx context.Context, id strin
 
 	return "todo", nil
 }
+
+func (r *mutationResolver) SceneFilenameUpdate(ctx context.Context, input models.SceneFilenameUpdateInput) ([]*models.SceneFilenameUpdate, error) {
+	template := config.GetSceneFilenameTemplate()
+	if input.Template != nil {
+		template = *input.Template
+	}
+	if template == "" {
+		return nil, fmt.Errorf("scene filename template is not set")
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	var ret []*models.SceneFilenameUpdate
+	sceneIDsByPath := make(map[string]int)
+	for _, id := range input.Ids {
+		sceneID, _ := strconv.Atoi(id)
+		scene, err := qb.Find(sceneID)
+		if err != nil {
+			return nil, err
+		}
+		if scene == nil {
+			return nil, fmt.Errorf("scene with id %s not found", id)
+		}
+
+		newPath, err := manager.GetSceneFilename(scene, template)
+		if err != nil {
+			return nil, err
+		}
+
+		if otherID, found := sceneIDsByPath[newPath]; found {
+			return nil, fmt.Errorf("scenes %d and %d would both be moved to %s", otherID, sceneID, newPath)
+		}
+		sceneIDsByPath[newPath] = sceneID
+
+		ret = append(ret, &models.SceneFilenameUpdate{
+			Scene:   scene,
+			OldPath: scene.Path,
+			NewPath: newPath,
+		})
+	}
+
+	if input.DryRun != nil && *input.DryRun {
+		return ret, nil
+	}
+
+	// each scene is moved separately, so that the failure to move one scene
+	// is reported with it without undoing the moves of the others
+	var movedIDs []int
+	for _, u := range ret {
+		scene, err := manager.MoveSceneFile(ctx, u.Scene, u.NewPath)
+		if err != nil {
+			logger.Errorf("Error moving the file of scene %d: %s", u.Scene.ID, err.Error())
+			errStr := err.Error()
+			u.Error = &errStr
+			continue
+		}
+
+		u.Scene = scene
+		movedIDs = append(movedIDs, scene.ID)
+	}
+
+	if len(movedIDs) > 0 {
+		publishEvent(ctx, event.EntityScene, event.ActionUpdate, movedIDs...)
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) SceneDateSuggestionsApply(ctx context.Context, input models.SceneDateSuggestionsInput) ([]*models.Scene, error) {
+	suggestions, err := getSceneDateSuggestions()
+	if err != nil {
+		return nil, err
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	updatedTime := time.Now()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	var ret []*models.Scene
+	for _, id := range input.SceneIds {
+		sceneID, _ := strconv.Atoi(id)
+		suggestion := suggestions[sceneID]
+		if suggestion == nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("scene %s has no date suggestion", id)
+		}
+
+		scene, err := qb.Update(models.ScenePartial{
+			ID:        sceneID,
+			Date:      &models.SQLiteDate{String: suggestion.Date, Valid: true},
+			UpdatedAt: &models.SQLiteTimestamp{Timestamp: updatedTime},
+		}, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		recordAppliedScrapeFields(models.ScrapeHistoryEntityScene, sceneID, map[string]interface{}{"date": suggestion.Date}, tx)
+		ret = append(ret, scene)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, scene := range ret {
+		ids = append(ids, scene.ID)
+	}
+	if len(ids) > 0 {
+		publishEvent(ctx, event.EntityScene, event.ActionUpdate, ids...)
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) SceneParserApply(ctx context.Context, input models.SceneParserApplyInput) ([]*models.Scene, error) {
+	results, _, err := manager.NewSceneFilenameParser(input.Filter, *input.Config).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var sceneIDs map[string]bool
+	if input.SceneIds != nil {
+		sceneIDs = make(map[string]bool)
+		for _, id := range input.SceneIds {
+			sceneIDs[id] = true
+		}
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	var ret []*models.Scene
+	for _, result := range results {
+		if sceneIDs != nil && !sceneIDs[strconv.Itoa(result.Scene.ID)] {
+			continue
+		}
+
+		updateInput, inputMap := sceneParserUpdateInput(result)
+		if len(inputMap) == 0 {
+			// nothing was parsed for the scene
+			continue
+		}
+
+		scene, err := r.sceneUpdate(updateInput, changesetTranslator{inputMap: inputMap}, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		ret = append(ret, scene)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, scene := range ret {
+		ids = append(ids, scene.ID)
+	}
+	if len(ids) > 0 {
+		publishEvent(ctx, event.EntityScene, event.ActionUpdate, ids...)
+	}
+
+	return ret, nil
+}
+
+// sceneParserUpdateInput returns the update of the scene to the values of the
+// parser result, and the input map of the fields which were parsed.
+func sceneParserUpdateInput(result *models.SceneParserResult) (models.SceneUpdateInput, map[string]interface{}) {
+	input := models.SceneUpdateInput{
+		ID:           strconv.Itoa(result.Scene.ID),
+		Title:        result.Title,
+		Date:         result.Date,
+		Rating:       result.Rating,
+		StudioID:     result.StudioID,
+		PerformerIds: result.PerformerIds,
+		TagIds:       result.TagIds,
+	}
+
+	inputMap := make(map[string]interface{})
+	if result.Title != nil {
+		inputMap["title"] = *result.Title
+	}
+	if result.Date != nil {
+		inputMap["date"] = *result.Date
+	}
+	if result.Rating != nil {
+		inputMap["rating"] = *result.Rating
+	}
+	if result.StudioID != nil {
+		inputMap["studio_id"] = *result.StudioID
+	}
+	if len(result.PerformerIds) > 0 {
+		inputMap["performer_ids"] = result.PerformerIds
+	}
+	if len(result.TagIds) > 0 {
+		inputMap["tag_ids"] = result.TagIds
+	}
+	if len(result.Movies) > 0 {
+		for _, movie := range result.Movies {
+			input.Movies = append(input.Movies, &models.SceneMovieInput{MovieID: movie.MovieID})
+		}
+		inputMap["movies"] = input.Movies
+	}
+
+	return input, inputMap
+}
+
+func (r *mutationResolver) SceneDateSuggestionsDismiss(ctx context.Context, input models.SceneDateSuggestionsInput) (bool, error) {
+	qb := models.NewScrapeHistoryQueryBuilder()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	for _, id := range input.SceneIds {
+		sceneID, _ := strconv.Atoi(id)
+		if err := qb.ClearResults(models.ScrapeHistoryEntityScene, sceneID, models.ScrapeHistorySourceDateInference, tx); err != nil {
+			_ = tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -3,20 +3,62 @@ package api
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scraper/stashbox"
 )
 
-func (r *mutationResolver) SubmitStashBoxFingerprints(ctx context.Context, input models.StashBoxFingerprintSubmissionInput) (bool, error) {
+func getStashBox(index int) (*models.StashBox, error) {
 	boxes := config.GetStashBoxes()
 
-	if input.StashBoxIndex < 0 || input.StashBoxIndex >= len(boxes) {
-		return false, fmt.Errorf("invalid stash_box_index %d", input.StashBoxIndex)
+	if index < 0 || index >= len(boxes) {
+		return nil, fmt.Errorf("invalid stash_box_index %d", index)
+	}
+
+	return boxes[index], nil
+}
+
+func (r *mutationResolver) SubmitStashBoxFingerprints(ctx context.Context, input models.StashBoxFingerprintSubmissionInput) (bool, error) {
+	box, err := getStashBox(input.StashBoxIndex)
+	if err != nil {
+		return false, err
+	}
+
+	client := stashbox.NewClient(*box)
+
+	return client.SubmitStashBoxFingerprints(input.SceneIds, box.Endpoint)
+}
+
+func (r *mutationResolver) SubmitStashBoxSceneDraft(ctx context.Context, input models.StashBoxDraftSubmissionInput) (string, error) {
+	box, err := getStashBox(input.StashBoxIndex)
+	if err != nil {
+		return "", err
+	}
+
+	sceneID, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return "", err
+	}
+
+	client := stashbox.NewClient(*box)
+
+	return client.SubmitSceneDraft(ctx, sceneID, input.Fields)
+}
+
+func (r *mutationResolver) SubmitStashBoxPerformerDraft(ctx context.Context, input models.StashBoxDraftSubmissionInput) (string, error) {
+	box, err := getStashBox(input.StashBoxIndex)
+	if err != nil {
+		return "", err
+	}
+
+	performerID, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return "", err
 	}
 
-	client := stashbox.NewClient(*boxes[input.StashBoxIndex])
+	client := stashbox.NewClient(*box)
 
-	return client.SubmitStashBoxFingerprints(input.SceneIds, boxes[input.StashBoxIndex].Endpoint)
+	return client.SubmitPerformerDraft(ctx, performerID, input.Fields)
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
@@ -35,6 +36,9 @@ func (r *mutationResolver) StudioCreate(ctx context.Context, input models.Studio
 		CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 	}
+	if input.Slug != nil {
+		newStudio.Slug = sql.NullString{String: *input.Slug, Valid: true}
+	}
 	if input.URL != nil {
 		newStudio.URL = sql.NullString{String: *input.URL, Valid: true}
 	}
@@ -82,6 +86,8 @@ func (r *mutationResolver) StudioCreate(ctx context.Context, input models.Studio
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityStudio, event.ActionCreate, studio.ID)
+
 	return studio, nil
 }
 
@@ -127,6 +133,27 @@ func (r *mutationResolver) StudioUpdate(ctx context.Context, input models.Studio
 		return nil, err
 	}
 
+	if input.Slug != nil {
+		if err := qb.UpdateSlug(studioID, *input.Slug, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if input.Pinned != nil {
+		if err := qb.SetPinned(studioID, *input.Pinned, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if minConfidence := translator.nullFloat64(input.AutoTagMinConfidence, "auto_tag_min_confidence"); minConfidence != nil {
+		if err := qb.UpdateAutoTagMinConfidence(studioID, *minConfidence, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	studio, err := qb.Update(updatedStudio, tx)
 	if err != nil {
 		tx.Rollback()
@@ -167,6 +194,8 @@ func (r *mutationResolver) StudioUpdate(ctx context.Context, input models.Studio
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityStudio, event.ActionUpdate, studio.ID)
+
 	return studio, nil
 }
 
@@ -180,6 +209,8 @@ func (r *mutationResolver) StudioDestroy(ctx context.Context, input models.Studi
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	id, _ := strconv.Atoi(input.ID)
+	publishEvent(ctx, event.EntityStudio, event.ActionDestroy, id)
 	return true, nil
 }
 
@@ -195,5 +226,6 @@ func (r *mutationResolver) StudiosDestroy(ctx context.Context, ids []string) (bo
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	publishEvent(ctx, event.EntityStudio, event.ActionDestroy, utils.StringSliceToIntSlice(ids)...)
 	return true, nil
 }
@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
@@ -56,11 +58,27 @@ func (r *mutationResolver) TagCreate(ctx context.Context, input models.TagCreate
 		}
 	}
 
+	if input.ParentIds != nil || input.ChildIds != nil {
+		if err := updateTagRelations(tag, input.ParentIds, input.ChildIds, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if len(input.Aliases) > 0 {
+		if err := updateTagAliases(tag, input.Aliases, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Commit
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityTag, event.ActionCreate, tag.ID)
+
 	return tag, nil
 }
 
@@ -112,6 +130,13 @@ func (r *mutationResolver) TagUpdate(ctx context.Context, input models.TagUpdate
 		}
 	}
 
+	if minConfidence := translator.nullFloat64(input.AutoTagMinConfidence, "auto_tag_min_confidence"); minConfidence != nil {
+		if err := qb.UpdateAutoTagMinConfidence(tagID, *minConfidence, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	tag, err := qb.Update(updatedTag, tx)
 	if err != nil {
 		_ = tx.Rollback()
@@ -132,14 +157,75 @@ func (r *mutationResolver) TagUpdate(ctx context.Context, input models.TagUpdate
 		}
 	}
 
+	var parentIDs, childIDs []string
+	if translator.hasField("parent_ids") {
+		parentIDs = append([]string{}, input.ParentIds...)
+	}
+	if translator.hasField("child_ids") {
+		childIDs = append([]string{}, input.ChildIds...)
+	}
+	if parentIDs != nil || childIDs != nil {
+		if err := updateTagRelations(tag, parentIDs, childIDs, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if translator.hasField("aliases") {
+		if err := updateTagAliases(tag, input.Aliases, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Commit
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
+	publishEvent(ctx, event.EntityTag, event.ActionUpdate, tag.ID)
+
 	return tag, nil
 }
 
+// updateTagRelations replaces the parents and children of the tag. Parents or
+// children are not changed if their ids are nil.
+func updateTagRelations(tag *models.Tag, parentIDs []string, childIDs []string, tx *sqlx.Tx) error {
+	qb := models.NewTagQueryBuilder()
+
+	if parentIDs != nil {
+		ids, err := utils.ParseIntSlice(parentIDs)
+		if err != nil {
+			return err
+		}
+		if err := qb.UpdateParentTags(tag.ID, ids, tx); err != nil {
+			return err
+		}
+	}
+
+	if childIDs != nil {
+		ids, err := utils.ParseIntSlice(childIDs)
+		if err != nil {
+			return err
+		}
+		if err := qb.UpdateChildTags(tag.ID, ids, tx); err != nil {
+			return err
+		}
+	}
+
+	return manager.EnsureTagHierarchyValid(*tag, tx)
+}
+
+// updateTagAliases replaces the aliases of the tag.
+func updateTagAliases(tag *models.Tag, aliases []string, tx *sqlx.Tx) error {
+	if err := manager.EnsureTagAliasesUnique(tag.ID, aliases, tx); err != nil {
+		return err
+	}
+
+	qb := models.NewTagQueryBuilder()
+	return qb.UpdateAliases(tag.ID, aliases, tx)
+}
+
 func (r *mutationResolver) TagDestroy(ctx context.Context, input models.TagDestroyInput) (bool, error) {
 	qb := models.NewTagQueryBuilder()
 	tx := database.DB.MustBeginTx(ctx, nil)
@@ -150,6 +236,8 @@ func (r *mutationResolver) TagDestroy(ctx context.Context, input models.TagDestr
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	id, _ := strconv.Atoi(input.ID)
+	publishEvent(ctx, event.EntityTag, event.ActionDestroy, id)
 	return true, nil
 }
 
@@ -166,5 +254,55 @@ func (r *mutationResolver) TagsDestroy(ctx context.Context, ids []string) (bool,
 	if err := tx.Commit(); err != nil {
 		return false, err
 	}
+	publishEvent(ctx, event.EntityTag, event.ActionDestroy, utils.StringSliceToIntSlice(ids)...)
 	return true, nil
 }
+
+func (r *mutationResolver) TagsMerge(ctx context.Context, input models.TagsMergeInput) (*models.Tag, error) {
+	sourceIDs, err := utils.ParseIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+	destinationID, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.IntInclude(sourceIDs, destinationID) {
+		return nil, fmt.Errorf("Tag with ID %d cannot be merged into itself", destinationID)
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewTagQueryBuilder()
+
+	destination, err := qb.Find(destinationID, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if destination == nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("Tag with ID %d not found", destinationID)
+	}
+
+	if err := qb.Merge(sourceIDs, destinationID, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// the parents of a source tag may be descendants of another
+	if err := manager.EnsureTagHierarchyValid(*destination, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	publishEvent(ctx, event.EntityTag, event.ActionDestroy, sourceIDs...)
+	publishEvent(ctx, event.EntityTag, event.ActionUpdate, destinationID)
+
+	return destination, nil
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"sort"
 
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
@@ -9,7 +10,14 @@ import (
 )
 
 func (r *queryResolver) Configuration(ctx context.Context) (*models.ConfigResult, error) {
-	return makeConfigResult(), nil
+	ret := makeConfigResult()
+
+	// viewers may read the configuration, but not the credentials in it
+	if getCurrentUserRole(ctx) == roleViewer {
+		redactConfigSecrets(ret.General)
+	}
+
+	return ret, nil
 }
 
 func (r *queryResolver) Directory(ctx context.Context, path *string) (*models.Directory, error) {
@@ -47,7 +55,15 @@ func makeConfigGeneralResult() *models.ConfigGeneralResult {
 		DatabasePath:               config.GetDatabasePath(),
 		GeneratedPath:              config.GetGeneratedPath(),
 		CachePath:                  config.GetCachePath(),
+		AssetsPath:                 config.GetAssetsPath(),
 		CalculateMd5:               config.IsCalculateMD5(),
+		DeferScanHashing:           config.IsDeferScanHashing(),
+		ScanMaxReadRate:            config.GetScanMaxReadRate(),
+		ScanHashWhenIdle:           config.IsScanHashWhenIdle(),
+		CacheRemoteArtwork:         config.IsCacheRemoteArtwork(),
+		ChapterMarkersOnScan:       config.IsChapterMarkersOnScan(),
+		ChapterMarkerTag:           config.GetChapterMarkerTag(),
+		AutoTagMinConfidence:       config.GetAutoTagMinConfidence(),
 		VideoFileNamingAlgorithm:   config.GetVideoFileNamingAlgorithm(),
 		ParallelTasks:              config.GetParallelTasks(),
 		PreviewSegments:            config.GetPreviewSegments(),
@@ -64,18 +80,85 @@ func makeConfigGeneralResult() *models.ConfigGeneralResult {
 		LogOut:                     config.GetLogOut(),
 		LogLevel:                   config.GetLogLevel(),
 		LogAccess:                  config.GetLogAccess(),
+		LogMaxSize:                 config.GetLogMaxSize(),
+		LogRotateInterval:          config.GetLogRotateInterval(),
+		LogMaxBackups:              config.GetLogMaxBackups(),
+		LogMaxAge:                  config.GetLogMaxAge(),
+		LogCompress:                config.GetLogCompress(),
+		Timezone:                   config.GetTimezone(),
+		ApproximateCounts:          config.GetApproximateCounts(),
+		ActivityRetentionDays:      config.GetActivityRetentionDays(),
+		MinimumFreeSpace:           config.GetMinimumFreeSpace(),
+		MaxIOJobs:                  config.GetMaxIOJobs(),
+		MaxCPUJobs:                 config.GetMaxCPUJobs(),
+		TranscodeCacheSize:         config.GetTranscodeCacheSize(),
+		BlobsStorage:               config.GetBlobsStorage(),
+		BlobsPath:                  config.GetBlobsPath(),
+		EnablePlayground:           config.GetEnablePlayground(),
+		EnableIntrospection:        config.GetEnableIntrospection(),
+		CorsAllowedOrigins:         config.GetCORSAllowedOrigins(),
+		CorsAllowedHeaders:         config.GetCORSAllowedHeaders(),
+		TrustedProxyHeader:         config.GetTrustedProxyHeader(),
+		TrustedProxies:             config.GetTrustedProxies(),
+		OidcIssuer:                 config.GetOIDCIssuer(),
+		OidcClientID:               config.GetOIDCClientID(),
+		OidcClientSecret:           config.GetOIDCClientSecret(),
+		OidcScopes:                 config.GetOIDCScopes(),
+		OidcGroupsClaim:            config.GetOIDCGroupsClaim(),
+		OidcAdminGroups:            config.GetOIDCAdminGroups(),
+		OidcViewerGroups:           config.GetOIDCViewerGroups(),
 		VideoExtensions:            config.GetVideoExtensions(),
 		ImageExtensions:            config.GetImageExtensions(),
 		GalleryExtensions:          config.GetGalleryExtensions(),
 		CreateGalleriesFromFolders: config.GetCreateGalleriesFromFolders(),
+		GalleryFolderMinImages:     config.GetGalleryFolderMinImages(),
+		WatchLibraries:             config.IsWatchLibraries(),
 		Excludes:                   config.GetExcludes(),
 		ImageExcludes:              config.GetImageExcludes(),
+		DisplayTitleCleanup:        config.GetDisplayTitleCleanup(),
+		SceneFilenameTemplate:      config.GetSceneFilenameTemplate(),
+		DateInferencePriority:      config.GetDateInferencePriority(),
+		QualityScore:               makeQualityScoreConfig(),
 		ScraperUserAgent:           &scraperUserAgent,
 		ScraperCDPPath:             &scraperCDPPath,
 		StashBoxes:                 config.GetStashBoxes(),
 	}
 }
 
+// redactConfigSecrets clears the password hash, OIDC client secret and
+// stash-box API keys of the configuration.
+func redactConfigSecrets(c *models.ConfigGeneralResult) {
+	c.Password = ""
+	c.OidcClientSecret = ""
+
+	for _, box := range c.StashBoxes {
+		box.APIKey = ""
+	}
+}
+
+func makeQualityScoreConfig() *models.QualityScoreConfig {
+	weights := config.GetQualityScoreWeights()
+
+	ret := &models.QualityScoreConfig{
+		ResolutionWeight: weights.Resolution,
+		BitrateWeight:    weights.Bitrate,
+		CodecWeight:      weights.Codec,
+		CodecScores:      []*models.QualityCodecScore{},
+	}
+
+	for codec, score := range weights.CodecScores {
+		ret.CodecScores = append(ret.CodecScores, &models.QualityCodecScore{
+			Codec: codec,
+			Score: score,
+		})
+	}
+	sort.Slice(ret.CodecScores, func(i, j int) bool {
+		return ret.CodecScores[i].Codec < ret.CodecScores[j].Codec
+	})
+
+	return ret
+}
+
 func makeConfigInterfaceResult() *models.ConfigInterfaceResult {
 	menuItems := config.GetMenuItems()
 	soundOnPreview := config.GetSoundOnPreview()
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestConfigurationRedactsSecretsForViewers(t *testing.T) {
+	defer config.Set(config.Password, config.GetPasswordHash())
+	defer config.Set(config.OIDCClientSecret, config.GetOIDCClientSecret())
+	defer config.Set(config.StashBoxes, config.GetStashBoxes())
+
+	config.Set(config.Password, "hash")
+	config.Set(config.OIDCClientSecret, "secret")
+	config.Set(config.StashBoxes, []*models.StashBoxInput{
+		{Endpoint: "https://stashbox/graphql", APIKey: "key", Name: "box"},
+	})
+
+	r := &queryResolver{}
+
+	viewerCtx := context.WithValue(context.Background(), ContextRole, roleViewer)
+	ret, err := r.Configuration(viewerCtx)
+	if assert.Nil(t, err) {
+		assert.Empty(t, ret.General.Password)
+		assert.Empty(t, ret.General.OidcClientSecret)
+		if assert.Len(t, ret.General.StashBoxes, 1) {
+			assert.Equal(t, "https://stashbox/graphql", ret.General.StashBoxes[0].Endpoint)
+			assert.Empty(t, ret.General.StashBoxes[0].APIKey)
+		}
+	}
+
+	adminCtx := context.WithValue(context.Background(), ContextRole, roleAdmin)
+	ret, err = r.Configuration(adminCtx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hash", ret.General.Password)
+		assert.Equal(t, "secret", ret.General.OidcClientSecret)
+		if assert.Len(t, ret.General.StashBoxes, 1) {
+			assert.Equal(t, "key", ret.General.StashBoxes[0].APIKey)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	defaultBirthdayDays = 7
+	maxBirthdayDays     = 366
+)
+
+func (r *queryResolver) Dashboard(ctx context.Context) (*models.Dashboard, error) {
+	mqb := models.NewMovieQueryBuilder()
+	movies, err := mqb.FindPinned(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pqb := models.NewPerformerQueryBuilder()
+	performers, err := pqb.FindPinned(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sqb := models.NewStudioQueryBuilder()
+	studios, err := sqb.FindPinned(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Dashboard{
+		Movies:     movies,
+		Performers: performers,
+		Studios:    studios,
+	}, nil
+}
+
+func (r *queryResolver) OnThisDay(ctx context.Context, birthdayDays *int) (*models.OnThisDay, error) {
+	days := defaultBirthdayDays
+	if birthdayDays != nil {
+		days = *birthdayDays
+	}
+	if days < 1 || days > maxBirthdayDays {
+		return nil, fmt.Errorf("birthday_days must be between 1 and %d", maxBirthdayDays)
+	}
+
+	now := time.Now().In(utils.GetTimezone())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	birthdays, err := getUpcomingBirthdays(today, days)
+	if err != nil {
+		return nil, err
+	}
+
+	monthDays := utils.AnniversaryMonthDays(today)
+	startOfYear := today.Format("2006") + "-01-01"
+
+	sqb := models.NewSceneQueryBuilder()
+	scenes, err := sqb.FindReleasedOnMonthDays(monthDays, startOfYear)
+	if err != nil {
+		return nil, err
+	}
+
+	mqb := models.NewMovieQueryBuilder()
+	movies, err := mqb.FindReleasedOnMonthDays(monthDays, startOfYear)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OnThisDay{
+		Date:      today.Format("2006-01-02"),
+		Birthdays: birthdays,
+		Scenes:    scenes,
+		Movies:    movies,
+	}, nil
+}
+
+// getUpcomingBirthdays returns the birthdays of performers in the days
+// starting with today, ordered by date and then performer name.
+func getUpcomingBirthdays(today time.Time, days int) ([]*models.UpcomingBirthday, error) {
+	end := today.AddDate(0, 0, days)
+
+	var monthDays []string
+	for d := today; d.Before(end); d = d.AddDate(0, 0, 1) {
+		monthDays = append(monthDays, utils.AnniversaryMonthDays(d)...)
+	}
+
+	pqb := models.NewPerformerQueryBuilder()
+	performers, err := pqb.FindByBirthdayMonthDays(monthDays)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*models.UpcomingBirthday{}
+	for _, p := range performers {
+		if !p.Birthdate.Valid {
+			continue
+		}
+
+		birthdate, err := time.Parse("2006-01-02", p.Birthdate.String)
+		if err != nil {
+			continue
+		}
+
+		birthday := utils.NextAnniversary(birthdate, today)
+		if !birthday.Before(end) {
+			continue
+		}
+
+		ret = append(ret, &models.UpcomingBirthday{
+			Performer: p,
+			Date:      birthday.Format("2006-01-02"),
+			Age:       birthday.Year() - birthdate.Year(),
+		})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Date != ret[j].Date {
+			return ret[i].Date < ret[j].Date
+		}
+		return strings.ToLower(ret[i].Performer.Name.String) < strings.ToLower(ret[j].Performer.Name.String)
+	})
+
+	return ret, nil
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) FindActivity(ctx context.Context, activityFilter *models.ActivityFilterType, filter *models.FindFilterType) (*models.FindActivityResultType, error) {
+	qb := models.NewActivityQueryBuilder()
+	activity, total, err := qb.Query(activityFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindActivityResultType{
+		Count:    total,
+		Activity: activity,
+	}
+	if len(activity) > 0 {
+		ret.NextCursor = filter.NextCursor(len(activity), activity[len(activity)-1].ID)
+	}
+
+	return ret, nil
+}
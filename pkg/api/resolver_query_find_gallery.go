@@ -15,9 +15,18 @@ func (r *queryResolver) FindGallery(ctx context.Context, id string) (*models.Gal
 
 func (r *queryResolver) FindGalleries(ctx context.Context, galleryFilter *models.GalleryFilterType, filter *models.FindFilterType) (*models.FindGalleriesResultType, error) {
 	qb := models.NewGalleryQueryBuilder()
-	galleries, total := qb.Query(galleryFilter, filter)
-	return &models.FindGalleriesResultType{
+	galleries, total, err := qb.Query(galleryFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindGalleriesResultType{
 		Count:     total,
 		Galleries: galleries,
-	}, nil
+	}
+	if len(galleries) > 0 {
+		ret.NextCursor = filter.NextCursor(len(galleries), galleries[len(galleries)-1].ID)
+	}
+
+	return ret, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 
+	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/models"
 )
 
@@ -22,9 +23,28 @@ func (r *queryResolver) FindImage(ctx context.Context, id *string, checksum *str
 
 func (r *queryResolver) FindImages(ctx context.Context, imageFilter *models.ImageFilterType, imageIds []int, filter *models.FindFilterType) (*models.FindImagesResultType, error) {
 	qb := models.NewImageQueryBuilder()
-	images, total := qb.Query(imageFilter, filter)
-	return &models.FindImagesResultType{
+	images, total, err := qb.Query(imageFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindImagesResultType{
 		Count:  total,
 		Images: images,
-	}, nil
+	}
+	if len(images) > 0 {
+		ret.NextCursor = filter.NextCursor(len(images), images[len(images)-1].ID)
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) FindDuplicateImages(ctx context.Context, phashDistance *int) ([]*models.ImageDuplicateGroup, error) {
+	qb := models.NewImageQueryBuilder()
+	images, err := qb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	return image.FindDuplicates(images, phashDistance), nil
 }
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/movie"
 )
 
 func (r *queryResolver) FindMovie(ctx context.Context, id string) (*models.Movie, error) {
@@ -13,13 +14,60 @@ func (r *queryResolver) FindMovie(ctx context.Context, id string) (*models.Movie
 	return qb.Find(idInt, nil)
 }
 
+func (r *queryResolver) FindMovieBySlug(ctx context.Context, slug string) (*models.Movie, error) {
+	qb := models.NewMovieQueryBuilder()
+	return qb.FindBySlug(slug, nil)
+}
+
 func (r *queryResolver) FindMovies(ctx context.Context, movieFilter *models.MovieFilterType, filter *models.FindFilterType) (*models.FindMoviesResultType, error) {
 	qb := models.NewMovieQueryBuilder()
-	movies, total := qb.Query(movieFilter, filter)
-	return &models.FindMoviesResultType{
-		Count:  total,
-		Movies: movies,
-	}, nil
+	movies, total, err := qb.Query(movieFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindMoviesResultType{
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		Movies:           movies,
+	}
+	if len(movies) > 0 {
+		ret.NextCursor = filter.NextCursor(len(movies), movies[len(movies)-1].ID)
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) FindMovieSceneIndexIssues(ctx context.Context) ([]*models.MovieSceneIndexIssues, error) {
+	qb := models.NewMovieQueryBuilder()
+	movies, err := qb.FindWithSceneIndexIssues(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jqb := models.NewJoinsQueryBuilder()
+	ret := []*models.MovieSceneIndexIssues{}
+	for _, m := range movies {
+		joins, err := jqb.GetMovieScenes(m.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		unindexed, duplicates, missing, invalid := movie.SceneIndexIssues(joins)
+		issues := &models.MovieSceneIndexIssues{
+			Movie:             m,
+			UnindexedSceneIds: []string{},
+			DuplicateIndexes:  append([]int{}, duplicates...),
+			MissingIndexes:    append([]int{}, missing...),
+			InvalidIndexes:    append([]int{}, invalid...),
+		}
+		for _, sceneID := range unindexed {
+			issues.UnindexedSceneIds = append(issues.UnindexedSceneIds, strconv.Itoa(sceneID))
+		}
+		ret = append(ret, issues)
+	}
+
+	return ret, nil
 }
 
 func (r *queryResolver) AllMovies(ctx context.Context) ([]*models.Movie, error) {
@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
-	"github.com/stashapp/stash/pkg/models"
 	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/performer"
 )
 
 func (r *queryResolver) FindPerformer(ctx context.Context, id string) (*models.Performer, error) {
@@ -12,13 +14,44 @@ func (r *queryResolver) FindPerformer(ctx context.Context, id string) (*models.P
 	return qb.Find(idInt)
 }
 
+func (r *queryResolver) FindPerformerBySlug(ctx context.Context, slug string) (*models.Performer, error) {
+	qb := models.NewPerformerQueryBuilder()
+	return qb.FindBySlug(slug)
+}
+
 func (r *queryResolver) FindPerformers(ctx context.Context, performerFilter *models.PerformerFilterType, filter *models.FindFilterType) (*models.FindPerformersResultType, error) {
 	qb := models.NewPerformerQueryBuilder()
-	performers, total := qb.Query(performerFilter, filter)
-	return &models.FindPerformersResultType{
-		Count:      total,
-		Performers: performers,
-	}, nil
+	performers, total, err := qb.Query(performerFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindPerformersResultType{
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		Performers:       performers,
+	}
+	if len(performers) > 0 {
+		ret.NextCursor = filter.NextCursor(len(performers), performers[len(performers)-1].ID)
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) FindDuplicatePerformers(ctx context.Context) ([]*models.PerformerDuplicateGroup, error) {
+	qb := models.NewPerformerQueryBuilder()
+	performers, err := qb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	jqb := models.NewJoinsQueryBuilder()
+	stashIDs, err := jqb.GetAllPerformerStashIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	return performer.FindDuplicates(performers, stashIDs), nil
 }
 
 func (r *queryResolver) AllPerformers(ctx context.Context) ([]*models.Performer, error) {
@@ -2,12 +2,21 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
+const defaultPhashDistance = 4
+
+const defaultTitleMismatchThreshold = 0.5
+
 func (r *queryResolver) FindScene(ctx context.Context, id *string, checksum *string) (*models.Scene, error) {
 	qb := models.NewSceneQueryBuilder()
 	var scene *models.Scene
@@ -45,11 +54,26 @@ func (r *queryResolver) FindSceneByHash(ctx context.Context, input models.SceneH
 
 func (r *queryResolver) FindScenes(ctx context.Context, sceneFilter *models.SceneFilterType, sceneIds []int, filter *models.FindFilterType) (*models.FindScenesResultType, error) {
 	qb := models.NewSceneQueryBuilder()
-	scenes, total := qb.Query(sceneFilter, filter)
-	return &models.FindScenesResultType{
-		Count:  total,
-		Scenes: scenes,
-	}, nil
+	scenes, total, err := qb.Query(sceneFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindScenesResultType{
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		Scenes:           scenes,
+	}
+	if len(scenes) > 0 {
+		ret.NextCursor = filter.NextCursor(len(scenes), scenes[len(scenes)-1].ID)
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) SceneQueryPlan(ctx context.Context, sceneFilter *models.SceneFilterType, filter *models.FindFilterType) ([]string, error) {
+	qb := models.NewSceneQueryBuilder()
+	return qb.QueryPlan(sceneFilter, filter)
 }
 
 func (r *queryResolver) FindScenesByPathRegex(ctx context.Context, filter *models.FindFilterType) (*models.FindScenesResultType, error) {
@@ -57,11 +81,163 @@ func (r *queryResolver) FindScenesByPathRegex(ctx context.Context, filter *model
 
 	scenes, total := qb.QueryByPathRegex(filter)
 	return &models.FindScenesResultType{
-		Count:  total,
-		Scenes: scenes,
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		Scenes:           scenes,
 	}, nil
 }
 
+func (r *queryResolver) FindScenesByPhashDistance(ctx context.Context, input models.ScenePhashDistanceInput) ([]*models.ScenePhashDistance, error) {
+	qb := models.NewSceneQueryBuilder()
+
+	var phash int64
+	excludeID := 0
+	if input.Phash != nil {
+		var err error
+		phash, err = utils.PhashFromString(*input.Phash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid phash %s: %s", *input.Phash, err.Error())
+		}
+	} else if input.SceneID != nil {
+		sceneID, err := strconv.Atoi(*input.SceneID)
+		if err != nil {
+			return nil, err
+		}
+
+		scene, err := qb.Find(sceneID)
+		if err != nil {
+			return nil, err
+		}
+
+		if scene == nil {
+			return nil, fmt.Errorf("scene with id %d not found", sceneID)
+		}
+
+		if !scene.Phash.Valid {
+			return nil, fmt.Errorf("scene with id %d does not have a phash", sceneID)
+		}
+
+		phash = scene.Phash.Int64
+		excludeID = sceneID
+	} else {
+		return nil, errors.New("one of scene_id or phash must be provided")
+	}
+
+	distance := defaultPhashDistance
+	if input.Distance != nil {
+		distance = *input.Distance
+	}
+
+	ids, distances, err := qb.FindByPhashDistance(phash, distance)
+	if err != nil {
+		return nil, err
+	}
+
+	// don't include the scene being compared against
+	var sceneIDs []int
+	var sceneDistances []int
+	for i, id := range ids {
+		if id != excludeID {
+			sceneIDs = append(sceneIDs, id)
+			sceneDistances = append(sceneDistances, distances[i])
+		}
+	}
+
+	scenes, err := qb.FindMany(sceneIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*models.ScenePhashDistance
+	for i, scene := range scenes {
+		ret = append(ret, &models.ScenePhashDistance{
+			Scene:    scene,
+			Distance: sceneDistances[i],
+		})
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) FindDuplicateScenes(ctx context.Context, distance *int) ([][]*models.Scene, error) {
+	d := defaultPhashDistance
+	if distance != nil {
+		d = *distance
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	return scene.FindDuplicates(scenes, d), nil
+}
+
+func (r *queryResolver) SceneTitleMismatches(ctx context.Context, threshold *float64) ([]*models.SceneTitleMismatch, error) {
+	qb := models.NewSceneQueryBuilder()
+
+	t := defaultTitleMismatchThreshold
+	if threshold != nil {
+		t = *threshold
+	}
+
+	ids, distances, err := qb.FindByTitleMismatch(t)
+	if err != nil {
+		return nil, err
+	}
+
+	scenes, err := qb.FindMany(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*models.SceneTitleMismatch{}
+	for i, scene := range scenes {
+		ret = append(ret, &models.SceneTitleMismatch{
+			Scene:    scene,
+			Distance: distances[i],
+		})
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) SceneDateSuggestions(ctx context.Context) ([]*models.SceneDateSuggestion, error) {
+	suggestions, err := getSceneDateSuggestions()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for id := range suggestions {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindMany(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*models.SceneDateSuggestion{}
+	for _, scene := range scenes {
+		// scenes given a date since the inference are left out
+		if scene.Date.Valid {
+			continue
+		}
+
+		ret = append(ret, &models.SceneDateSuggestion{
+			Scene:  scene,
+			Date:   suggestions[scene.ID].Date,
+			Source: suggestions[scene.ID].Source,
+		})
+	}
+
+	return ret, nil
+}
+
 func (r *queryResolver) ParseSceneFilenames(ctx context.Context, filter *models.FindFilterType, config models.SceneParserInput) (*models.SceneParserResultType, error) {
 	parser := manager.NewSceneFilenameParser(filter, config)
 
@@ -76,3 +252,7 @@ func (r *queryResolver) ParseSceneFilenames(ctx context.Context, filter *models.
 		Results: result,
 	}, nil
 }
+
+func (r *queryResolver) ParsePath(ctx context.Context, path string) (*models.ParsePathResult, error) {
+	return manager.ParsePath(path)
+}
@@ -7,9 +7,19 @@ import (
 
 func (r *queryResolver) FindSceneMarkers(ctx context.Context, sceneMarkerFilter *models.SceneMarkerFilterType, filter *models.FindFilterType) (*models.FindSceneMarkersResultType, error) {
 	qb := models.NewSceneMarkerQueryBuilder()
-	sceneMarkers, total := qb.Query(sceneMarkerFilter, filter)
-	return &models.FindSceneMarkersResultType{
-		Count:        total,
-		SceneMarkers: sceneMarkers,
-	}, nil
+	sceneMarkers, total, err := qb.Query(sceneMarkerFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindSceneMarkersResultType{
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		SceneMarkers:     sceneMarkers,
+	}
+	if len(sceneMarkers) > 0 {
+		ret.NextCursor = filter.NextCursor(len(sceneMarkers), sceneMarkers[len(sceneMarkers)-1].ID)
+	}
+
+	return ret, nil
 }
@@ -12,13 +12,28 @@ func (r *queryResolver) FindStudio(ctx context.Context, id string) (*models.Stud
 	return qb.Find(idInt, nil)
 }
 
-func (r *queryResolver) FindStudios(ctx context.Context, studioFilter *models.StudioFilterType, filter *models.FindFilterType) (*models.FindStudiosResultType, error) {
+func (r *queryResolver) FindStudioBySlug(ctx context.Context, slug string) (*models.Studio, error) {
 	qb := models.NewStudioQueryBuilder()
-	studios, total := qb.Query(studioFilter, filter)
-	return &models.FindStudiosResultType{
-		Count:   total,
-		Studios: studios,
-	}, nil
+	return qb.FindBySlug(slug, nil)
+}
+
+func (r *queryResolver) FindStudios(ctx context.Context, studioFilter *models.StudioFilterType, filter *models.FindFilterType, skipImageLookups *bool) (*models.FindStudiosResultType, error) {
+	qb := models.NewStudioQueryBuilder()
+	studios, total, err := qb.Query(studioFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindStudiosResultType{
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		Studios:          studios,
+	}
+	if len(studios) > 0 {
+		ret.NextCursor = filter.NextCursor(len(studios), studios[len(studios)-1].ID)
+	}
+
+	return ret, nil
 }
 
 func (r *queryResolver) AllStudios(ctx context.Context) ([]*models.Studio, error) {
@@ -15,11 +15,21 @@ func (r *queryResolver) FindTag(ctx context.Context, id string) (*models.Tag, er
 
 func (r *queryResolver) FindTags(ctx context.Context, tagFilter *models.TagFilterType, filter *models.FindFilterType) (*models.FindTagsResultType, error) {
 	qb := models.NewTagQueryBuilder()
-	tags, total := qb.Query(tagFilter, filter)
-	return &models.FindTagsResultType{
-		Count: total,
-		Tags:  tags,
-	}, nil
+	tags, total, err := qb.Query(tagFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindTagsResultType{
+		Count:            total,
+		CountApproximate: filter.IsCountApproximate(total),
+		Tags:             tags,
+	}
+	if len(tags) > 0 {
+		ret.NextCursor = filter.NextCursor(len(tags), tags[len(tags)-1].ID)
+	}
+
+	return ret, nil
 }
 
 func (r *queryResolver) AllTags(ctx context.Context) ([]*models.Tag, error) {
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func makeJob(j *manager.Job) *models.Job {
+	info := j.Info()
+
+	ret := &models.Job{
+		ID:          strconv.Itoa(info.ID),
+		Status:      info.State,
+		Description: info.Type.String(),
+		SubTasks:    info.SubTasks,
+		Paused:      info.Paused,
+		Priority:    info.Priority,
+		AddTime:     info.AddTime,
+		StartTime:   info.StartTime,
+		EndTime:     info.EndTime,
+	}
+
+	if info.Progress >= 0 {
+		progress := info.Progress
+		ret.Progress = &progress
+	}
+	if info.Message != "" {
+		message := info.Message
+		ret.Message = &message
+	}
+
+	return ret
+}
+
+// getMetadataUpdateStatus returns the status of the running job, or the idle
+// status if no job is running.
+func getMetadataUpdateStatus() models.MetadataUpdateStatus {
+	j := manager.GetInstance().JobQueue.Current()
+	if j == nil {
+		return models.MetadataUpdateStatus{
+			Progress: -1,
+			Status:   manager.Idle.String(),
+		}
+	}
+
+	info := j.Info()
+	return models.MetadataUpdateStatus{
+		Progress: info.Progress,
+		Status:   info.Type.String(),
+		Message:  info.Message,
+		Paused:   info.Paused,
+	}
+}
+
+func (r *queryResolver) JobQueue(ctx context.Context) ([]*models.Job, error) {
+	var ret []*models.Job
+	for _, j := range manager.GetInstance().JobQueue.Jobs() {
+		ret = append(ret, makeJob(j))
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) FindJob(ctx context.Context, id string) (*models.Job, error) {
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	j := manager.GetInstance().JobQueue.Find(jobID)
+	if j == nil {
+		return nil, nil
+	}
+
+	return makeJob(j), nil
+}
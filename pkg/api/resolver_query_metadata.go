@@ -8,12 +8,35 @@ import (
 )
 
 func (r *queryResolver) JobStatus(ctx context.Context) (*models.MetadataUpdateStatus, error) {
-	status := manager.GetInstance().Status
-	ret := models.MetadataUpdateStatus{
-		Progress: status.Progress,
-		Status:   status.Status.String(),
-		Message:  "",
+	ret := getMetadataUpdateStatus()
+	return &ret, nil
+}
+
+func (r *queryResolver) HashNamingMigrations(ctx context.Context) ([]*models.HashNamingMigration, error) {
+	migrations, err := manager.HashMigrations()
+	if err != nil {
+		return nil, err
 	}
 
-	return &ret, nil
+	ret := []*models.HashNamingMigration{}
+	for _, m := range migrations {
+		ret = append(ret, &models.HashNamingMigration{
+			Name:       m.Name,
+			From:       m.From,
+			To:         m.To,
+			Time:       m.Time,
+			Renamed:    len(m.Renames),
+			RolledBack: m.RolledBack,
+		})
+	}
+
+	return ret, nil
+}
+
+func (r *queryResolver) FindDanglingReferences(ctx context.Context) ([]*models.DanglingReference, error) {
+	return models.FindDanglingReferences()
+}
+
+func (r *queryResolver) DestroyPreview(ctx context.Context, input models.DestroyPreviewInput) (*models.DestroyPreview, error) {
+	return manager.PreviewDestroy(input)
 }
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const defaultUpgradeCandidatesLimit = 5
+
+func (r *queryResolver) SceneUpgradeCandidates(ctx context.Context, limit *int, maxScore *float64) (*models.SceneUpgradeCandidates, error) {
+	l := defaultUpgradeCandidatesLimit
+	if limit != nil && *limit > 0 {
+		l = *limit
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	pqb := models.NewPerformerQueryBuilder()
+	sqb := models.NewStudioQueryBuilder()
+
+	ret := &models.SceneUpgradeCandidates{
+		Performers: []*models.PerformerUpgradeCandidates{},
+		Studios:    []*models.StudioUpgradeCandidates{},
+	}
+
+	performerGroups, err := qb.FindLowestQualityByFavoritePerformer(l, maxScore)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range performerGroups {
+		performer, err := pqb.Find(g.ID)
+		if err != nil {
+			return nil, err
+		}
+		scenes, err := qb.FindMany(g.SceneIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		ret.Performers = append(ret.Performers, &models.PerformerUpgradeCandidates{
+			Performer: performer,
+			Scenes:    scenes,
+		})
+	}
+
+	studioGroups, err := qb.FindLowestQualityByStudio(l, maxScore)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range studioGroups {
+		studio, err := sqb.Find(g.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		scenes, err := qb.FindMany(g.SceneIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		ret.Studios = append(ret.Studios, &models.StudioUpgradeCandidates{
+			Studio: studio,
+			Scenes: scenes,
+		})
+	}
+
+	return ret, nil
+}
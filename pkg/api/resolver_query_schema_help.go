@@ -0,0 +1,11 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) SchemaHelp(ctx context.Context, name *string) ([]*models.SchemaTypeHelp, error) {
+	return models.GetSchemaHelp(name), nil
+}
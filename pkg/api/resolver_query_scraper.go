@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
@@ -68,22 +69,70 @@ func (r *queryResolver) ScrapePerformerURL(ctx context.Context, url string) (*mo
 	return manager.GetInstance().ScraperCache.ScrapePerformerURL(url)
 }
 
-func (r *queryResolver) ScrapeScene(ctx context.Context, scraperID string, scene models.SceneUpdateInput) (*models.ScrapedScene, error) {
-	return manager.GetInstance().ScraperCache.ScrapeScene(scraperID, scene)
+func (r *queryResolver) ScrapeScene(ctx context.Context, scraperID string, scene models.SceneUpdateInput, force *bool) (*models.ScrapedScene, error) {
+	sceneID, err := strconv.Atoi(scene.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	inputChecksum := scrapeInputChecksum(scene)
+	if force == nil || !*force {
+		var ret models.ScrapedScene
+		if getRecentScrape(models.ScrapeHistoryEntityScene, sceneID, scraperID, inputChecksum, &ret) {
+			return &ret, nil
+		}
+	}
+
+	ret, err := manager.GetInstance().ScraperCache.ScrapeScene(scraperID, scene)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret != nil {
+		recordScrape(models.ScrapeHistoryEntityScene, sceneID, scraperID, inputChecksum, ret)
+	}
+
+	return ret, nil
 }
 
 func (r *queryResolver) ScrapeSceneURL(ctx context.Context, url string) (*models.ScrapedScene, error) {
 	return manager.GetInstance().ScraperCache.ScrapeSceneURL(url)
 }
 
-func (r *queryResolver) ScrapeGallery(ctx context.Context, scraperID string, gallery models.GalleryUpdateInput) (*models.ScrapedGallery, error) {
-	return manager.GetInstance().ScraperCache.ScrapeGallery(scraperID, gallery)
+func (r *queryResolver) ScrapeGallery(ctx context.Context, scraperID string, gallery models.GalleryUpdateInput, force *bool) (*models.ScrapedGallery, error) {
+	galleryID, err := strconv.Atoi(gallery.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	inputChecksum := scrapeInputChecksum(gallery)
+	if force == nil || !*force {
+		var ret models.ScrapedGallery
+		if getRecentScrape(models.ScrapeHistoryEntityGallery, galleryID, scraperID, inputChecksum, &ret) {
+			return &ret, nil
+		}
+	}
+
+	ret, err := manager.GetInstance().ScraperCache.ScrapeGallery(scraperID, gallery)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret != nil {
+		recordScrape(models.ScrapeHistoryEntityGallery, galleryID, scraperID, inputChecksum, ret)
+	}
+
+	return ret, nil
 }
 
 func (r *queryResolver) ScrapeGalleryURL(ctx context.Context, url string) (*models.ScrapedGallery, error) {
 	return manager.GetInstance().ScraperCache.ScrapeGalleryURL(url)
 }
 
+func (r *queryResolver) ScrapeMovie(ctx context.Context, scraperID string, movie models.MovieUpdateInput) (*models.ScrapedMovie, error) {
+	return manager.GetInstance().ScraperCache.ScrapeMovie(scraperID, movie)
+}
+
 func (r *queryResolver) ScrapeMovieURL(ctx context.Context, url string) (*models.ScrapedMovie, error) {
 	return manager.GetInstance().ScraperCache.ScrapeMovieURL(url)
 }
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) StatsSnapshots(ctx context.Context, from *string, to *string, interval *models.StatsSnapshotInterval) ([]*models.StatsSnapshot, error) {
+	for _, date := range []*string{from, to} {
+		if date == nil {
+			continue
+		}
+		if _, err := time.Parse(statsSnapshotDateFormat, *date); err != nil {
+			return nil, fmt.Errorf("invalid date %q: must be in YYYY-MM-DD format", *date)
+		}
+	}
+
+	i := models.StatsSnapshotIntervalDay
+	if interval != nil && interval.IsValid() {
+		i = *interval
+	}
+
+	qb := models.NewStatsSnapshotQueryBuilder()
+	return qb.Query(from, to, i)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const defaultTopScenesLimit = 10
+
+func (r *queryResolver) StreamStats(ctx context.Context, since *time.Time, topScenesLimit *int) (*models.StreamStatsResultType, error) {
+	qb := models.NewStreamSessionQueryBuilder()
+	totals, err := qb.Totals(since)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := defaultTopScenesLimit
+	if topScenesLimit != nil {
+		limit = *topScenesLimit
+	}
+
+	topScenes, err := qb.TopScenes(since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	ret := &models.StreamStatsResultType{
+		SessionCount:      totals.SessionCount,
+		Duration:          totals.Duration,
+		TranscodeDuration: totals.TranscodeDuration,
+		Bytes:             float64(totals.Bytes),
+		TopScenes:         []*models.SceneStreamStatsType{},
+	}
+
+	for _, s := range topScenes {
+		scene, err := sqb.Find(s.SceneID)
+		if err != nil {
+			return nil, err
+		}
+
+		// the scene may have been deleted after the totals were queried
+		if scene == nil {
+			continue
+		}
+
+		ret.TopScenes = append(ret.TopScenes, &models.SceneStreamStatsType{
+			Scene:        scene,
+			SessionCount: s.SessionCount,
+			Duration:     s.Duration,
+		})
+	}
+
+	return ret, nil
+}
@@ -14,20 +14,15 @@ func (r *subscriptionResolver) MetadataUpdate(ctx context.Context) (<-chan *mode
 	ticker := time.NewTicker(5 * time.Second)
 
 	go func() {
-		lastStatus := manager.TaskStatus{}
+		lastStatus := models.MetadataUpdateStatus{}
 		for {
 			select {
 			case _ = <-ticker.C:
-				thisStatus := manager.GetInstance().Status
-				if thisStatus != lastStatus {
-					ret := models.MetadataUpdateStatus{
-						Progress: thisStatus.Progress,
-						Status:   thisStatus.Status.String(),
-						Message:  "",
-					}
+				ret := getMetadataUpdateStatus()
+				if ret != lastStatus {
 					msg <- &ret
 				}
-				lastStatus = thisStatus
+				lastStatus = ret
 			case <-ctx.Done():
 				ticker.Stop()
 				close(msg)
@@ -38,3 +33,22 @@ func (r *subscriptionResolver) MetadataUpdate(ctx context.Context) (<-chan *mode
 
 	return msg, nil
 }
+
+func (r *subscriptionResolver) JobsSubscribe(ctx context.Context) (<-chan *models.JobStatusUpdate, error) {
+	msg := make(chan *models.JobStatusUpdate, 100)
+	events := manager.GetInstance().JobQueue.Subscribe(ctx)
+
+	go func() {
+		defer close(msg)
+
+		for e := range events {
+			select {
+			case msg <- &models.JobStatusUpdate{Type: e.Type, Job: makeJob(e.Job)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msg, nil
+}
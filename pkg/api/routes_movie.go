@@ -19,6 +19,7 @@ func (rs movieRoutes) Routes() chi.Router {
 		r.Use(MovieCtx)
 		r.Get("/frontimage", rs.FrontImage)
 		r.Get("/backimage", rs.BackImage)
+		r.Get("/background", rs.Background)
 	})
 
 	return r
@@ -30,8 +31,14 @@ func (rs movieRoutes) FrontImage(w http.ResponseWriter, r *http.Request) {
 	image, _ := qb.GetFrontImage(movie.ID, nil)
 
 	defaultParam := r.URL.Query().Get("default")
+
+	// fall back to the remote cover artwork if there is no front image
+	if len(image) == 0 && defaultParam != "true" && movie.CoverURL.Valid && serveArtworkURL(w, r, movie.CoverURL.String) {
+		return
+	}
+
 	if len(image) == 0 || defaultParam == "true" {
-		_, image, _ = utils.ProcessBase64Image(models.DefaultMovieImage)
+		image = getDefaultMovieImage()
 	}
 
 	utils.ServeImage(image, w, r)
@@ -44,12 +51,19 @@ func (rs movieRoutes) BackImage(w http.ResponseWriter, r *http.Request) {
 
 	defaultParam := r.URL.Query().Get("default")
 	if len(image) == 0 || defaultParam == "true" {
-		_, image, _ = utils.ProcessBase64Image(models.DefaultMovieImage)
+		image = getDefaultMovieImage()
 	}
 
 	utils.ServeImage(image, w, r)
 }
 
+func (rs movieRoutes) Background(w http.ResponseWriter, r *http.Request) {
+	movie := r.Context().Value(movieKey).(*models.Movie)
+	if !movie.BackgroundURL.Valid || !serveArtworkURL(w, r, movie.BackgroundURL.String) {
+		http.NotFound(w, r)
+	}
+}
+
 func MovieCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		movieID, err := strconv.Atoi(chi.URLParam(r, "movieId"))
@@ -2,7 +2,9 @@ package api
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -32,9 +35,13 @@ func (rs sceneRoutes) Routes() chi.Router {
 		r.Get("/stream.mp4", rs.StreamMp4)
 
 		r.Get("/screenshot", rs.Screenshot)
+		r.Get("/background", rs.Background)
 		r.Get("/preview", rs.Preview)
 		r.Get("/webp", rs.Webp)
 		r.Get("/vtt/chapter", rs.ChapterVtt)
+		r.Get("/chapters.xml", rs.ChaptersMatroska)
+		r.Get("/chapters.ffmetadata", rs.ChaptersFFMetadata)
+		r.Get("/caption", rs.Caption)
 
 		r.Get("/scene_marker/{sceneMarkerId}/stream", rs.SceneMarkerStream)
 		r.Get("/scene_marker/{sceneMarkerId}/preview", rs.SceneMarkerPreview)
@@ -70,8 +77,11 @@ func (rs sceneRoutes) StreamDirect(w http.ResponseWriter, r *http.Request) {
 	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
 
 	filepath := manager.GetInstance().Paths.Scene.GetStreamPath(scene.Path, scene.GetHash(fileNamingAlgo))
+	sw := newStreamSessionWriter(w, r, scene, false)
+	defer sw.end()
+
 	manager.RegisterStream(filepath, &w)
-	http.ServeFile(w, r, filepath)
+	http.ServeFile(sw, r, filepath)
 	manager.WaitAndDeregisterStream(filepath, &w, r)
 }
 
@@ -161,6 +171,26 @@ func (rs sceneRoutes) streamTranscode(w http.ResponseWriter, r *http.Request, vi
 		options.MaxTranscodeSize = models.StreamingResolutionEnum(requestedSize)
 	}
 
+	// serve previously transcoded output if possible
+	cache := manager.GetInstance().GetTranscodeCache()
+	cacheKey := manager.TranscodeCacheKey(
+		scene.GetHash(config.GetVideoFileNamingAlgorithm()),
+		options.Codec.Codec,
+		options.Codec.MimeType,
+		string(options.MaxTranscodeSize),
+		options.StartTime,
+		strconv.FormatBool(options.VideoOnly),
+	)
+	if cachedPath, found := cache.Get(cacheKey); found {
+		logger.Debugf("[stream] serving cached transcode of %s", scene.Path)
+		sw := newStreamSessionWriter(w, r, scene, true)
+		defer sw.end()
+
+		w.Header().Set("Content-Type", options.Codec.MimeType)
+		http.ServeFile(sw, r, cachedPath)
+		return
+	}
+
 	encoder := ffmpeg.NewEncoder(manager.GetInstance().FFMPEGPath)
 	stream, err = encoder.GetTranscodeStream(options)
 
@@ -171,7 +201,31 @@ func (rs sceneRoutes) streamTranscode(w http.ResponseWriter, r *http.Request, vi
 		return
 	}
 
-	stream.Serve(w, r)
+	sw := newStreamSessionWriter(w, r, scene, true)
+	defer sw.end()
+
+	if !cache.Enabled() {
+		stream.Serve(sw, r)
+		return
+	}
+
+	cacheWriter, err := cache.NewWriter(cacheKey)
+	if err != nil {
+		logger.Warnf("[stream] error creating transcode cache file: %s", err.Error())
+		stream.Serve(sw, r)
+		return
+	}
+
+	// only cache the output if it was transcoded and served in full
+	stream.Tee = cacheWriter
+	if stream.Serve(sw, r) != nil || stream.Wait() != nil {
+		cacheWriter.Abort()
+		return
+	}
+
+	if err := cacheWriter.Commit(); err != nil {
+		logger.Warnf("[stream] error caching transcode: %s", err.Error())
+	}
 }
 
 func (rs sceneRoutes) Screenshot(w http.ResponseWriter, r *http.Request) {
@@ -185,10 +239,28 @@ func (rs sceneRoutes) Screenshot(w http.ResponseWriter, r *http.Request) {
 	} else {
 		qb := models.NewSceneQueryBuilder()
 		cover, _ := qb.GetSceneCover(scene.ID, nil)
+
+		// fall back to the remote cover artwork if there is no cover
+		if len(cover) == 0 && scene.CoverURL.Valid && serveArtworkURL(w, r, scene.CoverURL.String) {
+			return
+		}
+
+		// fall back to the screenshot placeholder asset, if there is one
+		if len(cover) == 0 {
+			cover = getAsset(screenshotAsset)
+		}
+
 		utils.ServeImage(cover, w, r)
 	}
 }
 
+func (rs sceneRoutes) Background(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+	if !scene.BackgroundURL.Valid || !serveArtworkURL(w, r, scene.BackgroundURL.String) {
+		http.NotFound(w, r)
+	}
+}
+
 func (rs sceneRoutes) Preview(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewPath(scene.GetHash(config.GetVideoFileNamingAlgorithm()))
@@ -228,26 +300,99 @@ func getChapterVttTitle(marker *models.SceneMarker) string {
 	return ret
 }
 
-func (rs sceneRoutes) ChapterVtt(w http.ResponseWriter, r *http.Request) {
-	scene := r.Context().Value(sceneKey).(*models.Scene)
+// getMarkerChapters returns the markers of the scene as chapters, each
+// ending where the next one starts.
+func getMarkerChapters(s *models.Scene) ([]scene.Chapter, error) {
 	qb := models.NewSceneMarkerQueryBuilder()
-	sceneMarkers, err := qb.FindBySceneID(scene.ID, nil)
+	sceneMarkers, err := qb.FindBySceneID(s.ID, nil)
 	if err != nil {
-		panic("invalid scene markers for chapter vtt")
+		return nil, err
 	}
 
-	vttLines := []string{"WEBVTT", ""}
-	for i, marker := range sceneMarkers {
-		vttLines = append(vttLines, strconv.Itoa(i+1))
-		time := utils.GetVTTTime(marker.Seconds)
-		vttLines = append(vttLines, time+" --> "+time)
-		vttLines = append(vttLines, getChapterVttTitle(marker))
-		vttLines = append(vttLines, "")
+	var chapters []scene.Chapter
+	for _, marker := range sceneMarkers {
+		chapters = append(chapters, scene.Chapter{
+			Start: marker.Seconds,
+			Title: getChapterVttTitle(marker),
+		})
 	}
-	vtt := strings.Join(vttLines, "\n")
+	scene.SetChapterEnds(chapters, s.Duration.Float64)
 
-	w.Header().Set("Content-Type", "text/vtt")
-	_, _ = w.Write([]byte(vtt))
+	return chapters, nil
+}
+
+// serveMarkerChapters writes the markers of the scene as chapters in the
+// format of write.
+func serveMarkerChapters(w http.ResponseWriter, r *http.Request, contentType string, write func(io.Writer, []scene.Chapter) error) {
+	s := r.Context().Value(sceneKey).(*models.Scene)
+	chapters, err := getMarkerChapters(s)
+	if err != nil {
+		logger.Errorf("error getting scene markers: %s", err.Error())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if err := write(w, chapters); err != nil {
+		logger.Warnf("error writing scene chapters: %s", err.Error())
+	}
+}
+
+func (rs sceneRoutes) ChapterVtt(w http.ResponseWriter, r *http.Request) {
+	serveMarkerChapters(w, r, "text/vtt", scene.WriteWebVTTChapters)
+}
+
+func (rs sceneRoutes) ChaptersMatroska(w http.ResponseWriter, r *http.Request) {
+	serveMarkerChapters(w, r, "application/xml", scene.WriteMatroskaChapters)
+}
+
+func (rs sceneRoutes) ChaptersFFMetadata(w http.ResponseWriter, r *http.Request) {
+	serveMarkerChapters(w, r, "text/plain; charset=utf-8", scene.WriteFFMetadataChapters)
+}
+
+// Caption serves the caption file of the scene with the language code and
+// type of the lang and type query parameters, converted to WebVTT.
+func (rs sceneRoutes) Caption(w http.ResponseWriter, r *http.Request) {
+	s := r.Context().Value(sceneKey).(*models.Scene)
+	languageCode := r.URL.Query().Get("lang")
+	captionType := r.URL.Query().Get("type")
+
+	qb := models.NewSceneQueryBuilder()
+	captions, err := qb.GetCaptions(s.ID, nil)
+	if err != nil {
+		logger.Errorf("error getting scene captions: %s", err.Error())
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	for _, c := range captions {
+		if c.LanguageCode == languageCode && c.CaptionType == captionType {
+			serveCaption(w, r, c.Path(s.Path), c.CaptionType)
+			return
+		}
+	}
+
+	http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
+func serveCaption(w http.ResponseWriter, r *http.Request, path string, captionType string) {
+	w.Header().Set("Content-Type", "text/vtt; charset=utf-8")
+	if captionType == scene.CaptionTypeVTT {
+		http.ServeFile(w, r, path)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Warnf("error reading caption file %s: %s", path, err.Error())
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	if err := scene.WriteWebVTTFromSRT(w, f); err != nil {
+		logger.Warnf("error converting caption file %s: %s", path, err.Error())
+	}
 }
 
 func (rs sceneRoutes) VttThumbs(w http.ResponseWriter, r *http.Request) {
@@ -34,7 +34,7 @@ func (rs studioRoutes) Image(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(image) == 0 {
-		_, image, _ = utils.ProcessBase64Image(models.DefaultStudioImage)
+		image = getDefaultStudioImage()
 	}
 
 	utils.ServeImage(image, w, r)
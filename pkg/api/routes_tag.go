@@ -31,7 +31,7 @@ func (rs tagRoutes) Image(w http.ResponseWriter, r *http.Request) {
 	// use default image if not present
 	defaultParam := r.URL.Query().Get("default")
 	if len(image) == 0 || defaultParam == "true" {
-		image = models.DefaultTagImage
+		image = getDefaultTagImage()
 	}
 
 	utils.ServeImage(image, w, r)
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// scrapeHistoryMaxAge is the maximum age of a stored scrape result that is
+// returned instead of scraping again with the same input.
+const scrapeHistoryMaxAge = 24 * time.Hour
+
+// scrapeInputChecksum returns a checksum identifying the input passed to a
+// scraper.
+func scrapeInputChecksum(input interface{}) string {
+	data, _ := json.Marshal(input)
+	return utils.MD5FromBytes(data)
+}
+
+// getRecentScrape populates result with the stored result of the most recent
+// scrape of the object from the source, if it was made with the same input
+// within scrapeHistoryMaxAge. Returns false if there is no such result.
+func getRecentScrape(entity string, entityID int, source string, inputChecksum string, result interface{}) bool {
+	qb := models.NewScrapeHistoryQueryBuilder()
+	latest, err := qb.FindLatest(entity, entityID, source)
+	if err != nil {
+		logger.Warnf("error getting scrape history: %s", err.Error())
+		return false
+	}
+
+	if latest == nil || !latest.Result.Valid || latest.InputChecksum != inputChecksum {
+		return false
+	}
+
+	if time.Since(latest.CreatedAt.Timestamp) > scrapeHistoryMaxAge {
+		return false
+	}
+
+	if err := json.Unmarshal([]byte(latest.Result.String), result); err != nil {
+		logger.Warnf("error decoding stored scrape result: %s", err.Error())
+		return false
+	}
+
+	return true
+}
+
+// recordScrape stores the scrape of the object from the source. Only the
+// result of the most recent scrape from each source is kept.
+func recordScrape(entity string, entityID int, source string, inputChecksum string, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		logger.Warnf("error encoding scrape result: %s", err.Error())
+		return
+	}
+
+	newHistory := models.ScrapeHistory{
+		Entity:        entity,
+		EntityID:      entityID,
+		Source:        source,
+		Fields:        strings.Join(populatedFields(data), ","),
+		InputChecksum: inputChecksum,
+		Result:        sql.NullString{String: string(data), Valid: true},
+		CreatedAt:     models.SQLiteTimestamp{Timestamp: time.Now()},
+	}
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	qb := models.NewScrapeHistoryQueryBuilder()
+
+	if err := qb.ClearResults(entity, entityID, source, tx); err != nil {
+		_ = tx.Rollback()
+		logger.Warnf("error recording scrape history: %s", err.Error())
+		return
+	}
+
+	if _, err := qb.Create(newHistory, tx); err != nil {
+		_ = tx.Rollback()
+		logger.Warnf("error recording scrape history: %s", err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Warnf("error recording scrape history: %s", err.Error())
+	}
+}
+
+// populatedFields returns the sorted names of the fields of the JSON encoded
+// scrape result that have a value.
+func populatedFields(data []byte) []string {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil
+	}
+
+	var ret []string
+	for k, v := range values {
+		switch vv := v.(type) {
+		case nil:
+			continue
+		case string:
+			if vv == "" {
+				continue
+			}
+		case []interface{}:
+			if len(vv) == 0 {
+				continue
+			}
+		}
+
+		ret = append(ret, k)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+// recordAppliedScrapeFields adds the fields of the stored scrape results of
+// the object whose values were saved by an update to the applied fields of
+// the scrapes. values holds the saved values, keyed by the name of the field
+// in the scrape result. Values are strings, or string slices of ids for
+// fields holding lists of matched objects.
+func recordAppliedScrapeFields(entity string, entityID int, values map[string]interface{}, tx *sqlx.Tx) {
+	qb := models.NewScrapeHistoryQueryBuilder()
+	histories, err := qb.FindResults(entity, entityID, tx)
+	if err != nil {
+		logger.Warnf("error getting scrape history: %s", err.Error())
+		return
+	}
+
+	for _, history := range histories {
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(history.Result.String), &result); err != nil {
+			logger.Warnf("error decoding stored scrape result: %s", err.Error())
+			continue
+		}
+
+		applied := history.GetAppliedFields()
+		changed := false
+		for field, saved := range values {
+			if !utils.StrInclude(applied, field) && isScrapedValueApplied(result[field], saved) {
+				applied = append(applied, field)
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		sort.Strings(applied)
+		if err := qb.SetAppliedFields(history.ID, applied, tx); err != nil {
+			logger.Warnf("error recording applied scrape fields: %s", err.Error())
+		}
+	}
+}
+
+// isScrapedValueApplied returns true if the saved value is the value of the
+// JSON decoded scrape result field. A list of matched objects is applied if
+// all of the matched objects were saved.
+func isScrapedValueApplied(scraped interface{}, saved interface{}) bool {
+	switch s := saved.(type) {
+	case string:
+		switch v := scraped.(type) {
+		case string:
+			return v != "" && v == s
+		case map[string]interface{}:
+			id := getScrapedMatchedID(v)
+			return id != "" && id == s
+		}
+	case []string:
+		list, ok := scraped.([]interface{})
+		if !ok {
+			return false
+		}
+
+		matched := false
+		for _, item := range list {
+			v, _ := item.(map[string]interface{})
+			id := getScrapedMatchedID(v)
+			if id == "" {
+				continue
+			}
+			if !utils.StrInclude(s, id) {
+				return false
+			}
+			matched = true
+		}
+		return matched
+	}
+
+	return false
+}
+
+// getScrapedMatchedID returns the id of the existing object matched by a
+// JSON decoded scraped object, or an empty string if it was not matched.
+func getScrapedMatchedID(v map[string]interface{}) string {
+	for _, key := range []string{"id", "stored_id"} {
+		if id, ok := v[key].(string); ok {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// getSceneDateSuggestions returns the dates inferred by the date inference
+// task that have not been applied, keyed by scene id.
+func getSceneDateSuggestions() (map[int]*models.SceneDateInference, error) {
+	qb := models.NewScrapeHistoryQueryBuilder()
+	histories, err := qb.FindResultsBySource(models.ScrapeHistoryEntityScene, models.ScrapeHistorySourceDateInference)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int]*models.SceneDateInference)
+	for _, history := range histories {
+		if ret[history.EntityID] != nil || utils.StrInclude(history.GetAppliedFields(), "date") {
+			continue
+		}
+
+		var inference models.SceneDateInference
+		if err := json.Unmarshal([]byte(history.Result.String), &inference); err != nil {
+			logger.Warnf("error decoding stored date inference: %s", err.Error())
+			continue
+		}
+
+		ret[history.EntityID] = &inference
+	}
+
+	return ret, nil
+}
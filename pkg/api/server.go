@@ -14,13 +14,16 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/handler"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/gobuffalo/packr/v2"
 	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
+	"github.com/stashapp/stash/pkg/api/loaders"
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
@@ -51,10 +54,11 @@ func authenticateHandler() func(http.Handler) http.Handler {
 
 			// translate api key into current user, if present
 			userID := ""
+			role := ""
 			var err error
 
 			// handle session
-			userID, err = getSessionUserID(w, r)
+			userID, role, err = getSessionUser(w, r)
 
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
@@ -62,6 +66,13 @@ func authenticateHandler() func(http.Handler) http.Handler {
 				return
 			}
 
+			// a user authenticated by a trusted proxy takes precedence over
+			// the session
+			if proxyUserID := getTrustedProxyUserID(r); proxyUserID != "" {
+				userID = proxyUserID
+				role = roleAdmin
+			}
+
 			// handle redirect if no user and user is required
 			if userID == "" && config.HasCredentials() && !allowUnauthenticated(r) {
 				// always allow
@@ -86,6 +97,7 @@ func authenticateHandler() func(http.Handler) http.Handler {
 			}
 
 			ctx = context.WithValue(ctx, ContextUser, userID)
+			ctx = context.WithValue(ctx, ContextRole, role)
 
 			r = r.WithContext(ctx)
 
@@ -94,6 +106,84 @@ func authenticateHandler() func(http.Handler) http.Handler {
 	}
 }
 
+// roleMiddleware rejects the mutations of users with the viewer role.
+func roleMiddleware(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if fc := graphql.GetFieldContext(ctx); fc != nil && fc.Object == "Mutation" && getCurrentUserRole(ctx) == roleViewer {
+		return nil, errors.New("viewers cannot make changes")
+	}
+
+	return next(ctx)
+}
+
+// corsHandler returns the CORS handler for the allowed origins and headers.
+// All origins and headers are allowed if none are configured.
+func corsHandler(origins []string, headers []string) *cors.Cors {
+	if len(origins) == 0 && len(headers) == 0 {
+		return cors.AllowAll()
+	}
+
+	options := cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"HEAD", "GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders: []string{"*"},
+	}
+
+	if len(origins) > 0 {
+		options.AllowedOrigins = origins
+		// allow explicitly trusted origins to send the session cookie
+		options.AllowCredentials = true
+	}
+
+	if len(headers) > 0 {
+		options.AllowedHeaders = headers
+	}
+
+	return cors.New(options)
+}
+
+// corsMiddleware applies the CORS handler of the configured allowed origins
+// and headers. The handler is rebuilt when the configuration changes, so
+// that changes apply without a restart.
+func corsMiddleware(next http.Handler) http.Handler {
+	var mutex sync.Mutex
+	var current http.Handler
+	var currentKey string
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origins := config.GetCORSAllowedOrigins()
+		headers := config.GetCORSAllowedHeaders()
+		key := strings.Join(origins, "\n") + "\x00" + strings.Join(headers, "\n")
+
+		mutex.Lock()
+		if current == nil || key != currentKey {
+			current = corsHandler(origins, headers).Handler(next)
+			currentKey = key
+		}
+		h := current
+		mutex.Unlock()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// playgroundHandler serves the GraphQL playground if it is enabled. A valid
+// session is required if credentials are configured.
+func playgroundHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !config.GetEnablePlayground() {
+			http.NotFound(w, r)
+			return
+		}
+
+		if userID, _ := r.Context().Value(ContextUser).(string); userID == "" && config.HasCredentials() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 const setupEndPoint = "/setup"
 const migrateEndPoint = "/migrate"
 const loginEndPoint = "/login"
@@ -109,15 +199,18 @@ func Start() {
 
 	r := chi.NewRouter()
 
+	// preflight requests are sent without credentials, so they must be
+	// answered before authentication
+	r.Use(corsMiddleware)
 	r.Use(authenticateHandler())
 	r.Use(middleware.Recoverer)
+	r.Use(tracingMiddleware)
 
 	if config.GetLogAccess() {
 		r.Use(middleware.Logger)
 	}
 	r.Use(middleware.DefaultCompress)
 	r.Use(middleware.StripSlashes)
-	r.Use(cors.AllowAll().Handler)
 	r.Use(BaseURLMiddleware)
 	r.Use(ConfigCheckMiddleware)
 	r.Use(DatabaseCheckMiddleware)
@@ -134,16 +227,29 @@ func Start() {
 			return true
 		},
 	})
-	gqlHandler := handler.GraphQL(models.NewExecutableSchema(models.Config{Resolvers: &Resolver{}}), recoverFunc, websocketUpgrader)
-
-	r.Handle("/graphql", gqlHandler)
-	r.Handle("/playground", handler.Playground("GraphQL playground", "/graphql"))
+	schema := models.NewExecutableSchema(models.Config{Resolvers: &Resolver{}})
+	resolverMiddleware := handler.ResolverMiddleware(roleMiddleware)
+	tracingResolverMiddleware := handler.ResolverMiddleware(tracingFieldMiddleware)
+	tracingRequestMiddleware := handler.RequestMiddleware(tracingOperationMiddleware)
+	gqlHandler := handler.GraphQL(schema, recoverFunc, websocketUpgrader, resolverMiddleware, tracingResolverMiddleware, tracingRequestMiddleware)
+	gqlNoIntrospectionHandler := handler.GraphQL(schema, recoverFunc, websocketUpgrader, resolverMiddleware, tracingResolverMiddleware, tracingRequestMiddleware, handler.IntrospectionEnabled(false))
+
+	r.Handle("/graphql", batchMiddleware(loaders.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if config.GetEnableIntrospection() {
+			gqlHandler(w, r)
+		} else {
+			gqlNoIntrospectionHandler(w, r)
+		}
+	}))))
+	r.Handle("/playground", playgroundHandler(handler.Playground("GraphQL playground", "/graphql")))
 
 	// session handlers
 	r.Post(loginEndPoint, handleLogin)
 	r.Get("/logout", handleLogout)
 
 	r.Get(loginEndPoint, getLoginHandler)
+	r.Get(oidcLoginEndPoint, handleOIDCLogin)
+	r.Get(oidcCallbackEndPoint, handleOIDCCallback)
 
 	r.Mount("/performer", performerRoutes{}.Routes())
 	r.Mount("/scene", sceneRoutes{}.Routes())
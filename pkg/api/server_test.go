@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+func TestCORSMiddlewareFollowsConfig(t *testing.T) {
+	defer config.Set(config.CORSAllowedOrigins, config.GetCORSAllowedOrigins())
+	defer config.Set(config.CORSAllowedHeaders, config.GetCORSAllowedHeaders())
+
+	config.Set(config.CORSAllowedHeaders, []string{})
+	config.Set(config.CORSAllowedOrigins, []string{"https://a.example"})
+
+	h := corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	allowedOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, "https://a.example", allowedOrigin("https://a.example"))
+	assert.Empty(t, allowedOrigin("https://b.example"))
+
+	// configuration changes apply to the next request
+	config.Set(config.CORSAllowedOrigins, []string{"https://b.example"})
+	assert.Empty(t, allowedOrigin("https://a.example"))
+	assert.Equal(t, "https://b.example", allowedOrigin("https://b.example"))
+}
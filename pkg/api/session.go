@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"html/template"
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 
 	"github.com/gorilla/securecookie"
@@ -16,6 +19,14 @@ const cookieName = "session"
 const usernameFormKey = "username"
 const passwordFormKey = "password"
 const userIDKey = "userID"
+const roleKey = "role"
+
+// roles of the logged in users. Users logged in with the local credentials or
+// by a trusted proxy are admins. Viewers cannot run mutations.
+const (
+	roleAdmin  = "admin"
+	roleViewer = "viewer"
+)
 
 const returnURLParam = "returnURL"
 
@@ -24,6 +35,7 @@ var sessionStore = sessions.NewCookieStore(config.GetSessionStoreKey())
 type loginTemplateData struct {
 	URL   string
 	Error string
+	OIDC  bool
 }
 
 func initSessionStore() {
@@ -38,7 +50,7 @@ func redirectToLogin(w http.ResponseWriter, returnURL string, loginError string)
 		return
 	}
 
-	err = templ.Execute(w, loginTemplateData{URL: returnURL, Error: loginError})
+	err = templ.Execute(w, loginTemplateData{URL: returnURL, Error: loginError, OIDC: config.IsOIDCEnabled()})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("error: %s", err), http.StatusInternalServerError)
 	}
@@ -73,6 +85,7 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newSession.Values[userIDKey] = username
+	newSession.Values[roleKey] = roleAdmin
 
 	err := newSession.Save(r, w)
 	if err != nil {
@@ -91,6 +104,7 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	delete(session.Values, userIDKey)
+	delete(session.Values, roleKey)
 	session.Options.MaxAge = -1
 
 	err = session.Save(r, w)
@@ -103,12 +117,14 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 	getLoginHandler(w, r)
 }
 
-func getSessionUserID(w http.ResponseWriter, r *http.Request) (string, error) {
+// getSessionUser returns the user id and role of the session of the request.
+// Sessions created before roles were added are admin sessions.
+func getSessionUser(w http.ResponseWriter, r *http.Request) (string, string, error) {
 	session, err := sessionStore.Get(r, cookieName)
 	// ignore errors and treat as an empty user id, so that we handle expired
 	// cookie
 	if err != nil {
-		return "", nil
+		return "", "", nil
 	}
 
 	if !session.IsNew {
@@ -117,15 +133,97 @@ func getSessionUserID(w http.ResponseWriter, r *http.Request) (string, error) {
 		// refresh the cookie
 		err = session.Save(r, w)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 
 		ret, _ := val.(string)
+		role, _ := session.Values[roleKey].(string)
+		if role == "" {
+			role = roleAdmin
+		}
+
+		return ret, role, nil
+	}
+
+	return "", "", nil
+}
+
+// getTrustedProxyUserID returns the user named in the trusted proxy header of
+// the request, if proxy authentication is enabled and the request was made by
+// a trusted proxy. The configured user is the only user, so other usernames
+// set by the proxy are not authenticated.
+func getTrustedProxyUserID(r *http.Request) string {
+	header := config.GetTrustedProxyHeader()
+	if header == "" {
+		return ""
+	}
+
+	username := strings.TrimSpace(r.Header.Get(header))
+	if username == "" {
+		return ""
+	}
+
+	if !isTrustedProxy(r.RemoteAddr) {
+		logger.Debugf("ignoring %s header of request from untrusted address %s", header, r.RemoteAddr)
+		return ""
+	}
+
+	if !config.HasCredentials() || username != config.GetUsername() {
+		logger.Debugf("ignoring %s header of request for unknown user %s", header, username)
+		return ""
+	}
+
+	return username
+}
+
+// isTrustedProxy returns true if remoteAddr is the address of one of the
+// configured trusted proxies.
+func isTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range config.GetTrustedProxies() {
+		ipNet, err := parseTrustedProxy(proxy)
+		if err != nil {
+			logger.Debugf("invalid trusted proxy %s: %s", proxy, err.Error())
+			continue
+		}
 
-		return ret, nil
+		if ipNet.Contains(ip) {
+			return true
+		}
 	}
 
-	return "", nil
+	return false
+}
+
+// parseTrustedProxy returns the range of addresses of a trusted proxy, which
+// may be an IP address or a CIDR range.
+func parseTrustedProxy(proxy string) (*net.IPNet, error) {
+	if strings.Contains(proxy, "/") {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		return ipNet, err
+	}
+
+	ip := net.ParseIP(proxy)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid trusted proxy address %s", proxy)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
 }
 
 func getCurrentUserID(ctx context.Context) *string {
@@ -138,6 +236,16 @@ func getCurrentUserID(ctx context.Context) *string {
 	return nil
 }
 
+// getCurrentUserRole returns the role of the current user. Requests without a
+// user are only possible without credentials, and are treated as admins.
+func getCurrentUserRole(ctx context.Context) string {
+	if role, _ := ctx.Value(ContextRole).(string); role != "" {
+		return role
+	}
+
+	return roleAdmin
+}
+
 func createSessionCookie(username string) (*http.Cookie, error) {
 	session := sessions.NewSession(sessionStore, cookieName)
 	session.Values[userIDKey] = username
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// statsSnapshotInterval is the time between updates of the stats snapshot of
+// the current day, so that the snapshot of each day has the stats at its end.
+const statsSnapshotInterval = time.Hour
+
+// statsSnapshotRetryInterval is the time between checks for the database to
+// be ready to record stats snapshots.
+const statsSnapshotRetryInterval = time.Minute
+
+const statsSnapshotDateFormat = "2006-01-02"
+
+func init() {
+	go recordStatsSnapshots()
+}
+
+func getStats() (*models.StatsResultType, error) {
+	ret := &models.StatsResultType{}
+
+	var err error
+	scenesQB := models.NewSceneQueryBuilder()
+	if ret.SceneCount, err = scenesQB.Count(); err != nil {
+		return nil, err
+	}
+	if ret.ScenesSize, err = scenesQB.Size(); err != nil {
+		return nil, err
+	}
+	imageQB := models.NewImageQueryBuilder()
+	if ret.ImageCount, err = imageQB.Count(); err != nil {
+		return nil, err
+	}
+	if ret.ImagesSize, err = imageQB.Size(); err != nil {
+		return nil, err
+	}
+	galleryQB := models.NewGalleryQueryBuilder()
+	if ret.GalleryCount, err = galleryQB.Count(); err != nil {
+		return nil, err
+	}
+	performersQB := models.NewPerformerQueryBuilder()
+	if ret.PerformerCount, err = performersQB.Count(); err != nil {
+		return nil, err
+	}
+	studiosQB := models.NewStudioQueryBuilder()
+	if ret.StudioCount, err = studiosQB.Count(); err != nil {
+		return nil, err
+	}
+	moviesQB := models.NewMovieQueryBuilder()
+	if ret.MovieCount, err = moviesQB.Count(); err != nil {
+		return nil, err
+	}
+	tagsQB := models.NewTagQueryBuilder()
+	if ret.TagCount, err = tagsQB.Count(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// recordStatsSnapshots periodically saves the current stats as the snapshot
+// of the current day.
+func recordStatsSnapshots() {
+	for {
+		interval := statsSnapshotInterval
+		if database.DB == nil || database.NeedsMigration() {
+			interval = statsSnapshotRetryInterval
+		} else if err := saveStatsSnapshot(time.Now()); err != nil {
+			logger.Errorf("error recording stats snapshot: %s", err.Error())
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// saveStatsSnapshot saves the current stats as the snapshot of the day of now,
+// replacing the existing snapshot of the day.
+func saveStatsSnapshot(now time.Time) error {
+	stats, err := getStats()
+	if err != nil {
+		return err
+	}
+
+	streamQB := models.NewStreamSessionQueryBuilder()
+	streamTotals, err := streamQB.Totals(nil)
+	if err != nil {
+		return err
+	}
+
+	snapshot := models.StatsSnapshot{
+		Date:               now.Format(statsSnapshotDateFormat),
+		SceneCount:         stats.SceneCount,
+		ScenesSize:         stats.ScenesSize,
+		ImageCount:         stats.ImageCount,
+		ImagesSize:         stats.ImagesSize,
+		GalleryCount:       stats.GalleryCount,
+		PerformerCount:     stats.PerformerCount,
+		StudioCount:        stats.StudioCount,
+		MovieCount:         stats.MovieCount,
+		TagCount:           stats.TagCount,
+		StreamSessionCount: streamTotals.SessionCount,
+		StreamDuration:     streamTotals.Duration,
+		StreamBytes:        float64(streamTotals.Bytes),
+	}
+
+	tx, err := database.DB.BeginTxx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+
+	qb := models.NewStatsSnapshotQueryBuilder()
+	if err := qb.Save(snapshot, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
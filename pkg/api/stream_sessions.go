@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// streamSessionTimeout is the time since the last request of a stream session
+// after which a new request from the client starts a new session.
+const streamSessionTimeout = 5 * time.Minute
+
+type streamSessionKey struct {
+	sceneID    int
+	remoteHost string
+	client     string
+	transcode  bool
+}
+
+// streamSessionQueueSize is the number of stream requests which may be
+// waiting to be recorded before further requests are dropped.
+const streamSessionQueueSize = 1000
+
+type streamRequest struct {
+	key   streamSessionKey
+	start time.Time
+	end   time.Time
+	bytes int64
+}
+
+// streamSessionTracker groups the stream requests made by clients into
+// sessions, since players typically make many range requests while playing a
+// single scene. The requests are recorded by a single goroutine, which owns
+// the sessions, so that requests are not blocked by the database.
+type streamSessionTracker struct {
+	queue    chan streamRequest
+	sessions map[streamSessionKey]*models.StreamSession
+}
+
+var streamSessions = newStreamSessionTracker()
+
+func newStreamSessionTracker() *streamSessionTracker {
+	t := &streamSessionTracker{
+		queue:    make(chan streamRequest, streamSessionQueueSize),
+		sessions: make(map[streamSessionKey]*models.StreamSession),
+	}
+	go t.run()
+	return t
+}
+
+// add queues the stream request to be recorded.
+func (t *streamSessionTracker) add(r streamRequest) {
+	select {
+	case t.queue <- r:
+	default:
+		logger.Warnf("stream session queue is full, not recording stream of scene %d", r.key.sceneID)
+	}
+}
+
+func (t *streamSessionTracker) run() {
+	for r := range t.queue {
+		if err := t.record(r); err != nil {
+			logger.Errorf("error recording stream session: %s", err.Error())
+		}
+	}
+}
+
+// record adds a stream request to the client's current session for the
+// scene, or starts a new session.
+//
+// The session duration is the wall-clock time during which the client was
+// receiving data, not the length of the scene that was watched. Only the
+// time not already covered by an earlier request of the session is added, so
+// that concurrent requests are not counted twice.
+func (t *streamSessionTracker) record(r streamRequest) error {
+	// forget sessions that can no longer be continued
+	for k, s := range t.sessions {
+		if r.end.Sub(s.EndedAt.Timestamp) > streamSessionTimeout {
+			delete(t.sessions, k)
+		}
+	}
+
+	if database.DB == nil {
+		return nil
+	}
+
+	tx, err := database.DB.BeginTxx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+
+	qb := models.NewStreamSessionQueryBuilder()
+
+	session := t.sessions[r.key]
+	if session == nil {
+		newSession := models.StreamSession{
+			SceneID:   r.key.sceneID,
+			Client:    r.key.client,
+			Transcode: r.key.transcode,
+			Duration:  r.end.Sub(r.start).Seconds(),
+			Bytes:     r.bytes,
+			StartedAt: models.SQLiteTimestamp{Timestamp: r.start},
+			EndedAt:   models.SQLiteTimestamp{Timestamp: r.end},
+		}
+
+		session, err = qb.Create(newSession, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	} else {
+		updated := *session
+		start := r.start
+		if start.Before(updated.EndedAt.Timestamp) {
+			start = updated.EndedAt.Timestamp
+		}
+		if r.end.After(start) {
+			updated.Duration += r.end.Sub(start).Seconds()
+			updated.EndedAt = models.SQLiteTimestamp{Timestamp: r.end}
+		}
+		updated.Bytes += r.bytes
+
+		if err := qb.UpdateProgress(updated, tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		session = &updated
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	t.sessions[r.key] = session
+	return nil
+}
+
+// streamSessionWriter counts the bytes written in response to a stream
+// request, so that the request can be recorded when it ends.
+type streamSessionWriter struct {
+	http.ResponseWriter
+	key   streamSessionKey
+	start time.Time
+	bytes int64
+}
+
+func newStreamSessionWriter(w http.ResponseWriter, r *http.Request, scene *models.Scene, transcode bool) *streamSessionWriter {
+	remoteHost, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteHost = r.RemoteAddr
+	}
+
+	manager.StreamStarted()
+
+	return &streamSessionWriter{
+		ResponseWriter: w,
+		key: streamSessionKey{
+			sceneID:    scene.ID,
+			remoteHost: remoteHost,
+			client:     r.UserAgent(),
+			transcode:  transcode,
+		},
+		start: time.Now(),
+	}
+}
+
+func (w *streamSessionWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// end records the request. Requests that did not stream any data are not
+// recorded.
+func (w *streamSessionWriter) end() {
+	manager.StreamEnded()
+
+	if w.bytes == 0 {
+		return
+	}
+
+	streamSessions.add(streamRequest{
+		key:   w.key,
+		start: w.start,
+		end:   time.Now(),
+		bytes: w.bytes,
+	})
+}
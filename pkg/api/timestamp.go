@@ -0,0 +1,24 @@
+package api
+
+import (
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// resolveTimestamp returns the database timestamp in the configured
+// timezone. Timestamps are stored in UTC.
+func resolveTimestamp(t models.SQLiteTimestamp) *time.Time {
+	ret := t.Timestamp.In(utils.GetTimezone())
+	return &ret
+}
+
+// resolveNullTimestamp is resolveTimestamp for nullable timestamps.
+func resolveNullTimestamp(t models.NullSQLiteTimestamp) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return resolveTimestamp(models.SQLiteTimestamp{Timestamp: t.Timestamp})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+
+	"github.com/stashapp/stash/pkg/tracing"
+)
+
+// tracingMiddleware records a span for each HTTP request, which continues
+// the trace of the traceparent header if the request has one. The span is
+// named after the route handling the request.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tracing.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := tracing.ContextWithTraceParent(r.Context(), r.Header.Get("traceparent"))
+		ctx, span := tracing.Start(ctx, r.Method)
+		defer span.End()
+
+		span.SetKind(tracing.KindServer)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.Path)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+		}
+		span.SetAttribute("http.status_code", ww.Status())
+	})
+}
+
+// tracingOperationMiddleware records a span for each GraphQL operation.
+func tracingOperationMiddleware(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !tracing.Enabled() {
+		return next(ctx)
+	}
+
+	name := "graphql"
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil {
+		name += " " + string(oc.Operation.Operation)
+	}
+	if oc.OperationName != "" {
+		name += " " + oc.OperationName
+	}
+
+	ctx, span := tracing.Start(ctx, name)
+	defer span.End()
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetError(resp.Errors)
+	}
+
+	return resp
+}
+
+// tracingFieldMiddleware records a span for each GraphQL field resolved by a
+// resolver method, such as Query.findScenes or Scene.performers.
+func tracingFieldMiddleware(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !tracing.Enabled() || fc == nil || !fc.IsMethod {
+		return next(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, fc.Object+"."+fc.Field.Name)
+	defer span.End()
+
+	ret, err := next(ctx)
+	span.SetError(err)
+
+	return ret, err
+}
@@ -21,3 +21,7 @@ func (b MovieURLBuilder) GetMovieFrontImageURL() string {
 func (b MovieURLBuilder) GetMovieBackImageURL() string {
 	return b.BaseURL + "/movie/" + b.MovieID + "/backimage"
 }
+
+func (b MovieURLBuilder) GetMovieBackgroundImageURL() string {
+	return b.BaseURL + "/movie/" + b.MovieID + "/background"
+}
@@ -1,6 +1,7 @@
 package urlbuilders
 
 import (
+	"net/url"
 	"strconv"
 	"time"
 )
@@ -41,6 +42,21 @@ func (b SceneURLBuilder) GetChaptersVTTURL() string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/vtt/chapter"
 }
 
+func (b SceneURLBuilder) GetChaptersMatroskaURL() string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/chapters.xml"
+}
+
+func (b SceneURLBuilder) GetChaptersFFMetadataURL() string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/chapters.ffmetadata"
+}
+
+func (b SceneURLBuilder) GetCaptionURL(languageCode string, captionType string) string {
+	v := url.Values{}
+	v.Set("lang", languageCode)
+	v.Set("type", captionType)
+	return b.BaseURL + "/scene/" + b.SceneID + "/caption?" + v.Encode()
+}
+
 func (b SceneURLBuilder) GetSceneMarkerStreamURL(sceneMarkerID int) string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/scene_marker/" + strconv.Itoa(sceneMarkerID) + "/stream"
 }
@@ -48,3 +64,7 @@ func (b SceneURLBuilder) GetSceneMarkerStreamURL(sceneMarkerID int) string {
 func (b SceneURLBuilder) GetSceneMarkerStreamPreviewURL(sceneMarkerID int) string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/scene_marker/" + strconv.Itoa(sceneMarkerID) + "/preview"
 }
+
+func (b SceneURLBuilder) GetBackgroundURL() string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/background"
+}
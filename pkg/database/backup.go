@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+const (
+	// backupPagesPerStep is the number of pages copied by each step of a
+	// backup. The database is locked only during a step, so writers are
+	// blocked for at most the time taken to copy this many pages.
+	backupPagesPerStep = 256
+	// backupStepInterval is the time between steps of a backup, during which
+	// writers may proceed.
+	backupStepInterval = 10 * time.Millisecond
+	// backupMaxRestarts is the number of times a backup is restarted by
+	// writes to the database before the remaining pages are copied in a
+	// single step, so that a busy database is still backed up.
+	backupMaxRestarts = 5
+)
+
+// BackupProgressFunc receives the number of pages copied and the total
+// number of pages of the database during a backup.
+type BackupProgressFunc func(copied int, total int)
+
+// Backup copies the database into a new file at backupPath using the online
+// backup API of SQLite. The database may be used while it is backed up and
+// the backup is a consistent snapshot of it. progress is called after each
+// step of the backup if it is not nil. The backup file is removed if the
+// backup fails.
+func Backup(backupPath string, progress BackupProgressFunc) error {
+	if _, err := os.Stat(backupPath); err == nil {
+		return fmt.Errorf("backup file %s already exists", backupPath)
+	}
+
+	logger.Infof("Backing up database into: %s", backupPath)
+	if err := backup(dbPath, backupPath, progress); err != nil {
+		os.Remove(backupPath)
+		return fmt.Errorf("backup of database %s failed: %s", dbPath, err.Error())
+	}
+
+	return nil
+}
+
+func backup(srcPath string, destPath string, progress BackupProgressFunc) error {
+	ctx := context.TODO()
+
+	srcDB, err := sql.Open(sqlite3Driver, "file:"+srcPath+"?_fk=true")
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+
+	destDB, err := sql.Open(sqlite3Driver, "file:"+destPath)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	srcConn, err := srcDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(dest interface{}) error {
+		return srcConn.Raw(func(src interface{}) error {
+			b, err := dest.(*sqlite3.SQLiteConn).Backup("main", src.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+
+			if err := stepBackup(b, progress); err != nil {
+				b.Close()
+				return err
+			}
+
+			return b.Finish()
+		})
+	})
+}
+
+// stepBackup copies the pages of the database in steps until the backup is
+// done.
+func stepBackup(b *sqlite3.SQLiteBackup, progress BackupProgressFunc) error {
+	pages := backupPagesPerStep
+	restarts := 0
+	remaining := -1
+	for {
+		done, err := b.Step(pages)
+		if err != nil {
+			return err
+		}
+
+		total := b.PageCount()
+		if progress != nil {
+			progress(total-b.Remaining(), total)
+		}
+		if done {
+			return nil
+		}
+
+		// SQLite restarts the backup when another connection writes to the
+		// database
+		if remaining >= 0 && b.Remaining() > remaining {
+			restarts++
+			if restarts >= backupMaxRestarts {
+				pages = -1
+			}
+		}
+		remaining = b.Remaining()
+
+		time.Sleep(backupStepInterval)
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-backup-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcPath := filepath.Join(dir, "stash-go.sqlite")
+	src, err := sql.Open(sqlite3Driver, "file:"+srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	// enough rows for the backup to take several steps
+	const rows = 2000
+	statements := []string{
+		"CREATE TABLE test (id integer primary key, value text)",
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < " + strconv.Itoa(rows) + ") INSERT INTO test (value) SELECT printf('%.1000c', 'x') FROM n",
+	}
+	for _, s := range statements {
+		if _, err := src.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldPath := dbPath
+	dbPath = srcPath
+	defer func() {
+		dbPath = oldPath
+	}()
+
+	backupPath := filepath.Join(dir, "backup.sqlite")
+	steps := 0
+	copied := 0
+	err = Backup(backupPath, func(c int, total int) {
+		steps++
+		copied = c
+		assert.True(t, c <= total)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, steps > 1)
+	assert.True(t, copied > 0)
+
+	dest, err := sql.Open(sqlite3Driver, "file:"+backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dest.Close()
+
+	var count int
+	if err := dest.QueryRow("SELECT COUNT(*) FROM test").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, rows, count)
+
+	// existing files are not overwritten
+	assert.NotNil(t, Backup(backupPath, nil))
+}
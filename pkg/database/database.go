@@ -7,7 +7,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/fvbommel/sortorder"
 	"github.com/gobuffalo/packr/v2"
 	"github.com/golang-migrate/migrate/v4"
 	sqlite3mig "github.com/golang-migrate/migrate/v4/database/sqlite3"
@@ -20,7 +19,7 @@ import (
 
 var DB *sqlx.DB
 var dbPath string
-var appSchemaVersion uint = 17
+var appSchemaVersion uint = 49
 var databaseSchemaVersion uint
 
 const sqlite3Driver = "sqlite3ex"
@@ -33,10 +32,18 @@ func init() {
 // Initialize initializes the database. If the database is new, then it
 // performs a full migration to the latest schema version. Otherwise, any
 // necessary migrations must be run separately using RunMigrations.
-// Returns true if the database is new.
+// If the database is corrupt, then the connection is not opened and the
+// database must be recovered first. Returns true if the database is new.
 func Initialize(databasePath string) bool {
 	dbPath = databasePath
 
+	checkIntegrity()
+	if NeedsRecovery() {
+		// the schema version may not be readable, so it is not required
+		_ = getDatabaseSchemaVersion()
+		return false
+	}
+
 	if err := getDatabaseSchemaVersion(); err != nil {
 		panic(err)
 	}
@@ -99,23 +106,6 @@ func Reset(databasePath string) error {
 	return nil
 }
 
-// Backup the database
-func Backup(backupPath string) error {
-	db, err := sqlx.Connect(sqlite3Driver, "file:"+dbPath+"?_fk=true")
-	if err != nil {
-		return fmt.Errorf("Open database %s failed:%s", dbPath, err)
-	}
-	defer db.Close()
-
-	logger.Infof("Backing up database into: %s", backupPath)
-	_, err = db.Exec(`VACUUM INTO "` + backupPath + `"`)
-	if err != nil {
-		return fmt.Errorf("Vacuum failed: %s", err)
-	}
-
-	return nil
-}
-
 func RestoreFromBackup(backupPath string) error {
 	logger.Infof("Restoring backup database %s into %s", backupPath, dbPath)
 	return os.Rename(backupPath, dbPath)
@@ -123,7 +113,7 @@ func RestoreFromBackup(backupPath string) error {
 
 // Migrate the database
 func NeedsMigration() bool {
-	return databaseSchemaVersion != appSchemaVersion
+	return !NeedsRecovery() && databaseSchemaVersion != appSchemaVersion
 }
 
 func AppSchemaVersion() uint {
@@ -216,8 +206,21 @@ func registerCustomDriver() {
 		&sqlite3.SQLiteDriver{
 			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
 				funcs := map[string]interface{}{
-					"regexp":            regexFn,
-					"durationToTinyInt": durationToTinyIntFn,
+					"regexp":             regexFn,
+					"durationToTinyInt":  durationToTinyIntFn,
+					"phash_distance":     phashDistanceFn,
+					"display_title":      displayTitleFn,
+					"quality_score":      qualityScoreFn,
+					"slugify":            slugifyFn,
+					"url_site":           urlSiteFn,
+					"social_url":         socialURLFn,
+					"parse_height":       parseHeightFn,
+					"parse_measurement":  parseMeasurementFn,
+					"search_fold":        searchFoldFn,
+					"title_distance":     titleDistanceFn,
+					"gallery_cover_rank": galleryCoverRankFn,
+					"json_string":        jsonStringFn,
+					"natural_sort_key":   naturalSortKeyFn,
 				}
 
 				for name, fn := range funcs {
@@ -226,17 +229,17 @@ func registerCustomDriver() {
 					}
 				}
 
-				// COLLATE NATURAL_CS - Case sensitive natural sort
-				err := conn.RegisterCollation("NATURAL_CS", func(s string, s2 string) int {
-					if sortorder.NaturalLess(s, s2) {
-						return -1
-					} else {
-						return 1
-					}
-				})
+				collations := map[string]func(string, string) int{
+					// COLLATE NATURAL_CS - Case sensitive natural sort
+					"NATURAL_CS": naturalCompareFn,
+					// COLLATE NATURAL_CI - Case insensitive natural sort
+					"NATURAL_CI": naturalCaseInsensitiveCompareFn,
+				}
 
-				if err != nil {
-					return fmt.Errorf("Error registering natural sort collation: %s", err.Error())
+				for name, fn := range collations {
+					if err := conn.RegisterCollation(name, fn); err != nil {
+						return fmt.Errorf("Error registering collation %s: %s", name, err.Error())
+					}
 				}
 
 				return nil
@@ -1,13 +1,142 @@
 package database
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/fvbommel/sortorder"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
+// maxCachedRegexps is the number of compiled regular expressions kept by
+// regexFn. The cache is cleared when it is full.
+const maxCachedRegexps = 100
+
+var (
+	regexpCache      = make(map[string]*regexp.Regexp)
+	regexpCacheMutex sync.Mutex
+)
+
+// regexFn returns true if s matches the regular expression re. The compiled
+// expression is cached, since the function is called for every row matched
+// against the same expression.
 func regexFn(re, s string) (bool, error) {
-	return regexp.MatchString(re, s)
+	regexpCacheMutex.Lock()
+	compiled, found := regexpCache[re]
+	if !found {
+		var err error
+		compiled, err = regexp.Compile(re)
+		if err != nil {
+			regexpCacheMutex.Unlock()
+			return false, err
+		}
+
+		if len(regexpCache) >= maxCachedRegexps {
+			regexpCache = make(map[string]*regexp.Regexp)
+		}
+		regexpCache[re] = compiled
+	}
+	regexpCacheMutex.Unlock()
+
+	return compiled.MatchString(s), nil
+}
+
+// phashDistanceFn returns the Hamming distance between two perceptual hashes.
+func phashDistanceFn(phash1, phash2 int64) (int64, error) {
+	return int64(bits.OnesCount64(uint64(phash1 ^ phash2))), nil
+}
+
+// displayTitleFn returns the display title of a scene, or of another object
+// with a title and a file path.
+func displayTitleFn(title, path string) (string, error) {
+	return utils.DisplayTitle(title, path), nil
+}
+
+// qualityScoreFn returns the quality score of a video.
+func qualityScoreFn(width, height, bitrate int64, framerate float64, codec string) (float64, error) {
+	return utils.QualityScore(width, height, bitrate, framerate, codec), nil
+}
+
+// titleDistanceFn returns how much the title of a scene differs from its
+// file name.
+func titleDistanceFn(title, path string) (float64, error) {
+	return utils.TitleDistance(title, path), nil
+}
+
+// galleryCoverRankFn returns how suitable an image file is as the automatic
+// cover of its gallery, lowest first.
+func galleryCoverRankFn(path string) (int64, error) {
+	return int64(utils.GalleryCoverRank(path)), nil
+}
+
+// slugifyFn returns the slug of a name.
+func slugifyFn(name string) (string, error) {
+	return utils.Slugify(name), nil
+}
+
+// urlSiteFn returns the site of a link.
+func urlSiteFn(link string) (string, error) {
+	return utils.URLSite(link), nil
+}
+
+// socialURLFn returns the URL of the profile with the handle on the social
+// site with the base URL.
+func socialURLFn(base, handle string) (string, error) {
+	return utils.SocialURL(base, handle), nil
+}
+
+// parseHeightFn returns the height in centimetres of a height, or 0 if it
+// cannot be parsed.
+func parseHeightFn(height string) (int64, error) {
+	ret, _ := utils.ParseHeight(height)
+	return int64(ret), nil
+}
+
+// parseMeasurementFn returns the bust, waist or hips in centimetres of
+// measurements for the index 0, 1 or 2, or 0 if the measurements cannot be
+// parsed.
+func parseMeasurementFn(measurements string, index int64) (int64, error) {
+	bust, waist, hips, ok := utils.ParseMeasurements(measurements)
+	if !ok || index < 0 || index > 2 {
+		return 0, nil
+	}
+
+	return int64([]int{bust, waist, hips}[index]), nil
+}
+
+// searchFoldFn returns a value folded for searching. NULL is folded to an
+// empty string.
+func searchFoldFn(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return utils.FoldSearch(v), nil
+	case []byte:
+		return utils.FoldSearch(string(v)), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// jsonStringFn returns the value encoded as a JSON string, so that migrations
+// can build JSON documents from text which may contain any character.
+func jsonStringFn(v interface{}) (string, error) {
+	var str string
+	switch v := v.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		str = fmt.Sprint(v)
+	}
+
+	data, err := json.Marshal(str)
+	return string(data), err
 }
 
 func durationToTinyIntFn(str string) (int64, error) {
@@ -35,3 +164,92 @@ func durationToTinyIntFn(str string) (int64, error) {
 
 	return int64(seconds), nil
 }
+
+// naturalCompareFn compares two strings in natural sort order. It returns 0
+// only if the strings are identical, so that it may be used in indexes.
+func naturalCompareFn(s, s2 string) int {
+	if s == s2 {
+		return 0
+	}
+
+	if sortorder.NaturalLess(s, s2) {
+		return -1
+	}
+
+	return 1
+}
+
+// naturalCaseInsensitiveCompareFn compares two strings in case-insensitive
+// natural sort order.
+func naturalCaseInsensitiveCompareFn(s, s2 string) int {
+	return naturalCompareFn(strings.ToLower(s), strings.ToLower(s2))
+}
+
+// naturalSortKeyFn returns the key of a value in natural sort order, which
+// sorts bytewise in the same order as the NATURAL_CS collation, or as the
+// NATURAL_CI collation if caseInsensitive is true. NULL has the key of an
+// empty string.
+func naturalSortKeyFn(v interface{}, caseInsensitive bool) (string, error) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if caseInsensitive {
+		s = strings.ToLower(s)
+	}
+
+	return naturalSortKey(s), nil
+}
+
+// naturalSortKey returns the key of s in natural sort order. Each number is
+// replaced by a marker sorting before all other characters, followed by the
+// number of its digits, its digits, and the number of its leading zeros,
+// which break ties as in sortorder.NaturalLess.
+func naturalSortKey(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if !isDigit(s[i]) {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		zeros := 0
+		for ; i < len(s) && s[i] == '0'; i++ {
+			zeros++
+		}
+		start := i
+		for ; i < len(s) && isDigit(s[i]); i++ {
+		}
+
+		b.WriteByte(naturalSortNumberMarker)
+		b.WriteString(naturalSortCount(i - start))
+		b.WriteString(s[start:i])
+		b.WriteString(naturalSortCount(zeros))
+	}
+
+	return b.String()
+}
+
+// naturalSortNumberMarker precedes the numbers of natural sort keys.
+const naturalSortNumberMarker = '\x01'
+
+// naturalSortCount returns n as three digits, so that counts sort bytewise.
+// Larger counts are limited to 999.
+func naturalSortCount(n int) string {
+	if n > 999 {
+		n = 999
+	}
+	return fmt.Sprintf("%03d", n)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/fvbommel/sortorder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNaturalSortKey(t *testing.T) {
+	values := []string{
+		"", "0", "00", "1", "01", "2", "10", "100", "a", "a0", "a1", "a01",
+		"a2", "a10", "a10b", "a10b2", "a10b10", "a2b", "ab", "b1", "B1",
+		"Scene 9", "Scene 10", "Scene 10.5", "Scene 9 - Part 2", "x 007 y",
+		"x 7 y", "é", "e", "file.mp4", "file 2.mp4", "file10.mp4", "file2.mp4",
+	}
+
+	natural := append([]string(nil), values...)
+	sort.Sort(sortorder.Natural(natural))
+
+	byKey := append([]string(nil), values...)
+	sort.Slice(byKey, func(i, j int) bool {
+		return naturalSortKey(byKey[i]) < naturalSortKey(byKey[j])
+	})
+
+	assert.Equal(t, natural, byKey)
+
+	key, _ := naturalSortKeyFn("Scene 10", true)
+	assert.Equal(t, naturalSortKey("scene 10"), key)
+	key, _ = naturalSortKeyFn(nil, false)
+	assert.Equal(t, "", key)
+}
@@ -0,0 +1,333 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// integrityProblems are the problems found by the integrity check at
+// startup. The database is not opened while there are problems.
+var integrityProblems []string
+
+// backupNameRegex matches the suffix of the names of the backups made by
+// DatabaseBackupPath, capturing the schema version and the time.
+var backupNameRegex = regexp.MustCompile(`\.(\d+)\.(\d{8}_\d{6})$`)
+
+// NeedsRecovery returns true if the integrity check at startup found the
+// database to be corrupt. The database must be recovered with
+// RecoverFromBackup or RecoverByReload before it can be used.
+func NeedsRecovery() bool {
+	return len(integrityProblems) > 0
+}
+
+// IntegrityProblems returns the problems found by the integrity check at
+// startup.
+func IntegrityProblems() []string {
+	return integrityProblems
+}
+
+// quickCheck returns the problems found by SQLite's quick integrity check of
+// the database at path, or nil if there are none.
+func quickCheck(path string) []string {
+	db, err := sql.Open(sqlite3Driver, "file:"+path+"?mode=ro")
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PRAGMA quick_check")
+	if err != nil {
+		return []string{err.Error()}
+	}
+	defer rows.Close()
+
+	var ret []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return append(ret, err.Error())
+		}
+		if problem != "ok" {
+			ret = append(ret, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		ret = append(ret, err.Error())
+	}
+
+	return ret
+}
+
+// checkIntegrity runs the quick integrity check of the existing database and
+// keeps the problems it finds.
+func checkIntegrity() {
+	integrityProblems = nil
+	if info, err := os.Stat(dbPath); err != nil || info.Size() == 0 {
+		return
+	}
+
+	integrityProblems = quickCheck(dbPath)
+	if len(integrityProblems) > 0 {
+		logger.Errorf("Database %s is corrupt and must be recovered: %s", dbPath, strings.Join(integrityProblems, "; "))
+	}
+}
+
+// GoodBackups returns the backups of the database made by
+// DatabaseBackupPath that pass the integrity check and are not newer than
+// the schema of this version, newest first.
+func GoodBackups() ([]string, error) {
+	matches, err := filepath.Glob(dbPath + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	times := make(map[string]string)
+	for _, path := range matches {
+		m := backupNameRegex.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.ParseUint(m[1], 10, 0)
+		if uint(version) > appSchemaVersion || len(quickCheck(path)) > 0 {
+			continue
+		}
+
+		times[path] = m[2]
+		ret = append(ret, path)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return times[ret[i]] > times[ret[j]]
+	})
+
+	return ret, nil
+}
+
+// setAsideCorruptDatabase renames the corrupt database and its journals so
+// that they are kept but not used.
+func setAsideCorruptDatabase() error {
+	corruptPath := dbPath + ".corrupt." + time.Now().Format("20060102_150405")
+	logger.Infof("Moving corrupt database %s to %s", dbPath, corruptPath)
+
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if err := os.Rename(dbPath+suffix, corruptPath+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecoverFromBackup replaces the corrupt database with a copy of the
+// backup, which must pass the integrity check, and initializes it. If
+// backupPath is empty, the newest good backup is used. The corrupt database
+// is kept next to it.
+func RecoverFromBackup(backupPath string) error {
+	if backupPath == "" {
+		backups, err := GoodBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			return errors.New("no good backup of the database was found")
+		}
+		backupPath = backups[0]
+	}
+
+	if problems := quickCheck(backupPath); len(problems) > 0 {
+		return fmt.Errorf("backup %s is corrupt: %s", backupPath, strings.Join(problems, "; "))
+	}
+
+	if err := setAsideCorruptDatabase(); err != nil {
+		return err
+	}
+
+	logger.Infof("Restoring database %s from backup %s", dbPath, backupPath)
+	if err := copyFile(backupPath, dbPath); err != nil {
+		return err
+	}
+
+	Initialize(dbPath)
+	return nil
+}
+
+// copyFile copies the file at srcPath to destPath. The copy is written to a
+// temporary file first, so that destPath is never left partially written.
+func copyFile(srcPath string, destPath string) error {
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile(filepath.Dir(destPath), filepath.Base(destPath)+".restore-")
+	if err != nil {
+		return err
+	}
+
+	// temporary files are only readable by the owner
+	err = out.Chmod(0644)
+	if err == nil {
+		_, err = io.Copy(out, in)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(out.Name(), destPath)
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return err
+	}
+
+	return nil
+}
+
+// RecoverByReload replaces the corrupt database with a new database with
+// its schema, into which the rows that can still be read are copied, and
+// initializes it. It returns the tables from which rows could not be read.
+// The corrupt database is kept next to it.
+func RecoverByReload() ([]string, error) {
+	reloadPath := dbPath + ".reload"
+	os.Remove(reloadPath)
+
+	logger.Infof("Reloading corrupt database %s into a new database", dbPath)
+	lost, err := reload(dbPath, reloadPath)
+	if err != nil {
+		os.Remove(reloadPath)
+		return nil, err
+	}
+
+	if problems := quickCheck(reloadPath); len(problems) > 0 {
+		os.Remove(reloadPath)
+		return nil, fmt.Errorf("reloaded database is corrupt: %s", strings.Join(problems, "; "))
+	}
+
+	if err := setAsideCorruptDatabase(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(reloadPath, dbPath); err != nil {
+		return nil, err
+	}
+
+	Initialize(dbPath)
+	return lost, nil
+}
+
+type schemaObject struct {
+	Type string         `db:"type"`
+	Name string         `db:"name"`
+	SQL  sql.NullString `db:"sql"`
+}
+
+// reload creates a database at destPath with the schema of the database at
+// srcPath and copies the rows of its tables. Indexes and triggers are
+// created after the rows are copied. It returns the tables from which rows
+// could not be read.
+func reload(srcPath string, destPath string) ([]string, error) {
+	src, err := sqlx.Open(sqlite3Driver, "file:"+srcPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	dest, err := sqlx.Open(sqlite3Driver, "file:"+destPath)
+	if err != nil {
+		return nil, err
+	}
+	defer dest.Close()
+
+	var objects []schemaObject
+	if err := src.Select(&objects, "SELECT type, name, sql FROM sqlite_master WHERE sql IS NOT NULL ORDER BY type = 'table' DESC, rowid"); err != nil {
+		return nil, err
+	}
+
+	var tables []string
+	for _, o := range objects {
+		if o.Type == "table" && !strings.HasPrefix(o.Name, "sqlite_") {
+			if _, err := dest.Exec(o.SQL.String); err != nil {
+				return nil, err
+			}
+			tables = append(tables, o.Name)
+		}
+	}
+
+	var lost []string
+	for _, table := range tables {
+		if err := copyTable(src, dest, table); err != nil {
+			logger.Warnf("Rows of table %s could not be copied: %s", table, err.Error())
+			lost = append(lost, table)
+		}
+	}
+
+	// the sequences were set by the copied rows, and are replaced by those of
+	// the corrupt database if they can be read
+	if _, err := dest.Exec("DELETE FROM sqlite_sequence"); err == nil {
+		if err := copyTable(src, dest, "sqlite_sequence"); err != nil {
+			logger.Warnf("Sequences could not be copied: %s", err.Error())
+		}
+	}
+
+	for _, o := range objects {
+		if o.Type != "table" {
+			if _, err := dest.Exec(o.SQL.String); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return lost, nil
+}
+
+// copyTable copies the rows of the table that can be read from src into
+// dest. The rows read before an error are kept.
+func copyTable(src *sqlx.DB, dest *sqlx.DB, table string) error {
+	rows, err := src.Queryx("SELECT * FROM `" + table + "`")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	tx, err := dest.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO `" + table + "` VALUES (" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	for rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
+			break
+		}
+		if _, err := tx.Exec(query, values...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	return rows.Err()
+}
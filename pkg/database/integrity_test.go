@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createIntegrityTestDatabase(t *testing.T, path string) {
+	db, err := sql.Open(sqlite3Driver, "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		"CREATE TABLE test (id integer primary key autoincrement, value text)",
+		"CREATE TABLE test_counts (count integer)",
+		"INSERT INTO test_counts VALUES (0)",
+		"CREATE INDEX index_test_on_value ON test (value)",
+		"CREATE TRIGGER test_count_insert AFTER INSERT ON test BEGIN UPDATE test_counts SET count = count + 1; END",
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 500) INSERT INTO test (value) SELECT printf('%.100c', 'x') || i FROM n",
+		"DELETE FROM test WHERE id > 400",
+	}
+	for _, s := range statements {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestQuickCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-integrity-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "stash-go.sqlite")
+	createIntegrityTestDatabase(t, path)
+	assert.Empty(t, quickCheck(path))
+
+	// overwrite the pages after the first with garbage
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	garbage := make([]byte, 4096)
+	for i := range garbage {
+		garbage[i] = 0xa5
+	}
+	_, err = f.WriteAt(garbage, 4096*2)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEmpty(t, quickCheck(path))
+}
+
+func TestGoodBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-integrity-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldPath := dbPath
+	dbPath = filepath.Join(dir, "stash-go.sqlite")
+	defer func() {
+		dbPath = oldPath
+	}()
+
+	older := dbPath + ".30.20200101_120000"
+	newer := dbPath + ".31.20210101_120000"
+	createIntegrityTestDatabase(t, older)
+	createIntegrityTestDatabase(t, newer)
+
+	// corrupt, too new and unrelated files are not good backups
+	if err := ioutil.WriteFile(dbPath+".31.20220101_120000", []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	createIntegrityTestDatabase(t, dbPath+".999.20220101_120000")
+	createIntegrityTestDatabase(t, dbPath+".corrupt.20220101_120000")
+
+	backups, err := GoodBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{newer, older}, backups)
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-integrity-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcPath := filepath.Join(dir, "stash-go.sqlite")
+	destPath := filepath.Join(dir, "reload.sqlite")
+	createIntegrityTestDatabase(t, srcPath)
+
+	lost, err := reload(srcPath, destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, lost)
+	assert.Empty(t, quickCheck(destPath))
+
+	dest, err := sql.Open(sqlite3Driver, "file:"+destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dest.Close()
+
+	// the rows were copied before the trigger was created
+	var count, rowCount, sequence int
+	if err := dest.QueryRow("SELECT COUNT(*) FROM test").Scan(&rowCount); err != nil {
+		t.Fatal(err)
+	}
+	if err := dest.QueryRow("SELECT count FROM test_counts").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if err := dest.QueryRow("SELECT seq FROM sqlite_sequence WHERE name = 'test'").Scan(&sequence); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 400, rowCount)
+	assert.Equal(t, 500, count)
+	assert.Equal(t, 500, sequence)
+
+	var objects int
+	if err := dest.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name IN ('index_test_on_value', 'test_count_insert')").Scan(&objects); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, objects)
+}
+
+func TestCopyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-integrity-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "backup.sqlite")
+	dest := filepath.Join(dir, "stash.sqlite")
+	createIntegrityTestDatabase(t, src)
+	if err := ioutil.WriteFile(dest, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyFile(src, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := ioutil.ReadFile(src)
+	got, _ := ioutil.ReadFile(dest)
+	assert.Equal(t, want, got)
+
+	// no temporary files are left behind
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 2)
+
+	assert.NotNil(t, copyFile(filepath.Join(dir, "missing.sqlite"), dest))
+}
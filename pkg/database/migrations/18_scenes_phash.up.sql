@@ -0,0 +1,2 @@
+ALTER TABLE `scenes` ADD COLUMN `phash` integer;
+CREATE INDEX `index_scenes_on_phash` on `scenes` (`phash`);
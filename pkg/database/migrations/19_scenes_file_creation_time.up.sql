@@ -0,0 +1 @@
+ALTER TABLE `scenes` ADD COLUMN `file_creation_time` datetime;
@@ -0,0 +1,23 @@
+-- store all timestamps in UTC
+UPDATE `scenes` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `scenes` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `scenes` SET `file_mod_time` = strftime('%Y-%m-%dT%H:%M:%SZ', `file_mod_time`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `file_mod_time`) IS NOT NULL;
+UPDATE `scenes` SET `file_creation_time` = strftime('%Y-%m-%dT%H:%M:%SZ', `file_creation_time`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `file_creation_time`) IS NOT NULL;
+UPDATE `scene_markers` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `scene_markers` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `galleries` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `galleries` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `galleries` SET `file_mod_time` = strftime('%Y-%m-%dT%H:%M:%SZ', `file_mod_time`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `file_mod_time`) IS NOT NULL;
+UPDATE `images` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `images` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `images` SET `file_mod_time` = strftime('%Y-%m-%dT%H:%M:%SZ', `file_mod_time`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `file_mod_time`) IS NOT NULL;
+UPDATE `movies` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `movies` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `performers` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `performers` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `studios` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `studios` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `tags` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `tags` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
+UPDATE `scraped_items` SET `created_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `created_at`) IS NOT NULL;
+UPDATE `scraped_items` SET `updated_at` = strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) WHERE strftime('%Y-%m-%dT%H:%M:%SZ', `updated_at`) IS NOT NULL;
@@ -0,0 +1,15 @@
+-- indexes matching the collations used when sorting by name, title and path
+--
+-- The collations are registered by stash on its connections (see
+-- registerCustomDriver). Other SQLite clients fail with "no such collation
+-- sequence" when writing to the indexed tables.
+CREATE INDEX `index_performers_on_name_natural` on `performers` (`name` COLLATE NATURAL_CI);
+CREATE INDEX `index_studios_on_name_natural` on `studios` (`name` COLLATE NATURAL_CI);
+CREATE INDEX `index_tags_on_name_natural` on `tags` (`name` COLLATE NATURAL_CI);
+CREATE INDEX `index_movies_on_name_natural` on `movies` (`name` COLLATE NATURAL_CI);
+CREATE INDEX `index_scenes_on_title_natural` on `scenes` (`title` COLLATE NATURAL_CS);
+CREATE INDEX `index_scenes_on_path_natural` on `scenes` (`path` COLLATE NATURAL_CS);
+CREATE INDEX `index_galleries_on_title_natural` on `galleries` (`title` COLLATE NATURAL_CS);
+CREATE INDEX `index_galleries_on_path_natural` on `galleries` (`path` COLLATE NATURAL_CS);
+CREATE INDEX `index_images_on_title_natural` on `images` (`title` COLLATE NATURAL_CS);
+CREATE INDEX `index_images_on_path_natural` on `images` (`path` COLLATE NATURAL_CS);
@@ -0,0 +1,48 @@
+-- indexes for commonly filtered and sorted columns
+CREATE INDEX `index_scenes_on_date` on `scenes` (`date`);
+CREATE INDEX `index_scenes_on_rating` on `scenes` (`rating`);
+CREATE INDEX `index_scenes_on_created_at` on `scenes` (`created_at`);
+CREATE INDEX `index_scenes_on_updated_at` on `scenes` (`updated_at`);
+CREATE INDEX `index_galleries_on_date` on `galleries` (`date`);
+CREATE INDEX `index_studios_on_parent_id` on `studios` (`parent_id`);
+CREATE INDEX `index_scene_stash_ids_on_scene_id` on `scene_stash_ids` (`scene_id`);
+CREATE INDEX `index_performer_stash_ids_on_performer_id` on `performer_stash_ids` (`performer_id`);
+CREATE INDEX `index_studio_stash_ids_on_studio_id` on `studio_stash_ids` (`studio_id`);
+
+-- replace the single column join table indexes with covering indexes in both directions
+DROP INDEX `index_performers_scenes_on_performer_id`;
+DROP INDEX `index_performers_scenes_on_scene_id`;
+CREATE INDEX `index_performers_scenes_on_performer_id_scene_id` on `performers_scenes` (`performer_id`, `scene_id`);
+CREATE INDEX `index_performers_scenes_on_scene_id_performer_id` on `performers_scenes` (`scene_id`, `performer_id`);
+DROP INDEX `index_scenes_tags_on_scene_id`;
+DROP INDEX `index_scenes_tags_on_tag_id`;
+CREATE INDEX `index_scenes_tags_on_scene_id_tag_id` on `scenes_tags` (`scene_id`, `tag_id`);
+CREATE INDEX `index_scenes_tags_on_tag_id_scene_id` on `scenes_tags` (`tag_id`, `scene_id`);
+DROP INDEX `index_movies_scenes_on_movie_id`;
+DROP INDEX `index_movies_scenes_on_scene_id`;
+CREATE INDEX `index_movies_scenes_on_movie_id_scene_id` on `movies_scenes` (`movie_id`, `scene_id`);
+CREATE INDEX `index_movies_scenes_on_scene_id_movie_id` on `movies_scenes` (`scene_id`, `movie_id`);
+DROP INDEX `index_scene_markers_tags_on_scene_marker_id`;
+DROP INDEX `index_scene_markers_tags_on_tag_id`;
+CREATE INDEX `index_scene_markers_tags_on_scene_marker_id_tag_id` on `scene_markers_tags` (`scene_marker_id`, `tag_id`);
+CREATE INDEX `index_scene_markers_tags_on_tag_id_scene_marker_id` on `scene_markers_tags` (`tag_id`, `scene_marker_id`);
+DROP INDEX `index_performers_images_on_performer_id`;
+DROP INDEX `index_performers_images_on_image_id`;
+CREATE INDEX `index_performers_images_on_performer_id_image_id` on `performers_images` (`performer_id`, `image_id`);
+CREATE INDEX `index_performers_images_on_image_id_performer_id` on `performers_images` (`image_id`, `performer_id`);
+DROP INDEX `index_images_tags_on_image_id`;
+DROP INDEX `index_images_tags_on_tag_id`;
+CREATE INDEX `index_images_tags_on_image_id_tag_id` on `images_tags` (`image_id`, `tag_id`);
+CREATE INDEX `index_images_tags_on_tag_id_image_id` on `images_tags` (`tag_id`, `image_id`);
+DROP INDEX `index_galleries_images_on_gallery_id`;
+DROP INDEX `index_galleries_images_on_image_id`;
+CREATE INDEX `index_galleries_images_on_gallery_id_image_id` on `galleries_images` (`gallery_id`, `image_id`);
+CREATE INDEX `index_galleries_images_on_image_id_gallery_id` on `galleries_images` (`image_id`, `gallery_id`);
+DROP INDEX `index_performers_galleries_on_performer_id`;
+DROP INDEX `index_performers_galleries_on_gallery_id`;
+CREATE INDEX `index_performers_galleries_on_performer_id_gallery_id` on `performers_galleries` (`performer_id`, `gallery_id`);
+CREATE INDEX `index_performers_galleries_on_gallery_id_performer_id` on `performers_galleries` (`gallery_id`, `performer_id`);
+DROP INDEX `index_galleries_tags_on_gallery_id`;
+DROP INDEX `index_galleries_tags_on_tag_id`;
+CREATE INDEX `index_galleries_tags_on_gallery_id_tag_id` on `galleries_tags` (`gallery_id`, `tag_id`);
+CREATE INDEX `index_galleries_tags_on_tag_id_gallery_id` on `galleries_tags` (`tag_id`, `gallery_id`);
@@ -0,0 +1,87 @@
+-- row counts of the main tables, used for approximate counts of unfiltered queries
+-- the triggers must be recreated if these tables are rebuilt by a later migration
+CREATE TABLE `table_counts` (
+  `table_name` varchar(255) not null primary key,
+  `count` integer not null default 0
+);
+
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'scenes', COUNT(*) FROM `scenes`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'scene_markers', COUNT(*) FROM `scene_markers`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'images', COUNT(*) FROM `images`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'galleries', COUNT(*) FROM `galleries`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'performers', COUNT(*) FROM `performers`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'studios', COUNT(*) FROM `studios`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'movies', COUNT(*) FROM `movies`;
+INSERT INTO `table_counts` (`table_name`, `count`) SELECT 'tags', COUNT(*) FROM `tags`;
+
+CREATE TRIGGER `scenes_count_insert` AFTER INSERT ON `scenes`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'scenes';
+END;
+CREATE TRIGGER `scenes_count_delete` AFTER DELETE ON `scenes`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'scenes';
+END;
+
+CREATE TRIGGER `scene_markers_count_insert` AFTER INSERT ON `scene_markers`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'scene_markers';
+END;
+CREATE TRIGGER `scene_markers_count_delete` AFTER DELETE ON `scene_markers`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'scene_markers';
+END;
+
+CREATE TRIGGER `images_count_insert` AFTER INSERT ON `images`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'images';
+END;
+CREATE TRIGGER `images_count_delete` AFTER DELETE ON `images`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'images';
+END;
+
+CREATE TRIGGER `galleries_count_insert` AFTER INSERT ON `galleries`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'galleries';
+END;
+CREATE TRIGGER `galleries_count_delete` AFTER DELETE ON `galleries`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'galleries';
+END;
+
+CREATE TRIGGER `performers_count_insert` AFTER INSERT ON `performers`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'performers';
+END;
+CREATE TRIGGER `performers_count_delete` AFTER DELETE ON `performers`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'performers';
+END;
+
+CREATE TRIGGER `studios_count_insert` AFTER INSERT ON `studios`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'studios';
+END;
+CREATE TRIGGER `studios_count_delete` AFTER DELETE ON `studios`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'studios';
+END;
+
+CREATE TRIGGER `movies_count_insert` AFTER INSERT ON `movies`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'movies';
+END;
+CREATE TRIGGER `movies_count_delete` AFTER DELETE ON `movies`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'movies';
+END;
+
+CREATE TRIGGER `tags_count_insert` AFTER INSERT ON `tags`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'tags';
+END;
+CREATE TRIGGER `tags_count_delete` AFTER DELETE ON `tags`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'tags';
+END;
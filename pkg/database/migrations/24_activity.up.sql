@@ -0,0 +1,14 @@
+-- changes made to objects, recorded from the event bus
+-- entity_id is not a foreign key so that history is kept for destroyed objects
+CREATE TABLE `activity` (
+  `id` integer not null primary key autoincrement,
+  `entity` varchar(255) not null,
+  `action` varchar(255) not null,
+  `entity_id` integer not null,
+  `actor` varchar(255),
+  `created_at` datetime not null
+);
+
+CREATE INDEX `index_activity_on_created_at` on `activity` (`created_at`);
+CREATE INDEX `index_activity_on_entity_entity_id` on `activity` (`entity`, `entity_id`);
+CREATE INDEX `index_activity_on_actor` on `activity` (`actor`);
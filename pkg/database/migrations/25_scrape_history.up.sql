@@ -0,0 +1,60 @@
+-- records each scrape of an existing object
+-- fields is the comma-separated list of fields populated by the scrape
+-- input_checksum identifies the input passed to the scraper, so that an
+-- unchanged input can be answered from the stored result
+CREATE TABLE `scrape_history` (
+  `id` integer not null primary key autoincrement,
+  `entity` varchar(255) not null,
+  `entity_id` integer not null,
+  `source` varchar(255) not null,
+  `fields` text not null,
+  `input_checksum` varchar(255) not null,
+  `result` text,
+  `created_at` datetime not null
+);
+
+CREATE INDEX `index_scrape_history_on_entity_entity_id_source` on `scrape_history` (`entity`, `entity_id`, `source`, `created_at`);
+
+-- the legacy scraped items are kept in the scrape history, with the item
+-- encoded in the format of the scraped items export file. Strings are encoded
+-- by json_string, which is registered by the application. Values are passed
+-- as blobs, since text arguments of functions end at the first NUL character.
+CREATE TEMPORARY TABLE `scraped_items_json` AS SELECT
+  `id`,
+  `updated_at`,
+  CASE WHEN `title` IS NULL OR `title` = '' THEN '' ELSE ',"title":' || json_string(CAST(`title` AS BLOB)) END ||
+  CASE WHEN `description` IS NULL OR `description` = '' THEN '' ELSE ',"description":' || json_string(CAST(`description` AS BLOB)) END ||
+  CASE WHEN `url` IS NULL OR `url` = '' THEN '' ELSE ',"url":' || json_string(CAST(`url` AS BLOB)) END ||
+  CASE WHEN `date` IS NULL OR `date` = '' THEN '' ELSE ',"date":' || json_string(CAST(`date` AS BLOB)) END ||
+  CASE WHEN `rating` IS NULL OR `rating` = '' THEN '' ELSE ',"rating":' || json_string(CAST(`rating` AS BLOB)) END ||
+  CASE WHEN `tags` IS NULL OR `tags` = '' THEN '' ELSE ',"tags":' || json_string(CAST(`tags` AS BLOB)) END ||
+  CASE WHEN `models` IS NULL OR `models` = '' THEN '' ELSE ',"models":' || json_string(CAST(`models` AS BLOB)) END ||
+  CASE WHEN `episode` IS NULL OR `episode` = 0 THEN '' ELSE ',"episode":' || CAST(`episode` AS INTEGER) END ||
+  CASE WHEN `gallery_filename` IS NULL OR `gallery_filename` = '' THEN '' ELSE ',"gallery_filename":' || json_string(CAST(`gallery_filename` AS BLOB)) END ||
+  CASE WHEN `gallery_url` IS NULL OR `gallery_url` = '' THEN '' ELSE ',"gallery_url":' || json_string(CAST(`gallery_url` AS BLOB)) END ||
+  CASE WHEN `video_filename` IS NULL OR `video_filename` = '' THEN '' ELSE ',"video_filename":' || json_string(CAST(`video_filename` AS BLOB)) END ||
+  CASE WHEN `video_url` IS NULL OR `video_url` = '' THEN '' ELSE ',"video_url":' || json_string(CAST(`video_url` AS BLOB)) END ||
+  COALESCE((SELECT ',"studio":' || json_string(CAST(`studios`.`name` AS BLOB)) FROM `studios` WHERE `studios`.`id` = `scraped_items`.`studio_id` AND `studios`.`name` != ''), '') AS `json`,
+  CASE WHEN `title` IS NULL OR `title` = '' THEN '' ELSE ',title' END ||
+  CASE WHEN `description` IS NULL OR `description` = '' THEN '' ELSE ',description' END ||
+  CASE WHEN `url` IS NULL OR `url` = '' THEN '' ELSE ',url' END ||
+  CASE WHEN `date` IS NULL OR `date` = '' THEN '' ELSE ',date' END ||
+  CASE WHEN `rating` IS NULL OR `rating` = '' THEN '' ELSE ',rating' END ||
+  CASE WHEN `tags` IS NULL OR `tags` = '' THEN '' ELSE ',tags' END ||
+  CASE WHEN `models` IS NULL OR `models` = '' THEN '' ELSE ',models' END ||
+  CASE WHEN `episode` IS NULL OR `episode` = 0 THEN '' ELSE ',episode' END ||
+  CASE WHEN `gallery_filename` IS NULL OR `gallery_filename` = '' THEN '' ELSE ',gallery_filename' END ||
+  CASE WHEN `gallery_url` IS NULL OR `gallery_url` = '' THEN '' ELSE ',gallery_url' END ||
+  CASE WHEN `video_filename` IS NULL OR `video_filename` = '' THEN '' ELSE ',video_filename' END ||
+  CASE WHEN `video_url` IS NULL OR `video_url` = '' THEN '' ELSE ',video_url' END ||
+  COALESCE((SELECT ',studio' FROM `studios` WHERE `studios`.`id` = `scraped_items`.`studio_id` AND `studios`.`name` != ''), '') AS `fields`
+FROM `scraped_items`;
+
+INSERT INTO `scrape_history` (`entity`, `entity_id`, `source`, `fields`, `input_checksum`, `result`, `created_at`)
+SELECT 'scraped_item', `id`, 'scraped_items', substr(`fields`, 2), '', '{' || substr(`json`, 2) || '}', `updated_at`
+FROM `scraped_items_json`;
+
+DROP TABLE `scraped_items_json`;
+
+DROP INDEX IF EXISTS `index_scraped_items_on_studio_id`;
+DROP TABLE `scraped_items`;
@@ -0,0 +1,10 @@
+-- user defined attributes of movies that are not part of the movies table
+CREATE TABLE `movie_custom_fields` (
+  `movie_id` integer not null,
+  `field` varchar(255) not null,
+  `value` text not null,
+  foreign key(`movie_id`) references `movies`(`id`) on delete CASCADE,
+  PRIMARY KEY(`movie_id`, `field`)
+);
+
+CREATE INDEX `index_movie_custom_fields_on_field_value` on `movie_custom_fields` (`field`, `value`);
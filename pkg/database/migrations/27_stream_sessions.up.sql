@@ -0,0 +1,16 @@
+-- a stream session groups the requests made by a client to stream a scene
+-- duration is the number of seconds that the stream requests were active
+CREATE TABLE `stream_sessions` (
+  `id` integer not null primary key autoincrement,
+  `scene_id` integer not null,
+  `client` varchar(255) not null,
+  `transcode` boolean not null default '0',
+  `duration` real not null default 0,
+  `bytes` integer not null default 0,
+  `started_at` datetime not null,
+  `ended_at` datetime not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+
+CREATE INDEX `index_stream_sessions_on_scene_id` on `stream_sessions` (`scene_id`);
+CREATE INDEX `index_stream_sessions_on_started_at` on `stream_sessions` (`started_at`);
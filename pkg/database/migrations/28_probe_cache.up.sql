@@ -0,0 +1,8 @@
+-- cached ffprobe output, keyed by the oshash and size of the probed file
+CREATE TABLE `probe_cache` (
+  `oshash` varchar(255) not null,
+  `size` integer not null,
+  `data` text not null,
+  `created_at` datetime not null,
+  primary key(`oshash`, `size`)
+);
@@ -0,0 +1,5 @@
+-- ratings are now stored on a 1-100 scale instead of 1-5
+UPDATE `scenes` SET `rating` = MIN(`rating` * 20, 100) WHERE `rating` IS NOT NULL;
+UPDATE `images` SET `rating` = MIN(`rating` * 20, 100) WHERE `rating` IS NOT NULL;
+UPDATE `galleries` SET `rating` = MIN(`rating` * 20, 100) WHERE `rating` IS NOT NULL;
+UPDATE `movies` SET `rating` = MIN(`rating` * 20, 100) WHERE `rating` IS NOT NULL;
@@ -0,0 +1,80 @@
+-- movies can have multiple urls, ordered by position
+CREATE TABLE `movie_urls` (
+  `movie_id` integer not null,
+  `position` integer not null,
+  `url` varchar(255) not null,
+  foreign key(`movie_id`) references `movies`(`id`) on delete CASCADE,
+  primary key(`movie_id`, `position`)
+);
+
+CREATE INDEX `index_movie_urls_on_url` on `movie_urls` (`url`);
+
+INSERT INTO `movie_urls` (`movie_id`, `position`, `url`)
+  SELECT `id`, 0, `url` FROM `movies` WHERE `url` IS NOT NULL AND `url` != '';
+
+-- recreate the movies table without the url column
+CREATE TABLE `movies_new` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255) not null,
+  `aliases` varchar(255),
+  `duration` integer,
+  `date` date,
+  `rating` tinyint,
+  `studio_id` integer,
+  `director` varchar(255),
+  `synopsis` text,
+  `checksum` varchar(255) not null,
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  foreign key(`studio_id`) references `studios`(`id`) on delete set null
+);
+
+INSERT INTO `movies_new`
+  (
+    `id`,
+    `name`,
+    `aliases`,
+    `duration`,
+    `date`,
+    `rating`,
+    `studio_id`,
+    `director`,
+    `synopsis`,
+    `checksum`,
+    `created_at`,
+    `updated_at`
+  )
+  SELECT
+    `id`,
+    `name`,
+    `aliases`,
+    `duration`,
+    `date`,
+    `rating`,
+    `studio_id`,
+    `director`,
+    `synopsis`,
+    `checksum`,
+    `created_at`,
+    `updated_at`
+  FROM `movies`;
+
+-- foreign keys are disabled during migrations, so dropping the table does
+-- not affect the tables referencing it
+DROP TABLE `movies`;
+ALTER TABLE `movies_new` rename to `movies`;
+
+CREATE UNIQUE INDEX `movies_name_unique` on `movies` (`name`);
+CREATE UNIQUE INDEX `movies_checksum_unique` on `movies` (`checksum`);
+CREATE INDEX `index_movies_on_studio_id` on `movies` (`studio_id`);
+CREATE INDEX `index_movies_on_name_natural` on `movies` (`name` COLLATE NATURAL_CI);
+
+CREATE TRIGGER `movies_count_insert` AFTER INSERT ON `movies`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'movies';
+END;
+
+CREATE TRIGGER `movies_count_delete` AFTER DELETE ON `movies`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'movies';
+END;
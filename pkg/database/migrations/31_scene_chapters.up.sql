@@ -0,0 +1,13 @@
+-- chapters embedded in the scene file, ordered by position
+CREATE TABLE `scene_chapters` (
+  `scene_id` integer not null,
+  `position` integer not null,
+  `seconds` real not null,
+  `end_seconds` real,
+  `title` varchar(255) not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  primary key(`scene_id`, `position`)
+);
+
+-- cached ffprobe output does not include chapters
+DELETE FROM `probe_cache`;
@@ -0,0 +1,3 @@
+-- applied_fields is the comma-separated list of scraped fields that were
+-- saved to the object
+ALTER TABLE `scrape_history` ADD COLUMN `applied_fields` text not null default '';
@@ -0,0 +1,20 @@
+-- images stored on the filesystem are recorded by the path of their file,
+-- relative to the blobs directory, instead of by an empty blob
+ALTER TABLE `movies_images` ADD COLUMN `front_image_file` varchar(255);
+ALTER TABLE `movies_images` ADD COLUMN `back_image_file` varchar(255);
+ALTER TABLE `performers_image` ADD COLUMN `image_file` varchar(255);
+ALTER TABLE `studios_image` ADD COLUMN `image_file` varchar(255);
+ALTER TABLE `tags_image` ADD COLUMN `image_file` varchar(255);
+ALTER TABLE `scenes_cover` ADD COLUMN `cover_file` varchar(255);
+
+UPDATE `movies_images` SET `front_image_file` = 'movies_images/' || `movie_id` || '_front_image' WHERE length(`front_image`) = 0;
+UPDATE `movies_images` SET `back_image_file` = 'movies_images/' || `movie_id` || '_back_image' WHERE length(`back_image`) = 0;
+UPDATE `performers_image` SET `image_file` = 'performers_image/' || `performer_id` || '_image' WHERE length(`image`) = 0;
+UPDATE `studios_image` SET `image_file` = 'studios_image/' || `studio_id` || '_image' WHERE length(`image`) = 0;
+UPDATE `tags_image` SET `image_file` = 'tags_image/' || `tag_id` || '_image' WHERE length(`image`) = 0;
+UPDATE `scenes_cover` SET `cover_file` = 'scenes_cover/' || `scene_id` || '_cover' WHERE length(`cover`) = 0;
+
+-- image files to delete once the transactions removing them are committed
+CREATE TABLE `blob_file_deletions` (
+  `path` varchar(255) not null primary key
+);
@@ -0,0 +1,17 @@
+-- library statistics, recorded once a day. Stream totals are of all sessions
+-- started up to the day of the snapshot
+CREATE TABLE `stats_snapshots` (
+  `date` varchar(10) not null primary key,
+  `scene_count` integer not null,
+  `scenes_size` real not null,
+  `image_count` integer not null,
+  `images_size` real not null,
+  `gallery_count` integer not null,
+  `performer_count` integer not null,
+  `studio_count` integer not null,
+  `movie_count` integer not null,
+  `tag_count` integer not null,
+  `stream_session_count` integer not null,
+  `stream_duration` real not null,
+  `stream_bytes` real not null
+);
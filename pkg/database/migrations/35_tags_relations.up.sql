@@ -0,0 +1,10 @@
+-- parent and child tags. A tag may have any number of parents
+CREATE TABLE `tags_relations` (
+  `parent_id` integer not null,
+  `child_id` integer not null,
+  foreign key(`parent_id`) references `tags`(`id`) on delete CASCADE,
+  foreign key(`child_id`) references `tags`(`id`) on delete CASCADE,
+  primary key(`parent_id`, `child_id`)
+);
+
+CREATE INDEX `index_tags_relations_on_child_id` on `tags_relations` (`child_id`);
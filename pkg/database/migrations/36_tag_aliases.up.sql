@@ -0,0 +1,9 @@
+-- alternative names of tags. Aliases are unique regardless of case
+CREATE TABLE `tag_aliases` (
+  `tag_id` integer not null,
+  `alias` varchar(255) COLLATE NOCASE not null,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE,
+  primary key(`tag_id`, `alias`)
+);
+
+CREATE UNIQUE INDEX `tag_aliases_alias_unique` on `tag_aliases` (`alias`);
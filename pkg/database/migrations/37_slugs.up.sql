@@ -0,0 +1,48 @@
+-- stable slugs of movies, performers and studios, and the previous slugs
+-- of each, which redirect to them. Existing slugs are made from the names,
+-- with the id appended to repeated slugs
+
+ALTER TABLE `movies` ADD COLUMN `slug` varchar(255);
+UPDATE `movies` SET `slug` = slugify(IFNULL(`name`, ''));
+UPDATE `movies` SET `slug` = 'movie' WHERE `slug` = '';
+UPDATE `movies` SET `slug` = `slug` || '-' || `id` WHERE EXISTS (SELECT 1 FROM `movies` AS `other` WHERE `other`.`slug` = `movies`.`slug` AND `other`.`id` < `movies`.`id`);
+CREATE UNIQUE INDEX `movies_slug_unique` on `movies` (`slug`);
+
+CREATE TABLE `movie_slug_redirects` (
+  `slug` varchar(255) not null,
+  `movie_id` integer not null,
+  foreign key(`movie_id`) references `movies`(`id`) on delete CASCADE,
+  primary key(`slug`)
+);
+
+CREATE INDEX `index_movie_slug_redirects_on_movie_id` on `movie_slug_redirects` (`movie_id`);
+
+ALTER TABLE `performers` ADD COLUMN `slug` varchar(255);
+UPDATE `performers` SET `slug` = slugify(IFNULL(`name`, ''));
+UPDATE `performers` SET `slug` = 'performer' WHERE `slug` = '';
+UPDATE `performers` SET `slug` = `slug` || '-' || `id` WHERE EXISTS (SELECT 1 FROM `performers` AS `other` WHERE `other`.`slug` = `performers`.`slug` AND `other`.`id` < `performers`.`id`);
+CREATE UNIQUE INDEX `performers_slug_unique` on `performers` (`slug`);
+
+CREATE TABLE `performer_slug_redirects` (
+  `slug` varchar(255) not null,
+  `performer_id` integer not null,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  primary key(`slug`)
+);
+
+CREATE INDEX `index_performer_slug_redirects_on_performer_id` on `performer_slug_redirects` (`performer_id`);
+
+ALTER TABLE `studios` ADD COLUMN `slug` varchar(255);
+UPDATE `studios` SET `slug` = slugify(IFNULL(`name`, ''));
+UPDATE `studios` SET `slug` = 'studio' WHERE `slug` = '';
+UPDATE `studios` SET `slug` = `slug` || '-' || `id` WHERE EXISTS (SELECT 1 FROM `studios` AS `other` WHERE `other`.`slug` = `studios`.`slug` AND `other`.`id` < `studios`.`id`);
+CREATE UNIQUE INDEX `studios_slug_unique` on `studios` (`slug`);
+
+CREATE TABLE `studio_slug_redirects` (
+  `slug` varchar(255) not null,
+  `studio_id` integer not null,
+  foreign key(`studio_id`) references `studios`(`id`) on delete CASCADE,
+  primary key(`slug`)
+);
+
+CREATE INDEX `index_studio_slug_redirects_on_studio_id` on `studio_slug_redirects` (`studio_id`);
@@ -0,0 +1,34 @@
+-- globally unique identifiers of objects, which are kept through export and
+-- import. Existing objects are given random (version 4) UUIDs
+
+ALTER TABLE `scenes` ADD COLUMN `uuid` varchar(36);
+UPDATE `scenes` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `scenes_uuid_unique` on `scenes` (`uuid`);
+
+ALTER TABLE `scene_markers` ADD COLUMN `uuid` varchar(36);
+UPDATE `scene_markers` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `scene_markers_uuid_unique` on `scene_markers` (`uuid`);
+
+ALTER TABLE `images` ADD COLUMN `uuid` varchar(36);
+UPDATE `images` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `images_uuid_unique` on `images` (`uuid`);
+
+ALTER TABLE `galleries` ADD COLUMN `uuid` varchar(36);
+UPDATE `galleries` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `galleries_uuid_unique` on `galleries` (`uuid`);
+
+ALTER TABLE `performers` ADD COLUMN `uuid` varchar(36);
+UPDATE `performers` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `performers_uuid_unique` on `performers` (`uuid`);
+
+ALTER TABLE `studios` ADD COLUMN `uuid` varchar(36);
+UPDATE `studios` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `studios_uuid_unique` on `studios` (`uuid`);
+
+ALTER TABLE `movies` ADD COLUMN `uuid` varchar(36);
+UPDATE `movies` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `movies_uuid_unique` on `movies` (`uuid`);
+
+ALTER TABLE `tags` ADD COLUMN `uuid` varchar(36);
+UPDATE `tags` SET `uuid` = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)));
+CREATE UNIQUE INDEX `tags_uuid_unique` on `tags` (`uuid`);
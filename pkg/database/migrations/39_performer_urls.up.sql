@@ -0,0 +1,113 @@
+-- performers can have multiple links, ordered by position. The site of a
+-- link is the name of its domain, or a well-known name such as twitter
+CREATE TABLE `performer_urls` (
+  `performer_id` integer not null,
+  `position` integer not null,
+  `site` varchar(255) not null,
+  `url` varchar(255) not null,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  primary key(`performer_id`, `position`)
+);
+
+CREATE INDEX `index_performer_urls_on_url` on `performer_urls` (`url`);
+CREATE INDEX `index_performer_urls_on_site` on `performer_urls` (`site`);
+
+-- twitter and instagram could be handles rather than URLs
+INSERT INTO `performer_urls` (`performer_id`, `position`, `site`, `url`)
+  SELECT `id`, 0, url_site(TRIM(`url`)), TRIM(`url`) FROM `performers` WHERE TRIM(IFNULL(`url`, '')) != ''
+  UNION ALL
+  SELECT `id`, 1, 'twitter', social_url('https://twitter.com/', `twitter`) FROM `performers` WHERE TRIM(IFNULL(`twitter`, '')) != ''
+  UNION ALL
+  SELECT `id`, 2, 'instagram', social_url('https://www.instagram.com/', `instagram`) FROM `performers` WHERE TRIM(IFNULL(`instagram`, '')) != '';
+
+-- recreate the performers table without the url, twitter and instagram
+-- columns
+CREATE TABLE `performers_new` (
+  `id` integer not null primary key autoincrement,
+  `checksum` varchar(255) not null,
+  `name` varchar(255),
+  `gender` varchar(20),
+  `birthdate` date,
+  `ethnicity` varchar(255),
+  `country` varchar(255),
+  `eye_color` varchar(255),
+  `height` varchar(255),
+  `measurements` varchar(255),
+  `fake_tits` varchar(255),
+  `career_length` varchar(255),
+  `tattoos` varchar(255),
+  `piercings` varchar(255),
+  `aliases` varchar(255),
+  `favorite` boolean not null default '0',
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  `slug` varchar(255),
+  `uuid` varchar(36)
+);
+
+INSERT INTO `performers_new`
+  (
+    `id`,
+    `checksum`,
+    `name`,
+    `gender`,
+    `birthdate`,
+    `ethnicity`,
+    `country`,
+    `eye_color`,
+    `height`,
+    `measurements`,
+    `fake_tits`,
+    `career_length`,
+    `tattoos`,
+    `piercings`,
+    `aliases`,
+    `favorite`,
+    `created_at`,
+    `updated_at`,
+    `slug`,
+    `uuid`
+  )
+  SELECT
+    `id`,
+    `checksum`,
+    `name`,
+    `gender`,
+    `birthdate`,
+    `ethnicity`,
+    `country`,
+    `eye_color`,
+    `height`,
+    `measurements`,
+    `fake_tits`,
+    `career_length`,
+    `tattoos`,
+    `piercings`,
+    `aliases`,
+    `favorite`,
+    `created_at`,
+    `updated_at`,
+    `slug`,
+    `uuid`
+  FROM `performers`;
+
+-- foreign keys are disabled during migrations, so dropping the table does
+-- not affect the tables referencing it
+DROP TABLE `performers`;
+ALTER TABLE `performers_new` rename to `performers`;
+
+CREATE UNIQUE INDEX `performers_checksum_unique` on `performers` (`checksum`);
+CREATE INDEX `index_performers_on_name` on `performers` (`name`);
+CREATE INDEX `index_performers_on_name_natural` on `performers` (`name` COLLATE NATURAL_CI);
+CREATE UNIQUE INDEX `performers_slug_unique` on `performers` (`slug`);
+CREATE UNIQUE INDEX `performers_uuid_unique` on `performers` (`uuid`);
+
+CREATE TRIGGER `performers_count_insert` AFTER INSERT ON `performers`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` + 1 WHERE `table_name` = 'performers';
+END;
+
+CREATE TRIGGER `performers_count_delete` AFTER DELETE ON `performers`
+BEGIN
+  UPDATE `table_counts` SET `count` = `count` - 1 WHERE `table_name` = 'performers';
+END;
@@ -0,0 +1,16 @@
+-- numeric height and measurements in centimetres parsed from the height and
+-- measurements strings, and the weight in kilograms, for range filters
+ALTER TABLE `performers` ADD COLUMN `height_cm` integer;
+ALTER TABLE `performers` ADD COLUMN `bust` integer;
+ALTER TABLE `performers` ADD COLUMN `waist` integer;
+ALTER TABLE `performers` ADD COLUMN `hips` integer;
+ALTER TABLE `performers` ADD COLUMN `weight` integer;
+
+UPDATE `performers` SET `height_cm` = NULLIF(parse_height(`height`), 0) WHERE `height` IS NOT NULL;
+UPDATE `performers` SET
+  `bust` = NULLIF(parse_measurement(`measurements`, 0), 0),
+  `waist` = NULLIF(parse_measurement(`measurements`, 1), 0),
+  `hips` = NULLIF(parse_measurement(`measurements`, 2), 0)
+  WHERE `measurements` IS NOT NULL;
+
+CREATE INDEX `index_performers_on_height_cm` on `performers` (`height_cm`);
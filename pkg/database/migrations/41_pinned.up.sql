@@ -0,0 +1,9 @@
+-- pinned movies, performers and studios are shown on the dashboard, ordered
+-- by their position. The position is null if they are not pinned
+ALTER TABLE `movies` ADD COLUMN `pinned_position` integer;
+ALTER TABLE `performers` ADD COLUMN `pinned_position` integer;
+ALTER TABLE `studios` ADD COLUMN `pinned_position` integer;
+
+CREATE INDEX `index_movies_on_pinned_position` on `movies` (`pinned_position`);
+CREATE INDEX `index_performers_on_pinned_position` on `performers` (`pinned_position`);
+CREATE INDEX `index_studios_on_pinned_position` on `studios` (`pinned_position`);
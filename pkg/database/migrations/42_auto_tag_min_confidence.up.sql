@@ -0,0 +1,5 @@
+-- minimum confidence of the auto tag matches of performers, studios and
+-- tags, overriding the setting. Null uses the setting
+ALTER TABLE `performers` ADD COLUMN `auto_tag_min_confidence` real;
+ALTER TABLE `studios` ADD COLUMN `auto_tag_min_confidence` real;
+ALTER TABLE `tags` ADD COLUMN `auto_tag_min_confidence` real;
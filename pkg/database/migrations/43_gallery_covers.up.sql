@@ -0,0 +1,13 @@
+-- the cover image chosen for a gallery, and the automatic cover used if none
+-- is chosen. The automatic cover is the first image by path, preferring
+-- images named cover.jpg and avoiding thumbnails
+ALTER TABLE `galleries` ADD COLUMN `cover_image_id` integer REFERENCES `images`(`id`) ON DELETE SET NULL;
+ALTER TABLE `galleries` ADD COLUMN `auto_cover_image_id` integer REFERENCES `images`(`id`) ON DELETE SET NULL;
+
+UPDATE `galleries` SET `auto_cover_image_id` = (
+  SELECT `images`.`id` FROM `galleries_images`
+  JOIN `images` ON `images`.`id` = `galleries_images`.`image_id`
+  WHERE `galleries_images`.`gallery_id` = `galleries`.`id`
+  ORDER BY gallery_cover_rank(`images`.`path`), `images`.`path`, `images`.`id`
+  LIMIT 1
+);
@@ -0,0 +1,4 @@
+-- perceptual hashes of images, used to find duplicate images
+ALTER TABLE `images` ADD COLUMN `phash` integer;
+
+CREATE INDEX `index_images_on_checksum` on `images` (`checksum`);
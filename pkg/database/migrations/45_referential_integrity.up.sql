@@ -0,0 +1,116 @@
+-- foreign keys were not enforced by older versions, so remove the rows
+-- referencing rows which no longer exist before enforcing them
+
+UPDATE `galleries` SET `studio_id` = NULL WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+UPDATE `galleries` SET `scene_id` = NULL WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+UPDATE `galleries` SET `auto_cover_image_id` = NULL WHERE `auto_cover_image_id` NOT IN (SELECT `id` FROM `images`);
+UPDATE `galleries` SET `cover_image_id` = NULL WHERE `cover_image_id` NOT IN (SELECT `id` FROM `images`);
+UPDATE `images` SET `studio_id` = NULL WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+UPDATE `movies` SET `studio_id` = NULL WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+UPDATE `scenes` SET `studio_id` = NULL WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+UPDATE `studios` SET `parent_id` = NULL WHERE `parent_id` NOT IN (SELECT `id` FROM `studios`);
+
+DELETE FROM `scene_markers` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `scene_markers` WHERE `primary_tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `galleries_images` WHERE `image_id` NOT IN (SELECT `id` FROM `images`);
+DELETE FROM `galleries_images` WHERE `gallery_id` NOT IN (SELECT `id` FROM `galleries`);
+DELETE FROM `galleries_tags` WHERE `tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `galleries_tags` WHERE `gallery_id` NOT IN (SELECT `id` FROM `galleries`);
+DELETE FROM `images_tags` WHERE `tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `images_tags` WHERE `image_id` NOT IN (SELECT `id` FROM `images`);
+DELETE FROM `movie_custom_fields` WHERE `movie_id` NOT IN (SELECT `id` FROM `movies`);
+DELETE FROM `movie_slug_redirects` WHERE `movie_id` NOT IN (SELECT `id` FROM `movies`);
+DELETE FROM `movie_urls` WHERE `movie_id` NOT IN (SELECT `id` FROM `movies`);
+DELETE FROM `movies_images` WHERE `movie_id` NOT IN (SELECT `id` FROM `movies`);
+DELETE FROM `movies_scenes` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `movies_scenes` WHERE `movie_id` NOT IN (SELECT `id` FROM `movies`);
+DELETE FROM `performer_slug_redirects` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `performer_stash_ids` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `performer_urls` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `performers_galleries` WHERE `gallery_id` NOT IN (SELECT `id` FROM `galleries`);
+DELETE FROM `performers_galleries` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `performers_image` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `performers_images` WHERE `image_id` NOT IN (SELECT `id` FROM `images`);
+DELETE FROM `performers_images` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `performers_scenes` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `performers_scenes` WHERE `performer_id` NOT IN (SELECT `id` FROM `performers`);
+DELETE FROM `scene_chapters` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `scene_markers_tags` WHERE `tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `scene_markers_tags` WHERE `scene_marker_id` NOT IN (SELECT `id` FROM `scene_markers`);
+DELETE FROM `scene_stash_ids` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `scenes_cover` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `scenes_tags` WHERE `tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `scenes_tags` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `stream_sessions` WHERE `scene_id` NOT IN (SELECT `id` FROM `scenes`);
+DELETE FROM `studio_slug_redirects` WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+DELETE FROM `studio_stash_ids` WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+DELETE FROM `studios_image` WHERE `studio_id` NOT IN (SELECT `id` FROM `studios`);
+DELETE FROM `tag_aliases` WHERE `tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `tags_image` WHERE `tag_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `tags_relations` WHERE `child_id` NOT IN (SELECT `id` FROM `tags`);
+DELETE FROM `tags_relations` WHERE `parent_id` NOT IN (SELECT `id` FROM `tags`);
+
+-- choose automatic covers for the galleries whose covers were removed
+UPDATE `galleries` SET `auto_cover_image_id` = (
+  SELECT `images`.`id` FROM `galleries_images`
+  JOIN `images` ON `images`.`id` = `galleries_images`.`image_id`
+  WHERE `galleries_images`.`gallery_id` = `galleries`.`id`
+  ORDER BY gallery_cover_rank(`images`.`path`), `images`.`path`, `images`.`id`
+  LIMIT 1
+) WHERE `auto_cover_image_id` IS NULL;
+
+-- recreate the join tables whose rows were left behind when the performers
+-- and tags they reference were deleted
+ALTER TABLE `performers_scenes` rename to `_performers_scenes_old`;
+ALTER TABLE `scenes_tags` rename to `_scenes_tags_old`;
+ALTER TABLE `scene_markers_tags` rename to `_scene_markers_tags_old`;
+
+CREATE TABLE `performers_scenes` (
+  `performer_id` integer,
+  `scene_id` integer,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+
+DROP INDEX `index_performers_scenes_on_performer_id_scene_id`;
+DROP INDEX `index_performers_scenes_on_scene_id_performer_id`;
+
+CREATE INDEX `index_performers_scenes_on_performer_id_scene_id` on `performers_scenes` (`performer_id`, `scene_id`);
+CREATE INDEX `index_performers_scenes_on_scene_id_performer_id` on `performers_scenes` (`scene_id`, `performer_id`);
+
+CREATE TABLE `scenes_tags` (
+  `scene_id` integer,
+  `tag_id` integer,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+
+DROP INDEX `index_scenes_tags_on_scene_id_tag_id`;
+DROP INDEX `index_scenes_tags_on_tag_id_scene_id`;
+
+CREATE INDEX `index_scenes_tags_on_scene_id_tag_id` on `scenes_tags` (`scene_id`, `tag_id`);
+CREATE INDEX `index_scenes_tags_on_tag_id_scene_id` on `scenes_tags` (`tag_id`, `scene_id`);
+
+CREATE TABLE `scene_markers_tags` (
+  `scene_marker_id` integer,
+  `tag_id` integer,
+  foreign key(`scene_marker_id`) references `scene_markers`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+
+DROP INDEX `index_scene_markers_tags_on_scene_marker_id_tag_id`;
+DROP INDEX `index_scene_markers_tags_on_tag_id_scene_marker_id`;
+
+CREATE INDEX `index_scene_markers_tags_on_scene_marker_id_tag_id` on `scene_markers_tags` (`scene_marker_id`, `tag_id`);
+CREATE INDEX `index_scene_markers_tags_on_tag_id_scene_marker_id` on `scene_markers_tags` (`tag_id`, `scene_marker_id`);
+
+INSERT INTO `performers_scenes` (`performer_id`, `scene_id`)
+  SELECT `performer_id`, `scene_id` FROM `_performers_scenes_old`;
+INSERT INTO `scenes_tags` (`scene_id`, `tag_id`)
+  SELECT `scene_id`, `tag_id` FROM `_scenes_tags_old`;
+INSERT INTO `scene_markers_tags` (`scene_marker_id`, `tag_id`)
+  SELECT `scene_marker_id`, `tag_id` FROM `_scene_markers_tags_old`;
+
+DROP TABLE `_performers_scenes_old`;
+DROP TABLE `_scenes_tags_old`;
+DROP TABLE `_scene_markers_tags_old`;
@@ -0,0 +1,204 @@
+-- search documents hold the folded text searched by the q filter of scenes,
+-- images and galleries, with the names of their performers, studio and tags,
+-- so that searches don't need to join those tables. The documents are
+-- maintained by triggers, which must be recreated along with the views if
+-- the tables they reference are rebuilt by a later migration.
+--
+-- The views and so the triggers call search_fold and display_title, which
+-- are registered by stash on its connections (see registerCustomDriver).
+-- Other SQLite clients fail with "no such function" when writing to the
+-- tables with triggers. The scene documents hold the display titles, which
+-- depend on the configured cleanup expressions, so are rebuilt by
+-- SceneQueryBuilder.RebuildSearchDocuments when those change.
+CREATE TABLE `search_documents` (
+  `entity_type` varchar(255) not null,
+  `entity_id` integer not null,
+  `text` text not null,
+  primary key(`entity_type`, `entity_id`)
+);
+
+-- fields are separated by newlines so that quoted searches don't match
+-- across fields
+CREATE VIEW `scene_search_documents` AS SELECT `scenes`.`id` AS `id`, search_fold(
+  IFNULL(`scenes`.`title`, '') || char(10) ||
+  IFNULL(`scenes`.`details`, '') || char(10) ||
+  IFNULL(`scenes`.`path`, '') || char(10) ||
+  IFNULL(`scenes`.`oshash`, '') || char(10) ||
+  IFNULL(`scenes`.`checksum`, '') || char(10) ||
+  display_title(IFNULL(`scenes`.`title`, ''), `scenes`.`path`) || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`scene_markers`.`title`, char(10)) FROM `scene_markers` WHERE `scene_markers`.`scene_id` = `scenes`.`id`), '') || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`performers`.`name` || ' ' || IFNULL(`performers`.`aliases`, ''), char(10)) FROM `performers_scenes`
+    JOIN `performers` ON `performers`.`id` = `performers_scenes`.`performer_id`
+    WHERE `performers_scenes`.`scene_id` = `scenes`.`id`), '') || char(10) ||
+  IFNULL((SELECT `studios`.`name` FROM `studios` WHERE `studios`.`id` = `scenes`.`studio_id`), '') || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`tags`.`name`, char(10)) FROM `scenes_tags`
+    JOIN `tags` ON `tags`.`id` = `scenes_tags`.`tag_id`
+    WHERE `scenes_tags`.`scene_id` = `scenes`.`id`), '')
+) AS `text` FROM `scenes`;
+
+CREATE VIEW `image_search_documents` AS SELECT `images`.`id` AS `id`, search_fold(
+  IFNULL(`images`.`title`, '') || char(10) ||
+  IFNULL(`images`.`path`, '') || char(10) ||
+  IFNULL(`images`.`checksum`, '') || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`performers`.`name` || ' ' || IFNULL(`performers`.`aliases`, ''), char(10)) FROM `performers_images`
+    JOIN `performers` ON `performers`.`id` = `performers_images`.`performer_id`
+    WHERE `performers_images`.`image_id` = `images`.`id`), '') || char(10) ||
+  IFNULL((SELECT `studios`.`name` FROM `studios` WHERE `studios`.`id` = `images`.`studio_id`), '') || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`tags`.`name`, char(10)) FROM `images_tags`
+    JOIN `tags` ON `tags`.`id` = `images_tags`.`tag_id`
+    WHERE `images_tags`.`image_id` = `images`.`id`), '')
+) AS `text` FROM `images`;
+
+CREATE VIEW `gallery_search_documents` AS SELECT `galleries`.`id` AS `id`, search_fold(
+  IFNULL(`galleries`.`path`, '') || char(10) ||
+  IFNULL(`galleries`.`checksum`, '') || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`performers`.`name` || ' ' || IFNULL(`performers`.`aliases`, ''), char(10)) FROM `performers_galleries`
+    JOIN `performers` ON `performers`.`id` = `performers_galleries`.`performer_id`
+    WHERE `performers_galleries`.`gallery_id` = `galleries`.`id`), '') || char(10) ||
+  IFNULL((SELECT `studios`.`name` FROM `studios` WHERE `studios`.`id` = `galleries`.`studio_id`), '') || char(10) ||
+  IFNULL((SELECT GROUP_CONCAT(`tags`.`name`, char(10)) FROM `galleries_tags`
+    JOIN `tags` ON `tags`.`id` = `galleries_tags`.`tag_id`
+    WHERE `galleries_tags`.`gallery_id` = `galleries`.`id`), '')
+) AS `text` FROM `galleries`;
+
+INSERT INTO `search_documents` (`entity_type`, `entity_id`, `text`) SELECT 'scene', `id`, `text` FROM `scene_search_documents`;
+INSERT INTO `search_documents` (`entity_type`, `entity_id`, `text`) SELECT 'image', `id`, `text` FROM `image_search_documents`;
+INSERT INTO `search_documents` (`entity_type`, `entity_id`, `text`) SELECT 'gallery', `id`, `text` FROM `gallery_search_documents`;
+
+-- scenes
+CREATE TRIGGER `scenes_search_insert` AFTER INSERT ON `scenes`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `scenes_search_update` AFTER UPDATE OF `title`, `details`, `path`, `oshash`, `checksum`, `studio_id` ON `scenes`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `scenes_search_delete` AFTER DELETE ON `scenes`
+BEGIN
+  DELETE FROM `search_documents` WHERE `entity_type` = 'scene' AND `entity_id` = OLD.`id`;
+END;
+
+CREATE TRIGGER `scene_markers_search_insert` AFTER INSERT ON `scene_markers`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = NEW.`scene_id`;
+END;
+CREATE TRIGGER `scene_markers_search_update` AFTER UPDATE OF `title`, `scene_id` ON `scene_markers`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` IN (OLD.`scene_id`, NEW.`scene_id`);
+END;
+CREATE TRIGGER `scene_markers_search_delete` AFTER DELETE ON `scene_markers`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = OLD.`scene_id`;
+END;
+
+CREATE TRIGGER `performers_scenes_search_insert` AFTER INSERT ON `performers_scenes`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = NEW.`scene_id`;
+END;
+CREATE TRIGGER `performers_scenes_search_delete` AFTER DELETE ON `performers_scenes`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = OLD.`scene_id`;
+END;
+
+CREATE TRIGGER `scenes_tags_search_insert` AFTER INSERT ON `scenes_tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = NEW.`scene_id`;
+END;
+CREATE TRIGGER `scenes_tags_search_delete` AFTER DELETE ON `scenes_tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` = OLD.`scene_id`;
+END;
+
+-- images
+CREATE TRIGGER `images_search_insert` AFTER INSERT ON `images`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `images_search_update` AFTER UPDATE OF `title`, `path`, `checksum`, `studio_id` ON `images`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `images_search_delete` AFTER DELETE ON `images`
+BEGIN
+  DELETE FROM `search_documents` WHERE `entity_type` = 'image' AND `entity_id` = OLD.`id`;
+END;
+
+CREATE TRIGGER `performers_images_search_insert` AFTER INSERT ON `performers_images`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` = NEW.`image_id`;
+END;
+CREATE TRIGGER `performers_images_search_delete` AFTER DELETE ON `performers_images`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` = OLD.`image_id`;
+END;
+
+CREATE TRIGGER `images_tags_search_insert` AFTER INSERT ON `images_tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` = NEW.`image_id`;
+END;
+CREATE TRIGGER `images_tags_search_delete` AFTER DELETE ON `images_tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` = OLD.`image_id`;
+END;
+
+-- galleries
+CREATE TRIGGER `galleries_search_insert` AFTER INSERT ON `galleries`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `galleries_search_update` AFTER UPDATE OF `path`, `checksum`, `studio_id` ON `galleries`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `galleries_search_delete` AFTER DELETE ON `galleries`
+BEGIN
+  DELETE FROM `search_documents` WHERE `entity_type` = 'gallery' AND `entity_id` = OLD.`id`;
+END;
+
+CREATE TRIGGER `performers_galleries_search_insert` AFTER INSERT ON `performers_galleries`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` = NEW.`gallery_id`;
+END;
+CREATE TRIGGER `performers_galleries_search_delete` AFTER DELETE ON `performers_galleries`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` = OLD.`gallery_id`;
+END;
+
+CREATE TRIGGER `galleries_tags_search_insert` AFTER INSERT ON `galleries_tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` = NEW.`gallery_id`;
+END;
+CREATE TRIGGER `galleries_tags_search_delete` AFTER DELETE ON `galleries_tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` = OLD.`gallery_id`;
+END;
+
+-- the names of performers, studios and tags are in the documents of the
+-- objects referencing them
+CREATE TRIGGER `performers_search_update` AFTER UPDATE OF `name`, `aliases` ON `performers`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents`
+    WHERE `id` IN (SELECT `scene_id` FROM `performers_scenes` WHERE `performer_id` = NEW.`id`);
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents`
+    WHERE `id` IN (SELECT `image_id` FROM `performers_images` WHERE `performer_id` = NEW.`id`);
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents`
+    WHERE `id` IN (SELECT `gallery_id` FROM `performers_galleries` WHERE `performer_id` = NEW.`id`);
+END;
+
+CREATE TRIGGER `studios_search_update` AFTER UPDATE OF `name` ON `studios`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents` WHERE `id` IN (SELECT `id` FROM `scenes` WHERE `studio_id` = NEW.`id`);
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents` WHERE `id` IN (SELECT `id` FROM `images` WHERE `studio_id` = NEW.`id`);
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents` WHERE `id` IN (SELECT `id` FROM `galleries` WHERE `studio_id` = NEW.`id`);
+END;
+
+CREATE TRIGGER `tags_search_update` AFTER UPDATE OF `name` ON `tags`
+BEGIN
+  INSERT OR REPLACE INTO `search_documents` SELECT 'scene', `id`, `text` FROM `scene_search_documents`
+    WHERE `id` IN (SELECT `scene_id` FROM `scenes_tags` WHERE `tag_id` = NEW.`id`);
+  INSERT OR REPLACE INTO `search_documents` SELECT 'image', `id`, `text` FROM `image_search_documents`
+    WHERE `id` IN (SELECT `image_id` FROM `images_tags` WHERE `tag_id` = NEW.`id`);
+  INSERT OR REPLACE INTO `search_documents` SELECT 'gallery', `id`, `text` FROM `gallery_search_documents`
+    WHERE `id` IN (SELECT `gallery_id` FROM `galleries_tags` WHERE `tag_id` = NEW.`id`);
+END;
@@ -0,0 +1,6 @@
+-- URLs of remote artwork referenced instead of stored images. The cover is
+-- used when the scene or movie has no cover or front image of its own
+ALTER TABLE `scenes` ADD COLUMN `cover_url` varchar(255);
+ALTER TABLE `scenes` ADD COLUMN `background_url` varchar(255);
+ALTER TABLE `movies` ADD COLUMN `cover_url` varchar(255);
+ALTER TABLE `movies` ADD COLUMN `background_url` varchar(255);
@@ -0,0 +1,10 @@
+-- caption sidecar files next to the scene file, read during the scan. The
+-- language code is empty if the file name has none
+CREATE TABLE `scene_captions` (
+  `scene_id` integer not null,
+  `filename` varchar(255) not null,
+  `language_code` varchar(255) not null,
+  `caption_type` varchar(255) not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  primary key(`scene_id`, `filename`)
+);
@@ -0,0 +1,145 @@
+-- natural sort keys of the columns sorted by name, title and path, which
+-- sort bytewise in natural order, so that plain indexes keep the sorts fast.
+-- They replace the indexes of migration 21, which used the NATURAL_CI and
+-- NATURAL_CS collations: SQLite checks the order of those indexes when rows
+-- are written or deleted and when the database is checked or vacuumed, so
+-- other SQLite clients could do none of these.
+--
+-- The keys are maintained by triggers, which must be recreated if the tables
+-- are rebuilt by a later migration. The triggers call natural_sort_key, which
+-- is registered by stash on its connections (see registerCustomDriver), so
+-- other SQLite clients still fail with "no such function" when inserting rows
+-- or changing the sorted columns.
+DROP INDEX `index_performers_on_name_natural`;
+DROP INDEX `index_studios_on_name_natural`;
+DROP INDEX `index_tags_on_name_natural`;
+DROP INDEX `index_movies_on_name_natural`;
+DROP INDEX `index_scenes_on_title_natural`;
+DROP INDEX `index_scenes_on_path_natural`;
+DROP INDEX `index_galleries_on_title_natural`;
+DROP INDEX `index_galleries_on_path_natural`;
+DROP INDEX `index_images_on_title_natural`;
+DROP INDEX `index_images_on_path_natural`;
+
+ALTER TABLE `performers` ADD COLUMN `name_sort` text;
+ALTER TABLE `studios` ADD COLUMN `name_sort` text;
+ALTER TABLE `tags` ADD COLUMN `name_sort` text;
+ALTER TABLE `movies` ADD COLUMN `name_sort` text;
+ALTER TABLE `scenes` ADD COLUMN `title_sort` text;
+ALTER TABLE `scenes` ADD COLUMN `path_sort` text;
+ALTER TABLE `galleries` ADD COLUMN `title_sort` text;
+ALTER TABLE `galleries` ADD COLUMN `path_sort` text;
+ALTER TABLE `images` ADD COLUMN `title_sort` text;
+ALTER TABLE `images` ADD COLUMN `path_sort` text;
+
+UPDATE `performers` SET `name_sort` = natural_sort_key(`name`, 1);
+UPDATE `studios` SET `name_sort` = natural_sort_key(`name`, 1);
+UPDATE `tags` SET `name_sort` = natural_sort_key(`name`, 1);
+UPDATE `movies` SET `name_sort` = natural_sort_key(`name`, 1);
+UPDATE `scenes` SET `title_sort` = natural_sort_key(`title`, 0);
+UPDATE `scenes` SET `path_sort` = natural_sort_key(`path`, 0);
+UPDATE `galleries` SET `title_sort` = natural_sort_key(`title`, 0);
+UPDATE `galleries` SET `path_sort` = natural_sort_key(`path`, 0);
+UPDATE `images` SET `title_sort` = natural_sort_key(`title`, 0);
+UPDATE `images` SET `path_sort` = natural_sort_key(`path`, 0);
+
+CREATE INDEX `index_performers_on_name_sort` on `performers` (`name_sort`);
+CREATE INDEX `index_studios_on_name_sort` on `studios` (`name_sort`);
+CREATE INDEX `index_tags_on_name_sort` on `tags` (`name_sort`);
+CREATE INDEX `index_movies_on_name_sort` on `movies` (`name_sort`);
+CREATE INDEX `index_scenes_on_title_sort` on `scenes` (`title_sort`);
+CREATE INDEX `index_scenes_on_path_sort` on `scenes` (`path_sort`);
+CREATE INDEX `index_galleries_on_title_sort` on `galleries` (`title_sort`);
+CREATE INDEX `index_galleries_on_path_sort` on `galleries` (`path_sort`);
+CREATE INDEX `index_images_on_title_sort` on `images` (`title_sort`);
+CREATE INDEX `index_images_on_path_sort` on `images` (`path_sort`);
+
+CREATE TRIGGER `performers_name_sort_insert` AFTER INSERT ON `performers`
+BEGIN
+  UPDATE `performers` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `performers_name_sort_update` AFTER UPDATE OF `name` ON `performers`
+BEGIN
+  UPDATE `performers` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `studios_name_sort_insert` AFTER INSERT ON `studios`
+BEGIN
+  UPDATE `studios` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `studios_name_sort_update` AFTER UPDATE OF `name` ON `studios`
+BEGIN
+  UPDATE `studios` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `tags_name_sort_insert` AFTER INSERT ON `tags`
+BEGIN
+  UPDATE `tags` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `tags_name_sort_update` AFTER UPDATE OF `name` ON `tags`
+BEGIN
+  UPDATE `tags` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `movies_name_sort_insert` AFTER INSERT ON `movies`
+BEGIN
+  UPDATE `movies` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `movies_name_sort_update` AFTER UPDATE OF `name` ON `movies`
+BEGIN
+  UPDATE `movies` SET `name_sort` = natural_sort_key(NEW.`name`, 1) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `scenes_title_sort_insert` AFTER INSERT ON `scenes`
+BEGIN
+  UPDATE `scenes` SET `title_sort` = natural_sort_key(NEW.`title`, 0) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `scenes_title_sort_update` AFTER UPDATE OF `title` ON `scenes`
+BEGIN
+  UPDATE `scenes` SET `title_sort` = natural_sort_key(NEW.`title`, 0) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `scenes_path_sort_insert` AFTER INSERT ON `scenes`
+BEGIN
+  UPDATE `scenes` SET `path_sort` = natural_sort_key(NEW.`path`, 0) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `scenes_path_sort_update` AFTER UPDATE OF `path` ON `scenes`
+BEGIN
+  UPDATE `scenes` SET `path_sort` = natural_sort_key(NEW.`path`, 0) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `galleries_title_sort_insert` AFTER INSERT ON `galleries`
+BEGIN
+  UPDATE `galleries` SET `title_sort` = natural_sort_key(NEW.`title`, 0) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `galleries_title_sort_update` AFTER UPDATE OF `title` ON `galleries`
+BEGIN
+  UPDATE `galleries` SET `title_sort` = natural_sort_key(NEW.`title`, 0) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `galleries_path_sort_insert` AFTER INSERT ON `galleries`
+BEGIN
+  UPDATE `galleries` SET `path_sort` = natural_sort_key(NEW.`path`, 0) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `galleries_path_sort_update` AFTER UPDATE OF `path` ON `galleries`
+BEGIN
+  UPDATE `galleries` SET `path_sort` = natural_sort_key(NEW.`path`, 0) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `images_title_sort_insert` AFTER INSERT ON `images`
+BEGIN
+  UPDATE `images` SET `title_sort` = natural_sort_key(NEW.`title`, 0) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `images_title_sort_update` AFTER UPDATE OF `title` ON `images`
+BEGIN
+  UPDATE `images` SET `title_sort` = natural_sort_key(NEW.`title`, 0) WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER `images_path_sort_insert` AFTER INSERT ON `images`
+BEGIN
+  UPDATE `images` SET `path_sort` = natural_sort_key(NEW.`path`, 0) WHERE `id` = NEW.`id`;
+END;
+CREATE TRIGGER `images_path_sort_update` AFTER UPDATE OF `path` ON `images`
+BEGIN
+  UPDATE `images` SET `path_sort` = natural_sort_key(NEW.`path`, 0) WHERE `id` = NEW.`id`;
+END;
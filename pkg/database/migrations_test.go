@@ -0,0 +1,176 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapedItemsMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-migration-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldPath := dbPath
+	dbPath = filepath.Join(dir, "stash-go.sqlite")
+	defer func() {
+		dbPath = oldPath
+	}()
+
+	m, err := getMigrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.Migrate(24); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(sqlite3Driver, "file:"+dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// every control character must be escaped
+	var title string
+	for c := 0; c < 0x20; c++ {
+		title += string(rune(c))
+	}
+	title += `"quoted" \ <title>`
+
+	if _, err := db.Exec("INSERT INTO studios (name, checksum, created_at, updated_at) VALUES ('Studio\b', 'x', '2020-01-01', '2020-01-01')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO scraped_items (title, date, episode, studio_id, created_at, updated_at) VALUES (?, '2020-01-02', 3, 1, '2020-01-01', '2020-01-01')", title); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Migrate(25); err != nil {
+		t.Fatal(err)
+	}
+
+	var fields, result string
+	if err := db.QueryRow("SELECT fields, result FROM scrape_history").Scan(&fields, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "title,date,episode,studio", fields)
+
+	var item map[string]interface{}
+	if assert.Nil(t, json.Unmarshal([]byte(result), &item), result) {
+		assert.Equal(t, map[string]interface{}{
+			"title":   title,
+			"date":    "2020-01-02",
+			"episode": float64(3),
+			"studio":  "Studio\b",
+		}, item)
+	}
+}
+
+func TestNaturalSortKeysMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-migration-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	oldPath := dbPath
+	dbPath = filepath.Join(dir, "stash-go.sqlite")
+	defer func() {
+		dbPath = oldPath
+	}()
+
+	m, err := getMigrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.Migrate(48); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open(sqlite3Driver, "file:"+dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tagNames := func(db *sql.DB) []string {
+		rows, err := db.Query("SELECT name FROM tags ORDER BY name_sort")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		var ret []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			ret = append(ret, name)
+		}
+		return ret
+	}
+
+	for _, name := range []string{"Tag 10", "tag 2"} {
+		if _, err := db.Exec("INSERT INTO tags (name, created_at, updated_at) VALUES (?, '2020-01-01', '2020-01-01')", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.Migrate(49); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys of existing rows are set by the migration, and those of new
+	// and changed rows by the triggers
+	assert.Equal(t, []string{"tag 2", "Tag 10"}, tagNames(db))
+
+	if _, err := db.Exec("INSERT INTO tags (name, created_at, updated_at) VALUES ('TAG 1', '2020-01-01', '2020-01-01')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE tags SET name = 'Tag 3' WHERE name = 'Tag 10'"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"TAG 1", "tag 2", "Tag 3"}, tagNames(db))
+	db.Close()
+
+	// other SQLite clients can check, copy and delete from the database, but
+	// not insert rows, since the triggers call natural_sort_key
+	other, err := sql.Open("sqlite3", "file:"+dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	var check string
+	if err := other.QueryRow("PRAGMA quick_check").Scan(&check); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ok", check)
+
+	vacuumPath := filepath.Join(dir, "vacuum.sqlite")
+	if _, err := other.Exec("VACUUM INTO ?", vacuumPath); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, quickCheck(vacuumPath))
+
+	if _, err := other.Exec("DELETE FROM tags WHERE name = 'Tag 3'"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"TAG 1", "tag 2"}, tagNames(other))
+
+	_, err = other.Exec("INSERT INTO tags (name, created_at, updated_at) VALUES ('Tag 4', '2020-01-01', '2020-01-01')")
+	assert.NotNil(t, err)
+}
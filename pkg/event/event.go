@@ -0,0 +1,67 @@
+// Package event provides a bus used to notify subscribers of changes made to
+// stored objects.
+package event
+
+import (
+	"sync"
+)
+
+// Entity is the type of object that an event refers to.
+type Entity string
+
+const (
+	EntityScene       Entity = "scene"
+	EntitySceneMarker Entity = "scene_marker"
+	EntityImage       Entity = "image"
+	EntityGallery     Entity = "gallery"
+	EntityPerformer   Entity = "performer"
+	EntityStudio      Entity = "studio"
+	EntityMovie       Entity = "movie"
+	EntityTag         Entity = "tag"
+)
+
+// Action is the change made to the objects that an event refers to.
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDestroy Action = "destroy"
+)
+
+// Event describes a change made to one or more objects of the same type.
+type Event struct {
+	Entity Entity
+	Action Action
+	IDs    []int
+	// Actor is the name of the user who made the change. It is empty if
+	// authentication is not enabled.
+	Actor string
+}
+
+// Handler is called for each published event.
+type Handler func(e Event)
+
+var (
+	mutex    sync.RWMutex
+	handlers []Handler
+)
+
+// Subscribe registers a handler to be called for every published event.
+// Handlers are called synchronously by Publish, so should return quickly.
+func Subscribe(h Handler) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	handlers = append(handlers, h)
+}
+
+// Publish notifies all subscribed handlers of the event.
+func Publish(e Event) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
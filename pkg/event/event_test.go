@@ -0,0 +1,23 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	var received []Event
+	Subscribe(func(e Event) {
+		received = append(received, e)
+	})
+
+	e := Event{
+		Entity: EntityScene,
+		Action: ActionUpdate,
+		IDs:    []int{1, 2},
+	}
+	Publish(e)
+
+	assert.Equal(t, []Event{e}, received)
+}
@@ -1,6 +1,7 @@
 package ffmpeg
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/tracing"
 )
 
 type Encoder struct {
@@ -83,6 +85,11 @@ func KillRunningEncoders(path string) {
 }
 
 func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
+	_, span := tracing.Start(context.Background(), "ffmpeg")
+	span.SetKind(tracing.KindClient)
+	span.SetAttribute("process.command_args", strings.Join(args, " "))
+	defer span.End()
+
 	cmd := exec.Command(e.Path, args...)
 
 	stderr, err := cmd.StderrPipe()
@@ -96,6 +103,7 @@ func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
 	}
 
 	if err = cmd.Start(); err != nil {
+		span.SetError(err)
 		return "", err
 	}
 
@@ -132,6 +140,7 @@ func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
 	if err != nil {
 		// error message should be in the stderr stream
 		logger.Errorf("ffmpeg error when running command <%s>: %s", strings.Join(cmd.Args, " "), errBuilder.String())
+		span.SetError(err)
 		return stdoutString, err
 	}
 
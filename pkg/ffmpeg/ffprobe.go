@@ -1,6 +1,7 @@
 package ffmpeg
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/tracing"
 )
 
 type Container string
@@ -219,23 +221,55 @@ type VideoFile struct {
 	Rotation     int64
 
 	AudioCodec string
+
+	Chapters []Chapter
+}
+
+// Chapter is a chapter embedded in a video file.
+type Chapter struct {
+	Start float64
+	// End is zero if the end of the chapter is unknown
+	End   float64
+	Title string
 }
 
 // Execute exec command and bind result to struct.
 func NewVideoFile(ffprobePath string, videoPath string, stripExt bool) (*VideoFile, error) {
-	args := []string{"-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", "-show_error", videoPath}
+	out, err := RunProbe(ffprobePath, videoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewVideoFileFromProbeJSON(videoPath, out, stripExt)
+}
+
+// RunProbe runs ffprobe on the video file and returns its JSON output.
+func RunProbe(ffprobePath string, videoPath string) ([]byte, error) {
+	args := []string{"-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", "-show_chapters", "-show_error", videoPath}
 	//// Extremely slow on windows for some reason
 	//if runtime.GOOS != "windows" {
 	//	args = append(args, "-count_frames")
 	//}
+	_, span := tracing.Start(context.Background(), "ffprobe")
+	span.SetKind(tracing.KindClient)
+	span.SetAttribute("process.command_args", strings.Join(args, " "))
+	defer span.End()
+
 	out, err := exec.Command(ffprobePath, args...).Output()
 
 	if err != nil {
+		span.SetError(err)
 		return nil, fmt.Errorf("FFProbe encountered an error with <%s>.\nError JSON:\n%s\nError: %s", videoPath, string(out), err.Error())
 	}
 
+	return out, nil
+}
+
+// NewVideoFileFromProbeJSON returns the video file described by the JSON
+// output of ffprobe.
+func NewVideoFileFromProbeJSON(videoPath string, data []byte, stripExt bool) (*VideoFile, error) {
 	probeJSON := &FFProbeJSON{}
-	if err := json.Unmarshal(out, probeJSON); err != nil {
+	if err := json.Unmarshal(data, probeJSON); err != nil {
 		return nil, fmt.Errorf("Error unmarshalling video data for <%s>: %s", videoPath, err.Error())
 	}
 
@@ -280,6 +314,15 @@ func parse(filePath string, probeJSON *FFProbeJSON, stripExt bool) (*VideoFile,
 	result.StartTime, _ = strconv.ParseFloat(probeJSON.Format.StartTime, 64)
 	result.CreationTime = probeJSON.Format.Tags.CreationTime.Time
 
+	for _, c := range probeJSON.Chapters {
+		chapter := Chapter{
+			Title: strings.TrimSpace(c.Tags.Title),
+		}
+		chapter.Start, _ = strconv.ParseFloat(c.StartTime, 64)
+		chapter.End, _ = strconv.ParseFloat(c.EndTime, 64)
+		result.Chapters = append(result.Chapters, chapter)
+	}
+
 	audioStream := result.GetAudioStream()
 	if audioStream != nil {
 		result.AudioCodec = audioStream.CodecName
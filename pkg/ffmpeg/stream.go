@@ -16,13 +16,18 @@ import (
 const CopyStreamCodec = "copy"
 
 type Stream struct {
-	Stdout   io.ReadCloser
-	Process  *os.Process
+	Stdout  io.ReadCloser
+	Process *os.Process
+	// Tee, if set, is written the stream output as it is served
+	Tee      io.Writer
 	options  TranscodeStreamOptions
 	mimeType string
+	done     chan error
 }
 
-func (s *Stream) Serve(w http.ResponseWriter, r *http.Request) {
+// Serve writes the stream output to w. It returns an error if the output
+// could not be written in full.
+func (s *Stream) Serve(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", s.mimeType)
 	w.WriteHeader(http.StatusOK)
 
@@ -35,10 +40,22 @@ func (s *Stream) Serve(w http.ResponseWriter, r *http.Request) {
 		s.Process.Kill()
 	}()
 
-	_, err := io.Copy(w, s.Stdout)
+	var src io.Reader = s.Stdout
+	if s.Tee != nil {
+		src = io.TeeReader(src, s.Tee)
+	}
+
+	_, err := io.Copy(w, src)
 	if err != nil {
 		logger.Errorf("[stream] error serving transcoded video file: %s", err.Error())
 	}
+	return err
+}
+
+// Wait waits for the transcode process to exit, returning an error if it
+// did not exit successfully.
+func (s *Stream) Wait() error {
+	return <-s.done
 }
 
 type Codec struct {
@@ -224,7 +241,10 @@ func (e *Encoder) stream(probeResult VideoFile, options TranscodeStreamOptions)
 	}
 
 	registerRunningEncoder(probeResult.Path, cmd.Process)
-	go waitAndDeregister(probeResult.Path, cmd)
+	done := make(chan error, 1)
+	go func() {
+		done <- waitAndDeregister(probeResult.Path, cmd)
+	}()
 
 	// stderr must be consumed or the process deadlocks
 	go func() {
@@ -240,6 +260,7 @@ func (e *Encoder) stream(probeResult VideoFile, options TranscodeStreamOptions)
 		Process:  cmd.Process,
 		options:  options,
 		mimeType: options.Codec.MimeType,
+		done:     done,
 	}
 	return ret, nil
 }
@@ -12,6 +12,7 @@ func ToBasicJSON(gallery *models.Gallery) (*jsonschema.Gallery, error) {
 	newGalleryJSON := jsonschema.Gallery{
 		Checksum:  gallery.Checksum,
 		Zip:       gallery.Zip,
+		UUID:      gallery.UUID.String,
 		CreatedAt: models.JSONTime{Time: gallery.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: gallery.UpdatedAt.Timestamp},
 	}
@@ -37,7 +38,7 @@ func ToBasicJSON(gallery *models.Gallery) (*jsonschema.Gallery, error) {
 	}
 
 	if gallery.Rating.Valid {
-		newGalleryJSON.Rating = int(gallery.Rating.Int64)
+		newGalleryJSON.Rating100 = int(gallery.Rating.Int64)
 	}
 
 	newGalleryJSON.Organized = gallery.Organized
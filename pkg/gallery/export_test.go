@@ -92,7 +92,7 @@ func createFullJSONGallery() *jsonschema.Gallery {
 		Checksum:  checksum,
 		Date:      date,
 		Details:   details,
-		Rating:    rating,
+		Rating100: rating,
 		Organized: organized,
 		URL:       url,
 		CreatedAt: models.JSONTime{
@@ -46,6 +46,7 @@ func (i *Importer) galleryJSONToGallery(galleryJSON jsonschema.Gallery) models.G
 	newGallery := models.Gallery{
 		Checksum: galleryJSON.Checksum,
 		Zip:      galleryJSON.Zip,
+		UUID:     sql.NullString{String: galleryJSON.UUID, Valid: galleryJSON.UUID != ""},
 	}
 
 	if galleryJSON.Path != "" {
@@ -64,8 +65,10 @@ func (i *Importer) galleryJSONToGallery(galleryJSON jsonschema.Gallery) models.G
 	if galleryJSON.Date != "" {
 		newGallery.Date = models.SQLiteDate{String: galleryJSON.Date, Valid: true}
 	}
-	if galleryJSON.Rating != 0 {
-		newGallery.Rating = sql.NullInt64{Int64: int64(galleryJSON.Rating), Valid: true}
+	if galleryJSON.Rating100 != 0 {
+		newGallery.Rating = sql.NullInt64{Int64: int64(galleryJSON.Rating100), Valid: true}
+	} else if galleryJSON.Rating != 0 {
+		newGallery.Rating = sql.NullInt64{Int64: int64(models.Rating5To100(galleryJSON.Rating)), Valid: true}
 	}
 
 	newGallery.Organized = galleryJSON.Organized
@@ -298,6 +301,8 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	gallery := i.gallery
 	gallery.ID = id
+	// keep the uuid of the existing gallery, which other instances refer to it by
+	gallery.UUID = sql.NullString{}
 	_, err := i.ReaderWriter.Update(gallery)
 	if err != nil {
 		return fmt.Errorf("error updating existing gallery: %s", err.Error())
@@ -61,7 +61,7 @@ func TestImporterPreImport(t *testing.T) {
 			Title:     title,
 			Date:      date,
 			Details:   details,
-			Rating:    rating,
+			Rating100: rating,
 			Organized: organized,
 			URL:       url,
 			CreatedAt: models.JSONTime{
@@ -0,0 +1,171 @@
+package image
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// duplicateGroups is a union-find structure over image indexes, which also
+// tracks the reasons that each set was joined.
+type duplicateGroups struct {
+	parent  []int
+	reasons map[int]map[models.ImageDuplicateReason]bool
+}
+
+func newDuplicateGroups(n int) *duplicateGroups {
+	ret := &duplicateGroups{
+		parent:  make([]int, n),
+		reasons: make(map[int]map[models.ImageDuplicateReason]bool),
+	}
+
+	for i := range ret.parent {
+		ret.parent[i] = i
+	}
+
+	return ret
+}
+
+func (g *duplicateGroups) find(i int) int {
+	for g.parent[i] != i {
+		g.parent[i] = g.parent[g.parent[i]]
+		i = g.parent[i]
+	}
+	return i
+}
+
+func (g *duplicateGroups) union(a, b int, reason models.ImageDuplicateReason) {
+	rootA := g.find(a)
+	rootB := g.find(b)
+
+	if rootA != rootB {
+		// always use the lowest index as the root
+		if rootB < rootA {
+			rootA, rootB = rootB, rootA
+		}
+		g.parent[rootB] = rootA
+
+		if g.reasons[rootA] == nil {
+			g.reasons[rootA] = make(map[models.ImageDuplicateReason]bool)
+		}
+		for r := range g.reasons[rootB] {
+			g.reasons[rootA][r] = true
+		}
+		delete(g.reasons, rootB)
+	}
+
+	if g.reasons[rootA] == nil {
+		g.reasons[rootA] = make(map[models.ImageDuplicateReason]bool)
+	}
+	g.reasons[rootA][reason] = true
+}
+
+// phashBlock returns the bits of phash in block i of n blocks of about equal
+// width.
+func phashBlock(phash int64, i int, n int) uint64 {
+	start := uint(i * 64 / n)
+	end := uint((i + 1) * 64 / n)
+	return (uint64(phash) >> start) & (1<<(end-start) - 1)
+}
+
+// unionPhashes joins the images with perceptual hashes within distance of
+// each other. Hashes within distance d of each other have at least one of
+// d+1 blocks in common, so only the images sharing a block are compared.
+func unionPhashes(images []*models.Image, distance int, groups *duplicateGroups) {
+	if distance < 0 {
+		return
+	}
+
+	n := distance + 1
+	if n > 64 {
+		n = 64
+	}
+
+	type blockKey struct {
+		index int
+		value uint64
+	}
+	blocks := make(map[blockKey][]int)
+	for i, img := range images {
+		if !img.Phash.Valid {
+			continue
+		}
+		for b := 0; b < n; b++ {
+			key := blockKey{index: b, value: phashBlock(img.Phash.Int64, b, n)}
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	for _, indexes := range blocks {
+		for x, i := range indexes {
+			for _, j := range indexes[x+1:] {
+				d := bits.OnesCount64(uint64(images[i].Phash.Int64 ^ images[j].Phash.Int64))
+				if d <= distance {
+					groups.union(i, j, models.ImageDuplicateReasonPhash)
+				}
+			}
+		}
+	}
+}
+
+// FindDuplicates returns groups of images that are probable duplicates of
+// each other. Images are duplicates if they have the same checksum or, if
+// phashDistance is not nil, perceptual hashes within phashDistance of each
+// other.
+//
+// Groups are ordered by the lowest image ID in each group, and the images in
+// each group are ordered by ID.
+func FindDuplicates(images []*models.Image, phashDistance *int) []*models.ImageDuplicateGroup {
+	sorted := make([]*models.Image, len(images))
+	copy(sorted, images)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	groups := newDuplicateGroups(len(sorted))
+
+	checksums := make(map[string]int)
+	for i, img := range sorted {
+		if first, found := checksums[img.Checksum]; found {
+			groups.union(first, i, models.ImageDuplicateReasonChecksum)
+		} else {
+			checksums[img.Checksum] = i
+		}
+	}
+
+	if phashDistance != nil {
+		unionPhashes(sorted, *phashDistance, groups)
+	}
+
+	members := make(map[int][]*models.Image)
+	var roots []int
+	for i, img := range sorted {
+		root := groups.find(i)
+		if _, found := members[root]; !found {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], img)
+	}
+
+	var ret []*models.ImageDuplicateGroup
+	for _, root := range roots {
+		if len(members[root]) < 2 {
+			continue
+		}
+
+		group := &models.ImageDuplicateGroup{
+			Images: members[root],
+		}
+
+		for _, reason := range models.AllImageDuplicateReason {
+			if groups.reasons[root][reason] {
+				group.Reasons = append(group.Reasons, reason)
+			}
+		}
+
+		ret = append(ret, group)
+	}
+
+	return ret
+}
@@ -0,0 +1,58 @@
+package image
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func createDuplicateTestImage(id int, checksum string, phash *int64) *models.Image {
+	ret := &models.Image{
+		ID:       id,
+		Checksum: checksum,
+	}
+
+	if phash != nil {
+		ret.Phash = sql.NullInt64{Int64: *phash, Valid: true}
+	}
+
+	return ret
+}
+
+func TestFindDuplicates(t *testing.T) {
+	base := int64(0x0f0f0f0f0f0f0f0f)
+	near := base ^ 0x7   // distance 3
+	far := base ^ 0xffff // distance 16
+
+	images := []*models.Image{
+		createDuplicateTestImage(5, "e", &far),
+		createDuplicateTestImage(1, "a", &base),
+		createDuplicateTestImage(2, "a", nil),
+		createDuplicateTestImage(3, "c", &near),
+		createDuplicateTestImage(4, "d", nil),
+	}
+
+	groups := FindDuplicates(images, nil)
+	assert.Len(t, groups, 1)
+	assert.Equal(t, 1, groups[0].Images[0].ID)
+	assert.Equal(t, 2, groups[0].Images[1].ID)
+	assert.Equal(t, []models.ImageDuplicateReason{models.ImageDuplicateReasonChecksum}, groups[0].Reasons)
+
+	distance := 4
+	groups = FindDuplicates(images, &distance)
+	assert.Len(t, groups, 1)
+	var ids []int
+	for _, img := range groups[0].Images {
+		ids = append(ids, img.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	assert.Equal(t, []models.ImageDuplicateReason{models.ImageDuplicateReasonChecksum, models.ImageDuplicateReasonPhash}, groups[0].Reasons)
+
+	distance = 16
+	groups = FindDuplicates(images, &distance)
+	assert.Len(t, groups, 1)
+	assert.Len(t, groups[0].Images, 4)
+}
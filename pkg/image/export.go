@@ -11,6 +11,7 @@ import (
 func ToBasicJSON(image *models.Image) *jsonschema.Image {
 	newImageJSON := jsonschema.Image{
 		Checksum:  image.Checksum,
+		UUID:      image.UUID.String,
 		CreatedAt: models.JSONTime{Time: image.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: image.UpdatedAt.Timestamp},
 	}
@@ -20,7 +21,7 @@ func ToBasicJSON(image *models.Image) *jsonschema.Image {
 	}
 
 	if image.Rating.Valid {
-		newImageJSON.Rating = int(image.Rating.Int64)
+		newImageJSON.Rating100 = int(image.Rating.Int64)
 	}
 
 	newImageJSON.Organized = image.Organized
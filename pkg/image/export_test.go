@@ -99,7 +99,7 @@ func createFullJSONImage() *jsonschema.Image {
 		Title:     title,
 		Checksum:  checksum,
 		OCounter:  ocounter,
-		Rating:    rating,
+		Rating100: rating,
 		Organized: organized,
 		File: &jsonschema.ImageFile{
 			Height: height,
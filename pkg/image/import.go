@@ -54,13 +54,16 @@ func (i *Importer) imageJSONToImage(imageJSON jsonschema.Image) models.Image {
 	newImage := models.Image{
 		Checksum: imageJSON.Checksum,
 		Path:     i.Path,
+		UUID:     sql.NullString{String: imageJSON.UUID, Valid: imageJSON.UUID != ""},
 	}
 
 	if imageJSON.Title != "" {
 		newImage.Title = sql.NullString{String: imageJSON.Title, Valid: true}
 	}
-	if imageJSON.Rating != 0 {
-		newImage.Rating = sql.NullInt64{Int64: int64(imageJSON.Rating), Valid: true}
+	if imageJSON.Rating100 != 0 {
+		newImage.Rating = sql.NullInt64{Int64: int64(imageJSON.Rating100), Valid: true}
+	} else if imageJSON.Rating != 0 {
+		newImage.Rating = sql.NullInt64{Int64: int64(models.Rating5To100(imageJSON.Rating)), Valid: true}
 	}
 
 	newImage.Organized = imageJSON.Organized
@@ -306,6 +309,8 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	image := i.image
 	image.ID = id
+	// keep the uuid of the existing image, which other instances refer to it by
+	image.UUID = sql.NullString{}
 	i.ID = id
 	_, err := i.ReaderWriter.UpdateFull(image)
 	if err != nil {
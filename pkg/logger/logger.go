@@ -26,17 +26,19 @@ var waiting = false
 var lastBroadcast = time.Now()
 var logBuffer []LogItem
 
+// logFile is the log file written to, if file logging is enabled
+var logFile *rotatingFile
+
 // Init initialises the logger based on a logging configuration
-func Init(logFile string, logOut bool, logLevel string) {
-	var file *os.File
+func Init(logFilePath string, logOut bool, logLevel string, rotate RotateConfig) {
+	var file *rotatingFile
 
-	if logFile != "" {
+	if logFilePath != "" {
 		var err error
-		file, err = os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		file, err = newRotatingFile(logFilePath, rotate)
 
 		if err != nil {
-			fmt.Printf("Could not open '%s' for log output due to error: %s\n", logFile, err.Error())
-			logFile = ""
+			fmt.Printf("Could not open '%s' for log output due to error: %s\n", logFilePath, err.Error())
 		}
 	}
 
@@ -49,9 +51,32 @@ func Init(logFile string, logOut bool, logLevel string) {
 
 	// otherwise, output to StdErr
 
+	logFile = file
+	if file != nil {
+		// apply the retention settings to files rotated by a previous run
+		go file.mill()
+	}
+
 	SetLogLevel(logLevel)
 }
 
+// SetRotateConfig sets the rotation settings of the log file.
+func SetRotateConfig(rotate RotateConfig) {
+	if logFile != nil {
+		logFile.setConfig(rotate)
+	}
+}
+
+// LogFiles returns the paths of the log file and its rotated files, newest
+// first. Nil is returned if file logging is disabled.
+func LogFiles() ([]string, error) {
+	if logFile == nil {
+		return nil, nil
+	}
+
+	return logFile.files()
+}
+
 func SetLogLevel(level string) {
 	logger.Level = logLevelFromString(level)
 }
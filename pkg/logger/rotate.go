@@ -0,0 +1,299 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the format of the time in the names of rotated log
+// files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+// RotateConfig configures the rotation of the log file.
+type RotateConfig struct {
+	// MaxSize is the size in bytes at which the log file is rotated. Zero
+	// disables rotation by size.
+	MaxSize int64
+	// Interval is the period after which the log file is rotated, aligned to
+	// UTC. Zero disables rotation by time.
+	Interval time.Duration
+	// MaxBackups is the number of rotated files kept. Zero keeps all files.
+	MaxBackups int
+	// MaxAge is the age after which rotated files are deleted. Zero keeps
+	// files regardless of age.
+	MaxAge time.Duration
+	// Compress is true if rotated files are compressed with gzip.
+	Compress bool
+}
+
+// rotatingFile is a log file which is rotated by size and time. Rotated files
+// are named after the log file with the time of rotation appended.
+type rotatingFile struct {
+	path   string
+	config RotateConfig
+
+	mutex     sync.Mutex
+	file      *os.File
+	size      int64
+	lastWrite time.Time
+
+	// millMutex serialises the compression and removal of rotated files,
+	// which is done in the background
+	millMutex sync.Mutex
+}
+
+func newRotatingFile(path string, config RotateConfig) (*rotatingFile, error) {
+	ret := &rotatingFile{
+		path:   path,
+		config: config,
+	}
+
+	if err := ret.open(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.lastWrite = info.ModTime()
+	if f.size == 0 {
+		f.lastWrite = time.Now()
+	}
+
+	return nil
+}
+
+func (f *rotatingFile) setConfig(config RotateConfig) {
+	f.mutex.Lock()
+	f.config = config
+	f.mutex.Unlock()
+
+	go f.mill()
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	now := time.Now()
+	if f.shouldRotate(now, int64(len(p))) {
+		if err := f.rotate(now); err != nil {
+			// keep writing to the current file rather than losing the log
+			fmt.Fprintf(os.Stderr, "Could not rotate log file '%s': %s\n", f.path, err.Error())
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	f.lastWrite = now
+
+	return n, err
+}
+
+func (f *rotatingFile) shouldRotate(now time.Time, writeSize int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.config.MaxSize > 0 && f.size+writeSize > f.config.MaxSize {
+		return true
+	}
+
+	interval := f.config.Interval
+	return interval > 0 && !now.Truncate(interval).Equal(f.lastWrite.Truncate(interval))
+}
+
+// rotate renames the log file to a backup file and opens a new log file.
+// Assumes mutex held.
+func (f *rotatingFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.path, f.backupPath(now)); err != nil {
+		// reopen the current file to continue logging to it
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	go f.mill()
+	return nil
+}
+
+// backupPath returns the path to rename the log file to when rotating it at
+// t. The time is advanced past that of existing rotated files, so that files
+// rotated within the same millisecond are not overwritten.
+func (f *rotatingFile) backupPath(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	for {
+		ret := strings.TrimSuffix(f.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+		if !fileExists(ret) && !fileExists(ret+compressSuffix) {
+			return ret
+		}
+
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// backups returns the rotated files of the log file, newest first.
+func (f *rotatingFile) backups() ([]backupFile, error) {
+	dir := filepath.Dir(f.path)
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []backupFile
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		ts := strings.TrimPrefix(strings.TrimSuffix(name, compressSuffix), prefix)
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(ts, ext), time.Local)
+		if err != nil {
+			continue
+		}
+
+		ret = append(ret, backupFile{
+			path: filepath.Join(dir, name),
+			time: t,
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].time.After(ret[j].time)
+	})
+
+	return ret, nil
+}
+
+// mill removes the rotated files exceeding the retention settings, and
+// compresses the remaining files if compression is enabled.
+func (f *rotatingFile) mill() {
+	f.millMutex.Lock()
+	defer f.millMutex.Unlock()
+
+	f.mutex.Lock()
+	config := f.config
+	f.mutex.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not list rotated log files: %s\n", err.Error())
+		return
+	}
+
+	cutoff := time.Now().Add(-config.MaxAge)
+	for i, b := range backups {
+		if (config.MaxBackups > 0 && i >= config.MaxBackups) || (config.MaxAge > 0 && b.time.Before(cutoff)) {
+			if err := os.Remove(b.path); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not remove rotated log file '%s': %s\n", b.path, err.Error())
+			}
+			continue
+		}
+
+		if config.Compress && !strings.HasSuffix(b.path, compressSuffix) {
+			if err := compressFile(b.path); err != nil {
+				fmt.Fprintf(os.Stderr, "Could not compress rotated log file '%s': %s\n", b.path, err.Error())
+			}
+		}
+	}
+}
+
+// compressFile compresses the file at path with gzip, replacing it.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + compressSuffix)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + compressSuffix)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + compressSuffix)
+		return err
+	}
+
+	in.Close()
+	return os.Remove(path)
+}
+
+// files returns the paths of the log file and its rotated files, newest
+// first.
+func (f *rotatingFile) files() ([]string, error) {
+	backups, err := f.backups()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []string{f.path}
+	for _, b := range backups {
+		ret = append(ret, b.path)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLine = "0123456789\n"
+
+func TestRotatingFileMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "log-rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "stash.log")
+	f, err := newRotatingFile(path, RotateConfig{
+		MaxSize:    int64(len(testLine)),
+		MaxBackups: 2,
+		Compress:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.file.Close()
+
+	// the file is rotated before each write after the first
+	for i := 0; i < 4; i++ {
+		if _, err := f.Write([]byte(testLine)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// wait for the files rotated in the background to be processed
+	f.mill()
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, backups, 2)
+	for _, b := range backups {
+		assert.True(t, strings.HasSuffix(b.path, compressSuffix))
+	}
+
+	gz, err := os.Open(backups[0].path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testLine, string(data))
+
+	files, err := f.files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{path, backups[0].path, backups[1].path}, files)
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	f := &rotatingFile{
+		config: RotateConfig{
+			Interval: 24 * time.Hour,
+		},
+		size: 1,
+	}
+
+	now := time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)
+	f.lastWrite = now.Add(-time.Hour)
+	assert.False(t, f.shouldRotate(now, 1))
+
+	f.lastWrite = now.Add(-13 * time.Hour)
+	assert.True(t, f.shouldRotate(now, 1))
+
+	// empty files are not rotated
+	f.size = 0
+	assert.False(t, f.shouldRotate(now, 1))
+}
@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// artworkGetTimeout is the timeout of fetching remote artwork.
+const artworkGetTimeout = 30 * time.Second
+
+// maxArtworkSize is the maximum size of remote artwork, in bytes.
+const maxArtworkSize = 20 * 1024 * 1024
+
+// ValidateArtworkURL returns an error if artworkURL is not an absolute http or
+// https URL.
+func ValidateArtworkURL(artworkURL string) error {
+	u, err := url.Parse(artworkURL)
+	if err != nil {
+		return fmt.Errorf("invalid artwork URL %s: %s", artworkURL, err.Error())
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid artwork URL %s: must be an http or https URL", artworkURL)
+	}
+
+	return nil
+}
+
+func artworkCachePath(artworkURL string) string {
+	return filepath.Join(config.GetCachePath(), "artwork", utils.MD5FromString(artworkURL))
+}
+
+// CachedArtwork returns the path of the cached copy of the remote artwork at
+// artworkURL, which is fetched into the cache directory if it is not cached.
+func CachedArtwork(artworkURL string) (string, error) {
+	if config.GetCachePath() == "" {
+		return "", fmt.Errorf("cache path is not set")
+	}
+
+	path := artworkCachePath(artworkURL)
+	if exists, _ := utils.FileExists(path); exists {
+		return path, nil
+	}
+
+	data, err := fetchArtwork(artworkURL)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(path)
+	if err := utils.EnsureDirAll(dir); err != nil {
+		return "", err
+	}
+
+	// write to a temporary file first, so that concurrent requests never
+	// serve a partial file
+	f, err := ioutil.TempFile(dir, ".artwork-")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return path, nil
+}
+
+func fetchArtwork(artworkURL string) ([]byte, error) {
+	if err := ValidateArtworkURL(artworkURL); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout: artworkGetTimeout,
+	}
+
+	resp, err := client.Get(artworkURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching artwork %s: %s", artworkURL, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxArtworkSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxArtworkSize {
+		return nil, fmt.Errorf("artwork %s is larger than %d bytes", artworkURL, maxArtworkSize)
+	}
+
+	if contentType := http.DetectContentType(data); !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("artwork %s is not an image: %s", artworkURL, contentType)
+	}
+
+	return data, nil
+}
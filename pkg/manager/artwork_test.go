@@ -0,0 +1,66 @@
+package manager
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateArtworkURL(t *testing.T) {
+	assert.NoError(t, ValidateArtworkURL("https://example.com/cover.jpg"))
+	assert.NoError(t, ValidateArtworkURL("http://example.com/cover.jpg"))
+	assert.Error(t, ValidateArtworkURL("file:///etc/passwd"))
+	assert.Error(t, ValidateArtworkURL("/cover.jpg"))
+	assert.Error(t, ValidateArtworkURL("https://"))
+}
+
+func TestCachedArtwork(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artwork")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer config.Set(config.Cache, config.GetCachePath())
+	config.Set(config.Cache, dir)
+
+	image := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 32)...)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/cover.png":
+			w.Write(image)
+		case "/page.html":
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		path, err := CachedArtwork(server.URL + "/cover.png")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		data, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, image, data)
+	}
+
+	// the artwork is only fetched once
+	assert.Equal(t, 1, requests)
+
+	_, err = CachedArtwork(server.URL + "/page.html")
+	assert.Error(t, err)
+	_, err = CachedArtwork(server.URL + "/missing.png")
+	assert.Error(t, err)
+}
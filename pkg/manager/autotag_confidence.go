@@ -0,0 +1,193 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// nameTokens returns the words of a name, which are its runs of letters and
+// digits.
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// isWordRune returns true if r is part of a word rather than a separator.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// matchConfidence returns the confidence, from 0 to 1, that the path
+// matched by the query regex of name refers to the object with the name. It
+// is 0 if the name is not in the path as whole words. Names of several words
+// are matched with full confidence. Single words are matched with more
+// confidence the longer they are, and with more if they are in the path with
+// the same case as the name.
+func matchConfidence(name string, path string) float64 {
+	tokens := nameTokens(name)
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	re, err := regexp.Compile("(?i)" + getNameRegex(name))
+	if err != nil {
+		return 0
+	}
+
+	var ret float64
+	for _, loc := range re.FindAllStringIndex(path, -1) {
+		// the query regex only checks for ASCII word boundaries, so the name
+		// may be part of a longer word with accented letters
+		if r, _ := utf8.DecodeLastRuneInString(path[:loc[0]]); loc[0] > 0 && isWordRune(r) {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(path[loc[1]:]); loc[1] < len(path) && isWordRune(r) {
+			continue
+		}
+
+		match := path[loc[0]:loc[1]]
+		confidence := 1.0
+		if len(tokens) == 1 {
+			confidence = singleWordConfidence(tokens[0])
+			if strings.Join(nameTokens(match), "") == tokens[0] {
+				confidence += 0.1
+			}
+		}
+
+		if confidence > ret {
+			ret = confidence
+		}
+	}
+
+	if ret > 1 {
+		ret = 1
+	}
+	return ret
+}
+
+// singleWordConfidence returns the confidence of a match of a name of one
+// word regardless of case: 0.4 for three characters, rising by 0.1 for each
+// additional character to 0.9.
+func singleWordConfidence(word string) float64 {
+	ret := 0.1 * float64(utf8.RuneCountInString(word)+1)
+	if ret > 0.9 {
+		ret = 0.9
+	}
+	return ret
+}
+
+// autoTagOptions are the options shared by the auto tag tasks.
+type autoTagOptions struct {
+	// minConfidence is the minimum confidence of the matches of objects
+	// which do not have their own
+	minConfidence float64
+	// dryRun rolls back the changes instead of committing them
+	dryRun bool
+	// paths are the directories of the scenes to tag, or nil for all
+	// scenes
+	paths []string
+	// report collects the matches made and skipped, if not nil
+	report *autoTagReport
+}
+
+// filterScenes returns the scenes in the directories of the options whose
+// paths or titles match one of the names with at least the minimum
+// confidence, which is own if it is valid. The other matching scenes are
+// skipped as ambiguous.
+func (o autoTagOptions) filterScenes(kind string, names []string, own sql.NullFloat64, scenes []*models.Scene) []*models.Scene {
+	min := o.minConfidence
+	if own.Valid {
+		min = own.Float64
+	}
+
+	var ret []*models.Scene
+	for _, scene := range scenes {
+		if o.paths != nil && !inPaths(scene.Path, o.paths) {
+			continue
+		}
+
+		var confidence float64
+		for _, name := range names {
+			if c := matchConfidence(name, scene.Path); c > confidence {
+				confidence = c
+			}
+			if c := matchConfidence(name, scene.Title.String); c > confidence {
+				confidence = c
+			}
+		}
+
+		if confidence == 0 || confidence < min {
+			logger.Debugf("Skipping %s '%s' for '%s': confidence %.2f, minimum %.2f", kind, names[0], scene.Path, confidence, min)
+			o.report.addSkipped(kind, names[0], scene.Path, confidence, min)
+			continue
+		}
+
+		ret = append(ret, scene)
+	}
+
+	return ret
+}
+
+// finish commits the changes made in tx, or rolls them back on a dry run.
+func (o autoTagOptions) finish(tx *sqlx.Tx) error {
+	if o.dryRun {
+		return tx.Rollback()
+	}
+	return tx.Commit()
+}
+
+// autoTagReport collects the matches made by the auto tag tasks and the
+// ambiguous matches they skipped for being less confident than the minimum.
+type autoTagReport struct {
+	mutex   sync.Mutex
+	added   []string
+	skipped []string
+}
+
+func (r *autoTagReport) addMatch(format string, args ...interface{}) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.added = append(r.added, fmt.Sprintf(format, args...))
+}
+
+func (r *autoTagReport) addSkipped(kind string, name string, path string, confidence float64, min float64) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.skipped = append(r.skipped, fmt.Sprintf("Skipped %s '%s' for '%s': confidence %.2f, minimum %.2f", kind, name, path, confidence, min))
+}
+
+func (r *autoTagReport) summary(dryRun bool) string {
+	verb := "Made"
+	if dryRun {
+		verb = "[dry run] Would make"
+	}
+	return fmt.Sprintf("%s %d matches. Skipped %d ambiguous matches", verb, len(r.added), len(r.skipped))
+}
+
+// Report returns the summary of the auto tag tasks followed by the matches
+// made and the ambiguous matches skipped, one per line.
+func (r *autoTagReport) Report(dryRun bool) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	lines := append([]string{r.summary(dryRun)}, r.added...)
+	return strings.Join(append(lines, r.skipped...), "\n")
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchConfidence(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want float64
+	}{
+		{"Foo Bar", "/videos/foo.bar.scene.mp4", 1},
+		{"Foo Bar", "/videos/foobar.mp4", 1},
+		{"Foo Bar", "/videos/bar foo.mp4", 0},
+		{"Ann", "/videos/Ann - scene.mp4", 0.5},
+		{"Ann", "/videos/ann - scene.mp4", 0.4},
+		{"Ann", "/videos/annie.mp4", 0},
+		{"Ann", "/videos/Jéann.mp4", 0},
+		{"Ann", "/videos/Annè.mp4", 0},
+		{"Ann", "/videos/Jéann Ann.mp4", 0.5},
+		{"Jo", "/videos/Jo.mp4", 0.4},
+		{"Samantha", "/videos/samantha.mp4", 0.9},
+		{"Samantha", "/videos/Samantha.mp4", 1},
+		{"...", "/videos/....mp4", 0},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, matchConfidence(tt.name, tt.path), 0.001, "%s in %s", tt.name, tt.path)
+	}
+}
+
+func TestAutoTagFilterScenes(t *testing.T) {
+	scenes := []*models.Scene{
+		{ID: 1, Path: "/videos/Ann - scene.mp4"},
+		{ID: 2, Path: "/videos/ann - scene.mp4"},
+		{ID: 3, Path: "/videos/annie.mp4"},
+	}
+
+	report := &autoTagReport{}
+	opts := autoTagOptions{minConfidence: 0.5, report: report}
+
+	got := opts.filterScenes("performer", []string{"Ann"}, sql.NullFloat64{}, scenes)
+	assert.Equal(t, []*models.Scene{scenes[0]}, got)
+	assert.Len(t, report.skipped, 2)
+
+	// the override of the object replaces the setting
+	got = opts.filterScenes("performer", []string{"Ann"}, sql.NullFloat64{Float64: 0.4, Valid: true}, scenes)
+	assert.Equal(t, []*models.Scene{scenes[0], scenes[1]}, got)
+
+	// matches which are not whole words are skipped regardless of the minimum
+	got = opts.filterScenes("tag", []string{"Ann", "Annie"}, sql.NullFloat64{Float64: 0, Valid: true}, scenes)
+	assert.Equal(t, scenes, got)
+	got = opts.filterScenes("tag", []string{"Ann"}, sql.NullFloat64{Float64: 0, Valid: true}, scenes)
+	assert.Equal(t, []*models.Scene{scenes[0], scenes[1]}, got)
+}
+
+func TestAutoTagFilterScenesTitlesAndPaths(t *testing.T) {
+	scenes := []*models.Scene{
+		{ID: 1, Path: "/videos/a/scene.mp4", Title: sql.NullString{String: "Foo Bar in a scene", Valid: true}},
+		{ID: 2, Path: "/videos/a/b/foo.bar.mp4"},
+		{ID: 3, Path: "/videos/ab/foo.bar.mp4"},
+	}
+
+	opts := autoTagOptions{minConfidence: 1}
+
+	got := opts.filterScenes("performer", []string{"Foo Bar"}, sql.NullFloat64{}, scenes)
+	assert.Equal(t, scenes, got)
+
+	opts.paths = []string{"/videos/a"}
+	got = opts.filterScenes("performer", []string{"Foo Bar"}, sql.NullFloat64{}, scenes)
+	assert.Equal(t, []*models.Scene{scenes[0], scenes[1]}, got)
+}
+
+func TestInPaths(t *testing.T) {
+	tests := []struct {
+		path string
+		dirs []string
+		want bool
+	}{
+		{"/videos/a/scene.mp4", []string{"/videos/a"}, true},
+		{"/videos/a/b/scene.mp4", []string{"/videos/a/"}, true},
+		{"/videos/ab/scene.mp4", []string{"/videos/a"}, false},
+		{"/videos/scene.mp4", []string{"/videos/a", "/videos"}, true},
+		{"/videos/scene.mp4", nil, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, inPaths(tt.path, tt.dirs), "%s in %v", tt.path, tt.dirs)
+	}
+}
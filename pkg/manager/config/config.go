@@ -8,9 +8,11 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"regexp"
+	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -31,6 +33,18 @@ const Database = "database"
 const Exclude = "exclude"
 const ImageExclude = "image_exclude"
 
+// DisplayTitleCleanup are the regular expressions whose matches are replaced
+// with spaces in file names to make display titles.
+const DisplayTitleCleanup = "display_title_cleanup"
+
+// SceneFilenameTemplate is the template of the paths, relative to their
+// libraries, to which scene files are moved by file name updates.
+const SceneFilenameTemplate = "scene_filename_template"
+
+// DateInferencePriority are the sources from which missing scene dates are
+// inferred, in order of priority.
+const DateInferencePriority = "date_inference_priority"
+
 const VideoExtensions = "video_extensions"
 
 var defaultVideoExtensions = []string{"m4v", "mp4", "mov", "wmv", "avi", "mpg", "mpeg", "rmvb", "rm", "flv", "asf", "mkv", "webm"}
@@ -45,10 +59,47 @@ var defaultGalleryExtensions = []string{"zip", "cbz"}
 
 const CreateGalleriesFromFolders = "create_galleries_from_folders"
 
+// WatchLibraries is the config key used to determine if the libraries are
+// watched for changed files, which are scanned or cleaned as they change.
+const WatchLibraries = "watch_libraries"
+
+// GalleryFolderMinImages is the minimum number of images a folder must
+// directly contain for a gallery to be created from it.
+const GalleryFolderMinImages = "gallery_folder_min_images"
+const galleryFolderMinImagesDefault = 1
+
 // CalculateMD5 is the config key used to determine if MD5 should be calculated
 // for video files.
 const CalculateMD5 = "calculate_md5"
 
+// DeferScanHashing is the config key used to determine if the scan registers
+// new video files with only their oshash, leaving the MD5 checksums to be
+// calculated by a hash job after the scan.
+const DeferScanHashing = "defer_scan_hashing"
+
+// ScanMaxReadRate is the config key of the maximum rate, in MiB per second,
+// at which video files are read to calculate their MD5 checksums. Zero is
+// unlimited.
+const ScanMaxReadRate = "scan_max_read_rate"
+
+// ScanHashWhenIdle is the config key used to determine if the MD5 checksums
+// of video files are only calculated while no scenes are being streamed.
+const ScanHashWhenIdle = "scan_hash_when_idle"
+
+// CacheRemoteArtwork is the config key used to determine if the remote
+// artwork of scenes and movies is fetched and cached when first served,
+// instead of redirecting to its URL.
+const CacheRemoteArtwork = "cache_remote_artwork"
+
+// ChapterMarkersOnScan is the config key used to determine if the scan
+// creates scene markers from the chapters embedded in scene files.
+const ChapterMarkersOnScan = "chapter_markers_on_scan"
+
+// ChapterMarkerTag is the config key of the name of the primary tag of the
+// markers created from chapters. The tag is created if it does not exist.
+const ChapterMarkerTag = "chapter_marker_tag"
+const DefaultChapterMarkerTag = "Chapter"
+
 // VideoFileNamingAlgorithm is the config key used to determine what hash
 // should be used when generating and using generated files for scenes.
 const VideoFileNamingAlgorithm = "video_file_naming_algorithm"
@@ -61,6 +112,12 @@ const MaxStreamingTranscodeSize = "max_streaming_transcode_size"
 const ParallelTasks = "parallel_tasks"
 const parallelTasksDefault = 1
 
+// AutoTagMinConfidence is the minimum confidence, from 0 to 1, of the file
+// name matches which the auto tag task tags files with. Performers, studios
+// and tags may override it.
+const AutoTagMinConfidence = "auto_tag_min_confidence"
+const autoTagMinConfidenceDefault = 0.5
+
 const PreviewSegmentDuration = "preview_segment_duration"
 const previewSegmentDurationDefault = 0.75
 
@@ -101,6 +158,11 @@ const Language = "language"
 // this should be manually configured only
 const CustomServedFolders = "custom_served_folders"
 
+// AssetsPath is the config key of the directory of the assets overriding the
+// default images served in place of missing images, such as the default movie
+// image and the missing screenshot placeholder.
+const AssetsPath = "assets_path"
+
 // Interface options
 const MenuItems = "menu_items"
 
@@ -120,6 +182,141 @@ const LogOut = "logOut"
 const LogLevel = "logLevel"
 const LogAccess = "logAccess"
 
+// LogMaxSize is the size, in MiB, at which the log file is rotated. Zero
+// disables rotation by size.
+const LogMaxSize = "logMaxSize"
+const DefaultLogMaxSize = 10
+
+// LogRotateInterval is the number of hours after which the log file is
+// rotated. Zero disables rotation by time.
+const LogRotateInterval = "logRotateInterval"
+
+// LogMaxBackups is the number of rotated log files kept. Zero keeps all
+// rotated files.
+const LogMaxBackups = "logMaxBackups"
+const DefaultLogMaxBackups = 5
+
+// LogMaxAge is the number of days after which rotated log files are deleted.
+// Zero keeps rotated files regardless of age.
+const LogMaxAge = "logMaxAge"
+
+// LogCompress is true if rotated log files are compressed.
+const LogCompress = "logCompress"
+
+// TracingEndpoint is the URL of the OTLP/HTTP traces endpoint, such as
+// http://localhost:4318/v1/traces, which the spans of requests, resolvers,
+// queries and ffmpeg calls are exported to. Tracing is disabled if it is not
+// set.
+const TracingEndpoint = "tracing_endpoint"
+
+// Security options
+
+// EnablePlayground is true if the GraphQL playground is served. Defaults to
+// false when credentials are configured.
+const EnablePlayground = "enable_playground"
+
+// EnableIntrospection is true if GraphQL introspection queries are allowed.
+// Defaults to false when credentials are configured.
+const EnableIntrospection = "enable_introspection"
+
+// CORSAllowedOrigins is the list of origins allowed to make cross-origin
+// requests. All origins are allowed if empty.
+const CORSAllowedOrigins = "cors_allowed_origins"
+
+// CORSAllowedHeaders is the list of headers allowed in cross-origin
+// requests. All headers are allowed if empty.
+const CORSAllowedHeaders = "cors_allowed_headers"
+
+// TrustedProxyHeader is the request header holding the username of a user
+// authenticated by a trusted proxy. Proxy authentication is disabled if
+// empty.
+const TrustedProxyHeader = "trusted_proxy_header"
+
+// TrustedProxies is the list of addresses and CIDR ranges of the proxies
+// allowed to set the trusted proxy header.
+const TrustedProxies = "trusted_proxies"
+
+// OIDCIssuer is the issuer URL of the OpenID Connect identity provider used
+// to log in. OpenID Connect login is disabled if empty.
+const OIDCIssuer = "oidc_issuer"
+
+// OIDCClientID and OIDCClientSecret are the credentials of the client
+// registered with the identity provider.
+const OIDCClientID = "oidc_client_id"
+const OIDCClientSecret = "oidc_client_secret"
+
+// OIDCScopes are the scopes requested from the identity provider.
+const OIDCScopes = "oidc_scopes"
+
+var defaultOIDCScopes = []string{"openid", "profile", "email"}
+
+// OIDCGroupsClaim is the ID token claim holding the groups of the user.
+const OIDCGroupsClaim = "oidc_groups_claim"
+const defaultOIDCGroupsClaim = "groups"
+
+// OIDCAdminGroups and OIDCViewerGroups are the groups of the identity
+// provider given the admin and viewer roles.
+const OIDCAdminGroups = "oidc_admin_groups"
+const OIDCViewerGroups = "oidc_viewer_groups"
+
+// ApproximateCounts is true if find queries should return approximate counts
+// unless an exact count is requested.
+const ApproximateCounts = "approximate_counts"
+
+// ActivityRetentionDays is the number of days for which activity entries are
+// kept. Zero keeps entries indefinitely.
+const ActivityRetentionDays = "activity_retention_days"
+
+const DefaultActivityRetentionDays = 90
+
+// MinimumFreeSpace is the free disk space, in MiB, below which generation
+// tasks are paused. Zero, the default, disables the check.
+const MinimumFreeSpace = "minimum_free_space"
+
+const DefaultMinimumFreeSpace = 0
+
+// MaxIOJobs is the maximum number of IO-bound jobs, such as scans, running
+// at the same time.
+const MaxIOJobs = "max_io_jobs"
+
+const DefaultMaxIOJobs = 1
+
+// MaxCPUJobs is the maximum number of CPU-bound jobs, such as generates,
+// running at the same time.
+const MaxCPUJobs = "max_cpu_jobs"
+
+const DefaultMaxCPUJobs = 1
+
+// TranscodeCacheSize is the maximum size, in MiB, of the cache of live
+// transcode output. Zero disables the cache.
+const TranscodeCacheSize = "transcode_cache_size"
+
+const DefaultTranscodeCacheSize = 1024
+
+// BlobsStorage is where the images of movies, performers, studios, tags and
+// scene covers are stored.
+const BlobsStorage = "blobs_storage"
+
+// BlobsPath is the directory of images stored on the filesystem.
+const BlobsPath = "blobs_path"
+
+// Timezone is the IANA name of the timezone used when displaying timestamps
+// and interpreting dates. The server timezone is used if empty.
+const Timezone = "timezone"
+
+// QualityResolutionWeight, QualityBitrateWeight and QualityCodecWeight are
+// the weights of the resolution, bitrate and codec in the quality scores of
+// scenes.
+const QualityResolutionWeight = "quality_resolution_weight"
+const QualityBitrateWeight = "quality_bitrate_weight"
+const QualityCodecWeight = "quality_codec_weight"
+
+const DefaultQualityWeight = 1.0
+
+// QualityCodecScores maps video codecs to their scores, from 0 to 1, in the
+// quality scores of scenes.
+const QualityCodecScores = "quality_codec_scores"
+
 func Set(key string, value interface{}) {
 	viper.Set(key, value)
 }
@@ -199,6 +396,42 @@ func GetImageExcludes() []string {
 	return viper.GetStringSlice(ImageExclude)
 }
 
+// GetDisplayTitleCleanup returns the regular expressions whose matches are
+// replaced with spaces in file names to make display titles.
+func GetDisplayTitleCleanup() []string {
+	return viper.GetStringSlice(DisplayTitleCleanup)
+}
+
+// GetSceneFilenameTemplate returns the template of the paths to which scene
+// files are moved by file name updates. An empty string means that no
+// template is set.
+func GetSceneFilenameTemplate() string {
+	return viper.GetString(SceneFilenameTemplate)
+}
+
+// GetDateInferencePriority returns the sources from which missing scene dates
+// are inferred, in order of priority. Unknown sources are ignored. Defaults
+// to the file name, then the movies, then the file creation time.
+func GetDateInferencePriority() []models.DateInferenceSource {
+	var ret []models.DateInferenceSource
+	for _, v := range viper.GetStringSlice(DateInferencePriority) {
+		source := models.DateInferenceSource(v)
+		if source.IsValid() {
+			ret = append(ret, source)
+		}
+	}
+
+	if len(ret) == 0 {
+		return []models.DateInferenceSource{
+			models.DateInferenceSourceFilename,
+			models.DateInferenceSourceMovie,
+			models.DateInferenceSourceFileCreationTime,
+		}
+	}
+
+	return ret
+}
+
 func GetVideoExtensions() []string {
 	ret := viper.GetStringSlice(VideoExtensions)
 	if ret == nil {
@@ -227,6 +460,18 @@ func GetCreateGalleriesFromFolders() bool {
 	return viper.GetBool(CreateGalleriesFromFolders)
 }
 
+// IsWatchLibraries returns true if the libraries are watched for changed
+// files.
+func IsWatchLibraries() bool {
+	return viper.GetBool(WatchLibraries)
+}
+
+// GetGalleryFolderMinImages returns the minimum number of images a folder
+// must directly contain for a gallery to be created from it.
+func GetGalleryFolderMinImages() int {
+	return viper.GetInt(GalleryFolderMinImages)
+}
+
 func GetLanguage() string {
 	ret := viper.GetString(Language)
 
@@ -238,12 +483,160 @@ func GetLanguage() string {
 	return ret
 }
 
+// GetTimezone returns the IANA name of the configured timezone. An empty
+// string means that the server timezone is used.
+func GetTimezone() string {
+	return viper.GetString(Timezone)
+}
+
+// GetQualityScoreWeights returns the weights of the quality scores of
+// scenes.
+func GetQualityScoreWeights() utils.QualityScoreWeights {
+	viper.SetDefault(QualityResolutionWeight, DefaultQualityWeight)
+	viper.SetDefault(QualityBitrateWeight, DefaultQualityWeight)
+	viper.SetDefault(QualityCodecWeight, DefaultQualityWeight)
+
+	ret := utils.QualityScoreWeights{
+		Resolution:  viper.GetFloat64(QualityResolutionWeight),
+		Bitrate:     viper.GetFloat64(QualityBitrateWeight),
+		Codec:       viper.GetFloat64(QualityCodecWeight),
+		CodecScores: utils.DefaultQualityCodecScores,
+	}
+
+	if viper.IsSet(QualityCodecScores) {
+		var codecScores map[string]float64
+		if err := viper.UnmarshalKey(QualityCodecScores, &codecScores); err != nil {
+			logger.Warnf("Using default quality codec scores: %s", err.Error())
+		} else {
+			ret.CodecScores = codecScores
+		}
+	}
+
+	return ret
+}
+
+// GetActivityRetentionDays returns the number of days for which activity
+// entries are kept.
+func GetActivityRetentionDays() int {
+	viper.SetDefault(ActivityRetentionDays, DefaultActivityRetentionDays)
+	return viper.GetInt(ActivityRetentionDays)
+}
+
+// GetMinimumFreeSpace returns the free disk space, in MiB, below which
+// generation tasks are paused.
+func GetMinimumFreeSpace() int {
+	return viper.GetInt(MinimumFreeSpace)
+}
+
+// GetMaxIOJobs returns the maximum number of IO-bound jobs running at the
+// same time, which is at least 1.
+func GetMaxIOJobs() int {
+	if ret := viper.GetInt(MaxIOJobs); ret > 1 {
+		return ret
+	}
+	return 1
+}
+
+// GetMaxCPUJobs returns the maximum number of CPU-bound jobs running at the
+// same time, which is at least 1.
+func GetMaxCPUJobs() int {
+	if ret := viper.GetInt(MaxCPUJobs); ret > 1 {
+		return ret
+	}
+	return 1
+}
+
+// GetTranscodeCacheSize returns the maximum size, in MiB, of the cache of
+// live transcode output.
+func GetTranscodeCacheSize() int {
+	viper.SetDefault(TranscodeCacheSize, DefaultTranscodeCacheSize)
+	return viper.GetInt(TranscodeCacheSize)
+}
+
+// GetBlobsStorage returns where new images are stored. Defaults to the
+// database.
+func GetBlobsStorage() models.BlobsStorageType {
+	ret := models.BlobsStorageType(viper.GetString(BlobsStorage))
+	if !ret.IsValid() {
+		return models.BlobsStorageTypeDatabase
+	}
+
+	return ret
+}
+
+// GetBlobsPath returns the directory of images stored on the filesystem.
+// Defaults to the blobs directory next to the config file.
+func GetBlobsPath() string {
+	ret := viper.GetString(BlobsPath)
+	if ret == "" {
+		return filepath.Join(GetConfigPath(), "blobs")
+	}
+
+	return ret
+}
+
+// GetApproximateCounts returns true if find queries should return approximate
+// counts, which are faster to calculate for large libraries.
+func GetApproximateCounts() bool {
+	return viper.GetBool(ApproximateCounts)
+}
+
 // IsCalculateMD5 returns true if MD5 checksums should be generated for
 // scene video files.
 func IsCalculateMD5() bool {
 	return viper.GetBool(CalculateMD5)
 }
 
+// IsDeferScanHashing returns true if the scan should not calculate the MD5
+// checksums of new video files.
+func IsDeferScanHashing() bool {
+	return viper.GetBool(DeferScanHashing)
+}
+
+// GetScanMaxReadRate returns the maximum rate, in MiB per second, at which
+// video files are read to calculate their MD5 checksums, or zero if the rate
+// is unlimited.
+func GetScanMaxReadRate() float64 {
+	ret := viper.GetFloat64(ScanMaxReadRate)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// IsScanHashWhenIdle returns true if the MD5 checksums of video files should
+// only be calculated while no scenes are being streamed.
+func IsScanHashWhenIdle() bool {
+	return viper.GetBool(ScanHashWhenIdle)
+}
+
+// IsCacheRemoteArtwork returns true if remote artwork should be fetched and
+// cached when first served.
+func IsCacheRemoteArtwork() bool {
+	return viper.GetBool(CacheRemoteArtwork)
+}
+
+// IsChapterMarkersOnScan returns true if the scan should create scene markers
+// from the chapters embedded in scene files.
+func IsChapterMarkersOnScan() bool {
+	return viper.GetBool(ChapterMarkersOnScan)
+}
+
+// GetChapterMarkerTag returns the name of the primary tag of the markers
+// created from chapters.
+func GetChapterMarkerTag() string {
+	if !viper.IsSet(ChapterMarkerTag) {
+		return DefaultChapterMarkerTag
+	}
+	return viper.GetString(ChapterMarkerTag)
+}
+
+// GetAutoTagMinConfidence returns the minimum confidence of the file name
+// matches which the auto tag task tags files with.
+func GetAutoTagMinConfidence() float64 {
+	return viper.GetFloat64(AutoTagMinConfidence)
+}
+
 // GetVideoFileNamingAlgorithm returns what hash algorithm should be used for
 // naming generated scene video files.
 func GetVideoFileNamingAlgorithm() models.HashAlgorithm {
@@ -460,6 +853,12 @@ func GetCustomServedFolders() URLMap {
 	return viper.GetStringMapString(CustomServedFolders)
 }
 
+// GetAssetsPath returns the directory of the assets overriding the default
+// images, or an empty string if the defaults are not overridden.
+func GetAssetsPath() string {
+	return viper.GetString(AssetsPath)
+}
+
 // Interface options
 func GetMenuItems() []string {
 	if viper.IsSet(MenuItems) {
@@ -568,6 +967,48 @@ func GetLogLevel() string {
 	return value
 }
 
+// GetLogMaxSize returns the size, in MiB, at which the log file is rotated.
+func GetLogMaxSize() int {
+	viper.SetDefault(LogMaxSize, DefaultLogMaxSize)
+	return viper.GetInt(LogMaxSize)
+}
+
+// GetLogRotateInterval returns the number of hours after which the log file
+// is rotated.
+func GetLogRotateInterval() int {
+	return viper.GetInt(LogRotateInterval)
+}
+
+// GetLogMaxBackups returns the number of rotated log files kept.
+func GetLogMaxBackups() int {
+	viper.SetDefault(LogMaxBackups, DefaultLogMaxBackups)
+	return viper.GetInt(LogMaxBackups)
+}
+
+// GetLogMaxAge returns the number of days after which rotated log files are
+// deleted.
+func GetLogMaxAge() int {
+	return viper.GetInt(LogMaxAge)
+}
+
+// GetLogCompress returns true if rotated log files are compressed. Defaults
+// to true.
+func GetLogCompress() bool {
+	viper.SetDefault(LogCompress, true)
+	return viper.GetBool(LogCompress)
+}
+
+// GetLogRotateConfig returns the rotation settings of the log file.
+func GetLogRotateConfig() logger.RotateConfig {
+	return logger.RotateConfig{
+		MaxSize:    int64(GetLogMaxSize()) * 1024 * 1024,
+		Interval:   time.Duration(GetLogRotateInterval()) * time.Hour,
+		MaxBackups: GetLogMaxBackups(),
+		MaxAge:     time.Duration(GetLogMaxAge()) * 24 * time.Hour,
+		Compress:   GetLogCompress(),
+	}
+}
+
 // GetLogAccess returns true if http requests should be logged to the terminal.
 // HTTP requests are not logged to the log file. Defaults to true.
 func GetLogAccess() bool {
@@ -579,6 +1020,106 @@ func GetLogAccess() bool {
 	return ret
 }
 
+// GetTracingEndpoint returns the URL of the OTLP/HTTP endpoint which traces
+// are exported to, or an empty string if tracing is disabled.
+func GetTracingEndpoint() string {
+	return viper.GetString(TracingEndpoint)
+}
+
+// GetEnablePlayground returns true if the GraphQL playground should be
+// served. Defaults to true unless credentials are configured.
+func GetEnablePlayground() bool {
+	if viper.IsSet(EnablePlayground) {
+		return viper.GetBool(EnablePlayground)
+	}
+
+	return !HasCredentials()
+}
+
+// GetEnableIntrospection returns true if GraphQL introspection queries should
+// be allowed. Defaults to true unless credentials are configured.
+func GetEnableIntrospection() bool {
+	if viper.IsSet(EnableIntrospection) {
+		return viper.GetBool(EnableIntrospection)
+	}
+
+	return !HasCredentials()
+}
+
+// GetCORSAllowedOrigins returns the origins allowed to make cross-origin
+// requests. An empty list allows all origins.
+func GetCORSAllowedOrigins() []string {
+	return viper.GetStringSlice(CORSAllowedOrigins)
+}
+
+// GetCORSAllowedHeaders returns the headers allowed in cross-origin requests.
+// An empty list allows all headers.
+func GetCORSAllowedHeaders() []string {
+	return viper.GetStringSlice(CORSAllowedHeaders)
+}
+
+// GetTrustedProxyHeader returns the request header holding the username of a
+// user authenticated by a trusted proxy, or an empty string if proxy
+// authentication is disabled.
+func GetTrustedProxyHeader() string {
+	return viper.GetString(TrustedProxyHeader)
+}
+
+// GetTrustedProxies returns the addresses and CIDR ranges of the proxies
+// allowed to set the trusted proxy header.
+func GetTrustedProxies() []string {
+	return viper.GetStringSlice(TrustedProxies)
+}
+
+func GetOIDCIssuer() string {
+	return viper.GetString(OIDCIssuer)
+}
+
+func GetOIDCClientID() string {
+	return viper.GetString(OIDCClientID)
+}
+
+func GetOIDCClientSecret() string {
+	return viper.GetString(OIDCClientSecret)
+}
+
+// GetOIDCScopes returns the scopes requested from the identity provider,
+// which default to the openid, profile and email scopes.
+func GetOIDCScopes() []string {
+	ret := viper.GetStringSlice(OIDCScopes)
+	if len(ret) == 0 {
+		return defaultOIDCScopes
+	}
+
+	return ret
+}
+
+// GetOIDCGroupsClaim returns the ID token claim holding the groups of the
+// user, which defaults to groups.
+func GetOIDCGroupsClaim() string {
+	ret := viper.GetString(OIDCGroupsClaim)
+	if ret == "" {
+		return defaultOIDCGroupsClaim
+	}
+
+	return ret
+}
+
+func GetOIDCAdminGroups() []string {
+	return viper.GetStringSlice(OIDCAdminGroups)
+}
+
+func GetOIDCViewerGroups() []string {
+	return viper.GetStringSlice(OIDCViewerGroups)
+}
+
+// IsOIDCEnabled returns true if users may log in with the OpenID Connect
+// identity provider. The local credentials are required, so that the local
+// admin can still log in if the identity provider is unavailable.
+func IsOIDCEnabled() bool {
+	return GetOIDCIssuer() != "" && GetOIDCClientID() != "" && HasCredentials()
+}
+
 func IsValid() bool {
 	setPaths := viper.IsSet(Stash) && viper.IsSet(Cache) && viper.IsSet(Generated) && viper.IsSet(Metadata)
 
@@ -588,10 +1129,18 @@ func IsValid() bool {
 
 func setDefaultValues() {
 	viper.SetDefault(ParallelTasks, parallelTasksDefault)
+	viper.SetDefault(AutoTagMinConfidence, autoTagMinConfidenceDefault)
+	viper.SetDefault(GalleryFolderMinImages, galleryFolderMinImagesDefault)
 	viper.SetDefault(PreviewSegmentDuration, previewSegmentDurationDefault)
 	viper.SetDefault(PreviewSegments, previewSegmentsDefault)
 	viper.SetDefault(PreviewExcludeStart, previewExcludeStartDefault)
 	viper.SetDefault(PreviewExcludeEnd, previewExcludeEndDefault)
+
+	// the settings below are read by jobs, which may run at the same time,
+	// so their defaults are not set when they are read
+	viper.SetDefault(MinimumFreeSpace, DefaultMinimumFreeSpace)
+	viper.SetDefault(MaxIOJobs, DefaultMaxIOJobs)
+	viper.SetDefault(MaxCPUJobs, DefaultMaxCPUJobs)
 }
 
 // SetInitialConfig fills in missing required config fields
@@ -0,0 +1,371 @@
+package manager
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// destroyPreview collects what deleting objects would delete or unlink.
+// Each object and file is only added once.
+type destroyPreview struct {
+	models.DestroyPreview
+
+	deleteFile      bool
+	deleteGenerated bool
+	fileNamingAlgo  models.HashAlgorithm
+
+	seen map[string]bool
+}
+
+// add returns true if the object of the kind with the id was not added yet.
+func (p *destroyPreview) add(kind string, id int) bool {
+	key := kind + ":" + strconv.Itoa(id)
+	if p.seen[key] {
+		return false
+	}
+	p.seen[key] = true
+	return true
+}
+
+func (p *destroyPreview) addFiles(paths ...string) {
+	for _, path := range paths {
+		key := "file:" + path
+		if !p.seen[key] {
+			p.seen[key] = true
+			p.Files = append(p.Files, path)
+		}
+	}
+}
+
+func (p *destroyPreview) addImage(image *models.Image, deleteFile bool) {
+	if p.add("image", image.ID) {
+		p.Images = append(p.Images, image)
+	}
+	p.addImageFiles(image, deleteFile)
+}
+
+func (p *destroyPreview) addImageFiles(image *models.Image, deleteFile bool) {
+	if p.deleteGenerated {
+		p.addFiles(existingFiles([]string{instance.Paths.Generated.GetThumbnailPath(image.Checksum, models.DefaultGthumbWidth)})...)
+	}
+	if deleteFile {
+		p.addFiles(existingFiles([]string{image.Path})...)
+	}
+}
+
+func (p *destroyPreview) addUnlinkedScenes(scenes []*models.Scene) {
+	for _, s := range scenes {
+		if p.add("unlinked_scene", s.ID) {
+			p.UnlinkedScenes = append(p.UnlinkedScenes, s)
+		}
+	}
+}
+
+func (p *destroyPreview) addUnlinkedImages(images []*models.Image) {
+	for _, i := range images {
+		if p.add("unlinked_image", i.ID) {
+			p.UnlinkedImages = append(p.UnlinkedImages, i)
+		}
+	}
+}
+
+func (p *destroyPreview) addUnlinkedGalleries(galleries []*models.Gallery) {
+	for _, g := range galleries {
+		if p.add("unlinked_gallery", g.ID) {
+			p.UnlinkedGalleries = append(p.UnlinkedGalleries, g)
+		}
+	}
+}
+
+// PreviewDestroy returns what deleting the objects of the input with their
+// destroy mutation would delete or unlink, without deleting them.
+func PreviewDestroy(input models.DestroyPreviewInput) (*models.DestroyPreview, error) {
+	ids, err := utils.ParseIntSlice(input.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &destroyPreview{
+		deleteFile:      input.DeleteFile != nil && *input.DeleteFile,
+		deleteGenerated: input.DeleteGenerated != nil && *input.DeleteGenerated,
+		fileNamingAlgo:  config.GetVideoFileNamingAlgorithm(),
+		seen:            make(map[string]bool),
+	}
+
+	for _, id := range ids {
+		var err error
+		switch input.Type {
+		case models.DestroyTypeScene:
+			err = p.previewScene(id)
+		case models.DestroyTypeSceneMarker:
+			err = p.previewSceneMarker(id)
+		case models.DestroyTypeImage:
+			err = p.previewImage(id)
+		case models.DestroyTypeGallery:
+			err = p.previewGallery(id, ids)
+		case models.DestroyTypeMovie:
+			err = p.previewMovie(id)
+		case models.DestroyTypePerformer:
+			err = p.previewPerformer(id)
+		case models.DestroyTypeStudio:
+			err = p.previewStudio(id)
+		case models.DestroyTypeTag:
+			err = p.previewTag(id)
+		default:
+			err = fmt.Errorf("invalid destroy type %s", input.Type)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &p.DestroyPreview, nil
+}
+
+func (p *destroyPreview) previewScene(id int) error {
+	qb := models.NewSceneQueryBuilder()
+	scene, err := qb.Find(id)
+	if err != nil || scene == nil {
+		return err
+	}
+
+	mqb := models.NewSceneMarkerQueryBuilder()
+	markers, err := mqb.FindBySceneID(id, nil)
+	if err != nil {
+		return err
+	}
+	// the marker previews are in the generated directory of the scene
+	p.SceneMarkers = append(p.SceneMarkers, markers...)
+
+	gqb := models.NewGalleryQueryBuilder()
+	gallery, err := gqb.FindBySceneID(id, nil)
+	if err != nil {
+		return err
+	}
+	if gallery != nil {
+		p.addUnlinkedGalleries([]*models.Gallery{gallery})
+	}
+
+	if p.deleteGenerated {
+		p.addFiles(ExistingGeneratedSceneFiles(scene, p.fileNamingAlgo)...)
+	}
+	if p.deleteFile {
+		p.addFiles(existingFiles([]string{scene.Path})...)
+	}
+
+	return nil
+}
+
+func (p *destroyPreview) previewSceneMarker(id int) error {
+	qb := models.NewSceneMarkerQueryBuilder()
+	marker, err := qb.Find(id)
+	if err != nil || marker == nil {
+		return err
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scene, err := sqb.Find(int(marker.SceneID.Int64))
+	if err != nil {
+		return err
+	}
+
+	if scene != nil {
+		p.addFiles(ExistingSceneMarkerFiles(scene, int(marker.Seconds), p.fileNamingAlgo)...)
+	}
+	return nil
+}
+
+func (p *destroyPreview) previewImage(id int) error {
+	qb := models.NewImageQueryBuilder()
+	image, err := qb.Find(id)
+	if err != nil || image == nil {
+		return err
+	}
+
+	gqb := models.NewGalleryQueryBuilder()
+	galleries, err := gqb.FindByImageID(id, nil)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedGalleries(galleries)
+
+	p.addImageFiles(image, p.deleteFile)
+	return nil
+}
+
+// previewGallery adds what deleting the gallery with the galleries with
+// galleryIDs would delete. The images of zip galleries are deleted, as are
+// the images only in deleted galleries if the files are deleted.
+func (p *destroyPreview) previewGallery(id int, galleryIDs []int) error {
+	qb := models.NewGalleryQueryBuilder()
+	gallery, err := qb.Find(id, nil)
+	if err != nil || gallery == nil {
+		return err
+	}
+
+	if gallery.SceneID.Valid {
+		sqb := models.NewSceneQueryBuilder()
+		scene, err := sqb.Find(int(gallery.SceneID.Int64))
+		if err != nil {
+			return err
+		}
+		if scene != nil {
+			p.addUnlinkedScenes([]*models.Scene{scene})
+		}
+	}
+
+	if p.deleteFile && gallery.Path.Valid {
+		p.addFiles(existingFiles([]string{gallery.Path.String})...)
+	}
+
+	if !gallery.Zip && !p.deleteFile {
+		return nil
+	}
+
+	iqb := models.NewImageQueryBuilder()
+	images, err := iqb.FindByGalleryID(id)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		if gallery.Zip {
+			// the files of the images in zip galleries are the zip file
+			p.addImage(image, false)
+			continue
+		}
+
+		galleries, err := qb.FindByImageID(image.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		onlyInDeleted := true
+		for _, g := range galleries {
+			if !utils.IntInclude(galleryIDs, g.ID) {
+				onlyInDeleted = false
+				break
+			}
+		}
+
+		if onlyInDeleted {
+			p.addImage(image, true)
+		}
+	}
+
+	return nil
+}
+
+func (p *destroyPreview) previewMovie(id int) error {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindByMovieID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedScenes(scenes)
+	return nil
+}
+
+func (p *destroyPreview) previewPerformer(id int) error {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindByPerformerID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedScenes(scenes)
+
+	iqb := models.NewImageQueryBuilder()
+	images, err := iqb.FindByPerformerID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedImages(images)
+
+	gqb := models.NewGalleryQueryBuilder()
+	galleries, err := gqb.FindByPerformerID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedGalleries(galleries)
+
+	return nil
+}
+
+func (p *destroyPreview) previewStudio(id int) error {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindByStudioID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedScenes(scenes)
+
+	iqb := models.NewImageQueryBuilder()
+	images, err := iqb.FindByStudioID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedImages(images)
+
+	gqb := models.NewGalleryQueryBuilder()
+	galleries, err := gqb.FindByStudioID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedGalleries(galleries)
+
+	return nil
+}
+
+func (p *destroyPreview) previewTag(id int) error {
+	tqb := models.NewTagQueryBuilder()
+	tag, err := tqb.Find(id, nil)
+	if err != nil || tag == nil {
+		return err
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindByTagID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedScenes(scenes)
+
+	mqb := models.NewSceneMarkerQueryBuilder()
+	markers, err := mqb.FindByTagIDs([]int{id}, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	primaryMarkers := 0
+	for _, m := range markers {
+		if m.PrimaryTagID == id {
+			primaryMarkers++
+		} else if p.add("unlinked_scene_marker", m.ID) {
+			p.UnlinkedSceneMarkers = append(p.UnlinkedSceneMarkers, m)
+		}
+	}
+	if primaryMarkers > 0 {
+		p.Errors = append(p.Errors, fmt.Sprintf("tag %s is the primary tag of %d scene markers", tag.Name, primaryMarkers))
+	}
+
+	iqb := models.NewImageQueryBuilder()
+	images, err := iqb.FindByTagID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedImages(images)
+
+	gqb := models.NewGalleryQueryBuilder()
+	galleries, err := gqb.FindByTagID(id)
+	if err != nil {
+		return err
+	}
+	p.addUnlinkedGalleries(galleries)
+
+	return nil
+}
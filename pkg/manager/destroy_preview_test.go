@@ -0,0 +1,116 @@
+// +build integration
+
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewDestroyTag(t *testing.T) {
+	// the tags must not be the first tag, which the auto tag tests use
+	const name = "TestPreviewDestroyTag"
+
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	tqb := models.NewTagQueryBuilder()
+	tag, err := tqb.Create(*models.NewTag(name), tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating tag: %s", err.Error())
+	}
+	primaryTag, err := tqb.Create(*models.NewTag(name + " Primary"), tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating tag: %s", err.Error())
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scene, err := sqb.Create(models.Scene{
+		Path:     name + ".mp4",
+		Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating scene: %s", err.Error())
+	}
+
+	jqb := models.NewJoinsQueryBuilder()
+	if err := jqb.CreateScenesTags([]models.ScenesTags{{SceneID: scene.ID, TagID: tag.ID}}, tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error adding scene tag: %s", err.Error())
+	}
+
+	mqb := models.NewSceneMarkerQueryBuilder()
+	marker, err := mqb.Create(models.SceneMarker{
+		Title:        name,
+		PrimaryTagID: primaryTag.ID,
+		SceneID:      sql.NullInt64{Int64: int64(scene.ID), Valid: true},
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating scene marker: %s", err.Error())
+	}
+	if err := jqb.CreateSceneMarkersTags([]models.SceneMarkersTags{{SceneMarkerID: marker.ID, TagID: tag.ID}}, tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error adding scene marker tag: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+
+	// the auto tag tests expect the other scenes to have no tags
+	defer func() {
+		tx := database.DB.MustBeginTx(ctx, nil)
+		if err := mqb.Destroy(strconv.Itoa(marker.ID), tx); err != nil {
+			tx.Rollback()
+			t.Fatalf("Error destroying scene marker: %s", err.Error())
+		}
+		if err := sqb.Destroy(strconv.Itoa(scene.ID), tx); err != nil {
+			tx.Rollback()
+			t.Fatalf("Error destroying scene: %s", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Error committing: %s", err.Error())
+		}
+	}()
+
+	preview, err := PreviewDestroy(models.DestroyPreviewInput{
+		Type: models.DestroyTypeTag,
+		Ids:  []string{strconv.Itoa(tag.ID), strconv.Itoa(tag.ID)},
+	})
+	if err != nil {
+		t.Fatalf("Error previewing destroy: %s", err.Error())
+	}
+
+	// objects are only listed once
+	if assert.Len(t, preview.UnlinkedScenes, 1) {
+		assert.Equal(t, scene.ID, preview.UnlinkedScenes[0].ID)
+	}
+	if assert.Len(t, preview.UnlinkedSceneMarkers, 1) {
+		assert.Equal(t, marker.ID, preview.UnlinkedSceneMarkers[0].ID)
+	}
+	assert.Len(t, preview.Errors, 0)
+	assert.Len(t, preview.Files, 0)
+
+	// primary tags of scene markers cannot be deleted
+	preview, err = PreviewDestroy(models.DestroyPreviewInput{
+		Type: models.DestroyTypeTag,
+		Ids:  []string{strconv.Itoa(primaryTag.ID)},
+	})
+	if err != nil {
+		t.Fatalf("Error previewing destroy: %s", err.Error())
+	}
+
+	assert.Len(t, preview.UnlinkedSceneMarkers, 0)
+	assert.Len(t, preview.Errors, 1)
+}
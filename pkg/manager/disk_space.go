@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// freeSpacePollInterval is how often the free disk space is checked while a
+// job is paused.
+const freeSpacePollInterval = 5 * time.Second
+
+// checkFreeSpace returns an error if the free space on the disks containing
+// the generated directory or the database is below the configured minimum.
+func checkFreeSpace() error {
+	minimumMiB := config.GetMinimumFreeSpace()
+	if minimumMiB <= 0 {
+		return nil
+	}
+	minimum := uint64(minimumMiB) * 1024 * 1024
+
+	paths := []string{config.GetGeneratedPath(), filepath.Dir(config.GetDatabasePath())}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		free, err := utils.GetFreeDiskSpace(path)
+		if err != nil {
+			logger.Warnf("error getting free disk space of %s: %s", path, err.Error())
+			continue
+		}
+
+		if free < minimum {
+			return fmt.Errorf("%s free on the disk containing %s is below the minimum of %d MiB", formatFileSize(int64(free)), path, minimumMiB)
+		}
+	}
+
+	return nil
+}
+
+// waitForFreeSpace pauses the job while the free disk space is below the
+// configured minimum. Returns false if the job was stopped while paused.
+func (j *Job) waitForFreeSpace() bool {
+	err := checkFreeSpace()
+	if err == nil {
+		return true
+	}
+
+	logger.Warnf("Pausing %s: %s", j.Info().Type.String(), err.Error())
+	j.setMessage("Paused: " + err.Error())
+	defer j.setMessage("")
+
+	for err != nil {
+		if j.isStopping() {
+			logger.Info("Stopping due to user request")
+			return false
+		}
+
+		time.Sleep(freeSpacePollInterval)
+		err = checkFreeSpace()
+	}
+
+	logger.Infof("Resuming %s", j.Info().Type.String())
+	return true
+}
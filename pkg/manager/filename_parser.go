@@ -413,7 +413,7 @@ type sceneQueryer interface {
 }
 
 type tagQueryer interface {
-	FindByName(name string, tx *sqlx.Tx, nocase bool) (*models.Tag, error)
+	FindByNameOrAlias(name string, tx *sqlx.Tx, nocase bool) (*models.Tag, error)
 }
 
 type studioQueryer interface {
@@ -602,8 +602,8 @@ func (p *SceneFilenameParser) queryTag(tagName string) *models.Tag {
 		return ret
 	}
 
-	// match tag name exactly
-	ret, _ := p.tagQuery.FindByName(tagName, nil, true)
+	// match tag name or alias exactly
+	ret, _ := p.tagQuery.FindByNameOrAlias(tagName, nil, true)
 
 	// add result to cache
 	p.tagCache[tagName] = ret
@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMapperTokens(t *testing.T) {
+	compileREs()
+
+	mapper, err := newParseMapper("{studio}.{yy}.{mm}.{dd}.{performer}.{title}.{ext}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := mapper.parse(&models.Scene{Path: "/videos/Studio.21.03.04.Jane-Doe.The Title.mp4"})
+	if !assert.NotNil(t, h) {
+		return
+	}
+
+	assert.Equal(t, "Studio", h.studio)
+	assert.Equal(t, []string{"Jane-Doe"}, h.performers)
+	assert.Equal(t, "The Title", h.result.Title.String)
+	assert.Equal(t, "2021-03-04", h.result.Date.String)
+
+	// files which don't match the pattern are not parsed
+	assert.Nil(t, mapper.parse(&models.Scene{Path: "/videos/Studio.2021.mp4"}))
+
+	_, err = newParseMapper("{studio}.{unknown}", nil)
+	assert.Error(t, err)
+}
@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 )
 
@@ -15,3 +18,32 @@ func DeleteGalleryFile(gallery *models.Gallery) {
 		}
 	}
 }
+
+// isFolderGallery returns true if the gallery was created from a folder of
+// images by the scan. Its path is the path of the folder.
+func isFolderGallery(gallery *models.Gallery) bool {
+	return !gallery.Zip && gallery.Path.Valid
+}
+
+// countImagesInFolder returns the number of image files directly in the
+// folder which are not excluded from image scans.
+func countImagesInFolder(path string) int {
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return 0
+	}
+
+	ret := 0
+	imageExcludes := config.GetImageExcludes()
+	for _, file := range files {
+		if file.IsDir() || !isImage(file.Name()) {
+			continue
+		}
+		if matchFile(filepath.Join(path, file.Name()), imageExcludes) {
+			continue
+		}
+		ret++
+	}
+
+	return ret
+}
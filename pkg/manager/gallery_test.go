@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountImagesInFolder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gallery_folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer config.Set(config.ImageExtensions, config.GetImageExtensions())
+	defer config.Set(config.ImageExclude, config.GetImageExcludes())
+	config.Set(config.ImageExtensions, []string{"jpg", "png"})
+	config.Set(config.ImageExclude, []string{`skip\.png$`})
+
+	writeTestFiles(t, dir, []string{
+		"1.jpg",
+		"2.PNG",
+		"skip.png",
+		"notes.txt",
+		"sub/3.jpg",
+	})
+
+	assert.Equal(t, 2, countImagesInFolder(dir))
+	assert.Equal(t, 0, countImagesInFolder(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+var (
+	errEmptyFile     = errors.New("empty file")
+	errTruncatedFile = errors.New("truncated file")
+)
+
+// generatedFileExists returns true if the generated file at path exists and
+// is valid. Invalid files, such as those left empty or truncated by
+// interrupted tasks, are deleted so that they are generated again.
+func generatedFileExists(path string) bool {
+	exists, _ := utils.FileExists(path)
+	return exists && !removeInvalidGeneratedFile(path)
+}
+
+// removeInvalidGeneratedFile deletes the generated file at path and returns
+// true if it exists and is invalid.
+func removeInvalidGeneratedFile(path string) bool {
+	exists, _ := utils.FileExists(path)
+	if !exists {
+		return false
+	}
+
+	err := validateGeneratedFile(path)
+	if err == nil {
+		return false
+	}
+
+	logger.Warnf("Deleting invalid generated file %s: %s", path, err.Error())
+	if err := os.Remove(path); err != nil {
+		logger.Warnf("Could not delete file %s: %s", path, err.Error())
+	}
+
+	return true
+}
+
+// validateGeneratedFile returns an error if the generated file at path is
+// empty or, for the formats of previews and sprites, is not complete.
+func validateGeneratedFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	size := info.Size()
+	if size == 0 {
+		return errEmptyFile
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4":
+		return validateMP4(f, size)
+	case ".jpg", ".jpeg":
+		return validateJPEG(f, size)
+	case ".webp":
+		return validateWebP(f, size)
+	case ".vtt":
+		return validateVTT(f)
+	}
+
+	return nil
+}
+
+// validateMP4 walks the top-level boxes of the file, which must start with
+// ftyp, fit in the file and include moov. ffmpeg writes moov last, so files
+// of interrupted encodes don't have it.
+func validateMP4(r io.ReaderAt, size int64) error {
+	var offset int64
+	hasMoov := false
+	header := make([]byte, 16)
+	for offset < size {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return errTruncatedFile
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+
+		switch boxSize {
+		case 0:
+			// the box extends to the end of the file
+			boxSize = size - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return errTruncatedFile
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+
+		if offset == 0 && boxType != "ftyp" {
+			return fmt.Errorf("missing ftyp box")
+		}
+		if boxSize < headerSize || offset+boxSize > size {
+			return errTruncatedFile
+		}
+		if boxType == "moov" {
+			hasMoov = true
+		}
+
+		offset += boxSize
+	}
+
+	if !hasMoov {
+		return fmt.Errorf("missing moov box")
+	}
+
+	return nil
+}
+
+// validateJPEG checks the start and end of image markers of the file.
+func validateJPEG(r io.ReaderAt, size int64) error {
+	start := make([]byte, 3)
+	if _, err := r.ReadAt(start, 0); err != nil || !bytes.Equal(start, []byte{0xff, 0xd8, 0xff}) {
+		return fmt.Errorf("not a jpeg image")
+	}
+
+	end := make([]byte, 2)
+	if _, err := r.ReadAt(end, size-2); err != nil || !bytes.Equal(end, []byte{0xff, 0xd9}) {
+		return errTruncatedFile
+	}
+
+	return nil
+}
+
+// validateWebP checks the RIFF header of the file, whose size must fit in
+// the file.
+func validateWebP(r io.ReaderAt, size int64) error {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil || string(header[:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+		return fmt.Errorf("not a webp image")
+	}
+
+	if int64(binary.LittleEndian.Uint32(header[4:8]))+8 > size {
+		return errTruncatedFile
+	}
+
+	return nil
+}
+
+// validateVTT checks the signature of the file.
+func validateVTT(r io.ReaderAt) error {
+	header := make([]byte, 6)
+	if _, err := r.ReadAt(header, 0); err != nil || string(header) != "WEBVTT" {
+		return fmt.Errorf("not a vtt file")
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mp4Box(boxType string, payload []byte) []byte {
+	ret := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(ret, uint32(8+len(payload)))
+	copy(ret[4:], boxType)
+	return append(ret, payload...)
+}
+
+func webpFile(payload []byte) []byte {
+	ret := []byte("RIFF\x00\x00\x00\x00WEBP")
+	binary.LittleEndian.PutUint32(ret[4:], uint32(4+len(payload)))
+	return append(ret, payload...)
+}
+
+func TestValidateGeneratedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-generated-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ftyp := mp4Box("ftyp", []byte("isom\x00\x00\x02\x00"))
+	mdat := mp4Box("mdat", make([]byte, 32))
+	moov := mp4Box("moov", make([]byte, 16))
+	// ffmpeg writes a zero size mdat until the encode is finished
+	unfinishedMdat := append([]byte{0, 0, 0, 0}, "mdat"...)
+
+	webp := webpFile(make([]byte, 20))
+
+	tests := []struct {
+		name  string
+		data  []byte
+		valid bool
+	}{
+		{"empty.mp4", nil, false},
+		{"valid.mp4", concat(ftyp, mdat, moov), true},
+		{"faststart.mp4", concat(ftyp, moov, mdat), true},
+		{"unfinished.mp4", concat(ftyp, unfinishedMdat, make([]byte, 32)), false},
+		{"truncated.mp4", concat(ftyp, mdat, moov)[:60], false},
+		{"notmp4.mp4", concat(mdat, moov), false},
+		{"valid.jpg", jpg.Bytes(), true},
+		{"truncated.jpg", jpg.Bytes()[:jpg.Len()-10], false},
+		{"valid.webp", webp, true},
+		{"truncated.webp", webp[:len(webp)-4], false},
+		{"valid.vtt", []byte("WEBVTT\n\n"), true},
+		{"invalid.vtt", []byte("<html>"), false},
+		{"other.txt", []byte("data"), true},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := ioutil.WriteFile(path, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := validateGeneratedFile(path)
+		assert.Equal(t, tt.valid, err == nil, "%s: %v", tt.name, err)
+
+		assert.Equal(t, tt.valid, generatedFileExists(path), tt.name)
+		exists, _ := os.Stat(path)
+		assert.Equal(t, tt.valid, exists != nil, "%s should be deleted if invalid", tt.name)
+	}
+
+	assert.False(t, generatedFileExists(filepath.Join(dir, "missing.mp4")))
+	assert.False(t, removeInvalidGeneratedFile(filepath.Join(dir, "missing.mp4")))
+}
+
+func concat(parts ...[]byte) []byte {
+	var ret []byte
+	for _, p := range parts {
+		ret = append(ret, p...)
+	}
+	return ret
+}
@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/disintegration/imaging"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	phashColumns     = 5
+	phashRows        = 5
+	phashFrameWidth  = 160
+	phashFrameCount  = phashColumns * phashRows
+	phashTmpFileName = "phash_%s_%.3d.jpg"
+)
+
+// PhashGenerator calculates the perceptual hash of a video file from a
+// montage of frames taken at regular intervals of the video.
+type PhashGenerator struct {
+	Info *GeneratorInfo
+
+	VideoChecksum string
+}
+
+func NewPhashGenerator(videoFile ffmpeg.VideoFile, videoChecksum string) (*PhashGenerator, error) {
+	exists, err := utils.FileExists(videoFile.Path)
+	if !exists {
+		return nil, err
+	}
+	generator, err := newGeneratorInfo(videoFile)
+	if err != nil {
+		return nil, err
+	}
+	generator.ChunkCount = phashFrameCount
+	if err := generator.configure(); err != nil {
+		return nil, err
+	}
+
+	return &PhashGenerator{
+		Info:          generator,
+		VideoChecksum: videoChecksum,
+	}, nil
+}
+
+// Generate returns the perceptual hash of the video file.
+func (g *PhashGenerator) Generate() (int64, error) {
+	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
+
+	montage, err := g.generateMontage(&encoder)
+	if err != nil {
+		return 0, err
+	}
+
+	return utils.ImagePhash(montage), nil
+}
+
+func (g *PhashGenerator) generateMontage(encoder *ffmpeg.Encoder) (image.Image, error) {
+	var montage *image.NRGBA
+	var width, height int
+
+	stepSize := g.Info.VideoFile.Duration / float64(g.Info.ChunkCount)
+	for i := 0; i < g.Info.ChunkCount; i++ {
+		outputPath := instance.Paths.Generated.GetTmpPath(fmt.Sprintf(phashTmpFileName, g.VideoChecksum, i))
+		options := ffmpeg.ScreenshotOptions{
+			OutputPath: outputPath,
+			Time:       float64(i) * stepSize,
+			Width:      phashFrameWidth,
+		}
+		if err := encoder.Screenshot(g.Info.VideoFile, options); err != nil {
+			return nil, err
+		}
+
+		img, err := imaging.Open(outputPath)
+		os.Remove(outputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if montage == nil {
+			width = img.Bounds().Size().X
+			height = img.Bounds().Size().Y
+			montage = imaging.New(width*phashColumns, height*phashRows, color.NRGBA{})
+		}
+
+		x := width * (i % phashColumns)
+		y := height * (i / phashColumns)
+		montage = imaging.Paste(montage, img, image.Pt(x, y))
+	}
+
+	if montage == nil {
+		return nil, fmt.Errorf("failed to generate frames for %s", g.Info.VideoFile.Path)
+	}
+
+	return montage, nil
+}
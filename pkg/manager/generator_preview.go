@@ -97,8 +97,7 @@ func (g *PreviewGenerator) generateConcatFile() error {
 
 func (g *PreviewGenerator) generateVideo(encoder *ffmpeg.Encoder, fallback bool) error {
 	outputPath := filepath.Join(g.OutputDirectory, g.VideoFilename)
-	outputExists, _ := utils.FileExists(outputPath)
-	if !g.Overwrite && outputExists {
+	if !g.Overwrite && generatedFileExists(outputPath) {
 		return nil
 	}
 
@@ -131,8 +130,7 @@ func (g *PreviewGenerator) generateVideo(encoder *ffmpeg.Encoder, fallback bool)
 
 func (g *PreviewGenerator) generateImage(encoder *ffmpeg.Encoder) error {
 	outputPath := filepath.Join(g.OutputDirectory, g.ImageFilename)
-	outputExists, _ := utils.FileExists(outputPath)
-	if !g.Overwrite && outputExists {
+	if !g.Overwrite && generatedFileExists(outputPath) {
 		return nil
 	}
 
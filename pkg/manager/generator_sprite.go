@@ -157,11 +157,9 @@ func (g *SpriteGenerator) generateSpriteVTT(encoder *ffmpeg.Encoder) error {
 }
 
 func (g *SpriteGenerator) imageExists() bool {
-	exists, _ := utils.FileExists(g.ImageOutputPath)
-	return exists
+	return generatedFileExists(g.ImageOutputPath)
 }
 
 func (g *SpriteGenerator) vttExists() bool {
-	exists, _ := utils.FileExists(g.VTTOutputPath)
-	return exists
+	return generatedFileExists(g.VTTOutputPath)
 }
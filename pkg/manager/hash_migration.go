@@ -0,0 +1,158 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// HashMigration is the record of a migration of the generated files to the
+// naming of another hash. It maps the renamed files, so that the migration
+// can be rolled back.
+type HashMigration struct {
+	// Name identifies the migration, such as 20210102-150405-md5-to-oshash
+	Name       string                `json:"name"`
+	From       models.HashAlgorithm  `json:"from"`
+	To         models.HashAlgorithm  `json:"to"`
+	Time       time.Time             `json:"time"`
+	RolledBack bool                  `json:"rolled_back"`
+	Renames    []HashMigrationRename `json:"renames"`
+
+	mutex sync.Mutex
+}
+
+// HashMigrationRename is a generated file or directory renamed by a hash
+// migration.
+type HashMigrationRename struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func newHashMigration(to models.HashAlgorithm) *HashMigration {
+	from := models.HashAlgorithmMd5
+	if to == models.HashAlgorithmMd5 {
+		from = models.HashAlgorithmOshash
+	}
+
+	now := time.Now()
+	return &HashMigration{
+		Name: strings.ToLower(fmt.Sprintf("%s-%s-to-%s", now.Format("20060102-150405"), from, to)),
+		From: from,
+		To:   to,
+		Time: now,
+	}
+}
+
+func (m *HashMigration) addRename(oldName string, newName string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.Renames = append(m.Renames, HashMigrationRename{Old: oldName, New: newName})
+}
+
+func (m *HashMigration) summary(dryRun bool) string {
+	verb := "Renamed"
+	if dryRun {
+		verb = "[dry run] Would rename"
+	}
+	return fmt.Sprintf("%s %d generated files from %s to %s naming", verb, len(m.Renames), m.From, m.To)
+}
+
+// Report returns the summary of the migration followed by the renamed files,
+// one per line.
+func (m *HashMigration) Report(dryRun bool) string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	lines := []string{m.summary(dryRun)}
+	for _, r := range m.Renames {
+		lines = append(lines, fmt.Sprintf("%s -> %s", r.Old, r.New))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hashMigrationPath(name string) string {
+	return filepath.Join(instance.Paths.Generated.HashMigrations, name+".json")
+}
+
+// save writes the record of the migration into the hash migrations
+// directory.
+func (m *HashMigration) save() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.EnsureDirAll(instance.Paths.Generated.HashMigrations); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(hashMigrationPath(m.Name), data, 0644)
+}
+
+func loadHashMigration(path string) (*HashMigration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret HashMigration
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("error reading hash migration %s: %s", path, err.Error())
+	}
+
+	return &ret, nil
+}
+
+// FindHashMigration returns the record of the hash migration with the name,
+// or nil if it is not found.
+func FindHashMigration(name string) (*HashMigration, error) {
+	// names must not escape the hash migrations directory
+	if name == "" || filepath.Base(name) != name {
+		return nil, nil
+	}
+
+	ret, err := loadHashMigration(hashMigrationPath(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return ret, err
+}
+
+// HashMigrations returns the records of the hash migrations, the most
+// recent first.
+func HashMigrations() ([]*HashMigration, error) {
+	paths, err := filepath.Glob(filepath.Join(instance.Paths.Generated.HashMigrations, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*HashMigration
+	for _, path := range paths {
+		m, err := loadHashMigration(path)
+		if err != nil {
+			logger.Warn(err.Error())
+			continue
+		}
+		ret = append(ret, m)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Time.After(ret[j].Time)
+	})
+
+	return ret, nil
+}
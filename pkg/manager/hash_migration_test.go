@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestMigrateHashTask(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-migrate-hash-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer config.Set(config.Generated, config.GetGeneratedPath())
+	config.Set(config.Generated, dir)
+
+	previous := instance
+	defer func() { instance = previous }()
+	instance = &singleton{Paths: paths.NewPaths()}
+
+	writeTestFiles(t, dir, []string{
+		"screenshots/md5.jpg",
+		"screenshots/md5.mp4",
+		"markers/md5/10.mp4",
+		// existing files are not replaced
+		"vtt/md5_thumbs.vtt",
+		"vtt/oshash_thumbs.vtt",
+	})
+
+	scene := &models.Scene{
+		Checksum: sql.NullString{String: "md5", Valid: true},
+		OSHash:   sql.NullString{String: "oshash", Valid: true},
+	}
+
+	run := func(dryRun bool) *HashMigration {
+		migration := newHashMigration(models.HashAlgorithmOshash)
+		task := MigrateHashTask{Scene: scene, fileNamingAlgorithm: models.HashAlgorithmOshash, dryRun: dryRun, migration: migration}
+		var wg sync.WaitGroup
+		wg.Add(1)
+		task.Start(&wg)
+		return migration
+	}
+
+	before := listTestFiles(t, dir)
+	migration := run(true)
+	assert.Len(t, migration.Renames, 3)
+	assert.Equal(t, before, listTestFiles(t, dir), "dry run renamed files")
+
+	migration = run(false)
+	assert.Equal(t, models.HashAlgorithmMd5, migration.From)
+	assert.Equal(t, []string{
+		"markers/oshash/10.mp4",
+		"screenshots/oshash.jpg",
+		"screenshots/oshash.mp4",
+		"vtt/md5_thumbs.vtt",
+		"vtt/oshash_thumbs.vtt",
+	}, listTestFiles(t, dir))
+	assert.Equal(t, HashMigrationRename{
+		Old: filepath.Join(dir, "markers", "md5"),
+		New: filepath.Join(dir, "markers", "oshash"),
+	}, migration.Renames[0])
+
+	if err := migration.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := FindHashMigration(migration.Name)
+	assert.Nil(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, migration.Renames, found.Renames)
+		assert.Equal(t, models.HashAlgorithmOshash, found.To)
+	}
+
+	migrations, err := HashMigrations()
+	assert.Nil(t, err)
+	assert.Len(t, migrations, 1)
+
+	found, err = FindHashMigration("../" + migration.Name)
+	assert.Nil(t, err)
+	assert.Nil(t, found)
+}
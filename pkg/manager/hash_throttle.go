@@ -0,0 +1,138 @@
+package manager
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// streamIdleDelay is the time after the last stream request ended during
+// which scenes are considered to still be streamed, since players pause
+// between their range requests.
+const streamIdleDelay = 30 * time.Second
+
+// idlePollInterval is how often hashing waiting for playback to end checks
+// whether scenes are still streamed.
+const idlePollInterval = time.Second
+
+// hashReadSize is the maximum number of bytes read at once by throttled
+// hashing.
+const hashReadSize = 1024 * 1024
+
+var errHashingStopped = errors.New("stopped while waiting to read")
+
+var playback struct {
+	mutex   sync.Mutex
+	active  int
+	lastEnd time.Time
+}
+
+// StreamStarted records that a stream request started, so that hashing can
+// wait for playback to end. It must be followed by StreamEnded.
+func StreamStarted() {
+	playback.mutex.Lock()
+	defer playback.mutex.Unlock()
+	playback.active++
+}
+
+// StreamEnded records that a stream request ended.
+func StreamEnded() {
+	playback.mutex.Lock()
+	defer playback.mutex.Unlock()
+	playback.active--
+	playback.lastEnd = time.Now()
+}
+
+// isStreaming returns true if scenes are being streamed.
+func isStreaming() bool {
+	playback.mutex.Lock()
+	defer playback.mutex.Unlock()
+	return playback.active > 0 || time.Since(playback.lastEnd) < streamIdleDelay
+}
+
+// hashThrottle limits the rate at which video files are read to calculate
+// their checksums. The rate is shared by all the files hashed concurrently.
+type hashThrottle struct {
+	mutex sync.Mutex
+	// next is the time at which the next read may start
+	next time.Time
+}
+
+var videoHashThrottle hashThrottle
+
+// wait blocks until n bytes may be read at the configured maximum read rate
+// and, if only hashing while idle, while scenes are streamed. Returns
+// errHashingStopped if the job j was stopped while waiting.
+func (t *hashThrottle) wait(j *Job, n int) error {
+	if config.IsScanHashWhenIdle() && isStreaming() {
+		if j != nil {
+			j.setMessage("Waiting for playback to end")
+			defer j.setMessage("")
+		}
+
+		for isStreaming() {
+			if j != nil && j.isStopping() {
+				return errHashingStopped
+			}
+			time.Sleep(idlePollInterval)
+		}
+	}
+
+	rate := config.GetScanMaxReadRate()
+	if rate <= 0 {
+		return nil
+	}
+
+	t.mutex.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(time.Duration(float64(n) / (rate * 1024 * 1024) * float64(time.Second)))
+	t.mutex.Unlock()
+
+	time.Sleep(delay)
+	return nil
+}
+
+// throttledReader is a reader which waits for the throttle before each read.
+type throttledReader struct {
+	r        io.Reader
+	throttle *hashThrottle
+	job      *Job
+}
+
+func (r throttledReader) Read(p []byte) (int, error) {
+	if len(p) > hashReadSize {
+		p = p[:hashReadSize]
+	}
+
+	if err := r.throttle.wait(r.job, len(p)); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
+
+// videoMD5FromFilePath returns the MD5 checksum of the video file, reading
+// it at the configured maximum read rate and, if only hashing while idle,
+// while no scenes are streamed.
+func videoMD5FromFilePath(j *Job, path string) (string, error) {
+	if config.GetScanMaxReadRate() <= 0 && !config.IsScanHashWhenIdle() {
+		return utils.MD5FromFilePath(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return utils.MD5FromReader(throttledReader{r: f, throttle: &videoHashThrottle, job: j})
+}
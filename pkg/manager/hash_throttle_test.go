@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func TestIsStreaming(t *testing.T) {
+	if isStreaming() {
+		t.Error("isStreaming() = true before any stream")
+	}
+
+	StreamStarted()
+	if !isStreaming() {
+		t.Error("isStreaming() = false while streaming")
+	}
+
+	StreamEnded()
+	if !isStreaming() {
+		t.Errorf("isStreaming() = false within %s of the end of a stream", streamIdleDelay)
+	}
+
+	playback.mutex.Lock()
+	playback.lastEnd = time.Now().Add(-streamIdleDelay)
+	playback.mutex.Unlock()
+	if isStreaming() {
+		t.Errorf("isStreaming() = true %s after the end of a stream", streamIdleDelay)
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	defer config.Set(config.ScanMaxReadRate, config.GetScanMaxReadRate())
+	// 4 MB/s
+	config.Set(config.ScanMaxReadRate, 4)
+
+	data := bytes.Repeat([]byte{1}, 2*1024*1024)
+	want, err := utils.MD5FromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var throttle hashThrottle
+	start := time.Now()
+	got, err := utils.MD5FromReader(throttledReader{r: bytes.NewReader(data), throttle: &throttle})
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("checksum = %s, want %s", got, want)
+	}
+
+	// the last read is not waited for
+	const minElapsed = 400 * time.Millisecond
+	if elapsed < minElapsed {
+		t.Errorf("reading 2MB at 4MB/s took %s, want at least %s", elapsed, minElapsed)
+	}
+}
@@ -0,0 +1,86 @@
+package manager
+
+import (
+	"sync"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+// jobClass is the concurrency class of a job. Jobs of different classes run
+// at the same time, up to the limit of running jobs of each class.
+type jobClass int
+
+const (
+	// jobClassIO jobs, such as scans, mostly read files and write to the
+	// database.
+	jobClassIO jobClass = iota
+	// jobClassCPU jobs, such as generates, mostly transcode or compute
+	// hashes.
+	jobClassCPU
+	// jobClassExclusive jobs, such as imports and migrations, change the
+	// database or the library as a whole, so run alone.
+	jobClassExclusive
+)
+
+// jobClassOf returns the concurrency class of jobs of type t.
+func jobClassOf(t JobStatus) jobClass {
+	switch t {
+	case Generate, DuplicateImages:
+		return jobClassCPU
+	case Import, Export, Migrate, MigrateBlobs, Backup, Clean, CleanGenerated, Sync, CheckConsistency:
+		return jobClassExclusive
+	}
+
+	return jobClassIO
+}
+
+// limit returns the maximum number of running jobs of the class.
+func (c jobClass) limit() int {
+	switch c {
+	case jobClassIO:
+		return config.GetMaxIOJobs()
+	case jobClassCPU:
+		return config.GetMaxCPUJobs()
+	}
+
+	return 1
+}
+
+// class returns the concurrency class of the job.
+func (j *Job) class() jobClass {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return jobClassOf(j.jobType)
+}
+
+// generatedTmpDir counts the users of the temporary directory of generated
+// files. Scans and generates may run at the same time, so the directory is
+// only removed once the last of them is done with it.
+var generatedTmpDir struct {
+	mutex sync.Mutex
+	users int
+}
+
+// acquireGeneratedTmpDir creates the temporary directory of generated files
+// if needed. It must be followed by releaseGeneratedTmpDir.
+func acquireGeneratedTmpDir() {
+	generatedTmpDir.mutex.Lock()
+	defer generatedTmpDir.mutex.Unlock()
+
+	if generatedTmpDir.users == 0 {
+		instance.Paths.Generated.EnsureTmpDir()
+	}
+	generatedTmpDir.users++
+}
+
+// releaseGeneratedTmpDir removes the temporary directory of generated files
+// if no other task is using it.
+func releaseGeneratedTmpDir() {
+	generatedTmpDir.mutex.Lock()
+	defer generatedTmpDir.mutex.Unlock()
+
+	generatedTmpDir.users--
+	if generatedTmpDir.users == 0 {
+		instance.Paths.Generated.RemoveTmpDir()
+	}
+}
@@ -0,0 +1,46 @@
+package manager
+
+import (
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// checkpoint is called by scan, hash and generate jobs before the task at
+// position pos of their tasks. If the job has been paused, or interrupted to
+// run a job of higher priority, the job yields: pos is saved and false is
+// returned, so that the job returns once its running tasks are done, leaving
+// the queue to the other jobs. The job runs again once resumed and the first
+// ready job which can run, and continues from the position returned by
+// resumePosition. It blocks while free disk space is low. Returns false as
+// well if the job was stopped.
+func (j *Job) checkpoint(pos int) bool {
+	if j.isStopping() {
+		return false
+	}
+
+	if j.yield(pos) {
+		return false
+	}
+
+	return j.waitForFreeSpace()
+}
+
+// yield saves pos as the position to continue from and returns true if the
+// job has been paused or interrupted.
+func (j *Job) yield(pos int) bool {
+	j.mutex.Lock()
+	paused := j.paused
+	if !paused && !j.interrupted {
+		j.mutex.Unlock()
+		return false
+	}
+	j.yielded = true
+	j.resumePos = pos
+	j.mutex.Unlock()
+
+	if paused {
+		logger.Infof("Pausing %s", j.Info().Type.String())
+	} else {
+		logger.Infof("Interrupting %s to run a job of higher priority", j.Info().Type.String())
+	}
+	return true
+}
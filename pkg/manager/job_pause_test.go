@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestJobPause(t *testing.T) {
+	j := &Job{jobType: Clean, state: models.JobStateRunning}
+	assert.False(t, j.Pause())
+
+	j = &Job{jobType: Scan, state: models.JobStateFinished}
+	assert.False(t, j.Pause())
+
+	j = &Job{jobType: Scan, state: models.JobStateReady}
+	assert.True(t, j.Pause())
+	assert.True(t, j.Resume())
+
+	j = &Job{jobType: Scan, state: models.JobStateRunning}
+	assert.False(t, j.Resume())
+	assert.True(t, j.Pause())
+	assert.True(t, j.IsPaused())
+	assert.True(t, j.Resume())
+	assert.False(t, j.IsPaused())
+}
+
+// checkpointTestExec returns a job exec running tasks positions, checkpointing
+// before each of them from the resume position, and sending the positions it
+// ran to ran. Each task blocks until it is released.
+func checkpointTestExec(positions int, ran chan<- int, release <-chan struct{}) func(j *Job) {
+	return func(j *Job) {
+		for i := j.resumePosition(); i < positions; i++ {
+			if !j.checkpoint(i) {
+				return
+			}
+
+			<-release
+			ran <- i
+		}
+	}
+}
+
+func receivePosition(t *testing.T, ran <-chan int) int {
+	t.Helper()
+
+	select {
+	case i := <-ran:
+		return i
+	case <-time.After(jobTestTimeout):
+		t.Fatal("no task was run")
+		return -1
+	}
+}
+
+func TestJobPauseCheckpoint(t *testing.T) {
+	q := newJobQueue()
+
+	ran := make(chan int)
+	release := make(chan struct{})
+	j := q.add(Scan, checkpointTestExec(4, ran, release))
+	waitForState(t, j, models.JobStateRunning)
+
+	release <- struct{}{}
+	assert.Equal(t, 0, receivePosition(t, ran))
+
+	// the job yields at the checkpoint before task 1, and leaves the queue
+	// to the jobs after it
+	assert.True(t, j.Pause())
+	release <- struct{}{}
+	assert.Equal(t, 1, receivePosition(t, ran))
+	waitForState(t, j, models.JobStateReady)
+
+	info := j.Info()
+	assert.True(t, info.Paused)
+	assert.Equal(t, "Paused", info.Message)
+	assert.Equal(t, 2, j.resumePosition())
+	assert.Nil(t, info.EndTime)
+
+	other := q.add(Generate, func(j *Job) {})
+	other.Wait()
+	assert.Equal(t, models.JobStateReady, j.Info().State)
+	assert.Equal(t, j, q.Paused())
+
+	// the resumed job continues from the checkpoint it yielded at
+	assert.True(t, j.Resume())
+	waitForState(t, j, models.JobStateRunning)
+	assert.Nil(t, q.Paused())
+
+	go func() {
+		for range ran {
+		}
+	}()
+	close(release)
+	j.Wait()
+
+	assert.Equal(t, models.JobStateFinished, j.Info().State)
+	assert.False(t, j.Info().Paused)
+}
+
+func TestJobPauseStop(t *testing.T) {
+	q := newJobQueue()
+
+	blocker := make(chan struct{})
+	running := q.add(Clean, func(j *Job) {
+		<-blocker
+	})
+	ran := false
+	queued := q.add(Generate, func(j *Job) {
+		ran = true
+	})
+
+	// a paused queued job is cancelled without running
+	assert.True(t, queued.Pause())
+	assert.True(t, q.Stop(queued.ID))
+	assert.Equal(t, models.JobStateCancelled, queued.Info().State)
+	assert.False(t, queued.IsPaused())
+
+	close(blocker)
+	running.Wait()
+	assert.False(t, ran)
+}
@@ -0,0 +1,792 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/tracing"
+)
+
+// maxFinishedJobs is the number of finished and cancelled jobs kept in the
+// queue, so that clients can see the outcome of recent jobs.
+const maxFinishedJobs = 10
+
+// jobUpdateInterval is the minimum time between the updates published for
+// the progress and subtasks of a job.
+const jobUpdateInterval = time.Second
+
+// Job is a job of the job queue, such as a scan or a generate.
+type Job struct {
+	ID int
+
+	// priority orders the ready jobs of the queue
+	priority int
+	exec     func(j *Job)
+	queue    *JobQueue
+	done     chan struct{}
+	// span is the span of the running job, which is the parent of the
+	// spans of its subtasks
+	span *tracing.Span
+
+	// mutex guards the fields below, which are shared between the running
+	// job and the API
+	mutex    sync.RWMutex
+	jobType  JobStatus
+	state    models.JobState
+	progress float64
+	upTo     int
+	total    int
+	message  string
+	subTasks []string
+	paused   bool
+	// interrupted is true if the running job is asked to yield to a job of
+	// higher priority at its next checkpoint
+	interrupted bool
+	// yielded is true if the job returned at a checkpoint to continue later,
+	// rather than finishing
+	yielded bool
+	// resumePos is the position saved at the checkpoint the job last yielded
+	// at
+	resumePos     int
+	addTime       time.Time
+	startTime     time.Time
+	endTime       time.Time
+	lastPublished time.Time
+}
+
+// JobInfo is a snapshot of the state of a job.
+type JobInfo struct {
+	ID       int
+	Type     JobStatus
+	State    models.JobState
+	Progress float64
+	Message  string
+	SubTasks []string
+	Paused   bool
+	Priority int
+	AddTime  time.Time
+	// StartTime and EndTime are nil until the job is started and finished
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// Info returns a snapshot of the state of the job.
+func (j *Job) Info() JobInfo {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	ret := JobInfo{
+		ID:       j.ID,
+		Type:     j.jobType,
+		State:    j.state,
+		Progress: j.progress,
+		Message:  j.message,
+		SubTasks: append([]string(nil), j.subTasks...),
+		Paused:   j.paused,
+		Priority: j.priority,
+		AddTime:  j.addTime,
+	}
+	if !j.startTime.IsZero() {
+		t := j.startTime
+		ret.StartTime = &t
+	}
+	if !j.endTime.IsZero() {
+		t := j.endTime
+		ret.EndTime = &t
+	}
+
+	return ret
+}
+
+// Wait blocks until the job is finished or cancelled.
+func (j *Job) Wait() {
+	<-j.done
+}
+
+func (j *Job) getState() models.JobState {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.state
+}
+
+// isStopping returns true if the user has requested that the job stop.
+func (j *Job) isStopping() bool {
+	return j.getState() == models.JobStateStopping
+}
+
+// canCheckpoint returns true if jobs of type t call checkpoint before each
+// of their tasks, so can be paused and interrupted.
+func canCheckpoint(t JobStatus) bool {
+	return t == Scan || t == Hash || t == Generate
+}
+
+// Pause pauses the scan, hash or generate job. A running job yields at its
+// next checkpoint, leaving the queue to the other jobs, and a queued job does
+// not run until it is resumed.
+func (j *Job) Pause() bool {
+	j.mutex.Lock()
+	if (j.state != models.JobStateRunning && j.state != models.JobStateReady) || !canCheckpoint(j.jobType) {
+		j.mutex.Unlock()
+		return false
+	}
+	j.paused = true
+	j.mutex.Unlock()
+
+	j.publish(true)
+	return true
+}
+
+// Resume resumes the paused job, which continues from the checkpoint it was
+// paused at once it is the first ready job which can run.
+func (j *Job) Resume() bool {
+	j.mutex.Lock()
+	wasPaused := j.paused
+	j.paused = false
+	if wasPaused && j.state == models.JobStateReady {
+		j.message = ""
+	}
+	j.mutex.Unlock()
+
+	if !wasPaused {
+		return false
+	}
+
+	j.publish(true)
+	if j.queue != nil {
+		j.queue.signal()
+	}
+	return true
+}
+
+// interrupt asks the running scan, hash or generate job to yield at its next
+// checkpoint, so that a job of higher priority can run.
+func (j *Job) interrupt() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.state == models.JobStateRunning && canCheckpoint(j.jobType) {
+		j.interrupted = true
+	}
+}
+
+// hasYielded returns true if the job returned at a checkpoint, so that it
+// continues later.
+func (j *Job) hasYielded() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.yielded
+}
+
+// resumePosition returns the position saved at the checkpoint the job last
+// yielded at, from which the job continues, or 0 if it has not yielded.
+func (j *Job) resumePosition() int {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.resumePos
+}
+
+// continueWith sets the function run in place of the exec of the job when
+// it continues after yielding, and resets the saved position. Jobs going on
+// to another phase, such as scans going on to hash the scanned files, use it
+// to continue from that phase.
+func (j *Job) continueWith(exec func(j *Job)) {
+	j.exec = exec
+
+	j.mutex.Lock()
+	j.resumePos = 0
+	j.mutex.Unlock()
+}
+
+// IsPaused returns true if the job has been paused.
+func (j *Job) IsPaused() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.paused
+}
+
+// setType changes the type of the running job, such as when a scan goes on
+// to hash the scanned files.
+func (j *Job) setType(t JobStatus) {
+	j.mutex.Lock()
+	j.jobType = t
+	j.mutex.Unlock()
+	j.publish(true)
+}
+
+func (j *Job) setProgress(upTo int, total int) {
+	j.mutex.Lock()
+	j.upTo = upTo
+	j.total = total
+	if total == 0 {
+		j.progress = 1
+	} else {
+		j.progress = float64(upTo) / float64(total)
+	}
+	j.mutex.Unlock()
+	j.publish(false)
+}
+
+func (j *Job) setProgressPercent(progress float64) {
+	j.mutex.Lock()
+	changed := progress != j.progress
+	j.progress = progress
+	j.mutex.Unlock()
+
+	if changed {
+		j.publish(false)
+	}
+}
+
+func (j *Job) incrementProgress() {
+	j.mutex.RLock()
+	upTo, total := j.upTo, j.total
+	j.mutex.RUnlock()
+	j.setProgress(upTo+1, total)
+}
+
+func (j *Job) indefiniteProgress() {
+	j.setProgressPercent(-1)
+}
+
+// setMessage sets the message of the job, such as why it is paused.
+func (j *Job) setMessage(message string) {
+	j.mutex.Lock()
+	j.message = message
+	j.mutex.Unlock()
+	j.publish(true)
+}
+
+// runSubTask runs fn, listing description in the subtasks of the job while
+// it runs.
+func (j *Job) runSubTask(description string, fn func()) {
+	j.mutex.Lock()
+	j.subTasks = append(j.subTasks, description)
+	j.mutex.Unlock()
+	j.publish(false)
+
+	span := j.span.StartChild(description)
+	defer span.End()
+
+	defer func() {
+		j.mutex.Lock()
+		for i, s := range j.subTasks {
+			if s == description {
+				j.subTasks = append(j.subTasks[:i], j.subTasks[i+1:]...)
+				break
+			}
+		}
+		j.mutex.Unlock()
+		j.publish(false)
+	}()
+
+	fn()
+}
+
+// publish notifies the subscribers of the queue that the job was updated.
+// Unless force is true, updates within jobUpdateInterval of the previous
+// one are not published.
+func (j *Job) publish(force bool) {
+	if j.queue == nil {
+		return
+	}
+
+	j.mutex.Lock()
+	now := time.Now()
+	if !force && now.Sub(j.lastPublished) < jobUpdateInterval {
+		j.mutex.Unlock()
+		return
+	}
+	j.lastPublished = now
+	j.mutex.Unlock()
+
+	j.queue.publish(JobEvent{Type: models.JobStatusUpdateTypeUpdate, Job: j})
+}
+
+// JobEvent is published to the subscribers of the job queue when a job is
+// added, updated or removed.
+type JobEvent struct {
+	Type models.JobStatusUpdateType
+	Job  *Job
+}
+
+// JobQueue runs jobs in descending order of priority, and in the order they
+// were added within a priority. Jobs of different classes run at the same
+// time, up to the limit of running jobs of each class, so that a scan runs
+// alongside a generate, but not alongside another scan by default. Exclusive
+// jobs run alone.
+type JobQueue struct {
+	mutex         sync.Mutex
+	jobs          []*Job
+	lastID        int
+	added         chan struct{}
+	subscriptions map[*jobSubscription]struct{}
+}
+
+func newJobQueue() *JobQueue {
+	q := &JobQueue{
+		added:         make(chan struct{}, 1),
+		subscriptions: make(map[*jobSubscription]struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// add queues a job of type t running exec with the default priority of 0,
+// and returns it.
+func (q *JobQueue) add(t JobStatus, exec func(j *Job)) *Job {
+	return q.addWithPriority(t, 0, exec)
+}
+
+// addWithPriority queues a job of type t running exec, and returns it. The
+// job is queued before the ready jobs of lower priority. Running scan, hash
+// or generate jobs of lower priority keeping the job from starting are
+// interrupted at their next checkpoint, and continue from it once the job has
+// run.
+func (q *JobQueue) addWithPriority(t JobStatus, priority int, exec func(j *Job)) *Job {
+	q.mutex.Lock()
+	q.lastID++
+	j := &Job{
+		ID:       q.lastID,
+		priority: priority,
+		exec:     exec,
+		queue:    q,
+		done:     make(chan struct{}),
+		jobType:  t,
+		state:    models.JobStateReady,
+		progress: -1,
+		addTime:  time.Now(),
+	}
+	q.insert(j)
+	blocking := q.blocking(j)
+	q.mutex.Unlock()
+
+	logger.Debugf("Queued %s job %d with priority %d", t.String(), j.ID, priority)
+	q.publish(JobEvent{Type: models.JobStatusUpdateTypeAdd, Job: j})
+
+	for _, b := range blocking {
+		if b.priority < priority {
+			b.interrupt()
+		}
+	}
+
+	q.signal()
+
+	return j
+}
+
+// insert inserts the ready job into the jobs of the queue, before the ready
+// jobs of lower priority and those of the same priority added after it. The
+// queue must be locked.
+func (q *JobQueue) insert(j *Job) {
+	for i, qj := range q.jobs {
+		if qj.getState() == models.JobStateReady && (qj.priority < j.priority || (qj.priority == j.priority && qj.ID > j.ID)) {
+			q.jobs = append(q.jobs[:i], append([]*Job{j}, q.jobs[i:]...)...)
+			return
+		}
+	}
+
+	q.jobs = append(q.jobs, j)
+}
+
+// running returns the running jobs. The queue must be locked.
+func (q *JobQueue) running() []*Job {
+	var ret []*Job
+	for _, j := range q.jobs {
+		if s := j.getState(); s == models.JobStateRunning || s == models.JobStateStopping {
+			ret = append(ret, j)
+		}
+	}
+
+	return ret
+}
+
+// blocking returns the running jobs keeping the ready job from starting: all
+// of them if it is exclusive, the running exclusive job, or the running job
+// of lowest priority of its class if the class is at its limit. The queue
+// must be locked.
+func (q *JobQueue) blocking(j *Job) []*Job {
+	running := q.running()
+	class := j.class()
+	if class == jobClassExclusive {
+		return running
+	}
+
+	var sameClass []*Job
+	for _, r := range running {
+		switch r.class() {
+		case jobClassExclusive:
+			return []*Job{r}
+		case class:
+			sameClass = append(sameClass, r)
+		}
+	}
+
+	if len(sameClass) < class.limit() {
+		return nil
+	}
+
+	lowest := sameClass[0]
+	for _, r := range sameClass[1:] {
+		if r.priority < lowest.priority {
+			lowest = r
+		}
+	}
+	return []*Job{lowest}
+}
+
+// signal wakes the queue to start the ready jobs which can run.
+func (q *JobQueue) signal() {
+	select {
+	case q.added <- struct{}{}:
+	default:
+	}
+}
+
+func (q *JobQueue) run() {
+	for {
+		j := q.next()
+		if j == nil {
+			<-q.added
+			continue
+		}
+
+		go q.runJob(j)
+	}
+}
+
+// next starts the first ready job which is not paused and whose class is
+// below its limit of running jobs, and returns it, or returns nil if there
+// are none. Exclusive jobs start once no job is running, and the jobs after
+// a ready exclusive job wait for it.
+func (q *JobQueue) next() *Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	running := make(map[jobClass]int)
+	total := 0
+	for _, j := range q.running() {
+		running[j.class()]++
+		total++
+	}
+	if running[jobClassExclusive] > 0 {
+		return nil
+	}
+
+	for _, j := range q.jobs {
+		j.mutex.Lock()
+		if j.state == models.JobStateReady && !j.paused {
+			class := jobClassOf(j.jobType)
+			if class == jobClassExclusive && total > 0 {
+				j.mutex.Unlock()
+				return nil
+			}
+			if running[class] >= class.limit() {
+				j.mutex.Unlock()
+				continue
+			}
+
+			j.state = models.JobStateRunning
+			if j.startTime.IsZero() {
+				j.startTime = time.Now()
+			}
+			j.message = ""
+			j.mutex.Unlock()
+			return j
+		}
+		j.mutex.Unlock()
+	}
+
+	return nil
+}
+
+func (q *JobQueue) runJob(j *Job) {
+	j.publish(true)
+
+	_, j.span = tracing.Start(context.Background(), "job "+j.Info().Type.String())
+	j.span.SetAttribute("job.id", j.ID)
+	defer j.span.End()
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Errorf("%s job %d panicked: %v", j.Info().Type.String(), j.ID, r)
+				j.span.SetError(fmt.Errorf("panic: %v", r))
+			}
+		}()
+
+		j.exec(j)
+	}()
+
+	// the queue is locked so that a job which yielded is not started again
+	// before it is moved to its place among the ready jobs
+	q.mutex.Lock()
+	j.mutex.Lock()
+	requeue := false
+	switch {
+	case j.state == models.JobStateStopping:
+		j.state = models.JobStateCancelled
+	case j.yielded:
+		j.state = models.JobStateReady
+		requeue = true
+		if j.paused {
+			j.message = "Paused"
+		} else {
+			j.message = "Interrupted by a job of higher priority"
+		}
+	default:
+		j.state = models.JobStateFinished
+	}
+	j.yielded = false
+	j.interrupted = false
+	j.subTasks = nil
+	if !requeue {
+		j.paused = false
+		j.endTime = time.Now()
+	}
+	j.mutex.Unlock()
+	if requeue {
+		q.requeue(j)
+	}
+	q.mutex.Unlock()
+
+	j.span.SetAttribute("job.state", j.getState().String())
+	if requeue {
+		logger.Infof("%s job %d will continue from its last checkpoint", j.Info().Type.String(), j.ID)
+		j.publish(true)
+	} else {
+		q.finish(j)
+	}
+
+	q.signal()
+}
+
+// requeue moves the job which yielded at a checkpoint to its place among the
+// ready jobs, so that it continues once it is the first ready job which can
+// run. The queue must be locked.
+func (q *JobQueue) requeue(j *Job) {
+	for i, qj := range q.jobs {
+		if qj == j {
+			q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+			break
+		}
+	}
+	q.insert(j)
+}
+
+// finish publishes the finished or cancelled job, and removes the oldest
+// finished jobs beyond maxFinishedJobs.
+func (q *JobQueue) finish(j *Job) {
+	close(j.done)
+	j.publish(true)
+
+	q.mutex.Lock()
+	var finished []*Job
+	for _, qj := range q.jobs {
+		s := qj.getState()
+		if s == models.JobStateFinished || s == models.JobStateCancelled {
+			finished = append(finished, qj)
+		}
+	}
+
+	var removed []*Job
+	if len(finished) > maxFinishedJobs {
+		removed = finished[:len(finished)-maxFinishedJobs]
+		remove := make(map[*Job]bool)
+		for _, r := range removed {
+			remove[r] = true
+		}
+
+		var jobs []*Job
+		for _, qj := range q.jobs {
+			if !remove[qj] {
+				jobs = append(jobs, qj)
+			}
+		}
+		q.jobs = jobs
+	}
+	q.mutex.Unlock()
+
+	for _, r := range removed {
+		q.publish(JobEvent{Type: models.JobStatusUpdateTypeRemove, Job: r})
+	}
+}
+
+// Jobs returns the ready and running jobs, and the most recently finished
+// jobs, in the order they were run or will run.
+func (q *JobQueue) Jobs() []*Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return append([]*Job(nil), q.jobs...)
+}
+
+// Find returns the job with the id, or nil if it is not in the queue.
+func (q *JobQueue) Find(id int) *Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, j := range q.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+
+	return nil
+}
+
+// Current returns the first running job, or nil if no job is running.
+func (q *JobQueue) Current() *Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if running := q.running(); len(running) > 0 {
+		return running[0]
+	}
+	return nil
+}
+
+// Paused returns the first paused job, or nil if no job is paused.
+func (q *JobQueue) Paused() *Job {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, j := range q.jobs {
+		if j.IsPaused() {
+			return j
+		}
+	}
+
+	return nil
+}
+
+// isBusy returns true if a job is running or waiting to run.
+func (q *JobQueue) isBusy() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, j := range q.jobs {
+		if s := j.getState(); s == models.JobStateReady || s == models.JobStateRunning || s == models.JobStateStopping {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Stop cancels the job with the id if it is waiting to run, or requests that
+// it stop if it is running. Returns false if the job is not found or has
+// already finished.
+func (q *JobQueue) Stop(id int) bool {
+	q.mutex.Lock()
+	var j *Job
+	for _, qj := range q.jobs {
+		if qj.ID == id {
+			j = qj
+			break
+		}
+	}
+
+	if j == nil {
+		q.mutex.Unlock()
+		return false
+	}
+
+	// the queue is locked so that the job cannot be started meanwhile
+	j.mutex.Lock()
+	state := j.state
+	switch state {
+	case models.JobStateReady:
+		j.state = models.JobStateCancelled
+		j.paused = false
+		j.endTime = time.Now()
+	case models.JobStateRunning:
+		j.state = models.JobStateStopping
+	}
+	j.mutex.Unlock()
+	q.mutex.Unlock()
+
+	switch state {
+	case models.JobStateReady:
+		logger.Infof("Cancelled %s job %d", j.Info().Type.String(), j.ID)
+		q.finish(j)
+		return true
+	case models.JobStateRunning:
+		logger.Infof("Stopping %s job %d", j.Info().Type.String(), j.ID)
+		j.publish(true)
+		return true
+	}
+
+	return false
+}
+
+type jobSubscription struct {
+	mutex   sync.Mutex
+	pending []JobEvent
+	signal  chan struct{}
+}
+
+func (q *JobQueue) publish(e JobEvent) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for sub := range q.subscriptions {
+		sub.mutex.Lock()
+		sub.pending = append(sub.pending, e)
+		sub.mutex.Unlock()
+
+		select {
+		case sub.signal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events of the queue until ctx is
+// done. Events are buffered, so that slow subscribers do not hold up the
+// jobs.
+func (q *JobQueue) Subscribe(ctx context.Context) <-chan JobEvent {
+	sub := &jobSubscription{
+		signal: make(chan struct{}, 1),
+	}
+
+	q.mutex.Lock()
+	q.subscriptions[sub] = struct{}{}
+	q.mutex.Unlock()
+
+	ret := make(chan JobEvent)
+
+	go func() {
+		defer func() {
+			q.mutex.Lock()
+			delete(q.subscriptions, sub)
+			q.mutex.Unlock()
+			close(ret)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sub.signal:
+			}
+
+			sub.mutex.Lock()
+			events := sub.pending
+			sub.pending = nil
+			sub.mutex.Unlock()
+
+			for _, e := range events {
+				select {
+				case ret <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ret
+}
@@ -0,0 +1,284 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const jobTestTimeout = 5 * time.Second
+
+func waitForState(t *testing.T, j *Job, state models.JobState) {
+	t.Helper()
+
+	deadline := time.Now().Add(jobTestTimeout)
+	for j.Info().State != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d is %s, not %s", j.ID, j.Info().State, state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobQueueRunsInOrder(t *testing.T) {
+	q := newJobQueue()
+
+	release := make(chan struct{})
+	var order []int
+	first := q.add(Scan, func(j *Job) {
+		<-release
+		order = append(order, j.ID)
+	})
+	second := q.add(Hash, func(j *Job) {
+		order = append(order, j.ID)
+	})
+
+	waitForState(t, first, models.JobStateRunning)
+	assert.Equal(t, models.JobStateReady, second.Info().State)
+	assert.Equal(t, first, q.Current())
+	assert.True(t, q.isBusy())
+
+	close(release)
+	second.Wait()
+
+	assert.Equal(t, []int{first.ID, second.ID}, order)
+	assert.Equal(t, models.JobStateFinished, first.Info().State)
+	assert.NotNil(t, second.Info().EndTime)
+	assert.Nil(t, q.Current())
+	assert.False(t, q.isBusy())
+}
+
+func TestJobQueueStop(t *testing.T) {
+	q := newJobQueue()
+
+	running := q.add(Scan, func(j *Job) {
+		for !j.isStopping() {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	ran := false
+	queued := q.add(Hash, func(j *Job) {
+		ran = true
+	})
+
+	waitForState(t, running, models.JobStateRunning)
+
+	// queued jobs are cancelled without running
+	assert.True(t, q.Stop(queued.ID))
+	assert.Equal(t, models.JobStateCancelled, queued.Info().State)
+	assert.False(t, q.Stop(queued.ID))
+
+	assert.True(t, q.Stop(running.ID))
+	running.Wait()
+	assert.Equal(t, models.JobStateCancelled, running.Info().State)
+	assert.False(t, ran)
+
+	assert.False(t, q.Stop(0))
+}
+
+func TestJobQueueRemovesFinishedJobs(t *testing.T) {
+	q := newJobQueue()
+
+	var last *Job
+	for i := 0; i < maxFinishedJobs+2; i++ {
+		last = q.add(Scan, func(j *Job) {})
+	}
+	last.Wait()
+
+	jobs := q.Jobs()
+	assert.Len(t, jobs, maxFinishedJobs)
+	assert.Equal(t, last, jobs[len(jobs)-1])
+	assert.Nil(t, q.Find(1))
+	assert.Equal(t, last, q.Find(last.ID))
+}
+
+func TestJobQueueSubscribe(t *testing.T) {
+	q := newJobQueue()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := q.Subscribe(ctx)
+
+	release := make(chan struct{})
+	j := q.add(Scan, func(j *Job) {
+		j.runSubTask("Scanning a.mp4", func() {
+			<-release
+		})
+	})
+
+	next := func() JobEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(jobTestTimeout):
+			t.Fatal("no job event received")
+			return JobEvent{}
+		}
+	}
+
+	assert.Equal(t, JobEvent{Type: models.JobStatusUpdateTypeAdd, Job: j}, next())
+	assert.Equal(t, JobEvent{Type: models.JobStatusUpdateTypeUpdate, Job: j}, next())
+
+	waitForState(t, j, models.JobStateRunning)
+	for len(j.Info().SubTasks) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"Scanning a.mp4"}, j.Info().SubTasks)
+
+	close(release)
+	j.Wait()
+	assert.Empty(t, j.Info().SubTasks)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestJobQueuePriority(t *testing.T) {
+	q := newJobQueue()
+
+	release := make(chan struct{})
+	var order []int
+	first := q.add(Clean, func(j *Job) {
+		<-release
+	})
+	waitForState(t, first, models.JobStateRunning)
+
+	exec := func(j *Job) {
+		order = append(order, j.ID)
+	}
+	low := q.addWithPriority(Generate, -1, exec)
+	normal := q.add(Generate, exec)
+	high := q.addWithPriority(Generate, 5, exec)
+	normal2 := q.add(Generate, exec)
+
+	assert.Equal(t, []*Job{first, high, normal, normal2, low}, q.Jobs())
+	assert.Equal(t, 5, high.Info().Priority)
+
+	close(release)
+	low.Wait()
+
+	assert.Equal(t, []int{high.ID, normal.ID, normal2.ID, low.ID}, order)
+}
+
+func TestJobQueueInterrupt(t *testing.T) {
+	q := newJobQueue()
+
+	ran := make(chan int)
+	release := make(chan struct{})
+	scan := q.add(Scan, checkpointTestExec(3, ran, release))
+	waitForState(t, scan, models.JobStateRunning)
+
+	// jobs of the same priority do not interrupt the scan
+	same := q.add(Clean, func(j *Job) {})
+	release <- struct{}{}
+	assert.Equal(t, 0, receivePosition(t, ran))
+	assert.Equal(t, models.JobStateRunning, scan.Info().State)
+
+	// a job of higher priority runs after the running task, then the scan
+	// continues from where it was interrupted, before the other jobs
+	var highRan bool
+	high := q.addWithPriority(Hash, 1, func(j *Job) {
+		highRan = true
+		assert.Equal(t, models.JobStateReady, scan.Info().State)
+		assert.Equal(t, 2, scan.resumePosition())
+	})
+	release <- struct{}{}
+	assert.Equal(t, 1, receivePosition(t, ran))
+	high.Wait()
+	assert.True(t, highRan)
+
+	waitForState(t, scan, models.JobStateRunning)
+	assert.Equal(t, models.JobStateReady, same.Info().State)
+	release <- struct{}{}
+	assert.Equal(t, 2, receivePosition(t, ran))
+	scan.Wait()
+	same.Wait()
+
+	assert.Equal(t, models.JobStateFinished, scan.Info().State)
+	assert.False(t, scan.Info().Paused)
+}
+
+func TestJobQueueClasses(t *testing.T) {
+	q := newJobQueue()
+
+	releaseScan := make(chan struct{})
+	scan := q.add(Scan, func(j *Job) {
+		<-releaseScan
+	})
+	releaseGenerate := make(chan struct{})
+	generate := q.add(Generate, func(j *Job) {
+		<-releaseGenerate
+	})
+
+	// jobs of different classes run at the same time, and those of the same
+	// class one at a time
+	waitForState(t, scan, models.JobStateRunning)
+	waitForState(t, generate, models.JobStateRunning)
+	hash := q.add(Hash, func(j *Job) {})
+	assert.Equal(t, models.JobStateReady, hash.Info().State)
+	assert.Equal(t, scan, q.Current())
+
+	close(releaseScan)
+	hash.Wait()
+	assert.Equal(t, models.JobStateRunning, generate.Info().State)
+
+	// exclusive jobs wait for the running jobs, and the jobs after them wait
+	// for them
+	var order []JobStatus
+	clean := q.add(Clean, func(j *Job) {
+		order = append(order, Clean)
+	})
+	autoTag := q.add(AutoTag, func(j *Job) {
+		order = append(order, AutoTag)
+	})
+	assert.Equal(t, models.JobStateReady, clean.Info().State)
+	assert.Equal(t, models.JobStateReady, autoTag.Info().State)
+
+	close(releaseGenerate)
+	autoTag.Wait()
+	assert.Equal(t, []JobStatus{Clean, AutoTag}, order)
+	assert.Equal(t, models.JobStateFinished, clean.Info().State)
+}
+
+func TestJobQueueClassLimits(t *testing.T) {
+	defer config.Set(config.MaxCPUJobs, config.GetMaxCPUJobs())
+	config.Set(config.MaxCPUJobs, 2)
+
+	q := newJobQueue()
+
+	release := make(chan struct{})
+	var jobs []*Job
+	for i := 0; i < 3; i++ {
+		jobs = append(jobs, q.add(Generate, func(j *Job) {
+			<-release
+		}))
+	}
+
+	waitForState(t, jobs[0], models.JobStateRunning)
+	waitForState(t, jobs[1], models.JobStateRunning)
+	assert.Equal(t, models.JobStateReady, jobs[2].Info().State)
+
+	// a job of higher priority only interrupts the running jobs keeping it
+	// from starting
+	ranAlongside := make(chan struct{})
+	high := q.addWithPriority(Scan, 1, func(j *Job) {
+		close(ranAlongside)
+	})
+	select {
+	case <-ranAlongside:
+	case <-time.After(jobTestTimeout):
+		t.Fatal("job of higher priority did not run alongside the running jobs")
+	}
+	high.Wait()
+	for _, j := range jobs[:2] {
+		assert.False(t, j.interrupted)
+	}
+
+	close(release)
+	jobs[2].Wait()
+}
@@ -3,16 +3,26 @@ package manager
 type JobStatus int
 
 const (
-	Idle            JobStatus = 0
-	Import          JobStatus = 1
-	Export          JobStatus = 2
-	Scan            JobStatus = 3
-	Generate        JobStatus = 4
-	Clean           JobStatus = 5
-	Scrape          JobStatus = 6
-	AutoTag         JobStatus = 7
-	Migrate         JobStatus = 8
-	PluginOperation JobStatus = 9
+	Idle             JobStatus = 0
+	Import           JobStatus = 1
+	Export           JobStatus = 2
+	Scan             JobStatus = 3
+	Generate         JobStatus = 4
+	Clean            JobStatus = 5
+	Scrape           JobStatus = 6
+	AutoTag          JobStatus = 7
+	Migrate          JobStatus = 8
+	PluginOperation  JobStatus = 9
+	CleanGenerated   JobStatus = 10
+	MigrateBlobs     JobStatus = 11
+	Hash             JobStatus = 12
+	Sync             JobStatus = 13
+	Backup           JobStatus = 14
+	DuplicateImages  JobStatus = 15
+	CheckConsistency JobStatus = 16
+	InferSceneDates  JobStatus = 17
+	CheckGenerated   JobStatus = 18
+	ChapterMarkers   JobStatus = 19
 )
 
 func (s JobStatus) String() string {
@@ -37,6 +47,26 @@ func (s JobStatus) String() string {
 		statusMessage = "Clean"
 	case PluginOperation:
 		statusMessage = "Plugin Operation"
+	case CleanGenerated:
+		statusMessage = "Clean Generated"
+	case MigrateBlobs:
+		statusMessage = "Migrate Blobs"
+	case Hash:
+		statusMessage = "Hash"
+	case Sync:
+		statusMessage = "Sync"
+	case Backup:
+		statusMessage = "Backup"
+	case DuplicateImages:
+		statusMessage = "Duplicate Images"
+	case CheckConsistency:
+		statusMessage = "Check Consistency"
+	case InferSceneDates:
+		statusMessage = "Infer Scene Dates"
+	case CheckGenerated:
+		statusMessage = "Check Generated Files"
+	case ChapterMarkers:
+		statusMessage = "Chapter Markers"
 	}
 
 	return statusMessage
@@ -16,12 +16,14 @@ type Gallery struct {
 	URL         string          `json:"url,omitempty"`
 	Date        string          `json:"date,omitempty"`
 	Details     string          `json:"details,omitempty"`
-	Rating      int             `json:"rating,omitempty"`
+	Rating      int             `json:"rating,omitempty"` // legacy 1-5 rating
+	Rating100   int             `json:"rating100,omitempty"`
 	Organized   bool            `json:"organized,omitempty"`
 	Studio      string          `json:"studio,omitempty"`
 	Performers  []string        `json:"performers,omitempty"`
 	Tags        []string        `json:"tags,omitempty"`
 	FileModTime models.JSONTime `json:"file_mod_time,omitempty"`
+	UUID        string          `json:"uuid,omitempty"`
 	CreatedAt   models.JSONTime `json:"created_at,omitempty"`
 	UpdatedAt   models.JSONTime `json:"updated_at,omitempty"`
 }
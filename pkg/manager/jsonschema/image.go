@@ -19,13 +19,15 @@ type Image struct {
 	Title      string          `json:"title,omitempty"`
 	Checksum   string          `json:"checksum,omitempty"`
 	Studio     string          `json:"studio,omitempty"`
-	Rating     int             `json:"rating,omitempty"`
+	Rating     int             `json:"rating,omitempty"` // legacy 1-5 rating
+	Rating100  int             `json:"rating100,omitempty"`
 	Organized  bool            `json:"organized,omitempty"`
 	OCounter   int             `json:"o_counter,omitempty"`
 	Galleries  []string        `json:"galleries,omitempty"`
 	Performers []string        `json:"performers,omitempty"`
 	Tags       []string        `json:"tags,omitempty"`
 	File       *ImageFile      `json:"file,omitempty"`
+	UUID       string          `json:"uuid,omitempty"`
 	CreatedAt  models.JSONTime `json:"created_at,omitempty"`
 	UpdatedAt  models.JSONTime `json:"updated_at,omitempty"`
 }
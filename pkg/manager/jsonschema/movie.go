@@ -9,19 +9,26 @@ import (
 )
 
 type Movie struct {
-	Name       string          `json:"name,omitempty"`
-	Aliases    string          `json:"aliases,omitempty"`
-	Duration   int             `json:"duration,omitempty"`
-	Date       string          `json:"date,omitempty"`
-	Rating     int             `json:"rating,omitempty"`
-	Director   string          `json:"director,omitempty"`
-	Synopsis   string          `json:"sypnopsis,omitempty"`
-	FrontImage string          `json:"front_image,omitempty"`
-	BackImage  string          `json:"back_image,omitempty"`
-	URL        string          `json:"url,omitempty"`
-	Studio     string          `json:"studio,omitempty"`
-	CreatedAt  models.JSONTime `json:"created_at,omitempty"`
-	UpdatedAt  models.JSONTime `json:"updated_at,omitempty"`
+	Name          string            `json:"name,omitempty"`
+	Aliases       string            `json:"aliases,omitempty"`
+	Duration      int               `json:"duration,omitempty"`
+	Date          string            `json:"date,omitempty"`
+	Rating        int               `json:"rating,omitempty"` // legacy 1-5 rating
+	Rating100     int               `json:"rating100,omitempty"`
+	Director      string            `json:"director,omitempty"`
+	Synopsis      string            `json:"sypnopsis,omitempty"`
+	FrontImage    string            `json:"front_image,omitempty"`
+	BackImage     string            `json:"back_image,omitempty"`
+	CoverURL      string            `json:"cover_url,omitempty"`
+	BackgroundURL string            `json:"background_url,omitempty"`
+	URL           string            `json:"url,omitempty"` // legacy single URL
+	URLs          []string          `json:"urls,omitempty"`
+	Studio        string            `json:"studio,omitempty"`
+	Slug          string            `json:"slug,omitempty"`
+	CustomFields  map[string]string `json:"custom_fields,omitempty"`
+	UUID          string            `json:"uuid,omitempty"`
+	CreatedAt     models.JSONTime   `json:"created_at,omitempty"`
+	UpdatedAt     models.JSONTime   `json:"updated_at,omitempty"`
 }
 
 func LoadMovieFile(filePath string) (*Movie, error) {
@@ -13,6 +13,7 @@ type SceneMarker struct {
 	Seconds    string          `json:"seconds,omitempty"`
 	PrimaryTag string          `json:"primary_tag,omitempty"`
 	Tags       []string        `json:"tags,omitempty"`
+	UUID       string          `json:"uuid,omitempty"`
 	CreatedAt  models.JSONTime `json:"created_at,omitempty"`
 	UpdatedAt  models.JSONTime `json:"updated_at,omitempty"`
 }
@@ -36,25 +37,29 @@ type SceneMovie struct {
 }
 
 type Scene struct {
-	Title      string          `json:"title,omitempty"`
-	Checksum   string          `json:"checksum,omitempty"`
-	OSHash     string          `json:"oshash,omitempty"`
-	Studio     string          `json:"studio,omitempty"`
-	URL        string          `json:"url,omitempty"`
-	Date       string          `json:"date,omitempty"`
-	Rating     int             `json:"rating,omitempty"`
-	Organized  bool            `json:"organized,omitempty"`
-	OCounter   int             `json:"o_counter,omitempty"`
-	Details    string          `json:"details,omitempty"`
-	Gallery    string          `json:"gallery,omitempty"`
-	Performers []string        `json:"performers,omitempty"`
-	Movies     []SceneMovie    `json:"movies,omitempty"`
-	Tags       []string        `json:"tags,omitempty"`
-	Markers    []SceneMarker   `json:"markers,omitempty"`
-	File       *SceneFile      `json:"file,omitempty"`
-	Cover      string          `json:"cover,omitempty"`
-	CreatedAt  models.JSONTime `json:"created_at,omitempty"`
-	UpdatedAt  models.JSONTime `json:"updated_at,omitempty"`
+	Title         string          `json:"title,omitempty"`
+	Checksum      string          `json:"checksum,omitempty"`
+	OSHash        string          `json:"oshash,omitempty"`
+	Studio        string          `json:"studio,omitempty"`
+	URL           string          `json:"url,omitempty"`
+	Date          string          `json:"date,omitempty"`
+	Rating        int             `json:"rating,omitempty"` // legacy 1-5 rating
+	Rating100     int             `json:"rating100,omitempty"`
+	Organized     bool            `json:"organized,omitempty"`
+	OCounter      int             `json:"o_counter,omitempty"`
+	Details       string          `json:"details,omitempty"`
+	Gallery       string          `json:"gallery,omitempty"`
+	Performers    []string        `json:"performers,omitempty"`
+	Movies        []SceneMovie    `json:"movies,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
+	Markers       []SceneMarker   `json:"markers,omitempty"`
+	File          *SceneFile      `json:"file,omitempty"`
+	Cover         string          `json:"cover,omitempty"`
+	CoverURL      string          `json:"cover_url,omitempty"`
+	BackgroundURL string          `json:"background_url,omitempty"`
+	UUID          string          `json:"uuid,omitempty"`
+	CreatedAt     models.JSONTime `json:"created_at,omitempty"`
+	UpdatedAt     models.JSONTime `json:"updated_at,omitempty"`
 }
 
 func LoadSceneFile(filePath string) (*Scene, error) {
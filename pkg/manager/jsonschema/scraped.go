@@ -24,6 +24,32 @@ type ScrapedItem struct {
 	UpdatedAt       models.JSONTime `json:"updated_at,omitempty"`
 }
 
+// Fields returns the names of the fields of the item that have a value.
+func (s ScrapedItem) Fields() []string {
+	var ret []string
+	add := func(name string, set bool) {
+		if set {
+			ret = append(ret, name)
+		}
+	}
+
+	add("title", s.Title != "")
+	add("description", s.Description != "")
+	add("url", s.URL != "")
+	add("date", s.Date != "")
+	add("rating", s.Rating != "")
+	add("tags", s.Tags != "")
+	add("models", s.Models != "")
+	add("episode", s.Episode != 0)
+	add("gallery_filename", s.GalleryFilename != "")
+	add("gallery_url", s.GalleryURL != "")
+	add("video_filename", s.VideoFilename != "")
+	add("video_url", s.VideoURL != "")
+	add("studio", s.Studio != "")
+
+	return ret
+}
+
 func LoadScrapedFile(filePath string) ([]ScrapedItem, error) {
 	var scraped []ScrapedItem
 	file, err := os.Open(filePath)
@@ -12,7 +12,9 @@ type Studio struct {
 	Name         string          `json:"name,omitempty"`
 	URL          string          `json:"url,omitempty"`
 	ParentStudio string          `json:"parent_studio,omitempty"`
+	Slug         string          `json:"slug,omitempty"`
 	Image        string          `json:"image,omitempty"`
+	UUID         string          `json:"uuid,omitempty"`
 	CreatedAt    models.JSONTime `json:"created_at,omitempty"`
 	UpdatedAt    models.JSONTime `json:"updated_at,omitempty"`
 }
@@ -11,6 +11,7 @@ import (
 type Tag struct {
 	Name      string          `json:"name,omitempty"`
 	Image     string          `json:"image,omitempty"`
+	UUID      string          `json:"uuid,omitempty"`
 	CreatedAt models.JSONTime `json:"created_at,omitempty"`
 	UpdatedAt models.JSONTime `json:"updated_at,omitempty"`
 }
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// getLibraries returns the configured libraries with the names. All
+// libraries are returned if names is empty.
+func getLibraries(names []string) ([]*models.StashConfig, error) {
+	stashes := config.GetStashPaths()
+	if len(names) == 0 {
+		return stashes, nil
+	}
+
+	var ret []*models.StashConfig
+	for _, name := range names {
+		var found *models.StashConfig
+		for _, s := range stashes {
+			if s.LibraryName() == name {
+				found = s
+				break
+			}
+		}
+
+		if found == nil {
+			return nil, fmt.Errorf("library %q not found", name)
+		}
+		ret = append(ret, found)
+	}
+
+	return ret, nil
+}
+
+// inLibraries returns true if the file at path is in one of the libraries.
+func inLibraries(path string, libraries []*models.StashConfig) bool {
+	stash := getStashFromPath(path)
+	if stash == nil {
+		return false
+	}
+
+	for _, l := range libraries {
+		if l.Path == stash.Path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inPaths returns true if path is in one of the directories, or in their
+// subdirectories.
+func inPaths(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// libraryExcludes returns the regexps of the video files excluded from the
+// library, including the global excludes.
+func libraryExcludes(s *models.StashConfig) []string {
+	return append(config.GetExcludes(), s.Excludes...)
+}
+
+// libraryImageExcludes returns the regexps of the image files excluded from
+// the library, including the global image excludes.
+func libraryImageExcludes(s *models.StashConfig) []string {
+	return append(config.GetImageExcludes(), s.ImageExcludes...)
+}
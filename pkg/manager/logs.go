@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// CreateLogArchive zips the log file and its rotated files, and returns the
+// hash of the download of the zip.
+func (s *singleton) CreateLogArchive() (string, error) {
+	files, err := logger.LogFiles()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", errors.New("logging to a file is not enabled")
+	}
+
+	utils.EnsureDir(s.Paths.Generated.Downloads)
+	z, err := ioutil.TempFile(s.Paths.Generated.Downloads, "logs*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer z.Close()
+
+	if err := zipLogFiles(z, files); err != nil {
+		os.Remove(z.Name())
+		return "", err
+	}
+
+	hash := s.DownloadStore.RegisterFile(z.Name(), "", false)
+	logger.Debugf("Generated log archive %s with hash %s", z.Name(), hash)
+	return hash, nil
+}
+
+func zipLogFiles(w io.Writer, files []string) error {
+	z := zip.NewWriter(w)
+
+	for _, fn := range files {
+		// rotated files may be compressed or removed while zipping
+		if err := addLogFile(z, fn); err != nil && !os.IsNotExist(err) {
+			z.Close()
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func addLogFile(z *zip.Writer, fn string) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(fn)
+	header.Method = zip.Deflate
+
+	zw, err := z.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	// copy only the size at the time of opening, since the log file may be
+	// written to while it is copied
+	_, err = io.CopyN(zw, f, info.Size())
+	return err
+}
@@ -10,14 +10,16 @@ import (
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/plugin"
 	"github.com/stashapp/stash/pkg/scraper"
+	"github.com/stashapp/stash/pkg/tracing"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
 type singleton struct {
-	Status TaskStatus
-	Paths  *paths.Paths
+	JobQueue *JobQueue
+	Paths    *paths.Paths
 
 	FFMPEGPath  string
 	FFProbePath string
@@ -26,6 +28,14 @@ type singleton struct {
 	ScraperCache *scraper.Cache
 
 	DownloadStore *DownloadStore
+
+	// transcodeCache is replaced when the configuration changes, while
+	// streams are using it
+	transcodeCache      *TranscodeCache
+	transcodeCacheMutex sync.RWMutex
+
+	watcher      *libraryWatcher
+	watcherMutex sync.Mutex
 }
 
 var instance *singleton
@@ -48,10 +58,15 @@ func Initialize() *singleton {
 		initFlags()
 		initConfig()
 		initLog()
+		tracing.SetEndpoint(config.GetTracingEndpoint())
+		initTimezone()
+		initDisplayTitleCleanup()
+		utils.SetQualityScoreWeights(config.GetQualityScoreWeights())
+		models.SetApproximateCounts(config.GetApproximateCounts())
 		initEnvs()
 		instance = &singleton{
-			Status: TaskStatus{Status: Idle, Progress: -1},
-			Paths:  paths.NewPaths(),
+			JobQueue: newJobQueue(),
+			Paths:    paths.NewPaths(),
 
 			PluginCache:  initPluginCache(),
 			ScraperCache: initScraperCache(),
@@ -166,7 +181,19 @@ The error was: %s
 }
 
 func initLog() {
-	logger.Init(config.GetLogFile(), config.GetLogOut(), config.GetLogLevel())
+	logger.Init(config.GetLogFile(), config.GetLogOut(), config.GetLogLevel(), config.GetLogRotateConfig())
+}
+
+func initTimezone() {
+	if err := utils.SetTimezone(config.GetTimezone()); err != nil {
+		logger.Warnf("Using server timezone: %s", err.Error())
+	}
+}
+
+func initDisplayTitleCleanup() {
+	if err := utils.SetDisplayTitleCleanup(config.GetDisplayTitleCleanup()); err != nil {
+		logger.Warnf("Not cleaning up display titles: %s", err.Error())
+	}
 }
 
 func initPluginCache() *plugin.Cache {
@@ -204,9 +231,45 @@ func (s *singleton) RefreshConfig() {
 		utils.EnsureDir(s.Paths.Generated.Transcodes)
 		utils.EnsureDir(s.Paths.Generated.Downloads)
 		paths.EnsureJSONDirs(config.GetMetadataPath())
+
+		s.refreshTranscodeCache()
+
+		useFilesystem := config.GetBlobsStorage() == models.BlobsStorageTypeFilesystem
+		models.SetBlobStorage(config.GetBlobsPath(), useFilesystem)
+		models.SetLibraries(config.GetStashPaths())
+		s.refreshWatcher()
 	}
 }
 
+// GetTranscodeCache returns the cache of live transcode output.
+func (s *singleton) GetTranscodeCache() *TranscodeCache {
+	s.transcodeCacheMutex.RLock()
+	defer s.transcodeCacheMutex.RUnlock()
+
+	return s.transcodeCache
+}
+
+// refreshTranscodeCache replaces the transcode cache if its directory or
+// size has changed. The old cache is closed first, so that the output of
+// transcodes still being written to it is not added to the directory
+// without being tracked by the new cache.
+func (s *singleton) refreshTranscodeCache() {
+	dir := s.Paths.Generated.TranscodeCache
+	maxSize := int64(config.GetTranscodeCacheSize()) * 1024 * 1024
+
+	s.transcodeCacheMutex.Lock()
+	defer s.transcodeCacheMutex.Unlock()
+
+	if old := s.transcodeCache; old != nil {
+		if old.dir == dir && old.maxSize == maxSize {
+			return
+		}
+		old.Close()
+	}
+
+	s.transcodeCache = NewTranscodeCache(dir, maxSize)
+}
+
 // RefreshScraperCache refreshes the scraper cache. Call this when scraper
 // configuration changes.
 func (s *singleton) RefreshScraperCache() {
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/remeh/sizedwaitgroup"
 
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
@@ -31,59 +35,12 @@ func isImage(pathname string) bool {
 	return matchExtension(pathname, imgExt)
 }
 
-type TaskStatus struct {
-	Status     JobStatus
-	Progress   float64
-	LastUpdate time.Time
-	stopping   bool
-	upTo       int
-	total      int
-}
-
-func (t *TaskStatus) Stop() bool {
-	t.stopping = true
-	t.updated()
-	return true
-}
-
-func (t *TaskStatus) SetStatus(s JobStatus) {
-	t.Status = s
-	t.updated()
-}
-
-func (t *TaskStatus) setProgress(upTo int, total int) {
-	if total == 0 {
-		t.Progress = 1
-	}
-	t.upTo = upTo
-	t.total = total
-	t.Progress = float64(upTo) / float64(total)
-	t.updated()
-}
-
-func (t *TaskStatus) setProgressPercent(progress float64) {
-	if progress != t.Progress {
-		t.Progress = progress
-		t.updated()
-	}
-}
-
-func (t *TaskStatus) incrementProgress() {
-	t.setProgress(t.upTo+1, t.total)
-}
-
-func (t *TaskStatus) indefiniteProgress() {
-	t.Progress = -1
-	t.updated()
-}
-
-func (t *TaskStatus) updated() {
-	t.LastUpdate = time.Now()
-}
-
-func getScanPaths(inputPaths []string) []*models.StashConfig {
+// getScanPaths returns the paths to scan, with the settings of their
+// libraries. The paths of the named libraries are scanned as well as
+// inputPaths. All library paths are scanned if neither are set.
+func getScanPaths(inputPaths []string, libraryNames []string) ([]*models.StashConfig, error) {
 	if len(inputPaths) == 0 {
-		return config.GetStashPaths()
+		return getLibraries(libraryNames)
 	}
 
 	var ret []*models.StashConfig
@@ -100,10 +57,18 @@ func getScanPaths(inputPaths []string) []*models.StashConfig {
 		ret = append(ret, &ss)
 	}
 
-	return ret
+	if len(libraryNames) > 0 {
+		libraries, err := getLibraries(libraryNames)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, libraries...)
+	}
+
+	return ret, nil
 }
 
-func (s *singleton) neededScan(paths []*models.StashConfig) (total *int, newFiles *int) {
+func (s *singleton) neededScan(j *Job, paths []*models.StashConfig) (total *int, newFiles *int) {
 	const timeout = 90 * time.Second
 
 	// create a control channel through which to signal the counting loop when the timeout is reached
@@ -132,7 +97,7 @@ func (s *singleton) neededScan(paths []*models.StashConfig) (total *int, newFile
 			}
 
 			// check stop
-			if s.Status.stopping {
+			if j.isStopping() {
 				return timeoutErr
 			}
 
@@ -153,21 +118,26 @@ func (s *singleton) neededScan(paths []*models.StashConfig) (total *int, newFile
 	return &t, &n
 }
 
-func (s *singleton) Scan(input models.ScanMetadataInput) {
-	if s.Status.Status != Idle {
-		return
+// Scan queues a scan of the paths or libraries of the input, and returns
+// the job ID. An error is returned if a library is not found.
+func (s *singleton) Scan(input models.ScanMetadataInput) (int, error) {
+	paths, err := getScanPaths(input.Paths, input.Libraries)
+	if err != nil {
+		return 0, err
 	}
-	s.Status.SetStatus(Scan)
-	s.Status.indefiniteProgress()
 
-	go func() {
-		defer s.returnToIdleState()
+	priority := 0
+	if input.Priority != nil {
+		priority = *input.Priority
+	}
 
-		paths := getScanPaths(input.Paths)
+	return s.JobQueue.addWithPriority(Scan, priority, func(j *Job) {
+		acquireGeneratedTmpDir()
+		defer releaseGeneratedTmpDir()
 
-		total, newFiles := s.neededScan(paths)
+		total, newFiles := s.neededScan(j, paths)
 
-		if s.Status.stopping {
+		if j.isStopping() {
 			logger.Info("Stopping due to user request")
 			return
 		}
@@ -184,23 +154,43 @@ func (s *singleton) Scan(input models.ScanMetadataInput) {
 		logger.Infof("Scan started with %d parallel tasks", parallelTasks)
 		wg := sizedwaitgroup.New(parallelTasks)
 
-		s.Status.Progress = 0
+		j.setProgressPercent(0)
 		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
 		calculateMD5 := config.IsCalculateMD5()
+		deferHashing := config.IsDeferScanHashing()
+		generatePhash := input.ScanGeneratePhashes != nil && *input.ScanGeneratePhashes
 
 		i := 0
 		stoppingErr := errors.New("stopping")
 
+		// files are walked in the same order when the job continues after
+		// yielding, so the files before the saved position are skipped
+		resumeFrom := j.resumePosition()
+		pos := 0
+
 		var galleries []string
 
 		for _, sp := range paths {
+			generatePreview := input.ScanGeneratePreviews
+			generateSprite := input.ScanGenerateSprites
+			if sp.GeneratePreviews != nil {
+				generatePreview = *sp.GeneratePreviews
+				generateSprite = *sp.GeneratePreviews
+			}
+
 			err := walkFilesToScan(sp, func(path string, info os.FileInfo, err error) error {
 				if total != nil {
-					s.Status.setProgress(i, *total)
+					j.setProgress(i, *total)
 					i++
 				}
 
-				if s.Status.stopping {
+				if j.isStopping() {
+					return stoppingErr
+				}
+
+				filePos := pos
+				pos++
+				if filePos >= resumeFrom && !j.checkpoint(filePos) {
 					return stoppingErr
 				}
 
@@ -208,11 +198,15 @@ func (s *singleton) Scan(input models.ScanMetadataInput) {
 					galleries = append(galleries, path)
 				}
 
-				instance.Paths.Generated.EnsureTmpDir()
+				if filePos < resumeFrom {
+					return nil
+				}
 
 				wg.Add()
-				task := ScanTask{FilePath: path, UseFileMetadata: input.UseFileMetadata, StripFileExtension: input.StripFileExtension, fileNamingAlgorithm: fileNamingAlgo, calculateMD5: calculateMD5, GeneratePreview: input.ScanGeneratePreviews, GenerateImagePreview: input.ScanGenerateImagePreviews, GenerateSprite: input.ScanGenerateSprites}
-				go task.Start(&wg)
+				task := ScanTask{FilePath: path, UseFileMetadata: input.UseFileMetadata, StripFileExtension: input.StripFileExtension, fileNamingAlgorithm: fileNamingAlgo, calculateMD5: calculateMD5, deferHashing: deferHashing, GeneratePreview: generatePreview, GenerateImagePreview: input.ScanGenerateImagePreviews, GenerateSprite: generateSprite, GeneratePhash: generatePhash, library: sp, job: j}
+				go j.runSubTask("Scanning "+path, func() {
+					task.Start(&wg)
+				})
 
 				return nil
 			})
@@ -227,13 +221,15 @@ func (s *singleton) Scan(input models.ScanMetadataInput) {
 			}
 		}
 
-		if s.Status.stopping {
+		if j.isStopping() {
 			logger.Info("Stopping due to user request")
 			return
 		}
 
 		wg.Wait()
-		instance.Paths.Generated.EmptyTmpDir()
+		if j.hasYielded() {
+			return
+		}
 
 		elapsed := time.Since(start)
 		logger.Info(fmt.Sprintf("Scan finished (%s)", elapsed))
@@ -245,19 +241,316 @@ func (s *singleton) Scan(input models.ScanMetadataInput) {
 			wg.Wait()
 		}
 		logger.Info("Finished gallery association")
-	}()
+
+		if deferHashing {
+			// the scanned files can be browsed while they are hashed
+			j.setType(Hash)
+			hash := func(j *Job) {
+				s.hashScenes(j, input.ScanGeneratePreviews, input.ScanGenerateImagePreviews, input.ScanGenerateSprites, generatePhash)
+			}
+			j.continueWith(hash)
+			hash(j)
+		}
+	}).ID, nil
 }
 
-func (s *singleton) Import() {
-	if s.Status.Status != Idle {
+// ScanSingle re-reads the file details of the scenes with the ids, such as
+// their hashes, codecs, duration, resolution and bitrate, whether or not
+// their files were modified.
+func (s *singleton) ScanSingle(sceneIDs []int) int {
+	return s.JobQueue.add(Scan, func(j *Job) {
+		qb := models.NewSceneQueryBuilder()
+		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+		calculateMD5 := config.IsCalculateMD5()
+
+		logger.Infof("Starting rescan of %d scenes", len(sceneIDs))
+
+		for i, sceneID := range sceneIDs {
+			j.setProgress(i, len(sceneIDs))
+
+			if j.isStopping() {
+				logger.Info("Stopping due to user request")
+				return
+			}
+
+			scene, err := qb.Find(sceneID)
+			if err != nil {
+				logger.Errorf("error finding scene with id %d: %s", sceneID, err.Error())
+				continue
+			}
+			if scene == nil {
+				logger.Errorf("scene with id %d not found", sceneID)
+				continue
+			}
+
+			task := ScanTask{FilePath: scene.Path, fileNamingAlgorithm: fileNamingAlgo, calculateMD5: calculateMD5, job: j}
+			fileModTime, err := task.getFileModTime()
+			if err != nil {
+				logger.Error(err.Error())
+				continue
+			}
+
+			if _, err := task.rescanScene(scene, fileModTime); err != nil {
+				logger.Errorf("error rescanning %s: %s", scene.Path, err.Error())
+			}
+		}
+
+		logger.Info("Finished rescan")
+	}).ID
+}
+
+// DuplicateImages calculates the missing perceptual hashes of images, then
+// logs the groups of duplicate images with perceptual hashes within
+// phashDistance of each other or the same checksum.
+func (s *singleton) DuplicateImages(phashDistance int) int {
+	return s.JobQueue.add(DuplicateImages, func(j *Job) {
+		qb := models.NewImageQueryBuilder()
+		images, err := qb.FindMissingPhash()
+		if err != nil {
+			logger.Errorf("failed to fetch list of images to hash: %s", err.Error())
+			return
+		}
+
+		logger.Infof("Calculating perceptual hashes of %d images", len(images))
+
+		wg := sizedwaitgroup.New(config.GetParallelTasksWithAutoDetection())
+		j.setProgressPercent(0)
+		total := len(images)
+
+		for i, img := range images {
+			j.setProgress(i, total)
+			if j.isStopping() || !j.checkpoint(i) {
+				break
+			}
+
+			wg.Add()
+			task := GenerateImagePhashTask{Image: *img}
+			go task.Start(&wg)
+		}
+
+		wg.Wait()
+
+		if j.isStopping() {
+			logger.Info("Stopping due to user request")
+			return
+		}
+
+		j.indefiniteProgress()
+
+		images, err = qb.All()
+		if err != nil {
+			logger.Errorf("failed to fetch list of images: %s", err.Error())
+			return
+		}
+
+		groups := image.FindDuplicates(images, &phashDistance)
+		for _, group := range groups {
+			var paths []string
+			for _, img := range group.Images {
+				paths = append(paths, image.PathDisplayName(img.Path))
+			}
+			logger.Infof("Duplicate images (%v): %s", group.Reasons, strings.Join(paths, ", "))
+		}
+
+		logger.Infof("Found %d groups of duplicate images", len(groups))
+	}).ID
+}
+
+// CheckConsistency logs the rows of the database referencing rows which no
+// longer exist.
+func (s *singleton) CheckConsistency() int {
+	return s.JobQueue.add(CheckConsistency, func(j *Job) {
+		logger.Info("Checking database consistency")
+
+		refs, err := models.FindDanglingReferences()
+		if err != nil {
+			logger.Errorf("error checking database consistency: %s", err.Error())
+			return
+		}
+
+		for _, r := range refs {
+			parentID := "null"
+			if r.ParentID != nil {
+				parentID = strconv.Itoa(*r.ParentID)
+			}
+			logger.Warnf("Dangling reference: %s.%s of row %d references missing %s %s", r.Table, r.Column, r.RowID, r.ParentTable, parentID)
+		}
+
+		logger.Infof("Found %d dangling references", len(refs))
+	}).ID
+}
+
+// InferSceneDates infers the dates of the scenes without dates from the
+// sources of the date inference priority setting, and stores them as
+// suggestions to be applied or dismissed.
+func (s *singleton) InferSceneDates() int {
+	return s.JobQueue.add(InferSceneDates, func(j *Job) {
+		qb := models.NewSceneQueryBuilder()
+		scenes, err := qb.FindMissingDate()
+		if err != nil {
+			logger.Errorf("failed to fetch list of scenes: %s", err.Error())
+			return
+		}
+
+		priority := config.GetDateInferencePriority()
+		total := len(scenes)
+		suggested := 0
+
+		for i, scene := range scenes {
+			j.setProgress(i, total)
+			if j.isStopping() {
+				logger.Info("Stopping due to user request")
+				return
+			}
+
+			found, err := suggestSceneDate(scene, priority)
+			if err != nil {
+				logger.Errorf("error inferring date of %s: %s", scene.Path, err.Error())
+				continue
+			}
+			if found {
+				suggested++
+			}
+		}
+
+		logger.Infof("Inferred the dates of %d of %d scenes without dates", suggested, total)
+	}).ID
+}
+
+// ChapterMarkers creates scene markers from the chapters embedded in scene
+// files, with the chapter marker tag as their primary tag. If reread is true,
+// the chapters are read from the files first. Returns the job ID.
+func (s *singleton) ChapterMarkers(input models.ChapterMarkersMetadataInput) int {
+	return s.JobQueue.add(ChapterMarkers, func(j *Job) {
+		reread := input.Reread != nil && *input.Reread
+		scenes, err := chapterMarkerScenes(input.SceneIds, reread)
+		if err != nil {
+			logger.Errorf("failed to fetch list of scenes: %s", err.Error())
+			return
+		}
+
+		total := len(scenes)
+		created := 0
+
+		for i, scene := range scenes {
+			j.setProgress(i, total)
+			if j.isStopping() {
+				logger.Info("Stopping due to user request")
+				return
+			}
+
+			if reread {
+				if _, err := ReadSceneChapters(scene); err != nil {
+					logger.Error(err.Error())
+					continue
+				}
+			}
+
+			var markers []*models.SceneMarker
+			if err := database.WithTxn(func(tx *sqlx.Tx) error {
+				var txnErr error
+				markers, txnErr = createChapterMarkers(scene.ID, tx)
+				return txnErr
+			}); err != nil {
+				logger.Errorf("error creating markers from the chapters of %s: %s", scene.Path, err.Error())
+				continue
+			}
+
+			created += len(markers)
+			publishMarkersCreated(markers)
+		}
+
+		logger.Infof("Created %d markers from the chapters of %d scenes", created, total)
+	}).ID
+}
+
+func chapterMarkerScenes(sceneIDs []string, reread bool) ([]*models.Scene, error) {
+	qb := models.NewSceneQueryBuilder()
+	if len(sceneIDs) > 0 {
+		return qb.FindMany(utils.StringSliceToIntSlice(sceneIDs))
+	}
+	if reread {
+		return qb.All()
+	}
+	return qb.FindWithChapters()
+}
+
+// Hash calculates the missing hashes of scenes, such as those added by a scan
+// with deferred hashing, and generates their screenshots.
+func (s *singleton) Hash() int {
+	return s.JobQueue.add(Hash, func(j *Job) {
+		s.hashScenes(j, false, false, false, false)
+	}).ID
+}
+
+func (s *singleton) hashScenes(j *Job, generatePreview bool, generateImagePreview bool, generateSprite bool, generatePhash bool) {
+	acquireGeneratedTmpDir()
+	defer releaseGeneratedTmpDir()
+
+	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+	calculateMD5 := config.IsCalculateMD5()
+
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindMissingHashes(calculateMD5 || fileNamingAlgo == models.HashAlgorithmMd5)
+	if err != nil {
+		logger.Errorf("failed to fetch list of scenes to hash: %s", err.Error())
 		return
 	}
-	s.Status.SetStatus(Import)
-	s.Status.indefiniteProgress()
 
-	go func() {
-		defer s.returnToIdleState()
+	logger.Infof("Starting hashing of %d scenes", len(scenes))
+
+	start := time.Now()
+	wg := sizedwaitgroup.New(config.GetParallelTasksWithAutoDetection())
+	j.setProgressPercent(0)
+	total := len(scenes)
+
+	// the scenes which are still missing hashes are found again when the job
+	// continues after yielding, so the saved position is not used
+	for i, scene := range scenes {
+		j.setProgress(i, total)
+		if j.isStopping() || !j.checkpoint(i) {
+			break
+		}
+
+		scenePreview := generatePreview
+		sceneSprite := generateSprite
+		if stash := getStashFromPath(scene.Path); stash != nil && stash.GeneratePreviews != nil {
+			scenePreview = *stash.GeneratePreviews
+			sceneSprite = *stash.GeneratePreviews
+		}
+
+		wg.Add()
+		task := HashTask{
+			Scene:                scene,
+			calculateMD5:         calculateMD5,
+			fileNamingAlgorithm:  fileNamingAlgo,
+			GeneratePreview:      scenePreview,
+			GenerateImagePreview: generateImagePreview,
+			GenerateSprite:       sceneSprite,
+			GeneratePhash:        generatePhash,
+			job:                  j,
+		}
+		go j.runSubTask("Hashing "+scene.Path, func() {
+			task.Start(&wg)
+		})
+	}
+
+	wg.Wait()
 
+	if j.isStopping() {
+		logger.Info("Stopping due to user request")
+		return
+	}
+
+	if j.hasYielded() {
+		return
+	}
+
+	logger.Infof("Hashing finished (%s)", time.Since(start))
+}
+
+func (s *singleton) Import() int {
+	return s.JobQueue.add(Import, func(j *Job) {
 		var wg sync.WaitGroup
 		wg.Add(1)
 		task := ImportTask{
@@ -269,45 +562,27 @@ func (s *singleton) Import() {
 		}
 		go task.Start(&wg)
 		wg.Wait()
-	}()
+	}).ID
 }
 
-func (s *singleton) Export() {
-	if s.Status.Status != Idle {
-		return
-	}
-	s.Status.SetStatus(Export)
-	s.Status.indefiniteProgress()
-
-	go func() {
-		defer s.returnToIdleState()
-
+func (s *singleton) Export() int {
+	return s.JobQueue.add(Export, func(j *Job) {
 		var wg sync.WaitGroup
 		wg.Add(1)
 		task := ExportTask{full: true, fileNamingAlgorithm: config.GetVideoFileNamingAlgorithm()}
 		go task.Start(&wg)
 		wg.Wait()
-	}()
+	}).ID
 }
 
-func (s *singleton) RunSingleTask(t Task) (*sync.WaitGroup, error) {
-	if s.Status.Status != Idle {
-		return nil, errors.New("task already running")
-	}
-
-	s.Status.SetStatus(t.GetStatus())
-	s.Status.indefiniteProgress()
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func() {
-		defer s.returnToIdleState()
-
+// RunSingleTask queues the task, returning its job.
+func (s *singleton) RunSingleTask(t Task) *Job {
+	return s.JobQueue.add(t.GetStatus(), func(j *Job) {
+		var wg sync.WaitGroup
+		wg.Add(1)
 		go t.Start(&wg)
 		wg.Wait()
-	}()
-
-	return &wg, nil
+	})
 }
 
 func setGeneratePreviewOptionsInput(optionsInput *models.GeneratePreviewOptionsInput) {
@@ -337,24 +612,21 @@ func setGeneratePreviewOptionsInput(optionsInput *models.GeneratePreviewOptionsI
 	}
 }
 
-func (s *singleton) Generate(input models.GenerateMetadataInput) {
-	if s.Status.Status != Idle {
-		return
-	}
-	s.Status.SetStatus(Generate)
-	s.Status.indefiniteProgress()
-
+func (s *singleton) Generate(input models.GenerateMetadataInput) int {
 	qb := models.NewSceneQueryBuilder()
 	mqb := models.NewSceneMarkerQueryBuilder()
 
-	//this.job.total = await ObjectionUtils.getCount(Scene);
-	instance.Paths.Generated.EnsureTmpDir()
-
 	sceneIDs := utils.StringSliceToIntSlice(input.SceneIDs)
 	markerIDs := utils.StringSliceToIntSlice(input.MarkerIDs)
 
-	go func() {
-		defer s.returnToIdleState()
+	priority := 0
+	if input.Priority != nil {
+		priority = *input.Priority
+	}
+
+	return s.JobQueue.addWithPriority(Generate, priority, func(j *Job) {
+		acquireGeneratedTmpDir()
+		defer releaseGeneratedTmpDir()
 
 		var scenes []*models.Scene
 		var err error
@@ -375,7 +647,7 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 		logger.Infof("Generate started with %d parallel tasks", parallelTasks)
 		wg := sizedwaitgroup.New(parallelTasks)
 
-		s.Status.Progress = 0
+		j.setProgressPercent(0)
 		lenScenes := len(scenes)
 		total := lenScenes
 
@@ -386,7 +658,7 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 			total += len(markers)
 		}
 
-		if s.Status.stopping {
+		if j.isStopping() {
 			logger.Info("Stopping due to user request")
 			return
 		}
@@ -396,7 +668,7 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 			logger.Infof("Taking too long to count content. Skipping...")
 			logger.Infof("Generating content")
 		} else {
-			logger.Infof("Generating %d sprites %d previews %d image previews %d markers %d transcodes", totalsNeeded.sprites, totalsNeeded.previews, totalsNeeded.imagePreviews, totalsNeeded.markers, totalsNeeded.transcodes)
+			logger.Infof("Generating %d sprites %d previews %d image previews %d markers %d transcodes %d phashes", totalsNeeded.sprites, totalsNeeded.previews, totalsNeeded.imagePreviews, totalsNeeded.markers, totalsNeeded.transcodes, totalsNeeded.phashes)
 		}
 
 		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
@@ -405,6 +677,7 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 		if input.Overwrite != nil {
 			overwrite = *input.Overwrite
 		}
+		generatePhash := input.Phashes != nil && *input.Phashes
 
 		generatePreviewOptions := input.PreviewOptions
 		if generatePreviewOptions == nil {
@@ -414,11 +687,19 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 
 		// Start measuring how long the scan has taken. (consider moving this up)
 		start := time.Now()
-		instance.Paths.Generated.EnsureTmpDir()
+
+		// the scenes and markers are found in the same order when the job
+		// continues after yielding, so those before the saved position are
+		// skipped
+		resumeFrom := j.resumePosition()
 
 		for i, scene := range scenes {
-			s.Status.setProgress(i, total)
-			if s.Status.stopping {
+			if i < resumeFrom {
+				continue
+			}
+
+			j.setProgress(i, total)
+			if j.isStopping() {
 				logger.Info("Stopping due to user request")
 				return
 			}
@@ -428,10 +709,17 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 				continue
 			}
 
+			if !j.checkpoint(i) {
+				wg.Wait()
+				return
+			}
+
 			if input.Sprites {
 				task := GenerateSpriteTask{Scene: *scene, Overwrite: overwrite, fileNamingAlgorithm: fileNamingAlgo}
 				wg.Add()
-				go task.Start(&wg)
+				go j.runSubTask("Generating sprite for "+scene.Path, func() {
+					task.Start(&wg)
+				})
 			}
 
 			if input.Previews {
@@ -443,27 +731,45 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 					fileNamingAlgorithm: fileNamingAlgo,
 				}
 				wg.Add()
-				go task.Start(&wg)
+				go j.runSubTask("Generating preview for "+scene.Path, func() {
+					task.Start(&wg)
+				})
 			}
 
 			if input.Markers {
 				wg.Add()
 				task := GenerateMarkersTask{Scene: scene, Overwrite: overwrite, fileNamingAlgorithm: fileNamingAlgo}
-				go task.Start(&wg)
+				go j.runSubTask("Generating markers for "+scene.Path, func() {
+					task.Start(&wg)
+				})
 			}
 
 			if input.Transcodes {
 				wg.Add()
 				task := GenerateTranscodeTask{Scene: *scene, Overwrite: overwrite, fileNamingAlgorithm: fileNamingAlgo}
-				go task.Start(&wg)
+				go j.runSubTask("Generating transcode for "+scene.Path, func() {
+					task.Start(&wg)
+				})
+			}
+
+			if generatePhash {
+				wg.Add()
+				task := GeneratePhashTask{Scene: *scene, Overwrite: overwrite, fileNamingAlgorithm: fileNamingAlgo}
+				go j.runSubTask("Generating phash for "+scene.Path, func() {
+					task.Start(&wg)
+				})
 			}
 		}
 
 		wg.Wait()
 
 		for i, marker := range markers {
-			s.Status.setProgress(lenScenes+i, total)
-			if s.Status.stopping {
+			if lenScenes+i < resumeFrom {
+				continue
+			}
+
+			j.setProgress(lenScenes+i, total)
+			if j.isStopping() {
 				logger.Info("Stopping due to user request")
 				return
 			}
@@ -473,6 +779,11 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 				continue
 			}
 
+			if !j.checkpoint(lenScenes + i) {
+				wg.Wait()
+				return
+			}
+
 			wg.Add()
 			task := GenerateMarkersTask{Marker: marker, Overwrite: overwrite, fileNamingAlgorithm: fileNamingAlgo}
 			go task.Start(&wg)
@@ -480,33 +791,84 @@ func (s *singleton) Generate(input models.GenerateMetadataInput) {
 
 		wg.Wait()
 
-		instance.Paths.Generated.EmptyTmpDir()
 		elapsed := time.Since(start)
 		logger.Info(fmt.Sprintf("Generate finished (%s)", elapsed))
-	}()
+	}).ID
 }
 
-func (s *singleton) GenerateDefaultScreenshot(sceneId string) {
-	s.generateScreenshot(sceneId, nil)
+// CheckGenerated queues a job which deletes the invalid previews and sprites
+// of the scenes, such as those left empty or truncated by interrupted tasks,
+// and queues their regeneration. Returns the job ID.
+func (s *singleton) CheckGenerated() int {
+	return s.JobQueue.add(CheckGenerated, func(j *Job) {
+		qb := models.NewSceneQueryBuilder()
+		scenes, err := qb.All()
+		if err != nil {
+			logger.Errorf("failed to fetch list of scenes for checking generated files: %s", err.Error())
+			return
+		}
+
+		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+		var sprites, previews, imagePreviews []string
+		for i, scene := range scenes {
+			j.setProgress(i, len(scenes))
+			if j.isStopping() {
+				logger.Info("Stopping due to user request")
+				return
+			}
+
+			sceneHash := scene.GetHash(fileNamingAlgo)
+			if sceneHash == "" {
+				continue
+			}
+
+			id := strconv.Itoa(scene.ID)
+			invalidImage := removeInvalidGeneratedFile(instance.Paths.Scene.GetSpriteImageFilePath(sceneHash))
+			invalidVTT := removeInvalidGeneratedFile(instance.Paths.Scene.GetSpriteVttFilePath(sceneHash))
+			if invalidImage || invalidVTT {
+				sprites = append(sprites, id)
+			}
+
+			// image previews are generated by the preview task, which also
+			// regenerates the video preview if it is invalid
+			invalidVideo := removeInvalidGeneratedFile(instance.Paths.Scene.GetStreamPreviewPath(sceneHash))
+			if removeInvalidGeneratedFile(instance.Paths.Scene.GetStreamPreviewImagePath(sceneHash)) {
+				imagePreviews = append(imagePreviews, id)
+			} else if invalidVideo {
+				previews = append(previews, id)
+			}
+		}
+
+		if len(sprites) > 0 {
+			logger.Infof("Regenerating the invalid sprites of %d scenes", len(sprites))
+			s.Generate(models.GenerateMetadataInput{Sprites: true, SceneIDs: sprites})
+		}
+		if len(previews) > 0 {
+			logger.Infof("Regenerating the invalid previews of %d scenes", len(previews))
+			s.Generate(models.GenerateMetadataInput{Previews: true, SceneIDs: previews})
+		}
+		if len(imagePreviews) > 0 {
+			logger.Infof("Regenerating the invalid image previews of %d scenes", len(imagePreviews))
+			s.Generate(models.GenerateMetadataInput{Previews: true, ImagePreviews: true, SceneIDs: imagePreviews})
+		}
+	}).ID
 }
 
-func (s *singleton) GenerateScreenshot(sceneId string, at float64) {
-	s.generateScreenshot(sceneId, &at)
+func (s *singleton) GenerateDefaultScreenshot(sceneId string) int {
+	return s.generateScreenshot(sceneId, nil)
 }
 
-// generate default screenshot if at is nil
-func (s *singleton) generateScreenshot(sceneId string, at *float64) {
-	if s.Status.Status != Idle {
-		return
-	}
-	s.Status.SetStatus(Generate)
-	s.Status.indefiniteProgress()
+func (s *singleton) GenerateScreenshot(sceneId string, at float64) int {
+	return s.generateScreenshot(sceneId, &at)
+}
 
+// generate default screenshot if at is nil
+func (s *singleton) generateScreenshot(sceneId string, at *float64) int {
 	qb := models.NewSceneQueryBuilder()
-	instance.Paths.Generated.EnsureTmpDir()
 
-	go func() {
-		defer s.returnToIdleState()
+	return s.JobQueue.add(Generate, func(j *Job) {
+		acquireGeneratedTmpDir()
+		defer releaseGeneratedTmpDir()
 
 		sceneIdInt, err := strconv.Atoi(sceneId)
 		if err != nil {
@@ -533,19 +895,15 @@ func (s *singleton) generateScreenshot(sceneId string, at *float64) {
 		wg.Wait()
 
 		logger.Infof("Generate finished")
-	}()
+	}).ID
 }
 
-func (s *singleton) AutoTag(performerIds []string, studioIds []string, tagIds []string) {
-	if s.Status.Status != Idle {
-		return
-	}
-	s.Status.SetStatus(AutoTag)
-	s.Status.indefiniteProgress()
-
-	go func() {
-		defer s.returnToIdleState()
+func (s *singleton) AutoTag(input models.AutoTagMetadataInput) int {
+	performerIds := input.Performers
+	studioIds := input.Studios
+	tagIds := input.Tags
 
+	return s.JobQueue.add(AutoTag, func(j *Job) {
 		// calculate work load
 		performerCount := len(performerIds)
 		studioCount := len(studioIds)
@@ -577,15 +935,43 @@ func (s *singleton) AutoTag(performerIds []string, studioIds []string, tagIds []
 		}
 
 		total := performerCount + studioCount + tagCount
-		s.Status.setProgress(0, total)
+		j.setProgress(0, total)
 
-		s.autoTagPerformers(performerIds)
-		s.autoTagStudios(studioIds)
-		s.autoTagTags(tagIds)
-	}()
+		opts := newAutoTagOptions(false, input.Paths)
+		s.autoTagPerformers(j, performerIds, opts)
+		s.autoTagStudios(j, studioIds, opts)
+		s.autoTagTags(j, tagIds, opts)
+
+		logger.Info(opts.report.summary(false))
+	}).ID
+}
+
+func newAutoTagOptions(dryRun bool, paths []string) autoTagOptions {
+	ret := autoTagOptions{
+		minConfidence: config.GetAutoTagMinConfidence(),
+		dryRun:        dryRun,
+		report:        &autoTagReport{},
+	}
+
+	if len(paths) > 0 {
+		ret.paths = paths
+	}
+
+	return ret
 }
 
-func (s *singleton) autoTagPerformers(performerIds []string) {
+// AutoTagDryRun returns the report of the matches that AutoTag would make
+// and the ambiguous matches it would skip. No changes are made.
+func (s *singleton) AutoTagDryRun(input models.AutoTagMetadataInput) (string, error) {
+	opts := newAutoTagOptions(true, input.Paths)
+	s.autoTagPerformers(nil, input.Performers, opts)
+	s.autoTagStudios(nil, input.Studios, opts)
+	s.autoTagTags(nil, input.Tags, opts)
+
+	return opts.report.Report(true), nil
+}
+
+func (s *singleton) autoTagPerformers(j *Job, performerIds []string, opts autoTagOptions) {
 	performerQuery := models.NewPerformerQueryBuilder()
 
 	var wg sync.WaitGroup
@@ -615,16 +1001,18 @@ func (s *singleton) autoTagPerformers(performerIds []string) {
 
 		for _, performer := range performers {
 			wg.Add(1)
-			task := AutoTagPerformerTask{performer: performer}
+			task := AutoTagPerformerTask{autoTagOptions: opts, performer: performer}
 			go task.Start(&wg)
 			wg.Wait()
 
-			s.Status.incrementProgress()
+			if j != nil {
+				j.incrementProgress()
+			}
 		}
 	}
 }
 
-func (s *singleton) autoTagStudios(studioIds []string) {
+func (s *singleton) autoTagStudios(j *Job, studioIds []string, opts autoTagOptions) {
 	studioQuery := models.NewStudioQueryBuilder()
 
 	var wg sync.WaitGroup
@@ -654,16 +1042,18 @@ func (s *singleton) autoTagStudios(studioIds []string) {
 
 		for _, studio := range studios {
 			wg.Add(1)
-			task := AutoTagStudioTask{studio: studio}
+			task := AutoTagStudioTask{autoTagOptions: opts, studio: studio}
 			go task.Start(&wg)
 			wg.Wait()
 
-			s.Status.incrementProgress()
+			if j != nil {
+				j.incrementProgress()
+			}
 		}
 	}
 }
 
-func (s *singleton) autoTagTags(tagIds []string) {
+func (s *singleton) autoTagTags(j *Job, tagIds []string, opts autoTagOptions) {
 	tagQuery := models.NewTagQueryBuilder()
 
 	var wg sync.WaitGroup
@@ -693,176 +1083,459 @@ func (s *singleton) autoTagTags(tagIds []string) {
 
 		for _, tag := range tags {
 			wg.Add(1)
-			task := AutoTagTagTask{tag: tag}
+			task := AutoTagTagTask{autoTagOptions: opts, tag: tag}
 			go task.Start(&wg)
 			wg.Wait()
 
-			s.Status.incrementProgress()
+			if j != nil {
+				j.incrementProgress()
+			}
 		}
 	}
 }
 
-func (s *singleton) Clean() {
-	if s.Status.Status != Idle {
-		return
+// Clean queues the removal of the scenes, images and galleries of the
+// libraries of the input whose files are missing or excluded, and returns the
+// job ID. The summary of the removals is the message of the finished job. An
+// error is returned if a library is not found or the trash directory cannot
+// be created.
+func (s *singleton) Clean(input models.CleanMetadataInput) (int, error) {
+	opts, err := newCleanOptions(input)
+	if err != nil {
+		return 0, err
 	}
-	s.Status.SetStatus(Clean)
-	s.Status.indefiniteProgress()
 
+	return s.JobQueue.add(Clean, func(j *Job) {
+		logger.Infof("Starting cleaning of tracked files")
+		if err := s.clean(j, opts); err != nil {
+			logger.Error(err.Error())
+			return
+		}
+
+		cleanProbeCache()
+
+		summary := opts.report.summary(false)
+		j.setMessage(summary)
+		logger.Info(summary)
+		logger.Info("Finished Cleaning")
+	}).ID, nil
+}
+
+// CleanDryRun returns the report of the scenes, images and galleries that
+// Clean would remove. No changes are made.
+func (s *singleton) CleanDryRun(input models.CleanMetadataInput) (string, error) {
+	opts, err := newCleanOptions(input)
+	if err != nil {
+		return "", err
+	}
+	opts.dryRun = true
+
+	if err := s.clean(nil, opts); err != nil {
+		return "", err
+	}
+
+	return opts.report.Report(true), nil
+}
+
+func newCleanOptions(input models.CleanMetadataInput) (cleanOptions, error) {
+	ret := cleanOptions{
+		dryRun: input.DryRun != nil && *input.DryRun,
+		report: &cleanReport{},
+	}
+
+	if len(input.Libraries) > 0 {
+		var err error
+		ret.libraries, err = getLibraries(input.Libraries)
+		if err != nil {
+			return ret, err
+		}
+	}
+
+	if input.TrashPath != nil && *input.TrashPath != "" {
+		ret.trashPath = *input.TrashPath
+		if !ret.dryRun {
+			if err := utils.EnsureDirAll(ret.trashPath); err != nil {
+				return ret, fmt.Errorf("error creating trash directory %s: %s", ret.trashPath, err.Error())
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// clean removes the scenes, images and galleries whose files are missing or
+// excluded, reporting them in opts. j is nil on a dry run.
+func (s *singleton) clean(j *Job, opts cleanOptions) error {
 	qb := models.NewSceneQueryBuilder()
 	iqb := models.NewImageQueryBuilder()
 	gqb := models.NewGalleryQueryBuilder()
-	go func() {
-		defer s.returnToIdleState()
 
-		logger.Infof("Starting cleaning of tracked files")
-		scenes, err := qb.All()
-		if err != nil {
-			logger.Errorf("failed to fetch list of scenes for cleaning")
+	scenes, err := qb.All()
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of scenes for cleaning: %s", err.Error())
+	}
+
+	images, err := iqb.All()
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of images for cleaning: %s", err.Error())
+	}
+
+	galleries, err := gqb.All()
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of galleries for cleaning: %s", err.Error())
+	}
+
+	stopping := func(upTo int, total int) bool {
+		if j == nil {
+			return false
+		}
+
+		j.setProgress(upTo, total)
+		if j.isStopping() {
+			logger.Info("Stopping due to user request")
+			return true
+		}
+		return false
+	}
+
+	var wg sync.WaitGroup
+	total := len(scenes) + len(images) + len(galleries)
+	if stopping(0, total) {
+		return nil
+	}
+
+	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+	for i, scene := range scenes {
+		if stopping(i, total) {
+			return nil
+		}
+
+		if scene == nil {
+			logger.Errorf("nil scene, skipping Clean")
+			continue
+		}
+
+		if opts.libraries != nil && !inLibraries(scene.Path, opts.libraries) {
+			continue
+		}
+
+		wg.Add(1)
+
+		task := CleanTask{cleanOptions: opts, Scene: scene, fileNamingAlgorithm: fileNamingAlgo}
+		go task.Start(&wg)
+		wg.Wait()
+	}
+
+	for i, img := range images {
+		if stopping(len(scenes)+i, total) {
+			return nil
+		}
+
+		if img == nil {
+			logger.Errorf("nil image, skipping Clean")
+			continue
+		}
+
+		if opts.libraries != nil && !inLibraries(img.Path, opts.libraries) {
+			continue
+		}
+
+		wg.Add(1)
+
+		task := CleanTask{cleanOptions: opts, Image: img}
+		go task.Start(&wg)
+		wg.Wait()
+	}
+
+	for i, gallery := range galleries {
+		if stopping(len(scenes)+len(images)+i, total) {
+			return nil
+		}
+
+		if gallery == nil {
+			logger.Errorf("nil gallery, skipping Clean")
+			continue
+		}
+
+		if opts.libraries != nil && !inLibraries(gallery.Path.String, opts.libraries) {
+			continue
+		}
+
+		wg.Add(1)
+
+		task := CleanTask{cleanOptions: opts, Gallery: gallery}
+		go task.Start(&wg)
+		wg.Wait()
+	}
+
+	return nil
+}
+
+func (s *singleton) CleanGenerated(input models.CleanGeneratedInput) int {
+	return s.JobQueue.add(CleanGenerated, func(j *Job) {
+		logger.Infof("Starting cleaning of generated files")
+
+		task := CleanGeneratedTask{
+			DryRun: input.DryRun != nil && *input.DryRun,
+		}
+		if err := task.Start(); err != nil {
+			logger.Errorf("error cleaning generated files: %s", err.Error())
 			return
 		}
 
-		images, err := iqb.All()
-		if err != nil {
-			logger.Errorf("failed to fetch list of images for cleaning")
+		logger.Info("Finished cleaning generated files")
+	}).ID
+}
+
+// CleanGeneratedDryRun returns the report of the generated files that would
+// be deleted by CleanGenerated. No files are deleted.
+func (s *singleton) CleanGeneratedDryRun() (string, error) {
+	task := CleanGeneratedTask{
+		DryRun: true,
+	}
+	if err := task.Start(); err != nil {
+		return "", err
+	}
+
+	return task.Report(), nil
+}
+
+func newSyncTask(input models.SyncInput) SyncTask {
+	ret := SyncTask{
+		URL:            input.URL,
+		ConflictPolicy: models.SyncConflictPolicyMerge,
+		DryRun:         input.DryRun != nil && *input.DryRun,
+	}
+	if input.ConflictPolicy != nil {
+		ret.ConflictPolicy = *input.ConflictPolicy
+	}
+
+	return ret
+}
+
+func (s *singleton) Sync(input models.SyncInput) int {
+	return s.JobQueue.add(Sync, func(j *Job) {
+		logger.Infof("Starting synchronization with %s", input.URL)
+
+		task := newSyncTask(input)
+		task.job = j
+		if err := task.Start(); err != nil {
+			logger.Errorf("error synchronizing with %s: %s", input.URL, err.Error())
 			return
 		}
 
-		galleries, err := gqb.All()
+		logger.Infof("Finished synchronization with %s", input.URL)
+	}).ID
+}
+
+// SyncDryRun returns the report of the changes that Sync would make. No
+// changes are made.
+func (s *singleton) SyncDryRun(input models.SyncInput) (string, error) {
+	task := newSyncTask(input)
+	task.DryRun = true
+	if err := task.Start(); err != nil {
+		return "", err
+	}
+
+	return task.Report(), nil
+}
+
+// BackupDatabase backs up the database into a new file at backupPath, or at
+// the default backup path if it is empty, while the database remains in use.
+func (s *singleton) BackupDatabase(backupPath string) int {
+	if backupPath == "" {
+		backupPath = database.DatabaseBackupPath()
+	}
+
+	return s.JobQueue.add(Backup, func(j *Job) {
+		err := database.Backup(backupPath, func(copied int, total int) {
+			j.setProgress(copied, total)
+		})
 		if err != nil {
-			logger.Errorf("failed to fetch list of galleries for cleaning")
+			logger.Errorf("error backing up database: %s", err.Error())
 			return
 		}
 
-		if s.Status.stopping {
-			logger.Info("Stopping due to user request")
+		logger.Infof("Finished backing up database into %s", backupPath)
+	}).ID
+}
+
+// MigrateHash queues the renaming of the generated files to the naming of
+// the configured hash, and returns the job ID. The renames are recorded, so
+// that the migration can be rolled back with RollbackHashMigration.
+func (s *singleton) MigrateHash() int {
+	return s.JobQueue.add(Migrate, func(j *Job) {
+		migration := newHashMigration(config.GetVideoFileNamingAlgorithm())
+		logger.Infof("Migrating generated files for %s naming hash", migration.To.String())
+
+		if err := s.migrateHash(j, migration, false); err != nil {
+			logger.Error(err.Error())
 			return
 		}
 
-		var wg sync.WaitGroup
-		s.Status.Progress = 0
-		total := len(scenes) + len(images) + len(galleries)
-		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
-		for i, scene := range scenes {
-			s.Status.setProgress(i, total)
-			if s.Status.stopping {
-				logger.Info("Stopping due to user request")
-				return
+		if len(migration.Renames) > 0 {
+			if err := migration.save(); err != nil {
+				logger.Errorf("error saving hash migration %s: %s", migration.Name, err.Error())
 			}
+		}
 
-			if scene == nil {
-				logger.Errorf("nil scene, skipping Clean")
-				continue
-			}
+		summary := migration.summary(false)
+		j.setMessage(summary)
+		logger.Info(summary)
+		logger.Info("Finished migrating")
+	}).ID
+}
 
-			wg.Add(1)
+// MigrateHashDryRun returns the report of the generated files that
+// MigrateHash would rename. No files are renamed.
+func (s *singleton) MigrateHashDryRun() (string, error) {
+	migration := newHashMigration(config.GetVideoFileNamingAlgorithm())
+	if err := s.migrateHash(nil, migration, true); err != nil {
+		return "", err
+	}
 
-			task := CleanTask{Scene: scene, fileNamingAlgorithm: fileNamingAlgo}
-			go task.Start(&wg)
-			wg.Wait()
-		}
+	return migration.Report(true), nil
+}
 
-		for i, img := range images {
-			s.Status.setProgress(len(scenes)+i, total)
-			if s.Status.stopping {
+// migrateHash renames the generated files of the scenes to the naming of the
+// hash of the migration, recording the renames in it. j is nil on a dry run.
+func (s *singleton) migrateHash(j *Job, migration *HashMigration, dryRun bool) error {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.All()
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of scenes for migration: %s", err.Error())
+	}
+
+	var wg sync.WaitGroup
+	total := len(scenes)
+
+	for i, scene := range scenes {
+		if j != nil {
+			j.setProgress(i, total)
+			if j.isStopping() {
 				logger.Info("Stopping due to user request")
-				return
+				return nil
 			}
+		}
 
-			if img == nil {
-				logger.Errorf("nil image, skipping Clean")
-				continue
-			}
+		if scene == nil {
+			logger.Errorf("nil scene, skipping migrate")
+			continue
+		}
 
-			wg.Add(1)
+		wg.Add(1)
 
-			task := CleanTask{Image: img}
-			go task.Start(&wg)
-			wg.Wait()
-		}
+		task := MigrateHashTask{Scene: scene, fileNamingAlgorithm: migration.To, dryRun: dryRun, migration: migration}
+		go task.Start(&wg)
+		wg.Wait()
+	}
+
+	return nil
+}
+
+// RollbackHashMigration sets the naming hash back to the one before the hash
+// migration with the name, and queues the renaming of the files it renamed
+// back to their previous names. Returns the job ID. An error is returned if
+// the migration is not found or was rolled back, or if the previous naming
+// hash cannot be used.
+func (s *singleton) RollbackHashMigration(name string) (int, error) {
+	migration, err := FindHashMigration(name)
+	if err != nil {
+		return 0, err
+	}
+	if migration == nil {
+		return 0, fmt.Errorf("hash migration %s not found", name)
+	}
+	if migration.RolledBack {
+		return 0, fmt.Errorf("hash migration %s is already rolled back", name)
+	}
+
+	if migration.From == models.HashAlgorithmMd5 && !config.IsCalculateMD5() {
+		return 0, errors.New("calculateMD5 must be true if using MD5")
+	}
+	if err := ValidateVideoFileNamingAlgorithm(migration.From); err != nil {
+		return 0, err
+	}
+
+	// generated files are named by the previous hash from now on
+	config.Set(config.VideoFileNamingAlgorithm, migration.From)
+	if err := config.Write(); err != nil {
+		return 0, err
+	}
 
-		for i, gallery := range galleries {
-			s.Status.setProgress(len(scenes)+len(galleries)+i, total)
-			if s.Status.stopping {
+	return s.JobQueue.add(Migrate, func(j *Job) {
+		logger.Infof("Rolling back hash migration %s", name)
+
+		// the renames are undone in reverse order, and only if the renamed
+		// files still exist, so that stopped rollbacks can be run again
+		renames := migration.Renames
+		restored := 0
+		for i := len(renames) - 1; i >= 0; i-- {
+			j.setProgress(len(renames)-1-i, len(renames))
+			if j.isStopping() {
 				logger.Info("Stopping due to user request")
 				return
 			}
 
-			if gallery == nil {
-				logger.Errorf("nil gallery, skipping Clean")
+			r := renames[i]
+			if newExists, _ := utils.FileExists(r.New); !newExists {
+				continue
+			}
+			if oldExists, _ := utils.FileExists(r.Old); oldExists {
+				logger.Warnf("not renaming %s back to %s since it exists", r.New, r.Old)
 				continue
 			}
 
-			wg.Add(1)
+			if err := os.Rename(r.New, r.Old); err != nil {
+				logger.Errorf("error renaming %s back to %s: %s", r.New, r.Old, err.Error())
+				continue
+			}
+			restored++
+		}
 
-			task := CleanTask{Gallery: gallery}
-			go task.Start(&wg)
-			wg.Wait()
+		migration.RolledBack = true
+		if err := migration.save(); err != nil {
+			logger.Errorf("error saving hash migration %s: %s", migration.Name, err.Error())
 		}
 
-		logger.Info("Finished Cleaning")
-	}()
+		summary := fmt.Sprintf("Restored %d of %d generated files to %s naming", restored, len(renames), migration.From)
+		j.setMessage(summary)
+		logger.Info(summary)
+	}).ID, nil
 }
 
-func (s *singleton) MigrateHash() {
-	if s.Status.Status != Idle {
-		return
-	}
-	s.Status.SetStatus(Migrate)
-	s.Status.indefiniteProgress()
-
-	qb := models.NewSceneQueryBuilder()
-
-	go func() {
-		defer s.returnToIdleState()
-
-		fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
-		logger.Infof("Migrating generated files for %s naming hash", fileNamingAlgo.String())
+// MigrateBlobs moves the stored images to the configured blob storage.
+func (s *singleton) MigrateBlobs() int {
+	return s.JobQueue.add(MigrateBlobs, func(j *Job) {
+		logger.Infof("Migrating images to %s storage", config.GetBlobsStorage().String())
 
-		scenes, err := qb.All()
+		refs, err := models.FindBlobsToMigrate()
 		if err != nil {
-			logger.Errorf("failed to fetch list of scenes for migration")
+			logger.Errorf("failed to fetch list of images to migrate: %s", err.Error())
 			return
 		}
 
-		var wg sync.WaitGroup
-		s.Status.Progress = 0
-		total := len(scenes)
-
-		for i, scene := range scenes {
-			s.Status.setProgress(i, total)
-			if s.Status.stopping {
+		total := len(refs)
+		migrated := 0
+		for i, ref := range refs {
+			j.setProgress(i, total)
+			if j.isStopping() {
 				logger.Info("Stopping due to user request")
-				return
+				break
 			}
 
-			if scene == nil {
-				logger.Errorf("nil scene, skipping migrate")
+			if err := database.WithTxn(func(tx *sqlx.Tx) error {
+				return models.MigrateBlob(ref, tx)
+			}); err != nil {
+				logger.Errorf("error migrating %s: %s", ref, err.Error())
 				continue
 			}
 
-			wg.Add(1)
-
-			task := MigrateHashTask{Scene: scene, fileNamingAlgorithm: fileNamingAlgo}
-			go task.Start(&wg)
-			wg.Wait()
+			migrated++
 		}
 
-		logger.Info("Finished migrating")
-	}()
-}
-
-func (s *singleton) returnToIdleState() {
-	if r := recover(); r != nil {
-		logger.Info("recovered from ", r)
-	}
-
-	if s.Status.Status == Generate {
-		instance.Paths.Generated.RemoveTmpDir()
-	}
-	s.Status.SetStatus(Idle)
-	s.Status.indefiniteProgress()
-	s.Status.stopping = false
+		logger.Infof("Finished migrating %d of %d images", migrated, total)
+	}).ID
 }
 
 type totalsGenerate struct {
@@ -871,9 +1544,11 @@ type totalsGenerate struct {
 	imagePreviews int64
 	markers       int64
 	transcodes    int64
+	phashes       int64
 }
 
 func (s *singleton) neededGenerate(scenes []*models.Scene, input models.GenerateMetadataInput) *totalsGenerate {
+	generatePhash := input.Phashes != nil && *input.Phashes
 
 	var totals totalsGenerate
 	const timeout = 90 * time.Second
@@ -943,6 +1618,16 @@ func (s *singleton) neededGenerate(scenes []*models.Scene, input models.Generate
 					totals.transcodes++
 				}
 			}
+
+			if generatePhash {
+				task := GeneratePhashTask{
+					Scene:               *scene,
+					fileNamingAlgorithm: fileNamingAlgo,
+				}
+				if overwrite || task.required() {
+					totals.phashes++
+				}
+			}
 		}
 		//check for timeout
 		select {
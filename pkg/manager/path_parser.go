@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	pathTokenStart = `(?:^|_|[^\w\d])`
+	pathTokenEnd   = `(?:$|_|[^\w\d])`
+	pathDateSep    = `[.\-_ ]`
+)
+
+var (
+	pathYYYYMMDDRE   = regexp.MustCompile(pathTokenStart + `(\d{4})` + pathDateSep + `(\d{2})` + pathDateSep + `(\d{2})` + pathTokenEnd)
+	pathDDMMYYYYRE   = regexp.MustCompile(pathTokenStart + `(\d{2})` + pathDateSep + `(\d{2})` + pathDateSep + `(\d{4})` + pathTokenEnd)
+	pathYYMMDDRE     = regexp.MustCompile(pathTokenStart + `(\d{2})` + pathDateSep + `(\d{2})` + pathDateSep + `(\d{2})` + pathTokenEnd)
+	pathResolutionRE = regexp.MustCompile(`(?i)` + pathTokenStart + `(\d{3,4}p|4k|uhd)` + pathTokenEnd)
+	pathPartRE       = regexp.MustCompile(`(?i)` + pathTokenStart + `(?:part|pt|cd|disc|disk)[.\-_ ]?(\d{1,2})` + pathTokenEnd)
+)
+
+// pathTokens are the values found in a path without querying the database.
+type pathTokens struct {
+	date       string
+	resolution string
+	parts      []int
+}
+
+// parsePathTokens returns the date, resolution and part numbers found in the
+// file name of path. Dates are returned in the YYYY-MM-DD format, and two
+// digit years are assumed to be in the 2000s.
+func parsePathTokens(path string) pathTokens {
+	var ret pathTokens
+
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	if m := pathYYYYMMDDRE.FindStringSubmatch(name); m != nil && validateDate(m[1]+"-"+m[2]+"-"+m[3]) {
+		ret.date = m[1] + "-" + m[2] + "-" + m[3]
+	} else if m := pathDDMMYYYYRE.FindStringSubmatch(name); m != nil && validateDate(m[3]+"-"+m[2]+"-"+m[1]) {
+		ret.date = m[3] + "-" + m[2] + "-" + m[1]
+	} else if m := pathYYMMDDRE.FindStringSubmatch(name); m != nil && validateDate("20"+m[1]+"-"+m[2]+"-"+m[3]) {
+		ret.date = "20" + m[1] + "-" + m[2] + "-" + m[3]
+	}
+
+	if m := pathResolutionRE.FindStringSubmatch(name); m != nil {
+		ret.resolution = strings.ToLower(m[1])
+		if ret.resolution == "4k" || ret.resolution == "uhd" {
+			ret.resolution = "2160p"
+		}
+	}
+
+	// the delimiter after a part number may start the next part, so continue
+	// from the last digit of the part number
+	for remaining := name; ; {
+		loc := pathPartRE.FindStringSubmatchIndex(remaining)
+		if loc == nil {
+			break
+		}
+
+		part, _ := strconv.Atoi(remaining[loc[2]:loc[3]])
+		ret.parts = append(ret.parts, part)
+		remaining = remaining[loc[3]-1:]
+	}
+
+	return ret
+}
+
+// pathMatchesName returns true if name appears in path, using the same
+// matching as the auto tag tasks.
+func pathMatchesName(path string, name string) bool {
+	if name == "" {
+		return false
+	}
+
+	re, err := regexp.Compile("(?i)" + getQueryRegex(regexp.QuoteMeta(name)))
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(path)
+}
+
+// matchPathStudio returns the studio with the longest name appearing in path,
+// or nil if none of the studios appear in path.
+func matchPathStudio(path string, studios []*models.Studio) *models.Studio {
+	var ret *models.Studio
+	for _, s := range studios {
+		if pathMatchesName(path, s.Name.String) && (ret == nil || len(s.Name.String) > len(ret.Name.String)) {
+			ret = s
+		}
+	}
+
+	return ret
+}
+
+// matchPathPerformers returns the performers appearing in path, sorted by
+// name.
+func matchPathPerformers(path string, performers []*models.Performer) []*models.Performer {
+	ret := []*models.Performer{}
+	for _, p := range performers {
+		if pathMatchesName(path, p.Name.String) {
+			ret = append(ret, p)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name.String < ret[j].Name.String
+	})
+
+	return ret
+}
+
+// ParsePath returns the date, studio, performers, resolution and part numbers
+// found in path, which need not be the path of an existing file. The studio
+// and performers are matched against the whole path, the other values against
+// the file name.
+func ParsePath(path string) (*models.ParsePathResult, error) {
+	sqb := models.NewStudioQueryBuilder()
+	studios, err := sqb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	pqb := models.NewPerformerQueryBuilder()
+	performers, err := pqb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := parsePathTokens(path)
+	ret := &models.ParsePathResult{
+		Studio:     matchPathStudio(path, studios),
+		Performers: matchPathPerformers(path, performers),
+		Parts:      tokens.parts,
+	}
+	if ret.Parts == nil {
+		ret.Parts = []int{}
+	}
+	if tokens.date != "" {
+		ret.Date = &tokens.date
+	}
+	if tokens.resolution != "" {
+		ret.Resolution = &tokens.resolution
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var parsePathTokensTests = []struct {
+	path       string
+	date       string
+	resolution string
+	parts      []int
+}{
+	{"/stash/Studio/Studio.2020.05.17.Performer.Name.1080p.mp4", "2020-05-17", "1080p", nil},
+	{"/stash/Studio - 17-05-2020 - Title 4K.mkv", "2020-05-17", "2160p", nil},
+	{"studio.20.05.17.title.part2.720P.mp4", "2020-05-17", "720p", []int{2}},
+	{"Title CD1 CD2.avi", "", "", []int{1, 2}},
+	{"Title pt_3.wmv", "", "", []int{3}},
+	{"2020.13.40 not a date.mp4", "", "", nil},
+	{"title1080p.mp4", "", "", nil},
+	{"/stash/2020.05.17/title.mp4", "", "", nil},
+}
+
+func TestParsePathTokens(t *testing.T) {
+	for _, test := range parsePathTokensTests {
+		tokens := parsePathTokens(test.path)
+		assert.Equal(t, test.date, tokens.date, test.path)
+		assert.Equal(t, test.resolution, tokens.resolution, test.path)
+		assert.Equal(t, test.parts, tokens.parts, test.path)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	studios := []*models.Studio{
+		{ID: 1, Name: sql.NullString{String: "Studio", Valid: true}},
+		{ID: 2, Name: sql.NullString{String: "Studio Plus", Valid: true}},
+		{ID: 3, Name: sql.NullString{String: "Other", Valid: true}},
+	}
+
+	performers := []*models.Performer{
+		{ID: 1, Name: sql.NullString{String: "Second Performer", Valid: true}},
+		{ID: 2, Name: sql.NullString{String: "First Performer", Valid: true}},
+		{ID: 3, Name: sql.NullString{String: "Perf", Valid: true}},
+		{ID: 4, Name: sql.NullString{String: "A.B. (C)", Valid: true}},
+	}
+
+	const path = "/stash/studio_plus/Studio.Plus.2020.05.17.first-performer.second.performer.mp4"
+
+	studio := matchPathStudio(path, studios)
+	if assert.NotNil(t, studio) {
+		assert.Equal(t, 2, studio.ID)
+	}
+	assert.Nil(t, matchPathStudio("/stash/another.mp4", studios))
+
+	matched := matchPathPerformers(path, performers)
+	var ids []int
+	for _, p := range matched {
+		ids = append(ids, p.ID)
+	}
+	assert.Equal(t, []int{2, 1}, ids)
+
+	// names are matched literally
+	assert.Len(t, matchPathPerformers("/stash/A.B. (C) title.mp4", performers), 1)
+	assert.Len(t, matchPathPerformers("/stash/AxB. (C) title.mp4", performers), 0)
+}
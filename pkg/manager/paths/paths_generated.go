@@ -18,8 +18,13 @@ type generatedPaths struct {
 	Vtt         string
 	Markers     string
 	Transcodes  string
-	Downloads   string
-	Tmp         string
+	// TranscodeCache contains cached live transcode output
+	TranscodeCache string
+	Downloads      string
+	Tmp            string
+	// HashMigrations contains the records of the migrations of generated
+	// files between hash namings
+	HashMigrations string
 }
 
 func newGeneratedPaths() *generatedPaths {
@@ -29,8 +34,10 @@ func newGeneratedPaths() *generatedPaths {
 	gp.Vtt = filepath.Join(config.GetGeneratedPath(), "vtt")
 	gp.Markers = filepath.Join(config.GetGeneratedPath(), "markers")
 	gp.Transcodes = filepath.Join(config.GetGeneratedPath(), "transcodes")
+	gp.TranscodeCache = filepath.Join(config.GetGeneratedPath(), "transcode_cache")
 	gp.Downloads = filepath.Join(config.GetGeneratedPath(), "downloads")
 	gp.Tmp = filepath.Join(config.GetGeneratedPath(), "tmp")
+	gp.HashMigrations = filepath.Join(config.GetGeneratedPath(), "hash_migrations")
 	return &gp
 }
 
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// probeVideoFile returns the ffprobe details of the video file. The output of
+// ffprobe is cached by the oshash and size of the file, so that unchanged
+// files are not probed again. The cache is not used if oshash is empty.
+func probeVideoFile(path string, oshash string, stripExt bool) (*ffmpeg.VideoFile, error) {
+	if oshash == "" {
+		return ffmpeg.NewVideoFile(instance.FFProbePath, path, stripExt)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+
+	qb := models.NewProbeCacheQueryBuilder()
+	data, err := qb.Find(oshash, size)
+	if err != nil {
+		logger.Warnf("error reading cached probe output for %s: %s", path, err.Error())
+	}
+
+	if data != nil {
+		videoFile, err := ffmpeg.NewVideoFileFromProbeJSON(path, data, stripExt)
+		if err == nil {
+			return videoFile, nil
+		}
+
+		logger.Warnf("discarding invalid cached probe output for %s: %s", path, err.Error())
+	}
+
+	data, err = ffmpeg.RunProbe(instance.FFProbePath, path)
+	if err != nil {
+		return nil, err
+	}
+
+	videoFile, err := ffmpeg.NewVideoFileFromProbeJSON(path, data, stripExt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		return qb.Set(oshash, size, data, tx)
+	}); err != nil {
+		logger.Warnf("error caching probe output for %s: %s", path, err.Error())
+	}
+
+	return videoFile, nil
+}
+
+// cleanProbeCache deletes the cached probe output of files that no longer
+// belong to a scene.
+func cleanProbeCache() {
+	var deleted int64
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		qb := models.NewProbeCacheQueryBuilder()
+		var err error
+		deleted, err = qb.DestroyUnused(tx)
+		return err
+	}); err != nil {
+		logger.Errorf("error cleaning probe cache: %s", err.Error())
+		return
+	}
+
+	if deleted > 0 {
+		logger.Infof("Deleted %d unused probe cache entries", deleted)
+	}
+}
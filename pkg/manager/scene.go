@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 
@@ -49,89 +50,139 @@ func DestroyScene(sceneID int, tx *sqlx.Tx) error {
 	return nil
 }
 
-// DeleteGeneratedSceneFiles deletes generated files for the provided scene.
-func DeleteGeneratedSceneFiles(scene *models.Scene, fileNamingAlgo models.HashAlgorithm) {
+// DeleteGeneratedSceneFiles deletes generated files for the provided scene,
+// which are its screenshots, previews, sprite, transcode and marker
+// previews. Returns an error listing the files which could not be deleted.
+func DeleteGeneratedSceneFiles(scene *models.Scene, fileNamingAlgo models.HashAlgorithm) error {
+	return removeGeneratedSceneFiles(scene, fileNamingAlgo, "")
+}
+
+// removeGeneratedSceneFiles deletes the generated files of the scene, or
+// moves them into trashPath if it is not empty. Returns an error listing the
+// files which could not be removed.
+func removeGeneratedSceneFiles(scene *models.Scene, fileNamingAlgo models.HashAlgorithm, trashPath string) error {
 	sceneHash := scene.GetHash(fileNamingAlgo)
+	transcodePath := instance.Paths.Scene.GetTranscodePath(sceneHash)
+
+	var failed []string
+	for _, path := range ExistingGeneratedSceneFiles(scene, fileNamingAlgo) {
+		if path == transcodePath {
+			// kill any running streams
+			KillRunningStreams(transcodePath)
+		}
+
+		if err := removeGeneratedFile(path, trashPath); err != nil {
+			logger.Warnf("Could not delete %s: %s", path, err.Error())
+			failed = append(failed, path)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not delete %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
 
+// generatedSceneFilePaths returns the paths of the generated files of the
+// scene: the directory of its marker previews, its screenshots, previews,
+// transcode and sprite.
+func generatedSceneFilePaths(scene *models.Scene, fileNamingAlgo models.HashAlgorithm) []string {
+	sceneHash := scene.GetHash(fileNamingAlgo)
 	if sceneHash == "" {
-		return
+		return nil
 	}
 
-	markersFolder := filepath.Join(GetInstance().Paths.Generated.Markers, sceneHash)
+	return []string{
+		filepath.Join(instance.Paths.Generated.Markers, sceneHash),
+		instance.Paths.Scene.GetThumbnailScreenshotPath(sceneHash),
+		instance.Paths.Scene.GetScreenshotPath(sceneHash),
+		instance.Paths.Scene.GetStreamPreviewPath(sceneHash),
+		instance.Paths.Scene.GetStreamPreviewImagePath(sceneHash),
+		instance.Paths.Scene.GetTranscodePath(sceneHash),
+		instance.Paths.Scene.GetSpriteImageFilePath(sceneHash),
+		instance.Paths.Scene.GetSpriteVttFilePath(sceneHash),
+	}
+}
 
-	exists, _ := utils.FileExists(markersFolder)
-	if exists {
-		err := os.RemoveAll(markersFolder)
-		if err != nil {
-			logger.Warnf("Could not delete folder %s: %s", markersFolder, err.Error())
+// ExistingGeneratedSceneFiles returns the paths of the generated files of the
+// scene which exist, which DeleteGeneratedSceneFiles deletes.
+func ExistingGeneratedSceneFiles(scene *models.Scene, fileNamingAlgo models.HashAlgorithm) []string {
+	return existingFiles(generatedSceneFilePaths(scene, fileNamingAlgo))
+}
+
+// ExistingSceneMarkerFiles returns the paths of the generated files of the
+// scene marker at seconds which exist, which DeleteSceneMarkerFiles deletes.
+func ExistingSceneMarkerFiles(scene *models.Scene, seconds int, fileNamingAlgo models.HashAlgorithm) []string {
+	sceneHash := scene.GetHash(fileNamingAlgo)
+	return existingFiles([]string{
+		instance.Paths.SceneMarkers.GetStreamPath(sceneHash, seconds),
+		instance.Paths.SceneMarkers.GetStreamPreviewImagePath(sceneHash, seconds),
+	})
+}
+
+func existingFiles(paths []string) []string {
+	var ret []string
+	for _, path := range paths {
+		if exists, _ := utils.FileExists(path); exists {
+			ret = append(ret, path)
 		}
 	}
+	return ret
+}
 
-	thumbPath := GetInstance().Paths.Scene.GetThumbnailScreenshotPath(sceneHash)
-	exists, _ = utils.FileExists(thumbPath)
-	if exists {
-		err := os.Remove(thumbPath)
-		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", thumbPath, err.Error())
-		}
+// removeGeneratedFile deletes the generated file or directory at path, or
+// moves it into trashPath if it is not empty. Moved files keep their path
+// relative to the generated directory, so that generated files of different
+// kinds with the same name don't overwrite each other.
+func removeGeneratedFile(path string, trashPath string) error {
+	if trashPath == "" {
+		return os.RemoveAll(path)
 	}
 
-	normalPath := GetInstance().Paths.Scene.GetScreenshotPath(sceneHash)
-	exists, _ = utils.FileExists(normalPath)
-	if exists {
-		err := os.Remove(normalPath)
-		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", normalPath, err.Error())
-		}
+	rel, err := filepath.Rel(config.GetGeneratedPath(), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
 	}
 
-	streamPreviewPath := GetInstance().Paths.Scene.GetStreamPreviewPath(sceneHash)
-	exists, _ = utils.FileExists(streamPreviewPath)
-	if exists {
-		err := os.Remove(streamPreviewPath)
-		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", streamPreviewPath, err.Error())
-		}
+	dest := filepath.Join(trashPath, rel)
+	if err := utils.EnsureDirAll(filepath.Dir(dest)); err != nil {
+		return err
 	}
 
-	streamPreviewImagePath := GetInstance().Paths.Scene.GetStreamPreviewImagePath(sceneHash)
-	exists, _ = utils.FileExists(streamPreviewImagePath)
-	if exists {
-		err := os.Remove(streamPreviewImagePath)
-		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", streamPreviewImagePath, err.Error())
-		}
+	// replace the files of a previous clean of the same scene
+	if err := os.RemoveAll(dest); err != nil {
+		return err
 	}
 
-	transcodePath := GetInstance().Paths.Scene.GetTranscodePath(sceneHash)
-	exists, _ = utils.FileExists(transcodePath)
-	if exists {
-		// kill any running streams
-		KillRunningStreams(transcodePath)
+	if err := os.Rename(path, dest); err == nil {
+		return nil
+	}
 
-		err := os.Remove(transcodePath)
+	// the trash may be on another device, in which case the files are
+	// copied
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", transcodePath, err.Error())
+			return err
 		}
-	}
 
-	spritePath := GetInstance().Paths.Scene.GetSpriteImageFilePath(sceneHash)
-	exists, _ = utils.FileExists(spritePath)
-	if exists {
-		err := os.Remove(spritePath)
+		prel, err := filepath.Rel(path, p)
 		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", spritePath, err.Error())
+			return err
 		}
-	}
+		pdest := filepath.Join(dest, prel)
 
-	vttPath := GetInstance().Paths.Scene.GetSpriteVttFilePath(sceneHash)
-	exists, _ = utils.FileExists(vttPath)
-	if exists {
-		err := os.Remove(vttPath)
-		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", vttPath, err.Error())
+		if info.IsDir() {
+			return utils.EnsureDirAll(pdest)
 		}
+
+		return utils.SafeMove(p, pdest)
+	})
+	if err != nil {
+		return err
 	}
+
+	return os.RemoveAll(path)
 }
 
 // DeleteSceneMarkerFiles deletes generated files for a scene marker with the
@@ -158,7 +209,7 @@ func DeleteSceneMarkerFiles(scene *models.Scene, seconds int, fileNamingAlgo mod
 }
 
 // DeleteSceneFile deletes the scene video file from the filesystem.
-func DeleteSceneFile(scene *models.Scene) {
+func DeleteSceneFile(scene *models.Scene) error {
 	// kill any running encoders
 	KillRunningStreams(scene.Path)
 
@@ -166,6 +217,8 @@ func DeleteSceneFile(scene *models.Scene) {
 	if err != nil {
 		logger.Warnf("Could not delete file %s: %s", scene.Path, err.Error())
 	}
+
+	return err
 }
 
 func GetSceneFileContainer(scene *models.Scene) (ffmpeg.Container, error) {
@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+)
+
+// sceneCaptionsFromDir returns the caption sidecar files of the scene file in
+// its directory, ordered by file name.
+func sceneCaptionsFromDir(scenePath string) ([]models.SceneCaption, error) {
+	files, err := ioutil.ReadDir(filepath.Dir(scenePath))
+	if err != nil {
+		return nil, err
+	}
+
+	videoFilename := filepath.Base(scenePath)
+
+	var ret []models.SceneCaption
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		languageCode, captionType, ok := scene.ParseCaptionFilename(videoFilename, f.Name())
+		if ok {
+			ret = append(ret, models.SceneCaption{
+				Filename:     f.Name(),
+				LanguageCode: languageCode,
+				CaptionType:  captionType,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+func sceneCaptionsEqual(a []*models.SceneCaption, b []models.SceneCaption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Filename != b[i].Filename || a[i].LanguageCode != b[i].LanguageCode || a[i].CaptionType != b[i].CaptionType {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scanCaptions stores the caption sidecar files of the scene, if they changed
+// since they were last stored. Errors are logged.
+func scanCaptions(s *models.Scene) {
+	captions, err := sceneCaptionsFromDir(s.Path)
+	if err != nil {
+		logger.Errorf("error reading captions of %s: %s", s.Path, err.Error())
+		return
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	existing, err := qb.GetCaptions(s.ID, nil)
+	if err != nil {
+		logger.Errorf("error getting captions of %s: %s", s.Path, err.Error())
+		return
+	}
+
+	if sceneCaptionsEqual(existing, captions) {
+		return
+	}
+
+	logger.Infof("Updating captions of %s", s.Path)
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		return qb.UpdateCaptions(s.ID, captions, tx)
+	}); err != nil {
+		logger.Errorf("error updating captions of %s: %s", s.Path, err.Error())
+	}
+}
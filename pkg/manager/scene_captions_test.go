@@ -0,0 +1,173 @@
+// +build integration
+
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanCaptions(t *testing.T) {
+	const name = "TestScanCaptions"
+
+	dir, err := ioutil.TempDir("", "stash-captions-")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"video.mp4", "video.srt", "video.en.vtt", "video.part2.srt", "other.srt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatalf("Error writing file: %s", err.Error())
+		}
+	}
+
+	qb := models.NewSceneQueryBuilder()
+
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	scene, err := qb.Create(models.Scene{
+		Path:     filepath.Join(dir, "video.mp4"),
+		Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating scene: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+
+	scanCaptions(scene)
+
+	captions, err := qb.GetCaptions(scene.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting captions: %s", err.Error())
+	}
+
+	if assert.Len(t, captions, 2) {
+		assert.Equal(t, models.SceneCaption{
+			SceneID:      scene.ID,
+			Filename:     "video.en.vtt",
+			LanguageCode: "en",
+			CaptionType:  "vtt",
+		}, *captions[0])
+		assert.Equal(t, models.SceneCaption{
+			SceneID:     scene.ID,
+			Filename:    "video.srt",
+			CaptionType: "srt",
+		}, *captions[1])
+	}
+
+	// removed caption files are removed from the scene
+	if err := os.Remove(filepath.Join(dir, "video.srt")); err != nil {
+		t.Fatalf("Error removing file: %s", err.Error())
+	}
+
+	scanCaptions(scene)
+
+	captions, err = qb.GetCaptions(scene.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting captions: %s", err.Error())
+	}
+
+	if assert.Len(t, captions, 1) {
+		assert.Equal(t, "video.en.vtt", captions[0].Filename)
+	}
+}
+
+func TestMoveSceneFileCaptions(t *testing.T) {
+	const name = "TestMoveSceneFileCaptions"
+
+	dir, err := ioutil.TempDir("", "stash-captions-")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []string{"video.mp4", "video.en.srt", "existing.mp4"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644); err != nil {
+			t.Fatalf("Error writing file: %s", err.Error())
+		}
+	}
+
+	qb := models.NewSceneQueryBuilder()
+
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	scene, err := qb.Create(models.Scene{
+		Path:     filepath.Join(dir, "video.mp4"),
+		Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating scene: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+
+	defer func() {
+		tx := database.DB.MustBeginTx(ctx, nil)
+		if err := qb.Destroy(strconv.Itoa(scene.ID), tx); err != nil {
+			tx.Rollback()
+			t.Fatalf("Error destroying scene: %s", err.Error())
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Error committing: %s", err.Error())
+		}
+	}()
+
+	scanCaptions(scene)
+
+	// existing files are not overwritten, and nothing is moved
+	_, err = MoveSceneFile(ctx, scene, filepath.Join(dir, "existing.mp4"))
+	assert.Error(t, err)
+	for f, contents := range map[string]string{"video.mp4": "video.mp4", "video.en.srt": "video.en.srt", "existing.mp4": "existing.mp4"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, f))
+		assert.NoError(t, err)
+		assert.Equal(t, contents, string(data))
+	}
+
+	newPath := filepath.Join(dir, "moved", "new.mp4")
+	updated, err := MoveSceneFile(ctx, scene, newPath)
+	if err != nil {
+		t.Fatalf("Error moving scene file: %s", err.Error())
+	}
+	assert.Equal(t, newPath, updated.Path)
+
+	// the caption files are moved and renamed with the scene file
+	for f, contents := range map[string]string{"new.mp4": "video.mp4", "new.en.srt": "video.en.srt"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "moved", f))
+		assert.NoError(t, err)
+		assert.Equal(t, contents, string(data))
+	}
+	for _, f := range []string{"video.mp4", "video.en.srt"} {
+		_, err := os.Stat(filepath.Join(dir, f))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	captions, err := qb.GetCaptions(scene.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting captions: %s", err.Error())
+	}
+
+	if assert.Len(t, captions, 1) {
+		assert.Equal(t, "new.en.srt", captions[0].Filename)
+		assert.Equal(t, "en", captions[0].LanguageCode)
+	}
+}
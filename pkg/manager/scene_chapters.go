@@ -0,0 +1,174 @@
+package manager
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// sceneChaptersFromVideoFile returns the chapters embedded in the video file.
+func sceneChaptersFromVideoFile(videoFile *ffmpeg.VideoFile) []models.SceneChapter {
+	var ret []models.SceneChapter
+	for _, c := range videoFile.Chapters {
+		ret = append(ret, models.SceneChapter{
+			Seconds:    c.Start,
+			EndSeconds: sql.NullFloat64{Float64: c.End, Valid: c.End > c.Start},
+			Title:      c.Title,
+		})
+	}
+
+	return ret
+}
+
+// ReadSceneChapters reads the chapters embedded in the scene file and stores
+// them, replacing the previously stored chapters of the scene.
+func ReadSceneChapters(scene *models.Scene) ([]*models.SceneChapter, error) {
+	videoFile, err := probeVideoFile(scene.Path, scene.OSHash.String, false)
+	if err != nil {
+		return nil, fmt.Errorf("error reading chapters of %s: %s", scene.Path, err.Error())
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		return qb.UpdateChapters(scene.ID, sceneChaptersFromVideoFile(videoFile), tx)
+	}); err != nil {
+		return nil, err
+	}
+
+	return qb.GetChapters(scene.ID, nil)
+}
+
+// ChapterMarkerTag returns the primary tag of the markers created from
+// chapters, which is created if it does not exist.
+func ChapterMarkerTag(tx *sqlx.Tx) (*models.Tag, error) {
+	name := config.GetChapterMarkerTag()
+	if name == "" {
+		return nil, fmt.Errorf("chapter marker tag is not set")
+	}
+
+	qb := models.NewTagQueryBuilder()
+	tag, err := qb.FindByName(name, tx, true)
+	if err != nil || tag != nil {
+		return tag, err
+	}
+
+	return qb.Create(*models.NewTag(name), tx)
+}
+
+// CreateChapterMarkers creates markers of the scene from the chapters, with
+// the primary tag and the additional tags. Chapters at the time of an
+// existing marker of the scene are skipped, and untitled chapters are titled
+// by their number.
+func CreateChapterMarkers(sceneID int, chapters []*models.SceneChapter, primaryTagID int, tagIDs []int, tx *sqlx.Tx) ([]*models.SceneMarker, error) {
+	qb := models.NewSceneMarkerQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	existing, err := qb.FindBySceneID(sceneID, tx)
+	if err != nil {
+		return nil, err
+	}
+	existingSeconds := make(map[int]bool)
+	for _, m := range existing {
+		existingSeconds[int(m.Seconds)] = true
+	}
+
+	currentTime := time.Now()
+	ret := []*models.SceneMarker{}
+	for i, c := range chapters {
+		if existingSeconds[int(c.Seconds)] {
+			continue
+		}
+
+		title := c.Title
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+
+		marker, err := qb.Create(models.SceneMarker{
+			Title:        title,
+			Seconds:      c.Seconds,
+			PrimaryTagID: primaryTagID,
+			SceneID:      sql.NullInt64{Int64: int64(sceneID), Valid: true},
+			CreatedAt:    models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:    models.SQLiteTimestamp{Timestamp: currentTime},
+		}, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		var markerTagJoins []models.SceneMarkersTags
+		for _, tagID := range tagIDs {
+			if tagID == primaryTagID {
+				continue
+			}
+			markerTagJoins = append(markerTagJoins, models.SceneMarkersTags{
+				SceneMarkerID: marker.ID,
+				TagID:         tagID,
+			})
+		}
+		if err := jqb.CreateSceneMarkersTags(markerTagJoins, tx); err != nil {
+			return nil, err
+		}
+
+		existingSeconds[int(c.Seconds)] = true
+		ret = append(ret, marker)
+	}
+
+	return ret, nil
+}
+
+// createChapterMarkers creates markers of the scene from its stored chapters,
+// with the chapter marker tag as their primary tag.
+func createChapterMarkers(sceneID int, tx *sqlx.Tx) ([]*models.SceneMarker, error) {
+	qb := models.NewSceneQueryBuilder()
+	chapters, err := qb.GetChapters(sceneID, tx)
+	if err != nil || len(chapters) == 0 {
+		return nil, err
+	}
+
+	tag, err := ChapterMarkerTag(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateChapterMarkers(sceneID, chapters, tag.ID, nil, tx)
+}
+
+// scanChapterMarkers creates markers of the scanned scene from its stored
+// chapters. Errors are logged, since they must not fail the scan.
+func scanChapterMarkers(scene *models.Scene) {
+	var markers []*models.SceneMarker
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		var err error
+		markers, err = createChapterMarkers(scene.ID, tx)
+		return err
+	}); err != nil {
+		logger.Errorf("error creating markers from the chapters of %s: %s", scene.Path, err.Error())
+		return
+	}
+
+	if len(markers) > 0 {
+		logger.Infof("Created %d markers from the chapters of %s", len(markers), scene.Path)
+	}
+	publishMarkersCreated(markers)
+}
+
+func publishMarkersCreated(markers []*models.SceneMarker) {
+	if len(markers) == 0 {
+		return
+	}
+
+	var ids []int
+	for _, m := range markers {
+		ids = append(ids, m.ID)
+	}
+	event.Publish(event.Event{Entity: event.EntitySceneMarker, Action: event.ActionCreate, IDs: ids})
+}
@@ -0,0 +1,98 @@
+// +build integration
+
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateChapterMarkers(t *testing.T) {
+	// the tag must not be the first tag, which the auto tag tests use
+	const name = "TestCreateChapterMarkers"
+	config.Set(config.ChapterMarkerTag, name)
+	defer config.Set(config.ChapterMarkerTag, config.DefaultChapterMarkerTag)
+
+	qb := models.NewSceneQueryBuilder()
+
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	scene, err := qb.Create(models.Scene{
+		Path:     name + ".mp4",
+		Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating scene: %s", err.Error())
+	}
+
+	chapters := []models.SceneChapter{
+		{Seconds: 0, Title: "Opening"},
+		{Seconds: 60},
+		{Seconds: 120, Title: "Existing"},
+	}
+	if err := qb.UpdateChapters(scene.ID, chapters, tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error updating scene chapters: %s", err.Error())
+	}
+
+	tag, err := ChapterMarkerTag(tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error getting chapter marker tag: %s", err.Error())
+	}
+	assert.Equal(t, name, tag.Name)
+
+	// chapters at the time of an existing marker are skipped
+	mqb := models.NewSceneMarkerQueryBuilder()
+	_, err = mqb.Create(models.SceneMarker{
+		Title:        "Existing",
+		Seconds:      120.4,
+		PrimaryTagID: tag.ID,
+		SceneID:      sql.NullInt64{Int64: int64(scene.ID), Valid: true},
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating scene marker: %s", err.Error())
+	}
+
+	markers, err := createChapterMarkers(scene.ID, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating chapter markers: %s", err.Error())
+	}
+
+	if assert.Len(t, markers, 2) {
+		assert.Equal(t, "Opening", markers[0].Title)
+		assert.Equal(t, "Chapter 2", markers[1].Title)
+		assert.Equal(t, 60.0, markers[1].Seconds)
+		assert.Equal(t, tag.ID, markers[1].PrimaryTagID)
+	}
+
+	// the existing tag is used and the created markers are not duplicated
+	markers, err = createChapterMarkers(scene.ID, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating chapter markers: %s", err.Error())
+	}
+	assert.Len(t, markers, 0)
+
+	existingTag, err := ChapterMarkerTag(tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error getting chapter marker tag: %s", err.Error())
+	}
+	assert.Equal(t, tag.ID, existingTag.ID)
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// inferSceneDate returns the date of the first source in priority with a
+// candidate date, and the source. Returns an empty date if none of the
+// sources have a candidate.
+func inferSceneDate(candidates map[models.DateInferenceSource]string, priority []models.DateInferenceSource) (string, models.DateInferenceSource) {
+	for _, source := range priority {
+		if date := candidates[source]; date != "" {
+			return date, source
+		}
+	}
+
+	return "", ""
+}
+
+// sceneDateCandidates returns the dates of the scene inferred from each of
+// the sources in priority.
+func sceneDateCandidates(scene *models.Scene, priority []models.DateInferenceSource) (map[models.DateInferenceSource]string, error) {
+	ret := make(map[models.DateInferenceSource]string)
+
+	for _, source := range priority {
+		switch source {
+		case models.DateInferenceSourceFilename:
+			ret[source] = parsePathTokens(scene.Path).date
+		case models.DateInferenceSourceFileCreationTime:
+			if scene.FileCreationTime.Valid {
+				ret[source] = scene.FileCreationTime.Timestamp.In(utils.GetTimezone()).Format("2006-01-02")
+			}
+		case models.DateInferenceSourceMovie:
+			mqb := models.NewMovieQueryBuilder()
+			movies, err := mqb.FindBySceneID(scene.ID, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, m := range movies {
+				if m.Date.Valid && (ret[source] == "" || m.Date.String < ret[source]) {
+					ret[source] = m.Date.String
+				}
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// suggestSceneDate stores the date inferred for the scene as a suggestion,
+// replacing the previous suggestion. The previous suggestion is removed if no
+// date is inferred.
+func suggestSceneDate(scene *models.Scene, priority []models.DateInferenceSource) (bool, error) {
+	candidates, err := sceneDateCandidates(scene, priority)
+	if err != nil {
+		return false, err
+	}
+
+	date, source := inferSceneDate(candidates, priority)
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	qb := models.NewScrapeHistoryQueryBuilder()
+
+	if err := qb.ClearResults(models.ScrapeHistoryEntityScene, scene.ID, models.ScrapeHistorySourceDateInference, tx); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if date != "" {
+		data, err := json.Marshal(models.SceneDateInference{
+			Date:   date,
+			Source: source,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return false, err
+		}
+
+		newHistory := models.ScrapeHistory{
+			Entity:    models.ScrapeHistoryEntityScene,
+			EntityID:  scene.ID,
+			Source:    models.ScrapeHistorySourceDateInference,
+			Fields:    "date",
+			Result:    sql.NullString{String: string(data), Valid: true},
+			CreatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+		}
+
+		if _, err := qb.Create(newHistory, tx); err != nil {
+			_ = tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return date != "", nil
+}
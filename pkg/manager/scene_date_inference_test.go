@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var sceneDateCandidatesTest = map[models.DateInferenceSource]string{
+	models.DateInferenceSourceFilename:         "",
+	models.DateInferenceSourceMovie:            "2019-03-02",
+	models.DateInferenceSourceFileCreationTime: "2020-05-17",
+}
+
+var inferSceneDateTests = []struct {
+	priority       []models.DateInferenceSource
+	expectedDate   string
+	expectedSource models.DateInferenceSource
+}{
+	{[]models.DateInferenceSource{models.DateInferenceSourceFilename, models.DateInferenceSourceMovie, models.DateInferenceSourceFileCreationTime}, "2019-03-02", models.DateInferenceSourceMovie},
+	{[]models.DateInferenceSource{models.DateInferenceSourceFileCreationTime, models.DateInferenceSourceMovie}, "2020-05-17", models.DateInferenceSourceFileCreationTime},
+	{[]models.DateInferenceSource{models.DateInferenceSourceFilename}, "", ""},
+	{nil, "", ""},
+}
+
+func TestInferSceneDate(t *testing.T) {
+	for _, test := range inferSceneDateTests {
+		date, source := inferSceneDate(sceneDateCandidatesTest, test.priority)
+		assert.Equal(t, test.expectedDate, date, test.priority)
+		assert.Equal(t, test.expectedSource, source, test.priority)
+	}
+}
@@ -0,0 +1,313 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+var (
+	sceneFilenameTokenRE = regexp.MustCompile(`\{(\w+)\}`)
+	// invalidFilenameCharsRE matches the characters which are not allowed in
+	// file names on some platforms
+	invalidFilenameCharsRE = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	filenameWhitespaceRE   = regexp.MustCompile(`\s+`)
+)
+
+// sceneFilenameTokens are the tokens of scene file name templates.
+var sceneFilenameTokens = map[string]bool{
+	"title":      true,
+	"date":       true,
+	"yyyy":       true,
+	"mm":         true,
+	"dd":         true,
+	"studio":     true,
+	"performers": true,
+}
+
+// filenameSegmentTrim are the characters trimmed from the ends of the
+// directory and file names made from templates, such as the separators left
+// by empty tokens.
+const filenameSegmentTrim = " -_.,"
+
+// ValidateSceneFilenameTemplate returns an error if the scene file name
+// template is empty, is not relative, or has unknown tokens.
+func ValidateSceneFilenameTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("scene filename template is empty")
+	}
+
+	if strings.HasPrefix(template, "/") || filepath.IsAbs(template) {
+		return fmt.Errorf("scene filename template %q is not relative", template)
+	}
+
+	for _, segment := range strings.Split(template, "/") {
+		if segment == ".." {
+			return fmt.Errorf("scene filename template %q leaves the library directory", template)
+		}
+	}
+
+	for _, m := range sceneFilenameTokenRE.FindAllStringSubmatch(template, -1) {
+		if !sceneFilenameTokens[m[1]] {
+			return fmt.Errorf("unknown token %s in scene filename template", m[0])
+		}
+	}
+
+	return nil
+}
+
+// formatSceneFilename returns the path given by the template with the tokens
+// replaced by their values, followed by ext. Directories are separated by /
+// in the template. Directories which are empty after the replacement are
+// left out.
+func formatSceneFilename(template string, values map[string]string, ext string) (string, error) {
+	if err := ValidateSceneFilenameTemplate(template); err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(template, "/") {
+		segment = sceneFilenameTokenRE.ReplaceAllStringFunc(segment, func(token string) string {
+			value := values[token[1:len(token)-1]]
+			return invalidFilenameCharsRE.ReplaceAllString(value, "")
+		})
+
+		segment = filenameWhitespaceRE.ReplaceAllString(segment, " ")
+		segments = append(segments, strings.Trim(segment, filenameSegmentTrim))
+	}
+
+	name := segments[len(segments)-1]
+	if name == "" {
+		return "", fmt.Errorf("scene filename template %q gives an empty file name", template)
+	}
+
+	var dirs []string
+	for _, dir := range segments[:len(segments)-1] {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return filepath.Join(append(dirs, name+ext)...), nil
+}
+
+// sceneFilenameValues returns the values of the file name template tokens of
+// the scene.
+func sceneFilenameValues(scene *models.Scene) (map[string]string, error) {
+	ret := map[string]string{
+		"title": utils.DisplayTitle(scene.Title.String, scene.Path),
+	}
+
+	if scene.Date.Valid && len(scene.Date.String) == len("2006-01-02") {
+		ret["date"] = scene.Date.String
+		ret["yyyy"] = scene.Date.String[0:4]
+		ret["mm"] = scene.Date.String[5:7]
+		ret["dd"] = scene.Date.String[8:10]
+	}
+
+	if scene.StudioID.Valid {
+		sqb := models.NewStudioQueryBuilder()
+		studio, err := sqb.Find(int(scene.StudioID.Int64), nil)
+		if err != nil {
+			return nil, err
+		}
+		if studio != nil {
+			ret["studio"] = studio.Name.String
+		}
+	}
+
+	pqb := models.NewPerformerQueryBuilder()
+	performers, err := pqb.FindBySceneID(scene.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, p := range performers {
+		if p.Name.String != "" {
+			names = append(names, p.Name.String)
+		}
+	}
+	ret["performers"] = strings.Join(names, ", ")
+
+	return ret, nil
+}
+
+// GetSceneFilename returns the path to which the file of the scene is moved
+// with the template. The path is within the library containing the file, and
+// keeps its extension. An error is returned if moving the file there would
+// overwrite another file.
+func GetSceneFilename(scene *models.Scene, template string) (string, error) {
+	stash := getStashFromPath(scene.Path)
+	if stash == nil {
+		return "", fmt.Errorf("%s is not in a library", scene.Path)
+	}
+
+	values, err := sceneFilenameValues(scene)
+	if err != nil {
+		return "", err
+	}
+
+	filename, err := formatSceneFilename(template, values, filepath.Ext(scene.Path))
+	if err != nil {
+		return "", err
+	}
+
+	newPath := filepath.Join(stash.Path, filename)
+	if newPath != scene.Path {
+		if err := checkSceneFileDestination(scene, newPath); err != nil {
+			return "", err
+		}
+	}
+
+	return newPath, nil
+}
+
+// checkSceneFileDestination returns an error if the file of the scene cannot
+// be moved to newPath without overwriting another file or the file of
+// another scene.
+func checkSceneFileDestination(scene *models.Scene, newPath string) error {
+	if err := checkFileDestination(scene.Path, newPath); err != nil {
+		return err
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	existing, err := qb.FindByPath(newPath)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != scene.ID {
+		return fmt.Errorf("%s is the path of scene %d", newPath, existing.ID)
+	}
+
+	return nil
+}
+
+// checkFileDestination returns an error if the file at oldPath cannot be
+// moved to newPath without overwriting another file.
+func checkFileDestination(oldPath string, newPath string) error {
+	info, err := os.Stat(newPath)
+	if err == nil {
+		// renames changing only the case of the name find the file itself on
+		// case-insensitive filesystems
+		oldInfo, oldErr := os.Stat(oldPath)
+		if oldErr != nil || !os.SameFile(info, oldInfo) {
+			return fmt.Errorf("refusing to overwrite existing file %s", newPath)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+type fileMove struct {
+	from string
+	to   string
+}
+
+// undoFileMoves moves the moved files back, in reverse order. It returns err,
+// with the errors moving the files back appended.
+func undoFileMoves(moves []fileMove, err error) error {
+	msg := err.Error()
+	for i := len(moves) - 1; i >= 0; i-- {
+		m := moves[i]
+		if moveErr := utils.MoveNoReplace(m.to, m.from); moveErr != nil {
+			msg += fmt.Sprintf("; error moving %s back to %s: %s", m.to, m.from, moveErr.Error())
+		}
+	}
+
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
+
+// movedCaptionFilename returns the name of the caption file with the name of
+// the scene file at oldPath once the scene file is moved to newPath. The
+// name of the scene file is replaced, keeping the language code and type.
+func movedCaptionFilename(oldPath string, newPath string, filename string) string {
+	oldBase := filepath.Base(oldPath)
+	newBase := filepath.Base(newPath)
+	oldPrefix := strings.TrimSuffix(oldBase, filepath.Ext(oldBase))
+	newPrefix := strings.TrimSuffix(newBase, filepath.Ext(newBase))
+	return newPrefix + strings.TrimPrefix(filename, oldPrefix)
+}
+
+// MoveSceneFile moves the file of the scene and its caption files to newPath
+// and updates the paths of the scene and its captions. The files are moved
+// before the paths are updated, so that no transaction is held while they
+// are copied between filesystems, and are moved back if the paths cannot be
+// updated. Existing files are never overwritten. Captions whose files no
+// longer exist are removed.
+func MoveSceneFile(ctx context.Context, scene *models.Scene, newPath string) (*models.Scene, error) {
+	if newPath == scene.Path {
+		return scene, nil
+	}
+
+	if err := checkSceneFileDestination(scene, newPath); err != nil {
+		return nil, err
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	captions, err := qb.GetCaptions(scene.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := []fileMove{{from: scene.Path, to: newPath}}
+	var newCaptions []models.SceneCaption
+	for _, c := range captions {
+		moved := *c
+		moved.Filename = movedCaptionFilename(scene.Path, newPath, c.Filename)
+		move := fileMove{from: c.Path(scene.Path), to: moved.Path(newPath)}
+
+		if _, err := os.Stat(move.from); os.IsNotExist(err) {
+			continue
+		}
+		if err := checkFileDestination(move.from, move.to); err != nil {
+			return nil, err
+		}
+
+		newCaptions = append(newCaptions, moved)
+		moves = append(moves, move)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return nil, err
+	}
+
+	for i, m := range moves {
+		if err := utils.MoveNoReplace(m.from, m.to); err != nil {
+			return nil, undoFileMoves(moves[:i], err)
+		}
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	updated, err := qb.Update(models.ScenePartial{
+		ID:   scene.ID,
+		Path: &newPath,
+	}, tx)
+	if err == nil {
+		err = qb.UpdateCaptions(scene.ID, newCaptions, tx)
+	}
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		_ = tx.Rollback()
+	}
+
+	if err != nil {
+		return nil, undoFileMoves(moves, fmt.Errorf("error updating the path of scene %d to %s: %s", scene.ID, newPath, err.Error()))
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sceneFilenameValuesTest = map[string]string{
+	"title":      "Title: Part 1/2",
+	"date":       "2020-05-17",
+	"yyyy":       "2020",
+	"mm":         "05",
+	"dd":         "17",
+	"studio":     "Studio",
+	"performers": "Alice, Bob",
+}
+
+var formatSceneFilenameTests = []struct {
+	template string
+	values   map[string]string
+	expected string
+}{
+	{"{studio}/{date} {title} - {performers}", sceneFilenameValuesTest, "Studio/2020-05-17 Title Part 12 - Alice, Bob.mp4"},
+	{"{studio}/{yyyy}/{mm}.{dd} {title}", sceneFilenameValuesTest, "Studio/2020/05.17 Title Part 12.mp4"},
+	{"{studio}/{date} {title} - {performers}", map[string]string{"title": "Title"}, "Title.mp4"},
+	{"Library/{title}", map[string]string{"title": "  spaced \t title "}, "Library/spaced title.mp4"},
+}
+
+func TestFormatSceneFilename(t *testing.T) {
+	for _, test := range formatSceneFilenameTests {
+		filename, err := formatSceneFilename(test.template, test.values, ".mp4")
+		if assert.Nil(t, err, test.template) {
+			assert.Equal(t, filepath.FromSlash(test.expected), filename, test.template)
+		}
+	}
+}
+
+func TestFormatSceneFilenameEmpty(t *testing.T) {
+	_, err := formatSceneFilename("{studio}/{performers}", map[string]string{"studio": "Studio"}, ".mp4")
+	assert.NotNil(t, err)
+
+	_, err = formatSceneFilename("{studio}/", sceneFilenameValuesTest, ".mp4")
+	assert.NotNil(t, err)
+}
+
+func TestValidateSceneFilenameTemplate(t *testing.T) {
+	assert.Nil(t, ValidateSceneFilenameTemplate("{studio}/{date} {title}"))
+	assert.NotNil(t, ValidateSceneFilenameTemplate(""))
+	assert.NotNil(t, ValidateSceneFilenameTemplate("/{title}"))
+	assert.NotNil(t, ValidateSceneFilenameTemplate("../{title}"))
+	assert.NotNil(t, ValidateSceneFilenameTemplate("{studio}/{rating}"))
+}
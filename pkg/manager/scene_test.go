@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestDeleteSceneFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-scene-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	generated := filepath.Join(dir, "generated")
+	defer config.Set(config.Generated, config.GetGeneratedPath())
+	config.Set(config.Generated, generated)
+
+	previous := instance
+	defer func() { instance = previous }()
+	instance = &singleton{Paths: paths.NewPaths()}
+
+	writeTestFiles(t, dir, []string{
+		"videos/scene.mp4",
+		"videos/other.mp4",
+		"generated/screenshots/abc.jpg",
+		"generated/screenshots/abc.mp4",
+		"generated/screenshots/abc.webp",
+		"generated/vtt/abc_sprite.jpg",
+		"generated/vtt/abc_thumbs.vtt",
+		"generated/markers/abc/10.mp4",
+		"generated/screenshots/def.jpg",
+	})
+
+	scene := &models.Scene{
+		ID:       1,
+		Path:     filepath.Join(dir, "videos", "scene.mp4"),
+		Checksum: sql.NullString{String: "abc", Valid: true},
+	}
+
+	assert.Nil(t, DeleteGeneratedSceneFiles(scene, models.HashAlgorithmMd5))
+	assert.Nil(t, DeleteSceneFile(scene))
+
+	// the files of other scenes are kept
+	assert.Equal(t, []string{
+		"generated/screenshots/def.jpg",
+		"videos/other.mp4",
+	}, listTestFiles(t, dir))
+
+	// deleting missing files fails
+	assert.NotNil(t, DeleteSceneFile(scene))
+}
@@ -19,16 +19,23 @@ func ValidateModifyStudio(studio models.StudioPartial, tx *sqlx.Tx) error {
 
 	currentParentID := *studio.ParentID
 
-	for currentParentID.Valid {
+	// stop at existing cycles not including this studio
+	visited := make(map[int64]bool)
+	for currentParentID.Valid && !visited[currentParentID.Int64] {
 		if currentParentID.Int64 == int64(thisID) {
 			return errors.New("studio cannot be an ancestor of itself")
 		}
+		visited[currentParentID.Int64] = true
 
 		currentStudio, err := qb.Find(int(currentParentID.Int64), tx)
 		if err != nil {
 			return fmt.Errorf("error finding parent studio: %s", err.Error())
 		}
 
+		if currentStudio == nil {
+			return fmt.Errorf("parent studio with id %d not found", currentParentID.Int64)
+		}
+
 		currentParentID = currentStudio.ParentID
 	}
 
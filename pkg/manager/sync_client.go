@@ -0,0 +1,74 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/graphql"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// syncPageSize is the number of scenes queried from the other instance at a
+// time.
+const syncPageSize = 100
+
+type syncRemoteTag struct {
+	UUID string `graphql:"uuid"`
+	Name string `graphql:"name"`
+}
+
+type syncRemoteScene struct {
+	ID        string          `graphql:"id"`
+	UUID      string          `graphql:"uuid"`
+	Checksum  *string         `graphql:"checksum"`
+	OSHash    *string         `graphql:"oshash"`
+	Rating100 *int            `graphql:"rating100"`
+	Organized bool            `graphql:"organized"`
+	UpdatedAt time.Time       `graphql:"updated_at"`
+	Tags      []syncRemoteTag `graphql:"tags"`
+}
+
+type syncFindScenesResultType struct {
+	Count  int               `graphql:"count"`
+	Scenes []syncRemoteScene `graphql:"scenes"`
+}
+
+// syncClient queries the scenes of another stash instance through its
+// GraphQL API.
+type syncClient struct {
+	client *graphql.Client
+}
+
+func newSyncClient(url string) *syncClient {
+	return &syncClient{
+		client: graphql.NewClient(strings.TrimSuffix(url, "/")+"/graphql", nil),
+	}
+}
+
+// findScenes returns the page of the scenes of the other instance, ordered by
+// id, and the total number of its scenes. Pages start at 1.
+func (c *syncClient) findScenes(ctx context.Context, page int) (int, []syncRemoteScene, error) {
+	var q struct {
+		FindScenes syncFindScenesResultType `graphql:"findScenes(filter: $f)"`
+	}
+
+	perPage := syncPageSize
+	sort := "id"
+	direction := models.SortDirectionEnumAsc
+	vars := map[string]interface{}{
+		"f": models.FindFilterType{
+			Page:      &page,
+			PerPage:   &perPage,
+			Sort:      &sort,
+			Direction: &direction,
+		},
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return 0, nil, err
+	}
+
+	return q.FindScenes.Count, q.FindScenes.Scenes, nil
+}
@@ -2,9 +2,11 @@ package manager
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func EnsureTagNameUnique(tag models.Tag, tx *sqlx.Tx) error {
@@ -21,5 +23,67 @@ func EnsureTagNameUnique(tag models.Tag, tx *sqlx.Tx) error {
 		return fmt.Errorf("Tag with name '%s' already exists", tag.Name)
 	}
 
+	// ensure name is not an alias of another tag
+	sameAliasTag, err := qb.FindByAlias(tag.Name, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if sameAliasTag != nil && tag.ID != sameAliasTag.ID {
+		return fmt.Errorf("Name '%s' is an alias of tag '%s'", tag.Name, sameAliasTag.Name)
+	}
+
+	return nil
+}
+
+// EnsureTagHierarchyValid returns an error if the tag is its own parent or
+// child, directly or through other tags.
+func EnsureTagHierarchyValid(tag models.Tag, tx *sqlx.Tx) error {
+	qb := models.NewTagQueryBuilder()
+
+	descendantIDs, err := qb.FindDescendantIDs(tag.ID, tx)
+	if err != nil {
+		return err
+	}
+
+	if utils.IntInclude(descendantIDs, tag.ID) {
+		return fmt.Errorf("Tag '%s' cannot be its own parent or child", tag.Name)
+	}
+
+	return nil
+}
+
+// EnsureTagAliasesUnique returns an error if any of the aliases is the name
+// of a tag or the alias of another tag, regardless of case, or if the aliases
+// repeat an alias.
+func EnsureTagAliasesUnique(tagID int, aliases []string, tx *sqlx.Tx) error {
+	qb := models.NewTagQueryBuilder()
+
+	seen := make(map[string]bool)
+	for _, alias := range aliases {
+		key := strings.ToLower(alias)
+		if seen[key] {
+			return fmt.Errorf("Alias '%s' is repeated", alias)
+		}
+		seen[key] = true
+
+		sameNameTag, err := qb.FindByName(alias, tx, true)
+		if err != nil {
+			return err
+		}
+		if sameNameTag != nil {
+			return fmt.Errorf("Alias '%s' is the name of tag '%s'", alias, sameNameTag.Name)
+		}
+
+		sameAliasTag, err := qb.FindByAlias(alias, tx)
+		if err != nil {
+			return err
+		}
+		if sameAliasTag != nil && sameAliasTag.ID != tagID {
+			return fmt.Errorf("Alias '%s' is an alias of tag '%s'", alias, sameAliasTag.Name)
+		}
+	}
+
 	return nil
 }
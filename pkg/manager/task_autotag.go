@@ -9,9 +9,11 @@ import (
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/performer"
 )
 
 type AutoTagPerformerTask struct {
+	autoTagOptions
 	performer *models.Performer
 }
 
@@ -21,30 +23,47 @@ func (t *AutoTagPerformerTask) Start(wg *sync.WaitGroup) {
 	t.autoTagPerformer()
 }
 
-func getQueryRegex(name string) string {
+// getNameRegex returns the regex matching name in a path, in which its words
+// may be separated by path separators.
+func getNameRegex(name string) string {
 	const separatorChars = `.\-_ `
 	// handle path separators
 	const separator = `[` + separatorChars + `]`
 
-	ret := strings.Replace(name, " ", separator+"*", -1)
-	ret = `(?:^|_|[^\w\d])` + ret + `(?:$|_|[^\w\d])`
-	return ret
+	return strings.Replace(name, " ", separator+"*", -1)
+}
+
+func getQueryRegex(name string) string {
+	return `(?:^|_|[^\w\d])` + getNameRegex(name) + `(?:$|_|[^\w\d])`
+}
+
+// getNamesQueryRegex returns the regex matching any of the names.
+func getNamesQueryRegex(names []string) string {
+	var regexes []string
+	for _, name := range names {
+		regexes = append(regexes, getQueryRegex(name))
+	}
+	return strings.Join(regexes, "|")
 }
 
 func (t *AutoTagPerformerTask) autoTagPerformer() {
 	qb := models.NewSceneQueryBuilder()
 	jqb := models.NewJoinsQueryBuilder()
 
-	regex := getQueryRegex(t.performer.Name.String)
+	// match the aliases of the performer as well as their name
+	names := append([]string{t.performer.Name.String}, performer.SplitAliases(t.performer.Aliases.String)...)
+	regex := getNamesQueryRegex(names)
 
 	const ignoreOrganized = true
-	scenes, err := qb.QueryAllByPathRegex(regex, ignoreOrganized)
+	scenes, err := qb.QueryAllByPathOrTitleRegex(regex, ignoreOrganized)
 
 	if err != nil {
 		logger.Infof("Error querying scenes with regex '%s': %s", regex, err.Error())
 		return
 	}
 
+	scenes = t.filterScenes("performer", names, t.performer.AutoTagMinConfidence, scenes)
+
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
@@ -59,16 +78,18 @@ func (t *AutoTagPerformerTask) autoTagPerformer() {
 
 		if added {
 			logger.Infof("Added performer '%s' to scene '%s'", t.performer.Name.String, scene.GetTitle())
+			t.report.addMatch("Added performer '%s' to '%s'", t.performer.Name.String, scene.Path)
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := t.finish(tx); err != nil {
 		logger.Infof("Error adding performer to scene: %s", err.Error())
 		return
 	}
 }
 
 type AutoTagStudioTask struct {
+	autoTagOptions
 	studio *models.Studio
 }
 
@@ -84,13 +105,15 @@ func (t *AutoTagStudioTask) autoTagStudio() {
 	regex := getQueryRegex(t.studio.Name.String)
 
 	const ignoreOrganized = true
-	scenes, err := qb.QueryAllByPathRegex(regex, ignoreOrganized)
+	scenes, err := qb.QueryAllByPathOrTitleRegex(regex, ignoreOrganized)
 
 	if err != nil {
 		logger.Infof("Error querying scenes with regex '%s': %s", regex, err.Error())
 		return
 	}
 
+	scenes = t.filterScenes("studio", []string{t.studio.Name.String}, t.studio.AutoTagMinConfidence, scenes)
+
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
@@ -102,6 +125,7 @@ func (t *AutoTagStudioTask) autoTagStudio() {
 		}
 
 		logger.Infof("Adding studio '%s' to scene '%s'", t.studio.Name.String, scene.GetTitle())
+		t.report.addMatch("Added studio '%s' to '%s'", t.studio.Name.String, scene.Path)
 
 		// set the studio id
 		studioID := sql.NullInt64{Int64: int64(t.studio.ID), Valid: true}
@@ -119,13 +143,14 @@ func (t *AutoTagStudioTask) autoTagStudio() {
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := t.finish(tx); err != nil {
 		logger.Infof("Error adding studio to scene: %s", err.Error())
 		return
 	}
 }
 
 type AutoTagTagTask struct {
+	autoTagOptions
 	tag *models.Tag
 }
 
@@ -138,17 +163,28 @@ func (t *AutoTagTagTask) Start(wg *sync.WaitGroup) {
 func (t *AutoTagTagTask) autoTagTag() {
 	qb := models.NewSceneQueryBuilder()
 	jqb := models.NewJoinsQueryBuilder()
+	tqb := models.NewTagQueryBuilder()
 
-	regex := getQueryRegex(t.tag.Name)
+	// match the aliases of the tag as well as its name
+	aliases, err := tqb.GetAliases(t.tag.ID, nil)
+	if err != nil {
+		logger.Infof("Error getting aliases of tag '%s': %s", t.tag.Name, err.Error())
+		return
+	}
+
+	names := append([]string{t.tag.Name}, aliases...)
+	regex := getNamesQueryRegex(names)
 
 	const ignoreOrganized = true
-	scenes, err := qb.QueryAllByPathRegex(regex, ignoreOrganized)
+	scenes, err := qb.QueryAllByPathOrTitleRegex(regex, ignoreOrganized)
 
 	if err != nil {
 		logger.Infof("Error querying scenes with regex '%s': %s", regex, err.Error())
 		return
 	}
 
+	scenes = t.filterScenes("tag", names, t.tag.AutoTagMinConfidence, scenes)
+
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
@@ -163,10 +199,11 @@ func (t *AutoTagTagTask) autoTagTag() {
 
 		if added {
 			logger.Infof("Added tag '%s' to scene '%s'", t.tag.Name, scene.GetTitle())
+			t.report.addMatch("Added tag '%s' to '%s'", t.tag.Name, scene.Path)
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := t.finish(tx); err != nil {
 		logger.Infof("Error adding tag to scene: %s", err.Error())
 		return
 	}
@@ -2,10 +2,12 @@ package manager
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/image"
@@ -14,7 +16,92 @@ import (
 	"github.com/stashapp/stash/pkg/models"
 )
 
+// cleanOptions are the options shared by the clean tasks of a Clean job.
+type cleanOptions struct {
+	// libraries are the libraries to clean, or nil for all libraries
+	libraries []*models.StashConfig
+	// dryRun only reports the removals without making them
+	dryRun bool
+	// trashPath is the directory which the generated files of removed
+	// scenes and images are moved to, or empty to delete them
+	trashPath string
+	// report collects the removals, if not nil
+	report *cleanReport
+}
+
+// cleanReport collects the scenes, images and galleries removed by the clean
+// tasks.
+type cleanReport struct {
+	mutex     sync.Mutex
+	// paths of the removed scenes, images and galleries
+	scenes    []string
+	images    []string
+	galleries []string
+}
+
+func (r *cleanReport) addScene(path string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.scenes = append(r.scenes, path)
+}
+
+func (r *cleanReport) addImage(path string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.images = append(r.images, path)
+}
+
+func (r *cleanReport) addGallery(path string) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.galleries = append(r.galleries, path)
+}
+
+func cleanVerb(dryRun bool) string {
+	if dryRun {
+		return "[dry run] Would remove"
+	}
+	return "Removed"
+}
+
+func (r *cleanReport) summary(dryRun bool) string {
+	return fmt.Sprintf("%s %d scenes, %d images and %d galleries", cleanVerb(dryRun), len(r.scenes), len(r.images), len(r.galleries))
+}
+
+// Report returns the summary of the clean tasks followed by the scenes,
+// images and galleries removed, one per line.
+func (r *cleanReport) Report(dryRun bool) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	verb := cleanVerb(dryRun)
+	lines := []string{r.summary(dryRun)}
+	addLines := func(kind string, paths []string) {
+		for _, path := range paths {
+			lines = append(lines, fmt.Sprintf("%s %s '%s'", verb, kind, path))
+		}
+	}
+	addLines("scene", r.scenes)
+	addLines("image", r.images)
+	addLines("gallery", r.galleries)
+
+	return strings.Join(lines, "\n")
+}
+
 type CleanTask struct {
+	cleanOptions
 	Scene               *models.Scene
 	Gallery             *models.Gallery
 	Image               *models.Image
@@ -25,15 +112,24 @@ func (t *CleanTask) Start(wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	if t.Scene != nil && t.shouldCleanScene(t.Scene) {
-		t.deleteScene(t.Scene.ID)
+		t.report.addScene(t.Scene.Path)
+		if !t.dryRun {
+			t.deleteScene(t.Scene.ID)
+		}
 	}
 
 	if t.Gallery != nil && t.shouldCleanGallery(t.Gallery) {
-		t.deleteGallery(t.Gallery.ID)
+		t.report.addGallery(t.Gallery.Path.String)
+		if !t.dryRun {
+			t.deleteGallery(t.Gallery.ID)
+		}
 	}
 
 	if t.Image != nil && t.shouldCleanImage(t.Image) {
-		t.deleteImage(t.Image.ID)
+		t.report.addImage(t.Image.Path)
+		if !t.dryRun {
+			t.deleteImage(t.Image.ID)
+		}
 	}
 }
 
@@ -65,7 +161,7 @@ func (t *CleanTask) shouldCleanScene(s *models.Scene) bool {
 		return true
 	}
 
-	if matchFile(s.Path, config.GetExcludes()) {
+	if matchFile(s.Path, libraryExcludes(stash)) {
 		logger.Infof("File matched regex. Cleaning: \"%s\"", s.Path)
 		return true
 	}
@@ -74,6 +170,10 @@ func (t *CleanTask) shouldCleanScene(s *models.Scene) bool {
 }
 
 func (t *CleanTask) shouldCleanGallery(g *models.Gallery) bool {
+	if isFolderGallery(g) {
+		return t.shouldCleanFolderGallery(g)
+	}
+
 	// never clean manually created galleries
 	if !g.Zip {
 		return false
@@ -95,7 +195,7 @@ func (t *CleanTask) shouldCleanGallery(g *models.Gallery) bool {
 		return true
 	}
 
-	if matchFile(path, config.GetImageExcludes()) {
+	if matchFile(path, libraryImageExcludes(stash)) {
 		logger.Infof("File matched regex. Cleaning: \"%s\"", path)
 		return true
 	}
@@ -108,6 +208,26 @@ func (t *CleanTask) shouldCleanGallery(g *models.Gallery) bool {
 	return false
 }
 
+func (t *CleanTask) shouldCleanFolderGallery(g *models.Gallery) bool {
+	path := g.Path.String
+	if t.shouldClean(path) {
+		return true
+	}
+
+	stash := getStashFromPath(path)
+	if stash.ExcludeImage {
+		logger.Infof("Folder in stash library that excludes images. Cleaning: \"%s\"", path)
+		return true
+	}
+
+	if countImagesInFolder(path) == 0 {
+		logger.Infof("Gallery folder has 0 images. Cleaning: \"%s\"", path)
+		return true
+	}
+
+	return false
+}
+
 func (t *CleanTask) shouldCleanImage(s *models.Image) bool {
 	if t.shouldClean(s.Path) {
 		return true
@@ -124,7 +244,7 @@ func (t *CleanTask) shouldCleanImage(s *models.Image) bool {
 		return true
 	}
 
-	if matchFile(s.Path, config.GetImageExcludes()) {
+	if matchFile(s.Path, libraryImageExcludes(stash)) {
 		logger.Infof("File matched regex. Cleaning: \"%s\"", s.Path)
 		return true
 	}
@@ -151,7 +271,8 @@ func (t *CleanTask) deleteScene(sceneID int) {
 		return
 	}
 
-	DeleteGeneratedSceneFiles(scene, t.fileNamingAlgorithm)
+	// failures are logged when removing the files
+	_ = removeGeneratedSceneFiles(scene, t.fileNamingAlgorithm, t.trashPath)
 }
 
 func (t *CleanTask) deleteGallery(galleryID int) {
@@ -176,9 +297,21 @@ func (t *CleanTask) deleteGallery(galleryID int) {
 func (t *CleanTask) deleteImage(imageID int) {
 	ctx := context.TODO()
 	qb := models.NewImageQueryBuilder()
+	gqb := models.NewGalleryQueryBuilder()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
-	err := qb.Destroy(imageID, tx)
+	galleries, err := gqb.FindByImageID(imageID, tx)
+	if err == nil {
+		err = qb.Destroy(imageID, tx)
+	}
+
+	// the folder galleries of the image are updated when it is removed
+	updatedAt := models.SQLiteTimestamp{Timestamp: time.Now()}
+	for _, g := range galleries {
+		if err == nil && isFolderGallery(g) {
+			_, err = gqb.UpdatePartial(models.GalleryPartial{ID: g.ID, UpdatedAt: &updatedAt}, tx)
+		}
+	}
 
 	if err != nil {
 		logger.Errorf("Error deleting image from database: %s", err.Error())
@@ -191,7 +324,7 @@ func (t *CleanTask) deleteImage(imageID int) {
 		return
 	}
 
-	pathErr := os.Remove(GetInstance().Paths.Generated.GetThumbnailPath(t.Image.Checksum, models.DefaultGthumbWidth)) // remove cache dir of gallery
+	pathErr := removeGeneratedFile(GetInstance().Paths.Generated.GetThumbnailPath(t.Image.Checksum, models.DefaultGthumbWidth), t.trashPath) // remove cache dir of gallery
 	if pathErr != nil {
 		logger.Errorf("Error deleting thumbnail image from cache: %s", pathErr)
 	}
@@ -0,0 +1,224 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// generated scene file suffixes, by generated directory. The longest suffix
+// must come first.
+var (
+	screenshotSuffixes = []string{".thumb.jpg", ".jpg", ".mp4", ".webp"}
+	vttSuffixes        = []string{"_sprite.jpg", "_thumbs.vtt"}
+	transcodeSuffixes  = []string{".mp4"}
+)
+
+// CleanGeneratedTask deletes generated files belonging to scenes and images
+// that are no longer in the database.
+type CleanGeneratedTask struct {
+	DryRun bool
+
+	sceneHashes    map[string]bool
+	imageChecksums map[string]bool
+
+	fileCount int
+	totalSize int64
+	// paths are the deleted files and marker directories
+	paths []string
+}
+
+func (t *CleanGeneratedTask) Start() error {
+	if err := t.loadHashes(); err != nil {
+		return err
+	}
+
+	generated := instance.Paths.Generated
+
+	t.cleanFiles(generated.Screenshots, screenshotSuffixes)
+	t.cleanFiles(generated.Vtt, vttSuffixes)
+	t.cleanFiles(generated.Transcodes, transcodeSuffixes)
+	t.cleanMarkers(generated.Markers)
+	t.cleanThumbnails(generated.Thumbnails)
+
+	logger.Info(t.summary())
+
+	return nil
+}
+
+func (t *CleanGeneratedTask) summary() string {
+	verb := "Deleted"
+	if t.DryRun {
+		verb = "[dry run] Would delete"
+	}
+	return fmt.Sprintf("%s %d orphaned generated files (%s)", verb, t.fileCount, formatFileSize(t.totalSize))
+}
+
+// Report returns the summary of the task followed by the deleted files and
+// marker directories, one per line.
+func (t *CleanGeneratedTask) Report() string {
+	return strings.Join(append([]string{t.summary()}, t.paths...), "\n")
+}
+
+func (t *CleanGeneratedTask) loadHashes() error {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.All()
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of scenes: %s", err.Error())
+	}
+
+	// keep files named by either hash, since the naming algorithm may have
+	// changed without migrating the generated files
+	t.sceneHashes = make(map[string]bool)
+	for _, scene := range scenes {
+		if scene.Checksum.Valid {
+			t.sceneHashes[scene.Checksum.String] = true
+		}
+		if scene.OSHash.Valid {
+			t.sceneHashes[scene.OSHash.String] = true
+		}
+	}
+
+	iqb := models.NewImageQueryBuilder()
+	images, err := iqb.All()
+	if err != nil {
+		return fmt.Errorf("failed to fetch list of images: %s", err.Error())
+	}
+
+	t.imageChecksums = make(map[string]bool)
+	for _, image := range images {
+		t.imageChecksums[image.Checksum] = true
+	}
+
+	return nil
+}
+
+// cleanFiles deletes the files in dir named with one of the suffixes that do
+// not belong to a scene. Files not matching a suffix are left alone.
+func (t *CleanGeneratedTask) cleanFiles(dir string, suffixes []string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("error reading %s: %s", dir, err.Error())
+		}
+		return
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		hash := trimGeneratedSuffix(f.Name(), suffixes)
+		if hash == "" || t.sceneHashes[hash] {
+			continue
+		}
+
+		t.deleteFile(filepath.Join(dir, f.Name()), f.Size())
+	}
+}
+
+// cleanMarkers deletes the marker directories, named by scene hash, that do
+// not belong to a scene.
+func (t *CleanGeneratedTask) cleanMarkers(dir string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("error reading %s: %s", dir, err.Error())
+		}
+		return
+	}
+
+	for _, f := range files {
+		if !f.IsDir() || t.sceneHashes[f.Name()] {
+			continue
+		}
+
+		markerDir := filepath.Join(dir, f.Name())
+		_ = filepath.Walk(markerDir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				t.fileCount++
+				t.totalSize += info.Size()
+			}
+			return nil
+		})
+
+		t.paths = append(t.paths, markerDir)
+		logger.Debugf("deleting orphaned generated directory %s", markerDir)
+		if !t.DryRun {
+			if err := os.RemoveAll(markerDir); err != nil {
+				logger.Warnf("error deleting %s: %s", markerDir, err.Error())
+			}
+		}
+	}
+}
+
+// cleanThumbnails deletes the image thumbnails, named <checksum>_<width>.jpg,
+// that do not belong to an image.
+func (t *CleanGeneratedTask) cleanThumbnails(dir string) {
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		checksum := trimGeneratedSuffix(info.Name(), []string{".jpg"})
+		if i := strings.LastIndex(checksum, "_"); i > 0 {
+			checksum = checksum[:i]
+		} else {
+			return nil
+		}
+
+		if !t.imageChecksums[checksum] {
+			t.deleteFile(path, info.Size())
+		}
+
+		return nil
+	})
+}
+
+func (t *CleanGeneratedTask) deleteFile(path string, size int64) {
+	t.fileCount++
+	t.totalSize += size
+	t.paths = append(t.paths, path)
+
+	logger.Debugf("deleting orphaned generated file %s", path)
+	if t.DryRun {
+		return
+	}
+
+	if err := os.Remove(path); err != nil {
+		logger.Warnf("error deleting %s: %s", path, err.Error())
+	}
+}
+
+// trimGeneratedSuffix returns the name without the first matching suffix, or
+// an empty string if the name does not match any suffix.
+func trimGeneratedSuffix(name string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+
+	return ""
+}
+
+func formatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,139 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimGeneratedSuffix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"abc.thumb.jpg", "abc"},
+		{"abc.jpg", "abc"},
+		{"abc.mp4", "abc"},
+		{"abc.webp", "abc"},
+		{"abc.txt", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, trimGeneratedSuffix(tt.name, screenshotSuffixes), tt.name)
+	}
+}
+
+func writeTestFiles(t *testing.T, dir string, names []string) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func listTestFiles(t *testing.T, dir string) []string {
+	var ret []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			ret = append(ret, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+func TestCleanGeneratedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clean-generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFiles(t, dir, []string{
+		"keep.jpg",
+		"keep.thumb.jpg",
+		"orphan.jpg",
+		"orphan.mp4",
+		"unknown.txt",
+	})
+
+	task := CleanGeneratedTask{
+		DryRun:      true,
+		sceneHashes: map[string]bool{"keep": true},
+	}
+
+	task.cleanFiles(dir, screenshotSuffixes)
+	assert.Equal(t, 2, task.fileCount)
+	assert.Equal(t, int64(8), task.totalSize)
+	assert.Len(t, listTestFiles(t, dir), 5)
+
+	report := task.Report()
+	assert.Contains(t, report, "Would delete 2 orphaned generated files")
+	assert.Contains(t, report, filepath.Join(dir, "orphan.jpg"))
+	assert.Contains(t, report, filepath.Join(dir, "orphan.mp4"))
+	assert.NotContains(t, report, "keep")
+
+	task.DryRun = false
+	task.cleanFiles(dir, screenshotSuffixes)
+	assert.Equal(t, []string{"keep.jpg", "keep.thumb.jpg", "unknown.txt"}, listTestFiles(t, dir))
+}
+
+func TestCleanGeneratedThumbnails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clean-generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFiles(t, dir, []string{
+		"ke/ep/keep_640.jpg",
+		"or/ph/orphan_640.jpg",
+		"or/ph/orphan.jpg",
+	})
+
+	task := CleanGeneratedTask{
+		imageChecksums: map[string]bool{"keep": true},
+	}
+
+	task.cleanThumbnails(dir)
+	assert.Equal(t, []string{"ke/ep/keep_640.jpg", "or/ph/orphan.jpg"}, listTestFiles(t, dir))
+}
+
+func TestCleanGeneratedMarkers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clean-generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFiles(t, dir, []string{
+		"keep/10.mp4",
+		"orphan/10.mp4",
+		"orphan/10.webp",
+	})
+
+	task := CleanGeneratedTask{
+		sceneHashes: map[string]bool{"keep": true},
+	}
+
+	task.cleanMarkers(dir)
+	assert.Equal(t, 2, task.fileCount)
+	assert.Equal(t, []string{"keep/10.mp4"}, listTestFiles(t, dir))
+}
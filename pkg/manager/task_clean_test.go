@@ -0,0 +1,74 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+func TestCleanReport(t *testing.T) {
+	r := &cleanReport{}
+	r.addScene("/videos/a.mp4")
+	r.addImage("/images/b.jpg")
+	r.addGallery("/images/c.zip")
+
+	assert.Equal(t, "[dry run] Would remove 1 scenes, 1 images and 1 galleries\n"+
+		"[dry run] Would remove scene '/videos/a.mp4'\n"+
+		"[dry run] Would remove image '/images/b.jpg'\n"+
+		"[dry run] Would remove gallery '/images/c.zip'", r.Report(true))
+	assert.Equal(t, "Removed 1 scenes, 1 images and 1 galleries\n"+
+		"Removed scene '/videos/a.mp4'\n"+
+		"Removed image '/images/b.jpg'\n"+
+		"Removed gallery '/images/c.zip'", r.Report(false))
+
+	// reports are optional
+	var none *cleanReport
+	none.addScene("/videos/a.mp4")
+}
+
+func TestRemoveGeneratedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-clean-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	generated := filepath.Join(dir, "generated")
+	trash := filepath.Join(dir, "trash")
+
+	defer config.Set(config.Generated, config.GetGeneratedPath())
+	config.Set(config.Generated, generated)
+
+	writeTestFiles(t, generated, []string{
+		"screenshots/abc.mp4",
+		"transcodes/abc.mp4",
+		"markers/abc/10.mp4",
+		"markers/abc/10.webp",
+	})
+
+	for _, name := range []string{"screenshots/abc.mp4", "transcodes/abc.mp4", "markers/abc"} {
+		if err := removeGeneratedFile(filepath.Join(generated, name), trash); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Empty(t, listTestFiles(t, generated))
+	assert.Equal(t, []string{
+		"markers/abc/10.mp4",
+		"markers/abc/10.webp",
+		"screenshots/abc.mp4",
+		"transcodes/abc.mp4",
+	}, listTestFiles(t, trash))
+
+	// without a trash directory the files are deleted
+	writeTestFiles(t, generated, []string{"markers/def/10.mp4"})
+	if err := removeGeneratedFile(filepath.Join(generated, "markers/def"), ""); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, listTestFiles(t, generated))
+}
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"archive/zip"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -942,72 +943,33 @@ func (t *ExportTask) exportMovie(wg *sync.WaitGroup, jobChan <-chan *models.Movi
 	}
 }
 
+// ExportScrapedItems exports the legacy scraped items kept in the scrape
+// history.
 func (t *ExportTask) ExportScrapedItems() {
-	qb := models.NewScrapedItemQueryBuilder()
-	sqb := models.NewStudioQueryBuilder()
-	scrapedItems, err := qb.All()
+	qb := models.NewScrapeHistoryQueryBuilder()
+	histories, err := qb.FindByEntityType(models.ScrapeHistoryEntityScrapedItem)
 	if err != nil {
 		logger.Errorf("[scraped sites] failed to fetch all items: %s", err.Error())
+		return
 	}
 
 	logger.Info("[scraped sites] exporting")
 
 	scraped := []jsonschema.ScrapedItem{}
 
-	for i, scrapedItem := range scrapedItems {
+	for i, history := range histories {
 		index := i + 1
-		logger.Progressf("[scraped sites] %d of %d", index, len(scrapedItems))
-
-		var studioName string
-		if scrapedItem.StudioID.Valid {
-			studio, _ := sqb.Find(int(scrapedItem.StudioID.Int64), nil)
-			if studio != nil {
-				studioName = studio.Name.String
-			}
-		}
+		logger.Progressf("[scraped sites] %d of %d", index, len(histories))
 
 		newScrapedItemJSON := jsonschema.ScrapedItem{}
-
-		if scrapedItem.Title.Valid {
-			newScrapedItemJSON.Title = scrapedItem.Title.String
-		}
-		if scrapedItem.Description.Valid {
-			newScrapedItemJSON.Description = scrapedItem.Description.String
-		}
-		if scrapedItem.URL.Valid {
-			newScrapedItemJSON.URL = scrapedItem.URL.String
-		}
-		if scrapedItem.Date.Valid {
-			newScrapedItemJSON.Date = utils.GetYMDFromDatabaseDate(scrapedItem.Date.String)
-		}
-		if scrapedItem.Rating.Valid {
-			newScrapedItemJSON.Rating = scrapedItem.Rating.String
-		}
-		if scrapedItem.Tags.Valid {
-			newScrapedItemJSON.Tags = scrapedItem.Tags.String
-		}
-		if scrapedItem.Models.Valid {
-			newScrapedItemJSON.Models = scrapedItem.Models.String
-		}
-		if scrapedItem.Episode.Valid {
-			newScrapedItemJSON.Episode = int(scrapedItem.Episode.Int64)
-		}
-		if scrapedItem.GalleryFilename.Valid {
-			newScrapedItemJSON.GalleryFilename = scrapedItem.GalleryFilename.String
-		}
-		if scrapedItem.GalleryURL.Valid {
-			newScrapedItemJSON.GalleryURL = scrapedItem.GalleryURL.String
-		}
-		if scrapedItem.VideoFilename.Valid {
-			newScrapedItemJSON.VideoFilename = scrapedItem.VideoFilename.String
-		}
-		if scrapedItem.VideoURL.Valid {
-			newScrapedItemJSON.VideoURL = scrapedItem.VideoURL.String
+		if history.Result.Valid {
+			if err := json.Unmarshal([]byte(history.Result.String), &newScrapedItemJSON); err != nil {
+				logger.Errorf("[scraped sites] <%d> failed to decode item: %s", history.EntityID, err.Error())
+				continue
+			}
 		}
 
-		newScrapedItemJSON.Studio = studioName
-		updatedAt := models.JSONTime{Time: scrapedItem.UpdatedAt.Timestamp} // TODO keeping ruby format
-		newScrapedItemJSON.UpdatedAt = updatedAt
+		newScrapedItemJSON.UpdatedAt = models.JSONTime{Time: history.CreatedAt.Timestamp}
 
 		scraped = append(scraped, newScrapedItemJSON)
 	}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/remeh/sizedwaitgroup"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// GeneratePhashTask calculates and stores the perceptual hash of a scene.
+type GeneratePhashTask struct {
+	Scene               models.Scene
+	Overwrite           bool
+	fileNamingAlgorithm models.HashAlgorithm
+}
+
+func (t *GeneratePhashTask) Start(wg *sizedwaitgroup.SizedWaitGroup) {
+	defer wg.Done()
+
+	if !t.Overwrite && !t.required() {
+		return
+	}
+
+	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.Scene.Path, false)
+	if err != nil {
+		logger.Errorf("error reading video file: %s", err.Error())
+		return
+	}
+
+	generator, err := NewPhashGenerator(*videoFile, t.Scene.GetHash(t.fileNamingAlgorithm))
+	if err != nil {
+		logger.Errorf("error creating phash generator: %s", err.Error())
+		return
+	}
+
+	phash, err := generator.Generate()
+	if err != nil {
+		logger.Errorf("error generating phash: %s", err.Error())
+		return
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		return qb.UpdatePhash(t.Scene.ID, phash, tx)
+	}); err != nil {
+		logger.Errorf("error setting phash: %s", err.Error())
+	}
+}
+
+// required returns true if the phash needs to be generated
+func (t GeneratePhashTask) required() bool {
+	return !t.Scene.Phash.Valid
+}
@@ -6,7 +6,6 @@ import (
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
-	"github.com/stashapp/stash/pkg/utils"
 )
 
 type GeneratePreviewTask struct {
@@ -69,8 +68,7 @@ func (t *GeneratePreviewTask) doesVideoPreviewExist(sceneChecksum string) bool {
 		return false
 	}
 
-	videoExists, _ := utils.FileExists(instance.Paths.Scene.GetStreamPreviewPath(sceneChecksum))
-	return videoExists
+	return generatedFileExists(instance.Paths.Scene.GetStreamPreviewPath(sceneChecksum))
 }
 
 func (t *GeneratePreviewTask) doesImagePreviewExist(sceneChecksum string) bool {
@@ -78,8 +76,7 @@ func (t *GeneratePreviewTask) doesImagePreviewExist(sceneChecksum string) bool {
 		return false
 	}
 
-	imageExists, _ := utils.FileExists(instance.Paths.Scene.GetStreamPreviewImagePath(sceneChecksum))
-	return imageExists
+	return generatedFileExists(instance.Paths.Scene.GetStreamPreviewImagePath(sceneChecksum))
 }
 
 func (t *GeneratePreviewTask) videoFilename() string {
@@ -6,7 +6,6 @@ import (
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
-	"github.com/stashapp/stash/pkg/utils"
 )
 
 type GenerateSpriteTask struct {
@@ -56,7 +55,7 @@ func (t *GenerateSpriteTask) doesSpriteExist(sceneChecksum string) bool {
 		return false
 	}
 
-	imageExists, _ := utils.FileExists(instance.Paths.Scene.GetSpriteImageFilePath(sceneChecksum))
-	vttExists, _ := utils.FileExists(instance.Paths.Scene.GetSpriteVttFilePath(sceneChecksum))
+	imageExists := generatedFileExists(instance.Paths.Scene.GetSpriteImageFilePath(sceneChecksum))
+	vttExists := generatedFileExists(instance.Paths.Scene.GetSpriteVttFilePath(sceneChecksum))
 	return imageExists && vttExists
 }
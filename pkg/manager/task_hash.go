@@ -0,0 +1,133 @@
+package manager
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/remeh/sizedwaitgroup"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// HashTask calculates the missing hashes of a scene, such as one added by a
+// scan with deferred hashing, and then generates the files of the scene that
+// are named by its hash.
+//
+// A scene with the same hash as an existing scene is handled as the scan
+// handles a new file with the same hash: the scene is removed, and the path of
+// the existing scene is updated if its file no longer exists.
+type HashTask struct {
+	Scene                *models.Scene
+	calculateMD5         bool
+	fileNamingAlgorithm  models.HashAlgorithm
+	GenerateSprite       bool
+	GeneratePreview      bool
+	GenerateImagePreview bool
+	GeneratePhash        bool
+	job                  *Job
+}
+
+// Start starts the task.
+func (t *HashTask) Start(wg *sizedwaitgroup.SizedWaitGroup) {
+	defer wg.Done()
+
+	scanTask := ScanTask{
+		FilePath:             t.Scene.Path,
+		calculateMD5:         t.calculateMD5,
+		fileNamingAlgorithm:  t.fileNamingAlgorithm,
+		GenerateSprite:       t.GenerateSprite,
+		GeneratePreview:      t.GeneratePreview,
+		GenerateImagePreview: t.GenerateImagePreview,
+		GeneratePhash:        t.GeneratePhash,
+		job:                  t.job,
+	}
+
+	scene, err := t.hashScene(&scanTask)
+	if err != nil {
+		logger.Errorf("error hashing %s: %s", t.Scene.Path, err.Error())
+		return
+	}
+
+	if scene == nil {
+		return
+	}
+
+	scanTask.makeScreenshots(nil, scene.OSHash.String, scene.GetHash(t.fileNamingAlgorithm))
+	scanTask.generateSceneFiles(scene)
+}
+
+// hashScene calculates and sets the missing hashes of the scene. Returns nil
+// if the scene was removed as a duplicate of an existing scene.
+func (t *HashTask) hashScene(scanTask *ScanTask) (*models.Scene, error) {
+	scenePartial := models.ScenePartial{
+		ID: t.Scene.ID,
+	}
+
+	var oshash string
+	if !t.Scene.OSHash.Valid {
+		logger.Infof("Calculating oshash for %s ...", t.Scene.Path)
+		var err error
+		oshash, err = utils.OSHashFromFilePath(t.Scene.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		scenePartial.OSHash = &sql.NullString{String: oshash, Valid: true}
+	}
+
+	var checksum string
+	if (t.calculateMD5 || t.fileNamingAlgorithm == models.HashAlgorithmMd5) && !t.Scene.Checksum.Valid {
+		var err error
+		checksum, err = scanTask.calculateChecksum()
+		if err != nil {
+			return nil, err
+		}
+
+		scenePartial.Checksum = &sql.NullString{String: checksum, Valid: true}
+	}
+
+	if scenePartial.OSHash == nil && scenePartial.Checksum == nil {
+		return t.Scene, nil
+	}
+
+	qb := models.NewSceneQueryBuilder()
+
+	var dupe *models.Scene
+	if checksum != "" {
+		dupe, _ = qb.FindByChecksum(checksum)
+	}
+	if dupe == nil && oshash != "" {
+		dupe, _ = qb.FindByOSHash(oshash)
+	}
+
+	var ret *models.Scene
+	err := database.WithTxn(func(tx *sqlx.Tx) error {
+		if dupe == nil {
+			var err error
+			ret, err = qb.Update(scenePartial, tx)
+			return err
+		}
+
+		if err := DestroyScene(t.Scene.ID, tx); err != nil {
+			return err
+		}
+
+		exists, _ := utils.FileExists(dupe.Path)
+		if exists {
+			logger.Infof("%s already exists. Duplicate of %s", t.Scene.Path, dupe.Path)
+			return nil
+		}
+
+		logger.Infof("%s already exists. Updating path...", t.Scene.Path)
+		_, err := qb.Update(models.ScenePartial{
+			ID:   dupe.ID,
+			Path: &t.Scene.Path,
+		}, tx)
+		return err
+	})
+
+	return ret, err
+}
@@ -0,0 +1,37 @@
+package manager
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/remeh/sizedwaitgroup"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/image"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// GenerateImagePhashTask calculates and stores the perceptual hash of an
+// image.
+type GenerateImagePhashTask struct {
+	Image models.Image
+}
+
+func (t *GenerateImagePhashTask) Start(wg *sizedwaitgroup.SizedWaitGroup) {
+	defer wg.Done()
+
+	srcImage, err := image.GetSourceImage(&t.Image)
+	if err != nil {
+		logger.Errorf("error reading image %s: %s", image.PathDisplayName(t.Image.Path), err.Error())
+		return
+	}
+
+	phash := utils.ImagePhash(srcImage)
+
+	qb := models.NewImageQueryBuilder()
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		return qb.UpdatePhash(t.Image.ID, phash, tx)
+	}); err != nil {
+		logger.Errorf("error setting phash: %s", err.Error())
+	}
+}
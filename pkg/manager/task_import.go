@@ -4,10 +4,12 @@ import (
 	"archive/zip"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -440,48 +442,40 @@ func (t *ImportTask) ImportTags(ctx context.Context) {
 	logger.Info("[tags] import complete")
 }
 
+// ImportScrapedItems imports the legacy scraped items into the scrape
+// history.
 func (t *ImportTask) ImportScrapedItems(ctx context.Context) {
 	tx := database.DB.MustBeginTx(ctx, nil)
-	qb := models.NewScrapedItemQueryBuilder()
-	sqb := models.NewStudioQueryBuilder()
-	currentTime := time.Now()
+	qb := models.NewScrapeHistoryQueryBuilder()
+
+	logger.Info("[scraped sites] importing")
 
 	for i, mappingJSON := range t.scraped {
 		index := i + 1
-		logger.Progressf("[scraped sites] %d of %d", index, len(t.mappings.Scenes))
-
-		newScrapedItem := models.ScrapedItem{
-			Title:           sql.NullString{String: mappingJSON.Title, Valid: true},
-			Description:     sql.NullString{String: mappingJSON.Description, Valid: true},
-			URL:             sql.NullString{String: mappingJSON.URL, Valid: true},
-			Date:            models.SQLiteDate{String: mappingJSON.Date, Valid: true},
-			Rating:          sql.NullString{String: mappingJSON.Rating, Valid: true},
-			Tags:            sql.NullString{String: mappingJSON.Tags, Valid: true},
-			Models:          sql.NullString{String: mappingJSON.Models, Valid: true},
-			Episode:         sql.NullInt64{Int64: int64(mappingJSON.Episode), Valid: true},
-			GalleryFilename: sql.NullString{String: mappingJSON.GalleryFilename, Valid: true},
-			GalleryURL:      sql.NullString{String: mappingJSON.GalleryURL, Valid: true},
-			VideoFilename:   sql.NullString{String: mappingJSON.VideoFilename, Valid: true},
-			VideoURL:        sql.NullString{String: mappingJSON.VideoURL, Valid: true},
-			CreatedAt:       models.SQLiteTimestamp{Timestamp: currentTime},
-			UpdatedAt:       models.SQLiteTimestamp{Timestamp: t.getTimeFromJSONTime(mappingJSON.UpdatedAt)},
-		}
-
-		studio, err := sqb.FindByName(mappingJSON.Studio, tx, false)
+		logger.Progressf("[scraped sites] %d of %d", index, len(t.scraped))
+
+		updatedAt := t.getTimeFromJSONTime(mappingJSON.UpdatedAt)
+		mappingJSON.UpdatedAt = models.JSONTime{}
+		data, err := json.Marshal(&mappingJSON)
 		if err != nil {
-			logger.Errorf("[scraped sites] failed to fetch studio: %s", err.Error())
+			logger.Errorf("[scraped sites] <%s> failed to encode: %s", mappingJSON.Title, err.Error())
+			continue
 		}
-		if studio != nil {
-			newScrapedItem.StudioID = sql.NullInt64{Int64: int64(studio.ID), Valid: true}
+
+		newHistory := models.ScrapeHistory{
+			Entity:    models.ScrapeHistoryEntityScrapedItem,
+			EntityID:  index,
+			Source:    models.ScrapeHistorySourceScrapedItems,
+			Fields:    strings.Join(mappingJSON.Fields(), ","),
+			Result:    sql.NullString{String: string(data), Valid: true},
+			CreatedAt: models.SQLiteTimestamp{Timestamp: updatedAt},
 		}
 
-		_, err = qb.Create(newScrapedItem, tx)
-		if err != nil {
-			logger.Errorf("[scraped sites] <%s> failed to create: %s", newScrapedItem.Title.String, err.Error())
+		if _, err := qb.Create(newHistory, tx); err != nil {
+			logger.Errorf("[scraped sites] <%s> failed to create: %s", mappingJSON.Title, err.Error())
 		}
 	}
 
-	logger.Info("[scraped sites] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[scraped sites] import failed to commit: %s", err.Error())
 	}
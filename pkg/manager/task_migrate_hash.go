@@ -15,6 +15,10 @@ import (
 type MigrateHashTask struct {
 	Scene               *models.Scene
 	fileNamingAlgorithm models.HashAlgorithm
+	// dryRun only records the renames without making them
+	dryRun bool
+	// migration records the renamed files
+	migration *HashMigration
 }
 
 // Start starts the task.
@@ -40,7 +44,7 @@ func (t *MigrateHashTask) Start(wg *sync.WaitGroup) {
 	newPath := filepath.Join(instance.Paths.Generated.Markers, newHash)
 	t.migrate(oldPath, newPath)
 
-	scenePaths := GetInstance().Paths.Scene
+	scenePaths := instance.Paths.Scene
 	oldPath = scenePaths.GetThumbnailScreenshotPath(oldHash)
 	newPath = scenePaths.GetThumbnailScreenshotPath(newHash)
 	t.migrate(oldPath, newPath)
@@ -77,10 +81,24 @@ func (t *MigrateHashTask) migrate(oldName, newName string) {
 		return
 	}
 
-	if oldExists {
+	if !oldExists {
+		return
+	}
+
+	// keep existing files, which the migration could not restore when
+	// rolled back
+	if newExists, _ := utils.FileExists(newName); newExists {
+		logger.Warnf("not renaming %s to %s since it exists", oldName, newName)
+		return
+	}
+
+	if !t.dryRun {
 		logger.Infof("renaming %s to %s", oldName, newName)
 		if err := os.Rename(oldName, newName); err != nil {
 			logger.Errorf("error renaming %s to %s: %s", oldName, newName, err.Error())
+			return
 		}
 	}
+
+	t.migration.addRename(oldName, newName)
 }
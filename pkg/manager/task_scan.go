@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -29,10 +30,17 @@ type ScanTask struct {
 	StripFileExtension   bool
 	calculateMD5         bool
 	fileNamingAlgorithm  models.HashAlgorithm
+	deferHashing         bool
 	GenerateSprite       bool
 	GeneratePreview      bool
 	GenerateImagePreview bool
+	GeneratePhash        bool
 	zipGallery           *models.Gallery
+	// library is the library being scanned, if known
+	library *models.StashConfig
+	// job is the job running the task, if any, which hashing waits for
+	// while throttled
+	job *Job
 }
 
 func (t *ScanTask) Start(wg *sizedwaitgroup.SizedWaitGroup) {
@@ -41,50 +49,74 @@ func (t *ScanTask) Start(wg *sizedwaitgroup.SizedWaitGroup) {
 	} else if isVideo(t.FilePath) {
 		scene := t.scanScene()
 
-		if scene != nil {
-			iwg := sizedwaitgroup.New(2)
+		// the files of scenes added without hashes are generated by the hash
+		// job
+		if scene != nil && scene.GetHash(t.fileNamingAlgorithm) != "" {
+			t.generateSceneFiles(scene)
+		}
+	} else if isImage(t.FilePath) {
+		t.scanImage()
+	}
 
-			if t.GenerateSprite {
-				iwg.Add()
-				taskSprite := GenerateSpriteTask{Scene: *scene, Overwrite: false, fileNamingAlgorithm: t.fileNamingAlgorithm}
-				go taskSprite.Start(&iwg)
-			}
+	wg.Done()
+}
 
-			if t.GeneratePreview {
-				iwg.Add()
-
-				var previewSegmentDuration = config.GetPreviewSegmentDuration()
-				var previewSegments = config.GetPreviewSegments()
-				var previewExcludeStart = config.GetPreviewExcludeStart()
-				var previewExcludeEnd = config.GetPreviewExcludeEnd()
-				var previewPresent = config.GetPreviewPreset()
-
-				// NOTE: the reuse of this model like this is painful.
-				previewOptions := models.GeneratePreviewOptionsInput{
-					PreviewSegments:        &previewSegments,
-					PreviewSegmentDuration: &previewSegmentDuration,
-					PreviewExcludeStart:    &previewExcludeStart,
-					PreviewExcludeEnd:      &previewExcludeEnd,
-					PreviewPreset:          &previewPresent,
-				}
+// generateSceneFiles generates the sprite, previews and phash of a scene, as
+// requested by the scan options.
+func (t *ScanTask) generateSceneFiles(scene *models.Scene) {
+	iwg := sizedwaitgroup.New(2)
 
-				taskPreview := GeneratePreviewTask{
-					Scene:               *scene,
-					ImagePreview:        t.GenerateImagePreview,
-					Options:             previewOptions,
-					Overwrite:           false,
-					fileNamingAlgorithm: t.fileNamingAlgorithm,
-				}
-				go taskPreview.Start(&iwg)
-			}
+	if t.GenerateSprite {
+		iwg.Add()
+		taskSprite := GenerateSpriteTask{Scene: *scene, Overwrite: false, fileNamingAlgorithm: t.fileNamingAlgorithm}
+		go taskSprite.Start(&iwg)
+	}
 
-			iwg.Wait()
+	if t.GeneratePreview {
+		iwg.Add()
+
+		var previewSegmentDuration = config.GetPreviewSegmentDuration()
+		var previewSegments = config.GetPreviewSegments()
+		var previewExcludeStart = config.GetPreviewExcludeStart()
+		var previewExcludeEnd = config.GetPreviewExcludeEnd()
+		var previewPresent = config.GetPreviewPreset()
+
+		// NOTE: the reuse of this model like this is painful.
+		previewOptions := models.GeneratePreviewOptionsInput{
+			PreviewSegments:        &previewSegments,
+			PreviewSegmentDuration: &previewSegmentDuration,
+			PreviewExcludeStart:    &previewExcludeStart,
+			PreviewExcludeEnd:      &previewExcludeEnd,
+			PreviewPreset:          &previewPresent,
 		}
-	} else if isImage(t.FilePath) {
-		t.scanImage()
+
+		taskPreview := GeneratePreviewTask{
+			Scene:               *scene,
+			ImagePreview:        t.GenerateImagePreview,
+			Options:             previewOptions,
+			Overwrite:           false,
+			fileNamingAlgorithm: t.fileNamingAlgorithm,
+		}
+		go taskPreview.Start(&iwg)
 	}
 
-	wg.Done()
+	if t.GeneratePhash {
+		iwg.Add()
+		taskPhash := GeneratePhashTask{Scene: *scene, Overwrite: false, fileNamingAlgorithm: t.fileNamingAlgorithm}
+		go taskPhash.Start(&iwg)
+	}
+
+	iwg.Wait()
+}
+
+// createGalleriesFromFolders returns true if images are added to galleries
+// of their folders, as set by the library or else the global setting.
+func (t *ScanTask) createGalleriesFromFolders() bool {
+	if t.library != nil && t.library.CreateGalleriesFromFolders != nil {
+		return *t.library.CreateGalleriesFromFolders
+	}
+
+	return config.GetCreateGalleriesFromFolders()
 }
 
 func (t *ScanTask) scanGallery() {
@@ -262,6 +294,34 @@ func (t *ScanTask) updateFileModTime(id int, fileModTime time.Time, updater file
 	return nil
 }
 
+// updateFileCreationTime sets the creation time of an existing scene, if the
+// media has one. The probe output is cached, so scenes without a creation
+// time are cheap to check again on the next scan.
+func (t *ScanTask) updateFileCreationTime(scene *models.Scene, qb *models.SceneQueryBuilder) {
+	videoFile, err := probeVideoFile(t.FilePath, scene.OSHash.String, t.StripFileExtension)
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	fileCreationTime := t.getFileCreationTime(videoFile)
+	if !fileCreationTime.Valid {
+		return
+	}
+
+	logger.Infof("setting file creation time on %s", t.FilePath)
+
+	err = database.WithTxn(func(tx *sqlx.Tx) error {
+		return qb.UpdateFileCreationTime(scene.ID, fileCreationTime, tx)
+	})
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+
+	scene.FileCreationTime = fileCreationTime
+}
+
 func (t *ScanTask) getFileModTime() (time.Time, error) {
 	fi, err := os.Stat(t.FilePath)
 	if err != nil {
@@ -275,6 +335,33 @@ func (t *ScanTask) getFileModTime() (time.Time, error) {
 	return ret, nil
 }
 
+// getFileCreationTime returns the creation time of the media. The creation
+// time stored in the container is preferred, falling back to the birth time of
+// the file where the file system supports it.
+func (t *ScanTask) getFileCreationTime(videoFile *ffmpeg.VideoFile) models.NullSQLiteTimestamp {
+	if !videoFile.CreationTime.IsZero() {
+		return models.NullSQLiteTimestamp{
+			Timestamp: videoFile.CreationTime.Truncate(time.Second),
+			Valid:     true,
+		}
+	}
+
+	fi, err := os.Stat(t.FilePath)
+	if err != nil {
+		return models.NullSQLiteTimestamp{}
+	}
+
+	birthTime, ok := utils.GetFileBirthTime(fi)
+	if !ok {
+		return models.NullSQLiteTimestamp{}
+	}
+
+	return models.NullSQLiteTimestamp{
+		Timestamp: birthTime.Truncate(time.Second),
+		Valid:     true,
+	}
+}
+
 func (t *ScanTask) isFileModified(fileModTime time.Time, modTime models.NullSQLiteTimestamp) bool {
 	return !modTime.Timestamp.Equal(fileModTime)
 }
@@ -369,13 +456,26 @@ func (t *ScanTask) scanScene() *models.Scene {
 			}
 		}
 
+		// caption files may be added without modifying the scene file
+		scanCaptions(scene)
+
+		// scenes scanned before creation times were stored have none
+		if !scene.FileCreationTime.Valid {
+			t.updateFileCreationTime(scene, &qb)
+		}
+
+		// leave scenes added without a checksum to the hash job
+		if t.deferHashing && scene.GetHash(t.fileNamingAlgorithm) == "" {
+			return nil
+		}
+
 		// We already have this item in the database
 		// check for thumbnails,screenshots
-		t.makeScreenshots(nil, scene.GetHash(t.fileNamingAlgorithm))
+		t.makeScreenshots(nil, scene.OSHash.String, scene.GetHash(t.fileNamingAlgorithm))
 
 		// check for container
 		if !scene.Format.Valid {
-			videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath, t.StripFileExtension)
+			videoFile, err := probeVideoFile(t.FilePath, scene.OSHash.String, t.StripFileExtension)
 			if err != nil {
 				logger.Error(err.Error())
 				return nil
@@ -423,7 +523,7 @@ func (t *ScanTask) scanScene() *models.Scene {
 		}
 
 		// check if MD5 is set, if calculateMD5 is true
-		if t.calculateMD5 && !scene.Checksum.Valid {
+		if t.calculateMD5 && !t.deferHashing && !scene.Checksum.Valid {
 			checksum, err := t.calculateChecksum()
 			if err != nil {
 				logger.Error(err.Error())
@@ -456,7 +556,16 @@ func (t *ScanTask) scanScene() *models.Scene {
 		return nil
 	}
 
-	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath, t.StripFileExtension)
+	// the oshash is always calculated, since it only reads the start and end
+	// of the file, and a scene must have at least one hash
+	logger.Infof("%s not found. Calculating oshash...", t.FilePath)
+	oshash, err := utils.OSHashFromFilePath(t.FilePath)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil
+	}
+
+	videoFile, err := probeVideoFile(t.FilePath, oshash, t.StripFileExtension)
 	if err != nil {
 		logger.Error(err.Error())
 		return nil
@@ -470,14 +579,7 @@ func (t *ScanTask) scanScene() *models.Scene {
 
 	var checksum string
 
-	logger.Infof("%s not found. Calculating oshash...", t.FilePath)
-	oshash, err := utils.OSHashFromFilePath(t.FilePath)
-	if err != nil {
-		logger.Error(err.Error())
-		return nil
-	}
-
-	if t.fileNamingAlgorithm == models.HashAlgorithmMd5 || t.calculateMD5 {
+	if !t.deferHashing && (t.fileNamingAlgorithm == models.HashAlgorithmMd5 || t.calculateMD5) {
 		checksum, err = t.calculateChecksum()
 		if err != nil {
 			logger.Error(err.Error())
@@ -501,9 +603,12 @@ func (t *ScanTask) scanScene() *models.Scene {
 		sceneHash = checksum
 	}
 
-	t.makeScreenshots(videoFile, sceneHash)
+	if sceneHash != "" {
+		t.makeScreenshots(videoFile, oshash, sceneHash)
+	}
 
 	var retScene *models.Scene
+	var movedScene *models.Scene
 
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
@@ -517,7 +622,7 @@ func (t *ScanTask) scanScene() *models.Scene {
 				ID:   scene.ID,
 				Path: &t.FilePath,
 			}
-			_, err = qb.Update(scenePartial, tx)
+			movedScene, err = qb.Update(scenePartial, tx)
 		}
 	} else {
 		logger.Infof("%s doesn't exist. Creating new item...", t.FilePath)
@@ -540,16 +645,21 @@ func (t *ScanTask) scanScene() *models.Scene {
 				Timestamp: fileModTime,
 				Valid:     true,
 			},
-			CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
-			UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+			FileCreationTime: t.getFileCreationTime(videoFile),
+			CreatedAt:        models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:        models.SQLiteTimestamp{Timestamp: currentTime},
 		}
 
 		if t.UseFileMetadata {
 			newScene.Details = sql.NullString{String: videoFile.Comment, Valid: true}
-			newScene.Date = models.SQLiteDate{String: videoFile.CreationTime.Format("2006-01-02")}
+			newScene.Date = models.SQLiteDate{String: videoFile.CreationTime.In(utils.GetTimezone()).Format("2006-01-02")}
 		}
 
 		retScene, err = qb.Create(newScene, tx)
+
+		if chapters := sceneChaptersFromVideoFile(videoFile); err == nil && len(chapters) > 0 {
+			err = qb.UpdateChapters(retScene.ID, chapters, tx)
+		}
 	}
 
 	if err != nil {
@@ -562,6 +672,18 @@ func (t *ScanTask) scanScene() *models.Scene {
 		return nil
 	}
 
+	if movedScene != nil {
+		scanCaptions(movedScene)
+	}
+
+	if retScene != nil {
+		scanCaptions(retScene)
+
+		if config.IsChapterMarkersOnScan() {
+			scanChapterMarkers(retScene)
+		}
+	}
+
 	return retScene
 }
 
@@ -589,11 +711,12 @@ func (t *ScanTask) rescanScene(scene *models.Scene, fileModTime time.Time) (*mod
 	}
 
 	// regenerate the file details as well
-	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath, t.StripFileExtension)
+	videoFile, err := probeVideoFile(t.FilePath, oshash, t.StripFileExtension)
 	if err != nil {
 		return nil, err
 	}
 	container := ffmpeg.MatchContainer(videoFile.Container, t.FilePath)
+	fileCreationTime := t.getFileCreationTime(videoFile)
 
 	currentTime := time.Now()
 	scenePartial := models.ScenePartial{
@@ -616,7 +739,8 @@ func (t *ScanTask) rescanScene(scene *models.Scene, fileModTime time.Time) (*mod
 			Timestamp: fileModTime,
 			Valid:     true,
 		},
-		UpdatedAt: &models.SQLiteTimestamp{Timestamp: currentTime},
+		FileCreationTime: &fileCreationTime,
+		UpdatedAt:        &models.SQLiteTimestamp{Timestamp: currentTime},
 	}
 
 	var ret *models.Scene
@@ -624,19 +748,27 @@ func (t *ScanTask) rescanScene(scene *models.Scene, fileModTime time.Time) (*mod
 		qb := models.NewSceneQueryBuilder()
 		var txnErr error
 		ret, txnErr = qb.Update(scenePartial, tx)
-		return txnErr
+		if txnErr != nil {
+			return txnErr
+		}
+
+		return qb.UpdateChapters(scene.ID, sceneChaptersFromVideoFile(videoFile), tx)
 	})
 	if err != nil {
 		logger.Error(err.Error())
 		return nil, err
 	}
 
+	if config.IsChapterMarkersOnScan() {
+		scanChapterMarkers(ret)
+	}
+
 	// leave the generated files as is - the scene file may have been moved
 	// elsewhere
 
 	return ret, nil
 }
-func (t *ScanTask) makeScreenshots(probeResult *ffmpeg.VideoFile, checksum string) {
+func (t *ScanTask) makeScreenshots(probeResult *ffmpeg.VideoFile, oshash string, checksum string) {
 	thumbPath := instance.Paths.Scene.GetThumbnailScreenshotPath(checksum)
 	normalPath := instance.Paths.Scene.GetScreenshotPath(checksum)
 
@@ -649,7 +781,7 @@ func (t *ScanTask) makeScreenshots(probeResult *ffmpeg.VideoFile, checksum strin
 
 	if probeResult == nil {
 		var err error
-		probeResult, err = ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath, t.StripFileExtension)
+		probeResult, err = probeVideoFile(t.FilePath, oshash, t.StripFileExtension)
 
 		if err != nil {
 			logger.Error(err.Error())
@@ -803,7 +935,7 @@ func (t *ScanTask) scanImage() {
 		if t.zipGallery != nil {
 			// associate with gallery
 			_, err = jqb.AddImageGallery(i.ID, t.zipGallery.ID, tx)
-		} else if config.GetCreateGalleriesFromFolders() {
+		} else if t.createGalleriesFromFolders() {
 			// create gallery from folder or associate with existing gallery
 			logger.Infof("Associating image %s with folder gallery", i.Path)
 			err = t.associateImageWithFolderGallery(i.ID, tx)
@@ -886,6 +1018,13 @@ func (t *ScanTask) associateImageWithFolderGallery(imageID int, tx *sqlx.Tx) err
 	}
 
 	if g == nil {
+		// wait for the folder to have enough images to be a gallery
+		count := countImagesInFolder(path)
+		if count < config.GetGalleryFolderMinImages() {
+			logger.Debugf("Not creating gallery for folder %s with %d images", path, count)
+			return nil
+		}
+
 		checksum := utils.MD5FromString(path)
 
 		// create the gallery
@@ -906,10 +1045,30 @@ func (t *ScanTask) associateImageWithFolderGallery(imageID int, tx *sqlx.Tx) err
 		if err != nil {
 			return err
 		}
+
+		// associate the images of the folder scanned before it had enough
+		// images
+		iqb := models.NewImageQueryBuilder()
+		images, err := iqb.FindByFolder(path, tx)
+		if err != nil {
+			return err
+		}
+		for _, i := range images {
+			if _, err := jqb.AddImageGallery(i.ID, g.ID, tx); err != nil {
+				return err
+			}
+		}
 	}
 
 	// associate image with gallery
-	_, err = jqb.AddImageGallery(imageID, g.ID, tx)
+	added, err := jqb.AddImageGallery(imageID, g.ID, tx)
+	if err != nil || !added {
+		return err
+	}
+
+	// the gallery is updated when images are added to its folder
+	updatedAt := models.SQLiteTimestamp{Timestamp: time.Now()}
+	_, err = gqb.UpdatePartial(models.GalleryPartial{ID: g.ID, UpdatedAt: &updatedAt}, tx)
 	return err
 }
 
@@ -942,7 +1101,7 @@ func (t *ScanTask) generateThumbnail(i *models.Image) {
 
 func (t *ScanTask) calculateChecksum() (string, error) {
 	logger.Infof("Calculating checksum for %s...", t.FilePath)
-	checksum, err := utils.MD5FromFilePath(t.FilePath)
+	checksum, err := videoMD5FromFilePath(t.job, t.FilePath)
 	if err != nil {
 		return "", err
 	}
@@ -989,12 +1148,44 @@ func (t *ScanTask) doesPathExist() bool {
 	return false
 }
 
+// scanFilter selects the files of a library that are scanned.
+type scanFilter struct {
+	library         *models.StashConfig
+	vidExt          []string
+	imgExt          []string
+	gExt            []string
+	excludeVidRegex []*regexp.Regexp
+	excludeImgRegex []*regexp.Regexp
+}
+
+func newScanFilter(s *models.StashConfig) *scanFilter {
+	return &scanFilter{
+		library:         s,
+		vidExt:          config.GetVideoExtensions(),
+		imgExt:          config.GetImageExtensions(),
+		gExt:            config.GetGalleryExtensions(),
+		excludeVidRegex: generateRegexps(libraryExcludes(s)),
+		excludeImgRegex: generateRegexps(libraryImageExcludes(s)),
+	}
+}
+
+// matches returns true if the file at path is scanned.
+func (f *scanFilter) matches(path string) bool {
+	if !f.library.ExcludeVideo && matchExtension(path, f.vidExt) && !matchFileRegex(path, f.excludeVidRegex) {
+		return true
+	}
+
+	if !f.library.ExcludeImage {
+		if (matchExtension(path, f.imgExt) || matchExtension(path, f.gExt)) && !matchFileRegex(path, f.excludeImgRegex) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func walkFilesToScan(s *models.StashConfig, f filepath.WalkFunc) error {
-	vidExt := config.GetVideoExtensions()
-	imgExt := config.GetImageExtensions()
-	gExt := config.GetGalleryExtensions()
-	excludeVidRegex := generateRegexps(config.GetExcludes())
-	excludeImgRegex := generateRegexps(config.GetImageExcludes())
+	filter := newScanFilter(s)
 
 	return utils.SymWalk(s.Path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -1006,16 +1197,10 @@ func walkFilesToScan(s *models.StashConfig, f filepath.WalkFunc) error {
 			return nil
 		}
 
-		if !s.ExcludeVideo && matchExtension(path, vidExt) && !matchFileRegex(path, excludeVidRegex) {
+		if filter.matches(path) {
 			return f(path, info, err)
 		}
 
-		if !s.ExcludeImage {
-			if (matchExtension(path, imgExt) || matchExtension(path, gExt)) && !matchFileRegex(path, excludeImgRegex) {
-				return f(path, info, err)
-			}
-		}
-
 		return nil
 	})
 }
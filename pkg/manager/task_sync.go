@@ -0,0 +1,355 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/event"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// syncSceneValues are the values of a scene that are synchronized between
+// stash instances.
+type syncSceneValues struct {
+	// Rating is on the 1-100 scale
+	Rating    sql.NullInt64
+	Organized bool
+	// Tags are the names of the tags of the scene in this instance, or the
+	// names of the tags of the other instance that this instance does not
+	// have
+	Tags      []string
+	UpdatedAt time.Time
+}
+
+// reconcileScene returns the values of the local scene after synchronizing
+// it with the remote scene using the conflict policy.
+func reconcileScene(local syncSceneValues, remote syncSceneValues, policy models.SyncConflictPolicy) syncSceneValues {
+	switch policy {
+	case models.SyncConflictPolicyRemote:
+		return remote
+	case models.SyncConflictPolicyNewest:
+		if remote.UpdatedAt.After(local.UpdatedAt) {
+			return remote
+		}
+		return local
+	}
+
+	ret := local
+	if !ret.Rating.Valid {
+		ret.Rating = remote.Rating
+	}
+	ret.Organized = local.Organized || remote.Organized
+
+	names := syncTagNameSet(local.Tags)
+	ret.Tags = append([]string{}, local.Tags...)
+	for _, name := range remote.Tags {
+		if !names[strings.ToLower(name)] {
+			names[strings.ToLower(name)] = true
+			ret.Tags = append(ret.Tags, name)
+		}
+	}
+
+	return ret
+}
+
+func syncTagNameSet(names []string) map[string]bool {
+	ret := make(map[string]bool)
+	for _, name := range names {
+		ret[strings.ToLower(name)] = true
+	}
+	return ret
+}
+
+func syncRatingString(rating sql.NullInt64) string {
+	if !rating.Valid {
+		return "none"
+	}
+	return strconv.FormatInt(rating.Int64, 10)
+}
+
+// describeSyncChanges returns the changes from the values before to those
+// after, or nil if there are none. Tag names are compared regardless of case.
+func describeSyncChanges(before syncSceneValues, after syncSceneValues) []string {
+	var ret []string
+	if before.Rating != after.Rating {
+		ret = append(ret, fmt.Sprintf("rating %s -> %s", syncRatingString(before.Rating), syncRatingString(after.Rating)))
+	}
+	if before.Organized != after.Organized {
+		ret = append(ret, fmt.Sprintf("organized %t -> %t", before.Organized, after.Organized))
+	}
+
+	var tags []string
+	beforeTags := syncTagNameSet(before.Tags)
+	afterTags := syncTagNameSet(after.Tags)
+	for _, name := range after.Tags {
+		if !beforeTags[strings.ToLower(name)] {
+			tags = append(tags, "+"+name)
+		}
+	}
+	for _, name := range before.Tags {
+		if !afterTags[strings.ToLower(name)] {
+			tags = append(tags, "-"+name)
+		}
+	}
+	if len(tags) > 0 {
+		ret = append(ret, "tags "+strings.Join(tags, " "))
+	}
+
+	return ret
+}
+
+// SyncTask updates the ratings, tags and organized flags of scenes from
+// those of the same scenes in another stash instance. Scenes are matched by
+// UUID, then by MD5 checksum and then by oshash. Tags of the other instance
+// are matched by UUID and then by name or alias, and are created if this
+// instance does not have them.
+type SyncTask struct {
+	URL            string
+	ConflictPolicy models.SyncConflictPolicy
+	DryRun         bool
+	// job receives the progress of the task if it is not nil
+	job *Job
+
+	remoteCount int
+	matched     int
+	updated     int
+	// createdTags are the lowercase names of the created tags
+	createdTags map[string]bool
+	// changes describe the changes made to each updated scene
+	changes []string
+}
+
+func (t *SyncTask) Start() error {
+	client := newSyncClient(t.URL)
+	t.createdTags = make(map[string]bool)
+
+	for page := 1; ; page++ {
+		count, scenes, err := client.findScenes(context.TODO(), page)
+		if err != nil {
+			return fmt.Errorf("error querying scenes of %s: %s", t.URL, err.Error())
+		}
+		t.remoteCount = count
+
+		for i, remote := range scenes {
+			if t.job != nil {
+				if t.job.isStopping() {
+					logger.Info("Stopping due to user request")
+					return nil
+				}
+				t.job.setProgress((page-1)*syncPageSize+i, count)
+			}
+
+			if err := t.syncScene(remote); err != nil {
+				logger.Errorf("error synchronizing scene %s of %s: %s", remote.ID, t.URL, err.Error())
+			}
+		}
+
+		if len(scenes) < syncPageSize || page*syncPageSize >= count {
+			break
+		}
+	}
+
+	logger.Info(t.summary())
+
+	return nil
+}
+
+func (t *SyncTask) summary() string {
+	verb, create := "Updated", "created"
+	if t.DryRun {
+		verb, create = "[dry run] Would update", "create"
+	}
+	return fmt.Sprintf("%s %d scenes and %s %d tags from %s. %d of its %d scenes matched scenes of this instance", verb, t.updated, create, len(t.createdTags), t.URL, t.matched, t.remoteCount)
+}
+
+// Report returns the summary of the task followed by the changes made to
+// each updated scene, one per line.
+func (t *SyncTask) Report() string {
+	return strings.Join(append([]string{t.summary()}, t.changes...), "\n")
+}
+
+func (t *SyncTask) findScene(remote syncRemoteScene) (*models.Scene, error) {
+	qb := models.NewSceneQueryBuilder()
+
+	if remote.UUID != "" {
+		scene, err := qb.FindByUUID(remote.UUID)
+		if err != nil || scene != nil {
+			return scene, err
+		}
+	}
+	if remote.Checksum != nil && *remote.Checksum != "" {
+		scene, err := qb.FindByChecksum(*remote.Checksum)
+		if err != nil || scene != nil {
+			return scene, err
+		}
+	}
+	if remote.OSHash != nil && *remote.OSHash != "" {
+		return qb.FindByOSHash(*remote.OSHash)
+	}
+
+	return nil, nil
+}
+
+// localTagName returns the name of the tag of this instance with the uuid of
+// the remote tag, or with its name or alias. It returns the name of the
+// remote tag if there is no such tag.
+func (t *SyncTask) localTagName(remote syncRemoteTag) (string, error) {
+	qb := models.NewTagQueryBuilder()
+
+	tag, err := qb.FindByUUID(remote.UUID, nil)
+	if err == nil && tag == nil {
+		tag, err = qb.FindByNameOrAlias(remote.Name, nil, true)
+	}
+	if err != nil || tag == nil {
+		return remote.Name, err
+	}
+
+	return tag.Name, nil
+}
+
+func (t *SyncTask) syncScene(remote syncRemoteScene) error {
+	scene, err := t.findScene(remote)
+	if err != nil || scene == nil {
+		return err
+	}
+	t.matched++
+
+	tqb := models.NewTagQueryBuilder()
+	tags, err := tqb.FindBySceneID(scene.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	local := syncSceneValues{
+		Rating:    scene.Rating,
+		Organized: scene.Organized,
+		UpdatedAt: scene.UpdatedAt.Timestamp,
+	}
+	for _, tag := range tags {
+		local.Tags = append(local.Tags, tag.Name)
+	}
+
+	// uuids of the remote tags, for the tags that are created
+	remoteUUIDs := make(map[string]string)
+	remoteValues := syncSceneValues{
+		Organized: remote.Organized,
+		UpdatedAt: remote.UpdatedAt,
+	}
+	if remote.Rating100 != nil {
+		remoteValues.Rating = sql.NullInt64{Int64: int64(*remote.Rating100), Valid: true}
+	}
+	for _, tag := range remote.Tags {
+		name, err := t.localTagName(tag)
+		if err != nil {
+			return err
+		}
+		remoteValues.Tags = append(remoteValues.Tags, name)
+		remoteUUIDs[strings.ToLower(name)] = tag.UUID
+	}
+
+	values := reconcileScene(local, remoteValues, t.ConflictPolicy)
+	changes := describeSyncChanges(local, values)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if !t.DryRun {
+		if err := t.updateScene(scene.ID, values, remoteUUIDs); err != nil {
+			return err
+		}
+	} else if err := t.findCreatedTags(values.Tags); err != nil {
+		return err
+	}
+
+	t.updated++
+	t.changes = append(t.changes, scene.Path+": "+strings.Join(changes, ", "))
+
+	return nil
+}
+
+// findCreatedTags adds the tags that do not exist in this instance to the
+// created tags.
+func (t *SyncTask) findCreatedTags(names []string) error {
+	qb := models.NewTagQueryBuilder()
+	for _, name := range names {
+		tag, err := qb.FindByNameOrAlias(name, nil, true)
+		if err != nil {
+			return err
+		}
+		if tag == nil {
+			t.createdTags[strings.ToLower(name)] = true
+		}
+	}
+
+	return nil
+}
+
+func (t *SyncTask) updateScene(sceneID int, values syncSceneValues, remoteUUIDs map[string]string) error {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	qb := models.NewSceneQueryBuilder()
+	tqb := models.NewTagQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	_, err := qb.Update(models.ScenePartial{
+		ID:        sceneID,
+		Rating:    &values.Rating,
+		Organized: &values.Organized,
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: time.Now()},
+	}, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	var createdTagIDs []int
+	var joins []models.ScenesTags
+	tagIDs := make(map[int]bool)
+	for _, name := range values.Tags {
+		tag, err := tqb.FindByNameOrAlias(name, tx, true)
+		if err == nil && tag == nil {
+			newTag := models.NewTag(name)
+			if uuid := remoteUUIDs[strings.ToLower(name)]; uuid != "" {
+				newTag.UUID = sql.NullString{String: uuid, Valid: true}
+			}
+			tag, err = tqb.Create(*newTag, tx)
+			if err == nil {
+				createdTagIDs = append(createdTagIDs, tag.ID)
+				t.createdTags[strings.ToLower(name)] = true
+			}
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		// several remote tags may be the same tag of this instance
+		if !tagIDs[tag.ID] {
+			tagIDs[tag.ID] = true
+			joins = append(joins, models.ScenesTags{SceneID: sceneID, TagID: tag.ID})
+		}
+	}
+
+	if err := jqb.UpdateScenesTags(sceneID, joins, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(createdTagIDs) > 0 {
+		event.Publish(event.Event{Entity: event.EntityTag, Action: event.ActionCreate, IDs: createdTagIDs})
+	}
+	event.Publish(event.Event{Entity: event.EntityScene, Action: event.ActionUpdate, IDs: []int{sceneID}})
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestReconcileScene(t *testing.T) {
+	older := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	local := syncSceneValues{
+		Rating:    sql.NullInt64{Int64: 60, Valid: true},
+		Organized: false,
+		Tags:      []string{"a", "B"},
+		UpdatedAt: older,
+	}
+	remote := syncSceneValues{
+		Rating:    sql.NullInt64{Int64: 80, Valid: true},
+		Organized: true,
+		Tags:      []string{"b", "c"},
+		UpdatedAt: newer,
+	}
+	unrated := local
+	unrated.Rating = sql.NullInt64{}
+
+	tests := []struct {
+		name   string
+		local  syncSceneValues
+		remote syncSceneValues
+		policy models.SyncConflictPolicy
+		want   syncSceneValues
+	}{
+		{"merge", local, remote, models.SyncConflictPolicyMerge, syncSceneValues{Rating: local.Rating, Organized: true, Tags: []string{"a", "B", "c"}, UpdatedAt: older}},
+		{"merge unrated", unrated, remote, models.SyncConflictPolicyMerge, syncSceneValues{Rating: remote.Rating, Organized: true, Tags: []string{"a", "B", "c"}, UpdatedAt: older}},
+		{"remote", local, remote, models.SyncConflictPolicyRemote, remote},
+		{"newest remote", local, remote, models.SyncConflictPolicyNewest, remote},
+		{"newest local", remote, local, models.SyncConflictPolicyNewest, remote},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, reconcileScene(tt.local, tt.remote, tt.policy), tt.name)
+	}
+}
+
+func TestDescribeSyncChanges(t *testing.T) {
+	before := syncSceneValues{
+		Organized: false,
+		Tags:      []string{"a", "B"},
+	}
+	after := syncSceneValues{
+		Rating:    sql.NullInt64{Int64: 80, Valid: true},
+		Organized: true,
+		Tags:      []string{"b", "c"},
+	}
+
+	assert.Equal(t, []string{"rating none -> 80", "organized false -> true", "tags +c -a"}, describeSyncChanges(before, after))
+	assert.Nil(t, describeSyncChanges(before, before))
+}
+
+func TestSyncClientFindScenes(t *testing.T) {
+	var query struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &query); err != nil {
+			t.Error(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": {"findScenes": {"count": 1, "scenes": [{
+			"id": "1",
+			"uuid": "0b1e5c1a-3f0e-4d6c-9a8b-7c6d5e4f3a2b",
+			"checksum": null,
+			"oshash": "abc",
+			"rating100": 80,
+			"organized": true,
+			"updated_at": "2020-01-01T00:00:00Z",
+			"tags": [{"uuid": "1b1e5c1a-3f0e-4d6c-9a8b-7c6d5e4f3a2b", "name": "a"}]
+		}]}}}`))
+	}))
+	defer server.Close()
+
+	count, scenes, err := newSyncClient(server.URL+"/").findScenes(context.TODO(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, query.Query, "findScenes(filter: $f)")
+	assert.Equal(t, float64(2), query.Variables["f"].(map[string]interface{})["page"])
+
+	assert.Equal(t, 1, count)
+	rating := 80
+	oshash := "abc"
+	assert.Equal(t, []syncRemoteScene{{
+		ID:        "1",
+		UUID:      "0b1e5c1a-3f0e-4d6c-9a8b-7c6d5e4f3a2b",
+		OSHash:    &oshash,
+		Rating100: &rating,
+		Organized: true,
+		UpdatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Tags:      []syncRemoteTag{{UUID: "1b1e5c1a-3f0e-4d6c-9a8b-7c6d5e4f3a2b", Name: "a"}},
+	}}, scenes)
+}
@@ -0,0 +1,267 @@
+package manager
+
+import (
+	"container/list"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const transcodeCacheTmpSuffix = ".tmp"
+
+var errTranscodeCacheClosed = errors.New("transcode cache was replaced")
+
+// TranscodeCache stores the output of live transcodes, so that repeated
+// requests for the same scene, profile and start time can be served from disk
+// instead of being transcoded again. The least recently used files are evicted
+// when the total size exceeds the maximum size.
+type TranscodeCache struct {
+	dir     string
+	maxSize int64
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	// most recently used entries are at the front
+	lru  *list.List
+	size int64
+	// closed is true once the cache has been replaced
+	closed bool
+}
+
+type transcodeCacheEntry struct {
+	key  string
+	size int64
+}
+
+// NewTranscodeCache returns a cache storing files in dir, loading any files
+// already present. A maxSize of zero disables the cache and removes all
+// cached files.
+func NewTranscodeCache(dir string, maxSize int64) *TranscodeCache {
+	c := &TranscodeCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	if err := utils.EnsureDir(dir); err != nil {
+		logger.Warnf("error creating transcode cache directory %s: %s", dir, err.Error())
+	}
+
+	c.load()
+	return c
+}
+
+// TranscodeCacheKey returns the cache key for a transcode identified by parts.
+func TranscodeCacheKey(parts ...string) string {
+	return utils.MD5FromString(strings.Join(parts, "|"))
+}
+
+// load adds the files in the cache directory to the cache, using the
+// modification time as the last used time. Incomplete files left over from
+// a previous run are deleted.
+func (c *TranscodeCache) load() {
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		logger.Warnf("error reading transcode cache directory %s: %s", c.dir, err.Error())
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		if strings.HasSuffix(f.Name(), transcodeCacheTmpSuffix) {
+			c.removeFile(filepath.Join(c.dir, f.Name()))
+			continue
+		}
+
+		c.add(f.Name(), f.Size())
+	}
+
+	c.evict()
+}
+
+// Enabled returns true if transcodes should be cached.
+func (c *TranscodeCache) Enabled() bool {
+	return c != nil && c.maxSize > 0
+}
+
+// Close stops adding output to the cache. The output of writers committed
+// afterwards is discarded, and no new writers are created. Files already in
+// the cache are kept.
+func (c *TranscodeCache) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+}
+
+func (c *TranscodeCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// Get returns the path of the cached file for key, and marks it as recently
+// used. It returns false if key is not cached.
+func (c *TranscodeCache) Get(key string) (string, bool) {
+	if !c.Enabled() {
+		return "", false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, found := c.entries[key]
+	if !found {
+		return "", false
+	}
+
+	c.lru.MoveToFront(e)
+
+	// persist the last used time for when the cache is next loaded
+	path := c.path(key)
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return path, true
+}
+
+// NewWriter returns a writer for the transcode output for key. The output is
+// only added to the cache once the writer is committed.
+func (c *TranscodeCache) NewWriter(key string) (*TranscodeCacheWriter, error) {
+	c.mutex.Lock()
+	closed := c.closed
+	c.mutex.Unlock()
+
+	if closed {
+		return nil, errTranscodeCacheClosed
+	}
+
+	f, err := ioutil.TempFile(c.dir, key+"-*"+transcodeCacheTmpSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TranscodeCacheWriter{
+		cache: c,
+		key:   key,
+		file:  f,
+	}, nil
+}
+
+// add adds or replaces the entry for key. The mutex must be held.
+func (c *TranscodeCache) add(key string, size int64) {
+	if e, found := c.entries[key]; found {
+		c.size -= e.Value.(*transcodeCacheEntry).size
+		c.lru.Remove(e)
+	}
+
+	c.entries[key] = c.lru.PushFront(&transcodeCacheEntry{
+		key:  key,
+		size: size,
+	})
+	c.size += size
+}
+
+// evict removes the least recently used entries until the cache is within
+// its maximum size. The mutex must be held.
+func (c *TranscodeCache) evict() {
+	for c.size > c.maxSize {
+		e := c.lru.Back()
+		if e == nil {
+			return
+		}
+
+		entry := e.Value.(*transcodeCacheEntry)
+		logger.Debugf("evicting transcode cache entry %s", entry.key)
+		c.removeFile(c.path(entry.key))
+
+		c.lru.Remove(e)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+	}
+}
+
+func (c *TranscodeCache) removeFile(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("error deleting transcode cache file %s: %s", path, err.Error())
+	}
+}
+
+// TranscodeCacheWriter writes transcode output to a temporary file in the
+// cache directory.
+type TranscodeCacheWriter struct {
+	cache *TranscodeCache
+	key   string
+	file  *os.File
+	size  int64
+	err   error
+}
+
+// Write writes p to the temporary file. Errors are not returned, so that a
+// failure to write to the cache does not interrupt the stream it is copied
+// from. They are instead returned by Commit.
+func (w *TranscodeCacheWriter) Write(p []byte) (int, error) {
+	if w.err == nil {
+		var n int
+		n, w.err = w.file.Write(p)
+		w.size += int64(n)
+	}
+
+	return len(p), nil
+}
+
+// Commit adds the written output to the cache, evicting older entries if
+// necessary. Output larger than the cache is discarded.
+func (w *TranscodeCacheWriter) Commit() error {
+	tmpPath := w.file.Name()
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+
+	if w.err != nil || w.size > w.cache.maxSize {
+		w.cache.removeFile(tmpPath)
+		return w.err
+	}
+
+	c := w.cache
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		c.removeFile(tmpPath)
+		return errTranscodeCacheClosed
+	}
+
+	if err := os.Rename(tmpPath, c.path(w.key)); err != nil {
+		c.removeFile(tmpPath)
+		return err
+	}
+
+	c.add(w.key, w.size)
+	c.evict()
+
+	return nil
+}
+
+// Abort discards the written output.
+func (w *TranscodeCacheWriter) Abort() {
+	_ = w.file.Close()
+	w.cache.removeFile(w.file.Name())
+}
@@ -0,0 +1,122 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCacheEntry(t *testing.T, c *TranscodeCache, key string, data string) {
+	w, err := c.NewWriter(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranscodeCacheEviction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcode-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewTranscodeCache(dir, 10)
+	assert.True(t, c.Enabled())
+
+	writeCacheEntry(t, c, "a", "aaaa")
+	writeCacheEntry(t, c, "b", "bbbb")
+
+	// mark a as recently used so that b is evicted first
+	path, found := c.Get("a")
+	assert.True(t, found)
+	data, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "aaaa", string(data))
+
+	writeCacheEntry(t, c, "c", "cccc")
+
+	_, found = c.Get("b")
+	assert.False(t, found)
+	_, found = c.Get("a")
+	assert.True(t, found)
+	_, found = c.Get("c")
+	assert.True(t, found)
+	assert.Equal(t, int64(8), c.size)
+
+	// larger than the cache
+	writeCacheEntry(t, c, "d", "ddddddddddd")
+	_, found = c.Get("d")
+	assert.False(t, found)
+
+	// incomplete output is discarded
+	w, err := c.NewWriter("e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("e"))
+	w.Abort()
+	_, found = c.Get("e")
+	assert.False(t, found)
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 2)
+}
+
+func TestTranscodeCacheLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcode-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFiles(t, dir, []string{"a", "b", "c-123" + transcodeCacheTmpSuffix})
+
+	c := NewTranscodeCache(dir, 10)
+	_, found := c.Get("a")
+	assert.True(t, found)
+	_, found = c.Get("c-123" + transcodeCacheTmpSuffix)
+	assert.False(t, found)
+
+	_, err = os.Stat(filepath.Join(dir, "c-123"+transcodeCacheTmpSuffix))
+	assert.True(t, os.IsNotExist(err))
+
+	// disabling the cache removes the cached files
+	c = NewTranscodeCache(dir, 0)
+	assert.False(t, c.Enabled())
+	_, found = c.Get("a")
+	assert.False(t, found)
+	assert.Empty(t, listTestFiles(t, dir))
+}
+
+func TestTranscodeCacheClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcode-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := NewTranscodeCache(dir, 10)
+	w, err := c.NewWriter("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("a"))
+
+	// output committed after the cache is replaced is discarded
+	c.Close()
+	assert.Equal(t, errTranscodeCacheClosed, w.Commit())
+	_, found := c.Get("a")
+	assert.False(t, found)
+	assert.Empty(t, listTestFiles(t, dir))
+
+	_, err = c.NewWriter("b")
+	assert.Equal(t, errTranscodeCacheClosed, err)
+}
@@ -0,0 +1,399 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/remeh/sizedwaitgroup"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// watchDelay is the time without further changes after which a changed file
+// is handled, so that files being copied or downloaded are scanned once
+// complete.
+var watchDelay = 5 * time.Second
+
+// cleanDelay is the time after which a removed file is cleaned. It is longer
+// than watchDelay so that the new path of a moved file is scanned first, and
+// the existing scene, image or gallery moved to it rather than cleaned.
+var cleanDelay = 3 * watchDelay
+
+var errWatcherClosed = errors.New("watcher closed")
+
+// debouncer calls fn with a key once the key has not been triggered for
+// delay.
+type debouncer struct {
+	delay   time.Duration
+	fn      func(key string)
+	mutex   sync.Mutex
+	pending map[string]*debouncedCall
+	// running is the number of calls to fn in progress
+	running int
+	stopped bool
+}
+
+type debouncedCall struct {
+	timer *time.Timer
+}
+
+func newDebouncer(delay time.Duration, fn func(key string)) *debouncer {
+	return &debouncer{
+		delay:   delay,
+		fn:      fn,
+		pending: make(map[string]*debouncedCall),
+	}
+}
+
+// trigger calls fn with key after delay, unless key is triggered again
+// before then.
+func (d *debouncer) trigger(key string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	if c, found := d.pending[key]; found {
+		c.timer.Stop()
+	}
+
+	c := &debouncedCall{}
+	c.timer = time.AfterFunc(d.delay, func() {
+		d.fire(key, c)
+	})
+	d.pending[key] = c
+}
+
+func (d *debouncer) fire(key string, c *debouncedCall) {
+	d.mutex.Lock()
+	// the key may have been triggered again while waiting for the mutex
+	if d.stopped || d.pending[key] != c {
+		d.mutex.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	d.running++
+	d.mutex.Unlock()
+
+	defer func() {
+		d.mutex.Lock()
+		d.running--
+		d.mutex.Unlock()
+	}()
+
+	d.fn(key)
+}
+
+// busy returns true if there are calls waiting or in progress.
+func (d *debouncer) busy() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return len(d.pending) > 0 || d.running > 0
+}
+
+// stop cancels the pending calls. Keys triggered afterwards are ignored.
+func (d *debouncer) stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.stopped = true
+	for _, c := range d.pending {
+		c.timer.Stop()
+	}
+	d.pending = nil
+}
+
+// libraryWatcher scans the new and changed files of the libraries, and
+// cleans the deleted files, as they change.
+type libraryWatcher struct {
+	watcher *fsnotify.Watcher
+	// debouncer handles changed paths, and cleaner the paths found to be
+	// removed
+	debouncer *debouncer
+	cleaner   *debouncer
+	// mutex serialises the handling of changed paths
+	mutex sync.Mutex
+	done  chan struct{}
+}
+
+// newLibraryWatcher starts watching the directories of the libraries.
+// Subdirectories are added in the background.
+func newLibraryWatcher(libraries []*models.StashConfig) (*libraryWatcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &libraryWatcher{
+		watcher: fw,
+		done:    make(chan struct{}),
+	}
+	w.debouncer = newDebouncer(watchDelay, w.handleChange)
+	w.cleaner = newDebouncer(cleanDelay, w.handleRemoved)
+
+	go w.run()
+	go func() {
+		for _, l := range libraries {
+			if err := w.addDir(l.Path, false); err != nil {
+				if err != errWatcherClosed {
+					logger.Warnf("error watching library %s: %s", l.Path, err.Error())
+				}
+				return
+			}
+		}
+		logger.Infof("Watching %d libraries for changes", len(libraries))
+	}()
+
+	return w, nil
+}
+
+// close stops watching the libraries. Pending changes are not handled.
+func (w *libraryWatcher) close() {
+	close(w.done)
+	w.debouncer.stop()
+	w.cleaner.stop()
+	if err := w.watcher.Close(); err != nil {
+		logger.Warnf("error closing library watcher: %s", err.Error())
+	}
+}
+
+func (w *libraryWatcher) isClosed() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// addDir watches the directory and its subdirectories. The files within are
+// handled as changed if scanFiles is true, such as for directories moved
+// into a library.
+func (w *libraryWatcher) addDir(path string, scanFiles bool) error {
+	return utils.SymWalk(path, func(p string, info os.FileInfo, err error) error {
+		if w.isClosed() {
+			return errWatcherClosed
+		}
+
+		if err != nil {
+			logger.Warnf("error watching %s: %s", p, err.Error())
+			return nil
+		}
+
+		if info.IsDir() {
+			// most likely the limit on the number of watches was reached
+			if err := w.watcher.Add(p); err != nil {
+				return fmt.Errorf("error watching %s: %s", p, err.Error())
+			}
+		} else if scanFiles {
+			w.debouncer.trigger(p)
+		}
+
+		return nil
+	})
+}
+
+func (w *libraryWatcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warnf("error watching libraries: %s", err.Error())
+		}
+	}
+}
+
+func (w *libraryWatcher) handleEvent(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addDir(event.Name, true); err != nil && err != errWatcherClosed {
+				logger.Warn(err.Error())
+			}
+			return
+		}
+	}
+
+	// removed directories are cleaned like removed files
+	w.debouncer.trigger(event.Name)
+}
+
+// handleChange scans the file at path, or queues the cleaning of the scenes,
+// images and galleries at or within path if it no longer exists.
+func (w *libraryWatcher) handleChange(path string) {
+	// changes are handled after the queued jobs, which may be scanning or
+	// cleaning the same files
+	if instance.JobQueue.isBusy() {
+		w.debouncer.trigger(path)
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		w.cleaner.trigger(path)
+		return
+	}
+
+	if err != nil {
+		logger.Warnf("error scanning changed file %s: %s", path, err.Error())
+		return
+	}
+
+	// the files of new directories are triggered separately
+	if info.IsDir() {
+		return
+	}
+
+	scanChangedFile(path)
+}
+
+// handleRemoved cleans the scenes, images and galleries at or within path,
+// once the pending changes have been scanned. A file or directory that was
+// moved is removed from its old path and created at its new path, and
+// scanning the new path first moves its scenes, images and galleries, so
+// that their metadata is kept.
+func (w *libraryWatcher) handleRemoved(path string) {
+	if instance.JobQueue.isBusy() || w.debouncer.busy() {
+		w.cleaner.trigger(path)
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// the path may have been created again since
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+
+	cleanUnderPath(path)
+}
+
+// scanChangedFile scans the file at path if it is in a library and not
+// excluded from it. Scene hashes are calculated, and the previews and
+// sprites generated if set for the library.
+func scanChangedFile(path string) {
+	stash := getStashFromPath(path)
+	if stash == nil || !newScanFilter(stash).matches(path) {
+		return
+	}
+
+	logger.Infof("Scanning changed file %s", path)
+
+	task := ScanTask{
+		FilePath:            path,
+		fileNamingAlgorithm: config.GetVideoFileNamingAlgorithm(),
+		calculateMD5:        config.IsCalculateMD5(),
+		library:             stash,
+	}
+	if stash.GeneratePreviews != nil {
+		task.GeneratePreview = *stash.GeneratePreviews
+		task.GenerateSprite = *stash.GeneratePreviews
+	}
+
+	acquireGeneratedTmpDir()
+	defer releaseGeneratedTmpDir()
+
+	wg := sizedwaitgroup.New(1)
+	wg.Add()
+	task.Start(&wg)
+
+	if isGallery(path) {
+		wg.Add()
+		task.associateGallery(&wg)
+	}
+}
+
+// cleanUnderPath cleans the scenes, images and galleries whose files are at
+// path or within the directory path, if their files no longer exist.
+func cleanUnderPath(path string) {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindUnderPath(path)
+	if err != nil {
+		logger.Errorf("error finding scenes to clean: %s", err.Error())
+		return
+	}
+
+	iqb := models.NewImageQueryBuilder()
+	images, err := iqb.FindUnderPath(path)
+	if err != nil {
+		logger.Errorf("error finding images to clean: %s", err.Error())
+		return
+	}
+
+	gqb := models.NewGalleryQueryBuilder()
+	galleries, err := gqb.FindUnderPath(path)
+	if err != nil {
+		logger.Errorf("error finding galleries to clean: %s", err.Error())
+		return
+	}
+
+	var wg sync.WaitGroup
+	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
+
+	for _, scene := range scenes {
+		wg.Add(1)
+		task := CleanTask{Scene: scene, fileNamingAlgorithm: fileNamingAlgo}
+		task.Start(&wg)
+	}
+
+	for _, img := range images {
+		wg.Add(1)
+		task := CleanTask{Image: img}
+		task.Start(&wg)
+	}
+
+	for _, gallery := range galleries {
+		wg.Add(1)
+		task := CleanTask{Gallery: gallery}
+		task.Start(&wg)
+	}
+}
+
+// refreshWatcher restarts watching the libraries if set in the config, or
+// stops watching them.
+func (s *singleton) refreshWatcher() {
+	s.watcherMutex.Lock()
+	defer s.watcherMutex.Unlock()
+
+	if s.watcher != nil {
+		s.watcher.close()
+		s.watcher = nil
+	}
+
+	if !config.IsWatchLibraries() {
+		return
+	}
+
+	w, err := newLibraryWatcher(config.GetStashPaths())
+	if err != nil {
+		logger.Errorf("error watching libraries: %s", err.Error())
+		return
+	}
+	s.watcher = w
+}
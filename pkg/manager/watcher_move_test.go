@@ -0,0 +1,180 @@
+// +build integration
+
+package manager
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func TestWatcherMovedScene(t *testing.T) {
+	const name = "TestWatcherMovedScene"
+
+	dir, err := ioutil.TempDir("", "stash-watcher-")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	library := filepath.Join(dir, "library")
+	oldPath := filepath.Join(library, "old", "video.mp4")
+	newPath := filepath.Join(library, "new", "renamed.mp4")
+	for _, d := range []string{filepath.Dir(oldPath), filepath.Dir(newPath)} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("Error creating directory: %s", err.Error())
+		}
+	}
+
+	// unique content, so that the oshash does not match other scenes
+	if err := ioutil.WriteFile(oldPath, bytes.Repeat([]byte(name), 4096), 0644); err != nil {
+		t.Fatalf("Error writing file: %s", err.Error())
+	}
+
+	defer config.Set(config.Stash, config.GetStashPaths())
+	config.Set(config.Stash, []string{library})
+	defer config.Set(config.Generated, config.GetGeneratedPath())
+	config.Set(config.Generated, filepath.Join(dir, "generated"))
+
+	previous := instance
+	defer func() { instance = previous }()
+	instance = &singleton{Paths: paths.NewPaths(), JobQueue: newJobQueue()}
+
+	defer func(scan, clean time.Duration) {
+		watchDelay = scan
+		cleanDelay = clean
+	}(watchDelay, cleanDelay)
+	watchDelay = 100 * time.Millisecond
+	cleanDelay = 300 * time.Millisecond
+
+	oshash, err := utils.OSHashFromFilePath(oldPath)
+	if err != nil {
+		t.Fatalf("Error calculating oshash: %s", err.Error())
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	var scene *models.Scene
+	var tag *models.Tag
+	if err := database.WithTxn(func(tx *sqlx.Tx) error {
+		// ffprobe is not needed to scan the moved file
+		pqb := models.NewProbeCacheQueryBuilder()
+		if err := pqb.Set(oshash, int64(len(name)*4096), []byte(`{"format":{"duration":"10"}}`), tx); err != nil {
+			return err
+		}
+
+		var err error
+		scene, err = qb.Create(models.Scene{
+			Path:   oldPath,
+			OSHash: sql.NullString{String: oshash, Valid: true},
+			Title:  sql.NullString{String: name, Valid: true},
+			Rating: sql.NullInt64{Int64: 80, Valid: true},
+		}, tx)
+		if err != nil {
+			return err
+		}
+
+		tqb := models.NewTagQueryBuilder()
+		tag, err = tqb.Create(models.Tag{Name: name}, tx)
+		if err != nil {
+			return err
+		}
+
+		jqb := models.NewJoinsQueryBuilder()
+		return jqb.UpdateScenesTags(scene.ID, []models.ScenesTags{
+			{SceneID: scene.ID, TagID: tag.ID},
+		}, tx)
+	}); err != nil {
+		t.Fatalf("Error creating scene: %s", err.Error())
+	}
+
+	w, err := newLibraryWatcher(config.GetStashPaths())
+	if err != nil {
+		t.Fatalf("Error watching library: %s", err.Error())
+	}
+	defer w.close()
+
+	// wait for the directories to be watched
+	time.Sleep(100 * time.Millisecond)
+
+	// move the file out of the library and back in, so that the removal of
+	// the old path is handled before the creation of the new path
+	outside := filepath.Join(dir, "video.mp4")
+	if err := os.Rename(oldPath, outside); err != nil {
+		t.Fatalf("Error renaming file: %s", err.Error())
+	}
+	time.Sleep(watchDelay / 2)
+	if err := os.Rename(outside, newPath); err != nil {
+		t.Fatalf("Error renaming file: %s", err.Error())
+	}
+
+	assertMoved := func(path string) {
+		// wait until the removed path would have been cleaned
+		time.Sleep(watchDelay + cleanDelay)
+		for i := 0; i < 50 && (w.debouncer.busy() || w.cleaner.busy()); i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		moved, err := qb.Find(scene.ID)
+		if err != nil {
+			t.Fatalf("Error finding scene: %s", err.Error())
+		}
+
+		if !assert.NotNil(t, moved) {
+			return
+		}
+		assert.Equal(t, path, moved.Path)
+		assert.Equal(t, scene.Rating, moved.Rating)
+		assert.Equal(t, scene.Title, moved.Title)
+
+		tqb := models.NewTagQueryBuilder()
+		tags, err := tqb.FindBySceneID(scene.ID, nil)
+		if err != nil {
+			t.Fatalf("Error finding tags: %s", err.Error())
+		}
+		if assert.Len(t, tags, 1) {
+			assert.Equal(t, tag.ID, tags[0].ID)
+		}
+
+		// no other scene is created for the new path
+		scenes, err := qb.FindUnderPath(library)
+		if err != nil {
+			t.Fatalf("Error finding scenes: %s", err.Error())
+		}
+		assert.Len(t, scenes, 1)
+	}
+
+	assertMoved(newPath)
+
+	// the files of moved directories are kept too
+	movedDir := filepath.Join(library, "moved")
+	if err := os.Rename(filepath.Dir(newPath), movedDir); err != nil {
+		t.Fatalf("Error renaming directory: %s", err.Error())
+	}
+
+	assertMoved(filepath.Join(movedDir, "renamed.mp4"))
+
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	if err := qb.Destroy(strconv.Itoa(scene.ID), tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error destroying scene: %s", err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+}
@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebouncer(t *testing.T) {
+	var mutex sync.Mutex
+	var calls []string
+	d := newDebouncer(50*time.Millisecond, func(key string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls = append(calls, key)
+	})
+
+	getCalls := func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), calls...)
+	}
+
+	// repeated triggers delay the call
+	for i := 0; i < 3; i++ {
+		d.trigger("a")
+		time.Sleep(20 * time.Millisecond)
+	}
+	assert.Empty(t, getCalls())
+	assert.True(t, d.busy())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"a"}, getCalls())
+	assert.False(t, d.busy())
+
+	// pending calls are cancelled by stop
+	d.trigger("b")
+	d.stop()
+	d.trigger("c")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"a"}, getCalls())
+}
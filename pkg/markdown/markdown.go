@@ -0,0 +1,192 @@
+// Package markdown renders user and scraper supplied markdown descriptions to
+// HTML that is safe to display.
+package markdown
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	"golang.org/x/net/html"
+)
+
+// allowedTags is the set of HTML elements, with their allowed attributes,
+// that may appear in sanitized output.
+var allowedTags = map[string][]string{
+	"a":          {"href", "title", "rel", "target"},
+	"b":          nil,
+	"blockquote": nil,
+	"br":         nil,
+	"code":       nil,
+	"del":        nil,
+	"em":         nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"li":         nil,
+	"ol":         nil,
+	"p":          nil,
+	"pre":        nil,
+	"s":          nil,
+	"strong":     nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"align"},
+	"th":         {"align"},
+	"thead":      nil,
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// droppedContentTags are elements whose content is removed along with the
+// element itself. This must include every element that switches the
+// tokenizer into raw text mode, since the content of those is not parsed for
+// tags.
+var droppedContentTags = map[string]bool{
+	"iframe":    true,
+	"noembed":   true,
+	"noframes":  true,
+	"noscript":  true,
+	"object":    true,
+	"plaintext": true,
+	"script":    true,
+	"style":     true,
+	"template":  true,
+	"textarea":  true,
+	"title":     true,
+	"xmp":       true,
+}
+
+var safeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+const htmlFlags = blackfriday.CommonHTMLFlags | blackfriday.SkipHTML | blackfriday.SkipImages |
+	blackfriday.Safelink | blackfriday.NofollowLinks | blackfriday.NoreferrerLinks |
+	blackfriday.NoopenerLinks | blackfriday.HrefTargetBlank
+
+// Render converts the markdown source to sanitized HTML. Raw HTML and images
+// in the source are not rendered.
+func Render(source string) string {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: htmlFlags,
+	})
+
+	output := blackfriday.Run([]byte(source), blackfriday.WithRenderer(renderer))
+	return Sanitize(string(output))
+}
+
+// Sanitize removes any HTML elements and attributes from s that are not in
+// the allowed set. Text outside of elements is escaped, so that it may be
+// used to clean markdown source before it is stored. Text which looks like a
+// tag but is not an HTML element, such as "a<b" or "<3", is escaped rather
+// than removed.
+func Sanitize(s string) string {
+	var buf bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	// depth of nested elements whose content is being dropped
+	dropDepth := 0
+	for {
+		tt := z.Next()
+		// Token unescapes text in place, so the raw bytes must be copied first
+		raw := append([]byte(nil), z.Raw()...)
+		if tt == html.ErrorToken {
+			// an unterminated tag at the end of the input is left in raw
+			if dropDepth == 0 {
+				buf.WriteString(html.EscapeString(string(raw)))
+			}
+			return buf.String()
+		}
+
+		token := z.Token()
+
+		switch tt {
+		case html.TextToken:
+			if dropDepth == 0 {
+				buf.WriteString(html.EscapeString(token.Data))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedContentTags[token.Data] {
+				if tt == html.StartTagToken {
+					dropDepth++
+				}
+				continue
+			}
+			if dropDepth == 0 {
+				if attrs, ok := allowedTags[token.Data]; ok {
+					token.Attr = sanitizeAttributes(token.Attr, attrs)
+					buf.WriteString(token.String())
+				} else if !isElement(token) {
+					buf.WriteString(html.EscapeString(string(raw)))
+				}
+			}
+		case html.EndTagToken:
+			if droppedContentTags[token.Data] {
+				if dropDepth > 0 {
+					dropDepth--
+				}
+				continue
+			}
+			if dropDepth == 0 {
+				if _, ok := allowedTags[token.Data]; ok {
+					buf.WriteString(token.String())
+				} else if !isElement(token) {
+					buf.WriteString(html.EscapeString(string(raw)))
+				}
+			}
+		}
+		// comments and doctypes are always dropped
+	}
+}
+
+// isElement returns true if the tag token is a known HTML element.
+func isElement(token html.Token) bool {
+	return token.DataAtom != 0
+}
+
+func sanitizeAttributes(attrs []html.Attribute, allowed []string) []html.Attribute {
+	var ret []html.Attribute
+	for _, a := range attrs {
+		if a.Namespace != "" || !isAllowedAttribute(a.Key, allowed) {
+			continue
+		}
+
+		if a.Key == "href" && !isSafeURL(a.Val) {
+			continue
+		}
+
+		ret = append(ret, a)
+	}
+
+	return ret
+}
+
+func isAllowedAttribute(key string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSafeURL returns true if the URL is relative or uses a safe scheme.
+func isSafeURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "" || safeSchemes[strings.ToLower(u.Scheme)]
+}
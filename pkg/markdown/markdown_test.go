@@ -0,0 +1,131 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"emphasis",
+			"some *emphasis* and **strong** text",
+			"<p>some <em>emphasis</em> and <strong>strong</strong> text</p>\n",
+		},
+		{
+			"link",
+			"[site](https://example.com)",
+			`<p><a href="https://example.com" target="_blank" rel="nofollow noreferrer noopener">site</a></p>` + "\n",
+		},
+		{
+			"unsafe link",
+			"[site](javascript:alert)",
+			"<p>site</p>\n",
+		},
+		{
+			"raw html",
+			"text <script>alert(1)</script> <b onclick=\"alert(1)\">bold</b>",
+			"<p>text alert(1) bold</p>\n",
+		},
+		{
+			"text resembling tags",
+			"a < b and <3",
+			"<p>a &lt; b and &lt;3</p>\n",
+		},
+		{
+			"image",
+			"![image](https://example.com/image.jpg)",
+			"<p></p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.source))
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			"markdown text escaped",
+			"# Title\n\n* one & two\n* 1 < 2\n",
+			"# Title\n\n* one &amp; two\n* 1 &lt; 2\n",
+		},
+		{
+			"script removed",
+			"before<script>alert('x')</script>after",
+			"beforeafter",
+		},
+		{
+			"attributes removed",
+			`<p class="x" onclick="alert(1)">text</p>`,
+			"<p>text</p>",
+		},
+		{
+			"unsafe href removed",
+			`<a href="javascript:alert(1)">link</a>`,
+			"<a>link</a>",
+		},
+		{
+			"safe href kept",
+			`<a href="https://example.com" title="t">link</a>`,
+			`<a href="https://example.com" title="t">link</a>`,
+		},
+		{
+			"unknown tags removed",
+			"<div><span>text</span></div><!-- comment -->",
+			"text",
+		},
+		{
+			"unterminated tag kept",
+			"a<b",
+			"a&lt;b",
+		},
+		{
+			"text resembling tag kept",
+			"I <3 this <scene>, really</scene>",
+			"I &lt;3 this &lt;scene&gt;, really&lt;/scene&gt;",
+		},
+		{
+			"trailing malformed tag kept",
+			"synopsis continues <a href=\"x",
+			"synopsis continues &lt;a href=&#34;x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sanitize(tt.input))
+		})
+	}
+}
+
+func TestSanitizeRawTextElements(t *testing.T) {
+	const payload = "<script>alert(1)</script>"
+
+	for _, tag := range []string{"xmp", "noembed", "noframes", "title", "textarea", "style", "noscript", "iframe"} {
+		t.Run(tag, func(t *testing.T) {
+			input := "before<" + tag + ">" + payload + "</" + tag + ">after"
+			assert.Equal(t, "beforeafter", Sanitize(input))
+			assert.NotContains(t, Render(input), "script")
+		})
+	}
+
+	// plaintext is never closed, so everything after it is dropped
+	t.Run("plaintext", func(t *testing.T) {
+		input := "before<plaintext>" + payload + "</plaintext>after"
+		assert.Equal(t, "before", Sanitize(input))
+		assert.NotContains(t, Render(input), "script")
+	})
+}
@@ -0,0 +1,13 @@
+package models
+
+// monthDayInClause returns the condition that the month and day of the date
+// held by column is one of monthDays, in MM-DD format. strftime returns null
+// for empty and unparseable dates, which are never matched.
+func monthDayInClause(column string, monthDays []string) (string, []interface{}) {
+	var args []interface{}
+	for _, md := range monthDays {
+		args = append(args, md)
+	}
+
+	return "strftime('%m-%d', " + column + ") IN " + getInBinding(len(monthDays)), args
+}
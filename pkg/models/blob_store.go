@@ -0,0 +1,410 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// blobStorage is where image data is stored. Image data stored on the
+// filesystem is recorded by the file column of the image table, which holds
+// the path of the file relative to the blobs directory. The image column then
+// holds an empty blob, since it cannot be null.
+var blobStorage struct {
+	mutex         sync.RWMutex
+	path          string
+	useFilesystem bool
+}
+
+// blobFileDeletionInterval is how often image files no longer referenced by
+// committed transactions are deleted.
+const blobFileDeletionInterval = 5 * time.Minute
+
+// blobFileDeletionDelay is the time between an image file being marked for
+// deletion and the deletion being attempted, to allow the transaction which
+// marked it to be committed.
+const blobFileDeletionDelay = time.Second
+
+// blobOrphanAge is the age after which image files not referenced by the
+// database are deleted. Files written by transactions that were rolled back
+// are never referenced. Younger files may belong to transactions which are
+// still open.
+const blobOrphanAge = time.Hour
+
+// blobOrphanDeletionInterval is how often unreferenced image files are
+// deleted.
+const blobOrphanDeletionInterval = 24 * time.Hour
+
+var (
+	blobFileDeleterOnce    sync.Once
+	blobFileDeletionSignal = make(chan struct{}, 1)
+)
+
+// SetBlobStorage sets the directory of images stored on the filesystem, and
+// whether new images are stored there instead of in the database. Images
+// already stored on the filesystem are read from path regardless of
+// useFilesystem.
+func SetBlobStorage(path string, useFilesystem bool) {
+	blobStorage.mutex.Lock()
+	blobStorage.path = path
+	blobStorage.useFilesystem = useFilesystem && path != ""
+	blobStorage.mutex.Unlock()
+
+	blobFileDeleterOnce.Do(func() {
+		go deleteBlobFiles()
+	})
+}
+
+func getBlobStorage() (string, bool) {
+	blobStorage.mutex.RLock()
+	defer blobStorage.mutex.RUnlock()
+
+	return blobStorage.path, blobStorage.useFilesystem
+}
+
+// blobColumn is a column of an image table holding image data, and the
+// column recording the file of image data stored on the filesystem.
+type blobColumn struct {
+	table      string
+	fkColumn   string
+	column     string
+	fileColumn string
+}
+
+var (
+	movieFrontImageBlob = blobColumn{table: "movies_images", fkColumn: "movie_id", column: "front_image", fileColumn: "front_image_file"}
+	movieBackImageBlob  = blobColumn{table: "movies_images", fkColumn: "movie_id", column: "back_image", fileColumn: "back_image_file"}
+	performerImageBlob  = blobColumn{table: "performers_image", fkColumn: "performer_id", column: "image", fileColumn: "image_file"}
+	studioImageBlob     = blobColumn{table: "studios_image", fkColumn: "studio_id", column: "image", fileColumn: "image_file"}
+	tagImageBlob        = blobColumn{table: "tags_image", fkColumn: "tag_id", column: "image", fileColumn: "image_file"}
+	sceneCoverBlob      = blobColumn{table: "scenes_cover", fkColumn: "scene_id", column: "cover", fileColumn: "cover_file"}
+)
+
+var blobColumns = []blobColumn{
+	movieFrontImageBlob,
+	movieBackImageBlob,
+	performerImageBlob,
+	studioImageBlob,
+	tagImageBlob,
+	sceneCoverBlob,
+}
+
+// store returns the values to store in the image and file columns for the
+// image data. If images are stored on the filesystem, the data is written to
+// a new file and an empty blob is returned with the file. Nil data is
+// returned as is.
+//
+// The file is written under a name not used by any other image, so that the
+// image of a committed transaction is never overwritten. If the transaction
+// is rolled back, the file is deleted once it is old enough to be certain
+// that it is not referenced.
+func (c blobColumn) store(id int, data []byte) ([]byte, sql.NullString, error) {
+	path, useFilesystem := getBlobStorage()
+	if data == nil || !useFilesystem {
+		return data, sql.NullString{}, nil
+	}
+
+	dir := filepath.Join(path, c.table)
+	if err := utils.EnsureDir(dir); err != nil {
+		return nil, sql.NullString{}, err
+	}
+
+	// write to a temporary file first so that an image file is never
+	// partially written
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return nil, sql.NullString{}, fmt.Errorf("error creating image file: %s", err.Error())
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, sql.NullString{}, fmt.Errorf("error writing image file: %s", err.Error())
+	}
+
+	name := c.table + "/" + strconv.Itoa(id) + "_" + c.column + "_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := os.Rename(tmp.Name(), filepath.Join(path, filepath.FromSlash(name))); err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, sql.NullString{}, fmt.Errorf("error writing image file: %s", err.Error())
+	}
+
+	return []byte{}, sql.NullString{String: name, Valid: true}, nil
+}
+
+// load returns the image data, reading it from file if it was stored on the
+// filesystem.
+func (c blobColumn) load(data []byte, file sql.NullString) ([]byte, error) {
+	if !file.Valid {
+		return data, nil
+	}
+
+	path, _ := getBlobStorage()
+	if path == "" {
+		return nil, fmt.Errorf("image %s is stored on the filesystem but the blobs path is not set", file.String)
+	}
+
+	ret, err := ioutil.ReadFile(filepath.Join(path, filepath.FromSlash(file.String)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return ret, err
+}
+
+// get returns the image data of the object, or nil if it has no image.
+func (c blobColumn) get(id int, tx *sqlx.Tx) ([]byte, error) {
+	query := "SELECT " + c.column + ", " + c.fileColumn + " FROM " + c.table + " WHERE " + c.fkColumn + " = ?"
+
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, id)
+	} else {
+		rows, err = database.DB.Queryx(query, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var data []byte
+	var file sql.NullString
+	if rows.Next() {
+		if err := rows.Scan(&data, &file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.load(data, file)
+}
+
+// destroy marks the image file of the object, if any, to be deleted once
+// the transaction is committed. It must be called before the image row is
+// deleted.
+func (c blobColumn) destroy(id int, tx *sqlx.Tx) error {
+	_, err := tx.Exec("INSERT OR IGNORE INTO blob_file_deletions (path) SELECT "+c.fileColumn+" FROM "+c.table+" WHERE "+c.fkColumn+" = ? AND "+c.fileColumn+" IS NOT NULL", id)
+	return err
+}
+
+// destroyImageFiles marks the image files of the object for each of columns
+// to be deleted once the transaction is committed.
+func destroyImageFiles(id int, tx *sqlx.Tx, columns ...blobColumn) error {
+	for _, c := range columns {
+		if err := c.destroy(id, tx); err != nil {
+			return err
+		}
+	}
+
+	requestBlobFileDeletion()
+	return nil
+}
+
+func requestBlobFileDeletion() {
+	select {
+	case blobFileDeletionSignal <- struct{}{}:
+	default:
+	}
+}
+
+// deleteBlobFiles deletes the image files marked for deletion by committed
+// transactions, and the image files which are not referenced.
+func deleteBlobFiles() {
+	ticker := time.NewTicker(blobFileDeletionInterval)
+	defer ticker.Stop()
+
+	var lastOrphanDeletion time.Time
+	for {
+		select {
+		case <-blobFileDeletionSignal:
+			time.Sleep(blobFileDeletionDelay)
+		case <-ticker.C:
+		}
+
+		if err := deletePendingBlobFiles(); err != nil {
+			logger.Warnf("error deleting image files: %s", err.Error())
+		}
+
+		if database.DB != nil && time.Since(lastOrphanDeletion) >= blobOrphanDeletionInterval {
+			lastOrphanDeletion = time.Now()
+			if err := deleteOrphanBlobFiles(); err != nil {
+				logger.Warnf("error deleting unreferenced image files: %s", err.Error())
+			}
+		}
+	}
+}
+
+// deletePendingBlobFiles deletes the image files marked for deletion.
+// Only committed markings are visible, so files are never deleted while a
+// transaction which may be rolled back still references them.
+func deletePendingBlobFiles() error {
+	path, _ := getBlobStorage()
+	if database.DB == nil || path == "" {
+		return nil
+	}
+
+	var files []string
+	if err := database.DB.Select(&files, "SELECT path FROM blob_file_deletions"); err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	tx, err := database.DB.BeginTxx(context.TODO(), nil)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		fn := filepath.Join(path, filepath.FromSlash(f))
+		if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+			logger.Warnf("error deleting image file %s: %s", fn, err.Error())
+			continue
+		}
+
+		if _, err := tx.Exec("DELETE FROM blob_file_deletions WHERE path = ?", f); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// deleteOrphanBlobFiles deletes the image files older than blobOrphanAge
+// which are not referenced by an image table.
+func deleteOrphanBlobFiles() error {
+	path, _ := getBlobStorage()
+	if database.DB == nil || path == "" {
+		return nil
+	}
+
+	referenced := make(map[string]bool)
+	for _, c := range blobColumns {
+		var files []string
+		if err := database.DB.Select(&files, "SELECT "+c.fileColumn+" FROM "+c.table+" WHERE "+c.fileColumn+" IS NOT NULL"); err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			referenced[f] = true
+		}
+	}
+
+	tables := make(map[string]bool)
+	for _, c := range blobColumns {
+		tables[c.table] = true
+	}
+
+	for table := range tables {
+		entries, err := ioutil.ReadDir(filepath.Join(path, table))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		for _, e := range entries {
+			name := table + "/" + e.Name()
+			if e.IsDir() || referenced[name] || time.Since(e.ModTime()) < blobOrphanAge {
+				continue
+			}
+
+			logger.Debugf("deleting unreferenced image file %s", name)
+			if err := os.Remove(filepath.Join(path, table, e.Name())); err != nil && !os.IsNotExist(err) {
+				logger.Warnf("error deleting image file %s: %s", name, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// BlobRef identifies the image data of an object.
+type BlobRef struct {
+	column blobColumn
+	ID     int
+}
+
+func (r BlobRef) String() string {
+	return fmt.Sprintf("%s.%s of %d", r.column.table, r.column.column, r.ID)
+}
+
+// FindBlobsToMigrate returns the images not stored in the configured blob
+// storage.
+func FindBlobsToMigrate() ([]BlobRef, error) {
+	_, useFilesystem := getBlobStorage()
+
+	where := "%[1]s IS NOT NULL AND %[2]s IS NULL"
+	if !useFilesystem {
+		where = "%[2]s IS NOT NULL"
+	}
+
+	var ret []BlobRef
+	for _, c := range blobColumns {
+		query := "SELECT " + c.fkColumn + " FROM " + c.table + " WHERE " + fmt.Sprintf(where, c.column, c.fileColumn)
+
+		var ids []int
+		if err := database.DB.Select(&ids, query); err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			ret = append(ret, BlobRef{column: c, ID: id})
+		}
+	}
+
+	return ret, nil
+}
+
+// MigrateBlob moves the image data to the configured blob storage. Image
+// files moved to the database are deleted once the transaction is
+// committed.
+func MigrateBlob(ref BlobRef, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	c := ref.column
+	data, err := c.get(ref.ID, tx)
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		// the image file is missing
+		return fmt.Errorf("image data for %s not found", ref)
+	}
+
+	var file sql.NullString
+	_, useFilesystem := getBlobStorage()
+	if useFilesystem {
+		data, file, err = c.store(ref.ID, data)
+		if err != nil {
+			return err
+		}
+	} else if err := destroyImageFiles(ref.ID, tx, c); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE "+c.table+" SET "+c.column+" = ?, "+c.fileColumn+" = ? WHERE "+c.fkColumn+" = ?", data, file, ref.ID)
+	return err
+}
@@ -0,0 +1,147 @@
+// +build integration
+
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestBlobStorageFilesystem(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	models.SetBlobStorage(dir, true)
+	defer models.SetBlobStorage("", false)
+
+	mqb := models.NewMovieQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.movie(models.Movie{Name: sql.NullString{String: "TestBlobStorageFilesystem", Valid: true}})
+
+	frontImage := []byte("frontImage")
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return mqb.UpdateMovieImages(created.ID, frontImage, nil, tx)
+	})
+
+	var frontImageFile sql.NullString
+	if err := database.DB.Get(&frontImageFile, "SELECT front_image_file FROM movies_images WHERE movie_id = ?", created.ID); err != nil {
+		t.Fatalf("Error getting front image file: %s", err.Error())
+	}
+	assert.True(t, frontImageFile.Valid)
+
+	frontImagePath := filepath.Join(dir, filepath.FromSlash(frontImageFile.String))
+	data, _ := ioutil.ReadFile(frontImagePath)
+	assert.Equal(t, frontImage, data)
+
+	storedFront, err := mqb.GetFrontImage(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting front image: %s", err.Error())
+	}
+	assert.Equal(t, frontImage, storedFront)
+
+	// replacing the image in a transaction that is rolled back leaves the
+	// committed image file in place
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := mqb.UpdateMovieImages(created.ID, []byte("replaced"), nil, tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error updating movie images: %s", err.Error())
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Error rolling back: %s", err.Error())
+	}
+
+	if err := models.DeletePendingBlobFiles(); err != nil {
+		t.Fatalf("Error deleting image files: %s", err.Error())
+	}
+
+	storedFront, err = mqb.GetFrontImage(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting front image: %s", err.Error())
+	}
+	assert.Equal(t, frontImage, storedFront)
+
+	storedBack, err := mqb.GetBackImage(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting back image: %s", err.Error())
+	}
+	assert.Nil(t, storedBack)
+
+	// images are not migrated while they are in the configured storage
+	assert.NotContains(t, blobsToMigrate(t), blobString(created.ID))
+
+	// switching to the database reads existing files and stores new images
+	// in the database
+	models.SetBlobStorage(dir, false)
+
+	storedFront, err = mqb.GetFrontImage(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting front image: %s", err.Error())
+	}
+	assert.Equal(t, frontImage, storedFront)
+
+	var migrated bool
+	for _, ref := range findBlobsToMigrate(t) {
+		if ref.String() != blobString(created.ID) {
+			continue
+		}
+
+		withTxn(t, func(tx *sqlx.Tx) error {
+			return models.MigrateBlob(ref, tx)
+		})
+		migrated = true
+	}
+	assert.True(t, migrated)
+
+	// the image file is deleted once the migration is committed
+	if err := models.DeletePendingBlobFiles(); err != nil {
+		t.Fatalf("Error deleting image files: %s", err.Error())
+	}
+
+	_, err = os.Stat(frontImagePath)
+	assert.True(t, os.IsNotExist(err))
+
+	storedFront, err = mqb.GetFrontImage(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting front image: %s", err.Error())
+	}
+	assert.Equal(t, frontImage, storedFront)
+}
+
+func blobString(movieID int) string {
+	return "movies_images.front_image of " + strconv.Itoa(movieID)
+}
+
+func findBlobsToMigrate(t *testing.T) []models.BlobRef {
+	refs, err := models.FindBlobsToMigrate()
+	if err != nil {
+		t.Fatalf("Error finding images to migrate: %s", err.Error())
+	}
+
+	return refs
+}
+
+func blobsToMigrate(t *testing.T) []string {
+	var ret []string
+	for _, ref := range findBlobsToMigrate(t) {
+		ret = append(ret, ref.String())
+	}
+
+	return ret
+}
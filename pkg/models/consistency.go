@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/database"
+)
+
+// FindDanglingReferences returns the rows referencing rows which no longer
+// exist through their foreign keys. Such rows may have been left by
+// versions which did not enforce foreign keys.
+func FindDanglingReferences() ([]*DanglingReference, error) {
+	rows, err := database.DB.Queryx(`
+		SELECT c."table", c.rowid, c.parent, f."from"
+		FROM pragma_foreign_key_check() AS c
+		JOIN pragma_foreign_key_list(c."table") AS f ON f.id = c.fkid
+		WHERE c.rowid IS NOT NULL
+		ORDER BY c."table", f."from", c.rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*DanglingReference
+	for rows.Next() {
+		var r DanglingReference
+		if err := rows.Scan(&r.Table, &r.RowID, &r.ParentTable, &r.Column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ret = append(ret, &r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, r := range ret {
+		// the names come from the database schema
+		query := fmt.Sprintf("SELECT `%s` FROM `%s` WHERE rowid = ?", r.Column, r.Table)
+
+		var parentID sql.NullInt64
+		if err := database.DB.Get(&parentID, query, r.RowID); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			r.ParentID = &id
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,53 @@
+// +build integration
+
+package models_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestFindDanglingReferences(t *testing.T) {
+	const missingTagID = 999999
+
+	ctx := context.TODO()
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Error getting connection: %s", err.Error())
+	}
+	defer conn.Close()
+
+	// foreign keys were not enforced by older versions
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("Error disabling foreign keys: %s", err.Error())
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	result, err := conn.ExecContext(ctx, "INSERT INTO scenes_tags (scene_id, tag_id) VALUES (?, ?)", sceneIDs[0], missingTagID)
+	if err != nil {
+		t.Fatalf("Error adding dangling reference: %s", err.Error())
+	}
+	rowID, _ := result.LastInsertId()
+	defer conn.ExecContext(ctx, "DELETE FROM scenes_tags WHERE rowid = ?", rowID)
+
+	refs, err := models.FindDanglingReferences()
+	if err != nil {
+		t.Fatalf("Error finding dangling references: %s", err.Error())
+	}
+
+	missingID := missingTagID
+	assert.Equal(t, []*models.DanglingReference{
+		{
+			Table:       "scenes_tags",
+			Column:      "tag_id",
+			RowID:       int(rowID),
+			ParentTable: "tags",
+			ParentID:    &missingID,
+		},
+	}, refs)
+}
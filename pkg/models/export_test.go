@@ -0,0 +1,14 @@
+package models
+
+// DeletePendingBlobFiles deletes the image files marked for deletion, so that
+// tests need not wait for the image file deleter.
+var DeletePendingBlobFiles = deletePendingBlobFiles
+
+// SetApproximateCountLimit sets the number of results at which approximate
+// counts of filtered queries stop, so that tests need not create as many
+// results. Returns the previous limit.
+func SetApproximateCountLimit(limit int) int {
+	ret := approximateCountLimit
+	approximateCountLimit = limit
+	return ret
+}
@@ -1,5 +1,15 @@
 package models
 
+// approximateCounts is true if find queries should return approximate counts
+// unless an exact count is requested.
+var approximateCounts bool
+
+// SetApproximateCounts sets whether find queries return approximate counts
+// when an exact count is not requested by the find filter.
+func SetApproximateCounts(enabled bool) {
+	approximateCounts = enabled
+}
+
 func (ff FindFilterType) GetSort(defaultSort string) string {
 	var sort string
 	if ff.Sort == nil {
@@ -23,3 +33,79 @@ func (ff FindFilterType) GetDirection() string {
 	}
 	return direction
 }
+
+// getSortFields returns the fields to sort the results by, in order of
+// precedence. sorts takes precedence over sort and direction. Fields without
+// a direction are sorted in defaultDirection.
+func (ff FindFilterType) getSortFields(defaultSort string, defaultDirection string) []sortField {
+	if len(ff.Sorts) == 0 {
+		direction := defaultDirection
+		if ff.Direction != nil {
+			direction = ff.GetDirection()
+		}
+
+		return []sortField{{sort: ff.GetSort(defaultSort), direction: direction}}
+	}
+
+	var ret []sortField
+	for _, s := range ff.Sorts {
+		direction := defaultDirection
+		if s.Direction != nil && s.Direction.IsValid() {
+			direction = s.Direction.String()
+		}
+
+		ret = append(ret, sortField{sort: s.Sort, direction: direction})
+	}
+
+	return ret
+}
+
+// IsApproximateCount returns true if the count of results of the query may be
+// approximated.
+func (ff FindFilterType) IsApproximateCount() bool {
+	if ff.ExactCount != nil && *ff.ExactCount {
+		return false
+	}
+	return approximateCounts
+}
+
+// IsCountApproximate returns true if count, the count of results of a query
+// with the find filter, is approximate. Counts of fewer results than the
+// limit of approximate counts are exact. The counts of filtered queries stop
+// at the limit, so more results may match the filter.
+func (ff *FindFilterType) IsCountApproximate(count int) bool {
+	approximate := approximateCounts
+	if ff != nil {
+		approximate = ff.IsApproximateCount()
+	}
+
+	return approximate && count >= approximateCountLimit
+}
+
+// IsCursorPaginated returns true if the page of results is selected by cursor
+// rather than by page number.
+func (ff *FindFilterType) IsCursorPaginated() bool {
+	return ff != nil && (ff.After != nil || ff.First != nil)
+}
+
+// GetFirst returns the number of results of a page selected by cursor. The
+// number of results per page is used if first is not set.
+func (ff FindFilterType) GetFirst() int {
+	if ff.First != nil {
+		return getPerPage(ff.First)
+	}
+
+	return getPerPage(ff.PerPage)
+}
+
+// NextCursor returns the cursor of the page of results after a page of count
+// results, the last of which has the id lastID. nil is returned if the page
+// was not selected by cursor, or was not full so has no results after it.
+func (ff *FindFilterType) NextCursor(count int, lastID int) *string {
+	if !ff.IsCursorPaginated() || count < ff.GetFirst() {
+		return nil
+	}
+
+	ret := encodeFindCursor(lastID)
+	return &ret
+}
@@ -14,7 +14,7 @@ type GalleryReader interface {
 	// ValidGalleriesForScenePath(scenePath string) ([]*Gallery, error)
 	// Count() (int, error)
 	All() ([]*Gallery, error)
-	// Query(galleryFilter *GalleryFilterType, findFilter *FindFilterType) ([]*Gallery, int)
+	// Query(galleryFilter *GalleryFilterType, findFilter *FindFilterType) ([]*Gallery, int, error)
 }
 
 type GalleryWriter interface {
@@ -18,7 +18,7 @@ type ImageReader interface {
 	// CountByStudioID(studioID int) (int, error)
 	// CountByTagID(tagID int) (int, error)
 	All() ([]*Image, error)
-	// Query(imageFilter *ImageFilterType, findFilter *FindFilterType) ([]*Image, int)
+	// Query(imageFilter *ImageFilterType, findFilter *FindFilterType) ([]*Image, int, error)
 }
 
 type ImageWriter interface {
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var libraries struct {
+	mutex sync.RWMutex
+	// paths maps the names of the libraries to their paths
+	paths map[string]string
+}
+
+// LibraryName returns the name of the library, or its path if it has no
+// name.
+func (s StashConfig) LibraryName() string {
+	if s.Name != nil && *s.Name != "" {
+		return *s.Name
+	}
+
+	return s.Path
+}
+
+// SetLibraries sets the libraries matched by library filter criteria.
+func SetLibraries(stashes []*StashConfig) {
+	paths := make(map[string]string)
+	for _, s := range stashes {
+		paths[s.LibraryName()] = s.Path
+	}
+
+	libraries.mutex.Lock()
+	libraries.paths = paths
+	libraries.mutex.Unlock()
+}
+
+func getLibraryPath(name string) (string, bool) {
+	libraries.mutex.RLock()
+	defer libraries.mutex.RUnlock()
+
+	path, found := libraries.paths[name]
+	return path, found
+}
+
+// libraryCriterionHandler filters by the library containing the file at the
+// path held by column.
+func libraryCriterionHandler(name *string, column string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if name == nil {
+			return
+		}
+
+		path, found := getLibraryPath(*name)
+		if !found {
+			f.setError(fmt.Errorf("library %q not found", *name))
+			return
+		}
+
+		prefix := strings.TrimRight(path, `/\`) + string(filepath.Separator)
+		f.where("substr("+column+", 1, length(?)) = ?", prefix, prefix)
+	}
+}
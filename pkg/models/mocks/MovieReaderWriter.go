@@ -196,6 +196,52 @@ func (_m *MovieReaderWriter) GetFrontImage(movieID int) ([]byte, error) {
 	return r0, r1
 }
 
+// GetURLs provides a mock function with given fields: movieID
+func (_m *MovieReaderWriter) GetURLs(movieID int) ([]string, error) {
+	ret := _m.Called(movieID)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(int) []string); ok {
+		r0 = rf(movieID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(movieID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCustomFields provides a mock function with given fields: movieID
+func (_m *MovieReaderWriter) GetCustomFields(movieID int) (map[string]string, error) {
+	ret := _m.Called(movieID)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(int) map[string]string); ok {
+		r0 = rf(movieID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(movieID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: updatedMovie
 func (_m *MovieReaderWriter) Update(updatedMovie models.MoviePartial) (*models.Movie, error) {
 	ret := _m.Called(updatedMovie)
@@ -255,3 +301,31 @@ func (_m *MovieReaderWriter) UpdateMovieImages(movieID int, frontImage []byte, b
 
 	return r0
 }
+
+// UpdateCustomFields provides a mock function with given fields: movieID, fields
+func (_m *MovieReaderWriter) UpdateCustomFields(movieID int, fields map[string]string) error {
+	ret := _m.Called(movieID, fields)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, map[string]string) error); ok {
+		r0 = rf(movieID, fields)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateURLs provides a mock function with given fields: movieID, urls
+func (_m *MovieReaderWriter) UpdateURLs(movieID int, urls []string) error {
+	ret := _m.Called(movieID, urls)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, []string) error); ok {
+		r0 = rf(movieID, urls)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -219,6 +219,29 @@ func (_m *PerformerReaderWriter) GetPerformerImage(performerID int) ([]byte, err
 	return r0, r1
 }
 
+// GetURLs provides a mock function with given fields: performerID
+func (_m *PerformerReaderWriter) GetURLs(performerID int) ([]models.PerformerURL, error) {
+	ret := _m.Called(performerID)
+
+	var r0 []models.PerformerURL
+	if rf, ok := ret.Get(0).(func(int) []models.PerformerURL); ok {
+		r0 = rf(performerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PerformerURL)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(performerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: updatedPerformer
 func (_m *PerformerReaderWriter) Update(updatedPerformer models.PerformerPartial) (*models.Performer, error) {
 	ret := _m.Called(updatedPerformer)
@@ -278,3 +301,17 @@ func (_m *PerformerReaderWriter) UpdatePerformerImage(performerID int, image []b
 
 	return r0
 }
+
+// UpdateURLs provides a mock function with given fields: performerID, urls
+func (_m *PerformerReaderWriter) UpdateURLs(performerID int, urls []models.PerformerURL) error {
+	ret := _m.Called(performerID, urls)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, []models.PerformerURL) error); ok {
+		r0 = rf(performerID, urls)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
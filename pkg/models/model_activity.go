@@ -0,0 +1,15 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// Activity is a change made to an object, recorded from the event bus.
+type Activity struct {
+	ID        int             `db:"id" json:"id"`
+	Entity    string          `db:"entity" json:"entity"`
+	Action    string          `db:"action" json:"action"`
+	EntityID  int             `db:"entity_id" json:"entity_id"`
+	Actor     sql.NullString  `db:"actor" json:"actor"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
@@ -18,27 +18,37 @@ type Gallery struct {
 	StudioID    sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
 	SceneID     sql.NullInt64       `db:"scene_id,omitempty" json:"scene_id"`
 	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
-	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
-	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+	UUID        sql.NullString      `db:"uuid" json:"uuid"`
+	// CoverImageID is the chosen cover image, and AutoCoverImageID the cover
+	// image used if none is chosen
+	CoverImageID     sql.NullInt64   `db:"cover_image_id,omitempty" json:"cover_image_id"`
+	AutoCoverImageID sql.NullInt64   `db:"auto_cover_image_id,omitempty" json:"auto_cover_image_id"`
+	CreatedAt        SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt        SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	// TitleSort and PathSort are the natural sort keys of Title and Path,
+	// maintained by the database
+	TitleSort sql.NullString `db:"title_sort" json:"title_sort"`
+	PathSort  sql.NullString `db:"path_sort" json:"path_sort"`
 }
 
 // GalleryPartial represents part of a Gallery object. It is used to update
 // the database entry. Only non-nil fields will be updated.
 type GalleryPartial struct {
-	ID          int                  `db:"id" json:"id"`
-	Path        *sql.NullString      `db:"path" json:"path"`
-	Checksum    *string              `db:"checksum" json:"checksum"`
-	Title       *sql.NullString      `db:"title" json:"title"`
-	URL         *sql.NullString      `db:"url" json:"url"`
-	Date        *SQLiteDate          `db:"date" json:"date"`
-	Details     *sql.NullString      `db:"details" json:"details"`
-	Rating      *sql.NullInt64       `db:"rating" json:"rating"`
-	Organized   *bool                `db:"organized" json:"organized"`
-	StudioID    *sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
-	SceneID     *sql.NullInt64       `db:"scene_id,omitempty" json:"scene_id"`
-	FileModTime *NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
-	CreatedAt   *SQLiteTimestamp     `db:"created_at" json:"created_at"`
-	UpdatedAt   *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+	ID           int                  `db:"id" json:"id"`
+	Path         *sql.NullString      `db:"path" json:"path"`
+	Checksum     *string              `db:"checksum" json:"checksum"`
+	Title        *sql.NullString      `db:"title" json:"title"`
+	URL          *sql.NullString      `db:"url" json:"url"`
+	Date         *SQLiteDate          `db:"date" json:"date"`
+	Details      *sql.NullString      `db:"details" json:"details"`
+	Rating       *sql.NullInt64       `db:"rating" json:"rating"`
+	Organized    *bool                `db:"organized" json:"organized"`
+	StudioID     *sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
+	SceneID      *sql.NullInt64       `db:"scene_id,omitempty" json:"scene_id"`
+	FileModTime  *NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	CoverImageID *sql.NullInt64       `db:"cover_image_id,omitempty" json:"cover_image_id"`
+	CreatedAt    *SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt    *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
 }
 
 const DefaultGthumbWidth int = 640
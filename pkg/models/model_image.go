@@ -18,8 +18,14 @@ type Image struct {
 	Height      sql.NullInt64       `db:"height" json:"height"`
 	StudioID    sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
 	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	UUID        sql.NullString      `db:"uuid" json:"uuid"`
+	Phash       sql.NullInt64       `db:"phash,omitempty" json:"phash"`
 	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
 	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+	// TitleSort and PathSort are the natural sort keys of Title and Path,
+	// maintained by the database
+	TitleSort sql.NullString `db:"title_sort" json:"title_sort"`
+	PathSort  sql.NullString `db:"path_sort" json:"path_sort"`
 }
 
 // ImagePartial represents part of a Image object. It is used to update
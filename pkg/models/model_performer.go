@@ -8,27 +8,37 @@ import (
 )
 
 type Performer struct {
-	ID           int             `db:"id" json:"id"`
-	Checksum     string          `db:"checksum" json:"checksum"`
-	Name         sql.NullString  `db:"name" json:"name"`
-	Gender       sql.NullString  `db:"gender" json:"gender"`
-	URL          sql.NullString  `db:"url" json:"url"`
-	Twitter      sql.NullString  `db:"twitter" json:"twitter"`
-	Instagram    sql.NullString  `db:"instagram" json:"instagram"`
-	Birthdate    SQLiteDate      `db:"birthdate" json:"birthdate"`
-	Ethnicity    sql.NullString  `db:"ethnicity" json:"ethnicity"`
-	Country      sql.NullString  `db:"country" json:"country"`
-	EyeColor     sql.NullString  `db:"eye_color" json:"eye_color"`
-	Height       sql.NullString  `db:"height" json:"height"`
-	Measurements sql.NullString  `db:"measurements" json:"measurements"`
-	FakeTits     sql.NullString  `db:"fake_tits" json:"fake_tits"`
-	CareerLength sql.NullString  `db:"career_length" json:"career_length"`
-	Tattoos      sql.NullString  `db:"tattoos" json:"tattoos"`
-	Piercings    sql.NullString  `db:"piercings" json:"piercings"`
-	Aliases      sql.NullString  `db:"aliases" json:"aliases"`
-	Favorite     sql.NullBool    `db:"favorite" json:"favorite"`
-	CreatedAt    SQLiteTimestamp `db:"created_at" json:"created_at"`
-	UpdatedAt    SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	ID           int            `db:"id" json:"id"`
+	Checksum     string         `db:"checksum" json:"checksum"`
+	Name         sql.NullString `db:"name" json:"name"`
+	Gender       sql.NullString `db:"gender" json:"gender"`
+	Birthdate    SQLiteDate     `db:"birthdate" json:"birthdate"`
+	Ethnicity    sql.NullString `db:"ethnicity" json:"ethnicity"`
+	Country      sql.NullString `db:"country" json:"country"`
+	EyeColor     sql.NullString `db:"eye_color" json:"eye_color"`
+	Height       sql.NullString `db:"height" json:"height"`
+	Measurements sql.NullString `db:"measurements" json:"measurements"`
+	// HeightCm, Bust, Waist and Hips are parsed from Height and Measurements
+	HeightCm sql.NullInt64 `db:"height_cm" json:"height_cm"`
+	Bust     sql.NullInt64 `db:"bust" json:"bust"`
+	Waist    sql.NullInt64 `db:"waist" json:"waist"`
+	Hips     sql.NullInt64 `db:"hips" json:"hips"`
+	// Weight is in kilograms
+	Weight               sql.NullInt64   `db:"weight" json:"weight"`
+	FakeTits             sql.NullString  `db:"fake_tits" json:"fake_tits"`
+	CareerLength         sql.NullString  `db:"career_length" json:"career_length"`
+	Tattoos              sql.NullString  `db:"tattoos" json:"tattoos"`
+	Piercings            sql.NullString  `db:"piercings" json:"piercings"`
+	Aliases              sql.NullString  `db:"aliases" json:"aliases"`
+	Favorite             sql.NullBool    `db:"favorite" json:"favorite"`
+	Slug                 sql.NullString  `db:"slug" json:"slug"`
+	UUID                 sql.NullString  `db:"uuid" json:"uuid"`
+	AutoTagMinConfidence sql.NullFloat64 `db:"auto_tag_min_confidence" json:"auto_tag_min_confidence"`
+	PinnedPosition       sql.NullInt64   `db:"pinned_position" json:"pinned_position"`
+	CreatedAt            SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt            SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	// NameSort is the natural sort key of Name, maintained by the database
+	NameSort sql.NullString `db:"name_sort" json:"name_sort"`
 }
 
 type PerformerPartial struct {
@@ -36,15 +46,17 @@ type PerformerPartial struct {
 	Checksum     *string          `db:"checksum" json:"checksum"`
 	Name         *sql.NullString  `db:"name" json:"name"`
 	Gender       *sql.NullString  `db:"gender" json:"gender"`
-	URL          *sql.NullString  `db:"url" json:"url"`
-	Twitter      *sql.NullString  `db:"twitter" json:"twitter"`
-	Instagram    *sql.NullString  `db:"instagram" json:"instagram"`
 	Birthdate    *SQLiteDate      `db:"birthdate" json:"birthdate"`
 	Ethnicity    *sql.NullString  `db:"ethnicity" json:"ethnicity"`
 	Country      *sql.NullString  `db:"country" json:"country"`
 	EyeColor     *sql.NullString  `db:"eye_color" json:"eye_color"`
 	Height       *sql.NullString  `db:"height" json:"height"`
 	Measurements *sql.NullString  `db:"measurements" json:"measurements"`
+	HeightCm     *sql.NullInt64   `db:"height_cm" json:"height_cm"`
+	Bust         *sql.NullInt64   `db:"bust" json:"bust"`
+	Waist        *sql.NullInt64   `db:"waist" json:"waist"`
+	Hips         *sql.NullInt64   `db:"hips" json:"hips"`
+	Weight       *sql.NullInt64   `db:"weight" json:"weight"`
 	FakeTits     *sql.NullString  `db:"fake_tits" json:"fake_tits"`
 	CareerLength *sql.NullString  `db:"career_length" json:"career_length"`
 	Tattoos      *sql.NullString  `db:"tattoos" json:"tattoos"`
@@ -55,6 +67,45 @@ type PerformerPartial struct {
 	UpdatedAt    *SQLiteTimestamp `db:"updated_at" json:"updated_at"`
 }
 
+// PerformerURL is a link of a performer to a site, such as twitter or the
+// name of the domain of the link.
+type PerformerURL struct {
+	Site string `db:"site" json:"site"`
+	URL  string `db:"url" json:"url"`
+}
+
+// setParsedMeasurements sets the numeric height, bust, waist and hips of the
+// performer from its height and measurements. They are null if the height
+// or measurements cannot be parsed.
+func (p *Performer) setParsedMeasurements() {
+	p.HeightCm = parseHeight(p.Height)
+	p.Bust, p.Waist, p.Hips = parseMeasurements(p.Measurements)
+}
+
+// setParsedMeasurements sets the numeric height, bust, waist and hips of the
+// performer from the height and measurements being updated.
+func (p *PerformerPartial) setParsedMeasurements() {
+	if p.Height != nil {
+		heightCm := parseHeight(*p.Height)
+		p.HeightCm = &heightCm
+	}
+	if p.Measurements != nil {
+		bust, waist, hips := parseMeasurements(*p.Measurements)
+		p.Bust, p.Waist, p.Hips = &bust, &waist, &hips
+	}
+}
+
+func parseHeight(height sql.NullString) sql.NullInt64 {
+	value, ok := utils.ParseHeight(height.String)
+	return sql.NullInt64{Int64: int64(value), Valid: height.Valid && ok}
+}
+
+func parseMeasurements(measurements sql.NullString) (bust sql.NullInt64, waist sql.NullInt64, hips sql.NullInt64) {
+	b, w, h, ok := utils.ParseMeasurements(measurements.String)
+	ok = ok && measurements.Valid
+	return sql.NullInt64{Int64: int64(b), Valid: ok}, sql.NullInt64{Int64: int64(w), Valid: ok}, sql.NullInt64{Int64: int64(h), Valid: ok}
+}
+
 func NewPerformer(name string) *Performer {
 	currentTime := time.Now()
 	return &Performer{
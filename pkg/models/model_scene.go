@@ -7,59 +7,72 @@ import (
 
 // Scene stores the metadata for a single video scene.
 type Scene struct {
-	ID          int                 `db:"id" json:"id"`
-	Checksum    sql.NullString      `db:"checksum" json:"checksum"`
-	OSHash      sql.NullString      `db:"oshash" json:"oshash"`
-	Path        string              `db:"path" json:"path"`
-	Title       sql.NullString      `db:"title" json:"title"`
-	Details     sql.NullString      `db:"details" json:"details"`
-	URL         sql.NullString      `db:"url" json:"url"`
-	Date        SQLiteDate          `db:"date" json:"date"`
-	Rating      sql.NullInt64       `db:"rating" json:"rating"`
-	Organized   bool                `db:"organized" json:"organized"`
-	OCounter    int                 `db:"o_counter" json:"o_counter"`
-	Size        sql.NullString      `db:"size" json:"size"`
-	Duration    sql.NullFloat64     `db:"duration" json:"duration"`
-	VideoCodec  sql.NullString      `db:"video_codec" json:"video_codec"`
-	Format      sql.NullString      `db:"format" json:"format_name"`
-	AudioCodec  sql.NullString      `db:"audio_codec" json:"audio_codec"`
-	Width       sql.NullInt64       `db:"width" json:"width"`
-	Height      sql.NullInt64       `db:"height" json:"height"`
-	Framerate   sql.NullFloat64     `db:"framerate" json:"framerate"`
-	Bitrate     sql.NullInt64       `db:"bitrate" json:"bitrate"`
-	StudioID    sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
-	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
-	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
-	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+	ID               int                 `db:"id" json:"id"`
+	Checksum         sql.NullString      `db:"checksum" json:"checksum"`
+	OSHash           sql.NullString      `db:"oshash" json:"oshash"`
+	Phash            sql.NullInt64       `db:"phash,omitempty" json:"phash"`
+	Path             string              `db:"path" json:"path"`
+	Title            sql.NullString      `db:"title" json:"title"`
+	Details          sql.NullString      `db:"details" json:"details"`
+	URL              sql.NullString      `db:"url" json:"url"`
+	Date             SQLiteDate          `db:"date" json:"date"`
+	Rating           sql.NullInt64       `db:"rating" json:"rating"`
+	Organized        bool                `db:"organized" json:"organized"`
+	OCounter         int                 `db:"o_counter" json:"o_counter"`
+	Size             sql.NullString      `db:"size" json:"size"`
+	Duration         sql.NullFloat64     `db:"duration" json:"duration"`
+	VideoCodec       sql.NullString      `db:"video_codec" json:"video_codec"`
+	Format           sql.NullString      `db:"format" json:"format_name"`
+	AudioCodec       sql.NullString      `db:"audio_codec" json:"audio_codec"`
+	Width            sql.NullInt64       `db:"width" json:"width"`
+	Height           sql.NullInt64       `db:"height" json:"height"`
+	Framerate        sql.NullFloat64     `db:"framerate" json:"framerate"`
+	Bitrate          sql.NullInt64       `db:"bitrate" json:"bitrate"`
+	StudioID         sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
+	FileModTime      NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	FileCreationTime NullSQLiteTimestamp `db:"file_creation_time" json:"file_creation_time"`
+	UUID             sql.NullString      `db:"uuid" json:"uuid"`
+	CoverURL         sql.NullString      `db:"cover_url" json:"cover_url"`
+	BackgroundURL    sql.NullString      `db:"background_url" json:"background_url"`
+	CreatedAt        SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt        SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+	// TitleSort and PathSort are the natural sort keys of Title and Path,
+	// maintained by the database
+	TitleSort sql.NullString `db:"title_sort" json:"title_sort"`
+	PathSort  sql.NullString `db:"path_sort" json:"path_sort"`
 }
 
 // ScenePartial represents part of a Scene object. It is used to update
 // the database entry. Only non-nil fields will be updated.
 type ScenePartial struct {
-	ID          int                  `db:"id" json:"id"`
-	Checksum    *sql.NullString      `db:"checksum" json:"checksum"`
-	OSHash      *sql.NullString      `db:"oshash" json:"oshash"`
-	Path        *string              `db:"path" json:"path"`
-	Title       *sql.NullString      `db:"title" json:"title"`
-	Details     *sql.NullString      `db:"details" json:"details"`
-	URL         *sql.NullString      `db:"url" json:"url"`
-	Date        *SQLiteDate          `db:"date" json:"date"`
-	Rating      *sql.NullInt64       `db:"rating" json:"rating"`
-	Organized   *bool                `db:"organized" json:"organized"`
-	Size        *sql.NullString      `db:"size" json:"size"`
-	Duration    *sql.NullFloat64     `db:"duration" json:"duration"`
-	VideoCodec  *sql.NullString      `db:"video_codec" json:"video_codec"`
-	Format      *sql.NullString      `db:"format" json:"format_name"`
-	AudioCodec  *sql.NullString      `db:"audio_codec" json:"audio_codec"`
-	Width       *sql.NullInt64       `db:"width" json:"width"`
-	Height      *sql.NullInt64       `db:"height" json:"height"`
-	Framerate   *sql.NullFloat64     `db:"framerate" json:"framerate"`
-	Bitrate     *sql.NullInt64       `db:"bitrate" json:"bitrate"`
-	StudioID    *sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
-	MovieID     *sql.NullInt64       `db:"movie_id,omitempty" json:"movie_id"`
-	FileModTime *NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
-	CreatedAt   *SQLiteTimestamp     `db:"created_at" json:"created_at"`
-	UpdatedAt   *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+	ID               int                  `db:"id" json:"id"`
+	Checksum         *sql.NullString      `db:"checksum" json:"checksum"`
+	OSHash           *sql.NullString      `db:"oshash" json:"oshash"`
+	Phash            *sql.NullInt64       `db:"phash,omitempty" json:"phash"`
+	Path             *string              `db:"path" json:"path"`
+	Title            *sql.NullString      `db:"title" json:"title"`
+	Details          *sql.NullString      `db:"details" json:"details"`
+	URL              *sql.NullString      `db:"url" json:"url"`
+	Date             *SQLiteDate          `db:"date" json:"date"`
+	Rating           *sql.NullInt64       `db:"rating" json:"rating"`
+	Organized        *bool                `db:"organized" json:"organized"`
+	Size             *sql.NullString      `db:"size" json:"size"`
+	Duration         *sql.NullFloat64     `db:"duration" json:"duration"`
+	VideoCodec       *sql.NullString      `db:"video_codec" json:"video_codec"`
+	Format           *sql.NullString      `db:"format" json:"format_name"`
+	AudioCodec       *sql.NullString      `db:"audio_codec" json:"audio_codec"`
+	Width            *sql.NullInt64       `db:"width" json:"width"`
+	Height           *sql.NullInt64       `db:"height" json:"height"`
+	Framerate        *sql.NullFloat64     `db:"framerate" json:"framerate"`
+	Bitrate          *sql.NullInt64       `db:"bitrate" json:"bitrate"`
+	StudioID         *sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
+	MovieID          *sql.NullInt64       `db:"movie_id,omitempty" json:"movie_id"`
+	FileModTime      *NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	FileCreationTime *NullSQLiteTimestamp `db:"file_creation_time" json:"file_creation_time"`
+	CoverURL         *sql.NullString      `db:"cover_url" json:"cover_url"`
+	BackgroundURL    *sql.NullString      `db:"background_url" json:"background_url"`
+	CreatedAt        *SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt        *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
 }
 
 // GetTitle returns the title of the scene. If the Title field is empty,
@@ -84,6 +97,29 @@ func (s Scene) GetHash(hashAlgorithm HashAlgorithm) string {
 	panic("unknown hash algorithm")
 }
 
+// SceneChapter is a chapter embedded in the scene file.
+type SceneChapter struct {
+	SceneID    int             `db:"scene_id" json:"scene_id"`
+	Position   int             `db:"position" json:"position"`
+	Seconds    float64         `db:"seconds" json:"seconds"`
+	EndSeconds sql.NullFloat64 `db:"end_seconds" json:"end_seconds"`
+	Title      string          `db:"title" json:"title"`
+}
+
+// SceneCaption is a caption sidecar file of the scene file, in the directory
+// of the scene file.
+type SceneCaption struct {
+	SceneID      int    `db:"scene_id" json:"scene_id"`
+	Filename     string `db:"filename" json:"filename"`
+	LanguageCode string `db:"language_code" json:"language_code"`
+	CaptionType  string `db:"caption_type" json:"caption_type"`
+}
+
+// Path returns the path of the caption file of the scene file at scenePath.
+func (c SceneCaption) Path(scenePath string) string {
+	return filepath.Join(filepath.Dir(scenePath), c.Filename)
+}
+
 // SceneFileType represents the file metadata for a scene.
 type SceneFileType struct {
 	Size       *string  `graphql:"size" json:"size"`
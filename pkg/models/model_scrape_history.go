@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Types of object that scrape history is recorded for.
+const (
+	ScrapeHistoryEntityScene   = "scene"
+	ScrapeHistoryEntityGallery = "gallery"
+
+	// ScrapeHistoryEntityScrapedItem is the entity of the items of the legacy
+	// scraped_items table, which are not associated with an object. The
+	// result of these entries is the item in the format of the scraped items
+	// export file.
+	ScrapeHistoryEntityScrapedItem = "scraped_item"
+)
+
+// ScrapeHistorySourceScrapedItems is the source of the legacy scraped items.
+const ScrapeHistorySourceScrapedItems = "scraped_items"
+
+// ScrapeHistorySourceDateInference is the source of the scene dates inferred
+// by the date inference task. The result of these entries holds the date
+// and the source it was inferred from.
+const ScrapeHistorySourceDateInference = "date_inference"
+
+// SceneDateInference is the result of the scrape history entries of the
+// date inference task.
+type SceneDateInference struct {
+	Date   string              `json:"date"`
+	Source DateInferenceSource `json:"date_source"`
+}
+
+// ScrapeHistory records a scrape of an existing object.
+type ScrapeHistory struct {
+	ID       int    `db:"id" json:"id"`
+	Entity   string `db:"entity" json:"entity"`
+	EntityID int    `db:"entity_id" json:"entity_id"`
+	// Source is the ID of the scraper used
+	Source string `db:"source" json:"source"`
+	// Fields is the comma-separated list of fields populated by the scrape
+	Fields string `db:"fields" json:"fields"`
+	// AppliedFields is the comma-separated list of scraped fields that were
+	// saved to the object
+	AppliedFields string `db:"applied_fields" json:"applied_fields"`
+	// InputChecksum identifies the input passed to the scraper
+	InputChecksum string `db:"input_checksum" json:"input_checksum"`
+	// Result is the JSON encoded scrape result
+	Result    sql.NullString  `db:"result" json:"result"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
+
+// GetFields returns the fields populated by the scrape.
+func (h ScrapeHistory) GetFields() []string {
+	if h.Fields == "" {
+		return nil
+	}
+
+	return strings.Split(h.Fields, ",")
+}
+
+// GetAppliedFields returns the scraped fields that were saved to the object.
+func (h ScrapeHistory) GetAppliedFields() []string {
+	if h.AppliedFields == "" {
+		return nil
+	}
+
+	return strings.Split(h.AppliedFields, ",")
+}
@@ -1,28 +1,5 @@
 package models
 
-import (
-	"database/sql"
-)
-
-type ScrapedItem struct {
-	ID              int             `db:"id" json:"id"`
-	Title           sql.NullString  `db:"title" json:"title"`
-	Description     sql.NullString  `db:"description" json:"description"`
-	URL             sql.NullString  `db:"url" json:"url"`
-	Date            SQLiteDate      `db:"date" json:"date"`
-	Rating          sql.NullString  `db:"rating" json:"rating"`
-	Tags            sql.NullString  `db:"tags" json:"tags"`
-	Models          sql.NullString  `db:"models" json:"models"`
-	Episode         sql.NullInt64   `db:"episode" json:"episode"`
-	GalleryFilename sql.NullString  `db:"gallery_filename" json:"gallery_filename"`
-	GalleryURL      sql.NullString  `db:"gallery_url" json:"gallery_url"`
-	VideoFilename   sql.NullString  `db:"video_filename" json:"video_filename"`
-	VideoURL        sql.NullString  `db:"video_url" json:"video_url"`
-	StudioID        sql.NullInt64   `db:"studio_id,omitempty" json:"studio_id"`
-	CreatedAt       SQLiteTimestamp `db:"created_at" json:"created_at"`
-	UpdatedAt       SQLiteTimestamp `db:"updated_at" json:"updated_at"`
-}
-
 type ScrapedPerformer struct {
 	Name         *string `graphql:"name" json:"name"`
 	Gender       *string `graphql:"gender" json:"gender"`
@@ -64,19 +41,20 @@ type ScrapedPerformerStash struct {
 }
 
 type ScrapedScene struct {
-	Title        *string                  `graphql:"title" json:"title"`
-	Details      *string                  `graphql:"details" json:"details"`
-	URL          *string                  `graphql:"url" json:"url"`
-	Date         *string                  `graphql:"date" json:"date"`
-	Image        *string                  `graphql:"image" json:"image"`
-	RemoteSiteID *string                  `graphql:"remote_site_id" json:"remote_site_id"`
-	Duration     *int                     `graphql:"duration" json:"duration"`
-	File         *SceneFileType           `graphql:"file" json:"file"`
-	Fingerprints []*StashBoxFingerprint   `graphql:"fingerprints" json:"fingerprints"`
-	Studio       *ScrapedSceneStudio      `graphql:"studio" json:"studio"`
-	Movies       []*ScrapedSceneMovie     `graphql:"movies" json:"movies"`
-	Tags         []*ScrapedSceneTag       `graphql:"tags" json:"tags"`
-	Performers   []*ScrapedScenePerformer `graphql:"performers" json:"performers"`
+	Title             *string                  `graphql:"title" json:"title"`
+	Details           *string                  `graphql:"details" json:"details"`
+	URL               *string                  `graphql:"url" json:"url"`
+	Date              *string                  `graphql:"date" json:"date"`
+	Image             *string                  `graphql:"image" json:"image"`
+	RemoteSiteID      *string                  `graphql:"remote_site_id" json:"remote_site_id"`
+	DuplicateSceneIDs []string                 `graphql:"duplicate_scene_ids" json:"duplicate_scene_ids"`
+	Duration          *int                     `graphql:"duration" json:"duration"`
+	File              *SceneFileType           `graphql:"file" json:"file"`
+	Fingerprints      []*StashBoxFingerprint   `graphql:"fingerprints" json:"fingerprints"`
+	Studio            *ScrapedSceneStudio      `graphql:"studio" json:"studio"`
+	Movies            []*ScrapedSceneMovie     `graphql:"movies" json:"movies"`
+	Tags              []*ScrapedSceneTag       `graphql:"tags" json:"tags"`
+	Performers        []*ScrapedScenePerformer `graphql:"performers" json:"performers"`
 }
 
 // stash doesn't return image, and we need id
@@ -0,0 +1,21 @@
+package models
+
+// StatsSnapshot is the statistics of the library at the end of a day.
+type StatsSnapshot struct {
+	// Date is the day of the snapshot in YYYY-MM-DD format
+	Date           string  `db:"date" json:"date"`
+	SceneCount     int     `db:"scene_count" json:"scene_count"`
+	ScenesSize     float64 `db:"scenes_size" json:"scenes_size"`
+	ImageCount     int     `db:"image_count" json:"image_count"`
+	ImagesSize     float64 `db:"images_size" json:"images_size"`
+	GalleryCount   int     `db:"gallery_count" json:"gallery_count"`
+	PerformerCount int     `db:"performer_count" json:"performer_count"`
+	StudioCount    int     `db:"studio_count" json:"studio_count"`
+	MovieCount     int     `db:"movie_count" json:"movie_count"`
+	TagCount       int     `db:"tag_count" json:"tag_count"`
+	// StreamSessionCount, StreamDuration and StreamBytes are the totals of
+	// all stream sessions
+	StreamSessionCount int     `db:"stream_session_count" json:"stream_session_count"`
+	StreamDuration     float64 `db:"stream_duration" json:"stream_duration"`
+	StreamBytes        float64 `db:"stream_bytes" json:"stream_bytes"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// StreamSession records the requests made by a client to stream a scene.
+type StreamSession struct {
+	ID      int    `db:"id" json:"id"`
+	SceneID int    `db:"scene_id" json:"scene_id"`
+	Client  string `db:"client" json:"client"`
+	// Transcode is true if the scene was transcoded, live or from the cache
+	Transcode bool `db:"transcode" json:"transcode"`
+	// Duration is the number of seconds during which the client was receiving
+	// data. It is not the length of the scene that was watched, since players
+	// buffer ahead and may skip.
+	Duration  float64         `db:"duration" json:"duration"`
+	Bytes     int64           `db:"bytes" json:"bytes"`
+	StartedAt SQLiteTimestamp `db:"started_at" json:"started_at"`
+	EndedAt   SQLiteTimestamp `db:"ended_at" json:"ended_at"`
+}
+
+// StreamTotals is the aggregate of a set of stream sessions.
+type StreamTotals struct {
+	SessionCount      int     `db:"session_count"`
+	Duration          float64 `db:"duration"`
+	TranscodeDuration float64 `db:"transcode_duration"`
+	Bytes             int64   `db:"bytes"`
+}
+
+// SceneStreamTotals is the aggregate of the stream sessions of a scene.
+type SceneStreamTotals struct {
+	SceneID      int     `db:"scene_id"`
+	SessionCount int     `db:"session_count"`
+	Duration     float64 `db:"duration"`
+}
@@ -8,13 +8,19 @@ import (
 )
 
 type Studio struct {
-	ID        int             `db:"id" json:"id"`
-	Checksum  string          `db:"checksum" json:"checksum"`
-	Name      sql.NullString  `db:"name" json:"name"`
-	URL       sql.NullString  `db:"url" json:"url"`
-	ParentID  sql.NullInt64   `db:"parent_id,omitempty" json:"parent_id"`
-	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
-	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	ID                   int             `db:"id" json:"id"`
+	Checksum             string          `db:"checksum" json:"checksum"`
+	Name                 sql.NullString  `db:"name" json:"name"`
+	URL                  sql.NullString  `db:"url" json:"url"`
+	ParentID             sql.NullInt64   `db:"parent_id,omitempty" json:"parent_id"`
+	Slug                 sql.NullString  `db:"slug" json:"slug"`
+	UUID                 sql.NullString  `db:"uuid" json:"uuid"`
+	AutoTagMinConfidence sql.NullFloat64 `db:"auto_tag_min_confidence" json:"auto_tag_min_confidence"`
+	PinnedPosition       sql.NullInt64   `db:"pinned_position" json:"pinned_position"`
+	CreatedAt            SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt            SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	// NameSort is the natural sort key of Name, maintained by the database
+	NameSort sql.NullString `db:"name_sort" json:"name_sort"`
 }
 
 type StudioPartial struct {
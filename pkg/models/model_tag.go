@@ -1,12 +1,19 @@
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type Tag struct {
-	ID        int             `db:"id" json:"id"`
-	Name      string          `db:"name" json:"name"` // TODO make schema not null
-	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
-	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	ID                   int             `db:"id" json:"id"`
+	Name                 string          `db:"name" json:"name"` // TODO make schema not null
+	UUID                 sql.NullString  `db:"uuid" json:"uuid"`
+	AutoTagMinConfidence sql.NullFloat64 `db:"auto_tag_min_confidence" json:"auto_tag_min_confidence"`
+	CreatedAt            SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt            SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	// NameSort is the natural sort key of Name, maintained by the database
+	NameSort sql.NullString `db:"name_sort" json:"name_sort"`
 }
 
 func NewTag(name string) *Tag {
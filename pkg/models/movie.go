@@ -12,9 +12,11 @@ type MovieReader interface {
 	FindByNames(names []string, nocase bool) ([]*Movie, error)
 	All() ([]*Movie, error)
 	// AllSlim() ([]*Movie, error)
-	// Query(movieFilter *MovieFilterType, findFilter *FindFilterType) ([]*Movie, int)
+	// Query(movieFilter *MovieFilterType, findFilter *FindFilterType) ([]*Movie, int, error)
 	GetFrontImage(movieID int) ([]byte, error)
 	GetBackImage(movieID int) ([]byte, error)
+	GetURLs(movieID int) ([]string, error)
+	GetCustomFields(movieID int) (map[string]string, error)
 }
 
 type MovieWriter interface {
@@ -23,6 +25,8 @@ type MovieWriter interface {
 	UpdateFull(updatedMovie Movie) (*Movie, error)
 	// Destroy(id string) error
 	UpdateMovieImages(movieID int, frontImage []byte, backImage []byte) error
+	UpdateURLs(movieID int, urls []string) error
+	UpdateCustomFields(movieID int, fields map[string]string) error
 	// DestroyMovieImages(movieID int) error
 }
 
@@ -71,6 +75,14 @@ func (t *movieReaderWriter) GetBackImage(movieID int) ([]byte, error) {
 	return t.qb.GetBackImage(movieID, t.tx)
 }
 
+func (t *movieReaderWriter) GetURLs(movieID int) ([]string, error) {
+	return t.qb.GetURLs(movieID, t.tx)
+}
+
+func (t *movieReaderWriter) GetCustomFields(movieID int) (map[string]string, error) {
+	return t.qb.GetCustomFields(movieID, t.tx)
+}
+
 func (t *movieReaderWriter) Create(newMovie Movie) (*Movie, error) {
 	return t.qb.Create(newMovie, t.tx)
 }
@@ -86,3 +98,11 @@ func (t *movieReaderWriter) UpdateFull(updatedMovie Movie) (*Movie, error) {
 func (t *movieReaderWriter) UpdateMovieImages(movieID int, frontImage []byte, backImage []byte) error {
 	return t.qb.UpdateMovieImages(movieID, frontImage, backImage, t.tx)
 }
+
+func (t *movieReaderWriter) UpdateURLs(movieID int, urls []string) error {
+	return t.qb.UpdateURLs(movieID, urls, t.tx)
+}
+
+func (t *movieReaderWriter) UpdateCustomFields(movieID int, fields map[string]string) error {
+	return t.qb.UpdateCustomFields(movieID, fields, t.tx)
+}
@@ -15,8 +15,9 @@ type PerformerReader interface {
 	// Count() (int, error)
 	All() ([]*Performer, error)
 	// AllSlim() ([]*Performer, error)
-	// Query(performerFilter *PerformerFilterType, findFilter *FindFilterType) ([]*Performer, int)
+	// Query(performerFilter *PerformerFilterType, findFilter *FindFilterType) ([]*Performer, int, error)
 	GetPerformerImage(performerID int) ([]byte, error)
+	GetURLs(performerID int) ([]PerformerURL, error)
 }
 
 type PerformerWriter interface {
@@ -25,6 +26,7 @@ type PerformerWriter interface {
 	UpdateFull(updatedPerformer Performer) (*Performer, error)
 	// Destroy(id string) error
 	UpdatePerformerImage(performerID int, image []byte) error
+	UpdateURLs(performerID int, urls []PerformerURL) error
 	// DestroyPerformerImage(performerID int) error
 }
 
@@ -61,6 +63,10 @@ func (t *performerReaderWriter) GetPerformerImage(performerID int) ([]byte, erro
 	return t.qb.GetPerformerImage(performerID, t.tx)
 }
 
+func (t *performerReaderWriter) GetURLs(performerID int) ([]PerformerURL, error) {
+	return t.qb.GetURLs(performerID, t.tx)
+}
+
 func (t *performerReaderWriter) FindBySceneID(id int) ([]*Performer, error) {
 	return t.qb.FindBySceneID(id, t.tx)
 }
@@ -92,3 +98,7 @@ func (t *performerReaderWriter) UpdateFull(updatedPerformer Performer) (*Perform
 func (t *performerReaderWriter) UpdatePerformerImage(performerID int, image []byte) error {
 	return t.qb.UpdatePerformerImage(performerID, image, t.tx)
 }
+
+func (t *performerReaderWriter) UpdateURLs(performerID int, urls []PerformerURL) error {
+	return t.qb.UpdateURLs(performerID, urls, t.tx)
+}
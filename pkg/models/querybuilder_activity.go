@@ -0,0 +1,160 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+const activityTable = "activity"
+
+type ActivityQueryBuilder struct{}
+
+func NewActivityQueryBuilder() ActivityQueryBuilder {
+	return ActivityQueryBuilder{}
+}
+
+func (qb *ActivityQueryBuilder) Create(newActivity Activity, tx *sqlx.Tx) (*Activity, error) {
+	ensureTx(tx)
+	result, err := tx.NamedExec(
+		`INSERT INTO activity (entity, action, entity_id, actor, created_at)
+				VALUES (:entity, :action, :entity_id, :actor, :created_at)
+		`,
+		newActivity,
+	)
+	if err != nil {
+		return nil, err
+	}
+	activityID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Get(&newActivity, `SELECT * FROM activity WHERE id = ? LIMIT 1`, activityID); err != nil {
+		return nil, err
+	}
+	return &newActivity, nil
+}
+
+// DestroyCreatedBefore removes the activity entries created before t.
+func (qb *ActivityQueryBuilder) DestroyCreatedBefore(t time.Time, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec("DELETE FROM activity WHERE created_at < ?", SQLiteTimestamp{Timestamp: t})
+	return err
+}
+
+func (qb *ActivityQueryBuilder) Find(id int, tx *sqlx.Tx) (*Activity, error) {
+	query := "SELECT * FROM activity WHERE id = ? LIMIT 1"
+	args := []interface{}{id}
+	results, err := qb.queryActivities(query, args, tx)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// Query returns recorded activity matching the filters, most recent first
+// unless otherwise sorted.
+func (qb *ActivityQueryBuilder) Query(activityFilter *ActivityFilterType, findFilter *FindFilterType) ([]*Activity, int, error) {
+	if activityFilter == nil {
+		activityFilter = &ActivityFilterType{}
+	}
+	if findFilter == nil {
+		findFilter = &FindFilterType{}
+	}
+
+	query := queryBuilder{
+		tableName: activityTable,
+	}
+
+	query.addFilter(qb.makeFilter(activityFilter))
+
+	query.paginate(qb.getActivitySort(findFilter), findFilter)
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var activities []*Activity
+	for _, id := range idsResult {
+		activity, _ := qb.Find(id, nil)
+		activities = append(activities, activity)
+	}
+
+	return activities, countResult, nil
+}
+
+// makeFilter returns the clauses of the activity filter and its sub-filters.
+func (qb *ActivityQueryBuilder) makeFilter(activityFilter *ActivityFilterType) *filterBuilder {
+	query := newFilterBuilder(activityTable, selectDistinctIDs(activityTable))
+
+	if entity := activityFilter.Entity; entity != nil {
+		query.addWhere("activity.entity = ?")
+		query.addArg(*entity)
+	}
+
+	if action := activityFilter.Action; action != nil {
+		query.addWhere("activity.action = ?")
+		query.addArg(*action)
+	}
+
+	if entityID := activityFilter.EntityID; entityID != nil {
+		query.addWhere("activity.entity_id = ?")
+		query.addArg(*entityID)
+	}
+
+	if actor := activityFilter.Actor; actor != nil {
+		query.addWhere("activity.actor = ?")
+		query.addArg(*actor)
+	}
+
+	if activityFilter.And != nil {
+		query.and = qb.makeFilter(activityFilter.And)
+	}
+	if activityFilter.Or != nil {
+		query.or = qb.makeFilter(activityFilter.Or)
+	}
+	if activityFilter.Not != nil {
+		query.not = qb.makeFilter(activityFilter.Not)
+	}
+
+	return query
+}
+
+func (qb *ActivityQueryBuilder) getActivitySort(findFilter *FindFilterType) sortTerms {
+	// order activity created at the same time by insertion order
+	ret := getSort(findFilter.getSortFields("created_at", "DESC"), activityTable)
+	return append(ret, sortTerm{expression: "activity.id", direction: ret[len(ret)-1].direction})
+}
+
+func (qb *ActivityQueryBuilder) queryActivities(query string, args []interface{}, tx *sqlx.Tx) ([]*Activity, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := make([]*Activity, 0)
+	for rows.Next() {
+		activity := Activity{}
+		if err := rows.StructScan(&activity); err != nil {
+			return nil, err
+		}
+		activities = append(activities, &activity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
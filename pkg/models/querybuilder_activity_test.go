@@ -0,0 +1,132 @@
+// +build integration
+
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityQuery(t *testing.T) {
+	const actor = "TestActivityQuery"
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	activities := []models.Activity{
+		{Entity: "scene", Action: "create", EntityID: 1},
+		{Entity: "scene", Action: "update", EntityID: 1},
+		{Entity: "tag", Action: "destroy", EntityID: 2},
+	}
+
+	aqb := models.NewActivityQueryBuilder()
+
+	var createdIDs []int
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, activity := range activities {
+			activity.Actor = sql.NullString{String: actor, Valid: true}
+			activity.CreatedAt = models.SQLiteTimestamp{Timestamp: baseTime.Add(time.Duration(i) * time.Minute)}
+
+			created, err := aqb.Create(activity, tx)
+			if err != nil {
+				return err
+			}
+			createdIDs = append(createdIDs, created.ID)
+		}
+		return nil
+	})
+
+	actorFilter := actor
+	activityFilter := models.ActivityFilterType{
+		Actor: &actorFilter,
+	}
+
+	// most recent first by default
+	results, count, err := aqb.Query(&activityFilter, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(activities), count)
+	if assert.Len(t, results, len(activities)) {
+		assert.Equal(t, createdIDs[2], results[0].ID)
+		assert.Equal(t, createdIDs[0], results[2].ID)
+	}
+
+	// pagination
+	page := 2
+	perPage := 2
+	results, count, err = aqb.Query(&activityFilter, &models.FindFilterType{
+		Page:    &page,
+		PerPage: &perPage,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, len(activities), count)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, createdIDs[0], results[0].ID)
+	}
+
+	entity := "scene"
+	entityID := 1
+	activityFilter.Entity = &entity
+	activityFilter.EntityID = &entityID
+	results, count, err = aqb.Query(&activityFilter, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	for _, result := range results {
+		assert.Equal(t, entity, result.Entity)
+		assert.Equal(t, entityID, result.EntityID)
+		assert.Equal(t, actor, result.Actor.String)
+	}
+}
+
+func TestActivityDestroyCreatedBefore(t *testing.T) {
+	const actor = "TestActivityDestroyCreatedBefore"
+	baseTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	aqb := models.NewActivityQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+
+	var createdIDs []int
+	for i := 0; i < 2; i++ {
+		activity := models.Activity{
+			Entity:    "scene",
+			Action:    "update",
+			EntityID:  1,
+			Actor:     sql.NullString{String: actor, Valid: true},
+			CreatedAt: models.SQLiteTimestamp{Timestamp: baseTime.Add(time.Duration(i) * 48 * time.Hour)},
+		}
+
+		created, err := aqb.Create(activity, tx)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("Error creating activity: %s", err.Error())
+		}
+		createdIDs = append(createdIDs, created.ID)
+	}
+
+	if err := aqb.DestroyCreatedBefore(baseTime.Add(24*time.Hour), tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error destroying activity: %s", err.Error())
+	}
+
+	older, err := aqb.Find(createdIDs[0], tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error finding activity: %s", err.Error())
+	}
+	assert.Nil(t, older)
+
+	newer, err := aqb.Find(createdIDs[1], tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error finding activity: %s", err.Error())
+	}
+	assert.NotNil(t, newer)
+
+	// don't keep the created activity
+	tx.Rollback()
+}
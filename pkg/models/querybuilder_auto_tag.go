@@ -0,0 +1,38 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// updateAutoTagMinConfidence sets the minimum confidence of the auto tag
+// matches of the object of table with the id. Null uses the setting.
+func updateAutoTagMinConfidence(table string, id int, minConfidence sql.NullFloat64, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	if minConfidence.Valid && (minConfidence.Float64 < 0 || minConfidence.Float64 > 1) {
+		return errors.New("auto tag minimum confidence must be between 0 and 1")
+	}
+
+	_, err := tx.Exec("UPDATE "+table+" SET auto_tag_min_confidence = ? WHERE id = ?", minConfidence, id)
+	return err
+}
+
+// UpdateAutoTagMinConfidence sets the minimum confidence of the auto tag
+// matches of the performer, overriding the setting if it is valid.
+func (qb *PerformerQueryBuilder) UpdateAutoTagMinConfidence(performerID int, minConfidence sql.NullFloat64, tx *sqlx.Tx) error {
+	return updateAutoTagMinConfidence("performers", performerID, minConfidence, tx)
+}
+
+// UpdateAutoTagMinConfidence sets the minimum confidence of the auto tag
+// matches of the studio, overriding the setting if it is valid.
+func (qb *StudioQueryBuilder) UpdateAutoTagMinConfidence(studioID int, minConfidence sql.NullFloat64, tx *sqlx.Tx) error {
+	return updateAutoTagMinConfidence("studios", studioID, minConfidence, tx)
+}
+
+// UpdateAutoTagMinConfidence sets the minimum confidence of the auto tag
+// matches of the tag, overriding the setting if it is valid.
+func (qb *TagQueryBuilder) UpdateAutoTagMinConfidence(tagID int, minConfidence sql.NullFloat64, tx *sqlx.Tx) error {
+	return updateAutoTagMinConfidence("tags", tagID, minConfidence, tx)
+}
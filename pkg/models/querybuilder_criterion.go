@@ -0,0 +1,193 @@
+package models
+
+// criterionHandler adds the clauses, joins and arguments of a filter
+// criterion to a filter. Handlers of unset criteria add nothing.
+type criterionHandler interface {
+	handle(f *filterBuilder)
+}
+
+// criterionHandlerFunc is a criterionHandler for criteria which are handled
+// by a single function.
+type criterionHandlerFunc func(f *filterBuilder)
+
+func (h criterionHandlerFunc) handle(f *filterBuilder) {
+	h(f)
+}
+
+// handleCriteria adds the criteria of the handlers to the filter. Where and
+// having clause arguments are kept apart by the filter, so the handlers may
+// be given in any order.
+func (f *filterBuilder) handleCriteria(handlers ...criterionHandler) {
+	for _, h := range handlers {
+		h.handle(f)
+	}
+}
+
+// addJoin adds a left join of table, aliased as as if not empty, to the body
+// of the filter. A join with an alias already added by another criterion is
+// not added again.
+func (f *filterBuilder) addJoin(table string, as string, onClause string) {
+	alias := table
+	if as != "" {
+		alias = as
+	}
+
+	for _, j := range f.joins {
+		if j == alias {
+			return
+		}
+	}
+	f.joins = append(f.joins, alias)
+
+	join := "left join " + table
+	if as != "" {
+		join += " as " + as
+	}
+	f.body += "\n\t" + join + " on " + onClause + "\n"
+}
+
+// where adds a where clause and its arguments to the filter.
+func (f *filterBuilder) where(clause string, args ...interface{}) {
+	if clause == "" {
+		return
+	}
+
+	f.addWhere(clause)
+	f.addArg(args...)
+}
+
+// having adds a having clause and its arguments to the filter.
+func (f *filterBuilder) having(clause string, args ...interface{}) {
+	if clause == "" {
+		return
+	}
+
+	f.addHaving(clause)
+	f.havingArgs = append(f.havingArgs, args...)
+}
+
+func stringCriterionHandler(c *StringCriterionInput, column string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		f.handleStringCriterionInput(c, column)
+	}
+}
+
+func intCriterionHandler(c *IntCriterionInput, column string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		f.handleIntCriterionInput(c, column)
+	}
+}
+
+// havingIntCriterionHandler compares an aggregate expression, such as a count
+// of joined rows, with the criterion.
+func havingIntCriterionHandler(c *IntCriterionInput, expression string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if c != nil {
+			clause, count := getIntCriterionWhereClause(expression, *c)
+			f.having(clause, getIntCriterionArgs(*c, count)...)
+		}
+	}
+}
+
+func ratingCriterionHandler(rating *IntCriterionInput, rating100 *IntCriterionInput, column string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		f.handleRatingCriterionInput(rating, rating100, column)
+	}
+}
+
+func timestampCriterionHandler(c *TimestampCriterionInput, column string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		f.handleTimestampCriterionInput(c, column)
+	}
+}
+
+func dateCriterionHandler(c *DateCriterionInput, column string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		f.handleDateCriterionInput(c, column)
+	}
+}
+
+// multiCriterionJoin is the join of the foreign table of a multi criterion.
+type multiCriterionJoin struct {
+	table    string
+	as       string
+	onClause string
+}
+
+// multiCriterionHandler handles a criterion on the ids of a foreign table.
+// join, if set, is added only when the criterion is set. The arguments are
+// the same as those of getMultiCriterionClause.
+type multiCriterionHandler struct {
+	criterion    *MultiCriterionInput
+	primaryTable string
+	foreignTable string
+	joinTable    string
+	primaryFK    string
+	foreignFK    string
+	join         *multiCriterionJoin
+}
+
+func (h multiCriterionHandler) handle(f *filterBuilder) {
+	if h.criterion == nil || len(h.criterion.Value) == 0 {
+		return
+	}
+
+	if h.join != nil {
+		f.addJoin(h.join.table, h.join.as, h.join.onClause)
+	}
+
+	var args []interface{}
+	for _, id := range h.criterion.Value {
+		args = append(args, id)
+	}
+
+	whereClause, havingClause := getMultiCriterionClause(h.primaryTable, h.foreignTable, h.joinTable, h.primaryFK, h.foreignFK, h.criterion)
+	f.where(whereClause, args...)
+	f.having(havingClause)
+}
+
+// customFieldsCriterionHandler handles the custom field criteria of the
+// objects of a custom fields table.
+func customFieldsCriterionHandler(t customFieldsTable, criteria []*CustomFieldCriterionInput) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		for _, criterion := range criteria {
+			if criterion.Value != nil {
+				if err := validateRegexCriterion(criterion.Modifier, *criterion.Value); err != nil {
+					f.setError(err)
+					return
+				}
+			}
+
+			clause, args := t.getCriterionClause(*criterion)
+			f.where(clause, args...)
+		}
+	}
+}
+
+// isMissingJoin is the join and where clause used to find the objects missing
+// a value stored in another table.
+type isMissingJoin struct {
+	table    string
+	onClause string
+	clause   string
+}
+
+// isMissingCriterionHandler handles an is_missing criterion. Values not in
+// joins are treated as columns of tableName.
+func isMissingCriterionHandler(isMissing *string, tableName string, joins map[string]isMissingJoin) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if isMissing == nil || *isMissing == "" {
+			return
+		}
+
+		if j, found := joins[*isMissing]; found {
+			if j.table != "" {
+				f.addJoin(j.table, "", j.onClause)
+			}
+			f.where(j.clause)
+			return
+		}
+
+		f.where(tableName + "." + *isMissing + " IS NULL")
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// findCursorTable is the name of the common table expression holding the sort
+// values of the object of the cursor of a cursor paginated query.
+const findCursorTable = "find_cursor"
+
+func encodeFindCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeFindCursor(cursor string) (int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	id, err := strconv.Atoi(string(decoded))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	return id, nil
+}
+
+// buildCursorQuery returns the query selecting the ids of the page of results
+// ordered after the object of the cursor, and its arguments. The results are
+// ordered by id after the sort terms so that every result has a distinct
+// position, and the page is selected by comparing the sort values of each
+// result with those of the cursor object rather than by offset, so results
+// inserted before the cursor do not shift the page.
+func (qb queryBuilder) buildCursorQuery() (string, []interface{}, error) {
+	idColumn := getColumn(qb.tableName, "id")
+	terms := append(sortTerms{}, qb.sort...)
+	terms = append(terms, sortTerm{expression: idColumn, direction: "ASC"})
+
+	sortAndLimit := terms.String() + " LIMIT " + strconv.Itoa(qb.findFilter.GetFirst()) + " "
+
+	if qb.findFilter.After == nil {
+		body := buildFindQuery(qb.tableName, qb.body, qb.whereClauses, qb.havingClauses)
+		return body + sortAndLimit, qb.args, nil
+	}
+
+	cursorID, err := decodeFindCursor(*qb.findFilter.After)
+	if err != nil {
+		return "", nil, err
+	}
+
+	count, err := runCountQuery("SELECT COUNT(*) as count FROM "+qb.tableName+" WHERE "+idColumn+" = ?", []interface{}{cursorID})
+	if err != nil {
+		return "", nil, err
+	}
+	if count == 0 {
+		return "", nil, fmt.Errorf("the object of cursor %q no longer exists", *qb.findFilter.After)
+	}
+
+	distinctIDs := "SELECT DISTINCT " + idColumn + " "
+	if !strings.HasPrefix(qb.body, distinctIDs) {
+		return "", nil, fmt.Errorf("cursor pagination is not supported by the %s query", qb.tableName)
+	}
+
+	// the sort values of the cursor object are selected with the joins of the
+	// query, which aggregated sort terms may count
+	var values []string
+	for i, t := range terms {
+		values = append(values, t.expression+" AS sort_"+strconv.Itoa(i))
+	}
+	cursorQuery := "SELECT " + strings.Join(values, ", ") + " " + strings.TrimPrefix(qb.body, distinctIDs) + " WHERE " + idColumn + " = ? GROUP BY " + idColumn
+
+	// results are after the cursor if they are after it in the first term, or
+	// equal in the first term and after it in the remaining terms
+	var clause string
+	aggregate := false
+	for i := len(terms) - 1; i >= 0; i-- {
+		t := terms[i]
+		value := "(SELECT sort_" + strconv.Itoa(i) + " FROM " + findCursorTable + ")"
+
+		if clause == "" {
+			clause = t.afterClause(value)
+		} else {
+			clause = "(" + t.afterClause(value) + " OR (" + t.equalClause(value) + " AND " + clause + "))"
+		}
+
+		aggregate = aggregate || t.aggregate
+	}
+
+	whereClauses := append([]string{}, qb.whereClauses...)
+	havingClauses := append([]string{}, qb.havingClauses...)
+	if aggregate {
+		havingClauses = append(havingClauses, clause)
+	} else {
+		whereClauses = append(whereClauses, clause)
+	}
+
+	query := "WITH " + findCursorTable + " AS (" + cursorQuery + ") " + buildFindQuery(qb.tableName, qb.body, whereClauses, havingClauses) + sortAndLimit
+	args := append([]interface{}{cursorID}, qb.args...)
+
+	return query, args, nil
+}
+
+// afterClause returns a clause matching the rows ordered after value by the
+// term. NULL values are ordered first, so are before all other values in
+// ascending order and after them in descending order.
+func (t sortTerm) afterClause(value string) string {
+	if t.direction == "DESC" {
+		return "((" + value + " IS NOT NULL AND " + t.expression + " IS NULL) OR " + t.expression + " < " + t.collate(value) + ")"
+	}
+
+	return "((" + value + " IS NULL AND " + t.expression + " IS NOT NULL) OR " + t.expression + " > " + t.collate(value) + ")"
+}
+
+// equalClause returns a clause matching the rows ordered equal to value by the
+// term.
+func (t sortTerm) equalClause(value string) string {
+	return t.expression + " IS " + t.collate(value)
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+// customFieldsTable is a table of key/value custom fields belonging to the
+// objects of a parent table.
+type customFieldsTable struct {
+	table       string
+	fkColumn    string
+	parentTable string
+}
+
+var movieCustomFieldsTable = customFieldsTable{
+	table:       "movie_custom_fields",
+	fkColumn:    "movie_id",
+	parentTable: "movies",
+}
+
+func (t customFieldsTable) get(id int, tx *sqlx.Tx) (map[string]string, error) {
+	query := "SELECT field, value FROM " + t.table + " WHERE " + t.fkColumn + " = ?"
+
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, id)
+	} else {
+		rows, err = database.DB.Queryx(query, id)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make(map[string]string)
+	for rows.Next() {
+		var field, value string
+		if err := rows.Scan(&field, &value); err != nil {
+			return nil, err
+		}
+		ret[field] = value
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// update replaces the custom fields of the object with fields.
+func (t customFieldsTable) update(id int, fields map[string]string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if err := t.destroy(id, tx); err != nil {
+		return err
+	}
+
+	for field, value := range fields {
+		_, err := tx.Exec("INSERT INTO "+t.table+" ("+t.fkColumn+", field, value) VALUES (?, ?, ?)", id, field, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t customFieldsTable) destroy(id int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	_, err := tx.Exec("DELETE FROM "+t.table+" WHERE "+t.fkColumn+" = ?", id)
+	return err
+}
+
+// getCriterionClause returns a where clause matching the parent objects with
+// a custom field satisfying the criterion. Objects without the field match
+// the negative modifiers.
+func (t customFieldsTable) getCriterionClause(criterion CustomFieldCriterionInput) (string, []interface{}) {
+	exists := "EXISTS (SELECT 1 FROM " + t.table + " AS custom_fields WHERE custom_fields." + t.fkColumn + " = " + t.parentTable + ".id AND custom_fields.field = ?"
+	args := []interface{}{criterion.Field}
+
+	value := ""
+	if criterion.Value != nil {
+		value = *criterion.Value
+	}
+
+	switch criterion.Modifier {
+	case CriterionModifierIsNull:
+		return "NOT " + exists + ")", args
+	case CriterionModifierIncludes, CriterionModifierExcludes:
+		clause, thisArgs := getSearchBinding([]string{"custom_fields.value"}, value, false)
+		clause = exists + " AND " + clause + ")"
+		if criterion.Modifier == CriterionModifierExcludes {
+			clause = "NOT " + clause
+		}
+		return clause, append(args, thisArgs...)
+	case CriterionModifierEquals:
+		return exists + " AND custom_fields.value LIKE ?)", append(args, value)
+	case CriterionModifierNotEquals:
+		return "NOT " + exists + " AND custom_fields.value LIKE ?)", append(args, value)
+	case CriterionModifierMatchesRegex:
+		return exists + " AND custom_fields.value regexp ?)", append(args, value)
+	case CriterionModifierNotMatchesRegex:
+		return "NOT " + exists + " AND custom_fields.value regexp ?)", append(args, value)
+	case CriterionModifierGreaterThan:
+		return exists + " AND CAST(custom_fields.value AS REAL) > CAST(? AS REAL))", append(args, value)
+	case CriterionModifierLessThan:
+		return exists + " AND CAST(custom_fields.value AS REAL) < CAST(? AS REAL))", append(args, value)
+	default:
+		// NOT_NULL
+		return exists + ")", args
+	}
+}
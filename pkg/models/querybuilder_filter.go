@@ -0,0 +1,133 @@
+package models
+
+// filterBuilder holds the clauses of a filter type input, and the filters of
+// its AND, OR and NOT sub-filters.
+type filterBuilder struct {
+	queryBuilder
+
+	// baseBody is the query body without the joins added by the filter
+	baseBody string
+
+	// joins are the aliases of the tables joined by the criteria
+	joins []string
+
+	// havingArgs are the arguments of the having clauses, which follow the
+	// where clause arguments in the query
+	havingArgs []interface{}
+
+	and *filterBuilder
+	or  *filterBuilder
+	not *filterBuilder
+}
+
+// newFilterBuilder returns a filter builder for a filter on tableName. body
+// is the select statement and joins shared by the find query and the
+// filters.
+func newFilterBuilder(tableName string, body string) *filterBuilder {
+	return &filterBuilder{
+		queryBuilder: queryBuilder{
+			tableName: tableName,
+			body:      body,
+		},
+		baseBody: body,
+	}
+}
+
+func (f *filterBuilder) hasSubFilters() bool {
+	return f.and != nil || f.or != nil || f.not != nil
+}
+
+// getError returns the first error found in the criteria of the filter or
+// its sub-filters.
+func (f *filterBuilder) getError() error {
+	if f.err != nil {
+		return f.err
+	}
+
+	for _, sub := range []*filterBuilder{f.and, f.or, f.not} {
+		if sub == nil {
+			continue
+		}
+		if err := sub.getError(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *filterBuilder) hasClauses() bool {
+	return len(f.whereClauses) > 0 || len(f.havingClauses) > 0
+}
+
+// getArgs returns the where clause arguments followed by the having clause
+// arguments of the filter.
+func (f *filterBuilder) getArgs() []interface{} {
+	args := append([]interface{}{}, f.args...)
+	return append(args, f.havingArgs...)
+}
+
+// buildIDsQuery returns a query selecting the ids of the objects matching
+// the filter, and its arguments. The filter matches the objects matching its
+// own criteria and the AND sub-filter, or the OR sub-filter, but not the NOT
+// sub-filter. An OR sub-filter of a filter without criteria is not combined
+// with the filter, since an empty filter matches all objects.
+func (f *filterBuilder) buildIDsQuery() (string, []interface{}) {
+	query := buildFindQuery(f.tableName, f.body, f.whereClauses, f.havingClauses)
+	args := f.getArgs()
+	hasClauses := f.hasClauses()
+
+	if f.and != nil {
+		subQuery, subArgs := f.and.buildSubQuery()
+		query += " INTERSECT " + subQuery
+		args = append(args, subArgs...)
+		hasClauses = true
+	}
+
+	if f.or != nil {
+		subQuery, subArgs := f.or.buildSubQuery()
+		if hasClauses {
+			query += " UNION " + subQuery
+			args = append(args, subArgs...)
+		} else {
+			query = subQuery
+			args = subArgs
+		}
+	}
+
+	if f.not != nil {
+		subQuery, subArgs := f.not.buildSubQuery()
+		query += " EXCEPT " + subQuery
+		args = append(args, subArgs...)
+	}
+
+	return query, args
+}
+
+// buildSubQuery returns the ids query of the filter wrapped in a select, so
+// that compound operators of the filter are not combined with those of its
+// parent.
+func (f *filterBuilder) buildSubQuery() (string, []interface{}) {
+	query, args := f.buildIDsQuery()
+	return "SELECT id FROM (" + query + ")", args
+}
+
+// addFilter adds the clauses of the filter to the query. Filters with
+// sub-filters are added as a single clause selecting the matching ids, with
+// the joins of the filter kept out of the query body.
+func (qb *queryBuilder) addFilter(f *filterBuilder) {
+	qb.setError(f.getError())
+
+	if !f.hasSubFilters() {
+		qb.body = f.body
+		qb.addWhere(f.whereClauses...)
+		qb.addHaving(f.havingClauses...)
+		qb.addArg(f.getArgs()...)
+		return
+	}
+
+	query, args := f.buildIDsQuery()
+	qb.body = f.baseBody
+	qb.addWhere(getColumn(qb.tableName, "id") + " IN (" + query + ")")
+	qb.addArg(args...)
+}
@@ -20,9 +20,15 @@ func NewGalleryQueryBuilder() GalleryQueryBuilder {
 
 func (qb *GalleryQueryBuilder) Create(newGallery Gallery, tx *sqlx.Tx) (*Gallery, error) {
 	ensureTx(tx)
+	uuid, err := uniqueUUID("galleries", newGallery.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newGallery.UUID = uuid
+
 	result, err := tx.NamedExec(
-		`INSERT INTO galleries (path, checksum, zip, title, date, details, url, studio_id, rating, organized, scene_id, file_mod_time, created_at, updated_at)
-				VALUES (:path, :checksum, :zip, :title, :date, :details, :url, :studio_id, :rating, :organized, :scene_id, :file_mod_time, :created_at, :updated_at)
+		`INSERT INTO galleries (path, checksum, zip, title, date, details, url, studio_id, rating, organized, scene_id, file_mod_time, uuid, created_at, updated_at)
+				VALUES (:path, :checksum, :zip, :title, :date, :details, :url, :studio_id, :rating, :organized, :scene_id, :file_mod_time, :uuid, :created_at, :updated_at)
 		`,
 		newGallery,
 	)
@@ -95,6 +101,10 @@ func (qb *GalleryQueryBuilder) UpdateFileModTime(id int, modTime NullSQLiteTimes
 }
 
 func (qb *GalleryQueryBuilder) Destroy(id int, tx *sqlx.Tx) error {
+	_, err := tx.Exec("DELETE FROM scrape_history WHERE entity = ? AND entity_id = ?", ScrapeHistoryEntityGallery, id)
+	if err != nil {
+		return err
+	}
 	return executeDeleteQuery("galleries", strconv.Itoa(id), tx)
 }
 
@@ -152,6 +162,13 @@ func (qb *GalleryQueryBuilder) FindByPath(path string) (*Gallery, error) {
 	return qb.queryGallery(query, args, nil)
 }
 
+// FindUnderPath returns the zip and folder galleries at path or within the
+// directory path.
+func (qb *GalleryQueryBuilder) FindUnderPath(path string) ([]*Gallery, error) {
+	clause, args := getUnderPathClause("galleries.path", path)
+	return qb.queryGalleries(selectAll("galleries")+"WHERE "+clause, args, nil)
+}
+
 func (qb *GalleryQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) (*Gallery, error) {
 	query := "SELECT galleries.* FROM galleries WHERE galleries.scene_id = ? LIMIT 1"
 	args := []interface{}{sceneID}
@@ -174,6 +191,35 @@ func (qb *GalleryQueryBuilder) FindByImageID(imageID int, tx *sqlx.Tx) ([]*Galle
 	return qb.queryGalleries(query, args, tx)
 }
 
+// FindByPerformerID returns the galleries of the performer.
+func (qb *GalleryQueryBuilder) FindByPerformerID(performerID int) ([]*Gallery, error) {
+	query := selectAll(galleryTable) + `
+	LEFT JOIN performers_galleries as performers_join on performers_join.gallery_id = galleries.id
+	WHERE performers_join.performer_id = ?
+	GROUP BY galleries.id
+	`
+	args := []interface{}{performerID}
+	return qb.queryGalleries(query, args, nil)
+}
+
+// FindByStudioID returns the galleries of the studio.
+func (qb *GalleryQueryBuilder) FindByStudioID(studioID int) ([]*Gallery, error) {
+	query := selectAll(galleryTable) + "WHERE galleries.studio_id = ?"
+	args := []interface{}{studioID}
+	return qb.queryGalleries(query, args, nil)
+}
+
+// FindByTagID returns the galleries tagged with the tag.
+func (qb *GalleryQueryBuilder) FindByTagID(tagID int) ([]*Gallery, error) {
+	query := selectAll(galleryTable) + `
+	LEFT JOIN galleries_tags as tags_join on tags_join.gallery_id = galleries.id
+	WHERE tags_join.tag_id = ?
+	GROUP BY galleries.id
+	`
+	args := []interface{}{tagID}
+	return qb.queryGalleries(query, args, nil)
+}
+
 func (qb *GalleryQueryBuilder) CountByImageID(imageID int) (int, error) {
 	query := `SELECT image_id FROM galleries_images
 	WHERE image_id = ?
@@ -187,10 +233,10 @@ func (qb *GalleryQueryBuilder) Count() (int, error) {
 }
 
 func (qb *GalleryQueryBuilder) All() ([]*Gallery, error) {
-	return qb.queryGalleries(selectAll("galleries")+qb.getGallerySort(nil), nil, nil)
+	return qb.queryGalleries(selectAll("galleries")+qb.getGallerySort(nil).String(), nil, nil)
 }
 
-func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilter *FindFilterType) ([]*Gallery, int) {
+func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilter *FindFilterType) ([]*Gallery, int, error) {
 	if galleryFilter == nil {
 		galleryFilter = &GalleryFilterType{}
 	}
@@ -202,21 +248,39 @@ func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilte
 		tableName: galleryTable,
 	}
 
-	query.body = selectDistinctIDs("galleries")
-	query.body += `
+	if q := findFilter.Q; q != nil && *q != "" {
+		clause, thisArgs := getSearchDocumentBinding(galleryTable, "gallery", *q)
+		query.addWhere(clause)
+		query.addArg(thisArgs...)
+	}
+
+	query.addFilter(qb.makeFilter(galleryFilter))
+
+	query.paginate(qb.getGallerySort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var galleries []*Gallery
+	for _, id := range idsResult {
+		gallery, _ := qb.Find(id, nil)
+		galleries = append(galleries, gallery)
+	}
+
+	return galleries, countResult, nil
+}
+
+// makeFilter returns the clauses of the gallery filter and its sub-filters.
+func (qb *GalleryQueryBuilder) makeFilter(galleryFilter *GalleryFilterType) *filterBuilder {
+	query := newFilterBuilder(galleryTable, selectDistinctIDs("galleries")+`
 		left join performers_galleries as performers_join on performers_join.gallery_id = galleries.id
 		left join studios as studio on studio.id = galleries.studio_id
 		left join galleries_tags as tags_join on tags_join.gallery_id = galleries.id
 		left join galleries_images as images_join on images_join.gallery_id = galleries.id
 		left join images on images_join.image_id = images.id
-	`
-
-	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"galleries.path", "galleries.checksum"}
-		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
-		query.addWhere(clause)
-		query.addArg(thisArgs...)
-	}
+	`)
 
 	if zipFilter := galleryFilter.IsZip; zipFilter != nil {
 		var favStr string
@@ -229,8 +293,13 @@ func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilte
 	}
 
 	query.handleStringCriterionInput(galleryFilter.Path, "galleries.path")
-	query.handleIntCriterionInput(galleryFilter.Rating, "galleries.rating")
-	qb.handleAverageResolutionFilter(&query, galleryFilter.AverageResolution)
+	query.handleCriteria(libraryCriterionHandler(galleryFilter.Library, "galleries.path"))
+	query.handleStringCriterionInput(galleryFilter.Title, "galleries.title")
+	query.handleDateCriterionInput(galleryFilter.Date, "galleries.date")
+	query.handleTimestampCriterionInput(galleryFilter.CreatedAt, "galleries.created_at")
+	query.handleTimestampCriterionInput(galleryFilter.UpdatedAt, "galleries.updated_at")
+	query.handleRatingCriterionInput(galleryFilter.Rating, galleryFilter.Rating100, "galleries.rating")
+	qb.handleAverageResolutionFilter(&query.queryBuilder, galleryFilter.AverageResolution)
 
 	if Organized := galleryFilter.Organized; Organized != nil {
 		var organized string
@@ -259,16 +328,7 @@ func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilte
 		}
 	}
 
-	if tagsFilter := galleryFilter.Tags; tagsFilter != nil && len(tagsFilter.Value) > 0 {
-		for _, tagID := range tagsFilter.Value {
-			query.addArg(tagID)
-		}
-
-		query.body += " LEFT JOIN tags on tags_join.tag_id = tags.id"
-		whereClause, havingClause := getMultiCriterionClause("galleries", "tags", "tags_join", "gallery_id", "tag_id", tagsFilter)
-		query.addWhere(whereClause)
-		query.addHaving(havingClause)
-	}
+	query.handleCriteria(subTags.criterionHandler(galleryFilter.Tags, joinTableTagClause("galleries", "galleries_tags", "gallery_id")))
 
 	if performersFilter := galleryFilter.Performers; performersFilter != nil && len(performersFilter.Value) > 0 {
 		for _, performerID := range performersFilter.Value {
@@ -281,26 +341,19 @@ func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilte
 		query.addHaving(havingClause)
 	}
 
-	if studiosFilter := galleryFilter.Studios; studiosFilter != nil && len(studiosFilter.Value) > 0 {
-		for _, studioID := range studiosFilter.Value {
-			query.addArg(studioID)
-		}
+	query.handleCriteria(subStudiosCriterionHandler(galleryFilter.Studios, "galleries"))
 
-		whereClause, havingClause := getMultiCriterionClause("galleries", "studio", "", "", "studio_id", studiosFilter)
-		query.addWhere(whereClause)
-		query.addHaving(havingClause)
+	if galleryFilter.And != nil {
+		query.and = qb.makeFilter(galleryFilter.And)
 	}
-
-	query.sortAndPagination = qb.getGallerySort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := query.executeFind()
-
-	var galleries []*Gallery
-	for _, id := range idsResult {
-		gallery, _ := qb.Find(id, nil)
-		galleries = append(galleries, gallery)
+	if galleryFilter.Or != nil {
+		query.or = qb.makeFilter(galleryFilter.Or)
+	}
+	if galleryFilter.Not != nil {
+		query.not = qb.makeFilter(galleryFilter.Not)
 	}
 
-	return galleries, countResult
+	return query
 }
 
 func (qb *GalleryQueryBuilder) handleAverageResolutionFilter(query *queryBuilder, resolutionFilter *ResolutionEnum) {
@@ -369,17 +422,11 @@ func (qb *GalleryQueryBuilder) handleAverageResolutionFilter(query *queryBuilder
 	}
 }
 
-func (qb *GalleryQueryBuilder) getGallerySort(findFilter *FindFilterType) string {
-	var sort string
-	var direction string
+func (qb *GalleryQueryBuilder) getGallerySort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		sort = "path"
-		direction = "ASC"
-	} else {
-		sort = findFilter.GetSort("path")
-		direction = findFilter.GetDirection()
+		return getSort([]sortField{{sort: "path", direction: "ASC"}}, "galleries")
 	}
-	return getSort(sort, direction, "galleries")
+	return getSort(findFilter.getSortFields("path", "ASC"), "galleries")
 }
 
 func (qb *GalleryQueryBuilder) queryGallery(query string, args []interface{}, tx *sqlx.Tx) (*Gallery, error) {
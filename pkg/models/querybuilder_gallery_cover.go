@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// updateAutoCoversQuery sets the automatic covers of the galleries matching
+// the WHERE clause appended to it to their first images by path, preferring
+// images named cover.jpg and avoiding thumbnails. See
+// utils.GalleryCoverRank.
+const updateAutoCoversQuery = `UPDATE galleries SET auto_cover_image_id = (
+	SELECT images.id FROM galleries_images
+	JOIN images ON images.id = galleries_images.image_id
+	WHERE galleries_images.gallery_id = galleries.id
+	ORDER BY gallery_cover_rank(images.path), images.path, images.id
+	LIMIT 1
+)`
+
+// UpdateAutoCovers sets the automatic covers of the galleries with the ids
+// from their current images.
+func (qb *GalleryQueryBuilder) UpdateAutoCovers(galleryIDs []int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if len(galleryIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, len(galleryIDs))
+	for i, id := range galleryIDs {
+		args[i] = id
+	}
+
+	_, err := tx.Exec(updateAutoCoversQuery+" WHERE galleries.id IN "+getInBinding(len(galleryIDs)), args...)
+	return err
+}
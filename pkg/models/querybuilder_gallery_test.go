@@ -3,11 +3,16 @@
 package models_test
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/modelstest"
 )
 
 func TestGalleryFind(t *testing.T) {
@@ -98,6 +103,59 @@ func TestGalleryFindBySceneID(t *testing.T) {
 	assert.Nil(t, gallery)
 }
 
+func TestGalleryFindByPerformerStudioTag(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	const name = "TestGalleryFindByPerformerStudioTag"
+	performer := f.performer(*models.NewPerformer(name))
+	studio := f.studio(*models.NewStudio(name))
+	tag := f.tag(*models.NewTag(name))
+	gallery := f.gallery(models.Gallery{
+		Path:     sql.NullString{String: name, Valid: true},
+		StudioID: sql.NullInt64{Int64: int64(studio.ID), Valid: true},
+	})
+
+	jqb := models.NewJoinsQueryBuilder()
+	withTxn(t, func(tx *sqlx.Tx) error {
+		if err := jqb.CreatePerformersGalleries([]models.PerformersGalleries{{PerformerID: performer.ID, GalleryID: gallery.ID}}, tx); err != nil {
+			return err
+		}
+		return jqb.CreateGalleriesTags([]models.GalleriesTags{{TagID: tag.ID, GalleryID: gallery.ID}}, tx)
+	})
+
+	gqb := models.NewGalleryQueryBuilder()
+	galleries, err := gqb.FindByPerformerID(performer.ID)
+	if err != nil {
+		t.Fatalf("error calling FindByPerformerID: %s", err.Error())
+	}
+	if assert.Len(t, galleries, 1) {
+		assert.Equal(t, gallery.ID, galleries[0].ID)
+	}
+
+	galleries, err = gqb.FindByStudioID(studio.ID)
+	if err != nil {
+		t.Fatalf("error calling FindByStudioID: %s", err.Error())
+	}
+	if assert.Len(t, galleries, 1) {
+		assert.Equal(t, gallery.ID, galleries[0].ID)
+	}
+
+	galleries, err = gqb.FindByTagID(tag.ID)
+	if err != nil {
+		t.Fatalf("error calling FindByTagID: %s", err.Error())
+	}
+	if assert.Len(t, galleries, 1) {
+		assert.Equal(t, gallery.ID, galleries[0].ID)
+	}
+
+	galleries, err = gqb.FindByTagID(0)
+	if err != nil {
+		t.Fatalf("error calling FindByTagID: %s", err.Error())
+	}
+	assert.Len(t, galleries, 0)
+}
+
 func TestGalleryQueryQ(t *testing.T) {
 	const galleryIdx = 0
 
@@ -112,7 +170,8 @@ func galleryQueryQ(t *testing.T, qb models.GalleryQueryBuilder, q string, expect
 	filter := models.FindFilterType{
 		Q: &q,
 	}
-	galleries, _ := qb.Query(nil, &filter)
+	galleries, _, err := qb.Query(nil, &filter)
+	assert.NoError(t, err)
 
 	assert.Len(t, galleries, 1)
 	gallery := galleries[0]
@@ -120,7 +179,8 @@ func galleryQueryQ(t *testing.T, qb models.GalleryQueryBuilder, q string, expect
 
 	// no Q should return all results
 	filter.Q = nil
-	galleries, _ = qb.Query(nil, &filter)
+	galleries, _, err = qb.Query(nil, &filter)
+	assert.NoError(t, err)
 
 	assert.Len(t, galleries, totalGalleries)
 }
@@ -146,7 +206,8 @@ func verifyGalleriesPath(t *testing.T, pathCriterion models.StringCriterionInput
 		Path: &pathCriterion,
 	}
 
-	galleries, _ := sqb.Query(&galleryFilter, nil)
+	galleries, _, err := sqb.Query(&galleryFilter, nil)
+	assert.NoError(t, err)
 
 	for _, gallery := range galleries {
 		verifyNullString(t, gallery.Path, pathCriterion)
@@ -184,10 +245,11 @@ func verifyGalleriesRating(t *testing.T, ratingCriterion models.IntCriterionInpu
 		Rating: &ratingCriterion,
 	}
 
-	galleries, _ := sqb.Query(&galleryFilter, nil)
+	galleries, _, err := sqb.Query(&galleryFilter, nil)
+	assert.NoError(t, err)
 
 	for _, gallery := range galleries {
-		verifyInt64(t, gallery.Rating, ratingCriterion)
+		verifyInt64(t, getRating5(gallery.Rating), ratingCriterion)
 	}
 }
 
@@ -203,12 +265,14 @@ func TestGalleryQueryIsMissingScene(t *testing.T) {
 		Q: &q,
 	}
 
-	galleries, _ := qb.Query(&galleryFilter, &findFilter)
+	galleries, _, err := qb.Query(&galleryFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, galleries, 0)
 
 	findFilter.Q = nil
-	galleries, _ = qb.Query(&galleryFilter, &findFilter)
+	galleries, _, err = qb.Query(&galleryFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with gallery
 	for _, gallery := range galleries {
@@ -216,6 +280,78 @@ func TestGalleryQueryIsMissingScene(t *testing.T) {
 	}
 }
 
+func TestGalleryAutoCover(t *testing.T) {
+	const name = "TestGalleryAutoCover"
+	paths := []string{
+		"/" + name + "/a_thumb.jpg",
+		"/" + name + "/c.jpg",
+		"/" + name + "/b.jpg",
+	}
+
+	gqb := models.NewGalleryQueryBuilder()
+	iqb := models.NewImageQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	gallery, err := gqb.Create(models.Gallery{
+		Path:     modelstest.NullString(name),
+		Checksum: name,
+	}, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error creating gallery: %s", err.Error())
+	}
+
+	var createdIDs []int
+	for _, path := range paths {
+		created, err := iqb.Create(models.Image{
+			Path:     path,
+			Checksum: path,
+		}, tx)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("Error creating image: %s", err.Error())
+		}
+		createdIDs = append(createdIDs, created.ID)
+
+		if _, err := jqb.AddImageGallery(created.ID, gallery.ID, tx); err != nil {
+			tx.Rollback()
+			t.Fatalf("Error adding image to gallery: %s", err.Error())
+		}
+	}
+
+	gallery, err = gqb.Find(gallery.ID, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error finding gallery: %s", err.Error())
+	}
+
+	// the first image which is not a thumbnail
+	assert.Equal(t, int64(createdIDs[2]), gallery.AutoCoverImageID.Int64)
+
+	if err := jqb.DestroyImageGalleries(createdIDs[2], tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error removing image from gallery: %s", err.Error())
+	}
+	if _, err := jqb.RemoveImageGallery(createdIDs[1], gallery.ID, tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error removing image from gallery: %s", err.Error())
+	}
+
+	gallery, err = gqb.Find(gallery.ID, tx)
+	if err != nil {
+		tx.Rollback()
+		t.Fatalf("Error finding gallery: %s", err.Error())
+	}
+
+	// only the thumbnail remains
+	assert.Equal(t, int64(createdIDs[0]), gallery.AutoCoverImageID.Int64)
+
+	// roll back to remove the created objects
+	tx.Rollback()
+}
+
 // TODO ValidGalleriesForScenePath
 // TODO Count
 // TODO All
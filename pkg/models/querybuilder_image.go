@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"strconv"
 
 	"github.com/jmoiron/sqlx"
@@ -34,6 +35,12 @@ WHERE movies_join.movie_id = ?
 GROUP BY images.id
 `
 
+var imagesForTagQuery = selectAll(imageTable) + `
+LEFT JOIN images_tags as tags_join on tags_join.image_id = images.id
+WHERE tags_join.tag_id = ?
+GROUP BY images.id
+`
+
 var countImagesForTagQuery = `
 SELECT tag_id AS id FROM images_tags
 WHERE images_tags.tag_id = ?
@@ -60,11 +67,17 @@ func NewImageQueryBuilder() ImageQueryBuilder {
 
 func (qb *ImageQueryBuilder) Create(newImage Image, tx *sqlx.Tx) (*Image, error) {
 	ensureTx(tx)
+	uuid, err := uniqueUUID("images", newImage.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newImage.UUID = uuid
+
 	result, err := tx.NamedExec(
 		`INSERT INTO images (checksum, path, title, rating, organized, o_counter, size,
-                    			    width, height, studio_id, file_mod_time, created_at, updated_at)
+                    			    width, height, studio_id, file_mod_time, uuid, created_at, updated_at)
 				VALUES (:checksum, :path, :title, :rating, :organized, :o_counter, :size,
-					:width, :height, :studio_id, :file_mod_time, :created_at, :updated_at)
+					:width, :height, :studio_id, :file_mod_time, :uuid, :created_at, :updated_at)
 		`,
 		newImage,
 	)
@@ -217,6 +230,34 @@ func (qb *ImageQueryBuilder) FindByPath(path string) (*Image, error) {
 	return qb.queryImage(query, args, nil)
 }
 
+// FindByFolder returns the images directly in the folder, not including
+// those in its subfolders or in zip files.
+// FindUnderPath returns the images whose file is at path or within the
+// directory path, including the images in zip files within the directory.
+func (qb *ImageQueryBuilder) FindUnderPath(path string) ([]*Image, error) {
+	clause, args := getUnderPathClause("images.path", path)
+	return qb.queryImages(selectAll(imageTable)+"WHERE "+clause, args, nil)
+}
+
+func (qb *ImageQueryBuilder) FindByFolder(folder string, tx *sqlx.Tx) ([]*Image, error) {
+	prefix := folder + string(filepath.Separator)
+	query := selectAll(imageTable) + "WHERE path LIKE ? AND path NOT LIKE ? AND instr(path, char(0)) = 0"
+	args := []interface{}{prefix + "%", prefix + "%" + string(filepath.Separator) + "%"}
+	images, err := qb.queryImages(query, args, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// LIKE ignores case and treats _ as a wildcard
+	var ret []*Image
+	for _, image := range images {
+		if filepath.Dir(image.Path) == folder {
+			ret = append(ret, image)
+		}
+	}
+	return ret, nil
+}
+
 func (qb *ImageQueryBuilder) FindByPerformerID(performerID int) ([]*Image, error) {
 	args := []interface{}{performerID}
 	return qb.queryImages(imagesForPerformerQuery, args, nil)
@@ -232,9 +273,15 @@ func (qb *ImageQueryBuilder) FindByStudioID(studioID int) ([]*Image, error) {
 	return qb.queryImages(imagesForStudioQuery, args, nil)
 }
 
+// FindByTagID returns the images tagged with the tag.
+func (qb *ImageQueryBuilder) FindByTagID(tagID int) ([]*Image, error) {
+	args := []interface{}{tagID}
+	return qb.queryImages(imagesForTagQuery, args, nil)
+}
+
 func (qb *ImageQueryBuilder) FindByGalleryID(galleryID int) ([]*Image, error) {
 	args := []interface{}{galleryID}
-	return qb.queryImages(imagesForGalleryQuery+qb.getImageSort(nil), args, nil)
+	return qb.queryImages(imagesForGalleryQuery+qb.getImageSort(nil).String(), args, nil)
 }
 
 func (qb *ImageQueryBuilder) CountByGalleryID(galleryID int) (int, error) {
@@ -261,10 +308,10 @@ func (qb *ImageQueryBuilder) CountByTagID(tagID int) (int, error) {
 }
 
 func (qb *ImageQueryBuilder) All() ([]*Image, error) {
-	return qb.queryImages(selectAll(imageTable)+qb.getImageSort(nil), nil, nil)
+	return qb.queryImages(selectAll(imageTable)+qb.getImageSort(nil).String(), nil, nil)
 }
 
-func (qb *ImageQueryBuilder) Query(imageFilter *ImageFilterType, findFilter *FindFilterType) ([]*Image, int) {
+func (qb *ImageQueryBuilder) Query(imageFilter *ImageFilterType, findFilter *FindFilterType) ([]*Image, int, error) {
 	if imageFilter == nil {
 		imageFilter = &ImageFilterType{}
 	}
@@ -276,37 +323,48 @@ func (qb *ImageQueryBuilder) Query(imageFilter *ImageFilterType, findFilter *Fin
 		tableName: imageTable,
 	}
 
-	query.body = selectDistinctIDs(imageTable)
-	query.body += `
-		left join performers_images as performers_join on performers_join.image_id = images.id
-		left join studios as studio on studio.id = images.studio_id
-		left join images_tags as tags_join on tags_join.image_id = images.id
-		left join galleries_images as galleries_join on galleries_join.image_id = images.id
-	`
-
 	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"images.title", "images.path", "images.checksum"}
-		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
+		clause, thisArgs := getSearchDocumentBinding(imageTable, "image", *q)
 		query.addWhere(clause)
 		query.addArg(thisArgs...)
 	}
 
-	query.handleStringCriterionInput(imageFilter.Path, "images.path")
+	query.addFilter(qb.makeFilter(imageFilter))
 
-	if rating := imageFilter.Rating; rating != nil {
-		clause, count := getIntCriterionWhereClause("images.rating", *imageFilter.Rating)
-		query.addWhere(clause)
-		if count == 1 {
-			query.addArg(imageFilter.Rating.Value)
-		}
+	query.paginate(qb.getImageSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
 	}
 
+	var images []*Image
+	for _, id := range idsResult {
+		image, _ := qb.Find(id)
+		images = append(images, image)
+	}
+
+	return images, countResult, nil
+}
+
+// makeFilter returns the clauses of the image filter and its sub-filters.
+func (qb *ImageQueryBuilder) makeFilter(imageFilter *ImageFilterType) *filterBuilder {
+	query := newFilterBuilder(imageTable, selectDistinctIDs(imageTable)+`
+		left join performers_images as performers_join on performers_join.image_id = images.id
+		left join studios as studio on studio.id = images.studio_id
+		left join images_tags as tags_join on tags_join.image_id = images.id
+		left join galleries_images as galleries_join on galleries_join.image_id = images.id
+	`)
+
+	query.handleStringCriterionInput(imageFilter.Path, "images.path")
+	query.handleStringCriterionInput(imageFilter.Title, "images.title")
+
+	query.handleRatingCriterionInput(imageFilter.Rating, imageFilter.Rating100, "images.rating")
+
 	if oCounter := imageFilter.OCounter; oCounter != nil {
 		clause, count := getIntCriterionWhereClause("images.o_counter", *imageFilter.OCounter)
 		query.addWhere(clause)
-		if count == 1 {
-			query.addArg(imageFilter.OCounter.Value)
-		}
+		query.addArg(getIntCriterionArgs(*imageFilter.OCounter, count)...)
 	}
 
 	if Organized := imageFilter.Organized; Organized != nil {
@@ -367,16 +425,7 @@ func (qb *ImageQueryBuilder) Query(imageFilter *ImageFilterType, findFilter *Fin
 		}
 	}
 
-	if tagsFilter := imageFilter.Tags; tagsFilter != nil && len(tagsFilter.Value) > 0 {
-		for _, tagID := range tagsFilter.Value {
-			query.addArg(tagID)
-		}
-
-		query.body += " LEFT JOIN tags on tags_join.tag_id = tags.id"
-		whereClause, havingClause := getMultiCriterionClause("images", "tags", "images_tags", "image_id", "tag_id", tagsFilter)
-		query.addWhere(whereClause)
-		query.addHaving(havingClause)
-	}
+	query.handleCriteria(subTags.criterionHandler(imageFilter.Tags, joinTableTagClause("images", "images_tags", "image_id")))
 
 	if galleriesFilter := imageFilter.Galleries; galleriesFilter != nil && len(galleriesFilter.Value) > 0 {
 		for _, galleryID := range galleriesFilter.Value {
@@ -400,35 +449,26 @@ func (qb *ImageQueryBuilder) Query(imageFilter *ImageFilterType, findFilter *Fin
 		query.addHaving(havingClause)
 	}
 
-	if studiosFilter := imageFilter.Studios; studiosFilter != nil && len(studiosFilter.Value) > 0 {
-		for _, studioID := range studiosFilter.Value {
-			query.addArg(studioID)
-		}
+	query.handleCriteria(subStudiosCriterionHandler(imageFilter.Studios, "images"))
 
-		whereClause, havingClause := getMultiCriterionClause("images", "studio", "", "", "studio_id", studiosFilter)
-		query.addWhere(whereClause)
-		query.addHaving(havingClause)
+	if imageFilter.And != nil {
+		query.and = qb.makeFilter(imageFilter.And)
 	}
-
-	query.sortAndPagination = qb.getImageSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := query.executeFind()
-
-	var images []*Image
-	for _, id := range idsResult {
-		image, _ := qb.Find(id)
-		images = append(images, image)
+	if imageFilter.Or != nil {
+		query.or = qb.makeFilter(imageFilter.Or)
+	}
+	if imageFilter.Not != nil {
+		query.not = qb.makeFilter(imageFilter.Not)
 	}
 
-	return images, countResult
+	return query
 }
 
-func (qb *ImageQueryBuilder) getImageSort(findFilter *FindFilterType) string {
+func (qb *ImageQueryBuilder) getImageSort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		return " ORDER BY images.path ASC "
+		return sortTerms{{expression: "images.path", direction: "ASC"}}
 	}
-	sort := findFilter.GetSort("title")
-	direction := findFilter.GetDirection()
-	return getSort(sort, direction, "images")
+	return getSort(findFilter.getSortFields("title", "ASC"), "images")
 }
 
 func (qb *ImageQueryBuilder) queryImage(query string, args []interface{}, tx *sqlx.Tx) (*Image, error) {
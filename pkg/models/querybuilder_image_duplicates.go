@@ -0,0 +1,77 @@
+package models
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// imageJoinTables are the tables joining images to other objects, by the
+// column of the id of the other object.
+var imageJoinTables = map[string]string{
+	"galleries_images":  "gallery_id",
+	"images_tags":       "tag_id",
+	"performers_images": "performer_id",
+}
+
+// UpdatePhash sets the perceptual hash of the image.
+func (qb *ImageQueryBuilder) UpdatePhash(id int, phash int64, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec("UPDATE images SET phash = ? WHERE images.id = ?", phash, id)
+	return err
+}
+
+// FindMissingPhash returns the images without a perceptual hash.
+func (qb *ImageQueryBuilder) FindMissingPhash() ([]*Image, error) {
+	query := selectAll(imageTable) + "WHERE images.phash IS NULL"
+	return qb.queryImages(query+qb.getImageSort(nil).String(), nil, nil)
+}
+
+// Merge merges the source images, which are duplicates of the destination
+// image, into the destination image. The destination image is added to the
+// galleries of the source images, and gets their tags, performers and o
+// counters. Galleries with a source image as cover use the destination image
+// instead. The source images are then deleted.
+func (qb *ImageQueryBuilder) Merge(sourceIDs []int, destinationID int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if len(sourceIDs) == 0 {
+		return nil
+	}
+
+	inBinding := getInBinding(len(sourceIDs))
+	var sourceArgs []interface{}
+	for _, id := range sourceIDs {
+		sourceArgs = append(sourceArgs, id)
+	}
+	withArgs := func(args ...interface{}) []interface{} {
+		return append(args, sourceArgs...)
+	}
+
+	for table, idColumn := range imageJoinTables {
+		query := "INSERT INTO " + table + " (" + idColumn + ", image_id) SELECT DISTINCT " + idColumn + ", ? FROM " + table + " AS source" +
+			" WHERE NOT EXISTS (SELECT 1 FROM " + table + " WHERE " + table + "." + idColumn + " = source." + idColumn + " AND " + table + ".image_id = ?)" +
+			" AND source.image_id IN " + inBinding
+		if _, err := tx.Exec(query, withArgs(destinationID, destinationID)...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE images SET o_counter = o_counter + (SELECT IFNULL(SUM(o_counter), 0) FROM images WHERE id IN "+inBinding+") WHERE id = ?", append(sourceArgs, destinationID)...); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE galleries SET cover_image_id = ? WHERE cover_image_id IN "+inBinding, withArgs(destinationID)...); err != nil {
+		return err
+	}
+
+	// the joins of the source images are deleted with them
+	if _, err := tx.Exec("DELETE FROM images WHERE id IN "+inBinding, sourceArgs...); err != nil {
+		return err
+	}
+
+	jqb := NewJoinsQueryBuilder()
+	joins, err := jqb.GetImageGalleries(destinationID, tx)
+	if err != nil {
+		return err
+	}
+	return jqb.updateAutoCovers(joins, tx)
+}
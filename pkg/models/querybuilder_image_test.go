@@ -3,13 +3,17 @@
 package models_test
 
 import (
+	"context"
 	"database/sql"
+	"path/filepath"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func TestImageFind(t *testing.T) {
@@ -60,6 +64,43 @@ func TestImageFindByPath(t *testing.T) {
 	assert.Nil(t, image)
 }
 
+func TestImageFindByFolder(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	sqb := models.NewImageQueryBuilder()
+
+	folder := filepath.Join("test_folder", "a_b")
+	paths := []string{
+		filepath.Join(folder, "1.jpg"),
+		filepath.Join(folder, "2.jpg"),
+		filepath.Join(folder, "sub", "3.jpg"),
+		filepath.Join(folder, "4.zip") + "\x00" + "4.jpg",
+		filepath.Join("test_folder", "A_B", "5.jpg"),
+		filepath.Join("test_folder", "axb", "6.jpg"),
+	}
+	for _, path := range paths {
+		if _, err := sqb.Create(models.Image{
+			Path:     path,
+			Checksum: utils.MD5FromString(path),
+		}, tx); err != nil {
+			t.Fatalf("Error creating image: %s", err.Error())
+		}
+	}
+
+	images, err := sqb.FindByFolder(folder, tx)
+	if err != nil {
+		t.Fatalf("Error finding images: %s", err.Error())
+	}
+
+	var got []string
+	for _, image := range images {
+		got = append(got, image.Path)
+	}
+	assert.ElementsMatch(t, paths[:2], got)
+}
+
 func TestImageCountByPerformerID(t *testing.T) {
 	sqb := models.NewImageQueryBuilder()
 	count, err := sqb.CountByPerformerID(performerIDs[performerIdxWithImage])
@@ -93,7 +134,8 @@ func imageQueryQ(t *testing.T, sqb models.ImageQueryBuilder, q string, expectedI
 	filter := models.FindFilterType{
 		Q: &q,
 	}
-	images, _ := sqb.Query(nil, &filter)
+	images, _, err := sqb.Query(nil, &filter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 1)
 	image := images[0]
@@ -101,7 +143,8 @@ func imageQueryQ(t *testing.T, sqb models.ImageQueryBuilder, q string, expectedI
 
 	// no Q should return all results
 	filter.Q = nil
-	images, _ = sqb.Query(nil, &filter)
+	images, _, err = sqb.Query(nil, &filter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, totalImages)
 }
@@ -127,7 +170,8 @@ func verifyImagePath(t *testing.T, pathCriterion models.StringCriterionInput) {
 		Path: &pathCriterion,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	for _, image := range images {
 		verifyString(t, image.Path, pathCriterion)
@@ -165,10 +209,11 @@ func verifyImagesRating(t *testing.T, ratingCriterion models.IntCriterionInput)
 		Rating: &ratingCriterion,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	for _, image := range images {
-		verifyInt64(t, image.Rating, ratingCriterion)
+		verifyInt64(t, getRating5(image.Rating), ratingCriterion)
 	}
 }
 
@@ -197,7 +242,8 @@ func verifyImagesOCounter(t *testing.T, oCounterCriterion models.IntCriterionInp
 		OCounter: &oCounterCriterion,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	for _, image := range images {
 		verifyInt(t, image.OCounter, oCounterCriterion)
@@ -219,7 +265,8 @@ func verifyImagesResolution(t *testing.T, resolution models.ResolutionEnum) {
 		Resolution: &resolution,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	for _, image := range images {
 		verifyImageResolution(t, image.Height, resolution)
@@ -256,12 +303,14 @@ func TestImageQueryIsMissingGalleries(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ := sqb.Query(&imageFilter, &findFilter)
+	images, _, err := sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 0)
 
 	findFilter.Q = nil
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with gallery
 	for _, image := range images {
@@ -281,12 +330,14 @@ func TestImageQueryIsMissingStudio(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ := sqb.Query(&imageFilter, &findFilter)
+	images, _, err := sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 0)
 
 	findFilter.Q = nil
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with studio
 	for _, image := range images {
@@ -306,12 +357,14 @@ func TestImageQueryIsMissingPerformers(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ := sqb.Query(&imageFilter, &findFilter)
+	images, _, err := sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 0)
 
 	findFilter.Q = nil
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.True(t, len(images) > 0)
 
@@ -333,12 +386,14 @@ func TestImageQueryIsMissingTags(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ := sqb.Query(&imageFilter, &findFilter)
+	images, _, err := sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 0)
 
 	findFilter.Q = nil
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.True(t, len(images) > 0)
 }
@@ -350,7 +405,8 @@ func TestImageQueryIsMissingRating(t *testing.T) {
 		IsMissing: &isMissing,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	assert.True(t, len(images) > 0)
 
@@ -374,7 +430,8 @@ func TestImageQueryPerformers(t *testing.T) {
 		Performers: &performerCriterion,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 2)
 
@@ -391,7 +448,8 @@ func TestImageQueryPerformers(t *testing.T) {
 		Modifier: models.CriterionModifierIncludesAll,
 	}
 
-	images, _ = sqb.Query(&imageFilter, nil)
+	images, _, err = sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 1)
 	assert.Equal(t, imageIDs[imageIdxWithTwoPerformers], images[0].ID)
@@ -408,13 +466,14 @@ func TestImageQueryPerformers(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, images, 0)
 }
 
 func TestImageQueryTags(t *testing.T) {
 	sqb := models.NewImageQueryBuilder()
-	tagCriterion := models.MultiCriterionInput{
+	tagCriterion := models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(tagIDs[tagIdxWithImage]),
 			strconv.Itoa(tagIDs[tagIdx1WithImage]),
@@ -426,7 +485,8 @@ func TestImageQueryTags(t *testing.T) {
 		Tags: &tagCriterion,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 2)
 
@@ -435,7 +495,7 @@ func TestImageQueryTags(t *testing.T) {
 		assert.True(t, image.ID == imageIDs[imageIdxWithTag] || image.ID == imageIDs[imageIdxWithTwoTags])
 	}
 
-	tagCriterion = models.MultiCriterionInput{
+	tagCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(tagIDs[tagIdx1WithImage]),
 			strconv.Itoa(tagIDs[tagIdx2WithImage]),
@@ -443,12 +503,13 @@ func TestImageQueryTags(t *testing.T) {
 		Modifier: models.CriterionModifierIncludesAll,
 	}
 
-	images, _ = sqb.Query(&imageFilter, nil)
+	images, _, err = sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 1)
 	assert.Equal(t, imageIDs[imageIdxWithTwoTags], images[0].ID)
 
-	tagCriterion = models.MultiCriterionInput{
+	tagCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(tagIDs[tagIdx1WithImage]),
 		},
@@ -460,13 +521,14 @@ func TestImageQueryTags(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, images, 0)
 }
 
 func TestImageQueryStudio(t *testing.T) {
 	sqb := models.NewImageQueryBuilder()
-	studioCriterion := models.MultiCriterionInput{
+	studioCriterion := models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(studioIDs[studioIdxWithImage]),
 		},
@@ -477,14 +539,15 @@ func TestImageQueryStudio(t *testing.T) {
 		Studios: &studioCriterion,
 	}
 
-	images, _ := sqb.Query(&imageFilter, nil)
+	images, _, err := sqb.Query(&imageFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 1)
 
 	// ensure id is correct
 	assert.Equal(t, imageIDs[imageIdxWithStudio], images[0].ID)
 
-	studioCriterion = models.MultiCriterionInput{
+	studioCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(studioIDs[studioIdxWithImage]),
 		},
@@ -496,7 +559,8 @@ func TestImageQueryStudio(t *testing.T) {
 		Q: &q,
 	}
 
-	images, _ = sqb.Query(&imageFilter, &findFilter)
+	images, _, err = sqb.Query(&imageFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, images, 0)
 }
 
@@ -509,7 +573,8 @@ func TestImageQuerySorting(t *testing.T) {
 	}
 
 	sqb := models.NewImageQueryBuilder()
-	images, _ := sqb.Query(nil, &findFilter)
+	images, _, err := sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 
 	// images should be in same order as indexes
 	firstImage := images[0]
@@ -521,7 +586,8 @@ func TestImageQuerySorting(t *testing.T) {
 	// sort in descending order
 	direction = models.SortDirectionEnumDesc
 
-	images, _ = sqb.Query(nil, &findFilter)
+	images, _, err = sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 	firstImage = images[0]
 	lastImage = images[len(images)-1]
 
@@ -536,7 +602,8 @@ func TestImageQueryPagination(t *testing.T) {
 	}
 
 	sqb := models.NewImageQueryBuilder()
-	images, _ := sqb.Query(nil, &findFilter)
+	images, _, err := sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 1)
 
@@ -544,7 +611,8 @@ func TestImageQueryPagination(t *testing.T) {
 
 	page := 2
 	findFilter.Page = &page
-	images, _ = sqb.Query(nil, &findFilter)
+	images, _, err = sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, images, 1)
 	secondID := images[0].ID
@@ -553,12 +621,34 @@ func TestImageQueryPagination(t *testing.T) {
 	perPage = 2
 	page = 1
 
-	images, _ = sqb.Query(nil, &findFilter)
+	images, _, err = sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, images, 2)
 	assert.Equal(t, firstID, images[0].ID)
 	assert.Equal(t, secondID, images[1].ID)
 }
 
+func TestImageFindByTagID(t *testing.T) {
+	sqb := models.NewImageQueryBuilder()
+
+	images, err := sqb.FindByTagID(tagIDs[tagIdxWithImage])
+
+	if err != nil {
+		t.Fatalf("error calling FindByTagID: %s", err.Error())
+	}
+
+	assert.Len(t, images, 1)
+	assert.Equal(t, imageIDs[imageIdxWithTag], images[0].ID)
+
+	images, err = sqb.FindByTagID(0)
+
+	if err != nil {
+		t.Fatalf("error calling FindByTagID: %s", err.Error())
+	}
+
+	assert.Len(t, images, 0)
+}
+
 func TestImageCountByTagID(t *testing.T) {
 	sqb := models.NewImageQueryBuilder()
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type JoinsQueryBuilder struct{}
@@ -143,6 +144,58 @@ func (qb *JoinsQueryBuilder) GetSceneMovies(sceneID int, tx *sqlx.Tx) ([]MoviesS
 	return movieScenes, nil
 }
 
+// GetMovieScenes returns the scene joins of a movie, ordered by scene index.
+// Scenes without an index are returned last.
+func (qb *JoinsQueryBuilder) GetMovieScenes(movieID int, tx *sqlx.Tx) ([]MoviesScenes, error) {
+	query := `SELECT * from movies_scenes WHERE movie_id = ? ORDER BY scene_index IS NULL, scene_index, scene_id`
+
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, movieID)
+	} else {
+		rows, err = database.DB.Queryx(query, movieID)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movieScenes := make([]MoviesScenes, 0)
+	for rows.Next() {
+		movieScene := MoviesScenes{}
+		if err := rows.StructScan(&movieScene); err != nil {
+			return nil, err
+		}
+		movieScenes = append(movieScenes, movieScene)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movieScenes, nil
+}
+
+// UpdateMovieSceneIndex sets the index of a scene within a movie. It returns
+// false if the scene is not in the movie.
+func (qb *JoinsQueryBuilder) UpdateMovieSceneIndex(movieID int, sceneID int, sceneIdx sql.NullInt64, tx *sqlx.Tx) (bool, error) {
+	ensureTx(tx)
+
+	result, err := tx.Exec("UPDATE movies_scenes SET scene_index = ? WHERE movie_id = ? AND scene_id = ?", sceneIdx, movieID, sceneID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
 func (qb *JoinsQueryBuilder) CreateMoviesScenes(newJoins []MoviesScenes, tx *sqlx.Tx) error {
 	ensureTx(tx)
 	for _, join := range newJoins {
@@ -626,12 +679,33 @@ func (qb *JoinsQueryBuilder) CreateGalleriesImages(newJoins []GalleriesImages, t
 func (qb *JoinsQueryBuilder) UpdateGalleriesImages(imageID int, updatedJoins []GalleriesImages, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
+	existingJoins, err := qb.GetImageGalleries(imageID, tx)
+	if err != nil {
+		return err
+	}
+
 	// Delete the existing joins and then create new ones
-	_, err := tx.Exec("DELETE FROM galleries_images WHERE image_id = ?", imageID)
+	_, err = tx.Exec("DELETE FROM galleries_images WHERE image_id = ?", imageID)
 	if err != nil {
 		return err
 	}
-	return qb.CreateGalleriesImages(updatedJoins, tx)
+	if err := qb.CreateGalleriesImages(updatedJoins, tx); err != nil {
+		return err
+	}
+
+	// the automatic covers of the galleries the image was added to or
+	// removed from may change
+	return qb.updateAutoCovers(append(existingJoins, updatedJoins...), tx)
+}
+
+func (qb *JoinsQueryBuilder) updateAutoCovers(joins []GalleriesImages, tx *sqlx.Tx) error {
+	var galleryIDs []int
+	for _, join := range joins {
+		galleryIDs = utils.IntAppendUnique(galleryIDs, join.GalleryID)
+	}
+
+	gqb := NewGalleryQueryBuilder()
+	return gqb.UpdateAutoCovers(galleryIDs, tx)
 }
 
 // AddGalleryImage adds a gallery to an image. It does not make any change if the tag
@@ -696,10 +770,18 @@ func (qb *JoinsQueryBuilder) RemoveImageGallery(imageID int, galleryID int, tx *
 func (qb *JoinsQueryBuilder) DestroyImageGalleries(imageID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
+	existingJoins, err := qb.GetImageGalleries(imageID, tx)
+	if err != nil {
+		return err
+	}
+
 	// Delete the existing joins
-	_, err := tx.Exec("DELETE FROM galleries_images WHERE image_id = ?", imageID)
+	_, err = tx.Exec("DELETE FROM galleries_images WHERE image_id = ?", imageID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return qb.updateAutoCovers(existingJoins, tx)
 }
 
 func (qb *JoinsQueryBuilder) GetGalleryPerformers(galleryID int, tx *sqlx.Tx) ([]PerformersGalleries, error) {
@@ -946,6 +1028,33 @@ func (qb *JoinsQueryBuilder) GetPerformerStashIDs(performerID int) ([]*StashID,
 	return stashIDs, nil
 }
 
+// GetAllPerformerStashIDs returns the stash ids of all performers, keyed by
+// performer id.
+func (qb *JoinsQueryBuilder) GetAllPerformerStashIDs() (map[int][]*StashID, error) {
+	rows, err := database.DB.Queryx(`SELECT performer_id, stash_id, endpoint from performer_stash_ids`)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make(map[int][]*StashID)
+	for rows.Next() {
+		var performerID int
+		stashID := StashID{}
+		if err := rows.Scan(&performerID, &stashID.StashID, &stashID.Endpoint); err != nil {
+			return nil, err
+		}
+		ret[performerID] = append(ret[performerID], &stashID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
 func (qb *JoinsQueryBuilder) GetStudioStashIDs(studioID int) ([]*StashID, error) {
 	rows, err := database.DB.Queryx(`SELECT stash_id, endpoint from studio_stash_ids WHERE studio_id = ?`, studioID)
 
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
@@ -16,9 +17,21 @@ func NewMovieQueryBuilder() MovieQueryBuilder {
 
 func (qb *MovieQueryBuilder) Create(newMovie Movie, tx *sqlx.Tx) (*Movie, error) {
 	ensureTx(tx)
+	slug, err := movieSlugs.unique(newMovie.Slug, newMovie.Name.String, tx)
+	if err != nil {
+		return nil, err
+	}
+	newMovie.Slug = slug
+
+	uuid, err := uniqueUUID("movies", newMovie.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newMovie.UUID = uuid
+
 	result, err := tx.NamedExec(
-		`INSERT INTO movies (checksum, name, aliases, duration, date, rating, studio_id, director, synopsis, url, created_at, updated_at)
-				VALUES (:checksum, :name, :aliases, :duration, :date, :rating, :studio_id, :director, :synopsis, :url, :created_at, :updated_at)
+		`INSERT INTO movies (checksum, name, aliases, duration, date, rating, studio_id, director, synopsis, slug, uuid, cover_url, background_url, created_at, updated_at)
+				VALUES (:checksum, :name, :aliases, :duration, :date, :rating, :studio_id, :director, :synopsis, :slug, :uuid, :cover_url, :background_url, :created_at, :updated_at)
 		`,
 		newMovie,
 	)
@@ -70,11 +83,20 @@ func (qb *MovieQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 		return err
 	}
 
-	// // remove movie from scraped items
-	// _, err = tx.Exec("UPDATE scraped_items SET movie_id = null WHERE movie_id = ?", id)
-	// if err != nil {
-	// 	return err
-	// }
+	_, err = tx.Exec("DELETE FROM movie_custom_fields WHERE movie_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM movie_urls WHERE movie_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	movieID, _ := strconv.Atoi(id)
+	if err := destroyImageFiles(movieID, tx, movieFrontImageBlob, movieBackImageBlob); err != nil {
+		return err
+	}
 
 	return executeDeleteQuery("movies", id, tx)
 }
@@ -85,14 +107,35 @@ func (qb *MovieQueryBuilder) Find(id int, tx *sqlx.Tx) (*Movie, error) {
 	return qb.queryMovie(query, args, tx)
 }
 
+// FindMany returns the movies with the given ids, in the same order as ids.
+// It returns an error if any of the movies is not found.
 func (qb *MovieQueryBuilder) FindMany(ids []int) ([]*Movie, error) {
-	var movies []*Movie
-	for _, id := range ids {
-		movie, err := qb.Find(id, nil)
+	byID := make(map[int]*Movie)
+	for start := 0; start < len(ids); start += findManyBatchSize {
+		end := start + findManyBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch := ids[start:end]
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+
+		movies, err := qb.queryMovies("SELECT * FROM movies WHERE id IN "+getInBinding(len(batch)), args, nil)
 		if err != nil {
 			return nil, err
 		}
 
+		for _, movie := range movies {
+			byID[movie.ID] = movie
+		}
+	}
+
+	var movies []*Movie
+	for _, id := range ids {
+		movie := byID[id]
 		if movie == nil {
 			return nil, fmt.Errorf("movie with id %d not found", id)
 		}
@@ -137,19 +180,46 @@ func (qb *MovieQueryBuilder) FindByNames(names []string, tx *sqlx.Tx, nocase boo
 	return qb.queryMovies(query, args, tx)
 }
 
+// FindWithSceneIndexIssues returns the movies with scenes without an index,
+// with scenes sharing an index, or with indexes not numbered from 1 without
+// gaps.
+func (qb *MovieQueryBuilder) FindWithSceneIndexIssues(tx *sqlx.Tx) ([]*Movie, error) {
+	query := selectAll("movies") + `WHERE movies.id IN (
+		SELECT movie_id FROM movies_scenes
+		GROUP BY movie_id
+		HAVING count(scene_index) < count(*)
+			OR count(DISTINCT scene_index) < count(scene_index)
+			OR min(scene_index) < 1
+			OR max(scene_index) > count(DISTINCT scene_index)
+	) ` + qb.getMovieSort(nil).String()
+	return qb.queryMovies(query, nil, tx)
+}
+
 func (qb *MovieQueryBuilder) Count() (int, error) {
 	return runCountQuery(buildCountQuery("SELECT movies.id FROM movies"), nil)
 }
 
 func (qb *MovieQueryBuilder) All() ([]*Movie, error) {
-	return qb.queryMovies(selectAll("movies")+qb.getMovieSort(nil), nil, nil)
+	return qb.queryMovies(selectAll("movies")+qb.getMovieSort(nil).String(), nil, nil)
+}
+
+// FindReleasedOnMonthDays returns the movies released on the months and days
+// in MM-DD format before the date before, most recent first.
+func (qb *MovieQueryBuilder) FindReleasedOnMonthDays(monthDays []string, before string) ([]*Movie, error) {
+	if len(monthDays) == 0 {
+		return nil, nil
+	}
+
+	clause, args := monthDayInClause("movies.date", monthDays)
+	query := selectAll("movies") + "WHERE " + clause + " AND movies.date < ? ORDER BY movies.date DESC, movies.id ASC"
+	return qb.queryMovies(query, append(args, before), nil)
 }
 
 func (qb *MovieQueryBuilder) AllSlim() ([]*Movie, error) {
-	return qb.queryMovies("SELECT movies.id, movies.name FROM movies "+qb.getMovieSort(nil), nil, nil)
+	return qb.queryMovies("SELECT movies.id, movies.name FROM movies "+qb.getMovieSort(nil).String(), nil, nil)
 }
 
-func (qb *MovieQueryBuilder) Query(movieFilter *MovieFilterType, findFilter *FindFilterType) ([]*Movie, int) {
+func (qb *MovieQueryBuilder) Query(movieFilter *MovieFilterType, findFilter *FindFilterType) ([]*Movie, int, error) {
 	if findFilter == nil {
 		findFilter = &FindFilterType{}
 	}
@@ -157,81 +227,93 @@ func (qb *MovieQueryBuilder) Query(movieFilter *MovieFilterType, findFilter *Fin
 		movieFilter = &MovieFilterType{}
 	}
 
-	var whereClauses []string
-	var havingClauses []string
-	var args []interface{}
-	body := selectDistinctIDs("movies")
-	body += `
-	left join movies_scenes as scenes_join on scenes_join.movie_id = movies.id
-	left join scenes on scenes_join.scene_id = scenes.id
-	left join studios as studio on studio.id = movies.studio_id
-`
+	query := queryBuilder{
+		tableName: "movies",
+	}
 
 	if q := findFilter.Q; q != nil && *q != "" {
 		searchColumns := []string{"movies.name"}
 		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
-		whereClauses = append(whereClauses, clause)
-		args = append(args, thisArgs...)
+		query.addWhere(clause)
+		query.addArg(thisArgs...)
 	}
 
-	if studiosFilter := movieFilter.Studios; studiosFilter != nil && len(studiosFilter.Value) > 0 {
-		for _, studioID := range studiosFilter.Value {
-			args = append(args, studioID)
-		}
+	query.addFilter(qb.makeFilter(movieFilter))
 
-		whereClause, havingClause := getMultiCriterionClause("movies", "studio", "", "", "studio_id", studiosFilter)
-		whereClauses = appendClause(whereClauses, whereClause)
-		havingClauses = appendClause(havingClauses, havingClause)
-	}
-
-	if isMissingFilter := movieFilter.IsMissing; isMissingFilter != nil && *isMissingFilter != "" {
-		switch *isMissingFilter {
-		case "front_image":
-			body += `left join movies_images on movies_images.movie_id = movies.id
-			`
-			whereClauses = appendClause(whereClauses, "movies_images.front_image IS NULL")
-		case "back_image":
-			body += `left join movies_images on movies_images.movie_id = movies.id
-			`
-			whereClauses = appendClause(whereClauses, "movies_images.back_image IS NULL")
-		case "scenes":
-			body += `left join movies_scenes on movies_scenes.movie_id = movies.id
-			`
-			whereClauses = appendClause(whereClauses, "movies_scenes.scene_id IS NULL")
-		default:
-			whereClauses = appendClause(whereClauses, "movies."+*isMissingFilter+" IS NULL")
-		}
+	query.paginate(qb.getMovieSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
 	}
 
-	sortAndPagination := qb.getMovieSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := executeFindQuery("movies", body, args, sortAndPagination, whereClauses, havingClauses)
+	movies, _ := qb.FindMany(idsResult)
 
-	var movies []*Movie
-	for _, id := range idsResult {
-		movie, _ := qb.Find(id, nil)
-		movies = append(movies, movie)
+	return movies, countResult, nil
+}
+
+// makeFilter returns the clauses of the movie filter and its sub-filters.
+func (qb *MovieQueryBuilder) makeFilter(movieFilter *MovieFilterType) *filterBuilder {
+	query := newFilterBuilder("movies", selectDistinctIDs("movies")+`
+	left join movies_scenes as scenes_join on scenes_join.movie_id = movies.id
+	left join scenes on scenes_join.scene_id = scenes.id
+`)
+
+	query.handleCriteria(
+		dateCriterionHandler(movieFilter.Date, "movies.date"),
+		timestampCriterionHandler(movieFilter.CreatedAt, "movies.created_at"),
+		timestampCriterionHandler(movieFilter.UpdatedAt, "movies.updated_at"),
+		subStudiosCriterionHandler(movieFilter.Studios, "movies"),
+		moviePins.criterionHandler(movieFilter.Pinned),
+		ratingCriterionHandler(movieFilter.Rating, movieFilter.Rating100, "movies.rating"),
+		isMissingCriterionHandler(movieFilter.IsMissing, "movies", map[string]isMissingJoin{
+			"front_image": {
+				table:    "movies_images",
+				onClause: "movies_images.movie_id = movies.id",
+				clause:   "movies_images.front_image IS NULL",
+			},
+			"back_image": {
+				table:    "movies_images",
+				onClause: "movies_images.movie_id = movies.id",
+				clause:   "movies_images.back_image IS NULL",
+			},
+			"scenes": {
+				clause: "scenes_join.scene_id IS NULL",
+			},
+		}),
+		movieURLCriterionHandler(movieFilter.URL),
+		customFieldsCriterionHandler(movieCustomFieldsTable, movieFilter.CustomFields),
+		havingIntCriterionHandler(movieFilter.SceneCount, "count(distinct scenes_join.scene_id)"),
+		havingIntCriterionHandler(movieFilter.Duration, "COALESCE(movies.duration, CAST(total(scenes.duration) AS INTEGER))"),
+	)
+
+	if movieFilter.And != nil {
+		query.and = qb.makeFilter(movieFilter.And)
+	}
+	if movieFilter.Or != nil {
+		query.or = qb.makeFilter(movieFilter.Or)
+	}
+	if movieFilter.Not != nil {
+		query.not = qb.makeFilter(movieFilter.Not)
 	}
 
-	return movies, countResult
+	return query
 }
 
-func (qb *MovieQueryBuilder) getMovieSort(findFilter *FindFilterType) string {
-	var sort string
-	var direction string
+func (qb *MovieQueryBuilder) getMovieSort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		sort = "name"
-		direction = "ASC"
-	} else {
-		sort = findFilter.GetSort("name")
-		direction = findFilter.GetDirection()
+		return getSort([]sortField{{sort: "name", direction: "ASC"}}, "movies")
 	}
 
-	// #943 - override name sorting to use natural sort
-	if sort == "name" {
-		return " ORDER BY " + getColumn("movies", sort) + " COLLATE NATURAL_CS " + direction
+	fields := findFilter.getSortFields("name", "ASC")
+	for i := range fields {
+		if fields[i].sort == "scene_count" {
+			// scenes is the alias of the joined scenes table
+			fields[i].sort = "scenes_count"
+		}
 	}
 
-	return getSort(sort, direction, "movies")
+	return getSort(fields, "movies")
 }
 
 func (qb *MovieQueryBuilder) queryMovie(query string, args []interface{}, tx *sqlx.Tx) (*Movie, error) {
@@ -280,11 +362,22 @@ func (qb *MovieQueryBuilder) UpdateMovieImages(movieID int, frontImage []byte, b
 		return err
 	}
 
-	_, err := tx.Exec(
-		`INSERT INTO movies_images (movie_id, front_image, back_image) VALUES (?, ?, ?)`,
+	frontImage, frontImageFile, err := movieFrontImageBlob.store(movieID, frontImage)
+	if err != nil {
+		return err
+	}
+	backImage, backImageFile, err := movieBackImageBlob.store(movieID, backImage)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO movies_images (movie_id, front_image, back_image, front_image_file, back_image_file) VALUES (?, ?, ?, ?, ?)`,
 		movieID,
 		frontImage,
 		backImage,
+		frontImageFile,
+		backImageFile,
 	)
 
 	return err
@@ -293,20 +386,121 @@ func (qb *MovieQueryBuilder) UpdateMovieImages(movieID int, frontImage []byte, b
 func (qb *MovieQueryBuilder) DestroyMovieImages(movieID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
-	// Delete the existing joins
-	_, err := tx.Exec("DELETE FROM movies_images WHERE movie_id = ?", movieID)
-	if err != nil {
+	if err := destroyImageFiles(movieID, tx, movieFrontImageBlob, movieBackImageBlob); err != nil {
 		return err
 	}
+
+	// Delete the existing joins
+	_, err := tx.Exec("DELETE FROM movies_images WHERE movie_id = ?", movieID)
 	return err
 }
 
 func (qb *MovieQueryBuilder) GetFrontImage(movieID int, tx *sqlx.Tx) ([]byte, error) {
-	query := `SELECT front_image from movies_images WHERE movie_id = ?`
-	return getImage(tx, query, movieID)
+	return movieFrontImageBlob.get(movieID, tx)
 }
 
 func (qb *MovieQueryBuilder) GetBackImage(movieID int, tx *sqlx.Tx) ([]byte, error) {
-	query := `SELECT back_image from movies_images WHERE movie_id = ?`
-	return getImage(tx, query, movieID)
+	return movieBackImageBlob.get(movieID, tx)
+}
+
+func movieURLCriterionHandler(c *StringCriterionInput) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if c != nil {
+			if err := validateRegexCriterion(c.Modifier, c.Value); err != nil {
+				f.setError(err)
+				return
+			}
+
+			clause, args := getMovieURLCriterionClause(*c)
+			f.where(clause, args...)
+		}
+	}
+}
+
+// getMovieURLCriterionClause returns a where clause matching the movies with a
+// URL satisfying the criterion. The negative modifiers match movies without
+// any URL satisfying the positive modifier.
+func getMovieURLCriterionClause(criterion StringCriterionInput) (string, []interface{}) {
+	exists := "EXISTS (SELECT 1 FROM movie_urls WHERE movie_urls.movie_id = movies.id"
+	return getURLTableCriterionClause(exists, nil, "movie_urls.url", criterion)
+}
+
+// getURLTableCriterionClause returns a where clause matching the objects with
+// a URL in the column satisfying the criterion, where exists is an
+// unterminated EXISTS clause selecting the URLs of an object, with the
+// arguments existsArgs. The negative modifiers match objects without any URL
+// satisfying the positive modifier.
+func getURLTableCriterionClause(exists string, existsArgs []interface{}, column string, criterion StringCriterionInput) (string, []interface{}) {
+	args := func(args ...interface{}) []interface{} {
+		return append(append([]interface{}{}, existsArgs...), args...)
+	}
+
+	switch criterion.Modifier {
+	case CriterionModifierIsNull:
+		return "NOT " + exists + ")", args()
+	case CriterionModifierIncludes, CriterionModifierExcludes:
+		clause, searchArgs := getSearchBinding([]string{column}, criterion.Value, false)
+		clause = exists + " AND " + clause + ")"
+		if criterion.Modifier == CriterionModifierExcludes {
+			clause = "NOT " + clause
+		}
+		return clause, args(searchArgs...)
+	case CriterionModifierEquals:
+		return exists + " AND " + column + " LIKE ?)", args(criterion.Value)
+	case CriterionModifierNotEquals:
+		return "NOT " + exists + " AND " + column + " LIKE ?)", args(criterion.Value)
+	case CriterionModifierMatchesRegex:
+		return exists + " AND " + column + " regexp ?)", args(criterion.Value)
+	case CriterionModifierNotMatchesRegex:
+		return "NOT " + exists + " AND " + column + " regexp ?)", args(criterion.Value)
+	default:
+		// NOT_NULL
+		return exists + ")", args()
+	}
+}
+
+// GetURLs returns the URLs of the movie, in order.
+func (qb *MovieQueryBuilder) GetURLs(movieID int, tx *sqlx.Tx) ([]string, error) {
+	query := "SELECT url FROM movie_urls WHERE movie_id = ? ORDER BY position"
+
+	var ret []string
+	var err error
+	if tx != nil {
+		err = tx.Select(&ret, query, movieID)
+	} else {
+		err = database.DB.Select(&ret, query, movieID)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// UpdateURLs replaces the URLs of the movie with urls.
+func (qb *MovieQueryBuilder) UpdateURLs(movieID int, urls []string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM movie_urls WHERE movie_id = ?", movieID); err != nil {
+		return err
+	}
+
+	for i, url := range urls {
+		if _, err := tx.Exec("INSERT INTO movie_urls (movie_id, position, url) VALUES (?, ?, ?)", movieID, i, url); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCustomFields returns the custom fields of the movie, keyed by field name.
+func (qb *MovieQueryBuilder) GetCustomFields(movieID int, tx *sqlx.Tx) (map[string]string, error) {
+	return movieCustomFieldsTable.get(movieID, tx)
+}
+
+// UpdateCustomFields replaces the custom fields of the movie with fields.
+func (qb *MovieQueryBuilder) UpdateCustomFields(movieID int, fields map[string]string, tx *sqlx.Tx) error {
+	return movieCustomFieldsTable.update(movieID, fields, tx)
 }
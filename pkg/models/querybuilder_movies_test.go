@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stashapp/stash/pkg/database"
@@ -68,6 +69,26 @@ func TestMovieFindByName(t *testing.T) {
 	assert.Equal(t, strings.ToLower(movieNames[movieIdxWithDupName]), strings.ToLower(movie.Name.String))
 }
 
+func TestMovieFindMany(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+
+	// results are in the order of the ids
+	ids := []int{movieIDs[movieIdxWithDupName], movieIDs[movieIdxWithScene], movieIDs[movieIdxWithDupName]}
+	movies, err := mqb.FindMany(ids)
+	if err != nil {
+		t.Fatalf("Error finding movies: %s", err.Error())
+	}
+
+	var foundIDs []int
+	for _, m := range movies {
+		foundIDs = append(foundIDs, m.ID)
+	}
+	assert.Equal(t, ids, foundIDs)
+
+	_, err = mqb.FindMany([]int{movieIDs[movieIdxWithScene], -1})
+	assert.NotNil(t, err)
+}
+
 func TestMovieFindByNames(t *testing.T) {
 	var names []string
 
@@ -93,7 +114,7 @@ func TestMovieFindByNames(t *testing.T) {
 
 func TestMovieQueryStudio(t *testing.T) {
 	mqb := models.NewMovieQueryBuilder()
-	studioCriterion := models.MultiCriterionInput{
+	studioCriterion := models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(studioIDs[studioIdxWithMovie]),
 		},
@@ -104,14 +125,15 @@ func TestMovieQueryStudio(t *testing.T) {
 		Studios: &studioCriterion,
 	}
 
-	movies, _ := mqb.Query(&movieFilter, nil)
+	movies, _, err := mqb.Query(&movieFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, movies, 1)
 
 	// ensure id is correct
 	assert.Equal(t, movieIDs[movieIdxWithStudio], movies[0].ID)
 
-	studioCriterion = models.MultiCriterionInput{
+	studioCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(studioIDs[studioIdxWithMovie]),
 		},
@@ -123,10 +145,62 @@ func TestMovieQueryStudio(t *testing.T) {
 		Q: &q,
 	}
 
-	movies, _ = mqb.Query(&movieFilter, &findFilter)
+	movies, _, err = mqb.Query(&movieFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, movies, 0)
 }
 
+func TestMovieQueryInvalidRegex(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+	const invalid = `movie_(`
+
+	_, _, err := mqb.Query(&models.MovieFilterType{
+		URL: &models.StringCriterionInput{
+			Value:    invalid,
+			Modifier: models.CriterionModifierNotMatchesRegex,
+		},
+	}, nil)
+	assert.NotNil(t, err)
+
+	value := invalid
+	_, _, err = mqb.Query(&models.MovieFilterType{
+		CustomFields: []*models.CustomFieldCriterionInput{
+			{Field: "disc count", Value: &value, Modifier: models.CriterionModifierMatchesRegex},
+		},
+	}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestMovieQuerySubFilters(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+
+	// sub-filters with having clause arguments
+	movieFilter := models.MovieFilterType{
+		SceneCount: &models.IntCriterionInput{Value: 0, Modifier: models.CriterionModifierGreaterThan},
+		Or: &models.MovieFilterType{
+			Studios: &models.HierarchicalMultiCriterionInput{
+				Value:    []string{strconv.Itoa(studioIDs[studioIdxWithMovie])},
+				Modifier: models.CriterionModifierIncludes,
+			},
+		},
+		Not: &models.MovieFilterType{
+			SceneCount: &models.IntCriterionInput{Value: 1, Modifier: models.CriterionModifierGreaterThan},
+		},
+	}
+
+	movies, count, err := mqb.Query(&movieFilter, nil)
+	assert.NoError(t, err)
+	assert.Len(t, movies, count)
+
+	var ids []int
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	assert.Contains(t, ids, movieIDs[movieIdxWithScene])
+	assert.Contains(t, ids, movieIDs[movieIdxWithStudio])
+	assert.NotContains(t, ids, movieIDs[movieIdxWithDupName])
+}
+
 func TestMovieUpdateMovieImages(t *testing.T) {
 	mqb := models.NewMovieQueryBuilder()
 
@@ -267,9 +341,416 @@ func TestMovieDestroyMovieImages(t *testing.T) {
 	assert.Nil(t, storedBack)
 }
 
+func TestMovieReorderScenes(t *testing.T) {
+	jqb := models.NewJoinsQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.movie(models.Movie{Name: sql.NullString{String: "TestMovieReorderScenes", Valid: true}})
+
+	scene1 := sceneIDs[sceneIdxWithGallery]
+	scene2 := sceneIDs[sceneIdxWithPerformer]
+	scene3 := sceneIDs[sceneIdxWithTag]
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		idx := 1
+		for _, sceneID := range []int{scene1, scene2, scene3} {
+			var sceneIdx *int
+			if sceneID != scene3 {
+				sceneIdx = &idx
+			}
+			if _, err := jqb.AddMoviesScene(sceneID, created.ID, sceneIdx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	sqb := models.NewSceneQueryBuilder()
+	getSceneIDs := func() []int {
+		scenes, err := sqb.FindByMovieID(created.ID)
+		if err != nil {
+			t.Fatalf("Error finding scenes: %s", err.Error())
+		}
+
+		var ret []int
+		for _, s := range scenes {
+			ret = append(ret, s.ID)
+		}
+		return ret
+	}
+
+	// scenes with equal index are ordered by id, unindexed scenes last
+	assert.Equal(t, []int{scene1, scene2, scene3}, getSceneIDs())
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, sceneID := range []int{scene3, scene1, scene2} {
+			sceneIdx := sql.NullInt64{Int64: int64(i + 1), Valid: true}
+			found, err := jqb.UpdateMovieSceneIndex(created.ID, sceneID, sceneIdx, tx)
+			if err != nil {
+				return err
+			}
+			assert.True(t, found)
+		}
+
+		// scene not in the movie
+		found, err := jqb.UpdateMovieSceneIndex(created.ID, sceneIDs[sceneIdxWithMovie], sql.NullInt64{}, tx)
+		if err != nil {
+			return err
+		}
+		assert.False(t, found)
+		return nil
+	})
+
+	assert.Equal(t, []int{scene3, scene1, scene2}, getSceneIDs())
+
+	movieScenes, err := jqb.GetMovieScenes(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting movie scenes: %s", err.Error())
+	}
+	assert.Len(t, movieScenes, 3)
+	assert.Equal(t, scene3, movieScenes[0].SceneID)
+	assert.Equal(t, int64(1), movieScenes[0].SceneIndex.Int64)
+}
+
+func TestMovieCustomFields(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.movie(models.Movie{Name: sql.NullString{String: "TestMovieCustomFields", Valid: true}})
+
+	fields := map[string]string{
+		"disc count":    "2",
+		"series number": "10",
+	}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return mqb.UpdateCustomFields(created.ID, fields, tx)
+	})
+
+	stored, err := mqb.GetCustomFields(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting custom fields: %s", err.Error())
+	}
+	assert.Equal(t, fields, stored)
+
+	queryIDs := func(criterion models.CustomFieldCriterionInput) []int {
+		movieFilter := models.MovieFilterType{
+			CustomFields: []*models.CustomFieldCriterionInput{&criterion},
+		}
+		movies, _, err := mqb.Query(&movieFilter, nil)
+		assert.NoError(t, err)
+
+		var ret []int
+		for _, m := range movies {
+			ret = append(ret, m.ID)
+		}
+		return ret
+	}
+
+	value := func(v string) *string {
+		return &v
+	}
+
+	matching := []models.CustomFieldCriterionInput{
+		{Field: "disc count", Modifier: models.CriterionModifierNotNull},
+		{Field: "disc count", Value: value("2"), Modifier: models.CriterionModifierEquals},
+		{Field: "series number", Value: value("9"), Modifier: models.CriterionModifierGreaterThan},
+		{Field: "series number", Value: value("1"), Modifier: models.CriterionModifierIncludes},
+	}
+	for _, c := range matching {
+		assert.Equal(t, []int{created.ID}, queryIDs(c), "%s %s", c.Field, c.Modifier)
+	}
+
+	notMatching := []models.CustomFieldCriterionInput{
+		{Field: "disc count", Modifier: models.CriterionModifierIsNull},
+		{Field: "disc count", Value: value("2"), Modifier: models.CriterionModifierNotEquals},
+		{Field: "series number", Value: value("10"), Modifier: models.CriterionModifierLessThan},
+		{Field: "missing", Modifier: models.CriterionModifierNotNull},
+	}
+	for _, c := range notMatching {
+		assert.NotContains(t, queryIDs(c), created.ID, "%s %s", c.Field, c.Modifier)
+	}
+
+	// movies without the field match negative criteria
+	assert.Contains(t, queryIDs(models.CustomFieldCriterionInput{Field: "disc count", Modifier: models.CriterionModifierIsNull}), movieIDs[movieIdxWithScene])
+
+	// updating replaces all fields
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return mqb.UpdateCustomFields(created.ID, map[string]string{"disc count": "3"}, tx)
+	})
+
+	stored, err = mqb.GetCustomFields(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting custom fields: %s", err.Error())
+	}
+	assert.Equal(t, map[string]string{"disc count": "3"}, stored)
+}
+
+func TestMovieURLs(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.movie(models.Movie{Name: sql.NullString{String: "TestMovieURLs", Valid: true}})
+
+	urls := []string{"https://example.com/b", "https://example.com/a"}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return mqb.UpdateURLs(created.ID, urls, tx)
+	})
+
+	// urls are returned in order
+	stored, err := mqb.GetURLs(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting urls: %s", err.Error())
+	}
+	assert.Equal(t, urls, stored)
+
+	queryIDs := func(criterion models.StringCriterionInput) []int {
+		movieFilter := models.MovieFilterType{
+			URL: &criterion,
+		}
+		movies, _, err := mqb.Query(&movieFilter, nil)
+		assert.NoError(t, err)
+
+		var ret []int
+		for _, m := range movies {
+			ret = append(ret, m.ID)
+		}
+		return ret
+	}
+
+	matching := []models.StringCriterionInput{
+		{Modifier: models.CriterionModifierNotNull},
+		{Value: "https://example.com/a", Modifier: models.CriterionModifierEquals},
+		{Value: "example.com/b", Modifier: models.CriterionModifierIncludes},
+	}
+	for _, c := range matching {
+		assert.Equal(t, []int{created.ID}, queryIDs(c), "%s %s", c.Value, c.Modifier)
+	}
+
+	notMatching := []models.StringCriterionInput{
+		{Modifier: models.CriterionModifierIsNull},
+		{Value: "https://example.com/a", Modifier: models.CriterionModifierNotEquals},
+		{Value: "example.com", Modifier: models.CriterionModifierExcludes},
+	}
+	for _, c := range notMatching {
+		assert.NotContains(t, queryIDs(c), created.ID, "%s %s", c.Value, c.Modifier)
+	}
+
+	// updating replaces all urls
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return mqb.UpdateURLs(created.ID, []string{"https://example.com/c"}, tx)
+	})
+
+	stored, err = mqb.GetURLs(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting urls: %s", err.Error())
+	}
+	assert.Equal(t, []string{"https://example.com/c"}, stored)
+}
+
 // TODO Update
 // TODO Destroy
 // TODO Find
 // TODO Count
 // TODO All
 // TODO Query
+
+func TestMovieQueryRating(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.movie(models.Movie{
+		Name:   sql.NullString{String: "TestMovieQueryRating", Valid: true},
+		Rating: sql.NullInt64{Int64: 50, Valid: true},
+	})
+
+	queryIDs := func(movieFilter models.MovieFilterType) []int {
+		movies, _, err := mqb.Query(&movieFilter, nil)
+		assert.NoError(t, err)
+
+		var ret []int
+		for _, m := range movies {
+			ret = append(ret, m.ID)
+		}
+		return ret
+	}
+
+	value2 := func(v int) *int {
+		return &v
+	}
+
+	matching := []models.MovieFilterType{
+		{Rating: &models.IntCriterionInput{Value: 3, Modifier: models.CriterionModifierEquals}},
+		{Rating: &models.IntCriterionInput{Value: 2, Modifier: models.CriterionModifierGreaterThan}},
+		{Rating100: &models.IntCriterionInput{Value: 45, Value2: value2(55), Modifier: models.CriterionModifierBetween}},
+		{Rating100: &models.IntCriterionInput{Value: 60, Value2: value2(80), Modifier: models.CriterionModifierNotBetween}},
+	}
+	for _, f := range matching {
+		assert.Equal(t, []int{created.ID}, queryIDs(f))
+	}
+
+	notMatching := []models.MovieFilterType{
+		{Rating: &models.IntCriterionInput{Value: 3, Modifier: models.CriterionModifierNotEquals}},
+		{Rating100: &models.IntCriterionInput{Value: 50, Modifier: models.CriterionModifierGreaterThan}},
+		{Rating100: &models.IntCriterionInput{Value: 40, Value2: value2(49), Modifier: models.CriterionModifierBetween}},
+	}
+	for _, f := range notMatching {
+		assert.NotContains(t, queryIDs(f), created.ID)
+	}
+}
+
+func TestMovieQuerySceneCountAndDuration(t *testing.T) {
+	const sceneIdx = 1
+	mqb := models.NewMovieQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	withoutScenes := f.movie(models.Movie{
+		Name:     sql.NullString{String: "TestMovieQueryDuration", Valid: true},
+		Duration: sql.NullInt64{Int64: 3600, Valid: true},
+	}).ID
+	withScenes := f.movie(models.Movie{
+		Name: sql.NullString{String: "TestMovieQuerySceneCount", Valid: true},
+	}).ID
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for _, sceneID := range []int{sceneIDs[sceneIdxWithMovie], sceneIDs[sceneIdx]} {
+			if _, err := jqb.AddMoviesScene(sceneID, withScenes, nil, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	queryIDs := func(movieFilter models.MovieFilterType) []int {
+		perPage := 1000
+		movies, count, err := mqb.Query(&movieFilter, &models.FindFilterType{
+			PerPage: &perPage,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, movies, count)
+
+		var ret []int
+		for _, m := range movies {
+			ret = append(ret, m.ID)
+		}
+		return ret
+	}
+
+	value2 := func(v int) *int {
+		return &v
+	}
+
+	ids := queryIDs(models.MovieFilterType{
+		SceneCount: &models.IntCriterionInput{Value: 0, Modifier: models.CriterionModifierEquals},
+	})
+	assert.Contains(t, ids, withoutScenes)
+	assert.NotContains(t, ids, withScenes)
+	assert.NotContains(t, ids, movieIDs[movieIdxWithScene])
+
+	ids = queryIDs(models.MovieFilterType{
+		SceneCount: &models.IntCriterionInput{Value: 0, Value2: value2(1), Modifier: models.CriterionModifierBetween},
+	})
+	assert.Contains(t, ids, withoutScenes)
+	assert.NotContains(t, ids, withScenes)
+	assert.Contains(t, ids, movieIDs[movieIdxWithScene])
+
+	ids = queryIDs(models.MovieFilterType{
+		SceneCount: &models.IntCriterionInput{Value: 1, Modifier: models.CriterionModifierGreaterThan},
+	})
+	assert.Equal(t, []int{withScenes}, ids)
+
+	// movies without a duration use the total duration of their scenes
+	sceneDuration := int(getSceneDuration(sceneIdx).Float64)
+	ids = queryIDs(models.MovieFilterType{
+		Duration: &models.IntCriterionInput{Value: sceneDuration, Modifier: models.CriterionModifierEquals},
+	})
+	assert.Equal(t, []int{withScenes}, ids)
+
+	ids = queryIDs(models.MovieFilterType{
+		Duration: &models.IntCriterionInput{Value: sceneDuration, Modifier: models.CriterionModifierGreaterThan},
+	})
+	assert.Equal(t, []int{withoutScenes}, ids)
+
+	// the filters are combined with the other criteria
+	ids = queryIDs(models.MovieFilterType{
+		Studios: &models.HierarchicalMultiCriterionInput{
+			Value:    []string{strconv.Itoa(studioIDs[studioIdxWithMovie])},
+			Modifier: models.CriterionModifierExcludes,
+		},
+		SceneCount: &models.IntCriterionInput{Value: 0, Modifier: models.CriterionModifierEquals},
+		Duration:   &models.IntCriterionInput{Value: 3600, Modifier: models.CriterionModifierEquals},
+	})
+	assert.Equal(t, []int{withoutScenes}, ids)
+
+	sort := "scene_count"
+	direction := models.SortDirectionEnumDesc
+	movies, _, err := mqb.Query(nil, &models.FindFilterType{
+		Sort:      &sort,
+		Direction: &direction,
+	})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, movies) {
+		assert.Equal(t, withScenes, movies[0].ID)
+	}
+}
+
+func TestMovieFindWithSceneIndexIssues(t *testing.T) {
+	mqb := models.NewMovieQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.movie(models.Movie{Name: sql.NullString{String: "TestMovieFindWithSceneIndexIssues", Valid: true}})
+
+	// two scenes with the same index and one without an index
+	index := 1
+	movieSceneIDs := []int{sceneIDs[sceneIdxWithGallery], sceneIDs[sceneIdxWithTag], sceneIDs[sceneIdxWithStudio]}
+	sceneIdxs := []*int{&index, &index, nil}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, sceneID := range movieSceneIDs {
+			if _, err := jqb.AddMoviesScene(sceneID, created.ID, sceneIdxs[i], tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	findIDs := func() []int {
+		movies, err := mqb.FindWithSceneIndexIssues(nil)
+		if err != nil {
+			t.Fatalf("Error finding movies: %s", err.Error())
+		}
+
+		var ret []int
+		for _, m := range movies {
+			ret = append(ret, m.ID)
+		}
+		return ret
+	}
+
+	assert.Contains(t, findIDs(), created.ID)
+
+	// movies with scenes numbered from 1 without gaps have no issues
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, sceneID := range movieSceneIDs {
+			sceneIdx := sql.NullInt64{Int64: int64(i + 1), Valid: true}
+			if _, err := jqb.UpdateMovieSceneIndex(created.ID, sceneID, sceneIdx, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	assert.NotContains(t, findIDs(), created.ID)
+}
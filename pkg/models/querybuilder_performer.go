@@ -4,10 +4,12 @@ import (
 	"database/sql"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type PerformerQueryBuilder struct{}
@@ -18,13 +20,26 @@ func NewPerformerQueryBuilder() PerformerQueryBuilder {
 
 func (qb *PerformerQueryBuilder) Create(newPerformer Performer, tx *sqlx.Tx) (*Performer, error) {
 	ensureTx(tx)
+	slug, err := performerSlugs.unique(newPerformer.Slug, newPerformer.Name.String, tx)
+	if err != nil {
+		return nil, err
+	}
+	newPerformer.Slug = slug
+
+	uuid, err := uniqueUUID("performers", newPerformer.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newPerformer.UUID = uuid
+	newPerformer.setParsedMeasurements()
+
 	result, err := tx.NamedExec(
-		`INSERT INTO performers (checksum, name, url, gender, twitter, instagram, birthdate, ethnicity, country,
-                        				eye_color, height, measurements, fake_tits, career_length, tattoos, piercings,
-                        				aliases, favorite, created_at, updated_at)
-				VALUES (:checksum, :name, :url, :gender, :twitter, :instagram, :birthdate, :ethnicity, :country,
-                        :eye_color, :height, :measurements, :fake_tits, :career_length, :tattoos, :piercings,
-                        :aliases, :favorite, :created_at, :updated_at)
+		`INSERT INTO performers (checksum, name, gender, birthdate, ethnicity, country,
+                        				eye_color, height, measurements, height_cm, bust, waist, hips, weight, fake_tits, career_length, tattoos, piercings,
+                        				aliases, favorite, slug, uuid, created_at, updated_at)
+				VALUES (:checksum, :name, :gender, :birthdate, :ethnicity, :country,
+                        :eye_color, :height, :measurements, :height_cm, :bust, :waist, :hips, :weight, :fake_tits, :career_length, :tattoos, :piercings,
+                        :aliases, :favorite, :slug, :uuid, :created_at, :updated_at)
 		`,
 		newPerformer,
 	)
@@ -44,6 +59,7 @@ func (qb *PerformerQueryBuilder) Create(newPerformer Performer, tx *sqlx.Tx) (*P
 
 func (qb *PerformerQueryBuilder) Update(updatedPerformer PerformerPartial, tx *sqlx.Tx) (*Performer, error) {
 	ensureTx(tx)
+	updatedPerformer.setParsedMeasurements()
 	_, err := tx.NamedExec(
 		`UPDATE performers SET `+SQLGenKeysPartial(updatedPerformer)+` WHERE performers.id = :id`,
 		updatedPerformer,
@@ -61,6 +77,7 @@ func (qb *PerformerQueryBuilder) Update(updatedPerformer PerformerPartial, tx *s
 
 func (qb *PerformerQueryBuilder) UpdateFull(updatedPerformer Performer, tx *sqlx.Tx) (*Performer, error) {
 	ensureTx(tx)
+	updatedPerformer.setParsedMeasurements()
 	_, err := tx.NamedExec(
 		`UPDATE performers SET `+SQLGenKeys(updatedPerformer)+` WHERE performers.id = :id`,
 		updatedPerformer,
@@ -81,6 +98,16 @@ func (qb *PerformerQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 		return err
 	}
 
+	_, err = tx.Exec("DELETE FROM performer_urls WHERE performer_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	performerID, _ := strconv.Atoi(id)
+	if err := destroyImageFiles(performerID, tx, performerImageBlob); err != nil {
+		return err
+	}
+
 	return executeDeleteQuery("performers", id, tx)
 }
 
@@ -168,14 +195,25 @@ func (qb *PerformerQueryBuilder) Count() (int, error) {
 }
 
 func (qb *PerformerQueryBuilder) All() ([]*Performer, error) {
-	return qb.queryPerformers(selectAll("performers")+qb.getPerformerSort(nil), nil, nil)
+	return qb.queryPerformers(selectAll("performers")+qb.getPerformerSort(nil).String(), nil, nil)
+}
+
+// FindByBirthdayMonthDays returns the performers born on the months and days
+// in MM-DD format.
+func (qb *PerformerQueryBuilder) FindByBirthdayMonthDays(monthDays []string) ([]*Performer, error) {
+	if len(monthDays) == 0 {
+		return nil, nil
+	}
+
+	clause, args := monthDayInClause("performers.birthdate", monthDays)
+	return qb.queryPerformers(selectAll("performers")+"WHERE "+clause+qb.getPerformerSort(nil).String(), args, nil)
 }
 
 func (qb *PerformerQueryBuilder) AllSlim() ([]*Performer, error) {
-	return qb.queryPerformers("SELECT performers.id, performers.name, performers.gender FROM performers "+qb.getPerformerSort(nil), nil, nil)
+	return qb.queryPerformers("SELECT performers.id, performers.name, performers.gender FROM performers "+qb.getPerformerSort(nil).String(), nil, nil)
 }
 
-func (qb *PerformerQueryBuilder) Query(performerFilter *PerformerFilterType, findFilter *FindFilterType) ([]*Performer, int) {
+func (qb *PerformerQueryBuilder) Query(performerFilter *PerformerFilterType, findFilter *FindFilterType) ([]*Performer, int, error) {
 	if performerFilter == nil {
 		performerFilter = &PerformerFilterType{}
 	}
@@ -183,25 +221,54 @@ func (qb *PerformerQueryBuilder) Query(performerFilter *PerformerFilterType, fin
 		findFilter = &FindFilterType{}
 	}
 
-	tableName := "performers"
 	query := queryBuilder{
-		tableName: tableName,
+		tableName: "performers",
 	}
 
-	query.body = selectDistinctIDs(tableName)
-	query.body += `
-		left join performers_scenes as scenes_join on scenes_join.performer_id = performers.id
-		left join scenes on scenes_join.scene_id = scenes.id
-		left join performer_stash_ids on performer_stash_ids.performer_id = performers.id
-	`
-
 	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"performers.name", "performers.checksum", "performers.birthdate", "performers.ethnicity"}
+		searchColumns := []string{"performers.name", "performers.aliases", "performers.checksum", "performers.birthdate", "performers.ethnicity"}
 		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
 		query.addWhere(clause)
 		query.addArg(thisArgs...)
 	}
 
+	query.addFilter(qb.makeFilter(performerFilter))
+
+	query.paginate(qb.getPerformerSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var performers []*Performer
+	for _, id := range idsResult {
+		performer, _ := qb.Find(id)
+		performers = append(performers, performer)
+	}
+
+	return performers, countResult, nil
+}
+
+// makeFilter returns the clauses of the performer filter and its sub-filters.
+func (qb *PerformerQueryBuilder) makeFilter(performerFilter *PerformerFilterType) *filterBuilder {
+	tableName := "performers"
+	query := newFilterBuilder(tableName, selectDistinctIDs(tableName)+`
+		left join performers_scenes as scenes_join on scenes_join.performer_id = performers.id
+		left join scenes on scenes_join.scene_id = scenes.id
+		left join performer_stash_ids on performer_stash_ids.performer_id = performers.id
+	`)
+
+	query.handleStringCriterionInput(performerFilter.Name, tableName+".name")
+	query.handleTimestampCriterionInput(performerFilter.CreatedAt, tableName+".created_at")
+	query.handleTimestampCriterionInput(performerFilter.UpdatedAt, tableName+".updated_at")
+	query.handleCriteria(
+		performerURLCriterionHandler(performerFilter.Urls),
+		performerPins.criterionHandler(performerFilter.Pinned),
+		performerSceneDateJoin.criterionHandler(performerFilter.FirstSceneDate, "MIN", "performers.id"),
+		performerSceneDateJoin.criterionHandler(performerFilter.LastSceneDate, "MAX", "performers.id"),
+	)
+
 	if favoritesFilter := performerFilter.FilterFavorites; favoritesFilter != nil {
 		var favStr string
 		if *favoritesFilter == true {
@@ -239,6 +306,11 @@ func (qb *PerformerQueryBuilder) Query(performerFilter *PerformerFilterType, fin
 			query.addWhere("performers_image.performer_id IS NULL")
 		case "stash_id":
 			query.addWhere("performer_stash_ids.performer_id IS NULL")
+		case "url":
+			query.addWhere("NOT EXISTS (SELECT 1 FROM performer_urls WHERE performer_urls.performer_id = performers.id)")
+		case utils.SiteTwitter, utils.SiteInstagram:
+			query.addWhere("NOT EXISTS (SELECT 1 FROM performer_urls WHERE performer_urls.performer_id = performers.id AND performer_urls.site = ?)")
+			query.addArg(*isMissingFilter)
 		default:
 			query.addWhere("(performers." + *isMissingFilter + " IS NULL OR TRIM(performers." + *isMissingFilter + ") = '')")
 		}
@@ -254,6 +326,11 @@ func (qb *PerformerQueryBuilder) Query(performerFilter *PerformerFilterType, fin
 	query.handleStringCriterionInput(performerFilter.EyeColor, tableName+".eye_color")
 	query.handleStringCriterionInput(performerFilter.Height, tableName+".height")
 	query.handleStringCriterionInput(performerFilter.Measurements, tableName+".measurements")
+	query.handleIntCriterionInput(performerFilter.HeightCm, tableName+".height_cm")
+	query.handleIntCriterionInput(performerFilter.Weight, tableName+".weight")
+	query.handleIntCriterionInput(performerFilter.Bust, tableName+".bust")
+	query.handleIntCriterionInput(performerFilter.Waist, tableName+".waist")
+	query.handleIntCriterionInput(performerFilter.Hips, tableName+".hips")
 	query.handleStringCriterionInput(performerFilter.FakeTits, tableName+".fake_tits")
 	query.handleStringCriterionInput(performerFilter.CareerLength, tableName+".career_length")
 	query.handleStringCriterionInput(performerFilter.Tattoos, tableName+".tattoos")
@@ -262,16 +339,17 @@ func (qb *PerformerQueryBuilder) Query(performerFilter *PerformerFilterType, fin
 	// TODO - need better handling of aliases
 	query.handleStringCriterionInput(performerFilter.Aliases, tableName+".aliases")
 
-	query.sortAndPagination = qb.getPerformerSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := query.executeFind()
-
-	var performers []*Performer
-	for _, id := range idsResult {
-		performer, _ := qb.Find(id)
-		performers = append(performers, performer)
+	if performerFilter.And != nil {
+		query.and = qb.makeFilter(performerFilter.And)
+	}
+	if performerFilter.Or != nil {
+		query.or = qb.makeFilter(performerFilter.Or)
+	}
+	if performerFilter.Not != nil {
+		query.not = qb.makeFilter(performerFilter.Not)
 	}
 
-	return performers, countResult
+	return query
 }
 
 func getBirthYearFilterClause(criterionModifier CriterionModifier, value int) ([]string, []interface{}) {
@@ -345,17 +423,11 @@ func getAgeFilterClause(criterionModifier CriterionModifier, value int) ([]strin
 	return clauses, args
 }
 
-func (qb *PerformerQueryBuilder) getPerformerSort(findFilter *FindFilterType) string {
-	var sort string
-	var direction string
+func (qb *PerformerQueryBuilder) getPerformerSort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		sort = "name"
-		direction = "ASC"
-	} else {
-		sort = findFilter.GetSort("name")
-		direction = findFilter.GetDirection()
+		return getSort([]sortField{{sort: "name", direction: "ASC"}}, "performers")
 	}
-	return getSort(sort, direction, "performers")
+	return getSort(findFilter.getSortFields("name", "ASC"), "performers")
 }
 
 func (qb *PerformerQueryBuilder) queryPerformers(query string, args []interface{}, tx *sqlx.Tx) ([]*Performer, error) {
@@ -396,10 +468,16 @@ func (qb *PerformerQueryBuilder) UpdatePerformerImage(performerID int, image []b
 		return err
 	}
 
-	_, err := tx.Exec(
-		`INSERT INTO performers_image (performer_id, image) VALUES (?, ?)`,
+	image, file, err := performerImageBlob.store(performerID, image)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO performers_image (performer_id, image, image_file) VALUES (?, ?, ?)`,
 		performerID,
 		image,
+		file,
 	)
 
 	return err
@@ -408,15 +486,82 @@ func (qb *PerformerQueryBuilder) UpdatePerformerImage(performerID int, image []b
 func (qb *PerformerQueryBuilder) DestroyPerformerImage(performerID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
-	// Delete the existing joins
-	_, err := tx.Exec("DELETE FROM performers_image WHERE performer_id = ?", performerID)
-	if err != nil {
+	if err := destroyImageFiles(performerID, tx, performerImageBlob); err != nil {
 		return err
 	}
+
+	// Delete the existing joins
+	_, err := tx.Exec("DELETE FROM performers_image WHERE performer_id = ?", performerID)
 	return err
 }
 
 func (qb *PerformerQueryBuilder) GetPerformerImage(performerID int, tx *sqlx.Tx) ([]byte, error) {
-	query := `SELECT image from performers_image WHERE performer_id = ?`
-	return getImage(tx, query, performerID)
+	return performerImageBlob.get(performerID, tx)
+}
+
+func performerURLCriterionHandler(c *PerformerURLCriterionInput) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if c != nil {
+			if err := validateRegexCriterion(c.Modifier, c.Value); err != nil {
+				f.setError(err)
+				return
+			}
+
+			clause, args := getPerformerURLCriterionClause(*c)
+			f.where(clause, args...)
+		}
+	}
+}
+
+// getPerformerURLCriterionClause returns a where clause matching the
+// performers with a link satisfying the criterion. If the site is set, only
+// links to the site are considered.
+func getPerformerURLCriterionClause(criterion PerformerURLCriterionInput) (string, []interface{}) {
+	exists := "EXISTS (SELECT 1 FROM performer_urls WHERE performer_urls.performer_id = performers.id"
+	var existsArgs []interface{}
+	if criterion.Site != nil && *criterion.Site != "" {
+		exists += " AND performer_urls.site = ?"
+		existsArgs = append(existsArgs, strings.ToLower(strings.TrimSpace(*criterion.Site)))
+	}
+
+	return getURLTableCriterionClause(exists, existsArgs, "performer_urls.url", StringCriterionInput{
+		Value:    criterion.Value,
+		Modifier: criterion.Modifier,
+	})
+}
+
+// GetURLs returns the links of the performer, in order.
+func (qb *PerformerQueryBuilder) GetURLs(performerID int, tx *sqlx.Tx) ([]PerformerURL, error) {
+	query := "SELECT site, url FROM performer_urls WHERE performer_id = ? ORDER BY position"
+
+	var ret []PerformerURL
+	var err error
+	if tx != nil {
+		err = tx.Select(&ret, query, performerID)
+	} else {
+		err = database.DB.Select(&ret, query, performerID)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// UpdateURLs replaces the links of the performer with urls.
+func (qb *PerformerQueryBuilder) UpdateURLs(performerID int, urls []PerformerURL, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM performer_urls WHERE performer_id = ?", performerID); err != nil {
+		return err
+	}
+
+	for i, url := range urls {
+		if _, err := tx.Exec("INSERT INTO performer_urls (performer_id, position, site, url) VALUES (?, ?, ?, ?)", performerID, i, url.Site, url.URL); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stashapp/stash/pkg/database"
@@ -233,7 +234,8 @@ func verifyPerformerAge(t *testing.T, ageCriterion models.IntCriterionInput) {
 		Age: &ageCriterion,
 	}
 
-	performers, _ := qb.Query(&performerFilter, nil)
+	performers, _, err := qb.Query(&performerFilter, nil)
+	assert.NoError(t, err)
 
 	now := time.Now()
 	for _, performer := range performers {
@@ -248,6 +250,56 @@ func verifyPerformerAge(t *testing.T, ageCriterion models.IntCriterionInput) {
 	}
 }
 
+func TestPerformerQueryCursorPagination(t *testing.T) {
+	qb := models.NewPerformerQueryBuilder()
+	sqb := models.NewSceneQueryBuilder()
+
+	total, err := qb.Count()
+	if err != nil {
+		t.Fatalf("Error counting performers: %s", err.Error())
+	}
+
+	// scene counts are aggregated, so are compared in the having clause
+	sort := "scenes_count"
+	direction := models.SortDirectionEnumDesc
+	first := 3
+	findFilter := models.FindFilterType{
+		Sort:      &sort,
+		Direction: &direction,
+		First:     &first,
+	}
+
+	ids := make(map[int]bool)
+	lastCount := -1
+	for page := 0; page <= total/first; page++ {
+		performers, _, err := qb.Query(nil, &findFilter)
+		if err != nil {
+			t.Fatalf("Error querying performers: %s", err.Error())
+		}
+
+		for _, performer := range performers {
+			assert.False(t, ids[performer.ID])
+			ids[performer.ID] = true
+
+			count, _ := sqb.CountByPerformerID(performer.ID)
+			if lastCount != -1 {
+				assert.LessOrEqual(t, count, lastCount)
+			}
+			lastCount = count
+		}
+
+		if len(performers) == 0 {
+			break
+		}
+		findFilter.After = findFilter.NextCursor(len(performers), performers[len(performers)-1].ID)
+		if findFilter.After == nil {
+			break
+		}
+	}
+
+	assert.Len(t, ids, total)
+}
+
 // TODO Update
 // TODO Destroy
 // TODO Find
@@ -255,3 +307,225 @@ func verifyPerformerAge(t *testing.T, ageCriterion models.IntCriterionInput) {
 // TODO All
 // TODO AllSlim
 // TODO Query
+
+func TestPerformerURLs(t *testing.T) {
+	pqb := models.NewPerformerQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.performer(*models.NewPerformer("TestPerformerURLs"))
+
+	urls := []models.PerformerURL{
+		{Site: "onlyfans", URL: "https://onlyfans.com/testperformerurls"},
+		{Site: "twitter", URL: "https://twitter.com/testperformerurls"},
+	}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return pqb.UpdateURLs(created.ID, urls, tx)
+	})
+
+	// urls are returned in order
+	stored, err := pqb.GetURLs(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting urls: %s", err.Error())
+	}
+	assert.Equal(t, urls, stored)
+
+	queryIDs := func(criterion models.PerformerURLCriterionInput) []int {
+		performerFilter := models.PerformerFilterType{
+			Urls: &criterion,
+		}
+		performers, _, err := pqb.Query(&performerFilter, nil)
+		assert.NoError(t, err)
+
+		var ret []int
+		for _, p := range performers {
+			ret = append(ret, p.ID)
+		}
+		return ret
+	}
+
+	twitter := "twitter"
+	instagram := "instagram"
+	matching := []models.PerformerURLCriterionInput{
+		{Value: "onlyfans.com/testperformerurls", Modifier: models.CriterionModifierIncludes},
+		{Site: &twitter, Value: "testperformerurls", Modifier: models.CriterionModifierIncludes},
+		{Site: &twitter, Value: "https://twitter.com/testperformerurls", Modifier: models.CriterionModifierEquals},
+		{Site: &instagram, Modifier: models.CriterionModifierIsNull},
+	}
+	for _, c := range matching {
+		assert.Contains(t, queryIDs(c), created.ID)
+	}
+
+	notMatching := []models.PerformerURLCriterionInput{
+		{Site: &twitter, Value: "onlyfans.com", Modifier: models.CriterionModifierIncludes},
+		{Site: &instagram, Modifier: models.CriterionModifierNotNull},
+		{Site: &twitter, Value: "testperformerurls", Modifier: models.CriterionModifierExcludes},
+	}
+	for _, c := range notMatching {
+		assert.NotContains(t, queryIDs(c), created.ID)
+	}
+
+	// is_missing twitter and instagram consider the sites of the links
+	isMissing := func(property string) []int {
+		performers, _, err := pqb.Query(&models.PerformerFilterType{IsMissing: &property}, nil)
+		assert.NoError(t, err)
+
+		var ret []int
+		for _, p := range performers {
+			ret = append(ret, p.ID)
+		}
+		return ret
+	}
+	assert.NotContains(t, isMissing("url"), created.ID)
+	assert.NotContains(t, isMissing("twitter"), created.ID)
+	assert.Contains(t, isMissing("instagram"), created.ID)
+}
+
+func TestPerformerQueryMeasurements(t *testing.T) {
+	pqb := models.NewPerformerQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	tall := models.NewPerformer("TestPerformerQueryMeasurementsTall")
+	tall.Height = sql.NullString{String: "1.80 m", Valid: true}
+	tall.Measurements = sql.NullString{String: "34D-24-34", Valid: true}
+	tall.Weight = sql.NullInt64{Int64: 60, Valid: true}
+	short := models.NewPerformer("TestPerformerQueryMeasurementsShort")
+	short.Height = sql.NullString{String: `5'2"`, Valid: true}
+
+	created := []*models.Performer{f.performer(*tall), f.performer(*short)}
+
+	assert.Equal(t, sql.NullInt64{Int64: 180, Valid: true}, created[0].HeightCm)
+	assert.Equal(t, sql.NullInt64{Int64: 61, Valid: true}, created[0].Waist)
+	assert.Equal(t, sql.NullInt64{Int64: 157, Valid: true}, created[1].HeightCm)
+	assert.False(t, created[1].Bust.Valid)
+
+	queryIDs := func(performerFilter models.PerformerFilterType) []int {
+		performers, _, err := pqb.Query(&performerFilter, nil)
+		assert.NoError(t, err)
+
+		var ret []int
+		for _, p := range performers {
+			ret = append(ret, p.ID)
+		}
+		return ret
+	}
+
+	value2 := 185
+	ids := queryIDs(models.PerformerFilterType{
+		HeightCm: &models.IntCriterionInput{Value: 175, Value2: &value2, Modifier: models.CriterionModifierBetween},
+	})
+	assert.Contains(t, ids, created[0].ID)
+	assert.NotContains(t, ids, created[1].ID)
+
+	ids = queryIDs(models.PerformerFilterType{
+		HeightCm: &models.IntCriterionInput{Value: 160, Modifier: models.CriterionModifierLessThan},
+	})
+	assert.NotContains(t, ids, created[0].ID)
+	assert.Contains(t, ids, created[1].ID)
+
+	ids = queryIDs(models.PerformerFilterType{
+		Bust:   &models.IntCriterionInput{Value: 80, Modifier: models.CriterionModifierGreaterThan},
+		Weight: &models.IntCriterionInput{Value: 60, Modifier: models.CriterionModifierEquals},
+	})
+	assert.Contains(t, ids, created[0].ID)
+	assert.NotContains(t, ids, created[1].ID)
+
+	// updating the height updates the parsed height
+	var updated *models.Performer
+	withTxn(t, func(tx *sqlx.Tx) error {
+		var err error
+		updated, err = pqb.Update(models.PerformerPartial{
+			ID:     created[1].ID,
+			Height: &sql.NullString{String: "190", Valid: true},
+		}, tx)
+		return err
+	})
+	assert.Equal(t, sql.NullInt64{Int64: 190, Valid: true}, updated.HeightCm)
+}
+
+func TestPerformerSearchFolded(t *testing.T) {
+	pqb := models.NewPerformerQueryBuilder()
+	sqb := models.NewSceneQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	newPerformer := *models.NewPerformer("Tánja Searchfolded")
+	newPerformer.Aliases = sql.NullString{String: "Tanya Aliasfolded", Valid: true}
+	performer := f.performer(newPerformer)
+	scene := f.scene(models.Scene{Path: "TestPerformerSearchFolded"})
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return jqb.CreatePerformersScenes([]models.PerformersScenes{{PerformerID: performer.ID, SceneID: scene.ID}}, tx)
+	})
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return jqb.DestroyPerformersScenes(scene.ID, tx)
+	})
+
+	queryPerformerIDs := func(q string) []int {
+		performers, _, err := pqb.Query(nil, &models.FindFilterType{Q: &q})
+		if err != nil {
+			t.Fatalf("Error querying performers: %s", err.Error())
+		}
+
+		var ret []int
+		for _, p := range performers {
+			ret = append(ret, p.ID)
+		}
+		return ret
+	}
+
+	querySceneIDs := func(q string) []int {
+		scenes, _, err := sqb.Query(nil, &models.FindFilterType{Q: &q})
+		if err != nil {
+			t.Fatalf("Error querying scenes: %s", err.Error())
+		}
+
+		var ret []int
+		for _, s := range scenes {
+			ret = append(ret, s.ID)
+		}
+		return ret
+	}
+
+	// accents and case are ignored in both the query and the names
+	for _, q := range []string{`"tanja searchfolded"`, `"TÁNJA SEARCHFOLDED"`, `"Tänja Searchfolded"`, "aliasfolded"} {
+		assert.Equal(t, []int{performer.ID}, queryPerformerIDs(q), q)
+	}
+	assert.Len(t, queryPerformerIDs(`"tanya searchfolded"`), 0)
+
+	// scenes are found by the names and aliases of their performers
+	for _, q := range []string{"searchfolded", `"tanya aliasfolded"`} {
+		assert.Equal(t, []int{scene.ID}, querySceneIDs(q), q)
+	}
+}
+
+func TestPerformerFindByBirthdayMonthDays(t *testing.T) {
+	pqb := models.NewPerformerQueryBuilder()
+
+	birthdate, _ := time.Parse("2006-01-02", getPerformerBirthdate(0))
+	performers, err := pqb.FindByBirthdayMonthDays([]string{birthdate.Format("01-02")})
+	if err != nil {
+		t.Fatalf("Error finding performers: %s", err.Error())
+	}
+
+	var ids []int
+	for _, p := range performers {
+		assert.Equal(t, birthdate.Format("01-02"), p.Birthdate.String[5:])
+		ids = append(ids, p.ID)
+	}
+	assert.Contains(t, ids, performerIDs[0])
+
+	other := birthdate.AddDate(0, 0, 1).Format("01-02")
+	performers, err = pqb.FindByBirthdayMonthDays([]string{other})
+	if err != nil {
+		t.Fatalf("Error finding performers: %s", err.Error())
+	}
+	for _, p := range performers {
+		assert.NotEqual(t, performerIDs[0], p.ID)
+	}
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pinnedTable is a table of objects which can be pinned to the dashboard.
+// Pinned objects have a position, which orders them on the dashboard.
+type pinnedTable string
+
+const (
+	moviePins     pinnedTable = "movies"
+	performerPins pinnedTable = "performers"
+	studioPins    pinnedTable = "studios"
+)
+
+// selectPinned returns the query selecting the pinned objects in the order
+// of their positions.
+func (t pinnedTable) selectPinned() string {
+	return "SELECT * FROM " + string(t) + " WHERE pinned_position IS NOT NULL ORDER BY pinned_position, id"
+}
+
+// setPinned pins the object with the id after the other pinned objects, or
+// unpins it. Objects which are already pinned keep their positions.
+func (t pinnedTable) setPinned(id int, pinned bool, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if !pinned {
+		_, err := tx.Exec("UPDATE "+string(t)+" SET pinned_position = NULL WHERE id = ?", id)
+		return err
+	}
+
+	_, err := tx.Exec("UPDATE "+string(t)+" SET pinned_position = (SELECT IFNULL(MAX(pinned_position) + 1, 0) FROM "+string(t)+") WHERE id = ? AND pinned_position IS NULL", id)
+	return err
+}
+
+// reorder sets the positions of the pinned objects to the order of ids.
+// Pinned objects not in ids are unpinned, and objects in ids are pinned.
+func (t pinnedTable) reorder(ids []int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("UPDATE " + string(t) + " SET pinned_position = NULL WHERE pinned_position IS NOT NULL"); err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		result, err := tx.Exec("UPDATE "+string(t)+" SET pinned_position = ? WHERE id = ?", i, id)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("%s with id %d not found", t, id)
+		}
+	}
+
+	return nil
+}
+
+// criterionHandler returns the handler filtering pinned or unpinned objects.
+func (t pinnedTable) criterionHandler(pinned *bool) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if pinned == nil {
+			return
+		}
+
+		if *pinned {
+			f.where(string(t) + ".pinned_position IS NOT NULL")
+		} else {
+			f.where(string(t) + ".pinned_position IS NULL")
+		}
+	}
+}
+
+// FindPinned returns the pinned movies in the order of their positions.
+func (qb *MovieQueryBuilder) FindPinned(tx *sqlx.Tx) ([]*Movie, error) {
+	return qb.queryMovies(moviePins.selectPinned(), nil, tx)
+}
+
+// SetPinned pins the movie after the other pinned movies, or unpins it.
+func (qb *MovieQueryBuilder) SetPinned(movieID int, pinned bool, tx *sqlx.Tx) error {
+	return moviePins.setPinned(movieID, pinned, tx)
+}
+
+// ReorderPinned sets the pinned movies to the movies with the ids, in order.
+func (qb *MovieQueryBuilder) ReorderPinned(movieIDs []int, tx *sqlx.Tx) error {
+	return moviePins.reorder(movieIDs, tx)
+}
+
+// FindPinned returns the pinned performers in the order of their positions.
+func (qb *PerformerQueryBuilder) FindPinned(tx *sqlx.Tx) ([]*Performer, error) {
+	return qb.queryPerformers(performerPins.selectPinned(), nil, tx)
+}
+
+// SetPinned pins the performer after the other pinned performers, or unpins
+// it.
+func (qb *PerformerQueryBuilder) SetPinned(performerID int, pinned bool, tx *sqlx.Tx) error {
+	return performerPins.setPinned(performerID, pinned, tx)
+}
+
+// ReorderPinned sets the pinned performers to the performers with the ids, in
+// order.
+func (qb *PerformerQueryBuilder) ReorderPinned(performerIDs []int, tx *sqlx.Tx) error {
+	return performerPins.reorder(performerIDs, tx)
+}
+
+// FindPinned returns the pinned studios in the order of their positions.
+func (qb *StudioQueryBuilder) FindPinned(tx *sqlx.Tx) ([]*Studio, error) {
+	return qb.queryStudios(studioPins.selectPinned(), nil, tx)
+}
+
+// SetPinned pins the studio after the other pinned studios, or unpins it.
+func (qb *StudioQueryBuilder) SetPinned(studioID int, pinned bool, tx *sqlx.Tx) error {
+	return studioPins.setPinned(studioID, pinned, tx)
+}
+
+// ReorderPinned sets the pinned studios to the studios with the ids, in order.
+func (qb *StudioQueryBuilder) ReorderPinned(studioIDs []int, tx *sqlx.Tx) error {
+	return studioPins.reorder(studioIDs, tx)
+}
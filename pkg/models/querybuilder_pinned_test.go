@@ -0,0 +1,79 @@
+// +build integration
+
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinned(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewStudioQueryBuilder()
+	var ids []int
+	for _, name := range []string{"Test Pinned 1", "Test Pinned 2", "Test Pinned 3"} {
+		created, err := qb.Create(models.Studio{
+			Checksum: utils.MD5FromString(name),
+			Name:     sql.NullString{String: name, Valid: true},
+		}, tx)
+		if err != nil {
+			t.Fatalf("Error creating studio: %s", err.Error())
+		}
+		ids = append(ids, created.ID)
+	}
+
+	assertPinned := func(want []int) {
+		t.Helper()
+		pinned, err := qb.FindPinned(tx)
+		if err != nil {
+			t.Fatalf("Error finding pinned studios: %s", err.Error())
+		}
+
+		var got []int
+		for _, studio := range pinned {
+			got = append(got, studio.ID)
+		}
+		assert.Equal(t, want, got)
+	}
+
+	assertPinned(nil)
+
+	for _, id := range []int{ids[2], ids[0], ids[1]} {
+		if err := qb.SetPinned(id, true, tx); err != nil {
+			t.Fatalf("Error pinning studio: %s", err.Error())
+		}
+	}
+	// pinning a pinned studio keeps its position
+	if err := qb.SetPinned(ids[2], true, tx); err != nil {
+		t.Fatalf("Error pinning studio: %s", err.Error())
+	}
+	assertPinned([]int{ids[2], ids[0], ids[1]})
+
+	if err := qb.SetPinned(ids[0], false, tx); err != nil {
+		t.Fatalf("Error unpinning studio: %s", err.Error())
+	}
+	assertPinned([]int{ids[2], ids[1]})
+
+	// studios not in the order are unpinned
+	if err := qb.ReorderPinned([]int{ids[1], ids[0]}, tx); err != nil {
+		t.Fatalf("Error reordering pinned studios: %s", err.Error())
+	}
+	assertPinned([]int{ids[1], ids[0]})
+
+	studio, err := qb.Find(ids[0], tx)
+	if err != nil {
+		t.Fatalf("Error finding studio: %s", err.Error())
+	}
+	assert.Equal(t, sql.NullInt64{Int64: 1, Valid: true}, studio.PinnedPosition)
+
+	assert.NotNil(t, qb.ReorderPinned([]int{-1}, tx))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type ProbeCacheQueryBuilder struct{}
+
+func NewProbeCacheQueryBuilder() ProbeCacheQueryBuilder {
+	return ProbeCacheQueryBuilder{}
+}
+
+// Find returns the cached probe output for the file with the given oshash and
+// size. It returns nil if the file has not been probed.
+func (qb *ProbeCacheQueryBuilder) Find(oshash string, size int64) ([]byte, error) {
+	var data string
+	err := database.DB.Get(&data, `SELECT data FROM probe_cache WHERE oshash = ? AND size = ? LIMIT 1`, oshash, size)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(data), nil
+}
+
+// Set stores the probe output for the file with the given oshash and size,
+// replacing any existing output.
+func (qb *ProbeCacheQueryBuilder) Set(oshash string, size int64, data []byte, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(
+		`INSERT OR REPLACE INTO probe_cache (oshash, size, data, created_at) VALUES (?, ?, ?, ?)`,
+		oshash, size, string(data), SQLiteTimestamp{Timestamp: time.Now()},
+	)
+	return err
+}
+
+// DestroyUnused deletes the cached probe output of files that do not belong
+// to a scene. It returns the number of deleted entries.
+func (qb *ProbeCacheQueryBuilder) DestroyUnused(tx *sqlx.Tx) (int64, error) {
+	ensureTx(tx)
+	result, err := tx.Exec(`DELETE FROM probe_cache WHERE oshash NOT IN (SELECT oshash FROM scenes WHERE oshash IS NOT NULL)`)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
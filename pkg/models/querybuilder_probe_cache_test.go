@@ -0,0 +1,55 @@
+// +build integration
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setProbeCache(t *testing.T, oshash string, size int64, data string) {
+	qb := models.NewProbeCacheQueryBuilder()
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return qb.Set(oshash, size, []byte(data), tx)
+	})
+}
+
+func TestProbeCache(t *testing.T) {
+	const oshash = "probeCacheHash"
+	qb := models.NewProbeCacheQueryBuilder()
+
+	setProbeCache(t, oshash, 100, "first")
+	setProbeCache(t, oshash, 100, "second")
+
+	data, err := qb.Find(oshash, 100)
+	if err != nil {
+		t.Fatalf("Error finding probe cache: %s", err.Error())
+	}
+	assert.Equal(t, "second", string(data))
+
+	// different size is not matched
+	data, err = qb.Find(oshash, 101)
+	if err != nil {
+		t.Fatalf("Error finding probe cache: %s", err.Error())
+	}
+	assert.Nil(t, data)
+
+	// no scene has the oshash
+	var deleted int64
+	withTxn(t, func(tx *sqlx.Tx) error {
+		var err error
+		deleted, err = qb.DestroyUnused(tx)
+		return err
+	})
+	assert.Equal(t, int64(1), deleted)
+
+	data, err = qb.Find(oshash, 100)
+	if err != nil {
+		t.Fatalf("Error finding probe cache: %s", err.Error())
+	}
+	assert.Nil(t, data)
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -32,6 +33,13 @@ var scenesForMovieQuery = selectAll(sceneTable) + `
 LEFT JOIN movies_scenes as movies_join on movies_join.scene_id = scenes.id
 WHERE movies_join.movie_id = ?
 GROUP BY scenes.id
+ORDER BY movies_join.scene_index IS NULL, movies_join.scene_index, scenes.id
+`
+
+var scenesForTagQuery = selectAll(sceneTable) + `
+LEFT JOIN scenes_tags as tags_join on tags_join.scene_id = scenes.id
+WHERE tags_join.tag_id = ?
+GROUP BY scenes.id
 `
 
 var countScenesForTagQuery = `
@@ -58,11 +66,17 @@ func NewSceneQueryBuilder() SceneQueryBuilder {
 
 func (qb *SceneQueryBuilder) Create(newScene Scene, tx *sqlx.Tx) (*Scene, error) {
 	ensureTx(tx)
+	uuid, err := uniqueUUID("scenes", newScene.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newScene.UUID = uuid
+
 	result, err := tx.NamedExec(
-		`INSERT INTO scenes (oshash, checksum, path, title, details, url, date, rating, organized, o_counter, size, duration, video_codec,
-                    			    audio_codec, format, width, height, framerate, bitrate, studio_id, file_mod_time, created_at, updated_at)
-				VALUES (:oshash, :checksum, :path, :title, :details, :url, :date, :rating, :organized, :o_counter, :size, :duration, :video_codec,
-					:audio_codec, :format, :width, :height, :framerate, :bitrate, :studio_id, :file_mod_time, :created_at, :updated_at)
+		`INSERT INTO scenes (oshash, checksum, phash, path, title, details, url, date, rating, organized, o_counter, size, duration, video_codec,
+                    			    audio_codec, format, width, height, framerate, bitrate, studio_id, file_mod_time, file_creation_time, uuid, cover_url, background_url, created_at, updated_at)
+				VALUES (:oshash, :checksum, :phash, :path, :title, :details, :url, :date, :rating, :organized, :o_counter, :size, :duration, :video_codec,
+					:audio_codec, :format, :width, :height, :framerate, :bitrate, :studio_id, :file_mod_time, :file_creation_time, :uuid, :cover_url, :background_url, :created_at, :updated_at)
 		`,
 		newScene,
 	)
@@ -118,6 +132,19 @@ func (qb *SceneQueryBuilder) UpdateFileModTime(id int, modTime NullSQLiteTimesta
 	return nil
 }
 
+func (qb *SceneQueryBuilder) UpdateFileCreationTime(id int, creationTime NullSQLiteTimestamp, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(
+		`UPDATE scenes SET file_creation_time = ? WHERE scenes.id = ? `,
+		creationTime, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (qb *SceneQueryBuilder) IncrementOCounter(id int, tx *sqlx.Tx) (int, error) {
 	ensureTx(tx)
 	_, err := tx.Exec(
@@ -177,6 +204,27 @@ func (qb *SceneQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 	if err != nil {
 		return err
 	}
+	_, err = tx.Exec("DELETE FROM scrape_history WHERE entity = ? AND entity_id = ?", ScrapeHistoryEntityScene, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM stream_sessions WHERE scene_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM scene_chapters WHERE scene_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM scene_captions WHERE scene_id = ?", id)
+	if err != nil {
+		return err
+	}
+	sceneID, _ := strconv.Atoi(id)
+	if err := destroyImageFiles(sceneID, tx, sceneCoverBlob); err != nil {
+		return err
+	}
+
 	return executeDeleteQuery("scenes", id, tx)
 }
 func (qb *SceneQueryBuilder) Find(id int) (*Scene, error) {
@@ -184,13 +232,32 @@ func (qb *SceneQueryBuilder) Find(id int) (*Scene, error) {
 }
 
 func (qb *SceneQueryBuilder) FindMany(ids []int) ([]*Scene, error) {
-	var scenes []*Scene
-	for _, id := range ids {
-		scene, err := qb.Find(id)
+	byID := make(map[int]*Scene)
+	for start := 0; start < len(ids); start += findManyBatchSize {
+		end := start + findManyBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		batch := ids[start:end]
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+
+		scenes, err := qb.queryScenes(selectAll(sceneTable)+"WHERE id IN "+getInBinding(len(batch)), args, nil)
 		if err != nil {
 			return nil, err
 		}
 
+		for _, scene := range scenes {
+			byID[scene.ID] = scene
+		}
+	}
+
+	var scenes []*Scene
+	for _, id := range ids {
+		scene := byID[id]
 		if scene == nil {
 			return nil, fmt.Errorf("scene with id %d not found", id)
 		}
@@ -219,12 +286,99 @@ func (qb *SceneQueryBuilder) FindByOSHash(oshash string) (*Scene, error) {
 	return qb.queryScene(query, args, nil)
 }
 
+// FindByStashID returns the scenes with the provided stash id.
+func (qb *SceneQueryBuilder) FindByStashID(stashID StashID) ([]*Scene, error) {
+	query := selectAll(sceneTable) + `
+		JOIN scene_stash_ids on scene_stash_ids.scene_id = scenes.id
+		WHERE scene_stash_ids.stash_id = ? AND scene_stash_ids.endpoint = ?
+	`
+	args := []interface{}{stashID.StashID, stashID.Endpoint}
+	return qb.queryScenes(query+qb.getSceneSort(nil).String(), args, nil)
+}
+
+// FindByPhashDistance returns the ids of scenes with a perceptual hash within
+// the provided Hamming distance of phash, along with the distance of each
+// scene. Results are ordered by ascending distance.
+func (qb *SceneQueryBuilder) FindByPhashDistance(phash int64, distance int) ([]int, []int, error) {
+	query := `SELECT scenes.id, phash_distance(scenes.phash, ?) as distance FROM scenes
+		WHERE scenes.phash IS NOT NULL AND phash_distance(scenes.phash, ?) <= ?
+		ORDER BY distance ASC, scenes.id ASC
+	`
+
+	rows, err := database.DB.Queryx(query, phash, phash, distance)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	var distances []int
+	for rows.Next() {
+		var id int
+		var d int
+		if err := rows.Scan(&id, &d); err != nil {
+			return nil, nil, err
+		}
+
+		ids = append(ids, id)
+		distances = append(distances, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return ids, distances, nil
+}
+
+// FindByTitleMismatch returns the ids of scenes with a title differing from
+// their file name by at least threshold, along with the distance of each
+// scene. See utils.TitleDistance. Results are ordered by descending distance.
+func (qb *SceneQueryBuilder) FindByTitleMismatch(threshold float64) ([]int, []float64, error) {
+	query := `SELECT scenes.id, title_distance(scenes.title, scenes.path) as distance FROM scenes
+		WHERE scenes.title IS NOT NULL AND scenes.title != '' AND title_distance(scenes.title, scenes.path) >= ?
+		ORDER BY distance DESC, scenes.id ASC
+	`
+
+	rows, err := database.DB.Queryx(query, threshold)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	var distances []float64
+	for rows.Next() {
+		var id int
+		var d float64
+		if err := rows.Scan(&id, &d); err != nil {
+			return nil, nil, err
+		}
+
+		ids = append(ids, id)
+		distances = append(distances, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return ids, distances, nil
+}
+
 func (qb *SceneQueryBuilder) FindByPath(path string) (*Scene, error) {
 	query := selectAll(sceneTable) + "WHERE path = ? LIMIT 1"
 	args := []interface{}{path}
 	return qb.queryScene(query, args, nil)
 }
 
+// FindUnderPath returns the scenes whose file is at path or within the
+// directory path.
+func (qb *SceneQueryBuilder) FindUnderPath(path string) ([]*Scene, error) {
+	clause, args := getUnderPathClause("scenes.path", path)
+	return qb.queryScenes(selectAll(sceneTable)+"WHERE "+clause, args, nil)
+}
+
 func (qb *SceneQueryBuilder) FindByPerformerID(performerID int) ([]*Scene, error) {
 	args := []interface{}{performerID}
 	return qb.queryScenes(scenesForPerformerQuery, args, nil)
@@ -240,6 +394,12 @@ func (qb *SceneQueryBuilder) FindByStudioID(studioID int) ([]*Scene, error) {
 	return qb.queryScenes(scenesForStudioQuery, args, nil)
 }
 
+// FindByTagID returns the scenes tagged with the tag.
+func (qb *SceneQueryBuilder) FindByTagID(tagID int) ([]*Scene, error) {
+	args := []interface{}{tagID}
+	return qb.queryScenes(scenesForTagQuery, args, nil)
+}
+
 func (qb *SceneQueryBuilder) FindByMovieID(movieID int) ([]*Scene, error) {
 	args := []interface{}{movieID}
 	return qb.queryScenes(scenesForMovieQuery, args, nil)
@@ -278,6 +438,40 @@ func (qb *SceneQueryBuilder) CountMissingOSHash() (int, error) {
 	return runCountQuery(buildCountQuery(countScenesForMissingOSHashQuery), []interface{}{})
 }
 
+// FindMissingHashes returns the scenes missing an oshash value, or a checksum
+// value if checksum is true.
+func (qb *SceneQueryBuilder) FindMissingHashes(checksum bool) ([]*Scene, error) {
+	query := selectAll(sceneTable) + "WHERE scenes.oshash is null"
+	if checksum {
+		query += " OR scenes.checksum is null"
+	}
+	return qb.queryScenes(query+qb.getSceneSort(nil).String(), nil, nil)
+}
+
+// FindMissingDate returns the scenes without a date.
+func (qb *SceneQueryBuilder) FindMissingDate() ([]*Scene, error) {
+	query := selectAll(sceneTable) + "WHERE scenes.date IS NULL OR scenes.date = '' OR scenes.date LIKE '0001-01-01%'"
+	return qb.queryScenes(query+qb.getSceneSort(nil).String(), nil, nil)
+}
+
+// FindWithChapters returns the scenes with chapters read from their files.
+func (qb *SceneQueryBuilder) FindWithChapters() ([]*Scene, error) {
+	query := selectAll(sceneTable) + "WHERE scenes.id IN (SELECT scene_id FROM scene_chapters)"
+	return qb.queryScenes(query+qb.getSceneSort(nil).String(), nil, nil)
+}
+
+// FindReleasedOnMonthDays returns the scenes released on the months and days
+// in MM-DD format before the date before, most recent first.
+func (qb *SceneQueryBuilder) FindReleasedOnMonthDays(monthDays []string, before string) ([]*Scene, error) {
+	if len(monthDays) == 0 {
+		return nil, nil
+	}
+
+	clause, args := monthDayInClause("scenes.date", monthDays)
+	query := selectAll(sceneTable) + "WHERE " + clause + " AND scenes.date < ? ORDER BY scenes.date DESC, scenes.id ASC"
+	return qb.queryScenes(query, append(args, before), nil)
+}
+
 func (qb *SceneQueryBuilder) Wall(q *string) ([]*Scene, error) {
 	s := ""
 	if q != nil {
@@ -288,10 +482,33 @@ func (qb *SceneQueryBuilder) Wall(q *string) ([]*Scene, error) {
 }
 
 func (qb *SceneQueryBuilder) All() ([]*Scene, error) {
-	return qb.queryScenes(selectAll(sceneTable)+qb.getSceneSort(nil), nil, nil)
+	return qb.queryScenes(selectAll(sceneTable)+qb.getSceneSort(nil).String(), nil, nil)
 }
 
-func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *FindFilterType) ([]*Scene, int) {
+func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *FindFilterType) ([]*Scene, int, error) {
+	query := qb.makeQuery(sceneFilter, findFilter)
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var scenes []*Scene
+	for _, id := range idsResult {
+		scene, _ := qb.Find(id)
+		scenes = append(scenes, scene)
+	}
+
+	return scenes, countResult, nil
+}
+
+// QueryPlan returns the query plan chosen by the database for the query
+// executed by Query with the same filters.
+func (qb *SceneQueryBuilder) QueryPlan(sceneFilter *SceneFilterType, findFilter *FindFilterType) ([]string, error) {
+	query := qb.makeQuery(sceneFilter, findFilter)
+	return query.explain()
+}
+
+func (qb *SceneQueryBuilder) makeQuery(sceneFilter *SceneFilterType, findFilter *FindFilterType) queryBuilder {
 	if sceneFilter == nil {
 		sceneFilter = &SceneFilterType{}
 	}
@@ -303,8 +520,23 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 		tableName: sceneTable,
 	}
 
-	query.body = selectDistinctIDs(sceneTable)
-	query.body += `
+	if q := findFilter.Q; q != nil && *q != "" {
+		clause, thisArgs := getSearchDocumentBinding(sceneTable, "scene", *q)
+		query.addWhere(clause)
+		query.addArg(thisArgs...)
+	}
+
+	query.addFilter(qb.makeFilter(sceneFilter))
+
+	query.paginate(qb.getSceneSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+
+	return query
+}
+
+// makeFilter returns the clauses of the scene filter and its sub-filters.
+func (qb *SceneQueryBuilder) makeFilter(sceneFilter *SceneFilterType) *filterBuilder {
+	query := newFilterBuilder(sceneTable, selectDistinctIDs(sceneTable)+`
 		left join scene_markers on scene_markers.scene_id = scenes.id
 		left join performers_scenes as performers_join on performers_join.scene_id = scenes.id
 		left join movies_scenes as movies_join on movies_join.scene_id = scenes.id
@@ -312,18 +544,17 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 		left join galleries as gallery on gallery.scene_id = scenes.id
 		left join scenes_tags as tags_join on tags_join.scene_id = scenes.id
 		left join scene_stash_ids on scene_stash_ids.scene_id = scenes.id
-	`
-
-	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"scenes.title", "scenes.details", "scenes.path", "scenes.oshash", "scenes.checksum", "scene_markers.title"}
-		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
-		query.addWhere(clause)
-		query.addArg(thisArgs...)
-	}
+	`)
 
 	query.handleStringCriterionInput(sceneFilter.Path, "scenes.path")
-	query.handleIntCriterionInput(sceneFilter.Rating, "scenes.rating")
+	query.handleCriteria(libraryCriterionHandler(sceneFilter.Library, "scenes.path"))
+	query.handleStringCriterionInput(sceneFilter.Title, "scenes.title")
+	query.handleRatingCriterionInput(sceneFilter.Rating, sceneFilter.Rating100, "scenes.rating")
 	query.handleIntCriterionInput(sceneFilter.OCounter, "scenes.o_counter")
+	query.handleTimestampCriterionInput(sceneFilter.FileCreationTime, "scenes.file_creation_time")
+	query.handleDateCriterionInput(sceneFilter.Date, "scenes.date")
+	query.handleTimestampCriterionInput(sceneFilter.CreatedAt, "scenes.created_at")
+	query.handleTimestampCriterionInput(sceneFilter.UpdatedAt, "scenes.updated_at")
 
 	if Organized := sceneFilter.Organized; Organized != nil {
 		var organized string
@@ -398,21 +629,14 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 			query.addWhere("tags_join.scene_id IS NULL")
 		case "stash_id":
 			query.addWhere("scene_stash_ids.scene_id IS NULL")
+		case "captions":
+			query.addWhere("scenes.id NOT IN (SELECT scene_id FROM scene_captions)")
 		default:
 			query.addWhere("(scenes." + *isMissingFilter + " IS NULL OR TRIM(scenes." + *isMissingFilter + ") = '')")
 		}
 	}
 
-	if tagsFilter := sceneFilter.Tags; tagsFilter != nil && len(tagsFilter.Value) > 0 {
-		for _, tagID := range tagsFilter.Value {
-			query.addArg(tagID)
-		}
-
-		query.body += " LEFT JOIN tags on tags_join.tag_id = tags.id"
-		whereClause, havingClause := getMultiCriterionClause("scenes", "tags", "scenes_tags", "scene_id", "tag_id", tagsFilter)
-		query.addWhere(whereClause)
-		query.addHaving(havingClause)
-	}
+	query.handleCriteria(subTags.criterionHandler(sceneFilter.Tags, joinTableTagClause("scenes", "scenes_tags", "scene_id")))
 
 	if performersFilter := sceneFilter.Performers; performersFilter != nil && len(performersFilter.Value) > 0 {
 		for _, performerID := range performersFilter.Value {
@@ -425,16 +649,15 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 		query.addHaving(havingClause)
 	}
 
-	if studiosFilter := sceneFilter.Studios; studiosFilter != nil && len(studiosFilter.Value) > 0 {
-		for _, studioID := range studiosFilter.Value {
-			query.addArg(studioID)
-		}
-
-		whereClause, havingClause := getMultiCriterionClause("scenes", "studio", "", "", "studio_id", studiosFilter)
-		query.addWhere(whereClause)
-		query.addHaving(havingClause)
+	if performerAge := sceneFilter.PerformerAge; performerAge != nil {
+		query.body += " LEFT JOIN performers AS age_performers ON age_performers.id = performers_join.performer_id"
+		clause, count := getIntCriterionWhereClause(performerAgeExpression("age_performers.birthdate", "scenes.date"), *performerAge)
+		query.addWhere("age_performers.birthdate <= scenes.date AND (" + clause + ")")
+		query.addArg(getIntCriterionArgs(*performerAge, count)...)
 	}
 
+	query.handleCriteria(subStudiosCriterionHandler(sceneFilter.Studios, "scenes"))
+
 	if moviesFilter := sceneFilter.Movies; moviesFilter != nil && len(moviesFilter.Value) > 0 {
 		for _, movieID := range moviesFilter.Value {
 			query.addArg(movieID)
@@ -451,16 +674,17 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 		query.addArg(stashIDFilter)
 	}
 
-	query.sortAndPagination = qb.getSceneSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := query.executeFind()
-
-	var scenes []*Scene
-	for _, id := range idsResult {
-		scene, _ := qb.Find(id)
-		scenes = append(scenes, scene)
+	if sceneFilter.And != nil {
+		query.and = qb.makeFilter(sceneFilter.And)
+	}
+	if sceneFilter.Or != nil {
+		query.or = qb.makeFilter(sceneFilter.Or)
+	}
+	if sceneFilter.Not != nil {
+		query.not = qb.makeFilter(sceneFilter.Not)
 	}
 
-	return scenes, countResult
+	return query
 }
 
 func appendClause(clauses []string, clause string) []string {
@@ -471,6 +695,15 @@ func appendClause(clauses []string, clause string) []string {
 	return clauses
 }
 
+// performerAgeExpression returns the SQL expression of the age in whole years
+// at the date in the date column of a performer born at the date in the
+// birthdate column, as computed by utils.AgeAt. The expression is null if
+// either date is not a valid date.
+func performerAgeExpression(birthdateColumn string, dateColumn string) string {
+	return "(CAST(strftime('%Y', " + dateColumn + ") AS INTEGER) - CAST(strftime('%Y', " + birthdateColumn + ") AS INTEGER) - " +
+		"(strftime('%m-%d', " + dateColumn + ") < strftime('%m-%d', " + birthdateColumn + ")))"
+}
+
 func getDurationWhereClause(durationFilter IntCriterionInput) (string, []interface{}) {
 	// special case for duration. We accept duration as seconds as int but the
 	// field is floating point. Change the equals filter to return a range
@@ -491,23 +724,23 @@ func getDurationWhereClause(durationFilter IntCriterionInput) (string, []interfa
 	} else {
 		var count int
 		clause, count = getIntCriterionWhereClause("scenes.duration", durationFilter)
-		if count == 1 {
-			args = append(args, value)
-		}
+		args = append(args, getIntCriterionArgs(durationFilter, count)...)
 	}
 
 	return clause, args
 }
 
-func (qb *SceneQueryBuilder) QueryAllByPathRegex(regex string, ignoreOrganized bool) ([]*Scene, error) {
+// QueryAllByPathOrTitleRegex returns the scenes whose paths or titles match
+// the regex, regardless of case.
+func (qb *SceneQueryBuilder) QueryAllByPathOrTitleRegex(regex string, ignoreOrganized bool) ([]*Scene, error) {
 	var args []interface{}
-	body := selectDistinctIDs("scenes") + " WHERE scenes.path regexp ?"
+	body := selectDistinctIDs("scenes") + " WHERE (scenes.path regexp ? OR COALESCE(scenes.title, '') regexp ?)"
 
 	if ignoreOrganized {
 		body += " AND scenes.organized = 0"
 	}
 
-	args = append(args, "(?i)"+regex)
+	args = append(args, "(?i)"+regex, "(?i)"+regex)
 
 	idsResult, err := runIdsQuery(body, args)
 
@@ -544,8 +777,8 @@ func (qb *SceneQueryBuilder) QueryByPathRegex(findFilter *FindFilterType) ([]*Sc
 		args = append(args, "(?i)"+*q)
 	}
 
-	sortAndPagination := qb.getSceneSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := executeFindQuery("scenes", body, args, sortAndPagination, whereClauses, havingClauses)
+	sortAndPagination := qb.getSceneSort(findFilter).String() + getPagination(findFilter)
+	idsResult, countResult := executeFindQuery("scenes", body, args, sortAndPagination, whereClauses, havingClauses, findFilter.IsApproximateCount())
 
 	var scenes []*Scene
 	for _, id := range idsResult {
@@ -556,13 +789,18 @@ func (qb *SceneQueryBuilder) QueryByPathRegex(findFilter *FindFilterType) ([]*Sc
 	return scenes, countResult
 }
 
-func (qb *SceneQueryBuilder) getSceneSort(findFilter *FindFilterType) string {
+// sceneDisplayTitleColumn is the expression of the display title of a scene.
+// See utils.DisplayTitle.
+const sceneDisplayTitleColumn = "display_title(COALESCE(scenes.title, ''), scenes.path)"
+
+func (qb *SceneQueryBuilder) getSceneSort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		return " ORDER BY scenes.path, scenes.date ASC "
+		return sortTerms{
+			{expression: "scenes.path", direction: "ASC"},
+			{expression: "scenes.date", direction: "ASC"},
+		}
 	}
-	sort := findFilter.GetSort("title")
-	direction := findFilter.GetDirection()
-	return getSort(sort, direction, "scenes")
+	return getSort(findFilter.getSortFields("title", "ASC"), "scenes")
 }
 
 func (qb *SceneQueryBuilder) queryScene(query string, args []interface{}, tx *sqlx.Tx) (*Scene, error) {
@@ -629,6 +867,19 @@ func (qb *SceneQueryBuilder) UpdateOSHash(id int, oshash string, tx *sqlx.Tx) er
 	return nil
 }
 
+func (qb *SceneQueryBuilder) UpdatePhash(id int, phash int64, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(
+		`UPDATE scenes SET phash = ? WHERE scenes.id = ? `,
+		phash, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (qb *SceneQueryBuilder) UpdateChecksum(id int, checksum string, tx *sqlx.Tx) error {
 	ensureTx(tx)
 	_, err := tx.Exec(
@@ -650,27 +901,130 @@ func (qb *SceneQueryBuilder) UpdateSceneCover(sceneID int, cover []byte, tx *sql
 		return err
 	}
 
-	_, err := tx.Exec(
-		`INSERT INTO scenes_cover (scene_id, cover) VALUES (?, ?)`,
+	cover, file, err := sceneCoverBlob.store(sceneID, cover)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO scenes_cover (scene_id, cover, cover_file) VALUES (?, ?, ?)`,
 		sceneID,
 		cover,
+		file,
 	)
 
 	return err
 }
 
+// GetChapters returns the chapters of the scene file, in order.
+func (qb *SceneQueryBuilder) GetChapters(sceneID int, tx *sqlx.Tx) ([]*SceneChapter, error) {
+	query := "SELECT * FROM scene_chapters WHERE scene_id = ? ORDER BY position"
+
+	var ret []*SceneChapter
+	var err error
+	if tx != nil {
+		err = tx.Select(&ret, query, sceneID)
+	} else {
+		err = database.DB.Select(&ret, query, sceneID)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// UpdateChapters replaces the chapters of the scene. The positions of the
+// chapters are set from their order.
+func (qb *SceneQueryBuilder) UpdateChapters(sceneID int, chapters []SceneChapter, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM scene_chapters WHERE scene_id = ?", sceneID); err != nil {
+		return err
+	}
+
+	for i, c := range chapters {
+		c.SceneID = sceneID
+		c.Position = i
+		_, err := tx.NamedExec(
+			`INSERT INTO scene_chapters (scene_id, position, seconds, end_seconds, title)
+				VALUES (:scene_id, :position, :seconds, :end_seconds, :title)`,
+			c,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCaptions returns the caption files of the scene file, ordered by file
+// name.
+func (qb *SceneQueryBuilder) GetCaptions(sceneID int, tx *sqlx.Tx) ([]*SceneCaption, error) {
+	query := "SELECT * FROM scene_captions WHERE scene_id = ? ORDER BY filename"
+
+	var ret []*SceneCaption
+	var err error
+	if tx != nil {
+		err = tx.Select(&ret, query, sceneID)
+	} else {
+		err = database.DB.Select(&ret, query, sceneID)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// UpdateCaptions replaces the caption files of the scene.
+func (qb *SceneQueryBuilder) UpdateCaptions(sceneID int, captions []SceneCaption, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM scene_captions WHERE scene_id = ?", sceneID); err != nil {
+		return err
+	}
+
+	for _, c := range captions {
+		c.SceneID = sceneID
+		_, err := tx.NamedExec(
+			`INSERT INTO scene_captions (scene_id, filename, language_code, caption_type)
+				VALUES (:scene_id, :filename, :language_code, :caption_type)`,
+			c,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RebuildSearchDocuments recomputes the search documents of all scenes. The
+// documents hold the display titles of the scenes, so must be rebuilt when
+// the display title cleanup expressions change.
+func (qb *SceneQueryBuilder) RebuildSearchDocuments(tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	_, err := tx.Exec("INSERT OR REPLACE INTO search_documents SELECT 'scene', id, text FROM scene_search_documents")
+	return err
+}
+
 func (qb *SceneQueryBuilder) DestroySceneCover(sceneID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
-	// Delete the existing joins
-	_, err := tx.Exec("DELETE FROM scenes_cover WHERE scene_id = ?", sceneID)
-	if err != nil {
+	if err := destroyImageFiles(sceneID, tx, sceneCoverBlob); err != nil {
 		return err
 	}
+
+	// Delete the existing joins
+	_, err := tx.Exec("DELETE FROM scenes_cover WHERE scene_id = ?", sceneID)
 	return err
 }
 
 func (qb *SceneQueryBuilder) GetSceneCover(sceneID int, tx *sqlx.Tx) ([]byte, error) {
-	query := `SELECT cover from scenes_cover WHERE scene_id = ?`
-	return getImage(tx, query, sceneID)
+	return sceneCoverBlob.get(sceneID, tx)
 }
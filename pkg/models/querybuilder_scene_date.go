@@ -0,0 +1,86 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/stashapp/stash/pkg/database"
+)
+
+// sceneDatedClause matches the scenes with a date. Scenes without a date may
+// have an empty or zero date, which date() returns as null or 0001-01-01.
+const sceneDatedClause = "date(scenes.date) > '0001-01-01'"
+
+// SceneDateRange is the dates of the earliest and latest scenes of an object,
+// in YYYY-MM-DD format. The dates are null if the object has no scenes with a
+// date.
+type SceneDateRange struct {
+	First sql.NullString `db:"first"`
+	Last  sql.NullString `db:"last"`
+}
+
+// sceneDateJoin selects the scenes of the objects of a table.
+type sceneDateJoin struct {
+	// from selects the scenes, joined with the join table if any
+	from string
+	// idColumn is the column of the id of the object of each scene
+	idColumn string
+}
+
+var (
+	performerSceneDateJoin = sceneDateJoin{
+		from:     "performers_scenes JOIN scenes ON scenes.id = performers_scenes.scene_id",
+		idColumn: "performers_scenes.performer_id",
+	}
+	studioSceneDateJoin = sceneDateJoin{
+		from:     "scenes",
+		idColumn: "scenes.studio_id",
+	}
+	tagSceneDateJoin = sceneDateJoin{
+		from:     "scenes_tags JOIN scenes ON scenes.id = scenes_tags.scene_id",
+		idColumn: "scenes_tags.tag_id",
+	}
+)
+
+// subquery returns a subquery selecting the date of the earliest or latest
+// scene of the object with the id in idExpression, depending on whether fn is
+// MIN or MAX.
+func (j sceneDateJoin) subquery(fn string, idExpression string) string {
+	return "(SELECT " + fn + "(date(scenes.date)) FROM " + j.from + " WHERE " + j.idColumn + " = " + idExpression + " AND " + sceneDatedClause + ")"
+}
+
+// criterionHandler compares the date of the earliest or latest scene of each
+// object, whose id is in idColumn, with the criterion, depending on whether
+// fn is MIN or MAX. Objects without scenes with a date only match the IS_NULL
+// modifier.
+func (j sceneDateJoin) criterionHandler(c *DateCriterionInput, fn string, idColumn string) criterionHandlerFunc {
+	return dateCriterionHandler(c, j.subquery(fn, idColumn))
+}
+
+func (j sceneDateJoin) getRange(id int) (*SceneDateRange, error) {
+	query := "SELECT MIN(date(scenes.date)) as first, MAX(date(scenes.date)) as last FROM " + j.from + " WHERE " + j.idColumn + " = ? AND " + sceneDatedClause
+
+	ret := SceneDateRange{}
+	if err := database.DB.Get(&ret, query, id); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// SceneDateRange returns the dates of the earliest and latest scenes of the
+// performer.
+func (qb *PerformerQueryBuilder) SceneDateRange(performerID int) (*SceneDateRange, error) {
+	return performerSceneDateJoin.getRange(performerID)
+}
+
+// SceneDateRange returns the dates of the earliest and latest scenes of the
+// studio, not including the scenes of its child studios.
+func (qb *StudioQueryBuilder) SceneDateRange(studioID int) (*SceneDateRange, error) {
+	return studioSceneDateJoin.getRange(studioID)
+}
+
+// SceneDateRange returns the dates of the earliest and latest scenes with the
+// tag.
+func (qb *TagQueryBuilder) SceneDateRange(tagID int) (*SceneDateRange, error) {
+	return tagSceneDateJoin.getRange(tagID)
+}
@@ -0,0 +1,213 @@
+// +build integration
+
+package models_test
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sceneDateFirst = "2001-01-01"
+	sceneDateLast  = "2005-06-07"
+)
+
+type sceneDateFixture struct {
+	sceneIDs        []int
+	studioID        int
+	performerID     int
+	undatedID       int
+	tagID           int
+	undatedTagID    int
+	undatedStudioID int
+}
+
+// createSceneDateFixture creates a studio, performer and tag with two dated
+// scenes and one undated scene, and a studio, performer and tag with only the
+// undated scene.
+func createSceneDateFixture(fixtures *testFixtures) sceneDateFixture {
+	ret := sceneDateFixture{}
+
+	ret.studioID = fixtures.studio(*models.NewStudio("TestSceneDate")).ID
+	ret.undatedStudioID = fixtures.studio(*models.NewStudio("TestSceneDateUndated")).ID
+
+	var performers []int
+	var tags []int
+	for _, name := range []string{"TestSceneDate", "TestSceneDateUndated"} {
+		performer := fixtures.performer(models.Performer{
+			Name:     sql.NullString{String: name, Valid: true},
+			Favorite: sql.NullBool{Bool: false, Valid: true},
+		})
+		performers = append(performers, performer.ID)
+		tags = append(tags, fixtures.tag(models.Tag{Name: name}).ID)
+	}
+	ret.performerID, ret.undatedID = performers[0], performers[1]
+	ret.tagID, ret.undatedTagID = tags[0], tags[1]
+
+	jqb := models.NewJoinsQueryBuilder()
+	dates := []models.SQLiteDate{
+		{String: sceneDateLast, Valid: true},
+		{String: sceneDateFirst, Valid: true},
+		{String: "", Valid: true},
+	}
+	for i, date := range dates {
+		scene := models.Scene{
+			Path:     "TestSceneDate" + strconv.Itoa(i),
+			Date:     date,
+			StudioID: sql.NullInt64{Int64: int64(ret.studioID), Valid: true},
+		}
+
+		performerID, tagID := ret.performerID, ret.tagID
+		if date.String == "" {
+			scene.StudioID.Int64 = int64(ret.undatedStudioID)
+			performerID, tagID = ret.undatedID, ret.undatedTagID
+		}
+
+		sceneID := fixtures.scene(scene).ID
+		ret.sceneIDs = append(ret.sceneIDs, sceneID)
+
+		withTxn(fixtures.t, func(tx *sqlx.Tx) error {
+			if err := jqb.CreatePerformersScenes([]models.PerformersScenes{{PerformerID: performerID, SceneID: sceneID}}, tx); err != nil {
+				return err
+			}
+			return jqb.CreateScenesTags([]models.ScenesTags{{SceneID: sceneID, TagID: tagID}}, tx)
+		})
+		fixtures.onDestroy(func(tx *sqlx.Tx) error {
+			if err := jqb.DestroyScenesTags(sceneID, tx); err != nil {
+				return err
+			}
+			return jqb.DestroyPerformersScenes(sceneID, tx)
+		})
+	}
+
+	return ret
+}
+
+func assertSceneDateRange(t *testing.T, dates *models.SceneDateRange, err error, first, last string) {
+	if err != nil {
+		t.Fatalf("Error getting scene date range: %s", err.Error())
+	}
+
+	assert.Equal(t, sql.NullString{String: first, Valid: first != ""}, dates.First)
+	assert.Equal(t, sql.NullString{String: last, Valid: last != ""}, dates.Last)
+}
+
+func TestSceneDateRange(t *testing.T) {
+	fixtures := newTestFixtures(t)
+	defer fixtures.destroy()
+	f := createSceneDateFixture(fixtures)
+
+	pqb := models.NewPerformerQueryBuilder()
+	dates, err := pqb.SceneDateRange(f.performerID)
+	assertSceneDateRange(t, dates, err, sceneDateFirst, sceneDateLast)
+	dates, err = pqb.SceneDateRange(f.undatedID)
+	assertSceneDateRange(t, dates, err, "", "")
+
+	sqb := models.NewStudioQueryBuilder()
+	dates, err = sqb.SceneDateRange(f.studioID)
+	assertSceneDateRange(t, dates, err, sceneDateFirst, sceneDateLast)
+	dates, err = sqb.SceneDateRange(f.undatedStudioID)
+	assertSceneDateRange(t, dates, err, "", "")
+
+	tqb := models.NewTagQueryBuilder()
+	dates, err = tqb.SceneDateRange(f.tagID)
+	assertSceneDateRange(t, dates, err, sceneDateFirst, sceneDateLast)
+	dates, err = tqb.SceneDateRange(f.undatedTagID)
+	assertSceneDateRange(t, dates, err, "", "")
+}
+
+func TestSceneDateFilters(t *testing.T) {
+	fixtures := newTestFixtures(t)
+	defer fixtures.destroy()
+	f := createSceneDateFixture(fixtures)
+
+	value2 := "2004-01-01"
+	criteria := []struct {
+		name      string
+		first     bool
+		criterion models.DateCriterionInput
+		dated     bool
+		undated   bool
+	}{
+		{"first equals", true, models.DateCriterionInput{Value: sceneDateFirst, Modifier: models.CriterionModifierEquals}, true, false},
+		{"last equals", false, models.DateCriterionInput{Value: sceneDateLast, Modifier: models.CriterionModifierEquals}, true, false},
+		{"last less than", false, models.DateCriterionInput{Value: "2004-01-01", Modifier: models.CriterionModifierLessThan}, false, false},
+		{"last greater than", false, models.DateCriterionInput{Value: "2004-01-01", Modifier: models.CriterionModifierGreaterThan}, true, false},
+		{"first between", true, models.DateCriterionInput{Value: "2000-01-01", Value2: &value2, Modifier: models.CriterionModifierBetween}, true, false},
+		{"last between", false, models.DateCriterionInput{Value: "2000-01-01", Value2: &value2, Modifier: models.CriterionModifierBetween}, false, false},
+		{"first is null", true, models.DateCriterionInput{Modifier: models.CriterionModifierIsNull}, false, true},
+		{"last not null", false, models.DateCriterionInput{Modifier: models.CriterionModifierNotNull}, true, false},
+	}
+
+	pqb := models.NewPerformerQueryBuilder()
+	sqb := models.NewStudioQueryBuilder()
+	tqb := models.NewTagQueryBuilder()
+
+	perPage := 1000
+	findFilter := models.FindFilterType{PerPage: &perPage}
+
+	for _, c := range criteria {
+		t.Run(c.name, func(t *testing.T) {
+			criterion := c.criterion
+
+			performerFilter := models.PerformerFilterType{}
+			studioFilter := models.StudioFilterType{}
+			tagFilter := models.TagFilterType{}
+			if c.first {
+				performerFilter.FirstSceneDate = &criterion
+				studioFilter.FirstSceneDate = &criterion
+				tagFilter.FirstSceneDate = &criterion
+			} else {
+				performerFilter.LastSceneDate = &criterion
+				studioFilter.LastSceneDate = &criterion
+				tagFilter.LastSceneDate = &criterion
+			}
+
+			var ids []int
+			performers, _, err := pqb.Query(&performerFilter, &findFilter)
+			if err != nil {
+				t.Fatalf("Error querying performers: %s", err.Error())
+			}
+			for _, p := range performers {
+				ids = append(ids, p.ID)
+			}
+			assertSceneDateMatch(t, ids, f.performerID, c.dated)
+			assertSceneDateMatch(t, ids, f.undatedID, c.undated)
+
+			ids = nil
+			studios, _, err := sqb.Query(&studioFilter, &findFilter)
+			if err != nil {
+				t.Fatalf("Error querying studios: %s", err.Error())
+			}
+			for _, s := range studios {
+				ids = append(ids, s.ID)
+			}
+			assertSceneDateMatch(t, ids, f.studioID, c.dated)
+			assertSceneDateMatch(t, ids, f.undatedStudioID, c.undated)
+
+			ids = nil
+			tags, _, err := tqb.Query(&tagFilter, &findFilter)
+			if err != nil {
+				t.Fatalf("Error querying tags: %s", err.Error())
+			}
+			for _, tag := range tags {
+				ids = append(ids, tag.ID)
+			}
+			assertSceneDateMatch(t, ids, f.tagID, c.dated)
+			assertSceneDateMatch(t, ids, f.undatedTagID, c.undated)
+		})
+	}
+}
+
+func assertSceneDateMatch(t *testing.T, ids []int, id int, match bool) {
+	if match {
+		assert.Contains(t, ids, id)
+	} else {
+		assert.NotContains(t, ids, id)
+	}
+}
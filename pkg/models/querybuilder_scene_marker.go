@@ -24,9 +24,15 @@ func NewSceneMarkerQueryBuilder() SceneMarkerQueryBuilder {
 
 func (qb *SceneMarkerQueryBuilder) Create(newSceneMarker SceneMarker, tx *sqlx.Tx) (*SceneMarker, error) {
 	ensureTx(tx)
+	uuid, err := uniqueUUID("scene_markers", newSceneMarker.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newSceneMarker.UUID = uuid
+
 	result, err := tx.NamedExec(
-		`INSERT INTO scene_markers (title, seconds, primary_tag_id, scene_id, created_at, updated_at)
-				VALUES (:title, :seconds, :primary_tag_id, :scene_id, :created_at, :updated_at)
+		`INSERT INTO scene_markers (title, seconds, primary_tag_id, scene_id, uuid, created_at, updated_at)
+				VALUES (:title, :seconds, :primary_tag_id, :scene_id, :uuid, :created_at, :updated_at)
 		`,
 		newSceneMarker,
 	)
@@ -103,6 +109,41 @@ func (qb *SceneMarkerQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) ([]*S
 	return qb.querySceneMarkers(query, args, tx)
 }
 
+// FindByTagIDs returns the markers with one of the tags as their primary tag
+// or one of their tags. Only the markers of the scenes are returned if
+// sceneIDs is not empty.
+func (qb *SceneMarkerQueryBuilder) FindByTagIDs(tagIDs []int, sceneIDs []int, tx *sqlx.Tx) ([]*SceneMarker, error) {
+	if len(tagIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT scene_markers.* FROM scene_markers
+		LEFT JOIN scene_markers_tags as tags_join on tags_join.scene_marker_id = scene_markers.id
+		WHERE (tags_join.tag_id IN ` + getInBinding(len(tagIDs)) + ` OR scene_markers.primary_tag_id IN ` + getInBinding(len(tagIDs)) + `)`
+
+	var args []interface{}
+	for i := 0; i < 2; i++ {
+		for _, id := range tagIDs {
+			args = append(args, id)
+		}
+	}
+
+	if len(sceneIDs) > 0 {
+		query += " AND scene_markers.scene_id IN " + getInBinding(len(sceneIDs))
+		for _, id := range sceneIDs {
+			args = append(args, id)
+		}
+	}
+
+	query += `
+		GROUP BY scene_markers.id
+		ORDER BY scene_markers.scene_id ASC, scene_markers.seconds ASC
+	`
+
+	return qb.querySceneMarkers(query, args, tx)
+}
+
 func (qb *SceneMarkerQueryBuilder) CountByTagID(tagID int) (int, error) {
 	args := []interface{}{tagID, tagID}
 	return runCountQuery(buildCountQuery(countSceneMarkersForTagQuery), args)
@@ -132,7 +173,7 @@ func (qb *SceneMarkerQueryBuilder) Wall(q *string) ([]*SceneMarker, error) {
 	return qb.querySceneMarkers(query, nil, nil)
 }
 
-func (qb *SceneMarkerQueryBuilder) Query(sceneMarkerFilter *SceneMarkerFilterType, findFilter *FindFilterType) ([]*SceneMarker, int) {
+func (qb *SceneMarkerQueryBuilder) Query(sceneMarkerFilter *SceneMarkerFilterType, findFilter *FindFilterType) ([]*SceneMarker, int, error) {
 	if sceneMarkerFilter == nil {
 		sceneMarkerFilter = &SceneMarkerFilterType{}
 	}
@@ -140,88 +181,56 @@ func (qb *SceneMarkerQueryBuilder) Query(sceneMarkerFilter *SceneMarkerFilterTyp
 		findFilter = &FindFilterType{}
 	}
 
-	var whereClauses []string
-	var havingClauses []string
-	var args []interface{}
-	body := selectDistinctIDs("scene_markers")
-	body = body + `
-		left join tags as primary_tag on primary_tag.id = scene_markers.primary_tag_id
-		left join scenes as scene on scene.id = scene_markers.scene_id
-		left join scene_markers_tags as tags_join on tags_join.scene_marker_id = scene_markers.id
-		left join tags on tags_join.tag_id = tags.id
-	`
-
-	if tagsFilter := sceneMarkerFilter.Tags; tagsFilter != nil && len(tagsFilter.Value) > 0 {
-		//select `scene_markers`.* from `scene_markers`
-		//left join `tags` as `primary_tags_join`
-		//  on `primary_tags_join`.`id` = `scene_markers`.`primary_tag_id`
-		//  and `primary_tags_join`.`id` in ('3', '37', '9', '89')
-		//left join `scene_markers_tags` as `tags_join`
-		//  on `tags_join`.`scene_marker_id` = `scene_markers`.`id`
-		//  and `tags_join`.`tag_id` in ('3', '37', '9', '89')
-		//group by `scene_markers`.`id`
-		//having ((count(distinct `primary_tags_join`.`id`) + count(distinct `tags_join`.`tag_id`)) = 4)
-
-		length := len(tagsFilter.Value)
-
-		if tagsFilter.Modifier == CriterionModifierIncludes || tagsFilter.Modifier == CriterionModifierIncludesAll {
-			body += " LEFT JOIN tags AS ptj ON ptj.id = scene_markers.primary_tag_id AND ptj.id IN " + getInBinding(length)
-			body += " LEFT JOIN scene_markers_tags AS tj ON tj.scene_marker_id = scene_markers.id AND tj.tag_id IN " + getInBinding(length)
-
-			// only one required for include any
-			requiredCount := 1
-
-			// all required for include all
-			if tagsFilter.Modifier == CriterionModifierIncludesAll {
-				requiredCount = length
-			}
+	query := queryBuilder{
+		tableName: "scene_markers",
+	}
 
-			havingClauses = append(havingClauses, "((COUNT(DISTINCT ptj.id) + COUNT(DISTINCT tj.tag_id)) >= "+strconv.Itoa(requiredCount)+")")
-		} else if tagsFilter.Modifier == CriterionModifierExcludes {
-			// excludes all of the provided ids
-			whereClauses = append(whereClauses, "scene_markers.primary_tag_id not in "+getInBinding(length))
-			whereClauses = append(whereClauses, "not exists (select smt.scene_marker_id from scene_markers_tags as smt where smt.scene_marker_id = scene_markers.id and smt.tag_id in "+getInBinding(length)+")")
-		}
+	query.addFilter(qb.makeFilter(sceneMarkerFilter))
 
-		for _, tagID := range tagsFilter.Value {
-			args = append(args, tagID)
-		}
-		for _, tagID := range tagsFilter.Value {
-			args = append(args, tagID)
-		}
+	if q := findFilter.Q; q != nil && *q != "" {
+		searchColumns := []string{"scene_markers.title", "scene.title"}
+		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
+		query.addWhere(clause)
+		query.addArg(thisArgs...)
 	}
 
-	if sceneTagsFilter := sceneMarkerFilter.SceneTags; sceneTagsFilter != nil && len(sceneTagsFilter.Value) > 0 {
-		length := len(sceneTagsFilter.Value)
-
-		if sceneTagsFilter.Modifier == CriterionModifierIncludes || sceneTagsFilter.Modifier == CriterionModifierIncludesAll {
-			body += " LEFT JOIN scenes_tags AS scene_tags_join ON scene_tags_join.scene_id = scene.id AND scene_tags_join.tag_id IN " + getInBinding(length)
+	query.paginate(qb.getSceneMarkerSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
+	}
 
-			// only one required for include any
-			requiredCount := 1
+	var sceneMarkers []*SceneMarker
+	for _, id := range idsResult {
+		sceneMarker, _ := qb.Find(id)
+		sceneMarkers = append(sceneMarkers, sceneMarker)
+	}
 
-			// all required for include all
-			if sceneTagsFilter.Modifier == CriterionModifierIncludesAll {
-				requiredCount = length
-			}
+	return sceneMarkers, countResult, nil
+}
 
-			havingClauses = append(havingClauses, "COUNT(DISTINCT scene_tags_join.tag_id) >= "+strconv.Itoa(requiredCount))
-		} else if sceneTagsFilter.Modifier == CriterionModifierExcludes {
-			// excludes all of the provided ids
-			whereClauses = append(whereClauses, "not exists (select st.scene_id from scenes_tags as st where st.scene_id = scene.id AND st.tag_id IN "+getInBinding(length)+")")
-		}
+// makeFilter returns the clauses of the scene marker filter and its
+// sub-filters.
+func (qb *SceneMarkerQueryBuilder) makeFilter(sceneMarkerFilter *SceneMarkerFilterType) *filterBuilder {
+	query := newFilterBuilder("scene_markers", selectDistinctIDs("scene_markers")+`
+		left join tags as primary_tag on primary_tag.id = scene_markers.primary_tag_id
+		left join scenes as scene on scene.id = scene_markers.scene_id
+		left join scene_markers_tags as tags_join on tags_join.scene_marker_id = scene_markers.id
+		left join tags on tags_join.tag_id = tags.id
+	`)
 
-		for _, tagID := range sceneTagsFilter.Value {
-			args = append(args, tagID)
-		}
-	}
+	query.handleCriteria(
+		subTags.criterionHandler(sceneMarkerFilter.Tags, "scene_markers.primary_tag_id IN %s", joinTableTagClause("scene_markers", "scene_markers_tags", "scene_marker_id")),
+		subTags.criterionHandler(sceneMarkerFilter.SceneTags, "EXISTS (SELECT 1 FROM scenes_tags WHERE scenes_tags.scene_id = scene_markers.scene_id AND scenes_tags.tag_id IN %s)"),
+	)
 
 	if performersFilter := sceneMarkerFilter.Performers; performersFilter != nil && len(performersFilter.Value) > 0 {
 		length := len(performersFilter.Value)
 
 		if performersFilter.Modifier == CriterionModifierIncludes || performersFilter.Modifier == CriterionModifierIncludesAll {
-			body += " LEFT JOIN performers_scenes as scene_performers ON scene.id = scene_performers.scene_id"
-			whereClauses = append(whereClauses, "scene_performers.performer_id IN "+getInBinding(length))
+			query.body += " LEFT JOIN performers_scenes as scene_performers ON scene.id = scene_performers.scene_id"
+			query.addWhere("scene_performers.performer_id IN " + getInBinding(length))
 
 			// only one required for include any
 			requiredCount := 1
@@ -231,49 +240,43 @@ func (qb *SceneMarkerQueryBuilder) Query(sceneMarkerFilter *SceneMarkerFilterTyp
 				requiredCount = length
 			}
 
-			havingClauses = append(havingClauses, "COUNT(DISTINCT scene_performers.performer_id) >= "+strconv.Itoa(requiredCount))
+			query.addHaving("COUNT(DISTINCT scene_performers.performer_id) >= " + strconv.Itoa(requiredCount))
 		} else if performersFilter.Modifier == CriterionModifierExcludes {
 			// excludes all of the provided ids
-			whereClauses = append(whereClauses, "not exists (select sp.scene_id from performers_scenes as sp where sp.scene_id = scene.id AND sp.performer_id IN "+getInBinding(length)+")")
+			query.addWhere("not exists (select sp.scene_id from performers_scenes as sp where sp.scene_id = scene.id AND sp.performer_id IN " + getInBinding(length) + ")")
 		}
 
 		for _, performerID := range performersFilter.Value {
-			args = append(args, performerID)
+			query.addArg(performerID)
 		}
 	}
 
-	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"scene_markers.title", "scene.title"}
-		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
-		whereClauses = append(whereClauses, clause)
-		args = append(args, thisArgs...)
-	}
-
 	if tagID := sceneMarkerFilter.TagID; tagID != nil {
-		whereClauses = append(whereClauses, "(scene_markers.primary_tag_id = "+*tagID+" OR tags.id = "+*tagID+")")
+		query.addWhere("(scene_markers.primary_tag_id = " + *tagID + " OR tags.id = " + *tagID + ")")
 	}
 
-	sortAndPagination := qb.getSceneMarkerSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := executeFindQuery("scene_markers", body, args, sortAndPagination, whereClauses, havingClauses)
-
-	var sceneMarkers []*SceneMarker
-	for _, id := range idsResult {
-		sceneMarker, _ := qb.Find(id)
-		sceneMarkers = append(sceneMarkers, sceneMarker)
+	if sceneMarkerFilter.And != nil {
+		query.and = qb.makeFilter(sceneMarkerFilter.And)
+	}
+	if sceneMarkerFilter.Or != nil {
+		query.or = qb.makeFilter(sceneMarkerFilter.Or)
+	}
+	if sceneMarkerFilter.Not != nil {
+		query.not = qb.makeFilter(sceneMarkerFilter.Not)
 	}
 
-	return sceneMarkers, countResult
+	return query
 }
 
-func (qb *SceneMarkerQueryBuilder) getSceneMarkerSort(findFilter *FindFilterType) string {
-	sort := findFilter.GetSort("title")
-	direction := findFilter.GetDirection()
-	tableName := "scene_markers"
-	if sort == "scenes_updated_at" {
-		sort = "updated_at"
-		tableName = "scene"
+func (qb *SceneMarkerQueryBuilder) getSceneMarkerSort(findFilter *FindFilterType) sortTerms {
+	fields := findFilter.getSortFields("title", "ASC")
+	for i := range fields {
+		if fields[i].sort == "scenes_updated_at" {
+			fields[i].sort = "updated_at"
+			fields[i].tableName = "scene"
+		}
 	}
-	return getSort(sort, direction, tableName)
+	return getSort(fields, "scene_markers")
 }
 
 func (qb *SceneMarkerQueryBuilder) querySceneMarkers(query string, args []interface{}, tx *sqlx.Tx) ([]*SceneMarker, error) {
@@ -32,6 +32,33 @@ func TestMarkerFindBySceneID(t *testing.T) {
 	assert.Len(t, markers, 0)
 }
 
+func TestMarkerFindByTagIDs(t *testing.T) {
+	mqb := models.NewSceneMarkerQueryBuilder()
+
+	// the tags match as the primary tag or one of the tags
+	for _, tagIdx := range []int{tagIdxWithPrimaryMarker, tagIdxWithMarker} {
+		markers, err := mqb.FindByTagIDs([]int{tagIDs[tagIdx], 0}, nil, nil)
+		if err != nil {
+			t.Fatalf("Error finding markers: %s", err.Error())
+		}
+
+		assert.Len(t, markers, 1)
+		assert.Equal(t, markerIDs[markerIdxWithScene], markers[0].ID)
+	}
+
+	markers, err := mqb.FindByTagIDs([]int{tagIDs[tagIdxWithMarker]}, []int{sceneIDs[sceneIdxWithMarker]}, nil)
+	if err != nil {
+		t.Fatalf("Error finding markers: %s", err.Error())
+	}
+	assert.Len(t, markers, 1)
+
+	markers, err = mqb.FindByTagIDs([]int{tagIDs[tagIdxWithMarker]}, []int{0}, nil)
+	if err != nil {
+		t.Fatalf("Error finding markers: %s", err.Error())
+	}
+	assert.Len(t, markers, 0)
+}
+
 func TestMarkerCountByTagID(t *testing.T) {
 	mqb := models.NewSceneMarkerQueryBuilder()
 
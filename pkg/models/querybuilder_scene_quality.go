@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/stashapp/stash/pkg/database"
+)
+
+// sceneQualityScoreColumn is the expression of the quality score of a scene.
+// See utils.QualityScore.
+const sceneQualityScoreColumn = "quality_score(CAST(IFNULL(scenes.width, 0) AS INTEGER), CAST(IFNULL(scenes.height, 0) AS INTEGER), CAST(IFNULL(scenes.bitrate, 0) AS INTEGER), CAST(IFNULL(scenes.framerate, 0) AS REAL), IFNULL(scenes.video_codec, ''))"
+
+// SceneGroup is the ids of a group of scenes, such as those of a performer.
+type SceneGroup struct {
+	ID       int
+	SceneIDs []int
+}
+
+// FindLowestQualityByFavoritePerformer returns the scenes of each favorite
+// performer with the lowest quality scores, lowest first. Up to limit scenes
+// with a score up to maxScore, if not nil, are returned for each performer.
+// The performers are ordered by name.
+func (qb *SceneQueryBuilder) FindLowestQualityByFavoritePerformer(limit int, maxScore *float64) ([]*SceneGroup, error) {
+	from := `performers_scenes
+		JOIN performers ON performers.id = performers_scenes.performer_id
+		JOIN scored_scenes ON scored_scenes.id = performers_scenes.scene_id
+		WHERE performers.favorite = 1`
+	return qb.findLowestQuality("performers.id", "performers.name", from, limit, maxScore)
+}
+
+// FindLowestQualityByStudio returns the scenes of each studio with the lowest
+// quality scores, lowest first. Up to limit scenes with a score up to
+// maxScore, if not nil, are returned for each studio. The studios are ordered
+// by name.
+func (qb *SceneQueryBuilder) FindLowestQualityByStudio(limit int, maxScore *float64) ([]*SceneGroup, error) {
+	from := `studios
+		JOIN scored_scenes ON scored_scenes.studio_id = studios.id`
+	return qb.findLowestQuality("studios.id", "studios.name", from, limit, maxScore)
+}
+
+// findLowestQuality ranks the scenes of each group by quality score. from
+// joins the groups with the scored_scenes table of scenes and their scores.
+func (qb *SceneQueryBuilder) findLowestQuality(groupColumn string, nameColumn string, from string, limit int, maxScore *float64) ([]*SceneGroup, error) {
+	var args []interface{}
+	scored := "SELECT scenes.id, scenes.studio_id, " + sceneQualityScoreColumn + " AS score FROM scenes"
+	if maxScore != nil {
+		scored += " WHERE " + sceneQualityScoreColumn + " <= ?"
+		args = append(args, *maxScore)
+	}
+
+	query := "WITH scored_scenes AS (" + scored + `)
+		SELECT group_id, scene_id FROM (
+			SELECT ` + groupColumn + ` AS group_id, ` + nameColumn + ` AS group_name, scored_scenes.id AS scene_id,
+			ROW_NUMBER() OVER (PARTITION BY ` + groupColumn + ` ORDER BY scored_scenes.score ASC, scored_scenes.id ASC) AS quality_rank
+			FROM ` + from + `
+		) WHERE quality_rank <= ?
+		ORDER BY group_name COLLATE NATURAL_CI ASC, group_id ASC, quality_rank ASC`
+	args = append(args, limit)
+
+	rows, err := database.DB.Queryx(query, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*SceneGroup
+	for rows.Next() {
+		var groupID, sceneID int
+		if err := rows.Scan(&groupID, &sceneID); err != nil {
+			return nil, err
+		}
+
+		if len(ret) == 0 || ret[len(ret)-1].ID != groupID {
+			ret = append(ret, &SceneGroup{ID: groupID})
+		}
+		group := ret[len(ret)-1]
+		group.SceneIDs = append(group.SceneIDs, sceneID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -5,9 +5,13 @@ package models_test
 import (
 	"context"
 	"database/sql"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stashapp/stash/pkg/database"
@@ -39,6 +43,25 @@ func TestSceneFind(t *testing.T) {
 	assert.Nil(t, scene)
 }
 
+func TestSceneFindMany(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	ids := []int{sceneIDs[2], sceneIDs[0], sceneIDs[1]}
+	scenes, err := sqb.FindMany(ids)
+	if err != nil {
+		t.Fatalf("Error finding scenes: %s", err.Error())
+	}
+
+	var foundIDs []int
+	for _, scene := range scenes {
+		foundIDs = append(foundIDs, scene.ID)
+	}
+	assert.Equal(t, ids, foundIDs)
+
+	_, err = sqb.FindMany([]int{sceneIDs[0], 0})
+	assert.NotNil(t, err)
+}
+
 func TestSceneFindByPath(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
 
@@ -118,11 +141,35 @@ func TestSceneQueryQ(t *testing.T) {
 	sceneQueryQ(t, sqb, q, sceneIdx)
 }
 
+func TestSceneQueryQStudio(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+	stqb := models.NewStudioQueryBuilder()
+
+	sceneQueryQ(t, sqb, studioNames[studioIdxWithScene], sceneIdxWithStudio)
+
+	renameStudio := func(name string) {
+		withTxn(t, func(tx *sqlx.Tx) error {
+			_, err := stqb.Update(models.StudioPartial{
+				ID:   studioIDs[studioIdxWithScene],
+				Name: &sql.NullString{String: name, Valid: true},
+			}, tx)
+			return err
+		})
+	}
+
+	// the search documents of the scenes of the studio are updated
+	renameStudio("Renamed Stüdio")
+	defer renameStudio(studioNames[studioIdxWithScene])
+
+	sceneQueryQ(t, sqb, "renamed studio", sceneIdxWithStudio)
+}
+
 func sceneQueryQ(t *testing.T, sqb models.SceneQueryBuilder, q string, expectedSceneIdx int) {
 	filter := models.FindFilterType{
 		Q: &q,
 	}
-	scenes, _ := sqb.Query(nil, &filter)
+	scenes, _, err := sqb.Query(nil, &filter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 	scene := scenes[0]
@@ -130,7 +177,8 @@ func sceneQueryQ(t *testing.T, sqb models.SceneQueryBuilder, q string, expectedS
 
 	// no Q should return all results
 	filter.Q = nil
-	scenes, _ = sqb.Query(nil, &filter)
+	scenes, _, err = sqb.Query(nil, &filter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, totalScenes)
 }
@@ -148,6 +196,76 @@ func TestSceneQueryPath(t *testing.T) {
 
 	pathCriterion.Modifier = models.CriterionModifierNotEquals
 	verifyScenesPath(t, pathCriterion)
+
+	pathCriterion.Value = `^scene_000[12]_Pa`
+	pathCriterion.Modifier = models.CriterionModifierMatchesRegex
+	verifyScenesPath(t, pathCriterion)
+
+	pathCriterion.Modifier = models.CriterionModifierNotMatchesRegex
+	verifyScenesPath(t, pathCriterion)
+}
+
+func TestSceneQueryTitleRegex(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+	titleCriterion := models.StringCriterionInput{
+		Value:    `(?i)^SCENE_000[12]_title$`,
+		Modifier: models.CriterionModifierMatchesRegex,
+	}
+	sceneFilter := models.SceneFilterType{
+		Title: &titleCriterion,
+	}
+
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.Len(t, scenes, 2)
+	for _, scene := range scenes {
+		verifyNullString(t, scene.Title, titleCriterion)
+	}
+
+	titleCriterion.Modifier = models.CriterionModifierNotMatchesRegex
+	scenes, _, err = sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, scenes)
+	for _, scene := range scenes {
+		verifyNullString(t, scene.Title, titleCriterion)
+	}
+}
+
+func TestSceneQueryAllByPathOrTitleRegex(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	// one scene matches by path and the other by title
+	regex := getSceneStringValue(1, "PATH") + "|" + getSceneStringValue(2, "title")
+	scenes, err := sqb.QueryAllByPathOrTitleRegex(regex, false)
+	if err != nil {
+		t.Fatalf("Error querying scenes: %s", err.Error())
+	}
+
+	assert.Len(t, scenes, 2)
+	for _, scene := range scenes {
+		assert.Contains(t, []string{getSceneStringValue(1, "Path"), getSceneStringValue(2, "Path")}, scene.Path)
+	}
+}
+
+func TestSceneQueryInvalidRegex(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+	pathCriterion := models.StringCriterionInput{
+		Value:    `scene_(`,
+		Modifier: models.CriterionModifierMatchesRegex,
+	}
+
+	_, _, err := sqb.Query(&models.SceneFilterType{
+		Path: &pathCriterion,
+	}, nil)
+	assert.NotNil(t, err)
+
+	// invalid expressions in sub-filters are reported
+	_, _, err = sqb.Query(&models.SceneFilterType{
+		Not: &models.SceneFilterType{
+			Path: &pathCriterion,
+		},
+	}, nil)
+	assert.NotNil(t, err)
 }
 
 func verifyScenesPath(t *testing.T, pathCriterion models.StringCriterionInput) {
@@ -156,7 +274,8 @@ func verifyScenesPath(t *testing.T, pathCriterion models.StringCriterionInput) {
 		Path: &pathCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	for _, scene := range scenes {
 		verifyString(t, scene.Path, pathCriterion)
@@ -178,6 +297,12 @@ func verifyNullString(t *testing.T, value sql.NullString, criterion models.Strin
 	if criterion.Modifier == models.CriterionModifierNotEquals {
 		assert.NotEqual(criterion.Value, value.String)
 	}
+	if criterion.Modifier == models.CriterionModifierMatchesRegex {
+		assert.Regexp(regexp.MustCompile(criterion.Value), value.String)
+	}
+	if criterion.Modifier == models.CriterionModifierNotMatchesRegex {
+		assert.NotRegexp(regexp.MustCompile(criterion.Value), value.String)
+	}
 }
 
 func verifyString(t *testing.T, value string, criterion models.StringCriterionInput) {
@@ -189,6 +314,12 @@ func verifyString(t *testing.T, value string, criterion models.StringCriterionIn
 	if criterion.Modifier == models.CriterionModifierNotEquals {
 		assert.NotEqual(criterion.Value, value)
 	}
+	if criterion.Modifier == models.CriterionModifierMatchesRegex {
+		assert.Regexp(regexp.MustCompile(criterion.Value), value)
+	}
+	if criterion.Modifier == models.CriterionModifierNotMatchesRegex {
+		assert.NotRegexp(regexp.MustCompile(criterion.Value), value)
+	}
 }
 
 func TestSceneQueryRating(t *testing.T) {
@@ -222,13 +353,61 @@ func verifyScenesRating(t *testing.T, ratingCriterion models.IntCriterionInput)
 		Rating: &ratingCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+
+	if ratingCriterion.Modifier == models.CriterionModifierEquals {
+		assert.NotEmpty(t, scenes)
+	}
+
+	for _, scene := range scenes {
+		verifyInt64(t, getRating5(scene.Rating), ratingCriterion)
+	}
+}
+
+func TestSceneQueryRating100(t *testing.T) {
+	value2 := 80
+	ratingCriterion := models.IntCriterionInput{
+		Value:    40,
+		Value2:   &value2,
+		Modifier: models.CriterionModifierBetween,
+	}
+
+	verifyScenesRating100(t, ratingCriterion)
+
+	ratingCriterion.Modifier = models.CriterionModifierNotBetween
+	verifyScenesRating100(t, ratingCriterion)
+
+	ratingCriterion.Modifier = models.CriterionModifierGreaterThan
+	verifyScenesRating100(t, ratingCriterion)
+
+	ratingCriterion.Modifier = models.CriterionModifierLessThan
+	verifyScenesRating100(t, ratingCriterion)
+}
+
+func verifyScenesRating100(t *testing.T, ratingCriterion models.IntCriterionInput) {
+	sqb := models.NewSceneQueryBuilder()
+	sceneFilter := models.SceneFilterType{
+		Rating100: &ratingCriterion,
+	}
+
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, scenes)
 
 	for _, scene := range scenes {
 		verifyInt64(t, scene.Rating, ratingCriterion)
 	}
 }
 
+// getRating5 returns the legacy 1-5 rating of a rating on the 1-100 scale.
+func getRating5(rating sql.NullInt64) sql.NullInt64 {
+	if rating.Valid {
+		rating.Int64 = int64(models.Rating100To5(int(rating.Int64)))
+	}
+	return rating
+}
+
 func verifyInt64(t *testing.T, value sql.NullInt64, criterion models.IntCriterionInput) {
 	t.Helper()
 	assert := assert.New(t)
@@ -250,6 +429,12 @@ func verifyInt64(t *testing.T, value sql.NullInt64, criterion models.IntCriterio
 	if criterion.Modifier == models.CriterionModifierLessThan {
 		assert.True(value.Int64 < int64(criterion.Value))
 	}
+	if criterion.Modifier == models.CriterionModifierBetween {
+		assert.True(value.Int64 >= int64(criterion.Value) && value.Int64 <= int64(*criterion.Value2))
+	}
+	if criterion.Modifier == models.CriterionModifierNotBetween {
+		assert.True(value.Int64 < int64(criterion.Value) || value.Int64 > int64(*criterion.Value2))
+	}
 }
 
 func TestSceneQueryOCounter(t *testing.T) {
@@ -277,7 +462,8 @@ func verifyScenesOCounter(t *testing.T, oCounterCriterion models.IntCriterionInp
 		OCounter: &oCounterCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	for _, scene := range scenes {
 		verifyInt(t, scene.OCounter, oCounterCriterion)
@@ -332,7 +518,8 @@ func verifyScenesDuration(t *testing.T, durationCriterion models.IntCriterionInp
 		Duration: &durationCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	for _, scene := range scenes {
 		if durationCriterion.Modifier == models.CriterionModifierEquals {
@@ -382,7 +569,8 @@ func verifyScenesResolution(t *testing.T, resolution models.ResolutionEnum) {
 		Resolution: &resolution,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	for _, scene := range scenes {
 		verifySceneResolution(t, scene.Height, resolution)
@@ -419,17 +607,20 @@ func TestSceneQueryHasMarkers(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err := sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 	assert.Equal(t, sceneIDs[sceneIdxWithMarker], scenes[0].ID)
 
 	hasMarkers = "false"
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, scenes, 0)
 
 	findFilter.Q = nil
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.NotEqual(t, 0, len(scenes))
 
@@ -451,12 +642,14 @@ func TestSceneQueryIsMissingGallery(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err := sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 0)
 
 	findFilter.Q = nil
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with gallery
 	for _, scene := range scenes {
@@ -476,12 +669,14 @@ func TestSceneQueryIsMissingStudio(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err := sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 0)
 
 	findFilter.Q = nil
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with studio
 	for _, scene := range scenes {
@@ -501,12 +696,14 @@ func TestSceneQueryIsMissingMovies(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err := sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 0)
 
 	findFilter.Q = nil
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with movies
 	for _, scene := range scenes {
@@ -526,12 +723,14 @@ func TestSceneQueryIsMissingPerformers(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err := sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 0)
 
 	findFilter.Q = nil
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.True(t, len(scenes) > 0)
 
@@ -541,6 +740,61 @@ func TestSceneQueryIsMissingPerformers(t *testing.T) {
 	}
 }
 
+func TestSceneQueryPerformerAge(t *testing.T) {
+	jqb := models.NewJoinsQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	const name = "TestSceneQueryPerformerAge"
+	performer := models.NewPerformer(name)
+	performer.Birthdate = models.SQLiteDate{String: "1990-05-17", Valid: true}
+	createdPerformer := f.performer(*performer)
+
+	// the day before the 30th birthday of the performer
+	created := f.scene(models.Scene{
+		Path: name,
+		Date: models.SQLiteDate{String: "2020-05-16", Valid: true},
+	})
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return jqb.CreatePerformersScenes([]models.PerformersScenes{{PerformerID: createdPerformer.ID, SceneID: created.ID}}, tx)
+	})
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return jqb.DestroyPerformersScenes(created.ID, tx)
+	})
+
+	qb := models.NewSceneQueryBuilder()
+	verify := func(modifier models.CriterionModifier, value int, match bool) {
+		t.Helper()
+		q := name
+		sceneFilter := models.SceneFilterType{
+			PerformerAge: &models.IntCriterionInput{
+				Modifier: modifier,
+				Value:    value,
+			},
+		}
+
+		scenes, _, err := qb.Query(&sceneFilter, &models.FindFilterType{Q: &q})
+		if err != nil {
+			t.Fatalf("Error querying scenes: %s", err.Error())
+		}
+
+		if match {
+			if assert.Len(t, scenes, 1) {
+				assert.Equal(t, created.ID, scenes[0].ID)
+			}
+		} else {
+			assert.Len(t, scenes, 0)
+		}
+	}
+
+	verify(models.CriterionModifierEquals, 29, true)
+	verify(models.CriterionModifierEquals, 30, false)
+	verify(models.CriterionModifierGreaterThan, 28, true)
+	verify(models.CriterionModifierLessThan, 29, false)
+}
+
 func TestSceneQueryIsMissingDate(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
 	isMissing := "date"
@@ -548,7 +802,8 @@ func TestSceneQueryIsMissingDate(t *testing.T) {
 		IsMissing: &isMissing,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.True(t, len(scenes) > 0)
 
@@ -570,12 +825,14 @@ func TestSceneQueryIsMissingTags(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err := sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 0)
 
 	findFilter.Q = nil
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.True(t, len(scenes) > 0)
 }
@@ -587,7 +844,8 @@ func TestSceneQueryIsMissingRating(t *testing.T) {
 		IsMissing: &isMissing,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.True(t, len(scenes) > 0)
 
@@ -611,7 +869,8 @@ func TestSceneQueryPerformers(t *testing.T) {
 		Performers: &performerCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 2)
 
@@ -628,7 +887,8 @@ func TestSceneQueryPerformers(t *testing.T) {
 		Modifier: models.CriterionModifierIncludesAll,
 	}
 
-	scenes, _ = sqb.Query(&sceneFilter, nil)
+	scenes, _, err = sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 	assert.Equal(t, sceneIDs[sceneIdxWithTwoPerformers], scenes[0].ID)
@@ -645,13 +905,14 @@ func TestSceneQueryPerformers(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, scenes, 0)
 }
 
 func TestSceneQueryTags(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
-	tagCriterion := models.MultiCriterionInput{
+	tagCriterion := models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(tagIDs[tagIdxWithScene]),
 			strconv.Itoa(tagIDs[tagIdx1WithScene]),
@@ -663,7 +924,8 @@ func TestSceneQueryTags(t *testing.T) {
 		Tags: &tagCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 2)
 
@@ -672,7 +934,7 @@ func TestSceneQueryTags(t *testing.T) {
 		assert.True(t, scene.ID == sceneIDs[sceneIdxWithTag] || scene.ID == sceneIDs[sceneIdxWithTwoTags])
 	}
 
-	tagCriterion = models.MultiCriterionInput{
+	tagCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(tagIDs[tagIdx1WithScene]),
 			strconv.Itoa(tagIDs[tagIdx2WithScene]),
@@ -680,12 +942,13 @@ func TestSceneQueryTags(t *testing.T) {
 		Modifier: models.CriterionModifierIncludesAll,
 	}
 
-	scenes, _ = sqb.Query(&sceneFilter, nil)
+	scenes, _, err = sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 	assert.Equal(t, sceneIDs[sceneIdxWithTwoTags], scenes[0].ID)
 
-	tagCriterion = models.MultiCriterionInput{
+	tagCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(tagIDs[tagIdx1WithScene]),
 		},
@@ -697,13 +960,14 @@ func TestSceneQueryTags(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, scenes, 0)
 }
 
 func TestSceneQueryStudio(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
-	studioCriterion := models.MultiCriterionInput{
+	studioCriterion := models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(studioIDs[studioIdxWithScene]),
 		},
@@ -714,14 +978,15 @@ func TestSceneQueryStudio(t *testing.T) {
 		Studios: &studioCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 
 	// ensure id is correct
 	assert.Equal(t, sceneIDs[sceneIdxWithStudio], scenes[0].ID)
 
-	studioCriterion = models.MultiCriterionInput{
+	studioCriterion = models.HierarchicalMultiCriterionInput{
 		Value: []string{
 			strconv.Itoa(studioIDs[studioIdxWithScene]),
 		},
@@ -733,10 +998,103 @@ func TestSceneQueryStudio(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, scenes, 0)
 }
 
+func TestSceneQuerySubFilters(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	queryIDs := func(sceneFilter models.SceneFilterType, findFilter *models.FindFilterType) []int {
+		scenes, count, err := sqb.Query(&sceneFilter, findFilter)
+		assert.NoError(t, err)
+		assert.Len(t, scenes, count)
+
+		var ret []int
+		for _, s := range scenes {
+			ret = append(ret, s.ID)
+		}
+		return ret
+	}
+
+	idCriterion := func(modifier models.CriterionModifier, ids ...int) *models.MultiCriterionInput {
+		ret := &models.MultiCriterionInput{
+			Modifier: modifier,
+		}
+		for _, id := range ids {
+			ret.Value = append(ret.Value, strconv.Itoa(id))
+		}
+		return ret
+	}
+	hierarchicalCriterion := func(modifier models.CriterionModifier, ids ...int) *models.HierarchicalMultiCriterionInput {
+		c := idCriterion(modifier, ids...)
+		return &models.HierarchicalMultiCriterionInput{
+			Value:    c.Value,
+			Modifier: c.Modifier,
+		}
+	}
+
+	studioOrTags := models.SceneFilterType{
+		Studios: hierarchicalCriterion(models.CriterionModifierIncludes, studioIDs[studioIdxWithScene]),
+		Or: &models.SceneFilterType{
+			Tags: hierarchicalCriterion(models.CriterionModifierIncludes, tagIDs[tagIdxWithScene], tagIDs[tagIdx1WithScene]),
+		},
+	}
+	assert.ElementsMatch(t, []int{
+		sceneIDs[sceneIdxWithStudio],
+		sceneIDs[sceneIdxWithTag],
+		sceneIDs[sceneIdxWithTwoTags],
+	}, queryIDs(studioOrTags, nil))
+
+	// studio or tags, but not the other tag
+	studioOrTagsNotTag := studioOrTags
+	studioOrTagsNotTag.Not = &models.SceneFilterType{
+		Tags: hierarchicalCriterion(models.CriterionModifierIncludes, tagIDs[tagIdx2WithScene]),
+	}
+	assert.ElementsMatch(t, []int{
+		sceneIDs[sceneIdxWithStudio],
+		sceneIDs[sceneIdxWithTag],
+	}, queryIDs(studioOrTagsNotTag, nil))
+
+	// the sub-filters are combined with the search query
+	q := getSceneStringValue(sceneIdxWithTag, titleField)
+	assert.Equal(t, []int{sceneIDs[sceneIdxWithTag]}, queryIDs(studioOrTagsNotTag, &models.FindFilterType{
+		Q: &q,
+	}))
+
+	// nested sub-filters with having clauses
+	bothTags := models.SceneFilterType{
+		Tags: hierarchicalCriterion(models.CriterionModifierIncludesAll, tagIDs[tagIdx1WithScene]),
+		And: &models.SceneFilterType{
+			Tags: hierarchicalCriterion(models.CriterionModifierIncludesAll, tagIDs[tagIdx2WithScene]),
+			Or: &models.SceneFilterType{
+				Studios: hierarchicalCriterion(models.CriterionModifierIncludes, studioIDs[studioIdxWithScene]),
+			},
+		},
+	}
+	assert.Equal(t, []int{sceneIDs[sceneIdxWithTwoTags]}, queryIDs(bothTags, nil))
+
+	// an OR sub-filter of an empty filter matches only the sub-filter
+	assert.Equal(t, []int{sceneIDs[sceneIdxWithStudio]}, queryIDs(models.SceneFilterType{
+		Or: &models.SceneFilterType{
+			Studios: hierarchicalCriterion(models.CriterionModifierIncludes, studioIDs[studioIdxWithScene]),
+		},
+	}, nil))
+
+	// a NOT sub-filter of an empty filter excludes the matching scenes
+	perPage := totalScenes
+	notStudio := queryIDs(models.SceneFilterType{
+		Not: &models.SceneFilterType{
+			Studios: hierarchicalCriterion(models.CriterionModifierIncludes, studioIDs[studioIdxWithScene]),
+		},
+	}, &models.FindFilterType{
+		PerPage: &perPage,
+	})
+	assert.NotContains(t, notStudio, sceneIDs[sceneIdxWithStudio])
+	assert.Contains(t, notStudio, sceneIDs[sceneIdxWithTag])
+}
+
 func TestSceneQueryMovies(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
 	movieCriterion := models.MultiCriterionInput{
@@ -750,7 +1108,8 @@ func TestSceneQueryMovies(t *testing.T) {
 		Movies: &movieCriterion,
 	}
 
-	scenes, _ := sqb.Query(&sceneFilter, nil)
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 
@@ -769,7 +1128,8 @@ func TestSceneQueryMovies(t *testing.T) {
 		Q: &q,
 	}
 
-	scenes, _ = sqb.Query(&sceneFilter, &findFilter)
+	scenes, _, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, scenes, 0)
 }
 
@@ -782,7 +1142,8 @@ func TestSceneQuerySorting(t *testing.T) {
 	}
 
 	sqb := models.NewSceneQueryBuilder()
-	scenes, _ := sqb.Query(nil, &findFilter)
+	scenes, _, err := sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 
 	// scenes should be in same order as indexes
 	firstScene := scenes[0]
@@ -794,7 +1155,8 @@ func TestSceneQuerySorting(t *testing.T) {
 	// sort in descending order
 	direction = models.SortDirectionEnumDesc
 
-	scenes, _ = sqb.Query(nil, &findFilter)
+	scenes, _, err = sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 	firstScene = scenes[0]
 	lastScene = scenes[len(scenes)-1]
 
@@ -802,6 +1164,68 @@ func TestSceneQuerySorting(t *testing.T) {
 	assert.Equal(t, sceneIDs[0], lastScene.ID)
 }
 
+func TestSceneQueryMultiSorting(t *testing.T) {
+	desc := models.SortDirectionEnumDesc
+	perPage := totalScenes
+	findFilter := models.FindFilterType{
+		Sorts: []*models.SortFieldInput{
+			{Sort: "rating"},
+			{Sort: titleField, Direction: &desc},
+		},
+		PerPage: &perPage,
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scenes, _, err := sqb.Query(nil, &findFilter)
+	if err != nil {
+		t.Errorf("Error querying scenes: %s", err.Error())
+		return
+	}
+	assert.Len(t, scenes, totalScenes)
+
+	// scenes are in ascending order of rating, with null ratings first, and in
+	// descending order of title within each rating
+	for i := 1; i < len(scenes); i++ {
+		prev := scenes[i-1]
+		scene := scenes[i]
+		if prev.Rating == scene.Rating {
+			assert.True(t, prev.Title.String > scene.Title.String, "%s is not before %s", prev.Title.String, scene.Title.String)
+		} else {
+			assert.True(t, !prev.Rating.Valid || (scene.Rating.Valid && prev.Rating.Int64 < scene.Rating.Int64), "%v is not before %v", prev.Rating, scene.Rating)
+		}
+	}
+
+	// pages selected by cursor are in the same order
+	first := 5
+	findFilter.First = &first
+	var ids []int
+	for page := 0; page <= totalScenes/first; page++ {
+		results, _, err := sqb.Query(nil, &findFilter)
+		if err != nil {
+			t.Errorf("Error querying scenes: %s", err.Error())
+			return
+		}
+
+		for _, scene := range results {
+			ids = append(ids, scene.ID)
+		}
+
+		if len(results) == 0 {
+			break
+		}
+		findFilter.After = findFilter.NextCursor(len(results), results[len(results)-1].ID)
+		if findFilter.After == nil {
+			break
+		}
+	}
+
+	var expected []int
+	for _, scene := range scenes {
+		expected = append(expected, scene.ID)
+	}
+	assert.Equal(t, expected, ids)
+}
+
 func TestSceneQueryPagination(t *testing.T) {
 	perPage := 1
 	findFilter := models.FindFilterType{
@@ -809,7 +1233,8 @@ func TestSceneQueryPagination(t *testing.T) {
 	}
 
 	sqb := models.NewSceneQueryBuilder()
-	scenes, _ := sqb.Query(nil, &findFilter)
+	scenes, _, err := sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 
@@ -817,7 +1242,8 @@ func TestSceneQueryPagination(t *testing.T) {
 
 	page := 2
 	findFilter.Page = &page
-	scenes, _ = sqb.Query(nil, &findFilter)
+	scenes, _, err = sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, scenes, 1)
 	secondID := scenes[0].ID
@@ -826,12 +1252,102 @@ func TestSceneQueryPagination(t *testing.T) {
 	perPage = 2
 	page = 1
 
-	scenes, _ = sqb.Query(nil, &findFilter)
+	scenes, _, err = sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, scenes, 2)
 	assert.Equal(t, firstID, scenes[0].ID)
 	assert.Equal(t, secondID, scenes[1].ID)
 }
 
+func TestSceneQueryCursorPagination(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	for _, sort := range []string{"title", "rating", "date"} {
+		for _, direction := range []models.SortDirectionEnum{models.SortDirectionEnumAsc, models.SortDirectionEnumDesc} {
+			sort := sort
+			direction := direction
+			perPage := totalScenes
+			findFilter := models.FindFilterType{
+				Sort:      &sort,
+				Direction: &direction,
+				PerPage:   &perPage,
+			}
+
+			all, _, err := sqb.Query(nil, &findFilter)
+			if err != nil {
+				t.Errorf("Error querying scenes: %s", err.Error())
+				return
+			}
+
+			first := 5
+			findFilter.First = &first
+
+			// the number of pages is limited, so repeated pages fail the test
+			var ids []int
+			for page := 0; page <= totalScenes/first; page++ {
+				scenes, count, err := sqb.Query(nil, &findFilter)
+				if err != nil {
+					t.Errorf("Error querying scenes: %s", err.Error())
+					return
+				}
+				assert.Equal(t, totalScenes, count)
+
+				for _, scene := range scenes {
+					ids = append(ids, scene.ID)
+				}
+
+				if len(scenes) == 0 {
+					break
+				}
+				findFilter.After = findFilter.NextCursor(len(scenes), scenes[len(scenes)-1].ID)
+				if findFilter.After == nil {
+					break
+				}
+			}
+
+			// every scene is returned once. Scenes with equal sort values may be
+			// ordered differently by offset pages, so only titles, which are
+			// unique, are compared in order.
+			var found []int
+			for _, scene := range all {
+				found = append(found, scene.ID)
+			}
+			if sort == "title" {
+				assert.Equal(t, found, ids, "sort %s %s", sort, direction)
+			} else {
+				assert.ElementsMatch(t, found, ids, "sort %s %s", sort, direction)
+			}
+		}
+	}
+
+	invalid := "invalid"
+	_, _, err := sqb.Query(nil, &models.FindFilterType{
+		After: &invalid,
+	})
+	assert.NotNil(t, err)
+}
+
+func TestSceneFindByTagID(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	scenes, err := sqb.FindByTagID(tagIDs[tagIdxWithScene])
+
+	if err != nil {
+		t.Fatalf("error calling FindByTagID: %s", err.Error())
+	}
+
+	assert.Len(t, scenes, 1)
+	assert.Equal(t, sceneIDs[sceneIdxWithTag], scenes[0].ID)
+
+	scenes, err = sqb.FindByTagID(0)
+
+	if err != nil {
+		t.Fatalf("error calling FindByTagID: %s", err.Error())
+	}
+
+	assert.Len(t, scenes, 0)
+}
+
 func TestSceneCountByTagID(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
 
@@ -892,6 +1408,192 @@ func TestSceneCountByStudioID(t *testing.T) {
 	assert.Equal(t, 0, sceneCount)
 }
 
+func TestSceneCreateWithoutHashes(t *testing.T) {
+	qb := models.NewSceneQueryBuilder()
+
+	// a scene must have at least one hash, so the scan calculates the oshash
+	// of new files even when hashing is deferred
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	_, err := qb.Create(models.Scene{
+		Path: "/scenes/missing_hashes.mp4",
+	}, tx)
+	assert.NotNil(t, err)
+}
+
+func TestSceneFindMissingHashes(t *testing.T) {
+	qb := models.NewSceneQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	// scene with only an oshash to test against
+	const path = "/scenes/missing_checksum.mp4"
+	created := f.scene(models.Scene{
+		Path:   path,
+		OSHash: sql.NullString{String: utils.MD5FromString(path), Valid: true},
+	})
+
+	getIDs := func(scenes []*models.Scene) []int {
+		var ids []int
+		for _, s := range scenes {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	// the test scenes have no oshash
+	scenes, err := qb.FindMissingHashes(false)
+	if err != nil {
+		t.Fatalf("Error finding scenes missing hashes: %s", err.Error())
+	}
+	ids := getIDs(scenes)
+	assert.Contains(t, ids, sceneIDs[0])
+	assert.NotContains(t, ids, created.ID)
+
+	scenes, err = qb.FindMissingHashes(true)
+	if err != nil {
+		t.Fatalf("Error finding scenes missing hashes: %s", err.Error())
+	}
+	ids = getIDs(scenes)
+	assert.Contains(t, ids, sceneIDs[0])
+	assert.Contains(t, ids, created.ID)
+}
+
+func TestSceneFindReleasedOnMonthDays(t *testing.T) {
+	qb := models.NewSceneQueryBuilder()
+
+	// the dated test scenes are released on 2001-02-03
+	scenes, err := qb.FindReleasedOnMonthDays([]string{"02-03"}, "2002-01-01")
+	if err != nil {
+		t.Fatalf("Error finding scenes: %s", err.Error())
+	}
+
+	var ids []int
+	for _, scene := range scenes {
+		ids = append(ids, scene.ID)
+	}
+	assert.Contains(t, ids, sceneIDs[3])
+	assert.NotContains(t, ids, sceneIDs[0])
+
+	// scenes released in or after the year are not returned
+	scenes, err = qb.FindReleasedOnMonthDays([]string{"02-03"}, "2001-01-01")
+	if err != nil {
+		t.Fatalf("Error finding scenes: %s", err.Error())
+	}
+	assert.Len(t, scenes, 0)
+
+	scenes, err = qb.FindReleasedOnMonthDays([]string{"02-04"}, "2002-01-01")
+	if err != nil {
+		t.Fatalf("Error finding scenes: %s", err.Error())
+	}
+	assert.Len(t, scenes, 0)
+}
+
+func TestSceneFindUnderPath(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	dir := filepath.Join("underpath", "dir")
+	paths := []string{
+		filepath.Join(dir, "a.mp4"),
+		filepath.Join(dir, "sub", "b.mp4"),
+		filepath.Join("underpath", "dir2", "c.mp4"),
+	}
+
+	var ids []int
+	for _, path := range paths {
+		ids = append(ids, f.scene(models.Scene{Path: path}).ID)
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	findIDs := func(path string) []int {
+		scenes, err := qb.FindUnderPath(path)
+		if err != nil {
+			t.Fatalf("Error finding scenes: %s", err.Error())
+		}
+
+		var ret []int
+		for _, scene := range scenes {
+			ret = append(ret, scene.ID)
+		}
+		return ret
+	}
+
+	assert.ElementsMatch(t, ids[:2], findIDs(dir))
+	assert.ElementsMatch(t, ids[1:2], findIDs(paths[1]))
+	assert.Len(t, findIDs(filepath.Join("underpath", "di")), 0)
+}
+
+func TestSceneFindMissingDate(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	scenes, err := sqb.FindMissingDate()
+	if err != nil {
+		t.Fatalf("Error finding scenes missing dates: %s", err.Error())
+	}
+
+	assert.True(t, len(scenes) > 0)
+
+	var ids []int
+	for _, scene := range scenes {
+		assert.False(t, scene.Date.Valid)
+		ids = append(ids, scene.ID)
+	}
+
+	// the test scene dates are null, empty, "0001-01-01" or a date in turn
+	assert.Contains(t, ids, sceneIDs[0])
+	assert.Contains(t, ids, sceneIDs[1])
+	assert.Contains(t, ids, sceneIDs[2])
+	assert.NotContains(t, ids, sceneIDs[3])
+}
+
+func TestSceneFindByStashID(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	sceneID := sceneIDs[0]
+	stashID := models.StashID{
+		StashID:  "scene-stash-id",
+		Endpoint: "https://stash-box.example.com/graphql",
+	}
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return jqb.UpdateSceneStashIDs(sceneID, []models.StashID{stashID}, tx)
+	})
+	defer withTxn(t, func(tx *sqlx.Tx) error {
+		return jqb.UpdateSceneStashIDs(sceneID, nil, tx)
+	})
+
+	scenes, err := sqb.FindByStashID(stashID)
+	if err != nil {
+		t.Fatalf("Error finding scenes by stash id: %s", err.Error())
+	}
+	if assert.Len(t, scenes, 1) {
+		assert.Equal(t, sceneID, scenes[0].ID)
+	}
+
+	// stash ids are matched by endpoint
+	scenes, err = sqb.FindByStashID(models.StashID{
+		StashID:  stashID.StashID,
+		Endpoint: "https://other.example.com/graphql",
+	})
+	if err != nil {
+		t.Fatalf("Error finding scenes by stash id: %s", err.Error())
+	}
+	assert.Len(t, scenes, 0)
+
+	scraped := models.ScrapedScene{
+		RemoteSiteID: &stashID.StashID,
+	}
+	if err := models.MatchScrapedSceneStashID(&scraped, stashID.Endpoint); err != nil {
+		t.Fatalf("Error matching scraped scene stash id: %s", err.Error())
+	}
+	assert.Equal(t, []string{strconv.Itoa(sceneID)}, scraped.DuplicateSceneIDs)
+}
+
 func TestFindByMovieID(t *testing.T) {
 	sqb := models.NewSceneQueryBuilder()
 
@@ -1053,6 +1755,197 @@ func TestSceneDestroySceneCover(t *testing.T) {
 	assert.Nil(t, storedImage)
 }
 
+func TestSceneUpdateChapters(t *testing.T) {
+	qb := models.NewSceneQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.scene(models.Scene{Path: "TestSceneUpdateChapters"})
+
+	chapters := []models.SceneChapter{
+		{
+			Seconds:    0,
+			EndSeconds: sql.NullFloat64{Float64: 90.5, Valid: true},
+			Title:      "Opening",
+		},
+		{
+			Seconds: 90.5,
+			Title:   "Second",
+		},
+	}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return qb.UpdateChapters(created.ID, chapters, tx)
+	})
+
+	stored, err := qb.GetChapters(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting chapters: %s", err.Error())
+	}
+
+	if assert.Len(t, stored, 2) {
+		assert.Equal(t, "Opening", stored[0].Title)
+		assert.Equal(t, 90.5, stored[0].EndSeconds.Float64)
+		assert.Equal(t, 1, stored[1].Position)
+		assert.Equal(t, 90.5, stored[1].Seconds)
+		assert.False(t, stored[1].EndSeconds.Valid)
+	}
+
+	assert.Contains(t, sceneIDsWithChapters(t), created.ID)
+
+	// updating with no chapters removes the stored chapters
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return qb.UpdateChapters(created.ID, nil, tx)
+	})
+
+	stored, err = qb.GetChapters(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting chapters: %s", err.Error())
+	}
+	assert.Len(t, stored, 0)
+	assert.NotContains(t, sceneIDsWithChapters(t), created.ID)
+}
+
+func sceneIDsWithChapters(t *testing.T) []int {
+	qb := models.NewSceneQueryBuilder()
+	scenes, err := qb.FindWithChapters()
+	if err != nil {
+		t.Fatalf("Error finding scenes with chapters: %s", err.Error())
+	}
+
+	var ret []int
+	for _, s := range scenes {
+		ret = append(ret, s.ID)
+	}
+	return ret
+}
+
+func TestSceneUpdateCaptions(t *testing.T) {
+	qb := models.NewSceneQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	const name = "TestSceneUpdateCaptions"
+	created := f.scene(models.Scene{Path: "/videos/" + name + ".mp4"})
+
+	assert.True(t, isSceneMissingCaptions(t, name))
+
+	captions := []models.SceneCaption{
+		{
+			Filename:    name + ".srt",
+			CaptionType: "srt",
+		},
+		{
+			Filename:     name + ".en.vtt",
+			LanguageCode: "en",
+			CaptionType:  "vtt",
+		},
+	}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return qb.UpdateCaptions(created.ID, captions, tx)
+	})
+
+	stored, err := qb.GetCaptions(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting captions: %s", err.Error())
+	}
+
+	// captions are ordered by file name
+	if assert.Len(t, stored, 2) {
+		assert.Equal(t, name+".en.vtt", stored[0].Filename)
+		assert.Equal(t, "en", stored[0].LanguageCode)
+		assert.Equal(t, "vtt", stored[0].CaptionType)
+		assert.Equal(t, "", stored[1].LanguageCode)
+		assert.Equal(t, "/videos/"+name+".srt", stored[1].Path(created.Path))
+	}
+
+	assert.False(t, isSceneMissingCaptions(t, name))
+
+	// updating with no captions removes the stored captions
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return qb.UpdateCaptions(created.ID, nil, tx)
+	})
+
+	stored, err = qb.GetCaptions(created.ID, nil)
+	if err != nil {
+		t.Fatalf("Error getting captions: %s", err.Error())
+	}
+	assert.Len(t, stored, 0)
+	assert.True(t, isSceneMissingCaptions(t, name))
+}
+
+// isSceneMissingCaptions returns true if the scenes matching q are found by
+// the captions is missing filter.
+func isSceneMissingCaptions(t *testing.T, q string) bool {
+	qb := models.NewSceneQueryBuilder()
+	isMissing := "captions"
+	sceneFilter := models.SceneFilterType{
+		IsMissing: &isMissing,
+	}
+	findFilter := models.FindFilterType{
+		Q: &q,
+	}
+
+	scenes, _, err := qb.Query(&sceneFilter, &findFilter)
+	if err != nil {
+		t.Fatalf("Error querying scenes: %s", err.Error())
+	}
+	return len(scenes) > 0
+}
+
+func TestSceneQueryDisplayTitle(t *testing.T) {
+	if err := utils.SetDisplayTitleCleanup([]string{`[._]`, `(?i)\b1080p\b`}); err != nil {
+		t.Fatalf("Error setting display title cleanup: %s", err.Error())
+	}
+	defer utils.SetDisplayTitleCleanup(nil)
+
+	qb := models.NewSceneQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	// untitled scene to test against
+	const path = "/scenes/Display_Title.Untitled.1080p.mp4"
+	created := f.scene(models.Scene{Path: path})
+
+	assert.Equal(t, "Display Title Untitled", utils.DisplayTitle("", path))
+
+	q := `"Title Untitled"`
+	scenes, _, err := qb.Query(nil, &models.FindFilterType{
+		Q: &q,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, scenes, 1) {
+		assert.Equal(t, created.ID, scenes[0].ID)
+	}
+
+	// the untitled scene sorts by its display title before the titled scenes
+	sort := "display_title"
+	direction := models.SortDirectionEnumAsc
+	scenes, _, err = qb.Query(nil, &models.FindFilterType{
+		Sort:      &sort,
+		Direction: &direction,
+	})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, scenes) {
+		assert.Equal(t, created.ID, scenes[0].ID)
+	}
+
+	// the display title is no longer found once the search documents are
+	// rebuilt without the cleanup expressions
+	if err := utils.SetDisplayTitleCleanup(nil); err != nil {
+		t.Fatalf("Error setting display title cleanup: %s", err.Error())
+	}
+	withTxn(t, qb.RebuildSearchDocuments)
+
+	scenes, _, err = qb.Query(nil, &models.FindFilterType{
+		Q: &q,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, scenes, 0)
+}
+
 // TODO Update
 // TODO IncrementOCounter
 // TODO DecrementOCounter
@@ -1062,3 +1955,435 @@ func TestSceneDestroySceneCover(t *testing.T) {
 // TODO Count
 // TODO SizeCount
 // TODO All
+
+func TestSceneFindByPhashDistance(t *testing.T) {
+	const basePhash = int64(0x0f0f0f0f0f0f0f0f)
+	phashes := []int64{
+		basePhash,
+		basePhash ^ 0x1,  // distance 1
+		basePhash ^ 0x7,  // distance 3
+		basePhash ^ 0xff, // distance 8
+	}
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	var createdIDs []int
+	for i, phash := range phashes {
+		created := f.scene(models.Scene{
+			Path:  "TestSceneFindByPhashDistance_" + strconv.Itoa(i),
+			Phash: sql.NullInt64{Int64: phash, Valid: true},
+		})
+		createdIDs = append(createdIDs, created.ID)
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	ids, distances, err := sqb.FindByPhashDistance(basePhash, 4)
+	if err != nil {
+		t.Fatalf("Error finding scenes by phash distance: %s", err.Error())
+	}
+
+	assert.Equal(t, createdIDs[0:3], ids)
+	assert.Equal(t, []int{0, 1, 3}, distances)
+
+	ids, _, err = sqb.FindByPhashDistance(basePhash, 0)
+	if err != nil {
+		t.Fatalf("Error finding scenes by phash distance: %s", err.Error())
+	}
+
+	assert.Equal(t, createdIDs[0:1], ids)
+}
+
+func TestSceneFindByTitleMismatch(t *testing.T) {
+	scenes := []struct {
+		title string
+		path  string
+	}{
+		{"abcd", "/TestSceneFindByTitleMismatch/abcd.mp4"},
+		{"abcd", "/TestSceneFindByTitleMismatch/abce.mp4"}, // distance 0.25
+		{"abcd", "/TestSceneFindByTitleMismatch/wxyz.mp4"}, // distance 1
+	}
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	var createdIDs []int
+	for _, s := range scenes {
+		created := f.scene(models.Scene{
+			Path:  s.path,
+			Title: sql.NullString{String: s.title, Valid: true},
+		})
+		createdIDs = append(createdIDs, created.ID)
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	ids, distances, err := sqb.FindByTitleMismatch(0.2)
+	if err != nil {
+		t.Fatalf("Error finding scenes by title mismatch: %s", err.Error())
+	}
+
+	var found []int
+	var foundDistances []float64
+	for i, id := range ids {
+		if id == createdIDs[0] || id == createdIDs[1] || id == createdIDs[2] {
+			found = append(found, id)
+			foundDistances = append(foundDistances, distances[i])
+		}
+	}
+
+	assert.Equal(t, []int{createdIDs[2], createdIDs[1]}, found)
+	assert.InDeltaSlice(t, []float64{1, 0.25}, foundDistances, 0.001)
+}
+
+func TestSceneQueryFileCreationTime(t *testing.T) {
+	creationTimes := []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	var createdIDs []int
+	for i, creationTime := range creationTimes {
+		created := f.scene(models.Scene{
+			Path: "TestSceneQueryFileCreationTime_" + strconv.Itoa(i),
+			FileCreationTime: models.NullSQLiteTimestamp{
+				Timestamp: creationTime,
+				Valid:     true,
+			},
+		})
+		createdIDs = append(createdIDs, created.ID)
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	sceneFilter := models.SceneFilterType{
+		FileCreationTime: &models.TimestampCriterionInput{
+			Value:    "2020-01-01",
+			Modifier: models.CriterionModifierGreaterThan,
+		},
+	}
+
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.Len(t, scenes, 1)
+	assert.Equal(t, createdIDs[1], scenes[0].ID)
+
+	sceneFilter.FileCreationTime.Modifier = models.CriterionModifierLessThan
+	scenes, _, err = sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.Len(t, scenes, 1)
+	assert.Equal(t, createdIDs[0], scenes[0].ID)
+
+	// dates are interpreted in the configured timezone
+	if err := utils.SetTimezone("Australia/Brisbane"); err != nil {
+		t.Fatalf("Error setting timezone: %s", err.Error())
+	}
+	defer utils.SetTimezone("")
+
+	sceneFilter.FileCreationTime.Value = "2021-01-01"
+	sceneFilter.FileCreationTime.Modifier = models.CriterionModifierGreaterThan
+	scenes, _, err = sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.Len(t, scenes, 1)
+	assert.Equal(t, createdIDs[1], scenes[0].ID)
+}
+
+func TestSceneUpdateFileCreationTime(t *testing.T) {
+	qb := models.NewSceneQueryBuilder()
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	created := f.scene(models.Scene{Path: "TestSceneUpdateFileCreationTime"})
+
+	creationTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return qb.UpdateFileCreationTime(created.ID, models.NullSQLiteTimestamp{
+			Timestamp: creationTime,
+			Valid:     true,
+		}, tx)
+	})
+
+	scene, err := qb.Find(created.ID)
+	if err != nil {
+		t.Fatalf("Error finding scene: %s", err.Error())
+	}
+
+	assert.True(t, scene.FileCreationTime.Valid)
+	assert.True(t, creationTime.Equal(scene.FileCreationTime.Timestamp))
+}
+
+func TestSceneQueryTimeRanges(t *testing.T) {
+	times := []time.Time{
+		time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	var createdIDs []int
+	for i, tm := range times {
+		created := f.scene(models.Scene{
+			Path:      "TestSceneQueryTimeRanges_" + strconv.Itoa(i),
+			Date:      models.SQLiteDate{String: tm.Format("2006-01-02"), Valid: true},
+			CreatedAt: models.SQLiteTimestamp{Timestamp: tm},
+			UpdatedAt: models.SQLiteTimestamp{Timestamp: tm.AddDate(0, 1, 0)},
+		})
+		createdIDs = append(createdIDs, created.ID)
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	queryIDs := func(sceneFilter models.SceneFilterType) []int {
+		sceneFilter.Path = &models.StringCriterionInput{
+			Value:    "^TestSceneQueryTimeRanges_",
+			Modifier: models.CriterionModifierMatchesRegex,
+		}
+		scenes, _, err := sqb.Query(&sceneFilter, nil)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, scene := range scenes {
+			ids = append(ids, scene.ID)
+		}
+		return ids
+	}
+
+	value2 := "2020-01-01"
+	assert.Equal(t, []int{createdIDs[0]}, queryIDs(models.SceneFilterType{
+		CreatedAt: &models.TimestampCriterionInput{
+			Value:    "2019-01-01",
+			Value2:   &value2,
+			Modifier: models.CriterionModifierBetween,
+		},
+	}))
+
+	assert.Equal(t, []int{createdIDs[1]}, queryIDs(models.SceneFilterType{
+		UpdatedAt: &models.TimestampCriterionInput{
+			Value:    "2021-07-01T00:00:00Z",
+			Modifier: models.CriterionModifierEquals,
+		},
+	}))
+
+	value2 = "2021-06-01"
+	assert.Equal(t, []int{createdIDs[1]}, queryIDs(models.SceneFilterType{
+		Date: &models.DateCriterionInput{
+			Value:    "2021-01-01",
+			Value2:   &value2,
+			Modifier: models.CriterionModifierBetween,
+		},
+	}))
+
+	assert.Equal(t, []int{createdIDs[0]}, queryIDs(models.SceneFilterType{
+		Date: &models.DateCriterionInput{
+			Value:    "2021-01-01",
+			Value2:   &value2,
+			Modifier: models.CriterionModifierNotBetween,
+		},
+	}))
+
+	assert.Equal(t, []int{createdIDs[0]}, queryIDs(models.SceneFilterType{
+		Date: &models.DateCriterionInput{
+			Value:    "2020-01-01",
+			Modifier: models.CriterionModifierLessThan,
+		},
+	}))
+}
+
+func TestSceneQueryPlan(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+
+	plan, err := sqb.QueryPlan(nil, nil)
+	if err != nil {
+		t.Fatalf("Error getting query plan: %s", err.Error())
+	}
+
+	assert.NotEmpty(t, plan)
+}
+
+func TestSceneQueryApproximateCount(t *testing.T) {
+	models.SetApproximateCounts(true)
+	defer models.SetApproximateCounts(false)
+
+	sqb := models.NewSceneQueryBuilder()
+
+	exactCount := true
+	findFilter := models.FindFilterType{
+		ExactCount: &exactCount,
+	}
+
+	// unfiltered counts are read from the table counts
+	_, exact, err := sqb.Query(nil, &findFilter)
+	assert.NoError(t, err)
+	_, approximate, err := sqb.Query(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, exact, approximate)
+
+	sceneFilter := models.SceneFilterType{
+		Rating: &models.IntCriterionInput{
+			Modifier: models.CriterionModifierNotNull,
+		},
+	}
+
+	// filtered counts stop at the limit, and are flagged as approximate
+	const limit = 2
+	defer models.SetApproximateCountLimit(models.SetApproximateCountLimit(limit))
+
+	_, exact, err = sqb.Query(&sceneFilter, &findFilter)
+	assert.NoError(t, err)
+	if exact <= limit {
+		t.Fatalf("expected more than %d rated scenes, got %d", limit, exact)
+	}
+	assert.False(t, findFilter.IsCountApproximate(exact))
+
+	_, approximate, err = sqb.Query(&sceneFilter, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, limit, approximate)
+	assert.NotEqual(t, exact, approximate)
+
+	var defaultFilter *models.FindFilterType
+	assert.True(t, defaultFilter.IsCountApproximate(approximate))
+}
+
+func TestSceneLowestQuality(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	studio := f.studio(models.Studio{Name: sql.NullString{String: "TestSceneLowestQuality", Valid: true}})
+	performer := f.performer(models.Performer{
+		Name:     sql.NullString{String: "TestSceneLowestQuality", Valid: true},
+		Favorite: sql.NullBool{Bool: true, Valid: true},
+	})
+
+	// scenes of increasing quality
+	sqb := models.NewSceneQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	var ids []int
+	for _, height := range []int64{480, 1080, 2160} {
+		scene := f.scene(models.Scene{
+			Path:       "TestSceneLowestQuality" + strconv.FormatInt(height, 10),
+			Height:     sql.NullInt64{Int64: height, Valid: true},
+			VideoCodec: sql.NullString{String: "h264", Valid: true},
+			StudioID:   sql.NullInt64{Int64: int64(studio.ID), Valid: true},
+		})
+		ids = append(ids, scene.ID)
+	}
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for _, id := range ids {
+			if err := jqb.CreatePerformersScenes([]models.PerformersScenes{{PerformerID: performer.ID, SceneID: id}}, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		for _, id := range ids {
+			if err := jqb.DestroyPerformersScenes(id, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	findGroup := func(groups []*models.SceneGroup, id int) []int {
+		for _, g := range groups {
+			if g.ID == id {
+				return g.SceneIDs
+			}
+		}
+		return nil
+	}
+
+	groups, err := sqb.FindLowestQualityByFavoritePerformer(2, nil)
+	if err != nil {
+		t.Fatalf("Error finding performer scenes: %s", err.Error())
+	}
+	assert.Equal(t, ids[:2], findGroup(groups, performer.ID))
+
+	groups, err = sqb.FindLowestQualityByStudio(5, nil)
+	if err != nil {
+		t.Fatalf("Error finding studio scenes: %s", err.Error())
+	}
+	assert.Equal(t, ids, findGroup(groups, studio.ID))
+
+	// only scenes up to the maximum score are returned
+	maxScore := utils.QualityScore(0, 1080, 0, 0, "h264")
+	groups, err = sqb.FindLowestQualityByStudio(5, &maxScore)
+	if err != nil {
+		t.Fatalf("Error finding studio scenes: %s", err.Error())
+	}
+	assert.Equal(t, ids[:2], findGroup(groups, studio.ID))
+
+	// scenes are sorted by score
+	sort := "quality_score"
+	direction := models.SortDirectionEnumDesc
+	studioCriterion := models.HierarchicalMultiCriterionInput{
+		Value:    []string{strconv.Itoa(studio.ID)},
+		Modifier: models.CriterionModifierIncludes,
+	}
+	scenes, _, err := sqb.Query(&models.SceneFilterType{Studios: &studioCriterion}, &models.FindFilterType{Sort: &sort, Direction: &direction})
+	if err != nil {
+		t.Fatalf("Error querying scenes: %s", err.Error())
+	}
+	var sorted []int
+	for _, s := range scenes {
+		sorted = append(sorted, s.ID)
+	}
+	assert.Equal(t, []int{ids[2], ids[1], ids[0]}, sorted)
+}
+
+func TestSceneQueryLibrary(t *testing.T) {
+	const name = "TestSceneQueryLibrary"
+	paths := []string{
+		"/" + name + "/a/scene.mp4",
+		"/" + name + "/a/sub/scene.mp4",
+		"/" + name + "/ab/scene.mp4",
+	}
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	var createdIDs []int
+	for _, path := range paths {
+		created := f.scene(models.Scene{Path: path})
+		createdIDs = append(createdIDs, created.ID)
+	}
+
+	libraryName := "library"
+	models.SetLibraries([]*models.StashConfig{
+		{Path: "/" + name + "/a/", Name: &libraryName},
+		{Path: "/" + name + "/ab"},
+	})
+	defer models.SetLibraries(nil)
+
+	sceneFilter := models.SceneFilterType{
+		Library: &libraryName,
+	}
+	sqb := models.NewSceneQueryBuilder()
+	scenes, _, err := sqb.Query(&sceneFilter, nil)
+	if err != nil {
+		t.Fatalf("Error querying scenes: %s", err.Error())
+	}
+
+	var found []int
+	for _, s := range scenes {
+		found = append(found, s.ID)
+	}
+	assert.ElementsMatch(t, createdIDs[:2], found)
+
+	// libraries without names are named by their paths
+	unnamed := "/" + name + "/ab"
+	sceneFilter.Library = &unnamed
+	scenes, _, err = sqb.Query(&sceneFilter, nil)
+	if err != nil {
+		t.Fatalf("Error querying scenes: %s", err.Error())
+	}
+	assert.Len(t, scenes, 1)
+
+	missing := "missing"
+	sceneFilter.Library = &missing
+	_, _, err = sqb.Query(&sceneFilter, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type ScrapeHistoryQueryBuilder struct{}
+
+func NewScrapeHistoryQueryBuilder() ScrapeHistoryQueryBuilder {
+	return ScrapeHistoryQueryBuilder{}
+}
+
+func (qb *ScrapeHistoryQueryBuilder) Create(newHistory ScrapeHistory, tx *sqlx.Tx) (*ScrapeHistory, error) {
+	ensureTx(tx)
+	result, err := tx.NamedExec(
+		`INSERT INTO scrape_history (entity, entity_id, source, fields, applied_fields, input_checksum, result, created_at)
+				VALUES (:entity, :entity_id, :source, :fields, :applied_fields, :input_checksum, :result, :created_at)
+		`,
+		newHistory,
+	)
+	if err != nil {
+		return nil, err
+	}
+	historyID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Get(&newHistory, `SELECT * FROM scrape_history WHERE id = ? LIMIT 1`, historyID); err != nil {
+		return nil, err
+	}
+	return &newHistory, nil
+}
+
+// ClearResults removes the stored results of previous scrapes of an object
+// from the source.
+func (qb *ScrapeHistoryQueryBuilder) ClearResults(entity string, entityID int, source string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec("UPDATE scrape_history SET result = NULL WHERE entity = ? AND entity_id = ? AND source = ?", entity, entityID, source)
+	return err
+}
+
+// SetAppliedFields sets the scraped fields that were saved to the object.
+func (qb *ScrapeHistoryQueryBuilder) SetAppliedFields(id int, fields []string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec("UPDATE scrape_history SET applied_fields = ? WHERE id = ?", strings.Join(fields, ","), id)
+	return err
+}
+
+// FindByEntityType returns the scrape history of all objects of the entity
+// type, oldest first.
+func (qb *ScrapeHistoryQueryBuilder) FindByEntityType(entity string) ([]*ScrapeHistory, error) {
+	query := `SELECT * FROM scrape_history WHERE entity = ? ORDER BY created_at ASC, id ASC`
+	args := []interface{}{entity}
+	return qb.queryScrapeHistories(query, args, nil)
+}
+
+// FindResults returns the scrapes of an object with a stored result, which
+// are the most recent scrapes from each source.
+func (qb *ScrapeHistoryQueryBuilder) FindResults(entity string, entityID int, tx *sqlx.Tx) ([]*ScrapeHistory, error) {
+	query := `SELECT * FROM scrape_history WHERE entity = ? AND entity_id = ? AND result IS NOT NULL ORDER BY created_at DESC, id DESC`
+	args := []interface{}{entity, entityID}
+	return qb.queryScrapeHistories(query, args, tx)
+}
+
+// FindResultsBySource returns the scrapes of all objects of the entity type
+// from the source with a stored result, ordered by object.
+func (qb *ScrapeHistoryQueryBuilder) FindResultsBySource(entity string, source string) ([]*ScrapeHistory, error) {
+	query := `SELECT * FROM scrape_history WHERE entity = ? AND source = ? AND result IS NOT NULL ORDER BY entity_id ASC, id DESC`
+	args := []interface{}{entity, source}
+	return qb.queryScrapeHistories(query, args, nil)
+}
+
+// FindByEntity returns the scrape history of an object, most recent first.
+func (qb *ScrapeHistoryQueryBuilder) FindByEntity(entity string, entityID int) ([]*ScrapeHistory, error) {
+	query := `SELECT * FROM scrape_history WHERE entity = ? AND entity_id = ? ORDER BY created_at DESC, id DESC`
+	args := []interface{}{entity, entityID}
+	return qb.queryScrapeHistories(query, args, nil)
+}
+
+// FindLatest returns the most recent scrape of an object from the source, or
+// nil if the object has not been scraped from the source.
+func (qb *ScrapeHistoryQueryBuilder) FindLatest(entity string, entityID int, source string) (*ScrapeHistory, error) {
+	query := `SELECT * FROM scrape_history WHERE entity = ? AND entity_id = ? AND source = ? ORDER BY created_at DESC, id DESC LIMIT 1`
+	args := []interface{}{entity, entityID, source}
+	results, err := qb.queryScrapeHistories(query, args, nil)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (qb *ScrapeHistoryQueryBuilder) queryScrapeHistories(query string, args []interface{}, tx *sqlx.Tx) ([]*ScrapeHistory, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := make([]*ScrapeHistory, 0)
+	for rows.Next() {
+		history := ScrapeHistory{}
+		if err := rows.StructScan(&history); err != nil {
+			return nil, err
+		}
+		histories = append(histories, &history)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
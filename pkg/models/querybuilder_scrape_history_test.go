@@ -0,0 +1,148 @@
+// +build integration
+
+package models_test
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeHistory(t *testing.T) {
+	const source = "TestScrapeHistory"
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	scene := f.scene(models.Scene{Path: source})
+
+	hqb := models.NewScrapeHistoryQueryBuilder()
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, fields := range []string{"title", "date,title"} {
+			if err := hqb.ClearResults(models.ScrapeHistoryEntityScene, scene.ID, source, tx); err != nil {
+				return err
+			}
+
+			_, err := hqb.Create(models.ScrapeHistory{
+				Entity:        models.ScrapeHistoryEntityScene,
+				EntityID:      scene.ID,
+				Source:        source,
+				Fields:        fields,
+				InputChecksum: strconv.Itoa(i),
+				Result:        sql.NullString{String: "{}", Valid: true},
+				CreatedAt:     models.SQLiteTimestamp{Timestamp: baseTime.Add(time.Duration(i) * time.Hour)},
+			}, tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	history, err := hqb.FindByEntity(models.ScrapeHistoryEntityScene, scene.ID)
+	if err != nil {
+		t.Fatalf("Error finding scrape history: %s", err.Error())
+	}
+
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, []string{"date", "title"}, history[0].GetFields())
+		assert.True(t, history[0].Result.Valid)
+		// results of previous scrapes are cleared
+		assert.False(t, history[1].Result.Valid)
+	}
+
+	latest, err := hqb.FindLatest(models.ScrapeHistoryEntityScene, scene.ID, source)
+	if err != nil {
+		t.Fatalf("Error finding latest scrape: %s", err.Error())
+	}
+	if assert.NotNil(t, latest) {
+		assert.Equal(t, "1", latest.InputChecksum)
+	}
+
+	latest, err = hqb.FindLatest(models.ScrapeHistoryEntityScene, scene.ID, "other")
+	assert.Nil(t, err)
+	assert.Nil(t, latest)
+
+	// only the latest scrape from the source has a result
+	withTxn(t, func(tx *sqlx.Tx) error {
+		results, err := hqb.FindResults(models.ScrapeHistoryEntityScene, scene.ID, tx)
+		if err != nil {
+			return err
+		}
+		if !assert.Len(t, results, 1) {
+			return nil
+		}
+		return hqb.SetAppliedFields(results[0].ID, []string{"date", "title"}, tx)
+	})
+
+	latest, err = hqb.FindLatest(models.ScrapeHistoryEntityScene, scene.ID, source)
+	if err != nil {
+		t.Fatalf("Error finding latest scrape: %s", err.Error())
+	}
+	if assert.NotNil(t, latest) {
+		assert.Equal(t, []string{"date", "title"}, latest.GetAppliedFields())
+	}
+
+	results, err := hqb.FindResultsBySource(models.ScrapeHistoryEntityScene, source)
+	if err != nil {
+		t.Fatalf("Error finding scrape results: %s", err.Error())
+	}
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, scene.ID, results[0].EntityID)
+		assert.Equal(t, "1", results[0].InputChecksum)
+	}
+
+	// destroying the scene removes its history
+	sqb := models.NewSceneQueryBuilder()
+	withTxn(t, func(tx *sqlx.Tx) error {
+		return sqb.Destroy(strconv.Itoa(scene.ID), tx)
+	})
+
+	history, err = hqb.FindByEntity(models.ScrapeHistoryEntityScene, scene.ID)
+	assert.Nil(t, err)
+	assert.Len(t, history, 0)
+}
+
+func TestScrapeHistoryFindByEntityType(t *testing.T) {
+	hqb := models.NewScrapeHistoryQueryBuilder()
+
+	var created *models.ScrapeHistory
+	withTxn(t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = hqb.Create(models.ScrapeHistory{
+			Entity:    models.ScrapeHistoryEntityScrapedItem,
+			EntityID:  1,
+			Source:    models.ScrapeHistorySourceScrapedItems,
+			Fields:    "title",
+			Result:    sql.NullString{String: `{"title":"TestScrapeHistoryFindByEntityType"}`, Valid: true},
+			CreatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+		}, tx)
+		return err
+	})
+
+	defer withTxn(t, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("DELETE FROM scrape_history WHERE id = ?", created.ID)
+		return err
+	})
+
+	histories, err := hqb.FindByEntityType(models.ScrapeHistoryEntityScrapedItem)
+	if err != nil {
+		t.Fatalf("Error finding scrape history: %s", err.Error())
+	}
+
+	var found bool
+	for _, h := range histories {
+		assert.Equal(t, models.ScrapeHistoryEntityScrapedItem, h.Entity)
+		if h.ID == created.ID {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
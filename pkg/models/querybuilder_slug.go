@@ -0,0 +1,180 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// slugTable is a table of objects with slugs, and the table of the previous
+// slugs of the objects, which redirect to them.
+type slugTable struct {
+	table         string
+	redirectTable string
+	redirectFK    string
+	// fallback is the slug of objects without letters or digits in their
+	// names
+	fallback string
+}
+
+var (
+	movieSlugs     = slugTable{table: "movies", redirectTable: "movie_slug_redirects", redirectFK: "movie_id", fallback: "movie"}
+	performerSlugs = slugTable{table: "performers", redirectTable: "performer_slug_redirects", redirectFK: "performer_id", fallback: "performer"}
+	studioSlugs    = slugTable{table: "studios", redirectTable: "studio_slug_redirects", redirectFK: "studio_id", fallback: "studio"}
+)
+
+// taken returns true if the slug is the slug or a previous slug of an object
+// other than that with the id.
+func (s slugTable) taken(slug string, id int, tx *sqlx.Tx) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM " + s.table + " WHERE slug = ? AND id <> ?) OR EXISTS (SELECT 1 FROM " + s.redirectTable + " WHERE slug = ? AND " + s.redirectFK + " <> ?)"
+
+	var ret bool
+	if err := tx.Get(&ret, query, slug, id, slug, id); err != nil {
+		return false, err
+	}
+
+	return ret, nil
+}
+
+// unique returns the slug of the given slug, or of name if it is not valid,
+// with a number appended if it is taken. New objects get their slugs from
+// it.
+func (s slugTable) unique(slug sql.NullString, name string, tx *sqlx.Tx) (sql.NullString, error) {
+	base := utils.Slugify(slug.String)
+	if !slug.Valid || base == "" {
+		base = utils.Slugify(name)
+	}
+	if base == "" {
+		base = s.fallback
+	}
+
+	ret := base
+	for i := 2; ; i++ {
+		// new objects have no id
+		taken, err := s.taken(ret, 0, tx)
+		if err != nil {
+			return sql.NullString{}, err
+		}
+		if !taken {
+			break
+		}
+
+		ret = base + "-" + strconv.Itoa(i)
+	}
+
+	return sql.NullString{String: ret, Valid: true}, nil
+}
+
+// update sets the slug of the object with the id, keeping its previous slug
+// as a redirect. It returns an error if the slug is not a valid slug or is
+// taken by another object.
+func (s slugTable) update(id int, slug string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if slug == "" || utils.Slugify(slug) != slug {
+		return fmt.Errorf("invalid slug '%s'", slug)
+	}
+
+	var current sql.NullString
+	if err := tx.Get(&current, "SELECT slug FROM "+s.table+" WHERE id = ?", id); err != nil {
+		return err
+	}
+	if current.String == slug {
+		return nil
+	}
+
+	taken, err := s.taken(slug, id, tx)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("slug '%s' is already in use", slug)
+	}
+
+	if current.Valid {
+		if _, err := tx.Exec("INSERT INTO "+s.redirectTable+" (slug, "+s.redirectFK+") VALUES (?, ?)", current.String, id); err != nil {
+			return err
+		}
+	}
+
+	// the object may be returning to a previous slug
+	if _, err := tx.Exec("DELETE FROM "+s.redirectTable+" WHERE slug = ?", slug); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE "+s.table+" SET slug = ? WHERE id = ?", slug, id)
+	return err
+}
+
+// findID returns the id of the object with the slug, or of the object
+// previously with the slug if no object has it now. It returns nil if
+// neither exists.
+func (s slugTable) findID(slug string, tx *sqlx.Tx) (*int, error) {
+	query := "SELECT id FROM " + s.table + " WHERE slug = ? UNION ALL SELECT " + s.redirectFK + " FROM " + s.redirectTable + " WHERE slug = ? LIMIT 1"
+
+	var ids []int
+	var err error
+	if tx != nil {
+		err = tx.Select(&ids, query, slug, slug)
+	} else {
+		err = database.DB.Select(&ids, query, slug, slug)
+	}
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	return &ids[0], nil
+}
+
+// FindBySlug returns the movie with the slug, or the movie previously with
+// the slug if no movie has it now.
+func (qb *MovieQueryBuilder) FindBySlug(slug string, tx *sqlx.Tx) (*Movie, error) {
+	id, err := movieSlugs.findID(slug, tx)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	return qb.Find(*id, tx)
+}
+
+// UpdateSlug sets the slug of the movie. Its previous slug redirects to it.
+func (qb *MovieQueryBuilder) UpdateSlug(movieID int, slug string, tx *sqlx.Tx) error {
+	return movieSlugs.update(movieID, slug, tx)
+}
+
+// FindBySlug returns the performer with the slug, or the performer previously
+// with the slug if no performer has it now.
+func (qb *PerformerQueryBuilder) FindBySlug(slug string) (*Performer, error) {
+	id, err := performerSlugs.findID(slug, nil)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	return qb.Find(*id)
+}
+
+// UpdateSlug sets the slug of the performer. Its previous slug redirects to
+// it.
+func (qb *PerformerQueryBuilder) UpdateSlug(performerID int, slug string, tx *sqlx.Tx) error {
+	return performerSlugs.update(performerID, slug, tx)
+}
+
+// FindBySlug returns the studio with the slug, or the studio previously with
+// the slug if no studio has it now.
+func (qb *StudioQueryBuilder) FindBySlug(slug string, tx *sqlx.Tx) (*Studio, error) {
+	id, err := studioSlugs.findID(slug, tx)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	return qb.Find(*id, tx)
+}
+
+// UpdateSlug sets the slug of the studio. Its previous slug redirects to it.
+func (qb *StudioQueryBuilder) UpdateSlug(studioID int, slug string, tx *sqlx.Tx) error {
+	return studioSlugs.update(studioID, slug, tx)
+}
@@ -0,0 +1,93 @@
+// +build integration
+
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugCreate(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewStudioQueryBuilder()
+	var slugs []string
+	for _, studio := range []models.Studio{
+		{Name: sql.NullString{String: "Test Slug Create", Valid: true}},
+		{Name: sql.NullString{String: "Test Slug Create!", Valid: true}},
+		{Name: sql.NullString{String: "!!!", Valid: true}},
+		{Name: sql.NullString{String: "Other", Valid: true}, Slug: sql.NullString{String: "Test Slug Create", Valid: true}},
+	} {
+		studio.Checksum = utils.MD5FromString(studio.Name.String)
+		created, err := qb.Create(studio, tx)
+		if err != nil {
+			t.Fatalf("Error creating studio: %s", err.Error())
+		}
+		slugs = append(slugs, created.Slug.String)
+	}
+
+	assert.Equal(t, []string{"test-slug-create", "test-slug-create-2", "studio", "test-slug-create-3"}, slugs)
+}
+
+func TestSlugUpdate(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewMovieQueryBuilder()
+	var ids []int
+	for _, name := range []string{"Test Slug Update", "Test Slug Update Other"} {
+		created, err := qb.Create(models.Movie{
+			Checksum: utils.MD5FromString(name),
+			Name:     sql.NullString{String: name, Valid: true},
+		}, tx)
+		if err != nil {
+			t.Fatalf("Error creating movie: %s", err.Error())
+		}
+		ids = append(ids, created.ID)
+	}
+
+	assertSlug := func(slug string, id int) {
+		t.Helper()
+		movie, err := qb.FindBySlug(slug, tx)
+		if err != nil {
+			t.Fatalf("Error finding movie by slug: %s", err.Error())
+		}
+		if assert.NotNil(t, movie) {
+			assert.Equal(t, id, movie.ID)
+		}
+	}
+
+	if err := qb.UpdateSlug(ids[0], "test-slug-renamed", tx); err != nil {
+		t.Fatalf("Error updating slug: %s", err.Error())
+	}
+	assertSlug("test-slug-renamed", ids[0])
+	// the previous slug redirects to the movie
+	assertSlug("test-slug-update", ids[0])
+
+	// slugs in use, including previous slugs, and invalid slugs are rejected
+	assert.NotNil(t, qb.UpdateSlug(ids[1], "test-slug-renamed", tx))
+	assert.NotNil(t, qb.UpdateSlug(ids[1], "test-slug-update", tx))
+	assert.NotNil(t, qb.UpdateSlug(ids[1], "Not A Slug", tx))
+
+	// the movie may return to its previous slug
+	if err := qb.UpdateSlug(ids[0], "test-slug-update", tx); err != nil {
+		t.Fatalf("Error updating slug: %s", err.Error())
+	}
+	assertSlug("test-slug-update", ids[0])
+	assertSlug("test-slug-renamed", ids[0])
+
+	movie, err := qb.FindBySlug("test-slug-missing", tx)
+	if err != nil {
+		t.Fatalf("Error finding movie by slug: %s", err.Error())
+	}
+	assert.Nil(t, movie)
+}
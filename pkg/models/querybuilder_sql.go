@@ -1,16 +1,22 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/tracing"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type queryBuilder struct {
@@ -21,11 +27,73 @@ type queryBuilder struct {
 	havingClauses []string
 	args          []interface{}
 
-	sortAndPagination string
+	sort             sortTerms
+	findFilter       *FindFilterType
+	approximateCount bool
+
+	// err is the first error found in the criteria, such as an invalid
+	// regular expression
+	err error
+}
+
+func (qb queryBuilder) executeFind() ([]int, int, error) {
+	if qb.err != nil {
+		return nil, 0, qb.err
+	}
+
+	idsQuery, idsArgs, err := qb.buildIDsQuery()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	countQuery := getFindCountQuery(qb.tableName, qb.body, qb.whereClauses, qb.havingClauses, qb.approximateCount)
+
+	// query builders don't receive the context of the request, so the
+	// queries are traced separately
+	_, span := tracing.Start(context.Background(), "find "+qb.tableName)
+	span.SetKind(tracing.KindClient)
+	span.SetAttribute("db.system", "sqlite")
+	span.SetAttribute("db.sql.table", qb.tableName)
+	span.SetAttribute("db.statement", idsQuery)
+	span.SetAttribute("db.count_statement", countQuery)
+	defer span.End()
+
+	idsResult, countResult := runFindQueries(countQuery, qb.args, idsQuery, idsArgs)
+	span.SetAttribute("db.count", countResult)
+
+	return idsResult, countResult, nil
+}
+
+// paginate sets the order of the results of the query and the page of results
+// returned, which is selected by the find filter.
+func (qb *queryBuilder) paginate(sort sortTerms, findFilter *FindFilterType) {
+	qb.sort = sort
+	qb.findFilter = findFilter
 }
 
-func (qb queryBuilder) executeFind() ([]int, int) {
-	return executeFindQuery(qb.tableName, qb.body, qb.args, qb.sortAndPagination, qb.whereClauses, qb.havingClauses)
+// buildIDsQuery returns the query selecting the ids of the page of results,
+// and its arguments.
+func (qb queryBuilder) buildIDsQuery() (string, []interface{}, error) {
+	if qb.findFilter.IsCursorPaginated() {
+		return qb.buildCursorQuery()
+	}
+
+	body := buildFindQuery(qb.tableName, qb.body, qb.whereClauses, qb.havingClauses)
+	return body + qb.sort.String() + getPagination(qb.findFilter), qb.args, nil
+}
+
+// explain returns the query plan of the find query.
+func (qb queryBuilder) explain() ([]string, error) {
+	if qb.err != nil {
+		return nil, qb.err
+	}
+
+	query, args, err := qb.buildIDsQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	return explainQueryPlan(query, args)
 }
 
 func (qb *queryBuilder) addWhere(clauses ...string) {
@@ -48,18 +116,49 @@ func (qb *queryBuilder) addArg(args ...interface{}) {
 	qb.args = append(qb.args, args...)
 }
 
+// setError sets the error of the query, if it has none.
+func (qb *queryBuilder) setError(err error) {
+	if qb.err == nil {
+		qb.err = err
+	}
+}
+
+// validateRegexCriterion returns an error if the modifier matches against a
+// regular expression and value is not a valid regular expression.
+func validateRegexCriterion(modifier CriterionModifier, value string) error {
+	if modifier != CriterionModifierMatchesRegex && modifier != CriterionModifierNotMatchesRegex {
+		return nil
+	}
+
+	if _, err := regexp.Compile(value); err != nil {
+		return fmt.Errorf("invalid regular expression %q: %s", value, err.Error())
+	}
+
+	return nil
+}
+
 func (qb *queryBuilder) handleIntCriterionInput(c *IntCriterionInput, column string) {
 	if c != nil {
 		clause, count := getIntCriterionWhereClause(column, *c)
 		qb.addWhere(clause)
-		if count == 1 {
-			qb.addArg(c.Value)
-		}
+		qb.addArg(getIntCriterionArgs(*c, count)...)
 	}
 }
 
+// handleRatingCriterionInput filters a column holding ratings on the 1-100
+// scale by the legacy 1-5 rating and the 1-100 rating criteria.
+func (qb *queryBuilder) handleRatingCriterionInput(rating *IntCriterionInput, rating100 *IntCriterionInput, column string) {
+	qb.handleIntCriterionInput(rating, getRating5Expression(column))
+	qb.handleIntCriterionInput(rating100, column)
+}
+
 func (qb *queryBuilder) handleStringCriterionInput(c *StringCriterionInput, column string) {
 	if c != nil {
+		if err := validateRegexCriterion(c.Modifier, c.Value); err != nil {
+			qb.setError(err)
+			return
+		}
+
 		if modifier := c.Modifier; c.Modifier.IsValid() {
 			switch modifier {
 			case CriterionModifierIncludes:
@@ -76,6 +175,12 @@ func (qb *queryBuilder) handleStringCriterionInput(c *StringCriterionInput, colu
 			case CriterionModifierNotEquals:
 				qb.addWhere(column + " NOT LIKE ?")
 				qb.addArg(c.Value)
+			case CriterionModifierMatchesRegex:
+				qb.addWhere("COALESCE(" + column + ", '') regexp ?")
+				qb.addArg(c.Value)
+			case CriterionModifierNotMatchesRegex:
+				qb.addWhere("COALESCE(" + column + ", '') NOT regexp ?")
+				qb.addArg(c.Value)
 			default:
 				clause, count := getSimpleCriterionClause(modifier, "?")
 				qb.addWhere(column + " " + clause)
@@ -87,8 +192,58 @@ func (qb *queryBuilder) handleStringCriterionInput(c *StringCriterionInput, colu
 	}
 }
 
+func (qb *queryBuilder) handleTimestampCriterionInput(c *TimestampCriterionInput, column string) {
+	if c != nil {
+		var value2 *string
+		if c.Value2 != nil {
+			v := getTimestampCriterionArg(*c.Value2)
+			value2 = &v
+		}
+		qb.handleTimeCriterion("datetime", column, c.Modifier, getTimestampCriterionArg(c.Value), value2)
+	}
+}
+
+// getTimestampCriterionArg returns the value of a timestamp criterion in UTC.
+// Dates without a time zone are in the configured timezone.
+func getTimestampCriterionArg(value string) string {
+	if t, err := utils.ParseDateStringInTimezone(value); err == nil {
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	return value
+}
+
+func (qb *queryBuilder) handleDateCriterionInput(c *DateCriterionInput, column string) {
+	if c != nil {
+		qb.handleTimeCriterion("date", column, c.Modifier, c.Value, c.Value2)
+	}
+}
+
+// handleTimeCriterion adds a clause comparing the column with value, or with
+// the range from value to value2 for the BETWEEN and NOT_BETWEEN modifiers.
+// The column and values are converted with the SQLite date and time function
+// fn before being compared. A missing value2 is the same as value.
+func (qb *queryBuilder) handleTimeCriterion(fn string, column string, modifier CriterionModifier, value string, value2 *string) {
+	binding, count := getCriterionModifierBinding(modifier, value)
+	binding = strings.ReplaceAll(binding, "?", fn+"(?)")
+	qb.addWhere(fn + "(" + column + ") " + binding)
+
+	switch count {
+	case 1:
+		qb.addArg(value)
+	case 2:
+		upper := value
+		if value2 != nil {
+			upper = *value2
+		}
+		qb.addArg(value, upper)
+	}
+}
+
 var randomSortFloat = rand.Float64()
 
+const randomSeedPrefix = "random_"
+
 func selectAll(tableName string) string {
 	idColumn := getColumn(tableName, "*")
 	return "SELECT " + idColumn + " FROM " + tableName + " "
@@ -119,37 +274,143 @@ func getPagination(findFilter *FindFilterType) string {
 		page = *findFilter.Page
 	}
 
-	var perPage int
-	if findFilter.PerPage == nil {
-		perPage = 25
-	} else {
-		perPage = *findFilter.PerPage
+	perPage := getPerPage(findFilter.PerPage)
+	page = (page - 1) * perPage
+	return " LIMIT " + strconv.Itoa(perPage) + " OFFSET " + strconv.Itoa(page) + " "
+}
+
+// getPerPage returns the number of results per page, limited to between 1
+// and 1000, or the default of 25 if perPage is nil.
+func getPerPage(perPage *int) int {
+	if perPage == nil {
+		return 25
 	}
 
-	if perPage > 1000 {
-		perPage = 1000
-	} else if perPage < 1 {
-		perPage = 1
+	if *perPage > 1000 {
+		return 1000
+	} else if *perPage < 1 {
+		return 1
 	}
 
-	page = (page - 1) * perPage
-	return " LIMIT " + strconv.Itoa(perPage) + " OFFSET " + strconv.Itoa(page) + " "
+	return *perPage
+}
+
+// sortTerm is an expression of the ORDER BY clause of a find query.
+type sortTerm struct {
+	expression string
+	// collation is the collation the expression is sorted by, if not the
+	// default collation
+	collation string
+	direction string
+	// aggregate is true if the expression aggregates the rows of the group of
+	// each result, so may only be compared in having clauses
+	aggregate bool
+}
+
+func (t sortTerm) String() string {
+	return t.collate(t.expression) + " " + t.direction
+}
+
+// collate returns expression with the collation of the term applied.
+func (t sortTerm) collate(expression string) string {
+	if t.collation == "" {
+		return expression
+	}
+
+	return expression + " COLLATE " + t.collation
+}
+
+// sortTerms are the terms of the ORDER BY clause of a find query.
+type sortTerms []sortTerm
+
+func (s sortTerms) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var terms []string
+	for _, t := range s {
+		terms = append(terms, t.String())
+	}
+
+	return " ORDER BY " + strings.Join(terms, ", ") + " "
+}
+
+// sortField is a field which find query results are sorted by.
+type sortField struct {
+	sort      string
+	direction string
+	// tableName is the table of the field, if not the table of the query
+	tableName string
+}
+
+// getSort returns the terms ordering the results of a query of tableName by
+// fields, in order of precedence.
+func getSort(fields []sortField, tableName string) sortTerms {
+	var ret sortTerms
+	for _, f := range fields {
+		fieldTable := tableName
+		if f.tableName != "" {
+			fieldTable = f.tableName
+		}
+		ret = append(ret, getFieldSort(f.sort, f.direction, fieldTable)...)
+	}
+
+	// random and count sorts are not broken by the columns of the results
+	for _, f := range fields {
+		if !isFieldSort(f.sort) {
+			return ret
+		}
+	}
+
+	if tableName == "scenes" {
+		ret = append(ret,
+			sortTerm{expression: "bitrate", direction: "DESC"},
+			sortTerm{expression: "framerate", direction: "DESC"},
+			sortTerm{expression: "scenes.rating", direction: "DESC"},
+			sortTerm{expression: "scenes.duration", direction: "DESC"},
+		)
+	} else if tableName == "scene_markers" {
+		ret = append(ret,
+			sortTerm{expression: "scene_markers.scene_id", direction: "ASC"},
+			sortTerm{expression: "scene_markers.seconds", direction: "ASC"},
+		)
+	}
+
+	return ret
+}
+
+// isFieldSort returns true if sort orders the results by the value of a
+// column, rather than randomly or by the count of related objects.
+func isFieldSort(sort string) bool {
+	return !strings.HasSuffix(sort, "_count") && !strings.HasPrefix(sort, randomSeedPrefix) && sort != "random"
 }
 
-func getSort(sort string, direction string, tableName string) string {
+// naturalSortKeyTables are the columns of each table with a natural sort key
+// column, which sorts in the same order as the natural sort collation of the
+// column.
+var naturalSortKeyTables = map[string]map[string]bool{
+	"performers": {"name": true},
+	"studios":    {"name": true},
+	"tags":       {"name": true},
+	"movies":     {"name": true},
+	"scenes":     {"title": true, "path": true},
+	"galleries":  {"title": true, "path": true},
+	"images":     {"title": true, "path": true},
+}
+
+func getFieldSort(sort string, direction string, tableName string) sortTerms {
 	if direction != "ASC" && direction != "DESC" {
 		direction = "ASC"
 	}
 
-	const randomSeedPrefix = "random_"
-
 	if strings.HasSuffix(sort, "_count") {
 		var relationTableName = strings.TrimSuffix(sort, "_count") // TODO: pluralize?
 		colName := getColumn(relationTableName, "id")
-		return " ORDER BY COUNT(distinct " + colName + ") " + direction
+		return sortTerms{{expression: "COUNT(distinct " + colName + ")", direction: direction, aggregate: true}}
 	} else if strings.Compare(sort, "filesize") == 0 {
 		colName := getColumn(tableName, "size")
-		return " ORDER BY cast(" + colName + " as integer) " + direction
+		return sortTerms{{expression: "cast(" + colName + " as integer)", direction: direction}}
 	} else if strings.HasPrefix(sort, randomSeedPrefix) {
 		// seed as a parameter from the UI
 		// turn the provided seed into a float
@@ -162,30 +423,37 @@ func getSort(sort string, direction string, tableName string) string {
 		return getRandomSort(tableName, direction, seed)
 	} else if strings.Compare(sort, "random") == 0 {
 		return getRandomSort(tableName, direction, randomSortFloat)
+	} else if tableName == "scenes" && sort == "display_title" {
+		return sortTerms{
+			{expression: sceneDisplayTitleColumn, collation: "NATURAL_CS", direction: direction},
+			{expression: "scenes.path_sort", direction: direction},
+		}
+	} else if tableName == "scenes" && sort == "quality_score" {
+		return sortTerms{{expression: sceneQualityScoreColumn, direction: direction}}
+	} else if naturalSortKeyTables[tableName][sort] {
+		// the natural sort keys are indexed - see migration 49
+		return sortTerms{{expression: getColumn(tableName, sort+"_sort"), direction: direction}}
 	} else {
-		colName := getColumn(tableName, sort)
-		var additional string
-		if tableName == "scenes" {
-			additional = ", bitrate DESC, framerate DESC, scenes.rating DESC, scenes.duration DESC"
-		} else if tableName == "scene_markers" {
-			additional = ", scene_markers.scene_id ASC, scene_markers.seconds ASC"
+		term := sortTerm{
+			expression: getColumn(tableName, sort),
+			direction:  direction,
 		}
 		if strings.Compare(sort, "name") == 0 {
-			return " ORDER BY " + colName + " COLLATE NOCASE " + direction + additional
+			term.collation = "NATURAL_CI"
 		}
-		if strings.Compare(sort, "title") == 0 {
-			return " ORDER BY " + colName + " COLLATE NATURAL_CS " + direction + additional
+		if strings.Compare(sort, "title") == 0 || strings.Compare(sort, "path") == 0 {
+			term.collation = "NATURAL_CS"
 		}
 
-		return " ORDER BY " + colName + " " + direction + additional
+		return sortTerms{term}
 	}
 }
 
-func getRandomSort(tableName string, direction string, seed float64) string {
+func getRandomSort(tableName string, direction string, seed float64) sortTerms {
 	// https://stackoverflow.com/a/24511461
 	colName := getColumn(tableName, "id")
 	randomSortString := strconv.FormatFloat(seed, 'f', 16, 32)
-	return " ORDER BY " + "(substr(" + colName + " * " + randomSortString + ", length(" + colName + ") + 2))" + " " + direction
+	return sortTerms{{expression: "(substr(" + colName + " * " + randomSortString + ", length(" + colName + ") + 2))", direction: direction}}
 }
 
 func getSearchBinding(columns []string, q string, not bool) (string, []interface{}) {
@@ -199,21 +467,20 @@ func getSearchBinding(columns []string, q string, not bool) (string, []interface
 		binaryType = " AND "
 	}
 
-	queryWords := strings.Split(q, " ")
-	trimmedQuery := strings.Trim(q, "\"")
-	if trimmedQuery == q {
-		// Search for any word
-		for _, word := range queryWords {
-			for _, column := range columns {
-				likeClauses = append(likeClauses, column+notStr+" LIKE ?")
-				args = append(args, "%"+word+"%")
-			}
+	// the columns and the query are folded so that searches ignore accents
+	likeClause := func(column string) string {
+		clause := "search_fold(" + column + ")" + notStr + " LIKE ?"
+		if not {
+			// null values are folded to empty strings, but never matched
+			clause = "(" + column + " IS NOT NULL AND " + clause + ")"
 		}
-	} else {
-		// Search the exact query
+		return clause
+	}
+
+	for _, term := range getSearchTerms(q) {
 		for _, column := range columns {
-			likeClauses = append(likeClauses, column+notStr+" LIKE ?")
-			args = append(args, "%"+trimmedQuery+"%")
+			likeClauses = append(likeClauses, likeClause(column))
+			args = append(args, "%"+term+"%")
 		}
 	}
 	likes := strings.Join(likeClauses, binaryType)
@@ -221,12 +488,50 @@ func getSearchBinding(columns []string, q string, not bool) (string, []interface
 	return "(" + likes + ")", args
 }
 
+// getSearchTerms returns the folded words of q, any of which is searched
+// for, or the exact query if it is quoted.
+func getSearchTerms(q string) []string {
+	q = utils.FoldSearch(q)
+	trimmedQuery := strings.Trim(q, "\"")
+	if trimmedQuery == q {
+		return strings.Split(q, " ")
+	}
+	return []string{trimmedQuery}
+}
+
+// getSearchDocumentBinding returns the condition that the search document of
+// the objects of table, with the entity type in the search_documents table,
+// matches q as getSearchBinding does. The documents are folded when they are
+// stored, see migration 46.
+func getSearchDocumentBinding(table string, entityType string, q string) (string, []interface{}) {
+	var likeClauses []string
+	args := []interface{}{entityType}
+	for _, term := range getSearchTerms(q) {
+		likeClauses = append(likeClauses, "search_documents.text LIKE ?")
+		args = append(args, "%"+term+"%")
+	}
+
+	return table + ".id IN (SELECT search_documents.entity_id FROM search_documents WHERE search_documents.entity_type = ? AND (" +
+		strings.Join(likeClauses, " OR ") + "))", args
+}
+
+// findManyBatchSize is the maximum number of ids bound in a single FindMany
+// query, keeping within the SQLite limit on the number of variables.
+const findManyBatchSize = 500
+
 func getInBinding(length int) string {
 	bindings := strings.Repeat("?, ", length)
 	bindings = strings.TrimRight(bindings, ", ")
 	return "(" + bindings + ")"
 }
 
+// getUnderPathClause returns the condition that the path held by column is
+// path or within the directory path.
+func getUnderPathClause(column string, path string) (string, []interface{}) {
+	prefix := strings.TrimRight(path, `/\`) + string(filepath.Separator)
+	return "(" + column + " = ? OR substr(" + column + ", 1, length(?)) = ?)", []interface{}{path, prefix, prefix}
+}
+
 func getCriterionModifierBinding(criterionModifier CriterionModifier, value interface{}) (string, int) {
 	var length int
 	switch x := value.(type) {
@@ -245,6 +550,10 @@ func getCriterionModifierBinding(criterionModifier CriterionModifier, value inte
 			return "IN " + getInBinding(length), length // TODO?
 		case "EXCLUDES":
 			return "NOT IN " + getInBinding(length), length // TODO?
+		case "BETWEEN":
+			return "BETWEEN ? AND ?", 2
+		case "NOT_BETWEEN":
+			return "NOT BETWEEN ? AND ?", 2
 		default:
 			logger.Errorf("todo")
 			return "= ?", 1 // TODO
@@ -282,6 +591,24 @@ func getIntCriterionWhereClause(column string, input IntCriterionInput) (string,
 	return column + " " + binding, count
 }
 
+// getIntCriterionArgs returns the arguments for the where clause returned by
+// getIntCriterionWhereClause. A missing upper bound for the BETWEEN and
+// NOT_BETWEEN modifiers defaults to the value.
+func getIntCriterionArgs(input IntCriterionInput, count int) []interface{} {
+	switch count {
+	case 1:
+		return []interface{}{input.Value}
+	case 2:
+		value2 := input.Value
+		if input.Value2 != nil {
+			value2 = *input.Value2
+		}
+		return []interface{}{input.Value, value2}
+	default:
+		return nil
+	}
+}
+
 // returns where clause and having clause
 func getMultiCriterionClause(primaryTable, foreignTable, joinTable, primaryFK, foreignFK string, criterion *MultiCriterionInput) (string, string) {
 	whereClause := ""
@@ -344,7 +671,7 @@ func runSumQuery(query string, args []interface{}) (float64, error) {
 	return result.Float64, nil
 }
 
-func executeFindQuery(tableName string, body string, args []interface{}, sortAndPagination string, whereClauses []string, havingClauses []string) ([]int, int) {
+func buildFindQuery(tableName string, body string, whereClauses []string, havingClauses []string) string {
 	if len(whereClauses) > 0 {
 		body = body + " WHERE " + strings.Join(whereClauses, " AND ") // TODO handle AND or OR
 	}
@@ -353,27 +680,120 @@ func executeFindQuery(tableName string, body string, args []interface{}, sortAnd
 		body = body + " HAVING " + strings.Join(havingClauses, " AND ") // TODO handle AND or OR
 	}
 
-	countQuery := buildCountQuery(body)
-	idsQuery := body + sortAndPagination
+	return body
+}
+
+// buildFindCountQuery returns a query counting the results of the find query.
+// The ids are counted directly where possible, rather than counting the rows
+// of the grouped query.
+func buildFindCountQuery(tableName string, body string, whereClauses []string, havingClauses []string) string {
+	idColumn := getColumn(tableName, "id")
+	distinctIDs := "SELECT DISTINCT " + idColumn + " "
+
+	// having clauses are applied to the groups, so the grouped query must be
+	// counted instead
+	if len(havingClauses) > 0 || !strings.HasPrefix(body, distinctIDs) {
+		return buildCountQuery(buildFindQuery(tableName, body, whereClauses, havingClauses))
+	}
+
+	query := "SELECT COUNT(DISTINCT " + idColumn + ") as count " + strings.TrimPrefix(body, distinctIDs)
+	if len(whereClauses) > 0 {
+		query = query + " WHERE " + strings.Join(whereClauses, " AND ")
+	}
+
+	return query
+}
+
+// approximateCountLimit is the number of results at which the counting of
+// the results of a filtered query stops when an approximate count is
+// returned. Counts of at least approximateCountLimit are flagged as
+// approximate by FindFilterType.IsCountApproximate.
+var approximateCountLimit = 10000
+
+// buildApproximateCountQuery returns a query approximating the number of
+// results of the find query. The count of unfiltered queries is read from the
+// table_counts table, which is maintained by triggers. The count of filtered
+// queries stops at approximateCountLimit.
+func buildApproximateCountQuery(tableName string, body string, whereClauses []string, havingClauses []string) string {
+	if len(whereClauses) == 0 && len(havingClauses) == 0 {
+		return "SELECT table_counts.count as count FROM table_counts WHERE table_counts.table_name = '" + tableName + "'"
+	}
+
+	query := buildFindQuery(tableName, body, whereClauses, havingClauses)
+	return "SELECT COUNT(*) as count FROM (" + query + " LIMIT " + strconv.Itoa(approximateCountLimit) + ") as temp"
+}
+
+// getFindCountQuery returns the query counting the results of the find query,
+// which may be approximated if approximateCount is true.
+func getFindCountQuery(tableName string, body string, whereClauses []string, havingClauses []string, approximateCount bool) string {
+	if approximateCount {
+		return buildApproximateCountQuery(tableName, body, whereClauses, havingClauses)
+	}
+
+	return buildFindCountQuery(tableName, body, whereClauses, havingClauses)
+}
+
+func executeFindQuery(tableName string, body string, args []interface{}, sortAndPagination string, whereClauses []string, havingClauses []string, approximateCount bool) ([]int, int) {
+	countQuery := getFindCountQuery(tableName, body, whereClauses, havingClauses, approximateCount)
+	idsQuery := buildFindQuery(tableName, body, whereClauses, havingClauses) + sortAndPagination
 
+	return runFindQueries(countQuery, args, idsQuery, args)
+}
+
+// runFindQueries runs the count and ids queries of a find query.
+func runFindQueries(countQuery string, countArgs []interface{}, idsQuery string, idsArgs []interface{}) ([]int, int) {
 	// Perform query and fetch result
-	logger.Tracef("SQL: %s, args: %v", idsQuery, args)
+	logger.Tracef("SQL: %s, args: %v", idsQuery, idsArgs)
 
-	countResult, countErr := runCountQuery(countQuery, args)
-	idsResult, idsErr := runIdsQuery(idsQuery, args)
+	countResult, countErr := runCountQuery(countQuery, countArgs)
+	idsResult, idsErr := runIdsQuery(idsQuery, idsArgs)
 
 	if countErr != nil {
-		logger.Errorf("Error executing count query with SQL: %s, args: %v, error: %s", countQuery, args, countErr.Error())
+		logger.Errorf("Error executing count query with SQL: %s, args: %v, error: %s", countQuery, countArgs, countErr.Error())
 		panic(countErr)
 	}
 	if idsErr != nil {
-		logger.Errorf("Error executing find query with SQL: %s, args: %v, error: %s", idsQuery, args, idsErr.Error())
+		logger.Errorf("Error executing find query with SQL: %s, args: %v, error: %s", idsQuery, idsArgs, idsErr.Error())
 		panic(idsErr)
 	}
 
 	return idsResult, countResult
 }
 
+// explainQueryPlan returns the steps of the query plan chosen by SQLite for
+// the provided query. Nested steps are indented.
+func explainQueryPlan(query string, args []interface{}) ([]string, error) {
+	rows, err := database.DB.Queryx("EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depths := make(map[int]int)
+	var ret []string
+	for rows.Next() {
+		var step struct {
+			ID      int    `db:"id"`
+			Parent  int    `db:"parent"`
+			NotUsed int    `db:"notused"`
+			Detail  string `db:"detail"`
+		}
+		if err := rows.StructScan(&step); err != nil {
+			return nil, err
+		}
+
+		depth := 0
+		if parentDepth, found := depths[step.Parent]; found {
+			depth = parentDepth + 1
+		}
+		depths[step.ID] = depth
+
+		ret = append(ret, strings.Repeat("  ", depth)+step.Detail)
+	}
+
+	return ret, rows.Err()
+}
+
 func executeDeleteQuery(tableName string, id string, tx *sqlx.Tx) error {
 	if tx == nil {
 		panic("must use a transaction")
@@ -454,31 +874,3 @@ func sqlGenKeys(i interface{}, partial bool) string {
 	}
 	return strings.Join(query, ", ")
 }
-
-func getImage(tx *sqlx.Tx, query string, args ...interface{}) ([]byte, error) {
-	var rows *sqlx.Rows
-	var err error
-	if tx != nil {
-		rows, err = tx.Queryx(query, args...)
-	} else {
-		rows, err = database.DB.Queryx(query, args...)
-	}
-
-	if err != nil && err != sql.ErrNoRows {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var ret []byte
-	if rows.Next() {
-		if err := rows.Scan(&ret); err != nil {
-			return nil, err
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return ret, nil
-}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetSortSceneTiebreaker(t *testing.T) {
+	const tiebreaker = "bitrate DESC, framerate DESC, scenes.rating DESC, scenes.duration DESC"
+
+	tests := []struct {
+		name   string
+		fields []sortField
+		want   bool
+	}{
+		{"field", []sortField{{sort: "title", direction: "ASC"}}, true},
+		{"fields", []sortField{{sort: "rating", direction: "ASC"}, {sort: "date", direction: "DESC"}}, true},
+		{"random", []sortField{{sort: "random", direction: "ASC"}}, false},
+		{"seeded random", []sortField{{sort: "random_1234", direction: "ASC"}}, false},
+		{"field and random", []sortField{{sort: "date", direction: "ASC"}, {sort: "random", direction: "ASC"}}, false},
+		{"count", []sortField{{sort: "tag_count", direction: "DESC"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort := getSort(tt.fields, "scenes").String()
+			if got := strings.Contains(sort, tiebreaker); got != tt.want {
+				t.Errorf("getSort() = %q, tiebreaker %v, want %v", sort, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type StatsSnapshotQueryBuilder struct{}
+
+func NewStatsSnapshotQueryBuilder() StatsSnapshotQueryBuilder {
+	return StatsSnapshotQueryBuilder{}
+}
+
+// Save creates the snapshot, replacing the existing snapshot of the same day.
+func (qb *StatsSnapshotQueryBuilder) Save(snapshot StatsSnapshot, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.NamedExec(
+		`INSERT OR REPLACE INTO stats_snapshots (date, scene_count, scenes_size, image_count, images_size, gallery_count,
+				performer_count, studio_count, movie_count, tag_count, stream_session_count, stream_duration, stream_bytes)
+				VALUES (:date, :scene_count, :scenes_size, :image_count, :images_size, :gallery_count,
+				:performer_count, :studio_count, :movie_count, :tag_count, :stream_session_count, :stream_duration, :stream_bytes)
+		`,
+		snapshot,
+	)
+	return err
+}
+
+// Query returns the snapshots of the days between from and to inclusive,
+// oldest first. from and to are dates in YYYY-MM-DD format, and either may be
+// nil. Only the last snapshot of each interval is returned if interval is
+// longer than a day.
+func (qb *StatsSnapshotQueryBuilder) Query(from *string, to *string, interval StatsSnapshotInterval) ([]*StatsSnapshot, error) {
+	var whereClauses []string
+	var args []interface{}
+	if from != nil {
+		whereClauses = append(whereClauses, "date >= ?")
+		args = append(args, *from)
+	}
+	if to != nil {
+		whereClauses = append(whereClauses, "date <= ?")
+		args = append(args, *to)
+	}
+
+	where := ""
+	if len(whereClauses) > 0 {
+		where = " WHERE " + strings.Join(whereClauses, " AND ")
+	}
+
+	var group string
+	switch interval {
+	case StatsSnapshotIntervalWeek:
+		// the Monday starting the week, so that weeks are not split at the
+		// start of the year
+		group = "date(date, 'weekday 0', '-6 days')"
+	case StatsSnapshotIntervalMonth:
+		group = "substr(date, 1, 7)"
+	case StatsSnapshotIntervalYear:
+		group = "substr(date, 1, 4)"
+	}
+
+	query := "SELECT * FROM stats_snapshots" + where
+	if group != "" {
+		query = "SELECT * FROM stats_snapshots WHERE date IN (SELECT MAX(date) FROM stats_snapshots" + where + " GROUP BY " + group + ")"
+	}
+	query += " ORDER BY date ASC"
+
+	rows, err := database.DB.Queryx(query, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]*StatsSnapshot, 0)
+	for rows.Next() {
+		snapshot := StatsSnapshot{}
+		if err := rows.StructScan(&snapshot); err != nil {
+			return nil, err
+		}
+		ret = append(ret, &snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,85 @@
+// +build integration
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsSnapshotQuery(t *testing.T) {
+	qb := models.NewStatsSnapshotQueryBuilder()
+
+	// 2021-01-03 is a Sunday, so is in the week of 2020-12-30 rather than of
+	// 2021-01-04
+	dates := []string{"2020-12-30", "2021-01-03", "2021-01-04", "2021-01-31", "2021-02-01", "2021-02-01"}
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, date := range dates {
+			snapshot := models.StatsSnapshot{
+				Date:       date,
+				SceneCount: i,
+				ScenesSize: float64(i * 100),
+			}
+			if err := qb.Save(snapshot, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	defer withTxn(t, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("DELETE FROM stats_snapshots")
+		return err
+	})
+
+	getDates := func(snapshots []*models.StatsSnapshot) []string {
+		var ret []string
+		for _, s := range snapshots {
+			ret = append(ret, s.Date)
+		}
+		return ret
+	}
+
+	snapshots, err := qb.Query(nil, nil, models.StatsSnapshotIntervalDay)
+	if err != nil {
+		t.Fatalf("Error querying stats snapshots: %s", err.Error())
+	}
+	assert.Equal(t, []string{"2020-12-30", "2021-01-03", "2021-01-04", "2021-01-31", "2021-02-01"}, getDates(snapshots))
+
+	// the second snapshot of a day replaces the first
+	last := snapshots[len(snapshots)-1]
+	assert.Equal(t, 5, last.SceneCount)
+	assert.Equal(t, float64(500), last.ScenesSize)
+
+	from := "2021-01-01"
+	to := "2021-01-31"
+	snapshots, err = qb.Query(&from, &to, models.StatsSnapshotIntervalDay)
+	if err != nil {
+		t.Fatalf("Error querying stats snapshots: %s", err.Error())
+	}
+	assert.Equal(t, []string{"2021-01-03", "2021-01-04", "2021-01-31"}, getDates(snapshots))
+
+	intervals := map[models.StatsSnapshotInterval][]string{
+		models.StatsSnapshotIntervalWeek:  {"2021-01-03", "2021-01-04", "2021-01-31", "2021-02-01"},
+		models.StatsSnapshotIntervalMonth: {"2020-12-30", "2021-01-31", "2021-02-01"},
+		models.StatsSnapshotIntervalYear:  {"2020-12-30", "2021-02-01"},
+	}
+	for interval, expected := range intervals {
+		snapshots, err := qb.Query(nil, nil, interval)
+		if err != nil {
+			t.Fatalf("Error querying stats snapshots: %s", err.Error())
+		}
+		assert.Equal(t, expected, getDates(snapshots), interval.String())
+	}
+
+	// the last snapshot of each interval within the range is returned
+	snapshots, err = qb.Query(nil, &to, models.StatsSnapshotIntervalMonth)
+	if err != nil {
+		t.Fatalf("Error querying stats snapshots: %s", err.Error())
+	}
+	assert.Equal(t, []string{"2020-12-30", "2021-01-31"}, getDates(snapshots))
+}
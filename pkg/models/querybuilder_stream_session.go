@@ -0,0 +1,106 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type StreamSessionQueryBuilder struct{}
+
+func NewStreamSessionQueryBuilder() StreamSessionQueryBuilder {
+	return StreamSessionQueryBuilder{}
+}
+
+func (qb *StreamSessionQueryBuilder) Create(newSession StreamSession, tx *sqlx.Tx) (*StreamSession, error) {
+	ensureTx(tx)
+	result, err := tx.NamedExec(
+		`INSERT INTO stream_sessions (scene_id, client, transcode, duration, bytes, started_at, ended_at)
+				VALUES (:scene_id, :client, :transcode, :duration, :bytes, :started_at, :ended_at)
+		`,
+		newSession,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Get(&newSession, `SELECT * FROM stream_sessions WHERE id = ? LIMIT 1`, sessionID); err != nil {
+		return nil, err
+	}
+	return &newSession, nil
+}
+
+// UpdateProgress sets the duration, bytes and end time of the session.
+func (qb *StreamSessionQueryBuilder) UpdateProgress(session StreamSession, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.NamedExec(
+		`UPDATE stream_sessions SET duration = :duration, bytes = :bytes, ended_at = :ended_at WHERE id = :id`,
+		session,
+	)
+	return err
+}
+
+// Totals returns the aggregate of the sessions started since the given time,
+// or of all sessions if since is nil.
+func (qb *StreamSessionQueryBuilder) Totals(since *time.Time) (*StreamTotals, error) {
+	query := `SELECT COUNT(*) as session_count,
+		COALESCE(SUM(duration), 0) as duration,
+		COALESCE(SUM(CASE WHEN transcode THEN duration ELSE 0 END), 0) as transcode_duration,
+		COALESCE(SUM(bytes), 0) as bytes
+		FROM stream_sessions`
+	where, args := getStreamSessionSinceClause(since)
+
+	ret := StreamTotals{}
+	if err := database.DB.Get(&ret, query+where, args...); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// TopScenes returns the aggregates of the scenes with the longest total
+// stream duration since the given time, longest first.
+func (qb *StreamSessionQueryBuilder) TopScenes(since *time.Time, limit int) ([]*SceneStreamTotals, error) {
+	where, args := getStreamSessionSinceClause(since)
+	query := `SELECT scene_id, COUNT(*) as session_count, SUM(duration) as duration
+		FROM stream_sessions` + where + `
+		GROUP BY scene_id
+		ORDER BY duration DESC, scene_id ASC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := database.DB.Queryx(query, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]*SceneStreamTotals, 0)
+	for rows.Next() {
+		totals := SceneStreamTotals{}
+		if err := rows.StructScan(&totals); err != nil {
+			return nil, err
+		}
+		ret = append(ret, &totals)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func getStreamSessionSinceClause(since *time.Time) (string, []interface{}) {
+	if since == nil {
+		return "", nil
+	}
+
+	return " WHERE started_at >= ?", []interface{}{SQLiteTimestamp{Timestamp: *since}}
+}
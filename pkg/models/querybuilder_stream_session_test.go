@@ -0,0 +1,91 @@
+// +build integration
+
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamSessionTotals(t *testing.T) {
+	baseTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	qb := models.NewStreamSessionQueryBuilder()
+
+	sessions := []models.StreamSession{
+		{SceneID: sceneIDs[0], Duration: 10, Bytes: 100},
+		{SceneID: sceneIDs[0], Duration: 20, Bytes: 200, Transcode: true},
+		{SceneID: sceneIDs[1], Duration: 40, Bytes: 400},
+		// before the since time
+		{SceneID: sceneIDs[2], Duration: 80, Bytes: 800},
+	}
+
+	withTxn(t, func(tx *sqlx.Tx) error {
+		for i, s := range sessions {
+			startedAt := baseTime.Add(time.Duration(len(sessions)-i) * time.Hour)
+			s.Client = "TestStreamSessionTotals"
+			s.StartedAt = models.SQLiteTimestamp{Timestamp: startedAt}
+			s.EndedAt = models.SQLiteTimestamp{Timestamp: startedAt.Add(time.Minute)}
+
+			created, err := qb.Create(s, tx)
+			if err != nil {
+				return err
+			}
+
+			if i == 0 {
+				created.Duration += 5
+				created.Bytes += 50
+				if err := qb.UpdateProgress(*created, tx); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	defer withTxn(t, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("DELETE FROM stream_sessions")
+		return err
+	})
+
+	totals, err := qb.Totals(nil)
+	if err != nil {
+		t.Fatalf("Error getting stream totals: %s", err.Error())
+	}
+	assert.Equal(t, 4, totals.SessionCount)
+	assert.Equal(t, float64(155), totals.Duration)
+	assert.Equal(t, float64(20), totals.TranscodeDuration)
+	assert.Equal(t, int64(1550), totals.Bytes)
+
+	since := baseTime.Add(90 * time.Minute)
+	totals, err = qb.Totals(&since)
+	if err != nil {
+		t.Fatalf("Error getting stream totals: %s", err.Error())
+	}
+	assert.Equal(t, 3, totals.SessionCount)
+	assert.Equal(t, float64(75), totals.Duration)
+
+	topScenes, err := qb.TopScenes(&since, 10)
+	if err != nil {
+		t.Fatalf("Error getting top scenes: %s", err.Error())
+	}
+	if assert.Len(t, topScenes, 2) {
+		assert.Equal(t, sceneIDs[1], topScenes[0].SceneID)
+		assert.Equal(t, sceneIDs[0], topScenes[1].SceneID)
+		assert.Equal(t, 2, topScenes[1].SessionCount)
+		assert.Equal(t, float64(35), topScenes[1].Duration)
+	}
+
+	topScenes, err = qb.TopScenes(nil, 1)
+	if err != nil {
+		t.Fatalf("Error getting top scenes: %s", err.Error())
+	}
+	if assert.Len(t, topScenes, 1) {
+		assert.Equal(t, sceneIDs[2], topScenes[0].SceneID)
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
@@ -16,9 +17,21 @@ func NewStudioQueryBuilder() StudioQueryBuilder {
 
 func (qb *StudioQueryBuilder) Create(newStudio Studio, tx *sqlx.Tx) (*Studio, error) {
 	ensureTx(tx)
+	slug, err := studioSlugs.unique(newStudio.Slug, newStudio.Name.String, tx)
+	if err != nil {
+		return nil, err
+	}
+	newStudio.Slug = slug
+
+	uuid, err := uniqueUUID("studios", newStudio.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newStudio.UUID = uuid
+
 	result, err := tx.NamedExec(
-		`INSERT INTO studios (checksum, name, url, parent_id, created_at, updated_at)
-            VALUES (:checksum, :name, :url, :parent_id, :created_at, :updated_at)
+		`INSERT INTO studios (checksum, name, url, parent_id, slug, uuid, created_at, updated_at)
+            VALUES (:checksum, :name, :url, :parent_id, :slug, :uuid, :created_at, :updated_at)
 		`,
 		newStudio,
 	)
@@ -77,9 +90,8 @@ func (qb *StudioQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 		return err
 	}
 
-	// remove studio from scraped items
-	_, err = tx.Exec("UPDATE scraped_items SET studio_id = null WHERE studio_id = ?", id)
-	if err != nil {
+	studioID, _ := strconv.Atoi(id)
+	if err := destroyImageFiles(studioID, tx, studioImageBlob); err != nil {
 		return err
 	}
 
@@ -132,19 +144,117 @@ func (qb *StudioQueryBuilder) FindByName(name string, tx *sqlx.Tx, nocase bool)
 	return qb.queryStudio(query, args, tx)
 }
 
+// studioDescendantsQuery selects the id of each direct child of a studio,
+// paired with the ids of that child and all of its descendants.
+var studioDescendantsQuery = `
+WITH RECURSIVE descendants(root_id, id) AS (
+	SELECT studios.id, studios.id FROM studios WHERE studios.parent_id = ?
+	UNION
+	SELECT descendants.root_id, studios.id FROM studios
+	JOIN descendants ON studios.parent_id = descendants.id
+)
+`
+
+// SceneCountByYear returns the number of scenes for the provided studio,
+// grouped by the year of the scene date. Scenes without a date are not
+// included.
+func (qb *StudioQueryBuilder) SceneCountByYear(studioID int) ([]*StudioYearSceneCount, error) {
+	query := `SELECT CAST(strftime('%Y', scenes.date) AS INTEGER) as year, COUNT(scenes.id) as count
+		FROM scenes
+		WHERE scenes.studio_id = ? AND scenes.date IS NOT NULL AND scenes.date != '' AND scenes.date != '0001-01-01'
+		GROUP BY year
+		ORDER BY year ASC
+	`
+
+	rows, err := database.DB.Queryx(query, studioID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make([]*StudioYearSceneCount, 0)
+	for rows.Next() {
+		var year sql.NullInt64
+		var count int
+		if err := rows.Scan(&year, &count); err != nil {
+			return nil, err
+		}
+
+		// strftime returns null for unparseable dates
+		if !year.Valid {
+			continue
+		}
+
+		ret = append(ret, &StudioYearSceneCount{
+			Year:       int(year.Int64),
+			SceneCount: count,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// ChildSceneCounts returns the number of scenes for each direct child of the
+// provided studio, including the scenes of all of the child's descendants.
+// The returned map is keyed by child studio id.
+func (qb *StudioQueryBuilder) ChildSceneCounts(studioID int) (map[int]int, error) {
+	query := studioDescendantsQuery + `
+		SELECT descendants.root_id, COUNT(scenes.id) FROM descendants
+		LEFT JOIN scenes ON scenes.studio_id = descendants.id
+		GROUP BY descendants.root_id
+	`
+
+	rows, err := database.DB.Queryx(query, studioID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := make(map[int]int)
+	for rows.Next() {
+		var childID int
+		var count int
+		if err := rows.Scan(&childID, &count); err != nil {
+			return nil, err
+		}
+
+		ret[childID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// CountScenesWithDescendants returns the number of scenes for the provided
+// studio and all of its descendant studios.
+func (qb *StudioQueryBuilder) CountScenesWithDescendants(studioID int) (int, error) {
+	query := studioDescendantsQuery + `
+		SELECT COUNT(scenes.id) as count FROM scenes
+		WHERE scenes.studio_id = ? OR scenes.studio_id IN (SELECT descendants.id FROM descendants)
+	`
+	return runCountQuery(query, []interface{}{studioID, studioID})
+}
+
 func (qb *StudioQueryBuilder) Count() (int, error) {
 	return runCountQuery(buildCountQuery("SELECT studios.id FROM studios"), nil)
 }
 
 func (qb *StudioQueryBuilder) All() ([]*Studio, error) {
-	return qb.queryStudios(selectAll("studios")+qb.getStudioSort(nil), nil, nil)
+	return qb.queryStudios(selectAll("studios")+qb.getStudioSort(nil).String(), nil, nil)
 }
 
 func (qb *StudioQueryBuilder) AllSlim() ([]*Studio, error) {
-	return qb.queryStudios("SELECT studios.id, studios.name, studios.parent_id FROM studios "+qb.getStudioSort(nil), nil, nil)
+	return qb.queryStudios("SELECT studios.id, studios.name, studios.parent_id FROM studios "+qb.getStudioSort(nil).String(), nil, nil)
 }
 
-func (qb *StudioQueryBuilder) Query(studioFilter *StudioFilterType, findFilter *FindFilterType) ([]*Studio, int) {
+func (qb *StudioQueryBuilder) Query(studioFilter *StudioFilterType, findFilter *FindFilterType) ([]*Studio, int, error) {
 	if studioFilter == nil {
 		studioFilter = &StudioFilterType{}
 	}
@@ -152,78 +262,96 @@ func (qb *StudioQueryBuilder) Query(studioFilter *StudioFilterType, findFilter *
 		findFilter = &FindFilterType{}
 	}
 
-	var whereClauses []string
-	var havingClauses []string
-	var args []interface{}
-	body := selectDistinctIDs("studios")
-	body += `
-		left join scenes on studios.id = scenes.studio_id		
-		left join studio_stash_ids on studio_stash_ids.studio_id = studios.id
-	`
+	query := queryBuilder{
+		tableName: "studios",
+	}
 
 	if q := findFilter.Q; q != nil && *q != "" {
 		searchColumns := []string{"studios.name"}
 
 		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
-		whereClauses = append(whereClauses, clause)
-		args = append(args, thisArgs...)
+		query.addWhere(clause)
+		query.addArg(thisArgs...)
 	}
 
-	if parentsFilter := studioFilter.Parents; parentsFilter != nil && len(parentsFilter.Value) > 0 {
-		body += `
-			left join studios as parent_studio on parent_studio.id = studios.parent_id
-		`
-
-		for _, studioID := range parentsFilter.Value {
-			args = append(args, studioID)
-		}
-
-		whereClause, havingClause := getMultiCriterionClause("studios", "parent_studio", "", "", "parent_id", parentsFilter)
-		whereClauses = appendClause(whereClauses, whereClause)
-		havingClauses = appendClause(havingClauses, havingClause)
-	}
+	query.addFilter(qb.makeFilter(studioFilter))
 
-	if stashIDFilter := studioFilter.StashID; stashIDFilter != nil {
-		whereClauses = append(whereClauses, "studio_stash_ids.stash_id = ?")
-		args = append(args, stashIDFilter)
-	}
-
-	if isMissingFilter := studioFilter.IsMissing; isMissingFilter != nil && *isMissingFilter != "" {
-		switch *isMissingFilter {
-		case "image":
-			body += `left join studios_image on studios_image.studio_id = studios.id
-			`
-			whereClauses = appendClause(whereClauses, "studios_image.studio_id IS NULL")
-		case "stash_id":
-			whereClauses = appendClause(whereClauses, "studio_stash_ids.studio_id IS NULL")
-		default:
-			whereClauses = appendClause(whereClauses, "studios."+*isMissingFilter+" IS NULL")
-		}
+	query.paginate(qb.getStudioSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
 	}
 
-	sortAndPagination := qb.getStudioSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := executeFindQuery("studios", body, args, sortAndPagination, whereClauses, havingClauses)
-
 	var studios []*Studio
 	for _, id := range idsResult {
 		studio, _ := qb.Find(id, nil)
 		studios = append(studios, studio)
 	}
 
-	return studios, countResult
+	return studios, countResult, nil
 }
 
-func (qb *StudioQueryBuilder) getStudioSort(findFilter *FindFilterType) string {
-	var sort string
-	var direction string
+// makeFilter returns the clauses of the studio filter and its sub-filters.
+func (qb *StudioQueryBuilder) makeFilter(studioFilter *StudioFilterType) *filterBuilder {
+	query := newFilterBuilder("studios", selectDistinctIDs("studios")+`
+		left join scenes on studios.id = scenes.studio_id		
+		left join studio_stash_ids on studio_stash_ids.studio_id = studios.id
+	`)
+
+	query.handleCriteria(
+		stringCriterionHandler(studioFilter.Name, "studios.name"),
+		timestampCriterionHandler(studioFilter.CreatedAt, "studios.created_at"),
+		timestampCriterionHandler(studioFilter.UpdatedAt, "studios.updated_at"),
+		studioPins.criterionHandler(studioFilter.Pinned),
+		studioSceneDateJoin.criterionHandler(studioFilter.FirstSceneDate, "MIN", "studios.id"),
+		studioSceneDateJoin.criterionHandler(studioFilter.LastSceneDate, "MAX", "studios.id"),
+		multiCriterionHandler{
+			criterion:    studioFilter.Parents,
+			primaryTable: "studios",
+			foreignTable: "parent_studio",
+			foreignFK:    "parent_id",
+			join: &multiCriterionJoin{
+				table:    "studios",
+				as:       "parent_studio",
+				onClause: "parent_studio.id = studios.parent_id",
+			},
+		},
+		criterionHandlerFunc(func(f *filterBuilder) {
+			if stashID := studioFilter.StashID; stashID != nil {
+				f.where("studio_stash_ids.stash_id = ?", stashID)
+			}
+		}),
+		isMissingCriterionHandler(studioFilter.IsMissing, "studios", map[string]isMissingJoin{
+			"image": {
+				table:    "studios_image",
+				onClause: "studios_image.studio_id = studios.id",
+				clause:   "studios_image.studio_id IS NULL",
+			},
+			"stash_id": {
+				clause: "studio_stash_ids.studio_id IS NULL",
+			},
+		}),
+	)
+
+	if studioFilter.And != nil {
+		query.and = qb.makeFilter(studioFilter.And)
+	}
+	if studioFilter.Or != nil {
+		query.or = qb.makeFilter(studioFilter.Or)
+	}
+	if studioFilter.Not != nil {
+		query.not = qb.makeFilter(studioFilter.Not)
+	}
+
+	return query
+}
+
+func (qb *StudioQueryBuilder) getStudioSort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		sort = "name"
-		direction = "ASC"
-	} else {
-		sort = findFilter.GetSort("name")
-		direction = findFilter.GetDirection()
+		return getSort([]sortField{{sort: "name", direction: "ASC"}}, "studios")
 	}
-	return getSort(sort, direction, "studios")
+	return getSort(findFilter.getSortFields("name", "ASC"), "studios")
 }
 
 func (qb *StudioQueryBuilder) queryStudio(query string, args []interface{}, tx *sqlx.Tx) (*Studio, error) {
@@ -272,10 +400,16 @@ func (qb *StudioQueryBuilder) UpdateStudioImage(studioID int, image []byte, tx *
 		return err
 	}
 
-	_, err := tx.Exec(
-		`INSERT INTO studios_image (studio_id, image) VALUES (?, ?)`,
+	image, file, err := studioImageBlob.store(studioID, image)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO studios_image (studio_id, image, image_file) VALUES (?, ?, ?)`,
 		studioID,
 		image,
+		file,
 	)
 
 	return err
@@ -284,17 +418,17 @@ func (qb *StudioQueryBuilder) UpdateStudioImage(studioID int, image []byte, tx *
 func (qb *StudioQueryBuilder) DestroyStudioImage(studioID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
-	// Delete the existing joins
-	_, err := tx.Exec("DELETE FROM studios_image WHERE studio_id = ?", studioID)
-	if err != nil {
+	if err := destroyImageFiles(studioID, tx, studioImageBlob); err != nil {
 		return err
 	}
+
+	// Delete the existing joins
+	_, err := tx.Exec("DELETE FROM studios_image WHERE studio_id = ?", studioID)
 	return err
 }
 
 func (qb *StudioQueryBuilder) GetStudioImage(studioID int, tx *sqlx.Tx) ([]byte, error) {
-	query := `SELECT image from studios_image WHERE studio_id = ?`
-	return getImage(tx, query, studioID)
+	return studioImageBlob.get(studioID, tx)
 }
 
 func (qb *StudioQueryBuilder) HasStudioImage(studioID int) (bool, error) {
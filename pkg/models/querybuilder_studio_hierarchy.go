@@ -0,0 +1,37 @@
+package models
+
+import "strconv"
+
+// subStudiosSubquery returns a subquery selecting the ids of the n studios
+// with the ids bound to it and of their child studios, up to depth levels
+// below them. Child studios at any level are selected if depth is negative.
+func subStudiosSubquery(n int, depth int) string {
+	if depth == 0 {
+		return getInBinding(n)
+	}
+
+	// as for tags, the level of each studio is selected only if the depth is
+	// limited, so the recursion ends on cycles
+	columns := "id"
+	rootLevel, nextLevel := "", ""
+	where := ""
+	if depth > 0 {
+		columns = "id, level"
+		rootLevel, nextLevel = ", 0", ", studio_tree.level + 1"
+		where = " WHERE studio_tree.level < " + strconv.Itoa(depth)
+	}
+
+	anchor := "SELECT studios.id" + rootLevel + " FROM studios WHERE studios.id IN " + getInBinding(n)
+	recursive := "SELECT studios.id" + nextLevel + " FROM studios JOIN studio_tree ON studios.parent_id = studio_tree.id" + where
+
+	return "(WITH RECURSIVE studio_tree(" + columns + ") AS (" + anchor + " UNION " + recursive + ") SELECT id FROM studio_tree)"
+}
+
+// subStudiosCriterionHandler handles a criterion on the studio of objects of
+// primaryTable, matching the studios of the criterion and their child
+// studios up to the depth of the criterion. Objects without a studio do not
+// match the studios, so they match EXCLUDES.
+func subStudiosCriterionHandler(c *HierarchicalMultiCriterionInput, primaryTable string) criterionHandlerFunc {
+	column := primaryTable + ".studio_id"
+	return hierarchicalCriterionHandler(c, false, subStudiosSubquery, column+" IS NOT NULL AND "+column+" IN %s")
+}
@@ -56,7 +56,8 @@ func TestStudioQueryParent(t *testing.T) {
 		Parents: &studioCriterion,
 	}
 
-	studios, _ := sqb.Query(&studioFilter, nil)
+	studios, _, err := sqb.Query(&studioFilter, nil)
+	assert.NoError(t, err)
 
 	assert.Len(t, studios, 1)
 
@@ -75,7 +76,8 @@ func TestStudioQueryParent(t *testing.T) {
 		Q: &q,
 	}
 
-	studios, _ = sqb.Query(&studioFilter, &findFilter)
+	studios, _, err = sqb.Query(&studioFilter, &findFilter)
+	assert.NoError(t, err)
 	assert.Len(t, studios, 0)
 }
 
@@ -300,6 +302,69 @@ func TestStudioDestroyStudioImage(t *testing.T) {
 	assert.Nil(t, storedImage)
 }
 
+func TestStudioHierarchyCriterion(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	// a network with a child studio, which has a child studio
+	var studios []int
+	var parentID sql.NullInt64
+	for _, name := range []string{"TestStudioHierarchyNetwork", "TestStudioHierarchyChild", "TestStudioHierarchyGrandchild"} {
+		created := f.studio(models.Studio{
+			Name:     sql.NullString{String: name, Valid: true},
+			ParentID: parentID,
+		})
+		studios = append(studios, created.ID)
+		parentID = sql.NullInt64{Int64: int64(created.ID), Valid: true}
+	}
+
+	var scenes []int
+	for i, studioID := range studios {
+		created := f.scene(models.Scene{
+			Path:     "TestStudioHierarchy" + strconv.Itoa(i),
+			StudioID: sql.NullInt64{Int64: int64(studioID), Valid: true},
+		})
+		scenes = append(scenes, created.ID)
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	perPage := totalScenes + len(scenes)
+	queryIDs := func(modifier models.CriterionModifier, depth int, studioIDs ...int) []int {
+		criterion := models.HierarchicalMultiCriterionInput{
+			Modifier: modifier,
+			Depth:    &depth,
+		}
+		for _, id := range studioIDs {
+			criterion.Value = append(criterion.Value, strconv.Itoa(id))
+		}
+
+		found, _, err := sqb.Query(&models.SceneFilterType{Studios: &criterion}, &models.FindFilterType{PerPage: &perPage})
+		if err != nil {
+			t.Fatalf("Error querying scenes: %s", err.Error())
+		}
+
+		var ret []int
+		for _, s := range found {
+			ret = append(ret, s.ID)
+		}
+		return ret
+	}
+
+	assert.Equal(t, []int{scenes[0]}, queryIDs(models.CriterionModifierIncludes, 0, studios[0]))
+	assert.ElementsMatch(t, scenes[:2], queryIDs(models.CriterionModifierIncludes, 1, studios[0]))
+	assert.ElementsMatch(t, scenes, queryIDs(models.CriterionModifierIncludes, -1, studios[0]))
+
+	// scenes of sub-studios of both studios
+	assert.ElementsMatch(t, scenes[1:], queryIDs(models.CriterionModifierIncludesAll, -1, studios[0], studios[1]))
+
+	excluded := queryIDs(models.CriterionModifierExcludes, -1, studios[1])
+	assert.Contains(t, excluded, scenes[0])
+	assert.NotContains(t, excluded, scenes[1])
+	assert.NotContains(t, excluded, scenes[2])
+	// scenes without a studio are not excluded
+	assert.Contains(t, excluded, sceneIDs[sceneIdxWithTag])
+}
+
 // TODO Create
 // TODO Update
 // TODO Destroy
@@ -309,3 +374,71 @@ func TestStudioDestroyStudioImage(t *testing.T) {
 // TODO All
 // TODO AllSlim
 // TODO Query
+
+func TestStudioSceneBreakdown(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	parent := f.studio(models.Studio{Name: sql.NullString{String: "breakdown_parent", Valid: true}})
+	child := f.studio(models.Studio{
+		Name:     sql.NullString{String: "breakdown_child", Valid: true},
+		ParentID: sql.NullInt64{Int64: int64(parent.ID), Valid: true},
+	})
+	grandchild := f.studio(models.Studio{
+		Name:     sql.NullString{String: "breakdown_grandchild", Valid: true},
+		ParentID: sql.NullInt64{Int64: int64(child.ID), Valid: true},
+	})
+
+	sceneStudios := []struct {
+		studioID int
+		date     string
+	}{
+		{parent.ID, "2001-02-03"},
+		{parent.ID, "2001-05-06"},
+		{parent.ID, "2005-01-01"},
+		{parent.ID, ""},
+		{child.ID, "2005-01-01"},
+		{grandchild.ID, "2010-01-01"},
+	}
+
+	for i, s := range sceneStudios {
+		f.scene(models.Scene{
+			Path:     "TestStudioSceneBreakdown_" + strconv.Itoa(i),
+			StudioID: sql.NullInt64{Int64: int64(s.studioID), Valid: true},
+			Date:     models.SQLiteDate{String: s.date, Valid: true},
+		})
+	}
+
+	qb := models.NewStudioQueryBuilder()
+
+	years, err := qb.SceneCountByYear(parent.ID)
+	if err != nil {
+		t.Fatalf("Error getting scene count by year: %s", err.Error())
+	}
+
+	assert.Equal(t, []*models.StudioYearSceneCount{
+		{Year: 2001, SceneCount: 2},
+		{Year: 2005, SceneCount: 1},
+	}, years)
+
+	childCounts, err := qb.ChildSceneCounts(parent.ID)
+	if err != nil {
+		t.Fatalf("Error getting child scene counts: %s", err.Error())
+	}
+
+	assert.Equal(t, map[int]int{child.ID: 2}, childCounts)
+
+	count, err := qb.CountScenesWithDescendants(parent.ID)
+	if err != nil {
+		t.Fatalf("Error getting rollup scene count: %s", err.Error())
+	}
+
+	assert.Equal(t, 6, count)
+
+	count, err = qb.CountScenesWithDescendants(grandchild.ID)
+	if err != nil {
+		t.Fatalf("Error getting rollup scene count: %s", err.Error())
+	}
+
+	assert.Equal(t, 1, count)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
@@ -19,9 +20,15 @@ func NewTagQueryBuilder() TagQueryBuilder {
 
 func (qb *TagQueryBuilder) Create(newTag Tag, tx *sqlx.Tx) (*Tag, error) {
 	ensureTx(tx)
+	uuid, err := uniqueUUID("tags", newTag.UUID, tx)
+	if err != nil {
+		return nil, err
+	}
+	newTag.UUID = uuid
+
 	result, err := tx.NamedExec(
-		`INSERT INTO tags (name, created_at, updated_at)
-				VALUES (:name, :created_at, :updated_at)
+		`INSERT INTO tags (name, uuid, created_at, updated_at)
+				VALUES (:name, :uuid, :created_at, :updated_at)
 		`,
 		newTag,
 	)
@@ -81,6 +88,11 @@ func (qb *TagQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 		return errors.New("Cannot delete tag used as a primary tag in scene markers")
 	}
 
+	tagID, _ := strconv.Atoi(id)
+	if err := destroyImageFiles(tagID, tx, tagImageBlob); err != nil {
+		return err
+	}
+
 	return executeDeleteQuery("tags", id, tx)
 }
 
@@ -115,7 +127,7 @@ func (qb *TagQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) ([]*Tag, erro
 		WHERE scenes_join.scene_id = ?
 		GROUP BY tags.id
 	`
-	query += qb.getTagSort(nil)
+	query += qb.getTagSort(nil).String()
 	args := []interface{}{sceneID}
 	return qb.queryTags(query, args, tx)
 }
@@ -127,7 +139,7 @@ func (qb *TagQueryBuilder) FindByImageID(imageID int, tx *sqlx.Tx) ([]*Tag, erro
 		WHERE images_join.image_id = ?
 		GROUP BY tags.id
 	`
-	query += qb.getTagSort(nil)
+	query += qb.getTagSort(nil).String()
 	args := []interface{}{imageID}
 	return qb.queryTags(query, args, tx)
 }
@@ -139,7 +151,7 @@ func (qb *TagQueryBuilder) FindByGalleryID(galleryID int, tx *sqlx.Tx) ([]*Tag,
 		WHERE galleries_join.gallery_id = ?
 		GROUP BY tags.id
 	`
-	query += qb.getTagSort(nil)
+	query += qb.getTagSort(nil).String()
 	args := []interface{}{galleryID}
 	return qb.queryTags(query, args, tx)
 }
@@ -151,7 +163,7 @@ func (qb *TagQueryBuilder) FindBySceneMarkerID(sceneMarkerID int, tx *sqlx.Tx) (
 		WHERE scene_markers_join.scene_marker_id = ?
 		GROUP BY tags.id
 	`
-	query += qb.getTagSort(nil)
+	query += qb.getTagSort(nil).String()
 	args := []interface{}{sceneMarkerID}
 	return qb.queryTags(query, args, tx)
 }
@@ -184,14 +196,14 @@ func (qb *TagQueryBuilder) Count() (int, error) {
 }
 
 func (qb *TagQueryBuilder) All() ([]*Tag, error) {
-	return qb.queryTags(selectAll("tags")+qb.getTagSort(nil), nil, nil)
+	return qb.queryTags(selectAll("tags")+qb.getTagSort(nil).String(), nil, nil)
 }
 
 func (qb *TagQueryBuilder) AllSlim() ([]*Tag, error) {
-	return qb.queryTags("SELECT tags.id, tags.name FROM tags "+qb.getTagSort(nil), nil, nil)
+	return qb.queryTags("SELECT tags.id, tags.name FROM tags "+qb.getTagSort(nil).String(), nil, nil)
 }
 
-func (qb *TagQueryBuilder) Query(tagFilter *TagFilterType, findFilter *FindFilterType) ([]*Tag, int) {
+func (qb *TagQueryBuilder) Query(tagFilter *TagFilterType, findFilter *FindFilterType) ([]*Tag, int, error) {
 	if tagFilter == nil {
 		tagFilter = &TagFilterType{}
 	}
@@ -203,8 +215,33 @@ func (qb *TagQueryBuilder) Query(tagFilter *TagFilterType, findFilter *FindFilte
 		tableName: tagTable,
 	}
 
-	query.body = selectDistinctIDs(tagTable)
+	if q := findFilter.Q; q != nil && *q != "" {
+		searchColumns := []string{"tags.name", tagAliasesColumn}
+		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
+		query.addWhere(clause)
+		query.addArg(thisArgs...)
+	}
+
+	query.addFilter(qb.makeFilter(tagFilter))
+
+	query.paginate(qb.getTagSort(findFilter), findFilter)
+	query.approximateCount = findFilter.IsApproximateCount()
+	idsResult, countResult, err := query.executeFind()
+	if err != nil {
+		return nil, 0, err
+	}
 
+	var tags []*Tag
+	for _, id := range idsResult {
+		tag, _ := qb.Find(id, nil)
+		tags = append(tags, tag)
+	}
+
+	return tags, countResult, nil
+}
+
+// makeFilter returns the clauses of the tag filter and its sub-filters.
+func (qb *TagQueryBuilder) makeFilter(tagFilter *TagFilterType) *filterBuilder {
 	/*
 		query.body += `
 		left join tags_image on tags_image.tag_id = tags.id
@@ -218,34 +255,28 @@ func (qb *TagQueryBuilder) Query(tagFilter *TagFilterType, findFilter *FindFilte
 	// appears to confuse sqlite and causes serious performance issues.
 	// Disabling querying/sorting on marker count for now.
 
-	query.body += ` 
-	left join tags_image on tags_image.tag_id = tags.id
+	query := newFilterBuilder(tagTable, selectDistinctIDs(tagTable)+` 
 	left join scenes_tags on scenes_tags.tag_id = tags.id
-	left join scenes on scenes_tags.scene_id = scenes.id`
-
-	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"tags.name"}
-		clause, thisArgs := getSearchBinding(searchColumns, *q, false)
-		query.addWhere(clause)
-		query.addArg(thisArgs...)
-	}
-
-	if isMissingFilter := tagFilter.IsMissing; isMissingFilter != nil && *isMissingFilter != "" {
-		switch *isMissingFilter {
-		case "image":
-			query.addWhere("tags_image.tag_id IS NULL")
-		default:
-			query.addWhere("tags." + *isMissingFilter + " IS NULL")
-		}
-	}
-
-	if sceneCount := tagFilter.SceneCount; sceneCount != nil {
-		clause, count := getIntCriterionWhereClause("count(distinct scenes_tags.scene_id)", *sceneCount)
-		query.addHaving(clause)
-		if count == 1 {
-			query.addArg(sceneCount.Value)
-		}
-	}
+	left join scenes on scenes_tags.scene_id = scenes.id`)
+
+	query.handleCriteria(
+		stringCriterionHandler(tagFilter.Name, "tags.name"),
+		// only join the image table when filtering on it
+		isMissingCriterionHandler(tagFilter.IsMissing, "tags", map[string]isMissingJoin{
+			"image": {
+				table:    "tags_image",
+				onClause: "tags_image.tag_id = tags.id",
+				clause:   "tags_image.tag_id IS NULL",
+			},
+		}),
+		havingIntCriterionHandler(tagFilter.SceneCount, "count(distinct scenes_tags.scene_id)"),
+		tagSceneDateJoin.criterionHandler(tagFilter.FirstSceneDate, "MIN", "tags.id"),
+		tagSceneDateJoin.criterionHandler(tagFilter.LastSceneDate, "MAX", "tags.id"),
+		descendantTags.criterionHandler(tagFilter.Parents, "tags.id IN %s"),
+		ancestorTags.criterionHandler(tagFilter.Children, "tags.id IN %s"),
+		intCriterionHandler(tagFilter.ParentCount, tagParentCountExpression),
+		intCriterionHandler(tagFilter.ChildCount, tagChildCountExpression),
+	)
 
 	// if markerCount := tagFilter.MarkerCount; markerCount != nil {
 	// 	clause, count := getIntCriterionWhereClause("count(distinct scene_markers.id)", *markerCount)
@@ -255,29 +286,37 @@ func (qb *TagQueryBuilder) Query(tagFilter *TagFilterType, findFilter *FindFilte
 	// 	}
 	// }
 
-	query.sortAndPagination = qb.getTagSort(findFilter) + getPagination(findFilter)
-	idsResult, countResult := query.executeFind()
-
-	var tags []*Tag
-	for _, id := range idsResult {
-		tag, _ := qb.Find(id, nil)
-		tags = append(tags, tag)
+	if tagFilter.And != nil {
+		query.and = qb.makeFilter(tagFilter.And)
+	}
+	if tagFilter.Or != nil {
+		query.or = qb.makeFilter(tagFilter.Or)
+	}
+	if tagFilter.Not != nil {
+		query.not = qb.makeFilter(tagFilter.Not)
 	}
 
-	return tags, countResult
+	return query
 }
 
-func (qb *TagQueryBuilder) getTagSort(findFilter *FindFilterType) string {
-	var sort string
-	var direction string
+func (qb *TagQueryBuilder) getTagSort(findFilter *FindFilterType) sortTerms {
 	if findFilter == nil {
-		sort = "name"
-		direction = "ASC"
-	} else {
-		sort = findFilter.GetSort("name")
-		direction = findFilter.GetDirection()
+		return getSort([]sortField{{sort: "name", direction: "ASC"}}, "tags")
 	}
-	return getSort(sort, direction, "tags")
+
+	var ret sortTerms
+	for _, f := range findFilter.getSortFields("name", "ASC") {
+		switch f.sort {
+		case "parent_count":
+			ret = append(ret, sortTerm{expression: tagParentCountExpression, direction: f.direction})
+		case "child_count":
+			ret = append(ret, sortTerm{expression: tagChildCountExpression, direction: f.direction})
+		default:
+			ret = append(ret, getSort([]sortField{f}, "tags")...)
+		}
+	}
+
+	return ret
 }
 
 func (qb *TagQueryBuilder) queryTag(query string, args []interface{}, tx *sqlx.Tx) (*Tag, error) {
@@ -326,10 +365,16 @@ func (qb *TagQueryBuilder) UpdateTagImage(tagID int, image []byte, tx *sqlx.Tx)
 		return err
 	}
 
-	_, err := tx.Exec(
-		`INSERT INTO tags_image (tag_id, image) VALUES (?, ?)`,
+	image, file, err := tagImageBlob.store(tagID, image)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO tags_image (tag_id, image, image_file) VALUES (?, ?, ?)`,
 		tagID,
 		image,
+		file,
 	)
 
 	return err
@@ -338,15 +383,15 @@ func (qb *TagQueryBuilder) UpdateTagImage(tagID int, image []byte, tx *sqlx.Tx)
 func (qb *TagQueryBuilder) DestroyTagImage(tagID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
-	// Delete the existing joins
-	_, err := tx.Exec("DELETE FROM tags_image WHERE tag_id = ?", tagID)
-	if err != nil {
+	if err := destroyImageFiles(tagID, tx, tagImageBlob); err != nil {
 		return err
 	}
+
+	// Delete the existing joins
+	_, err := tx.Exec("DELETE FROM tags_image WHERE tag_id = ?", tagID)
 	return err
 }
 
 func (qb *TagQueryBuilder) GetTagImage(tagID int, tx *sqlx.Tx) ([]byte, error) {
-	query := `SELECT image from tags_image WHERE tag_id = ?`
-	return getImage(tx, query, tagID)
+	return tagImageBlob.get(tagID, tx)
 }
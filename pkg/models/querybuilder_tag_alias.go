@@ -0,0 +1,147 @@
+package models
+
+import (
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+// tagAliasesColumn is the expression of the aliases of a tag separated by
+// spaces, for searching.
+const tagAliasesColumn = "(SELECT GROUP_CONCAT(tag_aliases.alias, ' ') FROM tag_aliases WHERE tag_aliases.tag_id = tags.id)"
+
+// tagJoinTables are the tables joining objects to their tags, by the column
+// of the id of the object.
+var tagJoinTables = map[string]string{
+	"scenes_tags":        "scene_id",
+	"scene_markers_tags": "scene_marker_id",
+	"images_tags":        "image_id",
+	"galleries_tags":     "gallery_id",
+}
+
+// GetAliases returns the aliases of the tag, ordered by alias.
+func (qb *TagQueryBuilder) GetAliases(tagID int, tx *sqlx.Tx) ([]string, error) {
+	query := "SELECT alias FROM tag_aliases WHERE tag_id = ? ORDER BY alias COLLATE NATURAL_CI ASC"
+
+	ret := []string{}
+	var err error
+	if tx != nil {
+		err = tx.Select(&ret, query, tagID)
+	} else {
+		err = database.DB.Select(&ret, query, tagID)
+	}
+
+	return ret, err
+}
+
+// UpdateAliases replaces the aliases of the tag.
+func (qb *TagQueryBuilder) UpdateAliases(tagID int, aliases []string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM tag_aliases WHERE tag_id = ?", tagID); err != nil {
+		return err
+	}
+
+	for _, alias := range aliases {
+		if _, err := tx.Exec("INSERT INTO tag_aliases (tag_id, alias) VALUES (?, ?)", tagID, alias); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindByAlias returns the tag with the alias, regardless of case, or nil if
+// no tag has it.
+func (qb *TagQueryBuilder) FindByAlias(alias string, tx *sqlx.Tx) (*Tag, error) {
+	query := `
+		SELECT tags.* FROM tags
+		INNER JOIN tag_aliases ON tag_aliases.tag_id = tags.id
+		WHERE tag_aliases.alias = ?
+		LIMIT 1
+	`
+	args := []interface{}{alias}
+	return qb.queryTag(query, args, tx)
+}
+
+// FindByNameOrAlias returns the tag with the name, or if there is none, the
+// tag with the alias.
+func (qb *TagQueryBuilder) FindByNameOrAlias(name string, tx *sqlx.Tx, nocase bool) (*Tag, error) {
+	ret, err := qb.FindByName(name, tx, nocase)
+	if err != nil || ret != nil {
+		return ret, err
+	}
+
+	return qb.FindByAlias(name, tx)
+}
+
+// Merge moves the scenes, markers, images, galleries, parents, children and
+// aliases of the source tags to the destination tag and destroys the source
+// tags. The names of the source tags become aliases of the destination tag.
+func (qb *TagQueryBuilder) Merge(sourceIDs []int, destinationID int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if len(sourceIDs) == 0 {
+		return nil
+	}
+
+	inBinding := getInBinding(len(sourceIDs))
+	var sourceArgs []interface{}
+	for _, id := range sourceIDs {
+		sourceArgs = append(sourceArgs, id)
+	}
+	withArgs := func(args ...interface{}) []interface{} {
+		return append(args, sourceArgs...)
+	}
+
+	for table, idColumn := range tagJoinTables {
+		query := "INSERT INTO " + table + " (" + idColumn + ", tag_id) SELECT DISTINCT " + idColumn + ", ? FROM " + table + " AS source" +
+			" WHERE NOT EXISTS (SELECT 1 FROM " + table + " WHERE " + table + "." + idColumn + " = source." + idColumn + " AND " + table + ".tag_id = ?)" +
+			" AND source.tag_id IN " + inBinding
+		if _, err := tx.Exec(query, withArgs(destinationID, destinationID)...); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE tag_id IN "+inBinding, sourceArgs...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE scene_markers SET primary_tag_id = ? WHERE primary_tag_id IN "+inBinding, withArgs(destinationID)...); err != nil {
+		return err
+	}
+
+	// relations between the merged tags are dropped rather than becoming
+	// relations of the destination with itself
+	mergedBinding := getInBinding(len(sourceIDs) + 1)
+	mergedArgs := withArgs(destinationID)
+	relations := []string{
+		"INSERT OR IGNORE INTO tags_relations (parent_id, child_id) SELECT parent_id, ? FROM tags_relations WHERE child_id IN " + inBinding + " AND parent_id NOT IN " + mergedBinding,
+		"INSERT OR IGNORE INTO tags_relations (parent_id, child_id) SELECT ?, child_id FROM tags_relations WHERE parent_id IN " + inBinding + " AND child_id NOT IN " + mergedBinding,
+	}
+	for _, query := range relations {
+		if _, err := tx.Exec(query, append(withArgs(destinationID), mergedArgs...)...); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE tag_aliases SET tag_id = ? WHERE tag_id IN "+inBinding, withArgs(destinationID)...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT OR IGNORE INTO tag_aliases (tag_id, alias) SELECT ?, name FROM tags WHERE id IN "+inBinding, withArgs(destinationID)...); err != nil {
+		return err
+	}
+	// the destination is not its own alias
+	if _, err := tx.Exec("DELETE FROM tag_aliases WHERE tag_id = ? AND alias = (SELECT name FROM tags WHERE id = ?)", destinationID, destinationID); err != nil {
+		return err
+	}
+
+	for _, id := range sourceIDs {
+		if err := qb.Destroy(strconv.Itoa(id), tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+const (
+	tagParentCountExpression = "(SELECT COUNT(*) FROM tags_relations WHERE tags_relations.child_id = tags.id)"
+	tagChildCountExpression  = "(SELECT COUNT(*) FROM tags_relations WHERE tags_relations.parent_id = tags.id)"
+)
+
+// tagHierarchy selects the tags related to a set of tags through the tag
+// hierarchy.
+type tagHierarchy struct {
+	// ancestors selects the parents of the tags rather than their children
+	ancestors bool
+	// excludeRoot selects only the related tags, not the tags themselves
+	excludeRoot bool
+}
+
+var (
+	// subTags selects tags and their children
+	subTags = tagHierarchy{}
+	// descendantTags selects the children of tags
+	descendantTags = tagHierarchy{excludeRoot: true}
+	// ancestorTags selects the parents of tags
+	ancestorTags = tagHierarchy{ancestors: true, excludeRoot: true}
+)
+
+// subquery returns a subquery selecting the ids of the tags related to the n
+// tags with the ids bound to it, up to depth levels below or above them. All
+// levels are selected if depth is negative.
+func (h tagHierarchy) subquery(n int, depth int) string {
+	if depth == 0 && !h.excludeRoot {
+		return getInBinding(n)
+	}
+
+	from, to := "parent_id", "child_id"
+	if h.ancestors {
+		from, to = to, from
+	}
+
+	// the level of each tag is selected only if the depth is limited. Without
+	// it, tags already selected are not selected again, so the recursion ends
+	// on cycles
+	columns := "id"
+	rootLevel, childLevel, nextLevel := "", "", ""
+	where := ""
+	if depth >= 0 {
+		columns = "id, level"
+		rootLevel, childLevel, nextLevel = ", 0", ", 1", ", tag_tree.level + 1"
+		where = " WHERE tag_tree.level < " + strconv.Itoa(depth)
+	}
+
+	anchor := "SELECT tags.id" + rootLevel + " FROM tags WHERE tags.id IN " + getInBinding(n)
+	if h.excludeRoot {
+		anchor = "SELECT tags_relations." + to + childLevel + " FROM tags_relations WHERE tags_relations." + from + " IN " + getInBinding(n)
+	}
+	recursive := "SELECT tags_relations." + to + nextLevel + " FROM tags_relations JOIN tag_tree ON tags_relations." + from + " = tag_tree.id" + where
+
+	return "(WITH RECURSIVE tag_tree(" + columns + ") AS (" + anchor + " UNION " + recursive + ") SELECT id FROM tag_tree)"
+}
+
+// criterionHandler handles a criterion on the tags of objects, matching the
+// tags of the criterion and the tags related to them up to the depth of the
+// criterion. Each of the clauses matches the objects with one of the tags
+// selected by the subquery substituted for %s, and objects matching any of
+// the clauses match.
+func (h tagHierarchy) criterionHandler(c *HierarchicalMultiCriterionInput, clauses ...string) criterionHandlerFunc {
+	return hierarchicalCriterionHandler(c, h.excludeRoot, h.subquery, clauses...)
+}
+
+// hierarchicalCriterionHandler handles a criterion on objects related to a
+// hierarchy, such as tags or studios. subquery returns a subquery selecting
+// the ids of the objects related to the n objects with the ids bound to it,
+// up to depth levels from them. The depth of the criterion is increased by
+// one if excludeRoot is true, so that a depth of 0 selects the directly
+// related objects.
+func hierarchicalCriterionHandler(c *HierarchicalMultiCriterionInput, excludeRoot bool, subquery func(n int, depth int) string, clauses ...string) criterionHandlerFunc {
+	return func(f *filterBuilder) {
+		if c == nil || len(c.Value) == 0 {
+			return
+		}
+
+		depth := 0
+		if c.Depth != nil {
+			depth = *c.Depth
+		}
+		if excludeRoot && depth >= 0 {
+			depth++
+		}
+
+		match := func(ids []string) (string, []interface{}) {
+			related := subquery(len(ids), depth)
+
+			var matches []string
+			var args []interface{}
+			for _, clause := range clauses {
+				matches = append(matches, fmt.Sprintf(clause, related))
+				for _, id := range ids {
+					args = append(args, id)
+				}
+			}
+
+			return "(" + strings.Join(matches, " OR ") + ")", args
+		}
+
+		switch c.Modifier {
+		case CriterionModifierIncludes:
+			clause, args := match(c.Value)
+			f.where(clause, args...)
+		case CriterionModifierIncludesAll:
+			for _, id := range c.Value {
+				clause, args := match([]string{id})
+				f.where(clause, args...)
+			}
+		case CriterionModifierExcludes:
+			clause, args := match(c.Value)
+			f.where("NOT "+clause, args...)
+		}
+	}
+}
+
+// joinTableTagClause returns a clause of a tag hierarchy criterion matching
+// the objects of primaryTable with tags in joinTable.
+func joinTableTagClause(primaryTable string, joinTable string, primaryFK string) string {
+	return "EXISTS (SELECT 1 FROM " + joinTable + " WHERE " + joinTable + "." + primaryFK + " = " + primaryTable + ".id AND " + joinTable + ".tag_id IN %s)"
+}
+
+func (qb *TagQueryBuilder) FindByParentTagID(parentID int, tx *sqlx.Tx) ([]*Tag, error) {
+	query := `
+		SELECT tags.* FROM tags
+		INNER JOIN tags_relations ON tags_relations.child_id = tags.id
+		WHERE tags_relations.parent_id = ?
+	`
+	query += qb.getTagSort(nil).String()
+	args := []interface{}{parentID}
+	return qb.queryTags(query, args, tx)
+}
+
+func (qb *TagQueryBuilder) FindByChildTagID(childID int, tx *sqlx.Tx) ([]*Tag, error) {
+	query := `
+		SELECT tags.* FROM tags
+		INNER JOIN tags_relations ON tags_relations.parent_id = tags.id
+		WHERE tags_relations.child_id = ?
+	`
+	query += qb.getTagSort(nil).String()
+	args := []interface{}{childID}
+	return qb.queryTags(query, args, tx)
+}
+
+func (qb *TagQueryBuilder) CountByParentTagID(parentID int) (int, error) {
+	args := []interface{}{parentID}
+	return runCountQuery(buildCountQuery("SELECT child_id FROM tags_relations WHERE parent_id = ?"), args)
+}
+
+func (qb *TagQueryBuilder) CountByChildTagID(childID int) (int, error) {
+	args := []interface{}{childID}
+	return runCountQuery(buildCountQuery("SELECT parent_id FROM tags_relations WHERE child_id = ?"), args)
+}
+
+// UpdateParentTags replaces the parents of the tag.
+func (qb *TagQueryBuilder) UpdateParentTags(tagID int, parentIDs []int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM tags_relations WHERE child_id = ?", tagID); err != nil {
+		return err
+	}
+
+	for _, parentID := range parentIDs {
+		if _, err := tx.Exec("INSERT INTO tags_relations (parent_id, child_id) VALUES (?, ?)", parentID, tagID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateChildTags replaces the children of the tag.
+func (qb *TagQueryBuilder) UpdateChildTags(tagID int, childIDs []int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	if _, err := tx.Exec("DELETE FROM tags_relations WHERE parent_id = ?", tagID); err != nil {
+		return err
+	}
+
+	for _, childID := range childIDs {
+		if _, err := tx.Exec("INSERT INTO tags_relations (parent_id, child_id) VALUES (?, ?)", tagID, childID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindDescendantIDs returns the ids of the children of the tag, and of their
+// children, at any depth. The tag is included only if the hierarchy has a
+// cycle through it.
+func (qb *TagQueryBuilder) FindDescendantIDs(tagID int, tx *sqlx.Tx) ([]int, error) {
+	query := "SELECT tag_tree_ids.id FROM " + descendantTags.subquery(1, -1) + " AS tag_tree_ids"
+
+	var ret []int
+	var err error
+	if tx != nil {
+		err = tx.Select(&ret, query, tagID)
+	} else {
+		err = database.DB.Select(&ret, query, tagID)
+	}
+
+	return ret, err
+}
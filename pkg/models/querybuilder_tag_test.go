@@ -5,11 +5,15 @@ package models_test
 import (
 	"context"
 	"database/sql"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/jmoiron/sqlx"
+
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -121,12 +125,14 @@ func TestTagQueryIsMissingImage(t *testing.T) {
 		Q: &q,
 	}
 
-	tags, _ := qb.Query(&tagFilter, &findFilter)
+	tags, _, err := qb.Query(&tagFilter, &findFilter)
+	assert.NoError(t, err)
 
 	assert.Len(t, tags, 0)
 
 	findFilter.Q = nil
-	tags, _ = qb.Query(&tagFilter, &findFilter)
+	tags, _, err = qb.Query(&tagFilter, &findFilter)
+	assert.NoError(t, err)
 
 	// ensure non of the ids equal the one with image
 	for _, tag := range tags {
@@ -159,7 +165,8 @@ func verifyTagSceneCount(t *testing.T, sceneCountCriterion models.IntCriterionIn
 		SceneCount: &sceneCountCriterion,
 	}
 
-	tags, _ := qb.Query(&tagFilter, nil)
+	tags, _, err := qb.Query(&tagFilter, nil)
+	assert.NoError(t, err)
 
 	for _, tag := range tags {
 		verifyInt64(t, sql.NullInt64{
@@ -196,7 +203,8 @@ func verifyTagMarkerCount(t *testing.T, markerCountCriterion models.IntCriterion
 		MarkerCount: &markerCountCriterion,
 	}
 
-	tags, _ := qb.Query(&tagFilter, nil)
+	tags, _, err := qb.Query(&tagFilter, nil)
+	assert.NoError(t, err)
 
 	for _, tag := range tags {
 		verifyInt64(t, sql.NullInt64{
@@ -307,8 +315,318 @@ func TestTagDestroyTagImage(t *testing.T) {
 // TODO Destroy
 // TODO Find
 // TODO FindBySceneID
+func TestTagHierarchy(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	// grandparent > parent > child, with a scene tagged with the child
+	qb := models.NewTagQueryBuilder()
+	grandparentID := f.tag(models.Tag{Name: "TestTagHierarchyGrandparent"}).ID
+	parentID := f.tag(models.Tag{Name: "TestTagHierarchyParent"}).ID
+	childID := f.tag(models.Tag{Name: "TestTagHierarchyChild"}).ID
+	scene := f.scene(models.Scene{Path: "TestTagHierarchy"})
+
+	sqb := models.NewSceneQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	withTxn(t, func(tx *sqlx.Tx) error {
+		if err := qb.UpdateChildTags(grandparentID, []int{parentID}, tx); err != nil {
+			return err
+		}
+		if err := qb.UpdateParentTags(childID, []int{parentID}, tx); err != nil {
+			return err
+		}
+		return jqb.CreateScenesTags([]models.ScenesTags{{SceneID: scene.ID, TagID: childID}}, tx)
+	})
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return jqb.DestroyScenesTags(scene.ID, tx)
+	})
+
+	parents, err := qb.FindByChildTagID(childID, nil)
+	if err != nil {
+		t.Fatalf("Error finding parent tags: %s", err.Error())
+	}
+	assert.Len(t, parents, 1)
+	assert.Equal(t, parentID, parents[0].ID)
+
+	children, err := qb.FindByParentTagID(grandparentID, nil)
+	if err != nil {
+		t.Fatalf("Error finding child tags: %s", err.Error())
+	}
+	assert.Len(t, children, 1)
+	assert.Equal(t, parentID, children[0].ID)
+
+	count, err := qb.CountByParentTagID(parentID)
+	if err != nil {
+		t.Fatalf("Error counting child tags: %s", err.Error())
+	}
+	assert.Equal(t, 1, count)
+
+	descendantIDs, err := qb.FindDescendantIDs(grandparentID, nil)
+	if err != nil {
+		t.Fatalf("Error finding descendant tags: %s", err.Error())
+	}
+	assert.ElementsMatch(t, []int{parentID, childID}, descendantIDs)
+
+	criterion := func(id int, depth int) *models.HierarchicalMultiCriterionInput {
+		return &models.HierarchicalMultiCriterionInput{
+			Value:    []string{strconv.Itoa(id)},
+			Modifier: models.CriterionModifierIncludes,
+			Depth:    &depth,
+		}
+	}
+
+	querySceneIDs := func(c *models.HierarchicalMultiCriterionInput) []int {
+		scenes, _, err := sqb.Query(&models.SceneFilterType{Tags: c}, nil)
+		if err != nil {
+			t.Fatalf("Error querying scenes: %s", err.Error())
+		}
+		var ret []int
+		for _, s := range scenes {
+			ret = append(ret, s.ID)
+		}
+		return ret
+	}
+
+	// sub-tags are included up to the depth
+	assert.Len(t, querySceneIDs(criterion(grandparentID, 0)), 0)
+	assert.Len(t, querySceneIDs(criterion(grandparentID, 1)), 0)
+	assert.Equal(t, []int{scene.ID}, querySceneIDs(criterion(grandparentID, 2)))
+	assert.Equal(t, []int{scene.ID}, querySceneIDs(criterion(grandparentID, -1)))
+
+	excludes := criterion(grandparentID, -1)
+	excludes.Modifier = models.CriterionModifierExcludes
+	assert.NotContains(t, querySceneIDs(excludes), scene.ID)
+
+	includesAll := &models.HierarchicalMultiCriterionInput{
+		Value:    []string{strconv.Itoa(grandparentID), strconv.Itoa(parentID)},
+		Modifier: models.CriterionModifierIncludesAll,
+	}
+	depth := -1
+	includesAll.Depth = &depth
+	assert.Equal(t, []int{scene.ID}, querySceneIDs(includesAll))
+
+	queryTagIDs := func(tagFilter models.TagFilterType, findFilter *models.FindFilterType) []int {
+		tags, _, err := qb.Query(&tagFilter, findFilter)
+		if err != nil {
+			t.Fatalf("Error querying tags: %s", err.Error())
+		}
+		var ret []int
+		for _, tag := range tags {
+			ret = append(ret, tag.ID)
+		}
+		return ret
+	}
+
+	assert.Equal(t, []int{parentID}, queryTagIDs(models.TagFilterType{Parents: criterion(grandparentID, 0)}, nil))
+	assert.ElementsMatch(t, []int{parentID, childID}, queryTagIDs(models.TagFilterType{Parents: criterion(grandparentID, -1)}, nil))
+	assert.Equal(t, []int{parentID}, queryTagIDs(models.TagFilterType{Children: criterion(childID, 0)}, nil))
+	assert.ElementsMatch(t, []int{grandparentID, parentID}, queryTagIDs(models.TagFilterType{Children: criterion(childID, 1)}, nil))
+
+	hasParent := models.IntCriterionInput{Value: 0, Modifier: models.CriterionModifierGreaterThan}
+	assert.ElementsMatch(t, []int{parentID, childID}, queryTagIDs(models.TagFilterType{ParentCount: &hasParent}, nil))
+
+	// tags with children are sorted first by child count
+	sort := "child_count"
+	direction := models.SortDirectionEnumDesc
+	sorted := queryTagIDs(models.TagFilterType{}, &models.FindFilterType{Sort: &sort, Direction: &direction})
+	assert.ElementsMatch(t, []int{grandparentID, parentID}, sorted[:2])
+}
+
+// TestTagHierarchyCycle checks that descendants are found when the hierarchy
+// has a cycle, and include the tags of the cycle.
+func TestTagHierarchyCycle(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewTagQueryBuilder()
+	var ids []int
+	for _, name := range []string{"TestTagHierarchyCycle1", "TestTagHierarchyCycle2"} {
+		created, err := qb.Create(models.Tag{Name: name}, tx)
+		if err != nil {
+			t.Fatalf("Error creating tag: %s", err.Error())
+		}
+		ids = append(ids, created.ID)
+	}
+
+	if err := qb.UpdateChildTags(ids[0], []int{ids[1]}, tx); err != nil {
+		t.Fatalf("Error updating child tags: %s", err.Error())
+	}
+	if err := qb.UpdateChildTags(ids[1], []int{ids[0]}, tx); err != nil {
+		t.Fatalf("Error updating child tags: %s", err.Error())
+	}
+
+	descendantIDs, err := qb.FindDescendantIDs(ids[0], tx)
+	if err != nil {
+		t.Fatalf("Error finding descendant tags: %s", err.Error())
+	}
+	assert.ElementsMatch(t, ids, descendantIDs)
+}
+
+func TestTagAliases(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewTagQueryBuilder()
+	created, err := qb.Create(models.Tag{Name: "TestTagAliases"}, tx)
+	if err != nil {
+		t.Fatalf("Error creating tag: %s", err.Error())
+	}
+
+	if err := qb.UpdateAliases(created.ID, []string{"TestTagAlias2", "TestTagAlias1"}, tx); err != nil {
+		t.Fatalf("Error updating aliases: %s", err.Error())
+	}
+
+	aliases, err := qb.GetAliases(created.ID, tx)
+	if err != nil {
+		t.Fatalf("Error getting aliases: %s", err.Error())
+	}
+	assert.Equal(t, []string{"TestTagAlias1", "TestTagAlias2"}, aliases)
+
+	// aliases match regardless of case
+	tag, err := qb.FindByNameOrAlias("testtagalias1", tx, true)
+	if err != nil {
+		t.Fatalf("Error finding tag by alias: %s", err.Error())
+	}
+	if assert.NotNil(t, tag) {
+		assert.Equal(t, created.ID, tag.ID)
+	}
+
+	// aliases are unique
+	other, err := qb.Create(models.Tag{Name: "TestTagAliasesOther"}, tx)
+	if err != nil {
+		t.Fatalf("Error creating tag: %s", err.Error())
+	}
+	assert.NotNil(t, qb.UpdateAliases(other.ID, []string{"TESTTAGALIAS2"}, tx))
+}
+
+func TestTagMerge(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewTagQueryBuilder()
+	var ids []int
+	for _, name := range []string{"TestTagMergeDestination", "TestTagMergeSource1", "TestTagMergeSource2", "TestTagMergeParent"} {
+		created, err := qb.Create(models.Tag{Name: name}, tx)
+		if err != nil {
+			t.Fatalf("Error creating tag: %s", err.Error())
+		}
+		ids = append(ids, created.ID)
+	}
+	destinationID, sourceIDs, parentID := ids[0], ids[1:3], ids[3]
+
+	if err := qb.UpdateAliases(sourceIDs[0], []string{"TestTagMergeAlias"}, tx); err != nil {
+		t.Fatalf("Error updating aliases: %s", err.Error())
+	}
+	if err := qb.UpdateParentTags(sourceIDs[1], []int{parentID, sourceIDs[0]}, tx); err != nil {
+		t.Fatalf("Error updating parent tags: %s", err.Error())
+	}
+
+	sqb := models.NewSceneQueryBuilder()
+	scene, err := sqb.Create(models.Scene{
+		Path:     "TestTagMerge",
+		Checksum: sql.NullString{String: utils.MD5FromString("TestTagMerge"), Valid: true},
+	}, tx)
+	if err != nil {
+		t.Fatalf("Error creating scene: %s", err.Error())
+	}
+
+	jqb := models.NewJoinsQueryBuilder()
+	if err := jqb.CreateScenesTags([]models.ScenesTags{
+		{SceneID: scene.ID, TagID: destinationID},
+		{SceneID: scene.ID, TagID: sourceIDs[0]},
+		{SceneID: scene.ID, TagID: sourceIDs[1]},
+	}, tx); err != nil {
+		t.Fatalf("Error creating scene tags: %s", err.Error())
+	}
+
+	if err := qb.Merge(sourceIDs, destinationID, tx); err != nil {
+		t.Fatalf("Error merging tags: %s", err.Error())
+	}
+
+	for _, id := range sourceIDs {
+		source, err := qb.Find(id, tx)
+		if err != nil {
+			t.Fatalf("Error finding tag: %s", err.Error())
+		}
+		assert.Nil(t, source)
+	}
+
+	sceneTags, err := qb.FindBySceneID(scene.ID, tx)
+	if err != nil {
+		t.Fatalf("Error finding scene tags: %s", err.Error())
+	}
+	assert.Equal(t, []int{destinationID}, getIDsOfTags(sceneTags))
+
+	// the relation between the source tags is dropped
+	parents, err := qb.FindByChildTagID(destinationID, tx)
+	if err != nil {
+		t.Fatalf("Error finding parent tags: %s", err.Error())
+	}
+	assert.Equal(t, []int{parentID}, getIDsOfTags(parents))
+
+	aliases, err := qb.GetAliases(destinationID, tx)
+	if err != nil {
+		t.Fatalf("Error getting aliases: %s", err.Error())
+	}
+	assert.Equal(t, []string{"TestTagMergeAlias", "TestTagMergeSource1", "TestTagMergeSource2"}, aliases)
+}
+
+func getIDsOfTags(tags []*models.Tag) []int {
+	var ret []int
+	for _, tag := range tags {
+		ret = append(ret, tag.ID)
+	}
+	return ret
+}
+
 // TODO FindBySceneMarkerID
 // TODO Count
 // TODO All
 // TODO AllSlim
 // TODO Query
+
+func TestTagQueryNaturalSort(t *testing.T) {
+	f := newTestFixtures(t)
+	defer f.destroy()
+
+	var created []*models.Tag
+	for _, name := range []string{"TestTagQueryNaturalSort 10", "testTagQueryNaturalSort 2", "TestTagQueryNaturalSort 1"} {
+		created = append(created, f.tag(models.Tag{Name: name}))
+	}
+
+	qb := models.NewTagQueryBuilder()
+	q := "TestTagQueryNaturalSort"
+	sort := "name"
+	findFilter := models.FindFilterType{
+		Q:    &q,
+		Sort: &sort,
+	}
+
+	names := func() []string {
+		tags, _, err := qb.Query(nil, &findFilter)
+		if err != nil {
+			t.Fatalf("Error querying tags: %s", err.Error())
+		}
+
+		var ret []string
+		for _, tag := range tags {
+			ret = append(ret, tag.Name)
+		}
+		return ret
+	}
+
+	assert.Equal(t, []string{"TestTagQueryNaturalSort 1", "testTagQueryNaturalSort 2", "TestTagQueryNaturalSort 10"}, names())
+
+	// the sort key follows changes of the name
+	renamed := *created[0]
+	renamed.Name = "TestTagQueryNaturalSort 0"
+	withTxn(t, func(tx *sqlx.Tx) error {
+		_, err := qb.Update(renamed, tx)
+		return err
+	})
+
+	assert.Equal(t, []string{"TestTagQueryNaturalSort 0", "TestTagQueryNaturalSort 1", "testTagQueryNaturalSort 2"}, names())
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// uniqueUUID returns the uuid of a new object in the table, or a new uuid if
+// it is not a valid uuid or another object in the table has it. Imported
+// objects keep their uuids unless they are taken.
+func uniqueUUID(table string, uuid sql.NullString, tx *sqlx.Tx) (sql.NullString, error) {
+	if uuid.Valid && utils.IsUUID(uuid.String) {
+		var taken bool
+		if err := tx.Get(&taken, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE uuid = ?)", uuid.String); err != nil {
+			return sql.NullString{}, err
+		}
+
+		if !taken {
+			return uuid, nil
+		}
+	}
+
+	return sql.NullString{String: utils.GenerateUUID(), Valid: true}, nil
+}
+
+// FindByUUID returns the scene with the uuid, or nil if no scene has it.
+func (qb *SceneQueryBuilder) FindByUUID(uuid string) (*Scene, error) {
+	query := "SELECT * FROM scenes WHERE uuid = ? LIMIT 1"
+	args := []interface{}{uuid}
+	return qb.queryScene(query, args, nil)
+}
+
+// FindByUUID returns the tag with the uuid, or nil if no tag has it.
+func (qb *TagQueryBuilder) FindByUUID(uuid string, tx *sqlx.Tx) (*Tag, error) {
+	query := "SELECT * FROM tags WHERE uuid = ? LIMIT 1"
+	args := []interface{}{uuid}
+	return qb.queryTag(query, args, tx)
+}
@@ -0,0 +1,79 @@
+// +build integration
+
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func TestUUIDCreate(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewTagQueryBuilder()
+
+	// generated if not given
+	created, err := qb.Create(models.Tag{Name: "TestUUIDCreate"}, tx)
+	if err != nil {
+		t.Fatalf("Error creating tag: %s", err.Error())
+	}
+	assert.True(t, utils.IsUUID(created.UUID.String))
+
+	// kept if given
+	uuid := utils.GenerateUUID()
+	created, err = qb.Create(models.Tag{Name: "TestUUIDCreate2", UUID: sql.NullString{String: uuid, Valid: true}}, tx)
+	if err != nil {
+		t.Fatalf("Error creating tag: %s", err.Error())
+	}
+	assert.Equal(t, uuid, created.UUID.String)
+
+	// replaced if taken or not a uuid
+	for _, given := range []string{uuid, "not a uuid"} {
+		created, err = qb.Create(models.Tag{Name: "TestUUIDCreate " + given, UUID: sql.NullString{String: given, Valid: true}}, tx)
+		if err != nil {
+			t.Fatalf("Error creating tag: %s", err.Error())
+		}
+		assert.NotEqual(t, given, created.UUID.String)
+		assert.True(t, utils.IsUUID(created.UUID.String))
+	}
+}
+
+func TestUUIDUpdate(t *testing.T) {
+	ctx := context.TODO()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Rollback()
+
+	qb := models.NewStudioQueryBuilder()
+
+	name := "TestUUIDUpdate"
+	created, err := qb.Create(models.Studio{
+		Name:     sql.NullString{String: name, Valid: true},
+		Checksum: utils.MD5FromString(name),
+	}, tx)
+	if err != nil {
+		t.Fatalf("Error creating studio: %s", err.Error())
+	}
+
+	// full updates without a uuid keep the existing uuid
+	updated := *created
+	updated.UUID = sql.NullString{}
+	updated.URL = sql.NullString{String: "http://example.com", Valid: true}
+	if _, err := qb.UpdateFull(updated, tx); err != nil {
+		t.Fatalf("Error updating studio: %s", err.Error())
+	}
+
+	found, err := qb.Find(created.ID, tx)
+	if err != nil {
+		t.Fatalf("Error finding studio: %s", err.Error())
+	}
+	assert.Equal(t, created.UUID, found.UUID)
+}
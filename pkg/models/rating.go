@@ -0,0 +1,21 @@
+package models
+
+// Ratings are stored on a 1-100 scale. The legacy 1-5 rating is derived
+// from it, with each legacy value covering a range of 20.
+
+// Rating5To100 converts a rating on the 1-5 scale to the 1-100 scale.
+func Rating5To100(rating int) int {
+	return rating * 20
+}
+
+// Rating100To5 converts a rating on the 1-100 scale to the 1-5 scale,
+// rounding up.
+func Rating100To5(rating int) int {
+	return (rating + 19) / 20
+}
+
+// getRating5Expression returns an SQL expression of the 1-5 rating of a
+// column holding ratings on the 1-100 scale.
+func getRating5Expression(column string) string {
+	return "((" + column + " + 19) / 20)"
+}
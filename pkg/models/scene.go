@@ -21,10 +21,12 @@ type SceneReader interface {
 	// CountByTagID(tagID int) (int, error)
 	// CountMissingChecksum() (int, error)
 	// CountMissingOSHash() (int, error)
+	// FindMissingHashes(checksum bool) ([]*Scene, error)
+	// FindByStashID(stashID StashID) ([]*Scene, error)
 	// Wall(q *string) ([]*Scene, error)
 	All() ([]*Scene, error)
-	// Query(sceneFilter *SceneFilterType, findFilter *FindFilterType) ([]*Scene, int)
-	// QueryAllByPathRegex(regex string) ([]*Scene, error)
+	// Query(sceneFilter *SceneFilterType, findFilter *FindFilterType) ([]*Scene, int, error)
+	// QueryAllByPathOrTitleRegex(regex string, ignoreOrganized bool) ([]*Scene, error)
 	// QueryByPathRegex(findFilter *FindFilterType) ([]*Scene, int)
 	GetSceneCover(sceneID int) ([]byte, error)
 }
@@ -11,7 +11,7 @@ type SceneMarkerReader interface {
 	// CountByTagID(tagID int) (int, error)
 	// GetMarkerStrings(q *string, sort *string) ([]*MarkerStringsResultType, error)
 	// Wall(q *string) ([]*SceneMarker, error)
-	// Query(sceneMarkerFilter *SceneMarkerFilterType, findFilter *FindFilterType) ([]*SceneMarker, int)
+	// Query(sceneMarkerFilter *SceneMarkerFilterType, findFilter *FindFilterType) ([]*SceneMarker, int, error)
 }
 
 type SceneMarkerWriter interface {
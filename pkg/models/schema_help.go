@@ -0,0 +1,91 @@
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GetSchemaHelp returns the documentation of the types of the schema, ordered
+// by name. Only the type with the given name is returned if name is not nil.
+// Built-in types are not included.
+func GetSchemaHelp(name *string) []*SchemaTypeHelp {
+	schema := NewExecutableSchema(Config{}).Schema()
+
+	ret := []*SchemaTypeHelp{}
+	for _, def := range schema.Types {
+		if def.BuiltIn || strings.HasPrefix(def.Name, "__") {
+			continue
+		}
+		if name != nil && def.Name != *name {
+			continue
+		}
+
+		ret = append(ret, newSchemaTypeHelp(def))
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+
+	return ret
+}
+
+func newSchemaTypeHelp(def *ast.Definition) *SchemaTypeHelp {
+	ret := &SchemaTypeHelp{
+		Name:        def.Name,
+		Kind:        string(def.Kind),
+		Description: schemaDescription(def.Description),
+		Fields:      []*SchemaFieldHelp{},
+		Values:      []*SchemaEnumValueHelp{},
+	}
+
+	for _, f := range def.Fields {
+		if strings.HasPrefix(f.Name, "__") {
+			continue
+		}
+
+		field := &SchemaFieldHelp{
+			Name:        f.Name,
+			Description: schemaDescription(f.Description),
+			Type:        f.Type.String(),
+			Arguments:   []*SchemaFieldHelp{},
+		}
+
+		for _, a := range f.Arguments {
+			field.Arguments = append(field.Arguments, &SchemaFieldHelp{
+				Name:        a.Name,
+				Description: schemaDescription(a.Description),
+				Type:        a.Type.String(),
+				Arguments:   []*SchemaFieldHelp{},
+			})
+		}
+
+		ret.Fields = append(ret.Fields, field)
+	}
+
+	for _, v := range def.EnumValues {
+		ret.Values = append(ret.Values, &SchemaEnumValueHelp{
+			Name:        v.Name,
+			Description: schemaDescription(v.Description),
+		})
+	}
+
+	return ret
+}
+
+func schemaDescription(description string) *string {
+	if description == "" {
+		return nil
+	}
+
+	// multi-line descriptions are indented with the field in the schema
+	lines := strings.Split(description, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	ret := strings.Join(lines, " ")
+
+	return &ret
+}
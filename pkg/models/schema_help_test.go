@@ -0,0 +1,57 @@
+// +build integration
+
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaHelpFieldDescriptions(t *testing.T) {
+	for _, typ := range models.GetSchemaHelp(nil) {
+		for _, f := range typ.Fields {
+			assert.NotNil(t, f.Description, "%s.%s has no description", typ.Name, f.Name)
+		}
+	}
+}
+
+func TestSchemaHelpByName(t *testing.T) {
+	name := "CriterionModifier"
+	help := models.GetSchemaHelp(&name)
+	if !assert.Len(t, help, 1) {
+		return
+	}
+
+	modifier := help[0]
+	assert.Equal(t, "ENUM", modifier.Kind)
+	assert.NotNil(t, modifier.Description)
+	assert.Len(t, modifier.Fields, 0)
+	assert.NotEmpty(t, modifier.Values)
+	for _, v := range modifier.Values {
+		assert.NotNil(t, v.Description, "%s has no description", v.Name)
+	}
+
+	name = "Query"
+	help = models.GetSchemaHelp(&name)
+	if !assert.Len(t, help, 1) {
+		return
+	}
+
+	var found bool
+	for _, f := range help[0].Fields {
+		if f.Name == "schemaHelp" {
+			found = true
+			assert.Equal(t, "[SchemaTypeHelp!]!", f.Type)
+			if assert.Len(t, f.Arguments, 1) {
+				assert.Equal(t, "name", f.Arguments[0].Name)
+				assert.Equal(t, "String", f.Arguments[0].Type)
+			}
+		}
+	}
+	assert.True(t, found)
+
+	name = "NotAType"
+	assert.Len(t, models.GetSchemaHelp(&name), 0)
+}
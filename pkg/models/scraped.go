@@ -23,6 +23,30 @@ func MatchScrapedScenePerformer(p *ScrapedScenePerformer) error {
 	return nil
 }
 
+// MatchScrapedSceneStashID sets the DuplicateSceneIDs field of the provided
+// stash-box scene to the ids of the scenes in the database that already have
+// its stash id.
+func MatchScrapedSceneStashID(s *ScrapedScene, endpoint string) error {
+	if s.RemoteSiteID == nil {
+		return nil
+	}
+
+	qb := NewSceneQueryBuilder()
+	scenes, err := qb.FindByStashID(StashID{
+		StashID:  *s.RemoteSiteID,
+		Endpoint: endpoint,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.DuplicateSceneIDs = nil
+	for _, scene := range scenes {
+		s.DuplicateSceneIDs = append(s.DuplicateSceneIDs, strconv.Itoa(scene.ID))
+	}
+	return nil
+}
+
 // MatchScrapedSceneStudio matches the provided studio with the studios
 // in the database and sets the ID field if one is found.
 func MatchScrapedSceneStudio(s *ScrapedSceneStudio) error {
@@ -70,7 +94,7 @@ func MatchScrapedSceneMovie(m *ScrapedSceneMovie) error {
 func MatchScrapedSceneTag(s *ScrapedSceneTag) error {
 	qb := NewTagQueryBuilder()
 
-	tag, err := qb.FindByName(s.Name, nil, true)
+	tag, err := qb.FindByNameOrAlias(s.Name, nil, true)
 
 	if err != nil {
 		return err
@@ -277,8 +277,9 @@ func getSceneStringValue(index int, field string) string {
 }
 
 func getRating(index int) sql.NullInt64 {
+	// 1-100 ratings of 10, 40, 50, 80 and 90, which are legacy ratings of 1-5
 	rating := index % 6
-	return sql.NullInt64{Int64: int64(rating), Valid: rating > 0}
+	return sql.NullInt64{Int64: int64(rating*20 - index%2*10), Valid: rating > 0}
 }
 
 func getOCounter(index int) int {
@@ -803,3 +804,210 @@ func addTagImage(tx *sqlx.Tx, tagIndex int) error {
 
 	return qb.UpdateTagImage(tagIDs[tagIndex], models.DefaultTagImage, tx)
 }
+
+// withTxn runs fn in a transaction, which is committed if fn succeeds. The
+// test fails if fn or the commit fails.
+func withTxn(t *testing.T, fn func(tx *sqlx.Tx) error) {
+	t.Helper()
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		t.Fatalf("Error in transaction: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+}
+
+// testFixtures creates the objects used by a single test, in addition to
+// the ones created by populateDB, and destroys them once the test is done:
+//
+//	f := newTestFixtures(t)
+//	defer f.destroy()
+//	scene := f.scene(models.Scene{Path: "TestName"})
+type testFixtures struct {
+	t        *testing.T
+	destroys []func(tx *sqlx.Tx) error
+}
+
+func newTestFixtures(t *testing.T) *testFixtures {
+	return &testFixtures{t: t}
+}
+
+// onDestroy adds fn to the functions run by destroy, such as to restore an
+// object created by populateDB.
+func (f *testFixtures) onDestroy(fn func(tx *sqlx.Tx) error) {
+	f.destroys = append(f.destroys, fn)
+}
+
+// destroy destroys the objects in the reverse order of their creation.
+func (f *testFixtures) destroy() {
+	f.t.Helper()
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	for i := len(f.destroys) - 1; i >= 0; i-- {
+		if err := f.destroys[i](tx); err != nil {
+			tx.Rollback()
+			f.t.Errorf("Error destroying test objects: %s", err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		f.t.Errorf("Error committing: %s", err.Error())
+	}
+}
+
+// scene creates scene, with the MD5 of its path as checksum if it has no hash.
+func (f *testFixtures) scene(scene models.Scene) *models.Scene {
+	f.t.Helper()
+
+	if !scene.Checksum.Valid && !scene.OSHash.Valid {
+		scene.Checksum = sql.NullString{String: utils.MD5FromString(scene.Path), Valid: true}
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	var created *models.Scene
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(scene, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(strconv.Itoa(created.ID), tx)
+	})
+	return created
+}
+
+// image creates image, with the MD5 of its path as checksum if it has none.
+func (f *testFixtures) image(image models.Image) *models.Image {
+	f.t.Helper()
+
+	if image.Checksum == "" {
+		image.Checksum = utils.MD5FromString(image.Path)
+	}
+
+	qb := models.NewImageQueryBuilder()
+	var created *models.Image
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(image, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(created.ID, tx)
+	})
+	return created
+}
+
+// gallery creates gallery, with the MD5 of its path as checksum if it has
+// none.
+func (f *testFixtures) gallery(gallery models.Gallery) *models.Gallery {
+	f.t.Helper()
+
+	if gallery.Checksum == "" {
+		gallery.Checksum = utils.MD5FromString(gallery.Path.String)
+	}
+
+	qb := models.NewGalleryQueryBuilder()
+	var created *models.Gallery
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(gallery, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(created.ID, tx)
+	})
+	return created
+}
+
+// movie creates movie, with the MD5 of its name as checksum if it has none.
+func (f *testFixtures) movie(movie models.Movie) *models.Movie {
+	f.t.Helper()
+
+	if movie.Checksum == "" {
+		movie.Checksum = utils.MD5FromString(movie.Name.String)
+	}
+
+	qb := models.NewMovieQueryBuilder()
+	var created *models.Movie
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(movie, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(strconv.Itoa(created.ID), tx)
+	})
+	return created
+}
+
+// performer creates performer, with the MD5 of its name as checksum if it has
+// none.
+func (f *testFixtures) performer(performer models.Performer) *models.Performer {
+	f.t.Helper()
+
+	if performer.Checksum == "" {
+		performer.Checksum = utils.MD5FromString(performer.Name.String)
+	}
+
+	qb := models.NewPerformerQueryBuilder()
+	var created *models.Performer
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(performer, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(strconv.Itoa(created.ID), tx)
+	})
+	return created
+}
+
+// studio creates studio, with the MD5 of its name as checksum if it has none.
+func (f *testFixtures) studio(studio models.Studio) *models.Studio {
+	f.t.Helper()
+
+	if studio.Checksum == "" {
+		studio.Checksum = utils.MD5FromString(studio.Name.String)
+	}
+
+	qb := models.NewStudioQueryBuilder()
+	var created *models.Studio
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(studio, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(strconv.Itoa(created.ID), tx)
+	})
+	return created
+}
+
+// tag creates tag.
+func (f *testFixtures) tag(tag models.Tag) *models.Tag {
+	f.t.Helper()
+
+	qb := models.NewTagQueryBuilder()
+	var created *models.Tag
+	withTxn(f.t, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = qb.Create(tag, tx)
+		return err
+	})
+
+	f.onDestroy(func(tx *sqlx.Tx) error {
+		return qb.Destroy(strconv.Itoa(created.ID), tx)
+	})
+	return created
+}
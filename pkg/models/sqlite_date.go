@@ -31,14 +31,16 @@ func (t *SQLiteDate) Scan(value interface{}) error {
 	return nil
 }
 
-// Value implements the driver Valuer interface.
+// Value implements the driver Valuer interface. Dates are stored as calendar
+// dates without a time zone; timestamps are stored as their date in the
+// configured timezone.
 func (t SQLiteDate) Value() (driver.Value, error) {
 	// handle empty string
 	if t.String == "" {
 		return "", nil
 	}
 
-	result, err := utils.ParseDateStringAsFormat(t.String, "2006-01-02")
+	result, err := utils.ParseDateStringAsDateInTimezone(t.String)
 	if err != nil {
 		logger.Debugf("sqlite date conversion error: %s", err.Error())
 	}
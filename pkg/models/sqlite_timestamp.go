@@ -15,9 +15,9 @@ func (t *SQLiteTimestamp) Scan(value interface{}) error {
 	return nil
 }
 
-// Value implements the driver Valuer interface.
+// Value implements the driver Valuer interface. Timestamps are stored in UTC.
 func (t SQLiteTimestamp) Value() (driver.Value, error) {
-	return t.Timestamp.Format(time.RFC3339), nil
+	return t.Timestamp.UTC().Format(time.RFC3339), nil
 }
 
 type NullSQLiteTimestamp struct {
@@ -39,11 +39,11 @@ func (t *NullSQLiteTimestamp) Scan(value interface{}) error {
 	return nil
 }
 
-// Value implements the driver Valuer interface.
+// Value implements the driver Valuer interface. Timestamps are stored in UTC.
 func (t NullSQLiteTimestamp) Value() (driver.Value, error) {
 	if t.Timestamp.IsZero() {
 		return nil, nil
 	}
 
-	return t.Timestamp.Format(time.RFC3339), nil
+	return t.Timestamp.UTC().Format(time.RFC3339), nil
 }
@@ -13,7 +13,7 @@ type StudioReader interface {
 	// Count() (int, error)
 	All() ([]*Studio, error)
 	// AllSlim() ([]*Studio, error)
-	// Query(studioFilter *StudioFilterType, findFilter *FindFilterType) ([]*Studio, int)
+	// Query(studioFilter *StudioFilterType, findFilter *FindFilterType) ([]*Studio, int, error)
 	GetStudioImage(studioID int) ([]byte, error)
 }
 
@@ -11,6 +11,7 @@ import (
 // ToJSON converts a Movie into its JSON equivalent.
 func ToJSON(reader models.MovieReader, studioReader models.StudioReader, movie *models.Movie) (*jsonschema.Movie, error) {
 	newMovieJSON := jsonschema.Movie{
+		UUID:      movie.UUID.String,
 		CreatedAt: models.JSONTime{Time: movie.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: movie.UpdatedAt.Timestamp},
 	}
@@ -25,7 +26,7 @@ func ToJSON(reader models.MovieReader, studioReader models.StudioReader, movie *
 		newMovieJSON.Date = utils.GetYMDFromDatabaseDate(movie.Date.String)
 	}
 	if movie.Rating.Valid {
-		newMovieJSON.Rating = int(movie.Rating.Int64)
+		newMovieJSON.Rating100 = int(movie.Rating.Int64)
 	}
 	if movie.Duration.Valid {
 		newMovieJSON.Duration = int(movie.Duration.Int64)
@@ -39,8 +40,30 @@ func ToJSON(reader models.MovieReader, studioReader models.StudioReader, movie *
 		newMovieJSON.Synopsis = movie.Synopsis.String
 	}
 
-	if movie.URL.Valid {
-		newMovieJSON.URL = movie.URL.String
+	if movie.Slug.Valid {
+		newMovieJSON.Slug = movie.Slug.String
+	}
+
+	if movie.CoverURL.Valid {
+		newMovieJSON.CoverURL = movie.CoverURL.String
+	}
+
+	if movie.BackgroundURL.Valid {
+		newMovieJSON.BackgroundURL = movie.BackgroundURL.String
+	}
+
+	urls, err := reader.GetURLs(movie.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting movie urls: %s", err.Error())
+	}
+	newMovieJSON.URLs = urls
+
+	customFields, err := reader.GetCustomFields(movie.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting movie custom fields: %s", err.Error())
+	}
+	if len(customFields) > 0 {
+		newMovieJSON.CustomFields = customFields
 	}
 
 	if movie.StudioID.Valid {
@@ -9,6 +9,7 @@ import (
 	"github.com/stashapp/stash/pkg/models/mocks"
 	"github.com/stashapp/stash/pkg/models/modelstest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"testing"
 	"time"
@@ -21,6 +22,8 @@ const (
 	errBackImageID       = 4
 	errStudioMovieID     = 5
 	missingStudioMovieID = 6
+	errURLsID            = 7
+	errCustomFieldsID    = 8
 )
 
 const (
@@ -43,6 +46,8 @@ const director = "director"
 const synopsis = "synopsis"
 const url = "url"
 
+var customFields = map[string]string{"field": "value"}
+
 const studioName = "studio"
 
 const frontImage = "ZnJvbnRJbWFnZUJ5dGVz"
@@ -74,7 +79,6 @@ func createFullMovie(id int, studioID int) models.Movie {
 		},
 		Director: modelstest.NullString(director),
 		Synopsis: modelstest.NullString(synopsis),
-		URL:      modelstest.NullString(url),
 		StudioID: sql.NullInt64{
 			Int64: int64(studioID),
 			Valid: true,
@@ -102,17 +106,18 @@ func createEmptyMovie(id int) models.Movie {
 
 func createFullJSONMovie(studio, frontImage, backImage string) *jsonschema.Movie {
 	return &jsonschema.Movie{
-		Name:       movieName,
-		Aliases:    movieAliases,
-		Date:       date.String,
-		Rating:     rating,
-		Duration:   duration,
-		Director:   director,
-		Synopsis:   synopsis,
-		URL:        url,
-		Studio:     studio,
-		FrontImage: frontImage,
-		BackImage:  backImage,
+		Name:         movieName,
+		Aliases:      movieAliases,
+		Date:         date.String,
+		Rating100:    rating,
+		Duration:     duration,
+		Director:     director,
+		Synopsis:     synopsis,
+		URLs:         []string{url},
+		Studio:       studio,
+		FrontImage:   frontImage,
+		BackImage:    backImage,
+		CustomFields: customFields,
 		CreatedAt: models.JSONTime{
 			Time: createTime,
 		},
@@ -168,6 +173,16 @@ func initTestTable() {
 			nil,
 			true,
 		},
+		testScenario{
+			createFullMovie(errURLsID, studioID),
+			nil,
+			true,
+		},
+		testScenario{
+			createFullMovie(errCustomFieldsID, studioID),
+			nil,
+			true,
+		},
 		testScenario{
 			createFullMovie(missingStudioMovieID, missingStudioID),
 			createFullJSONMovie("", frontImage, backImage),
@@ -196,6 +211,18 @@ func TestToJSON(t *testing.T) {
 	mockMovieReader.On("GetBackImage", errFrontImageID).Return(backImageBytes, nil).Maybe()
 	mockMovieReader.On("GetBackImage", errStudioMovieID).Return(backImageBytes, nil).Maybe()
 
+	urlsErr := errors.New("error getting urls")
+
+	mockMovieReader.On("GetURLs", emptyID).Return(nil, nil).Once()
+	mockMovieReader.On("GetURLs", errURLsID).Return(nil, urlsErr).Once()
+	mockMovieReader.On("GetURLs", mock.Anything).Return([]string{url}, nil)
+
+	customFieldsErr := errors.New("error getting custom fields")
+
+	mockMovieReader.On("GetCustomFields", emptyID).Return(nil, nil).Once()
+	mockMovieReader.On("GetCustomFields", errCustomFieldsID).Return(nil, customFieldsErr).Once()
+	mockMovieReader.On("GetCustomFields", mock.Anything).Return(customFields, nil)
+
 	mockStudioReader := &mocks.StudioReaderWriter{}
 
 	studioErr := errors.New("error getting studio")
@@ -0,0 +1,99 @@
+package movie
+
+import (
+	"database/sql"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+var fileNumberRE = regexp.MustCompile(`(\d+)\D*$`)
+
+// getFileNumber returns the last number in the file name of the scene,
+// without its extension, such as 3 for "Movie - Scene 3.mp4".
+func getFileNumber(scene *models.Scene) (int, bool) {
+	name := filepath.Base(scene.Path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	match := fileNumberRE.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(match[1])
+	return n, err == nil
+}
+
+// SortScenesForMovie sorts scenes in their inferred order within a movie.
+// If the file names of the scenes are numbered, with a different number for
+// each scene, the scenes are sorted by number. Otherwise they are sorted as
+// by SortScenesForIndexes.
+func SortScenesForMovie(scenes []*models.Scene) {
+	numbers := make(map[int]int)
+	seen := make(map[int]bool)
+	for _, s := range scenes {
+		n, found := getFileNumber(s)
+		if !found || seen[n] {
+			SortScenesForIndexes(scenes)
+			return
+		}
+		numbers[s.ID] = n
+		seen[n] = true
+	}
+
+	sort.SliceStable(scenes, func(i, j int) bool {
+		return numbers[scenes[i].ID] < numbers[scenes[j].ID]
+	})
+}
+
+// NewFromScenes returns a movie made up of the scenes. It is named after the
+// folder containing the scene files if they are all in the same folder. Its
+// duration is the total duration of the scenes, its date the earliest scene
+// date, and its studio the studio of the scenes if they all have the same
+// studio.
+func NewFromScenes(scenes []*models.Scene) models.Movie {
+	ret := models.Movie{}
+
+	var folder string
+	var duration float64
+	for i, s := range scenes {
+		dir := filepath.Dir(s.Path)
+		if i == 0 {
+			folder = dir
+		} else if dir != folder {
+			folder = ""
+		}
+
+		if s.Duration.Valid {
+			duration += s.Duration.Float64
+		}
+
+		if date := getSceneDate(s); date != "" && (!ret.Date.Valid || date < ret.Date.String) {
+			ret.Date = models.SQLiteDate{String: date, Valid: true}
+		}
+
+		if i == 0 {
+			ret.StudioID = s.StudioID
+		} else if ret.StudioID != s.StudioID {
+			ret.StudioID = sql.NullInt64{}
+		}
+	}
+
+	if folder != "" {
+		name := filepath.Base(folder)
+		ret.Name = sql.NullString{String: name, Valid: true}
+		ret.Checksum = utils.MD5FromString(name)
+	}
+
+	if duration > 0 {
+		ret.Duration = sql.NullInt64{Int64: int64(math.Round(duration)), Valid: true}
+	}
+
+	return ret
+}
@@ -0,0 +1,77 @@
+package movie
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func movieScene(id int, path string, date string) *models.Scene {
+	return &models.Scene{
+		ID:   id,
+		Path: path,
+		Date: models.SQLiteDate{String: date, Valid: date != ""},
+	}
+}
+
+func sceneIDs(scenes []*models.Scene) []int {
+	var ret []int
+	for _, s := range scenes {
+		ret = append(ret, s.ID)
+	}
+	return ret
+}
+
+func TestSortScenesForMovie(t *testing.T) {
+	scenes := []*models.Scene{
+		movieScene(1, "/movie/Movie - Scene 10.mp4", "2020-01-01"),
+		movieScene(2, "/movie/Movie - Scene 2.mp4", "2020-01-03"),
+		movieScene(3, "/movie/Movie - Scene 1 (extended).mp4", "2020-01-02"),
+	}
+	SortScenesForMovie(scenes)
+	assert.Equal(t, []int{3, 2, 1}, sceneIDs(scenes))
+
+	// duplicate numbers fall back to the order by date
+	scenes = []*models.Scene{
+		movieScene(1, "/movie/a 1080p.mp4", "2020-01-02"),
+		movieScene(2, "/movie/b 1080p.mp4", "2020-01-01"),
+	}
+	SortScenesForMovie(scenes)
+	assert.Equal(t, []int{2, 1}, sceneIDs(scenes))
+
+	// as do file names without numbers
+	scenes = []*models.Scene{
+		movieScene(1, "/movie/b.mp4", ""),
+		movieScene(2, "/movie/a 1.mp4", ""),
+	}
+	SortScenesForMovie(scenes)
+	assert.Equal(t, []int{2, 1}, sceneIDs(scenes))
+}
+
+func TestNewFromScenes(t *testing.T) {
+	studio := sql.NullInt64{Int64: 4, Valid: true}
+	scenes := []*models.Scene{
+		movieScene(1, "/movies/Some Movie/1.mp4", "2020-01-02"),
+		movieScene(2, "/movies/Some Movie/2.mp4", ""),
+		movieScene(3, "/movies/Some Movie/3.mp4", "2020-01-01"),
+	}
+	for i, s := range scenes {
+		s.Duration = sql.NullFloat64{Float64: 600.4 + float64(i), Valid: true}
+		s.StudioID = studio
+	}
+
+	m := NewFromScenes(scenes)
+	assert.Equal(t, "Some Movie", m.Name.String)
+	assert.NotEmpty(t, m.Checksum)
+	assert.Equal(t, int64(1804), m.Duration.Int64)
+	assert.Equal(t, "2020-01-01", m.Date.String)
+	assert.Equal(t, studio, m.StudioID)
+
+	scenes[2].Path = "/movies/Other/3.mp4"
+	scenes[2].StudioID = sql.NullInt64{}
+	m = NewFromScenes(scenes)
+	assert.False(t, m.Name.Valid)
+	assert.False(t, m.StudioID.Valid)
+}
@@ -54,19 +54,32 @@ func (i *Importer) movieJSONToMovie(movieJSON jsonschema.Movie) models.Movie {
 		Date:      models.SQLiteDate{String: movieJSON.Date, Valid: true},
 		Director:  sql.NullString{String: movieJSON.Director, Valid: true},
 		Synopsis:  sql.NullString{String: movieJSON.Synopsis, Valid: true},
-		URL:       sql.NullString{String: movieJSON.URL, Valid: true},
+		UUID:      sql.NullString{String: movieJSON.UUID, Valid: movieJSON.UUID != ""},
 		CreatedAt: models.SQLiteTimestamp{Timestamp: movieJSON.CreatedAt.GetTime()},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: movieJSON.UpdatedAt.GetTime()},
 	}
 
-	if movieJSON.Rating != 0 {
-		newMovie.Rating = sql.NullInt64{Int64: int64(movieJSON.Rating), Valid: true}
+	if movieJSON.Rating100 != 0 {
+		newMovie.Rating = sql.NullInt64{Int64: int64(movieJSON.Rating100), Valid: true}
+	} else if movieJSON.Rating != 0 {
+		newMovie.Rating = sql.NullInt64{Int64: int64(models.Rating5To100(movieJSON.Rating)), Valid: true}
 	}
 
 	if movieJSON.Duration != 0 {
 		newMovie.Duration = sql.NullInt64{Int64: int64(movieJSON.Duration), Valid: true}
 	}
 
+	if movieJSON.CoverURL != "" {
+		newMovie.CoverURL = sql.NullString{String: movieJSON.CoverURL, Valid: true}
+	}
+	if movieJSON.BackgroundURL != "" {
+		newMovie.BackgroundURL = sql.NullString{String: movieJSON.BackgroundURL, Valid: true}
+	}
+
+	if movieJSON.Slug != "" {
+		newMovie.Slug = sql.NullString{String: movieJSON.Slug, Valid: true}
+	}
+
 	return newMovie
 }
 
@@ -116,6 +129,19 @@ func (i *Importer) createStudio(name string) (int, error) {
 }
 
 func (i *Importer) PostImport(id int) error {
+	urls := i.Input.URLs
+	if len(urls) == 0 && i.Input.URL != "" {
+		urls = []string{i.Input.URL}
+	}
+
+	if err := i.ReaderWriter.UpdateURLs(id, urls); err != nil {
+		return fmt.Errorf("error setting movie urls: %s", err.Error())
+	}
+
+	if err := i.ReaderWriter.UpdateCustomFields(id, i.Input.CustomFields); err != nil {
+		return fmt.Errorf("error setting movie custom fields: %s", err.Error())
+	}
+
 	if len(i.frontImageData) > 0 {
 		if err := i.ReaderWriter.UpdateMovieImages(id, i.frontImageData, i.backImageData); err != nil {
 			return fmt.Errorf("error setting movie images: %s", err.Error())
@@ -157,6 +183,10 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	movie := i.movie
 	movie.ID = id
+	// keep the slug and uuid of the existing movie so that links and other
+	// instances referring to it still work
+	movie.Slug = sql.NullString{}
+	movie.UUID = sql.NullString{}
 	_, err := i.ReaderWriter.UpdateFull(movie)
 	if err != nil {
 		return fmt.Errorf("error updating existing movie: %s", err.Error())
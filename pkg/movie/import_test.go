@@ -66,6 +66,26 @@ func TestImporterPreImport(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestImporterPreImportRating(t *testing.T) {
+	i := Importer{
+		Input: jsonschema.Movie{
+			Name:   movieName,
+			Rating: 3,
+		},
+	}
+
+	// legacy ratings are converted to the 1-100 scale
+	err := i.PreImport()
+	assert.Nil(t, err)
+	assert.Equal(t, modelstest.NullInt64(60), i.movie.Rating)
+
+	i.Input.Rating100 = 55
+
+	err = i.PreImport()
+	assert.Nil(t, err)
+	assert.Equal(t, modelstest.NullInt64(55), i.movie.Rating)
+}
+
 func TestImporterPreImportWithStudio(t *testing.T) {
 	studioReaderWriter := &mocks.StudioReaderWriter{}
 
@@ -153,13 +173,26 @@ func TestImporterPostImport(t *testing.T) {
 	readerWriter := &mocks.MovieReaderWriter{}
 
 	i := Importer{
-		ReaderWriter:   readerWriter,
+		ReaderWriter: readerWriter,
+		Input: jsonschema.Movie{
+			URLs:         []string{url},
+			CustomFields: customFields,
+		},
 		frontImageData: frontImageBytes,
 		backImageData:  backImageBytes,
 	}
 
 	updateMovieImageErr := errors.New("UpdateMovieImage error")
-
+	updateURLsErr := errors.New("UpdateURLs error")
+	updateCustomFieldsErr := errors.New("UpdateCustomFields error")
+
+	readerWriter.On("UpdateURLs", movieID, []string{url}).Return(nil).Once()
+	readerWriter.On("UpdateURLs", errImageID, []string{url}).Return(nil).Once()
+	readerWriter.On("UpdateURLs", errURLsID, []string{url}).Return(updateURLsErr).Once()
+	readerWriter.On("UpdateURLs", errCustomFieldsID, []string{url}).Return(nil).Once()
+	readerWriter.On("UpdateCustomFields", movieID, customFields).Return(nil).Once()
+	readerWriter.On("UpdateCustomFields", errImageID, customFields).Return(nil).Once()
+	readerWriter.On("UpdateCustomFields", errCustomFieldsID, customFields).Return(updateCustomFieldsErr).Once()
 	readerWriter.On("UpdateMovieImages", movieID, frontImageBytes, backImageBytes).Return(nil).Once()
 	readerWriter.On("UpdateMovieImages", errImageID, frontImageBytes, backImageBytes).Return(updateMovieImageErr).Once()
 
@@ -169,6 +202,23 @@ func TestImporterPostImport(t *testing.T) {
 	err = i.PostImport(errImageID)
 	assert.NotNil(t, err)
 
+	err = i.PostImport(errURLsID)
+	assert.NotNil(t, err)
+
+	err = i.PostImport(errCustomFieldsID)
+	assert.NotNil(t, err)
+
+	// legacy single url
+	i.Input = jsonschema.Movie{
+		URL: url,
+	}
+	readerWriter.On("UpdateURLs", existingMovieID, []string{url}).Return(nil).Once()
+	readerWriter.On("UpdateCustomFields", existingMovieID, map[string]string(nil)).Return(nil).Once()
+	readerWriter.On("UpdateMovieImages", existingMovieID, frontImageBytes, backImageBytes).Return(nil).Once()
+
+	err = i.PostImport(existingMovieID)
+	assert.Nil(t, err)
+
 	readerWriter.AssertExpectations(t)
 }
 
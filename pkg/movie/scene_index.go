@@ -0,0 +1,77 @@
+package movie
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// SceneIndexIssues returns the scenes without an index, the indexes shared by
+// more than one scene, the indexes missing between 1 and the highest index,
+// and the indexes less than 1 of the scene joins of a movie.
+func SceneIndexIssues(joins []models.MoviesScenes) (unindexed []int, duplicates []int, missing []int, invalid []int) {
+	counts := make(map[int64]int)
+	var max int64
+	for _, j := range joins {
+		if !j.SceneIndex.Valid {
+			unindexed = append(unindexed, j.SceneID)
+			continue
+		}
+
+		index := j.SceneIndex.Int64
+		counts[index]++
+		if counts[index] == 1 && index < 1 {
+			invalid = append(invalid, int(index))
+		}
+		if counts[index] == 2 {
+			duplicates = append(duplicates, int(index))
+		}
+		if index > max {
+			max = index
+		}
+	}
+
+	for i := int64(1); i < max; i++ {
+		if counts[i] == 0 {
+			missing = append(missing, int(i))
+		}
+	}
+
+	sort.Ints(duplicates)
+	sort.Ints(invalid)
+
+	return unindexed, duplicates, missing, invalid
+}
+
+// SortScenesForIndexes sorts scenes in the order in which they are indexed
+// when the scene indexes of a movie are renumbered: by date, then by file
+// name. Scenes without a date are sorted last.
+func SortScenesForIndexes(scenes []*models.Scene) {
+	sort.SliceStable(scenes, func(i, j int) bool {
+		di := getSceneDate(scenes[i])
+		dj := getSceneDate(scenes[j])
+		if di != dj {
+			if di == "" || dj == "" {
+				return dj == ""
+			}
+			return di < dj
+		}
+
+		fi := filepath.Base(scenes[i].Path)
+		fj := filepath.Base(scenes[j].Path)
+		if fi != fj {
+			return fi < fj
+		}
+
+		return scenes[i].ID < scenes[j].ID
+	})
+}
+
+func getSceneDate(scene *models.Scene) string {
+	if !scene.Date.Valid || scene.Date.String == "0001-01-01" {
+		return ""
+	}
+
+	return scene.Date.String
+}
@@ -0,0 +1,76 @@
+package movie
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func sceneIndexJoin(sceneID int, index int) models.MoviesScenes {
+	return models.MoviesScenes{
+		SceneID:    sceneID,
+		SceneIndex: sql.NullInt64{Int64: int64(index), Valid: index != 0},
+	}
+}
+
+func TestSceneIndexIssues(t *testing.T) {
+	unindexed, duplicates, missing, invalid := SceneIndexIssues([]models.MoviesScenes{
+		sceneIndexJoin(1, 1),
+		sceneIndexJoin(2, 2),
+		sceneIndexJoin(3, 3),
+	})
+	assert.Empty(t, unindexed)
+	assert.Empty(t, duplicates)
+	assert.Empty(t, missing)
+	assert.Empty(t, invalid)
+
+	unindexed, duplicates, missing, invalid = SceneIndexIssues([]models.MoviesScenes{
+		sceneIndexJoin(1, 5),
+		sceneIndexJoin(2, 2),
+		sceneIndexJoin(3, 5),
+		sceneIndexJoin(4, 0),
+		sceneIndexJoin(5, 2),
+		sceneIndexJoin(6, 5),
+		sceneIndexJoin(7, 0),
+	})
+	assert.Equal(t, []int{4, 7}, unindexed)
+	assert.Equal(t, []int{2, 5}, duplicates)
+	assert.Equal(t, []int{1, 3, 4}, missing)
+	assert.Empty(t, invalid)
+
+	unindexed, duplicates, missing, invalid = SceneIndexIssues([]models.MoviesScenes{
+		sceneIndexJoin(1, 1),
+		sceneIndexJoin(2, -1),
+		sceneIndexJoin(3, 2),
+		sceneIndexJoin(4, -3),
+		sceneIndexJoin(5, -1),
+	})
+	assert.Empty(t, unindexed)
+	assert.Equal(t, []int{-1}, duplicates)
+	assert.Empty(t, missing)
+	assert.Equal(t, []int{-3, -1}, invalid)
+}
+
+func TestSortScenesForIndexes(t *testing.T) {
+	sceneDate := func(d string) models.SQLiteDate {
+		return models.SQLiteDate{String: d, Valid: d != ""}
+	}
+
+	scenes := []*models.Scene{
+		{ID: 1, Path: "/b/undated.mp4"},
+		{ID: 2, Path: "/a/second.mp4", Date: sceneDate("2020-02-01")},
+		{ID: 3, Path: "/z/a.mp4", Date: sceneDate("2020-01-01")},
+		{ID: 4, Path: "/a/b.mp4", Date: sceneDate("2020-01-01")},
+		{ID: 5, Path: "/a/a undated.mp4", Date: sceneDate("0001-01-01")},
+	}
+
+	SortScenesForIndexes(scenes)
+
+	var ids []int
+	for _, s := range scenes {
+		ids = append(ids, s.ID)
+	}
+	assert.Equal(t, []int{3, 4, 2, 5, 1}, ids)
+}
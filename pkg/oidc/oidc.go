@@ -0,0 +1,373 @@
+// Package oidc implements the parts of an OpenID Connect relying party needed
+// to log in with the authorization code flow of an identity provider.
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // register the hash functions of the signing algorithms
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+// clockSkew is the difference allowed between the clocks of stash and the
+// identity provider when checking the expiry of ID tokens.
+const clockSkew = time.Minute
+
+// Config is the client configuration registered with the identity provider.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Provider is an identity provider, as described by its discovery document.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	client *http.Client
+}
+
+// Discover fetches the discovery document of the identity provider with the
+// issuer URL issuer.
+func Discover(issuer string, client *http.Client) (*Provider, error) {
+	ret := &Provider{}
+	if err := getJSON(client, strings.TrimSuffix(issuer, "/")+discoveryPath, ret); err != nil {
+		return nil, fmt.Errorf("error getting discovery document: %s", err.Error())
+	}
+
+	if strings.TrimSuffix(ret.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("discovery document issuer %s does not match %s", ret.Issuer, issuer)
+	}
+
+	if ret.AuthorizationEndpoint == "" || ret.TokenEndpoint == "" || ret.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	ret.client = client
+	return ret, nil
+}
+
+// AuthCodeURL returns the URL of the authorization endpoint which the user is
+// sent to in order to log in. state and nonce are returned by the provider
+// in the redirect and the ID token respectively.
+func (p *Provider) AuthCodeURL(c Config, state string, nonce string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {c.RedirectURL},
+		"scope":         {strings.Join(c.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return p.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Exchange exchanges the authorization code returned by the provider for an
+// ID token, and returns the raw ID token.
+func (p *Provider) Exchange(c Config, code string) (string, error) {
+	v := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.RedirectURL},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.TokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("error decoding token response (status %d): %s", resp.StatusCode, err.Error())
+	}
+
+	if token.Error != "" {
+		return "", fmt.Errorf("token request failed: %s %s", token.Error, token.ErrorDescription)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+
+	return token.IDToken, nil
+}
+
+// Claims are the claims of a verified ID token.
+type Claims map[string]interface{}
+
+// String returns the string value of the claim name, or an empty string if
+// the claim is not a string.
+func (c Claims) String(name string) string {
+	ret, _ := c[name].(string)
+	return ret
+}
+
+// Strings returns the values of the claim name, which may be a string or an
+// array of strings.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var ret []string
+		for _, vv := range v {
+			if s, ok := vv.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+
+	return nil
+}
+
+// VerifyIDToken verifies the signature, issuer, audience, expiry and nonce of
+// the raw ID token, and returns its claims.
+func (p *Provider) VerifyIDToken(c Config, rawToken string, nonce string) (Claims, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed id token header: %s", err.Error())
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed id token signature: %s", err.Error())
+	}
+
+	keys, err := p.getKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(keys, header.Alg, header.Kid, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := Claims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed id token claims: %s", err.Error())
+	}
+
+	if claims.String("iss") != p.Issuer {
+		return nil, fmt.Errorf("id token issuer %s does not match %s", claims.String("iss"), p.Issuer)
+	}
+
+	if !utils.StrInclude(claims.Strings("aud"), c.ClientID) {
+		return nil, errors.New("id token was not issued for this client")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("id token has no expiry")
+	}
+	if time.Now().Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("id token has expired")
+	}
+
+	if claims.String("nonce") != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+
+	return claims, nil
+}
+
+// jsonWebKey is a public key of a JSON web key set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) getKeys() ([]jsonWebKey, error) {
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(p.client, p.JWKSURI, &keySet); err != nil {
+		return nil, fmt.Errorf("error getting signing keys: %s", err.Error())
+	}
+
+	return keySet.Keys, nil
+}
+
+// verifySignature verifies the signature of signed with the key kid, or with
+// any signing key of a matching type if kid is empty.
+func verifySignature(keys []jsonWebKey, alg string, kid string, signed string, signature []byte) error {
+	var hash crypto.Hash
+	// the curve of the key that ES algorithms must be used with
+	var curve string
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+		curve = "P-256"
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+		curve = "P-384"
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+		curve = "P-521"
+	default:
+		return fmt.Errorf("unsupported id token algorithm %s", alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	for _, k := range keys {
+		if (kid != "" && k.Kid != kid) || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(alg, "RS") && k.Kty == "RSA":
+			key, err := k.rsaKey()
+			if err != nil {
+				return err
+			}
+			if rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+				return nil
+			}
+		case strings.HasPrefix(alg, "ES") && k.Kty == "EC" && k.Crv == curve:
+			key, err := k.ecdsaKey()
+			if err != nil {
+				return err
+			}
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if len(signature) == 2*size {
+				r := new(big.Int).SetBytes(signature[:size])
+				s := new(big.Int).SetBytes(signature[size:])
+				if ecdsa.Verify(key, digest, r, s) {
+					return nil
+				}
+			}
+		}
+	}
+
+	return errors.New("id token signature is invalid")
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of key %s: %s", k.Kid, err.Error())
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent of key %s: %s", k.Kid, err.Error())
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func (k jsonWebKey) ecdsaKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %s of key %s", k.Crv, k.Kid)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x coordinate of key %s: %s", k.Kid, err.Error())
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y coordinate of key %s: %s", k.Kid, err.Error())
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,288 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testClientID     = "stash"
+	testClientSecret = "secret"
+	testCode         = "code"
+	testNonce        = "nonce"
+)
+
+type testProvider struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+
+	// idToken is returned by the token endpoint
+	idToken string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %s", err.Error())
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating ecdsa key: %s", err.Error())
+	}
+
+	p := &testProvider{
+		rsaKey: rsaKey,
+		ecKey:  ecKey,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		encode := func(b []byte) string {
+			return base64.RawURLEncoding.EncodeToString(b)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "rsa",
+					"use": "sig",
+					"n":   encode(rsaKey.N.Bytes()),
+					"e":   encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+				},
+				{
+					"kty": "EC",
+					"kid": "ec",
+					"crv": "P-256",
+					"x":   encode(ecKey.X.Bytes()),
+					"y":   encode(ecKey.Y.Bytes()),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != testClientID || secret != testClientSecret || r.FormValue("code") != testCode {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken})
+	})
+
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *testProvider) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                p.server.URL,
+		"aud":                testClientID,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"nonce":              testNonce,
+		"preferred_username": "user",
+		"groups":             []string{"admins", "users"},
+	}
+}
+
+func (p *testProvider) sign(t *testing.T, alg string, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, p.rsaKey, crypto.SHA256, digest.Sum(nil))
+		if err != nil {
+			t.Fatalf("error signing token: %s", err.Error())
+		}
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, p.ecKey, digest.Sum(nil))
+		if err != nil {
+			t.Fatalf("error signing token: %s", err.Error())
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testConfig() Config {
+	return Config{
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://stash/login/oidc/callback",
+		Scopes:       []string{"openid", "profile"},
+	}
+}
+
+func TestLogin(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+
+	provider, err := Discover(p.server.URL, p.server.Client())
+	if err != nil {
+		t.Fatalf("error discovering provider: %s", err.Error())
+	}
+
+	c := testConfig()
+	authURL, err := url.Parse(provider.AuthCodeURL(c, "state", testNonce))
+	if err != nil {
+		t.Fatalf("error parsing auth code url: %s", err.Error())
+	}
+	assert.Equal(t, "/authorize", authURL.Path)
+	assert.Equal(t, "state", authURL.Query().Get("state"))
+	assert.Equal(t, "openid profile", authURL.Query().Get("scope"))
+
+	p.idToken = p.sign(t, "RS256", "rsa", p.claims())
+	idToken, err := provider.Exchange(c, testCode)
+	if err != nil {
+		t.Fatalf("error exchanging code: %s", err.Error())
+	}
+
+	claims, err := provider.VerifyIDToken(c, idToken, testNonce)
+	if err != nil {
+		t.Fatalf("error verifying id token: %s", err.Error())
+	}
+	assert.Equal(t, "user", claims.String("preferred_username"))
+	assert.Equal(t, []string{"admins", "users"}, claims.Strings("groups"))
+
+	_, err = provider.Exchange(c, "invalid")
+	assert.NotNil(t, err)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+
+	provider, err := Discover(p.server.URL, p.server.Client())
+	if err != nil {
+		t.Fatalf("error discovering provider: %s", err.Error())
+	}
+
+	c := testConfig()
+
+	valid := p.sign(t, "ES256", "ec", p.claims())
+	_, err = provider.VerifyIDToken(c, valid, testNonce)
+	assert.Nil(t, err)
+
+	// the signature of a token from another key is invalid
+	_, err = provider.VerifyIDToken(c, p.sign(t, "RS256", "ec", p.claims()), testNonce)
+	assert.NotNil(t, err)
+
+	// tampered claims
+	tampered := p.claims()
+	tampered["preferred_username"] = "admin"
+	payload, _ := json.Marshal(tampered)
+	parts := strings.Split(valid, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	_, err = provider.VerifyIDToken(c, strings.Join(parts, "."), testNonce)
+	assert.NotNil(t, err)
+
+	_, err = provider.VerifyIDToken(c, valid, "other nonce")
+	assert.NotNil(t, err)
+
+	invalidClaims := map[string]func(map[string]interface{}){
+		"issuer":   func(claims map[string]interface{}) { claims["iss"] = "http://other" },
+		"audience": func(claims map[string]interface{}) { claims["aud"] = []string{"other"} },
+		"expired":  func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no exp":   func(claims map[string]interface{}) { delete(claims, "exp") },
+	}
+	for name, modify := range invalidClaims {
+		claims := p.claims()
+		modify(claims)
+		_, err := provider.VerifyIDToken(c, p.sign(t, "RS256", "rsa", claims), testNonce)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestVerifyIDTokenAlgorithms(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.server.Close()
+
+	provider, err := Discover(p.server.URL, p.server.Client())
+	if err != nil {
+		t.Fatalf("error discovering provider: %s", err.Error())
+	}
+
+	c := testConfig()
+
+	// the key id is optional
+	_, err = provider.VerifyIDToken(c, p.sign(t, "RS256", "", p.claims()), testNonce)
+	assert.Nil(t, err)
+
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := func(alg string, kid string) string {
+		return encode(map[string]string{"alg": alg, "kid": kid}) + "." + encode(p.claims())
+	}
+
+	// a token signed with the P-256 key, but claiming the algorithm of another
+	// curve
+	curveToken := unsigned("ES384", "ec")
+	digest := crypto.SHA384.New()
+	digest.Write([]byte(curveToken))
+	r, sig, err := ecdsa.Sign(rand.Reader, p.ecKey, digest.Sum(nil))
+	if err != nil {
+		t.Fatalf("error signing token: %s", err.Error())
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	curveToken += "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	// a token signed with the client secret as if it were a shared key
+	hmacToken := unsigned("HS256", "rsa")
+	mac := hmac.New(sha256.New, []byte(testClientSecret))
+	mac.Write([]byte(hmacToken))
+	hmacToken += "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	invalidTokens := map[string]string{
+		"none algorithm":     unsigned("none", "") + ".",
+		"hmac algorithm":     hmacToken,
+		"unknown key id":     p.sign(t, "RS256", "other", p.claims()),
+		"key of other type":  p.sign(t, "ES256", "rsa", p.claims()),
+		"key of other curve": curveToken,
+		"no signature":       unsigned("RS256", "rsa") + ".",
+		"missing segment":    unsigned("RS256", "rsa"),
+		"malformed header":   "header." + encode(p.claims()) + ".c2lnbmF0dXJl",
+	}
+	for name, token := range invalidTokens {
+		_, err := provider.VerifyIDToken(c, token, testNonce)
+		assert.NotNil(t, err, name)
+	}
+}
@@ -0,0 +1,200 @@
+package performer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// aliasSeparators are the characters used to separate multiple aliases in
+// the performer aliases field.
+const aliasSeparators = ",;/"
+
+// NormalizeName returns a normalized version of the provided name suitable
+// for comparing performer names. The returned value is lower case and has
+// all characters removed that are not letters or digits.
+func NormalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// SplitAliases splits the provided aliases string into the individual
+// aliases, omitting any empty values.
+func SplitAliases(aliases string) []string {
+	fields := strings.FieldsFunc(aliases, func(r rune) bool {
+		return strings.ContainsRune(aliasSeparators, r)
+	})
+
+	var ret []string
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f != "" {
+			ret = append(ret, f)
+		}
+	}
+
+	return ret
+}
+
+type duplicateKeyEntry struct {
+	index     int
+	fromAlias bool
+}
+
+// duplicateGroups is a union-find structure over performer indexes, which
+// also tracks the reasons that each set was joined.
+type duplicateGroups struct {
+	parent  []int
+	reasons map[int]map[models.PerformerDuplicateReason]bool
+}
+
+func newDuplicateGroups(n int) *duplicateGroups {
+	ret := &duplicateGroups{
+		parent:  make([]int, n),
+		reasons: make(map[int]map[models.PerformerDuplicateReason]bool),
+	}
+
+	for i := range ret.parent {
+		ret.parent[i] = i
+	}
+
+	return ret
+}
+
+func (g *duplicateGroups) find(i int) int {
+	for g.parent[i] != i {
+		g.parent[i] = g.parent[g.parent[i]]
+		i = g.parent[i]
+	}
+	return i
+}
+
+func (g *duplicateGroups) union(a, b int, reason models.PerformerDuplicateReason) {
+	rootA := g.find(a)
+	rootB := g.find(b)
+
+	if rootA != rootB {
+		// always use the lowest index as the root
+		if rootB < rootA {
+			rootA, rootB = rootB, rootA
+		}
+		g.parent[rootB] = rootA
+
+		if g.reasons[rootA] == nil {
+			g.reasons[rootA] = make(map[models.PerformerDuplicateReason]bool)
+		}
+		for r := range g.reasons[rootB] {
+			g.reasons[rootA][r] = true
+		}
+		delete(g.reasons, rootB)
+	}
+
+	if g.reasons[rootA] == nil {
+		g.reasons[rootA] = make(map[models.PerformerDuplicateReason]bool)
+	}
+	g.reasons[rootA][reason] = true
+}
+
+// FindDuplicates returns groups of performers that are probable duplicates
+// of each other. Performers are considered duplicates if their normalized
+// names match, if a normalized name or alias matches an alias of another
+// performer, or if they share a stash ID for the same endpoint. The
+// stashIDs map is keyed by performer ID.
+//
+// Groups are ordered by the lowest performer ID in each group, and the
+// performers in each group are ordered by ID.
+func FindDuplicates(performers []*models.Performer, stashIDs map[int][]*models.StashID) []*models.PerformerDuplicateGroup {
+	sorted := make([]*models.Performer, len(performers))
+	copy(sorted, performers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	nameKeys := make(map[string][]duplicateKeyEntry)
+	stashIDKeys := make(map[string][]int)
+
+	for i, p := range sorted {
+		if p.Name.Valid {
+			if n := NormalizeName(p.Name.String); n != "" {
+				nameKeys[n] = append(nameKeys[n], duplicateKeyEntry{index: i})
+			}
+		}
+
+		if p.Aliases.Valid {
+			for _, alias := range SplitAliases(p.Aliases.String) {
+				if n := NormalizeName(alias); n != "" {
+					nameKeys[n] = append(nameKeys[n], duplicateKeyEntry{index: i, fromAlias: true})
+				}
+			}
+		}
+
+		for _, stashID := range stashIDs[p.ID] {
+			key := stashID.Endpoint + "\x00" + stashID.StashID
+			stashIDKeys[key] = append(stashIDKeys[key], i)
+		}
+	}
+
+	groups := newDuplicateGroups(len(sorted))
+
+	for _, entries := range nameKeys {
+		first := entries[0]
+		for _, e := range entries[1:] {
+			if e.index == first.index {
+				continue
+			}
+
+			reason := models.PerformerDuplicateReasonName
+			if first.fromAlias || e.fromAlias {
+				reason = models.PerformerDuplicateReasonAlias
+			}
+			groups.union(first.index, e.index, reason)
+		}
+	}
+
+	for _, indexes := range stashIDKeys {
+		for _, i := range indexes[1:] {
+			if i != indexes[0] {
+				groups.union(indexes[0], i, models.PerformerDuplicateReasonStashID)
+			}
+		}
+	}
+
+	members := make(map[int][]*models.Performer)
+	var roots []int
+	for i, p := range sorted {
+		root := groups.find(i)
+		if _, found := members[root]; !found {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], p)
+	}
+
+	var ret []*models.PerformerDuplicateGroup
+	for _, root := range roots {
+		if len(members[root]) < 2 {
+			continue
+		}
+
+		group := &models.PerformerDuplicateGroup{
+			Performers: members[root],
+		}
+
+		for _, reason := range models.AllPerformerDuplicateReason {
+			if groups.reasons[root][reason] {
+				group.Reasons = append(group.Reasons, reason)
+			}
+		}
+
+		ret = append(ret, group)
+	}
+
+	return ret
+}
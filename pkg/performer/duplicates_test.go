@@ -0,0 +1,78 @@
+package performer
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/modelstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "janedoe", NormalizeName("Jane Doe"))
+	assert.Equal(t, "janedoe", NormalizeName(" jane-doe. "))
+	assert.Equal(t, "", NormalizeName("- !"))
+}
+
+func TestSplitAliases(t *testing.T) {
+	assert.Equal(t, []string{"a", "b c", "d", "e"}, SplitAliases(" a, b c;d / e, "))
+	assert.Nil(t, SplitAliases(""))
+}
+
+func createDuplicateTestPerformer(id int, name, aliases string) *models.Performer {
+	ret := &models.Performer{
+		ID:   id,
+		Name: modelstest.NullString(name),
+	}
+
+	if aliases != "" {
+		ret.Aliases = modelstest.NullString(aliases)
+	}
+
+	return ret
+}
+
+func TestFindDuplicates(t *testing.T) {
+	performers := []*models.Performer{
+		createDuplicateTestPerformer(6, "Unrelated", ""),
+		createDuplicateTestPerformer(1, "Jane Doe", ""),
+		createDuplicateTestPerformer(2, "jane-doe", ""),
+		createDuplicateTestPerformer(3, "Someone", "Another Name, J. Smith"),
+		createDuplicateTestPerformer(4, "Another Name", ""),
+		createDuplicateTestPerformer(5, "Different", ""),
+		createDuplicateTestPerformer(7, "Different Too", ""),
+	}
+
+	stashIDs := map[int][]*models.StashID{
+		5: {
+			{Endpoint: "endpoint", StashID: "stashid"},
+		},
+		7: {
+			{Endpoint: "endpoint", StashID: "stashid"},
+		},
+		6: {
+			{Endpoint: "other", StashID: "stashid"},
+		},
+	}
+
+	groups := FindDuplicates(performers, stashIDs)
+
+	assert.Len(t, groups, 3)
+
+	getIDs := func(g *models.PerformerDuplicateGroup) []int {
+		var ret []int
+		for _, p := range g.Performers {
+			ret = append(ret, p.ID)
+		}
+		return ret
+	}
+
+	assert.Equal(t, []int{1, 2}, getIDs(groups[0]))
+	assert.Equal(t, []models.PerformerDuplicateReason{models.PerformerDuplicateReasonName}, groups[0].Reasons)
+
+	assert.Equal(t, []int{3, 4}, getIDs(groups[1]))
+	assert.Equal(t, []models.PerformerDuplicateReason{models.PerformerDuplicateReasonAlias}, groups[1].Reasons)
+
+	assert.Equal(t, []int{5, 7}, getIDs(groups[2]))
+	assert.Equal(t, []models.PerformerDuplicateReason{models.PerformerDuplicateReasonStashID}, groups[2].Reasons)
+}
@@ -11,6 +11,7 @@ import (
 // ToJSON converts a Performer object into its JSON equivalent.
 func ToJSON(reader models.PerformerReader, performer *models.Performer) (*jsonschema.Performer, error) {
 	newPerformerJSON := jsonschema.Performer{
+		UUID:      performer.UUID.String,
 		CreatedAt: models.JSONTime{Time: performer.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: performer.UpdatedAt.Timestamp},
 	}
@@ -21,9 +22,6 @@ func ToJSON(reader models.PerformerReader, performer *models.Performer) (*jsonsc
 	if performer.Gender.Valid {
 		newPerformerJSON.Gender = performer.Gender.String
 	}
-	if performer.URL.Valid {
-		newPerformerJSON.URL = performer.URL.String
-	}
 	if performer.Birthdate.Valid {
 		newPerformerJSON.Birthdate = utils.GetYMDFromDatabaseDate(performer.Birthdate.String)
 	}
@@ -42,6 +40,9 @@ func ToJSON(reader models.PerformerReader, performer *models.Performer) (*jsonsc
 	if performer.Measurements.Valid {
 		newPerformerJSON.Measurements = performer.Measurements.String
 	}
+	if performer.Weight.Valid {
+		newPerformerJSON.Weight = int(performer.Weight.Int64)
+	}
 	if performer.FakeTits.Valid {
 		newPerformerJSON.FakeTits = performer.FakeTits.String
 	}
@@ -57,15 +58,18 @@ func ToJSON(reader models.PerformerReader, performer *models.Performer) (*jsonsc
 	if performer.Aliases.Valid {
 		newPerformerJSON.Aliases = performer.Aliases.String
 	}
-	if performer.Twitter.Valid {
-		newPerformerJSON.Twitter = performer.Twitter.String
-	}
-	if performer.Instagram.Valid {
-		newPerformerJSON.Instagram = performer.Instagram.String
-	}
 	if performer.Favorite.Valid {
 		newPerformerJSON.Favorite = performer.Favorite.Bool
 	}
+	if performer.Slug.Valid {
+		newPerformerJSON.Slug = performer.Slug.String
+	}
+
+	urls, err := reader.GetURLs(performer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting performer urls: %s", err.Error())
+	}
+	newPerformerJSON.URLs = urls
 
 	image, err := reader.GetPerformerImage(performer.ID)
 	if err != nil {
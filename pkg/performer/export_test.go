@@ -10,6 +10,7 @@ import (
 	"github.com/stashapp/stash/pkg/models/modelstest"
 	"github.com/stashapp/stash/pkg/utils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"testing"
 	"time"
@@ -19,11 +20,12 @@ const (
 	performerID = 1
 	noImageID   = 2
 	errImageID  = 3
+	errURLsID   = 4
 )
 
 const (
 	performerName = "testPerformer"
-	url           = "url"
+	url           = "https://www.iafd.com/person"
 	aliases       = "aliases"
 	careerLength  = "careerLength"
 	country       = "country"
@@ -32,15 +34,22 @@ const (
 	fakeTits      = "fakeTits"
 	gender        = "gender"
 	height        = "height"
-	instagram     = "instagram"
+	instagram     = "@instagram"
 	measurements  = "measurements"
 	piercings     = "piercings"
 	tattoos       = "tattoos"
 	twitter       = "twitter"
+	weight        = 55
 )
 
 var imageBytes = []byte("imageBytes")
 
+var performerURLs = []models.PerformerURL{
+	{Site: "iafd", URL: url},
+	{Site: "twitter", URL: "https://twitter.com/twitter"},
+	{Site: "instagram", URL: "https://www.instagram.com/instagram"},
+}
+
 const image = "aW1hZ2VCeXRlcw=="
 
 var birthDate = models.SQLiteDate{
@@ -55,7 +64,6 @@ func createFullPerformer(id int, name string) *models.Performer {
 		ID:           id,
 		Name:         modelstest.NullString(name),
 		Checksum:     utils.MD5FromString(name),
-		URL:          modelstest.NullString(url),
 		Aliases:      modelstest.NullString(aliases),
 		Birthdate:    birthDate,
 		CareerLength: modelstest.NullString(careerLength),
@@ -69,11 +77,10 @@ func createFullPerformer(id int, name string) *models.Performer {
 		},
 		Gender:       modelstest.NullString(gender),
 		Height:       modelstest.NullString(height),
-		Instagram:    modelstest.NullString(instagram),
 		Measurements: modelstest.NullString(measurements),
 		Piercings:    modelstest.NullString(piercings),
 		Tattoos:      modelstest.NullString(tattoos),
-		Twitter:      modelstest.NullString(twitter),
+		Weight:       sql.NullInt64{Int64: weight, Valid: true},
 		CreatedAt: models.SQLiteTimestamp{
 			Timestamp: createTime,
 		},
@@ -98,7 +105,6 @@ func createEmptyPerformer(id int) models.Performer {
 func createFullJSONPerformer(name string, image string) *jsonschema.Performer {
 	return &jsonschema.Performer{
 		Name:         name,
-		URL:          url,
 		Aliases:      aliases,
 		Birthdate:    birthDate.String,
 		CareerLength: careerLength,
@@ -109,11 +115,11 @@ func createFullJSONPerformer(name string, image string) *jsonschema.Performer {
 		Favorite:     true,
 		Gender:       gender,
 		Height:       height,
-		Instagram:    instagram,
 		Measurements: measurements,
 		Piercings:    piercings,
 		Tattoos:      tattoos,
-		Twitter:      twitter,
+		Weight:       weight,
+		URLs:         performerURLs,
 		CreatedAt: models.JSONTime{
 			Time: createTime,
 		},
@@ -160,6 +166,11 @@ func initTestTable() {
 			nil,
 			true,
 		},
+		testScenario{
+			*createFullPerformer(errURLsID, performerName),
+			nil,
+			true,
+		},
 	}
 }
 
@@ -169,6 +180,11 @@ func TestToJSON(t *testing.T) {
 	mockPerformerReader := &mocks.PerformerReaderWriter{}
 
 	imageErr := errors.New("error getting image")
+	urlsErr := errors.New("error getting urls")
+
+	mockPerformerReader.On("GetURLs", noImageID).Return(nil, nil).Once()
+	mockPerformerReader.On("GetURLs", errURLsID).Return(nil, urlsErr).Once()
+	mockPerformerReader.On("GetURLs", mock.Anything).Return(performerURLs, nil)
 
 	mockPerformerReader.On("GetPerformerImage", performerID).Return(imageBytes, nil).Once()
 	mockPerformerReader.On("GetPerformerImage", noImageID).Return(nil, nil).Once()
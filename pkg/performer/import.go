@@ -32,6 +32,15 @@ func (i *Importer) PreImport() error {
 }
 
 func (i *Importer) PostImport(id int) error {
+	urls := CleanURLs(i.Input.URLs)
+	if len(urls) == 0 {
+		urls = LegacyURLs(i.Input.URL, i.Input.Twitter, i.Input.Instagram)
+	}
+
+	if err := i.ReaderWriter.UpdateURLs(id, urls); err != nil {
+		return fmt.Errorf("error setting performer urls: %s", err.Error())
+	}
+
 	if len(i.imageData) > 0 {
 		if err := i.ReaderWriter.UpdatePerformerImage(id, i.imageData); err != nil {
 			return fmt.Errorf("error setting performer image: %s", err.Error())
@@ -73,6 +82,10 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	performer := i.performer
 	performer.ID = id
+	// keep the slug and uuid of the existing performer so that links and other
+	// instances referring to it still work
+	performer.Slug = sql.NullString{}
+	performer.UUID = sql.NullString{}
 	_, err := i.ReaderWriter.UpdateFull(performer)
 	if err != nil {
 		return fmt.Errorf("error updating existing performer: %s", err.Error())
@@ -87,6 +100,7 @@ func performerJSONToPerformer(performerJSON jsonschema.Performer) models.Perform
 	newPerformer := models.Performer{
 		Checksum:  checksum,
 		Favorite:  sql.NullBool{Bool: performerJSON.Favorite, Valid: true},
+		UUID:      sql.NullString{String: performerJSON.UUID, Valid: performerJSON.UUID != ""},
 		CreatedAt: models.SQLiteTimestamp{Timestamp: performerJSON.CreatedAt.GetTime()},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: performerJSON.UpdatedAt.GetTime()},
 	}
@@ -94,12 +108,12 @@ func performerJSONToPerformer(performerJSON jsonschema.Performer) models.Perform
 	if performerJSON.Name != "" {
 		newPerformer.Name = sql.NullString{String: performerJSON.Name, Valid: true}
 	}
+	if performerJSON.Slug != "" {
+		newPerformer.Slug = sql.NullString{String: performerJSON.Slug, Valid: true}
+	}
 	if performerJSON.Gender != "" {
 		newPerformer.Gender = sql.NullString{String: performerJSON.Gender, Valid: true}
 	}
-	if performerJSON.URL != "" {
-		newPerformer.URL = sql.NullString{String: performerJSON.URL, Valid: true}
-	}
 	if performerJSON.Birthdate != "" {
 		newPerformer.Birthdate = models.SQLiteDate{String: performerJSON.Birthdate, Valid: true}
 	}
@@ -118,6 +132,9 @@ func performerJSONToPerformer(performerJSON jsonschema.Performer) models.Perform
 	if performerJSON.Measurements != "" {
 		newPerformer.Measurements = sql.NullString{String: performerJSON.Measurements, Valid: true}
 	}
+	if performerJSON.Weight != 0 {
+		newPerformer.Weight = sql.NullInt64{Int64: int64(performerJSON.Weight), Valid: true}
+	}
 	if performerJSON.FakeTits != "" {
 		newPerformer.FakeTits = sql.NullString{String: performerJSON.FakeTits, Valid: true}
 	}
@@ -133,12 +150,6 @@ func performerJSONToPerformer(performerJSON jsonschema.Performer) models.Perform
 	if performerJSON.Aliases != "" {
 		newPerformer.Aliases = sql.NullString{String: performerJSON.Aliases, Valid: true}
 	}
-	if performerJSON.Twitter != "" {
-		newPerformer.Twitter = sql.NullString{String: performerJSON.Twitter, Valid: true}
-	}
-	if performerJSON.Instagram != "" {
-		newPerformer.Instagram = sql.NullString{String: performerJSON.Instagram, Valid: true}
-	}
 
 	return newPerformer
 }
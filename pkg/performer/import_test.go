@@ -59,11 +59,18 @@ func TestImporterPostImport(t *testing.T) {
 
 	i := Importer{
 		ReaderWriter: readerWriter,
-		imageData:    imageBytes,
+		Input: jsonschema.Performer{
+			URLs: performerURLs,
+		},
+		imageData: imageBytes,
 	}
 
 	updatePerformerImageErr := errors.New("UpdatePerformerImage error")
+	updateURLsErr := errors.New("UpdateURLs error")
 
+	readerWriter.On("UpdateURLs", performerID, performerURLs).Return(nil).Once()
+	readerWriter.On("UpdateURLs", errImageID, performerURLs).Return(nil).Once()
+	readerWriter.On("UpdateURLs", errURLsID, performerURLs).Return(updateURLsErr).Once()
 	readerWriter.On("UpdatePerformerImage", performerID, imageBytes).Return(nil).Once()
 	readerWriter.On("UpdatePerformerImage", errImageID, imageBytes).Return(updatePerformerImageErr).Once()
 
@@ -73,6 +80,21 @@ func TestImporterPostImport(t *testing.T) {
 	err = i.PostImport(errImageID)
 	assert.NotNil(t, err)
 
+	err = i.PostImport(errURLsID)
+	assert.NotNil(t, err)
+
+	// legacy url, twitter and instagram
+	i.Input = jsonschema.Performer{
+		URL:       url,
+		Twitter:   twitter,
+		Instagram: instagram,
+	}
+	readerWriter.On("UpdateURLs", existingPerformerID, performerURLs).Return(nil).Once()
+	readerWriter.On("UpdatePerformerImage", existingPerformerID, imageBytes).Return(nil).Once()
+
+	err = i.PostImport(existingPerformerID)
+	assert.Nil(t, err)
+
 	readerWriter.AssertExpectations(t)
 }
 
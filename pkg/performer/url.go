@@ -0,0 +1,117 @@
+package performer
+
+import (
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// Kinds of links set by the legacy url, twitter and instagram fields of
+// performers.
+const (
+	// LegacyWebsite is the kind of links to sites other than twitter and
+	// instagram
+	LegacyWebsite   = ""
+	LegacyTwitter   = utils.SiteTwitter
+	LegacyInstagram = utils.SiteInstagram
+)
+
+// legacyBaseURLs are the URLs to which the handles of the legacy kinds are
+// appended.
+var legacyBaseURLs = map[string]string{
+	LegacyTwitter:   utils.TwitterURL,
+	LegacyInstagram: utils.InstagramURL,
+}
+
+// NewURL returns the link with the url. Its site is made from the domain of
+// the url if site is empty.
+func NewURL(site string, url string) models.PerformerURL {
+	url = strings.TrimSpace(url)
+	site = strings.ToLower(strings.TrimSpace(site))
+	if site == "" {
+		site = utils.URLSite(url)
+	}
+
+	return models.PerformerURL{Site: site, URL: url}
+}
+
+// CleanURLs returns the links with empty and duplicate URLs removed.
+func CleanURLs(urls []models.PerformerURL) []models.PerformerURL {
+	var ret []models.PerformerURL
+	seen := make(map[string]bool)
+	for _, url := range urls {
+		url = NewURL(url.Site, url.URL)
+		if url.URL == "" || seen[url.URL] {
+			continue
+		}
+		seen[url.URL] = true
+		ret = append(ret, url)
+	}
+
+	return ret
+}
+
+// legacyKind returns the legacy kind of a link to the site.
+func legacyKind(site string) string {
+	if _, found := legacyBaseURLs[site]; found {
+		return site
+	}
+	return LegacyWebsite
+}
+
+// FirstURL returns the URL of the first link of the legacy kind, or nil if
+// there is none.
+func FirstURL(urls []models.PerformerURL, kind string) *string {
+	for _, url := range urls {
+		if legacyKind(url.Site) == kind {
+			ret := url.URL
+			return &ret
+		}
+	}
+
+	return nil
+}
+
+// SetLegacyURL replaces the first link of the legacy kind with a link made
+// from the value, or appends it if there is no such link. The value of the
+// twitter and instagram kinds may be a handle. The link is removed if the
+// value is empty.
+func SetLegacyURL(urls []models.PerformerURL, kind string, value string) []models.PerformerURL {
+	ret := append([]models.PerformerURL{}, urls...)
+
+	i := 0
+	for i < len(ret) && legacyKind(ret[i].Site) != kind {
+		i++
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if i < len(ret) {
+			ret = append(ret[:i], ret[i+1:]...)
+		}
+		return ret
+	}
+
+	url := NewURL("", value)
+	if base, found := legacyBaseURLs[kind]; found {
+		url = models.PerformerURL{Site: kind, URL: utils.SocialURL(base, value)}
+	}
+
+	if i < len(ret) {
+		ret[i] = url
+	} else {
+		ret = append(ret, url)
+	}
+
+	return ret
+}
+
+// LegacyURLs returns the links of the legacy url, twitter and instagram
+// fields.
+func LegacyURLs(url string, twitter string, instagram string) []models.PerformerURL {
+	var ret []models.PerformerURL
+	ret = SetLegacyURL(ret, LegacyWebsite, url)
+	ret = SetLegacyURL(ret, LegacyTwitter, twitter)
+	return SetLegacyURL(ret, LegacyInstagram, instagram)
+}
@@ -0,0 +1,55 @@
+package performer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestSetLegacyURL(t *testing.T) {
+	website := models.PerformerURL{Site: "iafd", URL: "https://www.iafd.com/person"}
+	onlyfans := models.PerformerURL{Site: "onlyfans", URL: "https://onlyfans.com/name"}
+	twitter := models.PerformerURL{Site: "twitter", URL: "https://twitter.com/name"}
+	urls := []models.PerformerURL{twitter, website, onlyfans}
+
+	tests := []struct {
+		name  string
+		kind  string
+		value string
+		want  []models.PerformerURL
+	}{
+		{"replace website", LegacyWebsite, "https://example.com", []models.PerformerURL{twitter, {Site: "example", URL: "https://example.com"}, onlyfans}},
+		{"remove website", LegacyWebsite, " ", []models.PerformerURL{twitter, onlyfans}},
+		{"replace twitter handle", LegacyTwitter, "@other", []models.PerformerURL{{Site: "twitter", URL: "https://twitter.com/other"}, website, onlyfans}},
+		{"append instagram", LegacyInstagram, "name", []models.PerformerURL{twitter, website, onlyfans, {Site: "instagram", URL: "https://www.instagram.com/name"}}},
+		{"remove missing", LegacyInstagram, "", urls},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SetLegacyURL(urls, tt.kind, tt.value))
+		})
+	}
+
+	// the input is not modified
+	assert.Equal(t, []models.PerformerURL{twitter, website, onlyfans}, urls)
+
+	assert.Equal(t, "https://www.iafd.com/person", *FirstURL(urls, LegacyWebsite))
+	assert.Nil(t, FirstURL(urls, LegacyInstagram))
+}
+
+func TestCleanURLs(t *testing.T) {
+	urls := []models.PerformerURL{
+		{Site: " OnlyFans ", URL: " https://onlyfans.com/name "},
+		{URL: "https://x.com/name"},
+		{Site: "other", URL: "https://onlyfans.com/name"},
+		{Site: "empty", URL: " "},
+	}
+
+	assert.Equal(t, []models.PerformerURL{
+		{Site: "onlyfans", URL: "https://onlyfans.com/name"},
+		{Site: "twitter", URL: "https://x.com/name"},
+	}, CleanURLs(urls))
+}
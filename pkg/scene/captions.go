@@ -0,0 +1,90 @@
+package scene
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Types of caption files, which are their file extensions.
+const (
+	CaptionTypeSRT = "srt"
+	CaptionTypeVTT = "vtt"
+)
+
+// captionLanguageRE matches the language codes of caption file names, such
+// as en, eng, pt-BR and zh_Hant.
+var captionLanguageRE = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// ParseCaptionFilename returns the language code and type of the caption file
+// with the name, if it is a caption sidecar file of the video file with the
+// name. The caption file name is the video file name with its extension
+// replaced by the caption type, optionally preceded by a language code, such
+// as video.srt or video.en.srt. The language code is empty if the caption file
+// name has none.
+func ParseCaptionFilename(videoFilename string, filename string) (languageCode string, captionType string, ok bool) {
+	ext := filepath.Ext(filename)
+	captionType = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if captionType != CaptionTypeSRT && captionType != CaptionTypeVTT {
+		return "", "", false
+	}
+
+	prefix := strings.TrimSuffix(videoFilename, filepath.Ext(videoFilename))
+	name := strings.TrimSuffix(filename, ext)
+	if name == prefix {
+		return "", captionType, true
+	}
+
+	if !strings.HasPrefix(name, prefix+".") {
+		return "", "", false
+	}
+
+	// names with other suffixes, such as video.part2.srt, belong to other
+	// video files
+	languageCode = strings.TrimPrefix(name, prefix+".")
+	if !captionLanguageRE.MatchString(languageCode) {
+		return "", "", false
+	}
+
+	return strings.ToLower(languageCode), captionType, true
+}
+
+// srtTimingRE matches the timing lines of SubRip cues. Positions following
+// the timings are not supported by WebVTT and are dropped.
+var srtTimingRE = regexp.MustCompile(`^\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})`)
+
+// parseSRTTime returns the time in seconds of the hours, minutes, seconds and
+// fraction of a SubRip timing.
+func parseSRTTime(h, m, s, fraction string) float64 {
+	hours, _ := strconv.Atoi(h)
+	minutes, _ := strconv.Atoi(m)
+	seconds, _ := strconv.Atoi(s)
+	ms, _ := strconv.Atoi((fraction + "00")[:3])
+	return float64(hours*3600+minutes*60+seconds) + float64(ms)/1000
+}
+
+// WriteWebVTTFromSRT writes the SubRip captions read from r as a WebVTT
+// captions file.
+func WriteWebVTTFromSRT(w io.Writer, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+
+	lines := []string{"WEBVTT", ""}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if m := srtTimingRE.FindStringSubmatch(line); m != nil {
+			line = formatWebVTTTime(parseSRTTime(m[1], m[2], m[3], m[4])) + " --> " + formatWebVTTTime(parseSRTTime(m[5], m[6], m[7], m[8]))
+		}
+		lines = append(lines, line)
+	}
+
+	_, err = io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
@@ -0,0 +1,68 @@
+package scene
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCaptionFilename(t *testing.T) {
+	tests := []struct {
+		filename     string
+		languageCode string
+		captionType  string
+		ok           bool
+	}{
+		{"video.srt", "", CaptionTypeSRT, true},
+		{"video.VTT", "", CaptionTypeVTT, true},
+		{"video.en.srt", "en", CaptionTypeSRT, true},
+		{"video.pt-BR.vtt", "pt-br", CaptionTypeVTT, true},
+		{"video.eng.srt", "eng", CaptionTypeSRT, true},
+		{"video.part2.srt", "", "", false},
+		{"video.en.txt", "", "", false},
+		{"other.en.srt", "", "", false},
+		{"video2.srt", "", "", false},
+		{"video.mp4", "", "", false},
+	}
+
+	for _, tt := range tests {
+		languageCode, captionType, ok := ParseCaptionFilename("video.mp4", tt.filename)
+		assert.Equal(t, tt.ok, ok, tt.filename)
+		assert.Equal(t, tt.languageCode, languageCode, tt.filename)
+		assert.Equal(t, tt.captionType, captionType, tt.filename)
+	}
+
+	// only the extension of the video file name is replaced
+	languageCode, _, ok := ParseCaptionFilename("video.name.mkv", "video.name.de.srt")
+	assert.True(t, ok)
+	assert.Equal(t, "de", languageCode)
+}
+
+func TestWriteWebVTTFromSRT(t *testing.T) {
+	srt := "\ufeff1\r\n" +
+		"00:00:01,500 --> 00:00:04,000\r\n" +
+		"<i>First</i> line\r\n" +
+		"second line\r\n" +
+		"\r\n" +
+		"2\r\n" +
+		"1:02:05,25 --> 1:02:07,000 X1:100 X2:200 Y1:10 Y2:20\r\n" +
+		"Last\r\n" +
+		"\r\n"
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteWebVTTFromSRT(&buf, strings.NewReader(srt)))
+
+	assert.Equal(t, `WEBVTT
+
+1
+00:00:01.500 --> 00:00:04.000
+<i>First</i> line
+second line
+
+2
+01:02:05.250 --> 01:02:07.000
+Last
+`, buf.String())
+}
@@ -0,0 +1,133 @@
+package scene
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Chapter is a jump point of a scene, exported to external players.
+type Chapter struct {
+	Start float64
+	End   float64
+	Title string
+}
+
+// SetChapterEnds sorts the chapters by start, and ends each chapter where
+// the next one starts. The last chapter ends at duration, or at its start if
+// the duration is unknown.
+func SetChapterEnds(chapters []Chapter, duration float64) {
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].Start < chapters[j].Start
+	})
+
+	for i := range chapters {
+		if i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		} else {
+			chapters[i].End = math.Max(duration, chapters[i].Start)
+		}
+	}
+}
+
+// splitChapterTime returns the hours, minutes, seconds and nanoseconds of
+// the time in seconds.
+func splitChapterTime(seconds float64) (int64, int64, int64, int64) {
+	ns := int64(math.Round(seconds * 1e9))
+	return ns / 3600e9, ns / 60e9 % 60, ns / 1e9 % 60, ns % 1e9
+}
+
+// formatWebVTTTime returns the time in seconds in the hh:mm:ss.ttt format of
+// WebVTT cue timings.
+func formatWebVTTTime(seconds float64) string {
+	h, m, s, ns := splitChapterTime(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ns/1e6)
+}
+
+// formatMatroskaTime returns the time in seconds in the
+// hh:mm:ss.nnnnnnnnn format of Matroska chapter XML.
+func formatMatroskaTime(seconds float64) string {
+	h, m, s, ns := splitChapterTime(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d.%09d", h, m, s, ns)
+}
+
+var webVTTTextReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r\n", " ", "\n", " ", "\r", " ")
+
+// WriteWebVTTChapters writes the chapters as a WebVTT chapters file.
+func WriteWebVTTChapters(w io.Writer, chapters []Chapter) error {
+	lines := []string{"WEBVTT", ""}
+	for i, c := range chapters {
+		lines = append(lines,
+			strconv.Itoa(i+1),
+			formatWebVTTTime(c.Start)+" --> "+formatWebVTTTime(c.End),
+			webVTTTextReplacer.Replace(c.Title),
+			"",
+		)
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+type matroskaChapters struct {
+	XMLName xml.Name              `xml:"Chapters"`
+	Atoms   []matroskaChapterAtom `xml:"EditionEntry>ChapterAtom"`
+}
+
+type matroskaChapterAtom struct {
+	TimeStart string `xml:"ChapterTimeStart"`
+	TimeEnd   string `xml:"ChapterTimeEnd"`
+	Title     string `xml:"ChapterDisplay>ChapterString"`
+}
+
+// WriteMatroskaChapters writes the chapters as a Matroska chapters XML file,
+// as read by mkvmerge.
+func WriteMatroskaChapters(w io.Writer, chapters []Chapter) error {
+	doc := matroskaChapters{}
+	for _, c := range chapters {
+		doc.Atoms = append(doc.Atoms, matroskaChapterAtom{
+			TimeStart: formatMatroskaTime(c.Start),
+			TimeEnd:   formatMatroskaTime(c.End),
+			Title:     c.Title,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header+"<!DOCTYPE Chapters SYSTEM \"matroskachapters.dtd\">\n"); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ffmetadataEscaper escapes the special characters of ffmetadata values.
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+// WriteFFMetadataChapters writes the chapters as an ffmetadata file, as read
+// by ffmpeg, with times in milliseconds.
+func WriteFFMetadataChapters(w io.Writer, chapters []Chapter) error {
+	lines := []string{";FFMETADATA1"}
+	for _, c := range chapters {
+		lines = append(lines,
+			"",
+			"[CHAPTER]",
+			"TIMEBASE=1/1000",
+			fmt.Sprintf("START=%d", int64(math.Round(c.Start*1000))),
+			fmt.Sprintf("END=%d", int64(math.Round(c.End*1000))),
+			"title="+ffmetadataEscaper.Replace(c.Title),
+		)
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
@@ -0,0 +1,93 @@
+package scene
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testChapters() []Chapter {
+	chapters := []Chapter{
+		{Start: 75.5, Title: "Second <part>"},
+		{Start: 0, Title: "Intro"},
+		{Start: 3725.25, Title: "a=b; #c"},
+	}
+	SetChapterEnds(chapters, 4000)
+	return chapters
+}
+
+func TestSetChapterEnds(t *testing.T) {
+	chapters := testChapters()
+
+	assert.Equal(t, []Chapter{
+		{Start: 0, End: 75.5, Title: "Intro"},
+		{Start: 75.5, End: 3725.25, Title: "Second <part>"},
+		{Start: 3725.25, End: 4000, Title: "a=b; #c"},
+	}, chapters)
+
+	// the last chapter ends at its start if the duration is unknown
+	chapters = []Chapter{{Start: 10}}
+	SetChapterEnds(chapters, 0)
+	assert.Equal(t, 10.0, chapters[0].End)
+}
+
+func TestWriteWebVTTChapters(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteWebVTTChapters(&buf, testChapters()))
+
+	assert.Equal(t, `WEBVTT
+
+1
+00:00:00.000 --> 00:01:15.500
+Intro
+
+2
+00:01:15.500 --> 01:02:05.250
+Second &lt;part&gt;
+
+3
+01:02:05.250 --> 01:06:40.000
+a=b; #c
+`, buf.String())
+}
+
+func TestWriteMatroskaChapters(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteMatroskaChapters(&buf, testChapters()[1:2]))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE Chapters SYSTEM "matroskachapters.dtd">
+<Chapters>
+  <EditionEntry>
+    <ChapterAtom>
+      <ChapterTimeStart>00:01:15.500000000</ChapterTimeStart>
+      <ChapterTimeEnd>01:02:05.250000000</ChapterTimeEnd>
+      <ChapterDisplay>
+        <ChapterString>Second &lt;part&gt;</ChapterString>
+      </ChapterDisplay>
+    </ChapterAtom>
+  </EditionEntry>
+</Chapters>
+`, buf.String())
+}
+
+func TestWriteFFMetadataChapters(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteFFMetadataChapters(&buf, testChapters()[1:]))
+
+	assert.Equal(t, `;FFMETADATA1
+
+[CHAPTER]
+TIMEBASE=1/1000
+START=75500
+END=3725250
+title=Second <part>
+
+[CHAPTER]
+TIMEBASE=1/1000
+START=3725250
+END=4000000
+title=a\=b\; \#c
+`, buf.String())
+}
@@ -0,0 +1,107 @@
+package scene
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// phashBlock returns the bits of phash in block i of n blocks of about equal
+// width.
+func phashBlock(phash int64, i int, n int) uint64 {
+	start := uint(i * 64 / n)
+	end := uint((i + 1) * 64 / n)
+	return (uint64(phash) >> start) & (1<<(end-start) - 1)
+}
+
+func findRoot(parent []int, i int) int {
+	for parent[i] != i {
+		parent[i] = parent[parent[i]]
+		i = parent[i]
+	}
+	return i
+}
+
+// FindDuplicates returns groups of scenes with perceptual hashes within
+// distance of each other. Scenes without a perceptual hash are ignored.
+// Hashes within distance d of each other have at least one of d+1 blocks in
+// common, so only the scenes sharing a block are compared.
+//
+// Groups are ordered by the lowest scene ID in each group, and the scenes in
+// each group are ordered by ID.
+func FindDuplicates(scenes []*models.Scene, distance int) [][]*models.Scene {
+	var sorted []*models.Scene
+	for _, s := range scenes {
+		if s.Phash.Valid {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	if distance < 0 {
+		return nil
+	}
+
+	parent := make([]int, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	n := distance + 1
+	if n > 64 {
+		n = 64
+	}
+
+	type blockKey struct {
+		index int
+		value uint64
+	}
+	blocks := make(map[blockKey][]int)
+	for i, s := range sorted {
+		for b := 0; b < n; b++ {
+			key := blockKey{index: b, value: phashBlock(s.Phash.Int64, b, n)}
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	for _, indexes := range blocks {
+		for x, i := range indexes {
+			for _, j := range indexes[x+1:] {
+				d := bits.OnesCount64(uint64(sorted[i].Phash.Int64 ^ sorted[j].Phash.Int64))
+				if d > distance {
+					continue
+				}
+
+				// always use the lowest index as the root
+				rootI := findRoot(parent, i)
+				rootJ := findRoot(parent, j)
+				if rootJ < rootI {
+					rootI, rootJ = rootJ, rootI
+				}
+				parent[rootJ] = rootI
+			}
+		}
+	}
+
+	members := make(map[int][]*models.Scene)
+	var roots []int
+	for i, s := range sorted {
+		root := findRoot(parent, i)
+		if _, found := members[root]; !found {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], s)
+	}
+
+	var ret [][]*models.Scene
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			ret = append(ret, members[root])
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,56 @@
+package scene
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func createDuplicateTestScene(id int, phash *int64) *models.Scene {
+	ret := &models.Scene{
+		ID: id,
+	}
+
+	if phash != nil {
+		ret.Phash = sql.NullInt64{Int64: *phash, Valid: true}
+	}
+
+	return ret
+}
+
+func TestFindDuplicates(t *testing.T) {
+	base := int64(0x0f0f0f0f0f0f0f0f)
+	same := base
+	near := base ^ 0x7   // distance 3
+	far := base ^ 0xffff // distance 16
+	farNear := far ^ 0x1 // distance 1 from far
+
+	scenes := []*models.Scene{
+		createDuplicateTestScene(6, &farNear),
+		createDuplicateTestScene(5, &far),
+		createDuplicateTestScene(1, &base),
+		createDuplicateTestScene(2, nil),
+		createDuplicateTestScene(3, &near),
+		createDuplicateTestScene(4, &same),
+	}
+
+	getIDs := func(groups [][]*models.Scene) [][]int {
+		var ret [][]int
+		for _, g := range groups {
+			var ids []int
+			for _, s := range g {
+				ids = append(ids, s.ID)
+			}
+			ret = append(ret, ids)
+		}
+		return ret
+	}
+
+	assert.Equal(t, [][]int{{1, 4}}, getIDs(FindDuplicates(scenes, 0)))
+	assert.Equal(t, [][]int{{1, 3, 4}, {5, 6}}, getIDs(FindDuplicates(scenes, 4)))
+	assert.Equal(t, [][]int{{1, 3, 4, 5, 6}}, getIDs(FindDuplicates(scenes, 16)))
+	assert.Nil(t, FindDuplicates(scenes, -1))
+}
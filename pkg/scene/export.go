@@ -15,6 +15,7 @@ import (
 // of cover image.
 func ToBasicJSON(reader models.SceneReader, scene *models.Scene) (*jsonschema.Scene, error) {
 	newSceneJSON := jsonschema.Scene{
+		UUID:      scene.UUID.String,
 		CreatedAt: models.JSONTime{Time: scene.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: scene.UpdatedAt.Timestamp},
 	}
@@ -35,12 +36,20 @@ func ToBasicJSON(reader models.SceneReader, scene *models.Scene) (*jsonschema.Sc
 		newSceneJSON.URL = scene.URL.String
 	}
 
+	if scene.CoverURL.Valid {
+		newSceneJSON.CoverURL = scene.CoverURL.String
+	}
+
+	if scene.BackgroundURL.Valid {
+		newSceneJSON.BackgroundURL = scene.BackgroundURL.String
+	}
+
 	if scene.Date.Valid {
 		newSceneJSON.Date = utils.GetYMDFromDatabaseDate(scene.Date.String)
 	}
 
 	if scene.Rating.Valid {
-		newSceneJSON.Rating = int(scene.Rating.Int64)
+		newSceneJSON.Rating100 = int(scene.Rating.Int64)
 	}
 
 	newSceneJSON.Organized = scene.Organized
@@ -266,6 +275,7 @@ func GetSceneMarkersJSON(markerReader models.SceneMarkerReader, tagReader models
 			Seconds:    getDecimalString(sceneMarker.Seconds),
 			PrimaryTag: primaryTag.Name,
 			Tags:       getTagNames(sceneMarkerTags),
+			UUID:       sceneMarker.UUID.String,
 			CreatedAt:  models.JSONTime{Time: sceneMarker.CreatedAt.Timestamp},
 			UpdatedAt:  models.JSONTime{Time: sceneMarker.UpdatedAt.Timestamp},
 		}
@@ -41,6 +41,7 @@ const (
 
 const (
 	url          = "url"
+	coverURL     = "https://example.com/cover.jpg"
 	checksum     = "checksum"
 	oshash       = "oshash"
 	title        = "title"
@@ -119,6 +120,7 @@ func createFullScene(id int) models.Scene {
 		VideoCodec: modelstest.NullString(videoCodec),
 		Width:      modelstest.NullInt64(width),
 		URL:        modelstest.NullString(url),
+		CoverURL:   modelstest.NullString(coverURL),
 		CreatedAt: models.SQLiteTimestamp{
 			Timestamp: createTime,
 		},
@@ -148,9 +150,10 @@ func createFullJSONScene(image string) *jsonschema.Scene {
 		Details:   details,
 		OCounter:  ocounter,
 		OSHash:    oshash,
-		Rating:    rating,
+		Rating100: rating,
 		Organized: organized,
 		URL:       url,
+		CoverURL:  coverURL,
 		File: &jsonschema.SceneFile{
 			AudioCodec: audioCodec,
 			Bitrate:    bitrate,
@@ -72,6 +72,7 @@ func (i *Importer) sceneJSONToScene(sceneJSON jsonschema.Scene) models.Scene {
 		Checksum: sql.NullString{String: sceneJSON.Checksum, Valid: sceneJSON.Checksum != ""},
 		OSHash:   sql.NullString{String: sceneJSON.OSHash, Valid: sceneJSON.OSHash != ""},
 		Path:     i.Path,
+		UUID:     sql.NullString{String: sceneJSON.UUID, Valid: sceneJSON.UUID != ""},
 	}
 
 	if sceneJSON.Title != "" {
@@ -83,11 +84,19 @@ func (i *Importer) sceneJSONToScene(sceneJSON jsonschema.Scene) models.Scene {
 	if sceneJSON.URL != "" {
 		newScene.URL = sql.NullString{String: sceneJSON.URL, Valid: true}
 	}
+	if sceneJSON.CoverURL != "" {
+		newScene.CoverURL = sql.NullString{String: sceneJSON.CoverURL, Valid: true}
+	}
+	if sceneJSON.BackgroundURL != "" {
+		newScene.BackgroundURL = sql.NullString{String: sceneJSON.BackgroundURL, Valid: true}
+	}
 	if sceneJSON.Date != "" {
 		newScene.Date = models.SQLiteDate{String: sceneJSON.Date, Valid: true}
 	}
-	if sceneJSON.Rating != 0 {
-		newScene.Rating = sql.NullInt64{Int64: int64(sceneJSON.Rating), Valid: true}
+	if sceneJSON.Rating100 != 0 {
+		newScene.Rating = sql.NullInt64{Int64: int64(sceneJSON.Rating100), Valid: true}
+	} else if sceneJSON.Rating != 0 {
+		newScene.Rating = sql.NullInt64{Int64: int64(models.Rating5To100(sceneJSON.Rating)), Valid: true}
 	}
 
 	newScene.Organized = sceneJSON.Organized
@@ -423,6 +432,8 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	scene := i.scene
 	scene.ID = id
+	// keep the uuid of the existing scene, which other instances refer to it by
+	scene.UUID = sql.NullString{}
 	i.ID = id
 	_, err := i.ReaderWriter.UpdateFull(scene)
 	if err != nil {
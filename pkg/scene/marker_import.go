@@ -27,6 +27,7 @@ func (i *MarkerImporter) PreImport() error {
 		Title:     i.Input.Title,
 		Seconds:   seconds,
 		SceneID:   sql.NullInt64{Int64: int64(i.SceneID), Valid: true},
+		UUID:      sql.NullString{String: i.Input.UUID, Valid: i.Input.UUID != ""},
 		CreatedAt: models.SQLiteTimestamp{Timestamp: i.Input.CreatedAt.GetTime()},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: i.Input.UpdatedAt.GetTime()},
 	}
@@ -116,6 +117,8 @@ func (i *MarkerImporter) Create() (*int, error) {
 func (i *MarkerImporter) Update(id int) error {
 	marker := i.marker
 	marker.ID = id
+	// keep the uuid of the existing marker, which other instances refer to it by
+	marker.UUID = sql.NullString{}
 	_, err := i.ReaderWriter.Update(marker)
 	if err != nil {
 		return fmt.Errorf("error updating existing marker: %s", err.Error())
@@ -43,6 +43,7 @@ type scraper interface {
 	scrapeGalleryByFragment(scene models.GalleryUpdateInput) (*models.ScrapedGallery, error)
 	scrapeGalleryByURL(url string) (*models.ScrapedGallery, error)
 
+	scrapeMovieByFragment(movie models.MovieUpdateInput) (*models.ScrapedMovie, error)
 	scrapeMovieByURL(url string) (*models.ScrapedMovie, error)
 }
 
@@ -35,6 +35,9 @@ type config struct {
 	// Configuration for querying gallery by a Gallery fragment
 	GalleryByFragment *scraperTypeConfig `yaml:"galleryByFragment"`
 
+	// Configuration for querying movie by a Movie fragment
+	MovieByFragment *scraperTypeConfig `yaml:"movieByFragment"`
+
 	// Configuration for querying a scene by a URL
 	SceneByURL []*scrapeByURLConfig `yaml:"sceneByURL"`
 
@@ -83,6 +86,12 @@ func (c config) validate() error {
 		}
 	}
 
+	if c.MovieByFragment != nil {
+		if err := c.MovieByFragment.validate(); err != nil {
+			return err
+		}
+	}
+
 	for _, s := range c.PerformerByURL {
 		if err := s.validate(); err != nil {
 			return err
@@ -276,6 +285,9 @@ func (c config) toScraper() *models.Scraper {
 	}
 
 	movie := models.ScraperSpec{}
+	if c.MovieByFragment != nil {
+		movie.SupportedScrapes = append(movie.SupportedScrapes, models.ScrapeTypeFragment)
+	}
 	if len(c.MovieByURL) > 0 {
 		movie.SupportedScrapes = append(movie.SupportedScrapes, models.ScrapeTypeURL)
 		for _, v := range c.MovieByURL {
@@ -373,7 +385,7 @@ func (c config) matchesGalleryURL(url string) bool {
 }
 
 func (c config) supportsMovies() bool {
-	return len(c.MovieByURL) > 0
+	return c.MovieByFragment != nil || len(c.MovieByURL) > 0
 }
 
 func (c config) matchesMovieURL(url string) bool {
@@ -440,6 +452,15 @@ func (c config) ScrapeGalleryURL(url string, globalConfig GlobalConfig) (*models
 	return nil, nil
 }
 
+func (c config) ScrapeMovie(movie models.MovieUpdateInput, globalConfig GlobalConfig) (*models.ScrapedMovie, error) {
+	if c.MovieByFragment != nil {
+		s := getScraper(*c.MovieByFragment, c, globalConfig)
+		return s.scrapeMovieByFragment(movie)
+	}
+
+	return nil, nil
+}
+
 func (c config) ScrapeMovieURL(url string, globalConfig GlobalConfig) (*models.ScrapedMovie, error) {
 	for _, scraper := range c.MovieByURL {
 		if scraper.matchesURL(url) {
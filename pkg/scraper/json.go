@@ -246,3 +246,36 @@ func (q *jsonQuery) subScrape(value string) mappedQuery {
 
 	return q.scraper.getJsonQuery(doc)
 }
+
+func (s *jsonScraper) scrapeMovieByFragment(movie models.MovieUpdateInput) (*models.ScrapedMovie, error) {
+	storedMovie, storedURLs, err := movieFromUpdateFragment(movie)
+	if err != nil {
+		return nil, err
+	}
+
+	if storedMovie == nil {
+		return nil, errors.New("no movie found")
+	}
+
+	// construct the URL
+	queryURL := queryURLParametersFromMovie(storedMovie, storedURLs)
+	if s.scraper.QueryURLReplacements != nil {
+		queryURL.applyReplacements(s.scraper.QueryURLReplacements)
+	}
+	url := queryURL.constructURL(s.scraper.QueryURL)
+
+	scraper := s.getJsonScraper()
+
+	if scraper == nil {
+		return nil, errors.New("json scraper with name " + s.scraper.Scraper + " not found in config")
+	}
+
+	doc, err := s.loadURL(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.getJsonQuery(doc)
+	return scraper.scrapeMovie(q)
+}
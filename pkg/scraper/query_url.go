@@ -32,6 +32,17 @@ func queryURLParametersFromGallery(gallery *models.Gallery) queryURLParameters {
 	return ret
 }
 
+func queryURLParametersFromMovie(movie *models.Movie, urls []string) queryURLParameters {
+	ret := make(queryURLParameters)
+	ret["name"] = movie.Name.String
+	ret["url"] = ""
+	if len(urls) > 0 {
+		ret["url"] = urls[0]
+	}
+
+	return ret
+}
+
 func (p queryURLParameters) applyReplacements(r queryURLReplacements) {
 	for k, v := range p {
 		rpl, found := r[k]
@@ -405,6 +405,48 @@ func matchMovieStudio(s *models.ScrapedMovieStudio) error {
 	return nil
 }
 
+func (c Cache) postScrapeMovie(ret *models.ScrapedMovie) error {
+	if ret.Studio != nil {
+		err := matchMovieStudio(ret.Studio)
+		if err != nil {
+			return err
+		}
+	}
+
+	// post-process - set the image if applicable
+	if err := setMovieFrontImage(ret, c.globalConfig); err != nil {
+		logger.Warnf("Could not set front image using URL %s: %s", *ret.FrontImage, err.Error())
+	}
+	if err := setMovieBackImage(ret, c.globalConfig); err != nil {
+		logger.Warnf("Could not set back image using URL %s: %s", *ret.BackImage, err.Error())
+	}
+
+	return nil
+}
+
+// ScrapeMovie uses the scraper with the provided ID to scrape a movie.
+func (c Cache) ScrapeMovie(scraperID string, movie models.MovieUpdateInput) (*models.ScrapedMovie, error) {
+	s := c.findScraper(scraperID)
+	if s != nil {
+		ret, err := s.ScrapeMovie(movie, c.globalConfig)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ret != nil {
+			err = c.postScrapeMovie(ret)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return ret, nil
+	}
+
+	return nil, errors.New("Scraped with ID " + scraperID + " not found")
+}
+
 // ScrapeMovieURL uses the first scraper it finds that matches the URL
 // provided to scrape a movie. If no scrapers are found that matches
 // the URL, then nil is returned.
@@ -416,21 +458,13 @@ func (c Cache) ScrapeMovieURL(url string) (*models.ScrapedMovie, error) {
 				return nil, err
 			}
 
-			if ret.Studio != nil {
-				err := matchMovieStudio(ret.Studio)
+			if ret != nil {
+				err = c.postScrapeMovie(ret)
 				if err != nil {
 					return nil, err
 				}
 			}
 
-			// post-process - set the image if applicable
-			if err := setMovieFrontImage(ret, c.globalConfig); err != nil {
-				logger.Warnf("Could not set front image using URL %s: %s", *ret.FrontImage, err.Error())
-			}
-			if err := setMovieBackImage(ret, c.globalConfig); err != nil {
-				logger.Warnf("Could not set back image using URL %s: %s", *ret.BackImage, err.Error())
-			}
-
 			return ret, nil
 		}
 	}
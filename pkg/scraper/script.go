@@ -180,3 +180,17 @@ func (s *scriptScraper) scrapeMovieByURL(url string) (*models.ScrapedMovie, erro
 
 	return &ret, err
 }
+
+func (s *scriptScraper) scrapeMovieByFragment(movie models.MovieUpdateInput) (*models.ScrapedMovie, error) {
+	inString, err := json.Marshal(movie)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ret models.ScrapedMovie
+
+	err = s.runScraperScript(string(inString), &ret)
+
+	return &ret, err
+}
@@ -262,6 +262,10 @@ func (s *stashScraper) scrapeMovieByURL(url string) (*models.ScrapedMovie, error
 	return nil, errors.New("scrapeMovieByURL not supported for stash scraper")
 }
 
+func (s *stashScraper) scrapeMovieByFragment(movie models.MovieUpdateInput) (*models.ScrapedMovie, error) {
+	return nil, errors.New("scrapeMovieByFragment not supported for stash scraper")
+}
+
 func sceneFromUpdateFragment(scene models.SceneUpdateInput) (*models.Scene, error) {
 	qb := models.NewSceneQueryBuilder()
 	id, err := strconv.Atoi(scene.ID)
@@ -282,3 +286,24 @@ func galleryFromUpdateFragment(gallery models.GalleryUpdateInput) (*models.Galle
 
 	return qb.Find(id, nil)
 }
+
+// movieFromUpdateFragment returns the stored movie and its URLs.
+func movieFromUpdateFragment(movie models.MovieUpdateInput) (*models.Movie, []string, error) {
+	qb := models.NewMovieQueryBuilder()
+	id, err := strconv.Atoi(movie.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ret, err := qb.Find(id, nil)
+	if err != nil || ret == nil {
+		return nil, nil, err
+	}
+
+	urls, err := qb.GetURLs(id, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ret, urls, nil
+}
@@ -0,0 +1,378 @@
+package stashbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper/stashbox/graphql"
+)
+
+// The draft mutations are not part of the generated client, which cannot
+// upload images. They are sent as GraphQL multipart requests instead.
+
+const submitSceneDraftQuery = `mutation SubmitSceneDraft($input: SceneDraftInput!) {
+	submitSceneDraft(input: $input) {
+		id
+	}
+}`
+
+const submitPerformerDraftQuery = `mutation SubmitPerformerDraft($input: PerformerDraftInput!) {
+	submitPerformerDraft(input: $input) {
+		id
+	}
+}`
+
+// SceneDraftFields are the fields of scenes which can be submitted in drafts.
+var SceneDraftFields = []string{"title", "details", "url", "date", "studio", "performers", "tags", "image", "fingerprints"}
+
+// PerformerDraftFields are the fields of performers which can be submitted
+// in drafts, besides the name which is always submitted.
+var PerformerDraftFields = []string{"aliases", "gender", "birthdate", "urls", "ethnicity", "country", "eye_color", "height", "measurements", "breast_type", "tattoos", "piercings", "career_length", "image"}
+
+type draftEntityInput struct {
+	Name string  `json:"name"`
+	ID   *string `json:"id,omitempty"`
+}
+
+type sceneDraftInput struct {
+	ID           *string                     `json:"id,omitempty"`
+	Title        *string                     `json:"title,omitempty"`
+	Details      *string                     `json:"details,omitempty"`
+	URL          *string                     `json:"url,omitempty"`
+	Date         *string                     `json:"date,omitempty"`
+	Studio       *draftEntityInput           `json:"studio,omitempty"`
+	Performers   []*draftEntityInput         `json:"performers"`
+	Tags         []*draftEntityInput         `json:"tags,omitempty"`
+	Image        interface{}                 `json:"image"`
+	Fingerprints []*graphql.FingerprintInput `json:"fingerprints"`
+}
+
+type performerDraftInput struct {
+	ID              *string     `json:"id,omitempty"`
+	Name            string      `json:"name"`
+	Aliases         *string     `json:"aliases,omitempty"`
+	Gender          *string     `json:"gender,omitempty"`
+	Birthdate       *string     `json:"birthdate,omitempty"`
+	Urls            []string    `json:"urls,omitempty"`
+	Ethnicity       *string     `json:"ethnicity,omitempty"`
+	Country         *string     `json:"country,omitempty"`
+	EyeColor        *string     `json:"eye_color,omitempty"`
+	Height          *string     `json:"height,omitempty"`
+	Measurements    *string     `json:"measurements,omitempty"`
+	BreastType      *string     `json:"breast_type,omitempty"`
+	Tattoos         *string     `json:"tattoos,omitempty"`
+	Piercings       *string     `json:"piercings,omitempty"`
+	CareerStartYear *int        `json:"career_start_year,omitempty"`
+	CareerEndYear   *int        `json:"career_end_year,omitempty"`
+	Image           interface{} `json:"image"`
+}
+
+// draftFields is the set of fields included in a draft.
+type draftFields map[string]bool
+
+// newDraftFields returns the set of fields, or of all valid fields if fields
+// is empty. An error is returned if a field is not valid.
+func newDraftFields(fields []string, valid []string) (draftFields, error) {
+	if len(fields) == 0 {
+		fields = valid
+	}
+
+	ret := make(draftFields)
+	for _, f := range fields {
+		found := false
+		for _, v := range valid {
+			if f == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid draft field %s, must be one of %s", f, strings.Join(valid, ", "))
+		}
+		ret[f] = true
+	}
+
+	return ret, nil
+}
+
+func (f draftFields) nullString(field string, s sql.NullString) *string {
+	if !f[field] || !s.Valid || s.String == "" {
+		return nil
+	}
+	return &s.String
+}
+
+func (c Client) findStashID(stashIDs []*models.StashID) *string {
+	for _, s := range stashIDs {
+		if s.Endpoint == c.endpoint {
+			id := s.StashID
+			return &id
+		}
+	}
+	return nil
+}
+
+// SubmitSceneDraft submits the scene as a draft to the stash-box instance,
+// which its users can review and turn into an edit. Only the fields of
+// SceneDraftFields named in fields are submitted, or all of them if fields is
+// empty. Returns the ID of the draft.
+func (c Client) SubmitSceneDraft(ctx context.Context, sceneID int, fields []string) (string, error) {
+	f, err := newDraftFields(fields, SceneDraftFields)
+	if err != nil {
+		return "", err
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	scene, err := qb.Find(sceneID)
+	if err != nil {
+		return "", err
+	}
+	if scene == nil {
+		return "", fmt.Errorf("scene with id %d not found", sceneID)
+	}
+
+	stashIDs, err := jqb.GetSceneStashIDs(sceneID)
+	if err != nil {
+		return "", err
+	}
+
+	draft := sceneDraftInput{
+		ID:           c.findStashID(stashIDs),
+		Title:        f.nullString("title", scene.Title),
+		Details:      f.nullString("details", scene.Details),
+		URL:          f.nullString("url", scene.URL),
+		Date:         f.nullString("date", sql.NullString(scene.Date)),
+		Performers:   []*draftEntityInput{},
+		Fingerprints: []*graphql.FingerprintInput{},
+	}
+
+	if f["studio"] && scene.StudioID.Valid {
+		sqb := models.NewStudioQueryBuilder()
+		studio, err := sqb.Find(int(scene.StudioID.Int64), nil)
+		if err != nil {
+			return "", err
+		}
+		if studio == nil {
+			return "", fmt.Errorf("studio with id %d not found", scene.StudioID.Int64)
+		}
+		studioStashIDs, err := jqb.GetStudioStashIDs(studio.ID)
+		if err != nil {
+			return "", err
+		}
+		draft.Studio = &draftEntityInput{Name: studio.Name.String, ID: c.findStashID(studioStashIDs)}
+	}
+
+	if f["performers"] {
+		pqb := models.NewPerformerQueryBuilder()
+		performers, err := pqb.FindBySceneID(sceneID, nil)
+		if err != nil {
+			return "", err
+		}
+		for _, p := range performers {
+			performerStashIDs, err := jqb.GetPerformerStashIDs(p.ID)
+			if err != nil {
+				return "", err
+			}
+			draft.Performers = append(draft.Performers, &draftEntityInput{Name: p.Name.String, ID: c.findStashID(performerStashIDs)})
+		}
+	}
+
+	if f["tags"] {
+		tqb := models.NewTagQueryBuilder()
+		tags, err := tqb.FindBySceneID(sceneID, nil)
+		if err != nil {
+			return "", err
+		}
+		for _, t := range tags {
+			draft.Tags = append(draft.Tags, &draftEntityInput{Name: t.Name})
+		}
+	}
+
+	if f["fingerprints"] {
+		draft.Fingerprints = append(draft.Fingerprints, getSceneFingerprints(scene)...)
+	}
+
+	var image []byte
+	if f["image"] {
+		image, err = qb.GetSceneCover(sceneID, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return c.submitDraft(ctx, submitSceneDraftQuery, "submitSceneDraft", draft, image)
+}
+
+// SubmitPerformerDraft submits the performer as a draft to the stash-box
+// instance, which its users can review and turn into an edit. Only the
+// fields of PerformerDraftFields named in fields are submitted, or all of
+// them if fields is empty. Returns the ID of the draft.
+func (c Client) SubmitPerformerDraft(ctx context.Context, performerID int, fields []string) (string, error) {
+	f, err := newDraftFields(fields, PerformerDraftFields)
+	if err != nil {
+		return "", err
+	}
+
+	qb := models.NewPerformerQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	performer, err := qb.Find(performerID)
+	if err != nil {
+		return "", err
+	}
+	if performer == nil {
+		return "", fmt.Errorf("performer with id %d not found", performerID)
+	}
+
+	stashIDs, err := jqb.GetPerformerStashIDs(performerID)
+	if err != nil {
+		return "", err
+	}
+
+	draft := performerDraftInput{
+		ID:           c.findStashID(stashIDs),
+		Name:         performer.Name.String,
+		Aliases:      f.nullString("aliases", performer.Aliases),
+		Gender:       f.nullString("gender", performer.Gender),
+		Birthdate:    f.nullString("birthdate", sql.NullString(performer.Birthdate)),
+		Ethnicity:    f.nullString("ethnicity", performer.Ethnicity),
+		Country:      f.nullString("country", performer.Country),
+		EyeColor:     f.nullString("eye_color", performer.EyeColor),
+		Height:       f.nullString("height", performer.Height),
+		Measurements: f.nullString("measurements", performer.Measurements),
+		BreastType:   f.nullString("breast_type", performer.FakeTits),
+		Tattoos:      f.nullString("tattoos", performer.Tattoos),
+		Piercings:    f.nullString("piercings", performer.Piercings),
+	}
+
+	if f["career_length"] && performer.CareerLength.Valid {
+		draft.CareerStartYear, draft.CareerEndYear = parseCareerLength(performer.CareerLength.String)
+	}
+
+	if f["urls"] {
+		urls, err := qb.GetURLs(performerID, nil)
+		if err != nil {
+			return "", err
+		}
+		for _, u := range urls {
+			draft.Urls = append(draft.Urls, u.URL)
+		}
+	}
+
+	var image []byte
+	if f["image"] {
+		image, err = qb.GetPerformerImage(performerID, nil)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return c.submitDraft(ctx, submitPerformerDraftQuery, "submitPerformerDraft", draft, image)
+}
+
+// parseCareerLength returns the start and end years of a career length such
+// as "2015 - 2020" or "2015 -". The end year is nil if the career is ongoing.
+func parseCareerLength(careerLength string) (start *int, end *int) {
+	parts := strings.SplitN(careerLength, "-", 2)
+
+	if y, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil {
+		start = &y
+	}
+	if len(parts) == 2 {
+		if y, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+			end = &y
+		}
+	}
+
+	return start, end
+}
+
+type draftResponse struct {
+	Data   map[string]*struct{ ID *string } `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// submitDraft sends the draft mutation as a GraphQL multipart request, with
+// the image as the image of the input if it is not empty, and returns the ID
+// of the draft.
+func (c Client) submitDraft(ctx context.Context, query string, mutation string, input interface{}, image []byte) (string, error) {
+	operations, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"input": input},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	fileMap := "{}"
+	if len(image) > 0 {
+		fileMap = `{"0": ["variables.input.image"]}`
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField("operations", string(operations)); err != nil {
+		return "", err
+	}
+	if err := w.WriteField("map", fileMap); err != nil {
+		return "", err
+	}
+	if len(image) > 0 {
+		part, err := w.CreateFormFile("0", "image")
+		if err != nil {
+			return "", err
+		}
+		if _, err := part.Write(image); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("ApiKey", c.apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var res draftResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", fmt.Errorf("error decoding response (http status %d): %s", resp.StatusCode, err.Error())
+	}
+
+	if len(res.Errors) > 0 {
+		var messages []string
+		for _, e := range res.Errors {
+			messages = append(messages, e.Message)
+		}
+		return "", errors.New(strings.Join(messages, "; "))
+	}
+
+	status := res.Data[mutation]
+	if status == nil || status.ID == nil {
+		return "", fmt.Errorf("%s returned no draft id", mutation)
+	}
+
+	return *status.ID, nil
+}
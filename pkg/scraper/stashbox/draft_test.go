@@ -0,0 +1,88 @@
+package stashbox
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDraftFields(t *testing.T) {
+	f, err := newDraftFields(nil, SceneDraftFields)
+	assert.Nil(t, err)
+	assert.Len(t, f, len(SceneDraftFields))
+
+	f, err = newDraftFields([]string{"title", "image"}, SceneDraftFields)
+	assert.Nil(t, err)
+	assert.Equal(t, draftFields{"title": true, "image": true}, f)
+
+	_, err = newDraftFields([]string{"rating"}, SceneDraftFields)
+	assert.NotNil(t, err)
+}
+
+func TestParseCareerLength(t *testing.T) {
+	start, end := parseCareerLength("2015 - 2020")
+	assert.Equal(t, 2015, *start)
+	assert.Equal(t, 2020, *end)
+
+	start, end = parseCareerLength("2015 -")
+	assert.Equal(t, 2015, *start)
+	assert.Nil(t, end)
+
+	start, end = parseCareerLength("unknown")
+	assert.Nil(t, start)
+	assert.Nil(t, end)
+}
+
+func TestSubmitDraft(t *testing.T) {
+	var operations map[string]interface{}
+	var fileMap string
+	var image []byte
+	var apiKey string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("ApiKey")
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_ = json.Unmarshal([]byte(r.FormValue("operations")), &operations)
+		fileMap = r.FormValue("map")
+		if f, _, err := r.FormFile("0"); err == nil {
+			image, _ = ioutil.ReadAll(f)
+		}
+
+		_, _ = w.Write([]byte(`{"data": {"submitSceneDraft": {"id": "draft-id"}}}`))
+	}))
+	defer server.Close()
+
+	c := Client{endpoint: server.URL, apiKey: "key"}
+	title := "Title"
+	id, err := c.submitDraft(context.Background(), submitSceneDraftQuery, "submitSceneDraft", sceneDraftInput{Title: &title}, []byte("image data"))
+	assert.Nil(t, err)
+	assert.Equal(t, "draft-id", id)
+
+	assert.Equal(t, "key", apiKey)
+	assert.Equal(t, submitSceneDraftQuery, operations["query"])
+	input := operations["variables"].(map[string]interface{})["input"].(map[string]interface{})
+	assert.Equal(t, "Title", input["title"])
+	assert.Nil(t, input["image"])
+	assert.JSONEq(t, `{"0": ["variables.input.image"]}`, fileMap)
+	assert.Equal(t, "image data", string(image))
+}
+
+func TestSubmitDraftError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors": [{"message": "not authorized"}], "data": null}`))
+	}))
+	defer server.Close()
+
+	c := Client{endpoint: server.URL}
+	_, err := c.submitDraft(context.Background(), submitPerformerDraftQuery, "submitPerformerDraft", performerDraftInput{Name: "a"}, nil)
+	assert.EqualError(t, err, "not authorized")
+}
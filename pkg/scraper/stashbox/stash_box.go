@@ -23,7 +23,9 @@ const imageGetTimeout = time.Second * 30
 
 // Client represents the client interface to a stash-box server instance.
 type Client struct {
-	client *graphql.Client
+	client   *graphql.Client
+	endpoint string
+	apiKey   string
 }
 
 // NewClient returns a new instance of a stash-box client.
@@ -37,7 +39,9 @@ func NewClient(box models.StashBox) *Client {
 	}
 
 	return &Client{
-		client: client,
+		client:   client,
+		endpoint: box.Endpoint,
+		apiKey:   box.APIKey,
 	}
 }
 
@@ -52,7 +56,7 @@ func (c Client) QueryStashBoxScene(queryStr string) ([]*models.ScrapedScene, err
 
 	var ret []*models.ScrapedScene
 	for _, s := range sceneFragments {
-		ss, err := sceneFragmentToScrapedScene(s)
+		ss, err := c.sceneFragmentToScrapedScene(s)
 		if err != nil {
 			return nil, err
 		}
@@ -108,7 +112,7 @@ func (c Client) findStashBoxScenesByFingerprints(fingerprints []string) ([]*mode
 		sceneFragments := scenes.FindScenesByFingerprints
 
 		for _, s := range sceneFragments {
-			ss, err := sceneFragmentToScrapedScene(s)
+			ss, err := c.sceneFragmentToScrapedScene(s)
 			if err != nil {
 				return nil, err
 			}
@@ -149,27 +153,10 @@ func (c Client) SubmitStashBoxFingerprints(sceneIDs []string, endpoint string) (
 		}
 
 		if sceneStashID != "" {
-			if scene.Checksum.Valid && scene.Duration.Valid {
-				fingerprint := graphql.FingerprintInput{
-					Hash:      scene.Checksum.String,
-					Algorithm: graphql.FingerprintAlgorithmMd5,
-					Duration:  int(scene.Duration.Float64),
-				}
+			for _, fingerprint := range getSceneFingerprints(scene) {
 				fingerprints = append(fingerprints, graphql.FingerprintSubmission{
 					SceneID:     sceneStashID,
-					Fingerprint: &fingerprint,
-				})
-			}
-
-			if scene.OSHash.Valid && scene.Duration.Valid {
-				fingerprint := graphql.FingerprintInput{
-					Hash:      scene.OSHash.String,
-					Algorithm: graphql.FingerprintAlgorithmOshash,
-					Duration:  int(scene.Duration.Float64),
-				}
-				fingerprints = append(fingerprints, graphql.FingerprintSubmission{
-					SceneID:     sceneStashID,
-					Fingerprint: &fingerprint,
+					Fingerprint: fingerprint,
 				})
 			}
 		}
@@ -178,6 +165,33 @@ func (c Client) SubmitStashBoxFingerprints(sceneIDs []string, endpoint string) (
 	return c.submitStashBoxFingerprints(fingerprints)
 }
 
+// getSceneFingerprints returns the MD5 and oshash fingerprints of the scene,
+// if it has them and a duration.
+func getSceneFingerprints(scene *models.Scene) []*graphql.FingerprintInput {
+	if !scene.Duration.Valid {
+		return nil
+	}
+
+	var ret []*graphql.FingerprintInput
+	if scene.Checksum.Valid {
+		ret = append(ret, &graphql.FingerprintInput{
+			Hash:      scene.Checksum.String,
+			Algorithm: graphql.FingerprintAlgorithmMd5,
+			Duration:  int(scene.Duration.Float64),
+		})
+	}
+
+	if scene.OSHash.Valid {
+		ret = append(ret, &graphql.FingerprintInput{
+			Hash:      scene.OSHash.String,
+			Algorithm: graphql.FingerprintAlgorithmOshash,
+			Duration:  int(scene.Duration.Float64),
+		})
+	}
+
+	return ret
+}
+
 func (c Client) submitStashBoxFingerprints(fingerprints []graphql.FingerprintSubmission) (bool, error) {
 	for _, fingerprint := range fingerprints {
 		_, err := c.client.SubmitFingerprint(context.TODO(), fingerprint)
@@ -355,7 +369,7 @@ func getFingerprints(scene *graphql.SceneFragment) []*models.StashBoxFingerprint
 	return fingerprints
 }
 
-func sceneFragmentToScrapedScene(s *graphql.SceneFragment) (*models.ScrapedScene, error) {
+func (c Client) sceneFragmentToScrapedScene(s *graphql.SceneFragment) (*models.ScrapedScene, error) {
 	stashID := s.ID
 	ss := &models.ScrapedScene{
 		Title:        s.Title,
@@ -369,6 +383,10 @@ func sceneFragmentToScrapedScene(s *graphql.SceneFragment) (*models.ScrapedScene
 		// stash_id
 	}
 
+	if err := models.MatchScrapedSceneStashID(ss, c.endpoint); err != nil {
+		return nil, err
+	}
+
 	if len(s.Images) > 0 {
 		// TODO - #454 code sorts images by aspect ratio according to a wanted
 		// orientation. I'm just grabbing the first for now
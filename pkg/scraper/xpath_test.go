@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -738,6 +739,49 @@ xPathScrapers:
 	assert.Equal(t, "January 2, 2006", string(*parseDate))
 }
 
+func TestLoadMovieFragmentScraperFromYAML(t *testing.T) {
+	const yamlStr = `name: Test
+movieByFragment:
+  action: scrapeXPath
+  queryURL: https://test.com/search?q={name}
+  queryURLReplace:
+    name:
+      - regex: \s+
+        with: "+"
+  scraper: movieScraper
+xPathScrapers:
+  movieScraper:
+    movie:
+      Name: //h1
+      Synopsis: //div[@class="synopsis"]
+      Studio:
+        Name: //studio
+`
+
+	c, err := loadScraperFromYAML("test", strings.NewReader(yamlStr))
+	if err != nil {
+		t.Errorf("Error loading yaml: %s", err.Error())
+		return
+	}
+
+	assert.True(t, c.supportsMovies())
+
+	spec := c.toScraper().Movie
+	if assert.NotNil(t, spec) {
+		assert.Equal(t, []models.ScrapeType{models.ScrapeTypeFragment}, spec.SupportedScrapes)
+	}
+
+	queryURL := queryURLParametersFromMovie(&models.Movie{
+		Name: sql.NullString{String: "Movie Name", Valid: true},
+	}, nil)
+	queryURL.applyReplacements(c.MovieByFragment.QueryURLReplacements)
+	assert.Equal(t, "https://test.com/search?q=Movie+Name", queryURL.constructURL(c.MovieByFragment.QueryURL))
+
+	movieConfig := c.XPathScrapers["movieScraper"].Movie
+	assert.Equal(t, "//h1", movieConfig.mappedConfig["Name"].Selector)
+	assert.Equal(t, "//studio", movieConfig.Studio["Name"].Selector)
+}
+
 func TestLoadInvalidXPath(t *testing.T) {
 	config := make(mappedConfig)
 
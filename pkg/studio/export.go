@@ -11,6 +11,7 @@ import (
 // ToJSON converts a Studio object into its JSON equivalent.
 func ToJSON(reader models.StudioReader, studio *models.Studio) (*jsonschema.Studio, error) {
 	newStudioJSON := jsonschema.Studio{
+		UUID:      studio.UUID.String,
 		CreatedAt: models.JSONTime{Time: studio.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: studio.UpdatedAt.Timestamp},
 	}
@@ -23,6 +24,10 @@ func ToJSON(reader models.StudioReader, studio *models.Studio) (*jsonschema.Stud
 		newStudioJSON.URL = studio.URL.String
 	}
 
+	if studio.Slug.Valid {
+		newStudioJSON.Slug = studio.Slug.String
+	}
+
 	if studio.ParentID.Valid {
 		parent, err := reader.Find(int(studio.ParentID.Int64))
 		if err != nil {
@@ -28,10 +28,15 @@ func (i *Importer) PreImport() error {
 		Checksum:  checksum,
 		Name:      sql.NullString{String: i.Input.Name, Valid: true},
 		URL:       sql.NullString{String: i.Input.URL, Valid: true},
+		UUID:      sql.NullString{String: i.Input.UUID, Valid: i.Input.UUID != ""},
 		CreatedAt: models.SQLiteTimestamp{Timestamp: i.Input.CreatedAt.GetTime()},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: i.Input.UpdatedAt.GetTime()},
 	}
 
+	if i.Input.Slug != "" {
+		i.studio.Slug = sql.NullString{String: i.Input.Slug, Valid: true}
+	}
+
 	if err := i.populateParentStudio(); err != nil {
 		return err
 	}
@@ -134,6 +139,10 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	studio := i.studio
 	studio.ID = id
+	// keep the slug and uuid of the existing studio so that links and other
+	// instances referring to it still work
+	studio.Slug = sql.NullString{}
+	studio.UUID = sql.NullString{}
 	_, err := i.ReaderWriter.UpdateFull(studio)
 	if err != nil {
 		return fmt.Errorf("error updating existing studio: %s", err.Error())
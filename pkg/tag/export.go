@@ -12,6 +12,7 @@ import (
 func ToJSON(reader models.TagReader, tag *models.Tag) (*jsonschema.Tag, error) {
 	newTagJSON := jsonschema.Tag{
 		Name:      tag.Name,
+		UUID:      tag.UUID.String,
 		CreatedAt: models.JSONTime{Time: tag.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: tag.UpdatedAt.Timestamp},
 	}
@@ -1,6 +1,7 @@
 package tag
 
 import (
+	"database/sql"
 	"errors"
 
 	"github.com/stashapp/stash/pkg/manager/jsonschema"
@@ -19,6 +20,7 @@ const (
 )
 
 const tagName = "testTag"
+const tagUUID = "0b1e5c1a-3f0e-4d6c-9a8b-7c6d5e4f3a2b"
 
 var createTime time.Time = time.Date(2001, 01, 01, 0, 0, 0, 0, time.UTC)
 var updateTime time.Time = time.Date(2002, 01, 01, 0, 0, 0, 0, time.UTC)
@@ -27,6 +29,7 @@ func createTag(id int) models.Tag {
 	return models.Tag{
 		ID:   id,
 		Name: tagName,
+		UUID: sql.NullString{String: tagUUID, Valid: true},
 		CreatedAt: models.SQLiteTimestamp{
 			Timestamp: createTime,
 		},
@@ -39,6 +42,7 @@ func createTag(id int) models.Tag {
 func createJSONTag(image string) *jsonschema.Tag {
 	return &jsonschema.Tag{
 		Name: tagName,
+		UUID: tagUUID,
 		CreatedAt: models.JSONTime{
 			Time: createTime,
 		},
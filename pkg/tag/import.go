@@ -1,6 +1,7 @@
 package tag
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/stashapp/stash/pkg/manager/jsonschema"
@@ -19,6 +20,7 @@ type Importer struct {
 func (i *Importer) PreImport() error {
 	i.tag = models.Tag{
 		Name:      i.Input.Name,
+		UUID:      sql.NullString{String: i.Input.UUID, Valid: i.Input.UUID != ""},
 		CreatedAt: models.SQLiteTimestamp{Timestamp: i.Input.CreatedAt.GetTime()},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: i.Input.UpdatedAt.GetTime()},
 	}
@@ -76,6 +78,8 @@ func (i *Importer) Create() (*int, error) {
 func (i *Importer) Update(id int) error {
 	tag := i.tag
 	tag.ID = id
+	// keep the uuid of the existing tag, which other instances refer to it by
+	tag.UUID = sql.NullString{}
 	_, err := i.ReaderWriter.Update(tag)
 	if err != nil {
 		return fmt.Errorf("error updating existing tag: %s", err.Error())
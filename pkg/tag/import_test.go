@@ -45,10 +45,12 @@ func TestImporterPreImport(t *testing.T) {
 	assert.NotNil(t, err)
 
 	i.Input.Image = image
+	i.Input.UUID = tagUUID
 
 	err = i.PreImport()
 
 	assert.Nil(t, err)
+	assert.Equal(t, tagUUID, i.tag.UUID.String)
 }
 
 func TestImporterPostImport(t *testing.T) {
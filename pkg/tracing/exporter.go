@@ -0,0 +1,338 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// serviceName is the name of the service of the exported spans.
+const serviceName = "stash"
+
+// exportInterval is the maximum time that ended spans wait to be exported.
+const exportInterval = 5 * time.Second
+
+// maxExportBatch is the number of queued spans which are exported without
+// waiting for the export interval.
+const maxExportBatch = 512
+
+// maxQueuedSpans is the maximum number of spans waiting to be exported.
+// Spans ended while the queue is full are dropped.
+const maxQueuedSpans = 4096
+
+var (
+	exporterMutex sync.RWMutex
+	current       *exporter
+)
+
+// exporter queues ended spans and periodically posts them to the OTLP/HTTP
+// traces endpoint.
+type exporter struct {
+	endpoint string
+	client   *http.Client
+	flush    chan chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+
+	// mutex guards the fields below
+	mutex   sync.Mutex
+	queue   []*Span
+	dropped int
+	failing bool
+}
+
+// SetEndpoint sets the URL of the OTLP/HTTP traces endpoint, such as
+// http://localhost:4318/v1/traces, which spans are exported to. The spans of
+// the previous endpoint are exported before it is replaced. An empty
+// endpoint disables tracing.
+func SetEndpoint(endpoint string) {
+	exporterMutex.Lock()
+	previous := current
+	current = nil
+	if endpoint != "" {
+		current = newExporter(endpoint)
+	}
+	exporterMutex.Unlock()
+
+	if previous != nil {
+		previous.shutdown()
+	}
+
+	if endpoint != "" {
+		logger.Infof("Exporting traces to %s", endpoint)
+	}
+}
+
+// Enabled returns true if spans are recorded and exported.
+func Enabled() bool {
+	return getExporter() != nil
+}
+
+// Flush exports the ended spans which have not yet been exported.
+func Flush() {
+	if e := getExporter(); e != nil {
+		done := make(chan struct{})
+		select {
+		case e.flush <- done:
+			<-done
+		case <-e.stopped:
+		}
+	}
+}
+
+func getExporter() *exporter {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return current
+}
+
+func newExporter(endpoint string) *exporter {
+	ret := &exporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		flush:    make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	go ret.run()
+
+	return ret
+}
+
+func (e *exporter) run() {
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.export()
+		case done := <-e.flush:
+			e.export()
+			close(done)
+		case <-e.stop:
+			e.export()
+			close(e.stopped)
+			return
+		}
+	}
+}
+
+// shutdown exports the queued spans and stops the exporter.
+func (e *exporter) shutdown() {
+	close(e.stop)
+	<-e.stopped
+}
+
+func (e *exporter) add(s *Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(e.queue) >= maxQueuedSpans {
+		e.dropped++
+		return
+	}
+
+	e.queue = append(e.queue, s)
+	if len(e.queue) == maxExportBatch {
+		// export without blocking the caller
+		select {
+		case e.flush <- make(chan struct{}):
+		default:
+		}
+	}
+}
+
+// export posts the queued spans in batches of at most maxExportBatch spans.
+func (e *exporter) export() {
+	e.mutex.Lock()
+	spans := e.queue
+	dropped := e.dropped
+	e.queue = nil
+	e.dropped = 0
+	e.mutex.Unlock()
+
+	if dropped > 0 {
+		logger.Warnf("Dropped %d spans because the export queue was full", dropped)
+	}
+
+	for len(spans) > 0 {
+		n := len(spans)
+		if n > maxExportBatch {
+			n = maxExportBatch
+		}
+
+		err := e.post(spans[:n])
+		spans = spans[n:]
+
+		// only log the first of consecutive failures
+		e.mutex.Lock()
+		if err != nil && !e.failing {
+			logger.Warnf("Error exporting spans to %s: %s", e.endpoint, err.Error())
+		}
+		e.failing = err != nil
+		e.mutex.Unlock()
+	}
+}
+
+func (e *exporter) post(spans []*Span) error {
+	body, err := json.Marshal(newOTLPRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// The types below are the JSON encoding of the OTLP trace export request.
+// See https://github.com/open-telemetry/opentelemetry-proto.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// otlpStatusError is the status code of failed spans.
+const otlpStatusError = 2
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func newOTLPKeyValue(key string, value interface{}) otlpKeyValue {
+	ret := otlpKeyValue{Key: key}
+
+	switch v := value.(type) {
+	case int:
+		s := strconv.Itoa(v)
+		ret.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		ret.Value.IntValue = &s
+	case float64:
+		ret.Value.DoubleValue = &v
+	case bool:
+		ret.Value.BoolValue = &v
+	case string:
+		ret.Value.StringValue = &v
+	default:
+		s := fmt.Sprint(v)
+		ret.Value.StringValue = &s
+	}
+
+	return ret
+}
+
+func newOTLPSpan(s *Span) otlpSpan {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+
+	if s.parentID != (spanID{}) {
+		ret.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+
+	var keys []string
+	for k := range s.attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ret.Attributes = append(ret.Attributes, newOTLPKeyValue(k, s.attributes[k]))
+	}
+
+	if s.err != nil {
+		ret.Status = &otlpStatus{Code: otlpStatusError, Message: s.err.Error()}
+	}
+
+	return ret
+}
+
+func newOTLPRequest(spans []*Span) otlpRequest {
+	scopeSpans := otlpScopeSpans{
+		Scope: otlpScope{Name: serviceName},
+	}
+	for _, s := range spans {
+		scopeSpans.Spans = append(scopeSpans.Spans, newOTLPSpan(s))
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{newOTLPKeyValue("service.name", serviceName)},
+				},
+				ScopeSpans: []otlpScopeSpans{scopeSpans},
+			},
+		},
+	}
+}
@@ -0,0 +1,195 @@
+// Package tracing records the spans of requests, resolvers, queries, jobs
+// and ffmpeg calls, and exports them to an OpenTelemetry collector, or to
+// Jaeger which accepts OTLP, using the OTLP/HTTP JSON protocol.
+//
+// Spans are parented through contexts. Query builders and ffmpeg calls don't
+// receive the context of their caller, so their spans start their own traces.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanKind is the OpenTelemetry kind of a span.
+type SpanKind int
+
+const (
+	// KindInternal spans are operations within stash
+	KindInternal SpanKind = 1
+	// KindServer spans handle requests from remote clients
+	KindServer SpanKind = 2
+	// KindClient spans make requests to remote services or processes
+	KindClient SpanKind = 3
+)
+
+type traceID [16]byte
+type spanID [8]byte
+
+// spanContext identifies a span, which is the parent of the spans started
+// with a context holding it.
+type spanContext struct {
+	traceID traceID
+	spanID  spanID
+}
+
+type spanContextKey struct{}
+
+// Span is a timed operation of a trace. All methods of Span can be called on
+// a nil span, which is returned when tracing is disabled.
+type Span struct {
+	traceID  traceID
+	spanID   spanID
+	parentID spanID
+	start    time.Time
+
+	// mutex guards the fields below
+	mutex      sync.Mutex
+	name       string
+	kind       SpanKind
+	attributes map[string]interface{}
+	err        error
+	end        time.Time
+	ended      bool
+}
+
+// Start starts a span, which is the child of the span of ctx if it has one,
+// and returns a context holding the span. The span must be ended with End.
+// Returns ctx and a nil span if tracing is disabled.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+
+	span := &Span{
+		name:  name,
+		kind:  KindInternal,
+		start: time.Now(),
+	}
+
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+	}
+	_, _ = rand.Read(span.spanID[:])
+
+	return context.WithValue(ctx, spanContextKey{}, spanContext{traceID: span.traceID, spanID: span.spanID}), span
+}
+
+// StartChild starts a span which is the child of s, or a new trace if s is
+// nil.
+func (s *Span) StartChild(name string) *Span {
+	ctx := context.Background()
+	if s != nil {
+		ctx = context.WithValue(ctx, spanContextKey{}, spanContext{traceID: s.traceID, spanID: s.spanID})
+	}
+
+	_, ret := Start(ctx, name)
+	return ret
+}
+
+// ContextWithTraceParent returns a context holding the remote parent span
+// of a W3C traceparent header, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01, so that the spans
+// started with it continue the trace of the caller. Returns ctx if the header
+// is empty or invalid.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return ctx
+	}
+
+	var parent spanContext
+	traceIDBytes, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceIDBytes) != len(parent.traceID) {
+		return ctx
+	}
+	spanIDBytes, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanIDBytes) != len(parent.spanID) {
+		return ctx
+	}
+	copy(parent.traceID[:], traceIDBytes)
+	copy(parent.spanID[:], spanIDBytes)
+
+	if parent.traceID == (traceID{}) || parent.spanID == (spanID{}) {
+		return ctx
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, parent)
+}
+
+// SetName changes the name of the span, such as when the route of a request
+// is known after it was handled.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.name = name
+}
+
+// SetKind sets the kind of the span, which is KindInternal by default.
+func (s *Span) SetKind(kind SpanKind) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.kind = kind
+}
+
+// SetAttribute sets an attribute of the span. Values are exported as
+// integers, floats, booleans or, for other types, strings.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with err. Nil errors are ignored.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+// End ends the span and queues it for export. Only the first call has an
+// effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	if e := getExporter(); e != nil {
+		e.add(s)
+	}
+}
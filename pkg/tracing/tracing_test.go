@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisabled(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := Start(ctx, "span")
+
+	assert.Nil(t, span)
+	assert.Equal(t, ctx, spanCtx)
+
+	// methods of nil spans have no effect
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("error"))
+	span.End()
+	assert.Nil(t, span.StartChild("child"))
+}
+
+func TestContextWithTraceParent(t *testing.T) {
+	ctx := context.Background()
+
+	parentCtx := ContextWithTraceParent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	parent, ok := parentCtx.Value(spanContextKey{}).(spanContext)
+	assert.True(t, ok)
+	assert.Equal(t, traceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}, parent.traceID)
+	assert.Equal(t, spanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}, parent.spanID)
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-zzf067aa0ba902b7-01",
+	}
+	for _, h := range invalid {
+		assert.Equal(t, ctx, ContextWithTraceParent(ctx, h), h)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []otlpRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		mutex.Lock()
+		requests = append(requests, req)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	SetEndpoint(server.URL)
+	defer SetEndpoint("")
+
+	ctx := ContextWithTraceParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, parent := Start(ctx, "parent")
+	parent.SetKind(KindServer)
+	parent.SetAttribute("count", 2)
+
+	_, child := Start(ctx, "child")
+	child.SetError(errors.New("failed"))
+	child.End()
+
+	job := parent.StartChild("job")
+	job.End()
+	parent.End()
+	// ending twice has no effect
+	parent.End()
+
+	Flush()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Len(t, requests, 1)
+	resourceSpans := requests[0].ResourceSpans[0]
+	assert.Equal(t, "service.name", resourceSpans.Resource.Attributes[0].Key)
+	assert.Equal(t, serviceName, *resourceSpans.Resource.Attributes[0].Value.StringValue)
+
+	spans := resourceSpans.ScopeSpans[0].Spans
+	assert.Len(t, spans, 3)
+
+	childSpan, jobSpan, parentSpan := spans[0], spans[1], spans[2]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parentSpan.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", parentSpan.ParentSpanID)
+	assert.Equal(t, KindServer, parentSpan.Kind)
+	assert.Equal(t, "count", parentSpan.Attributes[0].Key)
+	assert.Equal(t, "2", *parentSpan.Attributes[0].Value.IntValue)
+	assert.Nil(t, parentSpan.Status)
+
+	assert.Equal(t, "child", childSpan.Name)
+	assert.Equal(t, parentSpan.TraceID, childSpan.TraceID)
+	assert.Equal(t, parentSpan.SpanID, childSpan.ParentSpanID)
+	assert.Equal(t, KindInternal, childSpan.Kind)
+	assert.Equal(t, &otlpStatus{Code: otlpStatusError, Message: "failed"}, childSpan.Status)
+
+	assert.Equal(t, parentSpan.SpanID, jobSpan.ParentSpanID)
+}
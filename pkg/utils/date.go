@@ -2,11 +2,45 @@ package utils
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
 const railsTimeLayout = "2006-01-02 15:04:05 MST"
 
+var timezone = struct {
+	mutex    sync.RWMutex
+	location *time.Location
+}{
+	location: time.Local,
+}
+
+// SetTimezone sets the location used to display timestamps and to interpret
+// dates without a time zone. The server time zone is used if name is empty.
+func SetTimezone(name string) error {
+	loc := time.Local
+	if name != "" {
+		var err error
+		loc, err = time.LoadLocation(name)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %s: %s", name, err.Error())
+		}
+	}
+
+	timezone.mutex.Lock()
+	defer timezone.mutex.Unlock()
+	timezone.location = loc
+
+	return nil
+}
+
+// GetTimezone returns the location set by SetTimezone.
+func GetTimezone() *time.Location {
+	timezone.mutex.RLock()
+	defer timezone.mutex.RUnlock()
+	return timezone.location
+}
+
 func GetYMDFromDatabaseDate(dateString string) string {
 	result, _ := ParseDateStringAsFormat(dateString, "2006-01-02")
 	return result
@@ -45,3 +79,89 @@ func ParseDateStringAsTime(dateString string) (time.Time, error) {
 
 	return time.Time{}, fmt.Errorf("ParseDateStringAsTime failed: dateString <%s>", dateString)
 }
+
+// ParseDateStringInTimezone parses the provided date string. Date strings
+// without a time zone are interpreted in the configured timezone.
+func ParseDateStringInTimezone(dateString string) (time.Time, error) {
+	t, e := time.Parse(time.RFC3339, dateString)
+	if e == nil {
+		return t, nil
+	}
+
+	loc := GetTimezone()
+	t, e = time.ParseInLocation("2006-01-02", dateString, loc)
+	if e == nil {
+		return t, nil
+	}
+
+	t, e = time.ParseInLocation("2006-01-02 15:04:05", dateString, loc)
+	if e == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("ParseDateStringInTimezone failed: dateString <%s>", dateString)
+}
+
+// ParseDateStringAsDateInTimezone returns the date of the provided date string
+// in YYYY-MM-DD format. Date strings with a time zone are converted to the
+// configured timezone first, so that a timestamp gives the date it falls on
+// for the user rather than in UTC.
+func ParseDateStringAsDateInTimezone(dateString string) (string, error) {
+	t, e := ParseDateStringInTimezone(dateString)
+	if e != nil {
+		return ParseDateStringAsFormat(dateString, "2006-01-02")
+	}
+
+	return t.In(GetTimezone()).Format("2006-01-02"), nil
+}
+
+// AnniversaryMonthDays returns the month and day of t in MM-DD format, with
+// 02-29 as well if t is the 28th of February of a year which is not a leap
+// year, when the anniversaries of the 29th are kept.
+func AnniversaryMonthDays(t time.Time) []string {
+	ret := []string{t.Format("01-02")}
+	if t.Month() == time.February && t.Day() == 28 && !isLeapYear(t.Year()) {
+		ret = append(ret, "02-29")
+	}
+
+	return ret
+}
+
+// NextAnniversary returns the first anniversary of date on or after the day
+// of from, in the location of from. Anniversaries of the 29th of February
+// are kept on the 28th in years which are not leap years.
+func NextAnniversary(date time.Time, from time.Time) time.Time {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	ret := anniversaryInYear(date, from.Year(), from.Location())
+	if ret.Before(from) {
+		ret = anniversaryInYear(date, from.Year()+1, from.Location())
+	}
+
+	return ret
+}
+
+// AgeAt returns the age in whole years at date of someone born at birthdate.
+// Those born on the 29th of February age on the 1st of March in years which
+// are not leap years.
+func AgeAt(birthdate time.Time, date time.Time) int {
+	age := date.Year() - birthdate.Year()
+	if date.Month() < birthdate.Month() || (date.Month() == birthdate.Month() && date.Day() < birthdate.Day()) {
+		age--
+	}
+
+	return age
+}
+
+func anniversaryInYear(date time.Time, year int, loc *time.Location) time.Time {
+	day := date.Day()
+	if date.Month() == time.February && day == 29 && !isLeapYear(year) {
+		day = 28
+	}
+
+	return time.Date(year, date.Month(), day, 0, 0, 0, 0, loc)
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
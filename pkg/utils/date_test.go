@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnniversaryMonthDays(t *testing.T) {
+	tests := []struct {
+		name string
+		date string
+		want []string
+	}{
+		{"ordinary", "2021-05-17", []string{"05-17"}},
+		{"leap day", "2020-02-29", []string{"02-29"}},
+		{"28th of leap year", "2020-02-28", []string{"02-28"}},
+		{"28th of other year", "2021-02-28", []string{"02-28", "02-29"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := AnniversaryMonthDays(date); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnniversaryMonthDays() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextAnniversary(t *testing.T) {
+	tests := []struct {
+		name string
+		date string
+		from string
+		want string
+	}{
+		{"later this year", "1990-06-01", "2021-05-17", "2021-06-01"},
+		{"today", "1990-05-17", "2021-05-17", "2021-05-17"},
+		{"next year", "1990-01-01", "2021-05-17", "2022-01-01"},
+		{"leap day in leap year", "1992-02-29", "2024-01-01", "2024-02-29"},
+		{"leap day in other year", "1992-02-29", "2021-01-01", "2021-02-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			from, _ := time.Parse("2006-01-02", tt.from)
+			if got := NextAnniversary(date, from.Add(15*time.Hour)).Format("2006-01-02"); got != tt.want {
+				t.Errorf("NextAnniversary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgeAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		birthdate string
+		date      string
+		want      int
+	}{
+		{"before birthday", "1990-05-17", "2020-05-16", 29},
+		{"on birthday", "1990-05-17", "2020-05-17", 30},
+		{"after birthday", "1990-05-17", "2020-12-01", 30},
+		{"leap day in leap year", "2000-02-29", "2020-02-29", 20},
+		{"leap day in other year", "2000-02-29", "2021-02-28", 20},
+		{"day after leap day in other year", "2000-02-29", "2021-03-01", 21},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			birthdate, _ := time.Parse("2006-01-02", tt.birthdate)
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := AgeAt(birthdate, date); got != tt.want {
+				t.Errorf("AgeAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateStringAsDateInTimezone(t *testing.T) {
+	defer SetTimezone("")
+	if err := SetTimezone("Asia/Tokyo"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		dateString string
+		want       string
+	}{
+		{"date", "2020-01-02", "2020-01-02"},
+		{"date and time", "2020-01-02 23:30:00", "2020-01-02"},
+		{"utc timestamp", "2020-01-01T20:00:00Z", "2020-01-02"},
+		{"timestamp in timezone", "2020-01-02T00:30:00+09:00", "2020-01-02"},
+		{"timestamp west of utc", "2020-01-01T20:00:00-05:00", "2020-01-02"},
+		{"rails timestamp", "2020-01-02 10:00:00 UTC", "2020-01-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDateStringAsDateInTimezone(tt.dateString)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDateStringAsDateInTimezone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
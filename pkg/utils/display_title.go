@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var displayTitleCleanup struct {
+	mutex sync.RWMutex
+	res   []*regexp.Regexp
+}
+
+var whitespaceRE = regexp.MustCompile(`\s+`)
+
+// SetDisplayTitleCleanup sets the regular expressions whose matches are
+// replaced with spaces in file names to make display titles.
+func SetDisplayTitleCleanup(patterns []string) error {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid display title cleanup pattern %s: %s", p, err.Error())
+		}
+		res = append(res, re)
+	}
+
+	displayTitleCleanup.mutex.Lock()
+	defer displayTitleCleanup.mutex.Unlock()
+	displayTitleCleanup.res = res
+
+	return nil
+}
+
+// DisplayTitle returns title if it is not empty. Otherwise it returns the
+// base name of path without the extension, with the matches of the cleanup
+// expressions replaced with spaces and repeated whitespace collapsed. The
+// base name is returned as is if nothing remains after the cleanup.
+func DisplayTitle(title string, path string) string {
+	if title != "" {
+		return title
+	}
+
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	displayTitleCleanup.mutex.RLock()
+	ret := name
+	for _, re := range displayTitleCleanup.res {
+		ret = re.ReplaceAllString(ret, " ")
+	}
+	displayTitleCleanup.mutex.RUnlock()
+
+	ret = strings.TrimSpace(whitespaceRE.ReplaceAllString(ret, " "))
+	if ret == "" {
+		return name
+	}
+
+	return ret
+}
@@ -166,6 +166,66 @@ func SafeMove(src, dst string) error {
 	return nil
 }
 
+// MoveNoReplace moves the file at src to dst, failing if dst exists. Unlike
+// os.Rename, it never overwrites dst: the file is hard linked at dst and then
+// removed from src, or copied to a newly created dst if it cannot be linked,
+// such as across filesystems. Renames changing only the case of the name on
+// case-insensitive filesystems are made with os.Rename, as dst is src.
+func MoveNoReplace(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(srcInfo, dstInfo) {
+		return os.Rename(src, dst)
+	}
+
+	if err := os.Link(src, dst); err != nil {
+		if os.IsExist(err) {
+			return err
+		}
+
+		logger.Debugf("[Util] unable to link: \"%s\" due to %s. Falling back to copying.", src, err.Error())
+		if err := copyNoReplace(src, dst, srcInfo.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(src); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+
+	return nil
+}
+
+// copyNoReplace copies the file at src to dst with the permissions perm,
+// failing if dst exists. dst is removed if the file cannot be copied.
+func copyNoReplace(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+
+	return nil
+}
+
 // IsZipFileUnmcompressed returns true if zip file in path is using 0 compression level
 func IsZipFileUncompressed(path string) (bool, error) {
 	r, err := zip.OpenReader(path)
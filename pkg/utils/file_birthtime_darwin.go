@@ -0,0 +1,20 @@
+// +build darwin
+
+package utils
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// GetFileBirthTime returns the creation time of the file, if supported by
+// the platform and filesystem.
+func GetFileBirthTime(fi os.FileInfo) (time.Time, bool) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec), true
+}
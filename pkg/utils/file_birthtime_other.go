@@ -0,0 +1,15 @@
+// +build !darwin,!windows
+
+package utils
+
+import (
+	"os"
+	"time"
+)
+
+// GetFileBirthTime returns the creation time of the file, if supported by
+// the platform and filesystem. The birth time is not available from the
+// standard library on this platform, so false is always returned.
+func GetFileBirthTime(fi os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,20 @@
+// +build windows
+
+package utils
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// GetFileBirthTime returns the creation time of the file, if supported by
+// the platform and filesystem.
+func GetFileBirthTime(fi os.FileInfo) (time.Time, bool) {
+	data, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, data.CreationTime.Nanoseconds()), true
+}
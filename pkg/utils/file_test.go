@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveNoReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stash-move-")
+	if err != nil {
+		t.Fatalf("Error creating temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	existing := filepath.Join(dir, "existing")
+	for f, contents := range map[string]string{src: "src", existing: "existing"} {
+		if err := ioutil.WriteFile(f, []byte(contents), 0644); err != nil {
+			t.Fatalf("Error writing file: %s", err.Error())
+		}
+	}
+
+	// existing files are not overwritten
+	if err := MoveNoReplace(src, existing); err == nil {
+		t.Errorf("MoveNoReplace() to existing file returned no error")
+	}
+	if data, _ := ioutil.ReadFile(existing); string(data) != "existing" {
+		t.Errorf("existing file = %q, want %q", data, "existing")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source file removed: %s", err.Error())
+	}
+
+	if err := MoveNoReplace(src, dst); err != nil {
+		t.Fatalf("MoveNoReplace() error = %s", err.Error())
+	}
+	if data, _ := ioutil.ReadFile(dst); string(data) != "src" {
+		t.Errorf("moved file = %q, want %q", data, "src")
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source file not removed")
+	}
+}
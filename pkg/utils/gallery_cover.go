@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// thumbnailNameRE matches the names, without extensions, of image files
+// which are likely to be thumbnails of other images.
+var thumbnailNameRE = regexp.MustCompile(`(?i)thumb|^tn[_\-. ]|[_\-. ](tn|t)$`)
+
+// GalleryCoverRank returns how suitable the image file at path is as the
+// automatic cover of its gallery, lowest first: 0 for files named cover.jpg,
+// 2 for thumbnails and 1 for other images. Files in zip files have the path
+// of the zip file and the path in the zip file separated by a null
+// character.
+func GalleryCoverRank(path string) int {
+	if i := strings.LastIndex(path, "\x00"); i != -1 {
+		path = path[i+1:]
+	}
+	name := filepath.Base(path)
+
+	if name == "cover.jpg" {
+		return 0
+	}
+
+	if thumbnailNameRE.MatchString(strings.TrimSuffix(name, filepath.Ext(name))) {
+		return 2
+	}
+
+	return 1
+}
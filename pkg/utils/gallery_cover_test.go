@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGalleryCoverRank(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/gallery/cover.jpg", 0},
+		{"/gallery.zip\x00cover.jpg", 0},
+		{"/gallery.zip\x00sub/cover.jpg", 0},
+		{"/gallery/001.jpg", 1},
+		{"/thumbs/001.jpg", 1},
+		{"/gallery/001_thumb.jpg", 2},
+		{"/gallery/Thumbnail.png", 2},
+		{"/gallery/tn_001.jpg", 2},
+		{"/gallery/001-t.jpg", 2},
+		{"/gallery/portrait.jpg", 1},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, GalleryCoverRank(tt.path), tt.path)
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const cmPerInch = 2.54
+
+var (
+	heightCmRegex     = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(cm|m)?$`)
+	heightFeetRegex   = regexp.MustCompile(`^(\d+)\s*(?:'|’|ft|feet)\s*(?:(\d+(?:\.\d+)?)\s*(?:"|”|''|in|inches)?)?$`)
+	measurementsRegex = regexp.MustCompile(`^(\d+)\s*[a-z]*\s*-\s*(\d+)\s*-\s*(\d+)$`)
+)
+
+// ParseHeight returns the height in centimetres of a height such as 170,
+// 170 cm, 1.70 m or 5'7". Plain numbers are centimetres, or metres if they
+// are less than 3. It returns false if the height cannot be parsed.
+func ParseHeight(height string) (int, bool) {
+	height = strings.ToLower(strings.TrimSpace(height))
+
+	if m := heightFeetRegex.FindStringSubmatch(height); m != nil {
+		feet, _ := strconv.Atoi(m[1])
+		inches := 0.0
+		if m[2] != "" {
+			inches, _ = strconv.ParseFloat(m[2], 64)
+		}
+		return int(math.Round((float64(feet)*12 + inches) * cmPerInch)), true
+	}
+
+	m := heightCmRegex.FindStringSubmatch(height)
+	if m == nil {
+		return 0, false
+	}
+
+	value, _ := strconv.ParseFloat(m[1], 64)
+	if m[2] == "m" || (m[2] == "" && value < 3) {
+		value *= 100
+	}
+	if value <= 0 {
+		return 0, false
+	}
+
+	return int(math.Round(value)), true
+}
+
+// ParseMeasurements returns the bust, waist and hips in centimetres of
+// measurements such as 34D-24-34 or 86-61-86. The measurements are taken to
+// be in inches if the bust is less than 60. It returns false if the
+// measurements cannot be parsed.
+func ParseMeasurements(measurements string) (bust int, waist int, hips int, ok bool) {
+	m := measurementsRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(measurements)))
+	if m == nil {
+		return 0, 0, 0, false
+	}
+
+	values := make([]int, 3)
+	for i := range values {
+		values[i], _ = strconv.Atoi(m[i+1])
+	}
+
+	if values[0] < 60 {
+		for i := range values {
+			values[i] = int(math.Round(float64(values[i]) * cmPerInch))
+		}
+	}
+
+	return values[0], values[1], values[2], true
+}
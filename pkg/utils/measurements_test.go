@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestParseHeight(t *testing.T) {
+	tests := []struct {
+		height string
+		want   int
+		wantOK bool
+	}{
+		{"170", 170, true},
+		{" 170 cm ", 170, true},
+		{"170.4cm", 170, true},
+		{"1.70 m", 170, true},
+		{"1.7", 170, true},
+		{`5'7"`, 170, true},
+		{"5' 7''", 170, true},
+		{"5 ft 7 in", 170, true},
+		{"6'", 183, true},
+		{"", 0, false},
+		{"tall", 0, false},
+		{"0", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.height, func(t *testing.T) {
+			got, ok := ParseHeight(tt.height)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseHeight() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseMeasurements(t *testing.T) {
+	tests := []struct {
+		measurements string
+		want         [3]int
+		wantOK       bool
+	}{
+		{"34D-24-34", [3]int{86, 61, 86}, true},
+		{"34 DD - 24 - 35", [3]int{86, 61, 89}, true},
+		{"90-60-90", [3]int{90, 60, 90}, true},
+		{"", [3]int{}, false},
+		{"34D", [3]int{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.measurements, func(t *testing.T) {
+			bust, waist, hips, ok := ParseMeasurements(tt.measurements)
+			if got := [3]int{bust, waist, hips}; got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseMeasurements() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
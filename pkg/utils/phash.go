@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"image"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// phashImageSize is the width and height of the grayscale image of which
+// the DCT is calculated.
+const phashImageSize = 32
+
+// phashSize is the width and height of the low frequency DCT coefficients
+// making up the hash.
+const phashSize = 8
+
+// PhashToString returns the hexadecimal representation of a perceptual
+// hash.
+func PhashToString(phash int64) string {
+	return strconv.FormatUint(uint64(phash), 16)
+}
+
+// PhashFromString parses a perceptual hash from its hexadecimal
+// representation.
+func PhashFromString(s string) (int64, error) {
+	ret, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(ret), nil
+}
+
+// ImagePhash returns the perceptual hash of img. The image is reduced to a
+// 32x32 grayscale image, and each bit of the hash is set if the matching low
+// frequency DCT coefficient of the reduced image is above the median.
+func ImagePhash(img image.Image) int64 {
+	resized := imaging.Grayscale(imaging.Resize(img, phashImageSize, phashImageSize, imaging.Lanczos))
+
+	pixels := make([][]float64, phashImageSize)
+	for y := 0; y < phashImageSize; y++ {
+		pixels[y] = make([]float64, phashImageSize)
+		for x := 0; x < phashImageSize; x++ {
+			pixels[y][x] = float64(resized.Pix[y*resized.Stride+x*4])
+		}
+	}
+
+	coefficients := make([]float64, 0, phashSize*phashSize)
+	for v := 0; v < phashSize; v++ {
+		for u := 0; u < phashSize; u++ {
+			coefficients = append(coefficients, dctCoefficient(pixels, u, v))
+		}
+	}
+
+	sorted := append([]float64{}, coefficients...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var ret uint64
+	for i, c := range coefficients {
+		if c > median {
+			ret |= 1 << uint(len(coefficients)-1-i)
+		}
+	}
+
+	return int64(ret)
+}
+
+// dctCoefficient returns the unscaled (u, v) coefficient of the 2D DCT-II of
+// pixels.
+func dctCoefficient(pixels [][]float64, u int, v int) float64 {
+	n := float64(len(pixels))
+	var ret float64
+	for y, row := range pixels {
+		cy := math.Cos((2*float64(y) + 1) * float64(v) * math.Pi / (2 * n))
+		for x, p := range row {
+			ret += p * math.Cos((2*float64(x)+1)*float64(u)*math.Pi/(2*n)) * cy
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"sync"
+)
+
+// qualityScoreMaxSide is the shorter side in pixels of videos with the best
+// resolution score.
+const qualityScoreMaxSide = 2160
+
+// qualityScoreMaxBitsPerPixel is the bitrate per pixel per frame of videos
+// with the best bitrate score.
+const qualityScoreMaxBitsPerPixel = 0.1
+
+// qualityScoreDefaultFramerate is the framerate assumed for videos without
+// one.
+const qualityScoreDefaultFramerate = 30
+
+// QualityScoreUnknownCodec is the codec score of codecs without a score.
+const QualityScoreUnknownCodec = 0.5
+
+// DefaultQualityCodecScores are the codec scores used if none are
+// configured, from 0 to 1.
+var DefaultQualityCodecScores = map[string]float64{
+	"av1":        1,
+	"hevc":       1,
+	"vp9":        0.9,
+	"h264":       0.8,
+	"vp8":        0.6,
+	"mpeg4":      0.4,
+	"mpeg2video": 0.3,
+	"wmv3":       0.3,
+	"msmpeg4v3":  0.2,
+}
+
+// QualityScoreWeights are the weights of the components of the quality score
+// of a video, and the scores of its codecs.
+type QualityScoreWeights struct {
+	Resolution  float64
+	Bitrate     float64
+	Codec       float64
+	CodecScores map[string]float64
+}
+
+var qualityScoreWeights = struct {
+	mutex   sync.RWMutex
+	weights QualityScoreWeights
+}{
+	weights: QualityScoreWeights{
+		Resolution:  1,
+		Bitrate:     1,
+		Codec:       1,
+		CodecScores: DefaultQualityCodecScores,
+	},
+}
+
+// SetQualityScoreWeights sets the weights used by QualityScore.
+func SetQualityScoreWeights(weights QualityScoreWeights) {
+	codecScores := make(map[string]float64)
+	for codec, score := range weights.CodecScores {
+		codecScores[strings.ToLower(codec)] = score
+	}
+	weights.CodecScores = codecScores
+
+	qualityScoreWeights.mutex.Lock()
+	defer qualityScoreWeights.mutex.Unlock()
+	qualityScoreWeights.weights = weights
+}
+
+// QualityScore returns the quality score of a video from 0 to 100. It is the
+// weighted mean of the scores of the resolution, of the bitrate per pixel and
+// of the codec, each from 0 to 1.
+func QualityScore(width int64, height int64, bitrate int64, framerate float64, codec string) float64 {
+	qualityScoreWeights.mutex.RLock()
+	weights := qualityScoreWeights.weights
+	qualityScoreWeights.mutex.RUnlock()
+
+	total := weights.Resolution + weights.Bitrate + weights.Codec
+	if total <= 0 {
+		return 0
+	}
+
+	// the shorter side is that known if the other is not
+	side := math.Min(float64(width), float64(height))
+	if width <= 0 || height <= 0 {
+		side = math.Max(float64(width), float64(height))
+	}
+	resolution := side / qualityScoreMaxSide
+
+	if framerate <= 0 {
+		framerate = qualityScoreDefaultFramerate
+	}
+	var bitsPerPixel float64
+	if width > 0 && height > 0 {
+		bitsPerPixel = float64(bitrate) / (float64(width*height) * framerate)
+	}
+	bitrateScore := bitsPerPixel / qualityScoreMaxBitsPerPixel
+
+	codecScore, found := weights.CodecScores[strings.ToLower(codec)]
+	if !found {
+		codecScore = QualityScoreUnknownCodec
+	}
+
+	score := weights.Resolution*clampScore(resolution) + weights.Bitrate*clampScore(bitrateScore) + weights.Codec*clampScore(codecScore)
+	return math.Round(score/total*1000) / 10
+}
+
+func clampScore(score float64) float64 {
+	return math.Max(0, math.Min(score, 1))
+}
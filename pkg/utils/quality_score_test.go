@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestQualityScore(t *testing.T) {
+	defer SetQualityScoreWeights(QualityScoreWeights{
+		Resolution:  1,
+		Bitrate:     1,
+		Codec:       1,
+		CodecScores: DefaultQualityCodecScores,
+	})
+
+	tests := []struct {
+		name      string
+		weights   QualityScoreWeights
+		width     int64
+		height    int64
+		bitrate   int64
+		framerate float64
+		codec     string
+		want      float64
+	}{
+		{"best", QualityScoreWeights{1, 1, 1, DefaultQualityCodecScores}, 3840, 2160, 100000000, 30, "hevc", 100},
+		{"unknown", QualityScoreWeights{1, 1, 1, DefaultQualityCodecScores}, 0, 0, 0, 0, "", 16.7},
+		{"1080p h264", QualityScoreWeights{1, 1, 1, DefaultQualityCodecScores}, 1920, 1080, 3110400, 30, "h264", 60},
+		{"height only", QualityScoreWeights{1, 0, 0, nil}, 0, 1080, 0, 0, "", 50},
+		{"codec case", QualityScoreWeights{0, 0, 1, map[string]float64{"HEVC": 0.7}}, 0, 0, 0, 0, "hevc", 70},
+		{"default framerate", QualityScoreWeights{0, 1, 0, nil}, 100, 100, 15000, 0, "", 50},
+		{"no weights", QualityScoreWeights{0, 0, 0, nil}, 3840, 2160, 100000000, 30, "hevc", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetQualityScoreWeights(tt.weights)
+			if got := QualityScore(tt.width, tt.height, tt.bitrate, tt.framerate, tt.codec); got != tt.want {
+				t.Errorf("QualityScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// searchLetters are the letters without decompositions and the letters they
+// are folded to.
+var searchLetters = strings.NewReplacer(
+	"ß", "ss",
+	"æ", "ae",
+	"œ", "oe",
+	"ø", "o",
+	"đ", "d",
+	"ð", "d",
+	"ł", "l",
+	"þ", "th",
+	"ı", "i",
+)
+
+// FoldSearch returns s in lower case with compatibility characters replaced
+// and diacritics removed, so that searches match regardless of case, accents
+// and ligatures. Tánja and TANJA both fold to tanja.
+func FoldSearch(s string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	ret, _, err := transform.String(t, s)
+	if err != nil {
+		ret = s
+	}
+
+	return searchLetters.Replace(strings.ToLower(ret))
+}
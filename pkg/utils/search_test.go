@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestFoldSearch(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"Tanja", "tanja"},
+		{"Tánja", "tanja"},
+		{"TÁNJA", "tanja"},
+		{"Zoë Ångström", "zoe angstrom"},
+		{"Straße", "strasse"},
+		{"Søren Łukasz", "soren lukasz"},
+		{"ﬁnal", "final"},
+		{"名前", "名前"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := FoldSearch(tt.s); got != tt.want {
+				t.Errorf("FoldSearch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// slugMaxLength is the maximum number of characters of a slug.
+const slugMaxLength = 100
+
+// Slugify returns the lower case letters and digits of s, with each run of
+// other characters replaced by a single hyphen. Leading and trailing hyphens
+// are removed, and the slug is truncated to 100 characters. The slug is empty
+// if s has no letters or digits.
+func Slugify(s string) string {
+	var b strings.Builder
+	n := 0
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if n == slugMaxLength {
+			break
+		}
+
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			hyphen = n > 0
+			continue
+		}
+
+		if hyphen {
+			b.WriteRune('-')
+			n++
+			hyphen = false
+			if n == slugMaxLength {
+				break
+			}
+		}
+		b.WriteRune(r)
+		n++
+	}
+
+	return strings.TrimRight(b.String(), "-")
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"simple", "Foo Bar", "foo-bar"},
+		{"punctuation", "  Foo's -- Bar!  ", "foo-s-bar"},
+		{"digits", "Studio 21", "studio-21"},
+		{"unicode", "Ünïcödé 名前", "ünïcödé-名前"},
+		{"no letters", "!!!", ""},
+		{"truncated", strings.Repeat("a", 99) + " b", strings.Repeat("a", 99)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.input); got != tt.want {
+				t.Errorf("Slugify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -46,3 +46,18 @@ func StringSliceToIntSlice(ss []string) []int {
 
 	return ret
 }
+
+// ParseIntSlice converts a slice of strings to a slice of ints. Unlike
+// StringSliceToIntSlice, it returns an error if any value cannot be parsed.
+func ParseIntSlice(ss []string) ([]int, error) {
+	ret := make([]int, len(ss))
+	for i, v := range ss {
+		var err error
+		ret[i], err = strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIntSlice(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		want    []int
+		wantErr bool
+	}{
+		{"empty", []string{}, []int{}, false},
+		{"valid", []string{"1", "23", "-4"}, []int{1, 23, -4}, false},
+		{"invalid", []string{"1", "a"}, nil, true},
+		{"blank", []string{""}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIntSlice(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseIntSlice() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseIntSlice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
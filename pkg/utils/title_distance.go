@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Levenshtein returns the number of single character insertions, deletions
+// and substitutions needed to change a into b.
+func Levenshtein(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	// previous and current rows of the distances between the prefixes
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(values ...int) int {
+	ret := values[0]
+	for _, v := range values[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+// normalizeTitle returns the words of a title folded for searching and
+// separated by single spaces.
+func normalizeTitle(title string) string {
+	words := strings.FieldsFunc(FoldSearch(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// TitleDistance returns how much a title differs from the title made from
+// the file name of path, from 0 if they have the same words regardless of
+// case, accents and punctuation, to 1 if they have nothing in common. It is
+// the Levenshtein distance between them divided by the length of the longer.
+func TitleDistance(title string, path string) float64 {
+	a := normalizeTitle(title)
+	b := normalizeTitle(DisplayTitle("", path))
+
+	length := len([]rune(a))
+	if l := len([]rune(b)); l > length {
+		length = l
+	}
+	if length == 0 {
+		return 0
+	}
+
+	return float64(Levenshtein(a, b)) / float64(length)
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"café", "cafe", 1},
+		{"same", "same", 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Levenshtein(tt.a, tt.b), "%s to %s", tt.a, tt.b)
+	}
+}
+
+func TestTitleDistance(t *testing.T) {
+	tests := []struct {
+		title string
+		path  string
+		want  float64
+	}{
+		{"My Scene", "/videos/my.scene.mp4", 0},
+		{"Tánja's Day Out", "/videos/Tanja s day-out.mkv", 0},
+		{"abcd", "/videos/abce.mp4", 0.25},
+		{"abcd", "/videos/wxyz.mp4", 1},
+		{"", "/videos/.mp4", 0},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, TitleDistance(tt.title, tt.path), 0.001, "%s for %s", tt.title, tt.path)
+	}
+}
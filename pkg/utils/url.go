@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Sites of links that are not named after their domains.
+const (
+	SiteTwitter   = "twitter"
+	SiteInstagram = "instagram"
+	// SiteWebsite is the site of links without a domain
+	SiteWebsite = "website"
+)
+
+// URLs of the profiles of social sites, to which handles are appended.
+const (
+	TwitterURL   = "https://twitter.com/"
+	InstagramURL = "https://www.instagram.com/"
+)
+
+// siteAliases maps the names of domains to the sites they belong to.
+var siteAliases = map[string]string{
+	"x": SiteTwitter,
+}
+
+// secondLevelDomains are the names commonly registered under country code
+// top-level domains, such as co in co.uk.
+var secondLevelDomains = map[string]bool{
+	"ac":  true,
+	"co":  true,
+	"com": true,
+	"net": true,
+	"org": true,
+}
+
+// URLSite returns the site of a link: the lower case name of its domain
+// without the top-level domain and www, such as iafd for
+// https://www.iafd.com/person. Links to x.com are twitter links. Links
+// without a domain are website links.
+func URLSite(link string) string {
+	link = strings.TrimSpace(link)
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return SiteWebsite
+	}
+
+	labels := strings.Split(strings.ToLower(u.Hostname()), ".")
+	n := len(labels)
+	if n < 2 || labels[n-2] == "" {
+		return SiteWebsite
+	}
+
+	site := labels[n-2]
+	if n > 2 && len(labels[n-1]) == 2 && secondLevelDomains[site] {
+		site = labels[n-3]
+	}
+	if alias, found := siteAliases[site]; found {
+		site = alias
+	}
+
+	return site
+}
+
+// SocialURL returns the URL of the profile with the handle on a social site,
+// where base is the URL of the site ending with a slash. The handle may start
+// with @. It is returned unchanged if it is already a URL.
+func SocialURL(base string, handle string) string {
+	handle = strings.TrimSpace(handle)
+	if strings.HasPrefix(handle, "http://") || strings.HasPrefix(handle, "https://") {
+		return handle
+	}
+
+	return base + strings.TrimPrefix(handle, "@")
+}
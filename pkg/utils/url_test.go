@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestURLSite(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"domain", "https://www.iafd.com/person.rme/perfid=x", "iafd"},
+		{"no scheme", "onlyfans.com/name", "onlyfans"},
+		{"case", "HTTPS://Twitter.COM/name", "twitter"},
+		{"x", "https://x.com/name", "twitter"},
+		{"second level domain", "https://www.example.co.uk/name", "example"},
+		{"port", "http://localhost.localdomain:9999/", "localhost"},
+		{"no domain", "http://localhost:9999/", "website"},
+		{"empty", "", "website"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := URLSite(tt.link); got != tt.want {
+				t.Errorf("URLSite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSocialURL(t *testing.T) {
+	const base = "https://twitter.com/"
+
+	tests := []struct {
+		name   string
+		handle string
+		want   string
+	}{
+		{"handle", "name", "https://twitter.com/name"},
+		{"at", " @name ", "https://twitter.com/name"},
+		{"url", "https://x.com/name", "https://x.com/name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SocialURL(base, tt.handle); got != tt.want {
+				t.Errorf("SocialURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// GenerateUUID returns a random (version 4) UUID in its lowercase string
+// form.
+func GenerateUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	// version 4, variant 10
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IsUUID returns true if s is a UUID in lowercase string form.
+func IsUUID(s string) bool {
+	return uuidRegex.MatchString(s)
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestGenerateUUID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		uuid := GenerateUUID()
+		if !IsUUID(uuid) {
+			t.Fatalf("GenerateUUID() = %s, not a UUID", uuid)
+		}
+		if uuid[14] != '4' {
+			t.Errorf("GenerateUUID() = %s, want version 4", uuid)
+		}
+		if c := uuid[19]; c != '8' && c != '9' && c != 'a' && c != 'b' {
+			t.Errorf("GenerateUUID() = %s, want variant 10", uuid)
+		}
+		if seen[uuid] {
+			t.Errorf("GenerateUUID() = %s, repeated", uuid)
+		}
+		seen[uuid] = true
+	}
+}
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"0b1e5c1a-3f0e-4d6c-9a8b-7c6d5e4f3a2b", true},
+		{"0B1E5C1A-3F0E-4D6C-9A8B-7C6D5E4F3A2B", false},
+		{"0b1e5c1a3f0e4d6c9a8b7c6d5e4f3a2b", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUUID(tt.s); got != tt.want {
+			t.Errorf("IsUUID(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
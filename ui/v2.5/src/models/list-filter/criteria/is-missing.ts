@@ -21,6 +21,7 @@ export class SceneIsMissingCriterion extends IsMissingCriterion {
     "performers",
     "tags",
     "stash_id",
+    "captions",
   ];
 }
 